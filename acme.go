@@ -0,0 +1,655 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Let's Encrypt production directory.
+	acmeDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+	// ACME account key and certificates are saved in this folder
+	// inside the config path.
+	acmeDir            = "acme"
+	acmeAccountKeyFile = "account.key"
+
+	// Certificates are renewed when they expire within this duration.
+	acmeRenewBefore = 30 * 24 * time.Hour
+
+	// Interval at which certificates are checked for renewal, also
+	// the retry interval after a failed attempt.
+	acmeCheckInterval = time.Hour
+
+	// ALPN protocol used by tls-alpn-01 challenges (RFC 8737).
+	acmeALPNProto = "acme-tls/1"
+)
+
+// id-pe-acmeIdentifier extension carried by tls-alpn-01 challenge
+// certificates.
+var acmeIdentifierOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// errACMENoChallenge - ACME server offered no supported challenge.
+var errACMENoChallenge = errors.New("ACME server offered no tls-alpn-01 challenge")
+
+// acmeError - problem document returned by the ACME server.
+type acmeError struct {
+	Status int    `json:"status"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (e acmeError) Error() string {
+	return fmt.Sprintf("ACME error %d: %s: %s", e.Status, e.Type, e.Detail)
+}
+
+// acmeDirectory - ACME server endpoints.
+type acmeDirectory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+// acmeIdentifier - identifier a certificate is ordered for.
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// acmeOrder - ACME certificate order.
+type acmeOrder struct {
+	Status         string     `json:"status"`
+	Authorizations []string   `json:"authorizations"`
+	Finalize       string     `json:"finalize"`
+	Certificate    string     `json:"certificate"`
+	Error          *acmeError `json:"error"`
+}
+
+// acmeChallenge - ACME authorization challenge.
+type acmeChallenge struct {
+	Type   string     `json:"type"`
+	URL    string     `json:"url"`
+	Token  string     `json:"token"`
+	Status string     `json:"status"`
+	Error  *acmeError `json:"error"`
+}
+
+// acmeAuthorization - ACME authorization of an identifier.
+type acmeAuthorization struct {
+	Status     string          `json:"status"`
+	Identifier acmeIdentifier  `json:"identifier"`
+	Challenges []acmeChallenge `json:"challenges"`
+}
+
+// acmeClient - minimal ACME (RFC 8555) client, supports account
+// registration and ordering certificates. Not safe for concurrent use.
+type acmeClient struct {
+	directoryURL string
+	client       *http.Client
+	key          *ecdsa.PrivateKey
+
+	// Polling interval for pending authorizations and orders.
+	pollInterval time.Duration
+
+	directory  *acmeDirectory
+	nonce      string
+	accountURL string
+}
+
+// newACMEClient - returns an ACME client using key as account key.
+func newACMEClient(directoryURL string, key *ecdsa.PrivateKey) *acmeClient {
+	return &acmeClient{
+		directoryURL: directoryURL,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		key:          key,
+		pollInterval: 2 * time.Second,
+	}
+}
+
+// base64url - unpadded base64url encoding used by JWS.
+func base64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// jwk - returns the JSON web key of the account key, members are in
+// lexicographic order as required for the thumbprint.
+func (c *acmeClient) jwk() string {
+	size := (c.key.Curve.Params().BitSize + 7) / 8
+	x := make([]byte, size)
+	y := make([]byte, size)
+	c.key.X.FillBytes(x)
+	c.key.Y.FillBytes(y)
+	return fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`, base64url(x), base64url(y))
+}
+
+// keyAuthorization - returns the key authorization for a challenge
+// token (RFC 8555 section 8.1).
+func (c *acmeClient) keyAuthorization(token string) string {
+	thumbprint := sha256.Sum256([]byte(c.jwk()))
+	return token + "." + base64url(thumbprint[:])
+}
+
+// sign - returns a flattened JWS of payload, signed with the account
+// key. Until the account is registered the key is embedded, the
+// account URL is referenced afterwards.
+func (c *acmeClient) sign(url, nonce string, payload []byte) ([]byte, error) {
+	protected := fmt.Sprintf(`{"alg":"ES256","nonce":%q,"url":%q`, nonce, url)
+	if c.accountURL == "" {
+		protected += `,"jwk":` + c.jwk() + `}`
+	} else {
+		protected += fmt.Sprintf(`,"kid":%q}`, c.accountURL)
+	}
+	signingInput := base64url([]byte(protected)) + "." + base64url(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	// ES256 signatures are the fixed size concatenation of r and s.
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return json.Marshal(map[string]string{
+		"protected": base64url([]byte(protected)),
+		"payload":   base64url(payload),
+		"signature": base64url(signature),
+	})
+}
+
+// discover - fetches the directory of the ACME server.
+func (c *acmeClient) discover() (*acmeDirectory, error) {
+	if c.directory != nil {
+		return c.directory, nil
+	}
+	resp, err := c.client.Get(c.directoryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unable to fetch ACME directory, server returned %s", resp.Status)
+	}
+	directory := &acmeDirectory{}
+	if err = json.NewDecoder(resp.Body).Decode(directory); err != nil {
+		return nil, err
+	}
+	c.directory = directory
+	return directory, nil
+}
+
+// getNonce - returns a fresh nonce, nonces returned with previous
+// responses are used first.
+func (c *acmeClient) getNonce() (string, error) {
+	if nonce := c.nonce; nonce != "" {
+		c.nonce = ""
+		return nonce, nil
+	}
+	directory, err := c.discover()
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Head(directory.NewNonce)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", errors.New("ACME server returned no nonce")
+	}
+	return nonce, nil
+}
+
+// post - sends a signed request, a nil payload sends a POST-as-GET
+// request. The response is decoded into result if not nil, the
+// Location header is returned.
+func (c *acmeClient) post(url string, payload interface{}, result interface{}) (string, error) {
+	var payloadBytes []byte
+	if payload != nil {
+		var err error
+		if payloadBytes, err = json.Marshal(payload); err != nil {
+			return "", err
+		}
+	}
+	// A rejected nonce is retried once with a fresh nonce.
+	for retry := true; ; retry = false {
+		nonce, err := c.getNonce()
+		if err != nil {
+			return "", err
+		}
+		body, err := c.sign(url, nonce, payloadBytes)
+		if err != nil {
+			return "", err
+		}
+		resp, err := c.client.Post(url, "application/jose+json", bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		c.nonce = resp.Header.Get("Replay-Nonce")
+		respBytes, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			acmeErr := acmeError{Status: resp.StatusCode}
+			json.Unmarshal(respBytes, &acmeErr)
+			if retry && acmeErr.Type == "urn:ietf:params:acme:error:badNonce" {
+				continue
+			}
+			return "", acmeErr
+		}
+		switch result := result.(type) {
+		case nil:
+		case *[]byte:
+			*result = respBytes
+		default:
+			if err = json.Unmarshal(respBytes, result); err != nil {
+				return "", err
+			}
+		}
+		return resp.Header.Get("Location"), nil
+	}
+}
+
+// register - registers the account key, existing accounts are reused.
+func (c *acmeClient) register(email string) error {
+	if c.accountURL != "" {
+		return nil
+	}
+	directory, err := c.discover()
+	if err != nil {
+		return err
+	}
+	account := map[string]interface{}{
+		"termsOfServiceAgreed": true,
+	}
+	if email != "" {
+		account["contact"] = []string{"mailto:" + email}
+	}
+	accountURL, err := c.post(directory.NewAccount, account, nil)
+	if err != nil {
+		return err
+	}
+	if accountURL == "" {
+		return errors.New("ACME server returned no account URL")
+	}
+	c.accountURL = accountURL
+	return nil
+}
+
+// poll - fetches url into result until status returns false.
+func (c *acmeClient) poll(url string, result interface{}, pending func() bool) error {
+	for i := 0; i < 30; i++ {
+		if _, err := c.post(url, nil, result); err != nil {
+			return err
+		}
+		if !pending() {
+			return nil
+		}
+		time.Sleep(c.pollInterval)
+	}
+	return fmt.Errorf("Timed out waiting for %s", url)
+}
+
+// authorize - completes the tls-alpn-01 challenge of an authorization,
+// setChallenge is called with the challenge certificate to serve.
+func (c *acmeClient) authorize(authzURL string, setChallenge func(domain string, cert *tls.Certificate)) error {
+	authz := acmeAuthorization{}
+	if _, err := c.post(authzURL, nil, &authz); err != nil {
+		return err
+	}
+	if authz.Status == "valid" {
+		return nil
+	}
+	var challenge *acmeChallenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == "tls-alpn-01" {
+			challenge = &authz.Challenges[i]
+			break
+		}
+	}
+	if challenge == nil {
+		return errACMENoChallenge
+	}
+	domain := authz.Identifier.Value
+	cert, err := newACMEChallengeCert(domain, c.keyAuthorization(challenge.Token))
+	if err != nil {
+		return err
+	}
+	setChallenge(domain, cert)
+	defer setChallenge(domain, nil)
+
+	// Empty object tells the server the challenge is ready.
+	if _, err = c.post(challenge.URL, struct{}{}, nil); err != nil {
+		return err
+	}
+	if err = c.poll(authzURL, &authz, func() bool { return authz.Status == "pending" }); err != nil {
+		return err
+	}
+	if authz.Status != "valid" {
+		for _, ch := range authz.Challenges {
+			if ch.Error != nil {
+				return *ch.Error
+			}
+		}
+		return fmt.Errorf("Authorization of %s failed with status %s", domain, authz.Status)
+	}
+	return nil
+}
+
+// obtainCertificate - orders a certificate for domains signed for key,
+// returns the PEM encoded certificate chain.
+func (c *acmeClient) obtainCertificate(domains []string, key crypto.Signer, setChallenge func(domain string, cert *tls.Certificate)) ([]byte, error) {
+	directory, err := c.discover()
+	if err != nil {
+		return nil, err
+	}
+	var identifiers []acmeIdentifier
+	for _, domain := range domains {
+		identifiers = append(identifiers, acmeIdentifier{Type: "dns", Value: domain})
+	}
+	order := acmeOrder{}
+	orderURL, err := c.post(directory.NewOrder, map[string]interface{}{"identifiers": identifiers}, &order)
+	if err != nil {
+		return nil, err
+	}
+	for _, authzURL := range order.Authorizations {
+		if err = c.authorize(authzURL, setChallenge); err != nil {
+			return nil, err
+		}
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = c.post(order.Finalize, map[string]string{"csr": base64url(csr)}, &order); err != nil {
+		return nil, err
+	}
+	isPending := func() bool { return order.Status == "pending" || order.Status == "processing" }
+	if isPending() {
+		if err = c.poll(orderURL, &order, isPending); err != nil {
+			return nil, err
+		}
+	}
+	if order.Status != "valid" {
+		if order.Error != nil {
+			return nil, *order.Error
+		}
+		return nil, fmt.Errorf("Certificate order failed with status %s", order.Status)
+	}
+	var chain []byte
+	if _, err = c.post(order.Certificate, nil, &chain); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// newACMEChallengeCert - returns a self-signed tls-alpn-01 challenge
+// certificate for domain.
+func newACMEChallengeCert(domain, keyAuthorization string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(keyAuthorization))
+	extValue, err := asn1.Marshal(digest[:])
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		ExtraExtensions: []pkix.Extension{
+			{Id: acmeIdentifierOID, Critical: true, Value: extValue},
+		},
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{certBytes}, PrivateKey: key}, nil
+}
+
+// loadOrCreateECKey - loads a PEM encoded EC private key, a new key is
+// generated and saved if the file does not exist.
+func loadOrCreateECKey(keyFile string) (*ecdsa.PrivateKey, error) {
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err == nil {
+		block, _ := pem.Decode(keyPEM)
+		if block == nil {
+			return nil, fmt.Errorf("Unable to decode key %s", keyFile)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+	if err = ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// acmeManager - obtains and renews a certificate for the server
+// domains from an ACME server such as Let's Encrypt. Domains are
+// validated through tls-alpn-01 challenges, the server must be
+// reachable on port 443 of every domain.
+//
+// The account key and the certificate are saved in the config path.
+//
+//	.minio/acme/account.key
+//	.minio/acme/public.crt, .minio/acme/private.key
+type acmeManager struct {
+	client  *acmeClient
+	domains []string
+	email   string
+	path    string
+
+	// Certificates for all other host names, may be nil.
+	fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	mutex          *sync.RWMutex
+	cert           *tls.Certificate
+	challengeCerts map[string]*tls.Certificate
+}
+
+// newACMEManager - initializes the ACME account key in path and loads
+// a previously obtained certificate.
+func newACMEManager(directoryURL, path string, domains []string, email string) (*acmeManager, error) {
+	if len(domains) == 0 {
+		return nil, errInvalidArgument
+	}
+	for i, domain := range domains {
+		domains[i] = strings.ToLower(strings.TrimSpace(domain))
+		if domains[i] == "" || strings.Contains(domains[i], "*") {
+			return nil, fmt.Errorf("Invalid ACME domain ‘%s’", domain)
+		}
+	}
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	key, err := loadOrCreateECKey(filepath.Join(path, acmeAccountKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &acmeManager{
+		client:         newACMEClient(directoryURL, key),
+		domains:        domains,
+		email:          email,
+		path:           path,
+		mutex:          &sync.RWMutex{},
+		challengeCerts: make(map[string]*tls.Certificate),
+	}
+	cert, err := loadCertificate(filepath.Join(path, globalMinioCertFile), filepath.Join(path, globalMinioKeyFile))
+	if err == nil {
+		m.cert = cert
+	} else if !os.IsNotExist(err) {
+		errorIf(err, "Unable to load ACME certificate, obtaining a new certificate.")
+	}
+	return m, nil
+}
+
+// needsRenewal - returns true if there is no valid certificate for all
+// domains or the certificate expires soon.
+func (m *acmeManager) needsRenewal() bool {
+	m.mutex.RLock()
+	cert := m.cert
+	m.mutex.RUnlock()
+	if cert == nil {
+		return true
+	}
+	if time.Now().Add(acmeRenewBefore).After(cert.Leaf.NotAfter) {
+		return true
+	}
+	for _, domain := range m.domains {
+		if cert.Leaf.VerifyHostname(domain) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setChallengeCert - sets or removes the challenge certificate served
+// for domain.
+func (m *acmeManager) setChallengeCert(domain string, cert *tls.Certificate) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if cert == nil {
+		delete(m.challengeCerts, domain)
+		return
+	}
+	m.challengeCerts[domain] = cert
+}
+
+// renew - obtains a new certificate and saves it in the config path.
+func (m *acmeManager) renew() error {
+	if err := m.client.register(m.email); err != nil {
+		return err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	chain, err := m.client.obtainCertificate(m.domains, key, m.setChallengeCert)
+	if err != nil {
+		return err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+	cert, err := tls.X509KeyPair(chain, keyPEM)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(m.path, globalMinioKeyFile), keyPEM, 0600); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(m.path, globalMinioCertFile), chain, 0600); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	m.cert = &cert
+	m.mutex.Unlock()
+	return nil
+}
+
+// run - renews the certificate when required, checks every interval
+// until doneCh is closed.
+func (m *acmeManager) run(interval time.Duration, doneCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if m.needsRenewal() {
+			errorIf(m.renew(), "Unable to obtain certificate for %s.", strings.Join(m.domains, ", "))
+		}
+		select {
+		case <-ticker.C:
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// GetCertificate - returns challenge certificates to the ACME server,
+// the obtained certificate for the ACME domains and falls back to the
+// static certificates otherwise, implements tls.Config.GetCertificate.
+func (m *acmeManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == acmeALPNProto {
+		if cert, ok := m.challengeCerts[host]; ok {
+			return cert, nil
+		}
+		return nil, fmt.Errorf("No ACME challenge pending for %s", host)
+	}
+	if m.cert != nil && contains(m.domains, host) {
+		return m.cert, nil
+	}
+	if m.fallback != nil {
+		return m.fallback(hello)
+	}
+	if m.cert == nil {
+		return nil, errNoCertificate
+	}
+	return m.cert, nil
+}
@@ -0,0 +1,414 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// verifyACMEChallengeCert - verifies cert is a valid tls-alpn-01
+// challenge certificate for domain and keyAuthorization.
+func verifyACMEChallengeCert(cert *tls.Certificate, domain, keyAuthorization string) error {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	if err = leaf.VerifyHostname(domain); err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(keyAuthorization))
+	expected, err := asn1.Marshal(digest[:])
+	if err != nil {
+		return err
+	}
+	for _, ext := range leaf.Extensions {
+		if ext.Id.Equal(acmeIdentifierOID) {
+			if !ext.Critical {
+				return fmt.Errorf("acmeIdentifier extension is not critical")
+			}
+			if !bytes.Equal(ext.Value, expected) {
+				return fmt.Errorf("acmeIdentifier extension does not match key authorization")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("acmeIdentifier extension is missing")
+}
+
+// fakeACMEServer - ACME server issuing certificates from a throw away
+// CA, challenges are validated through validate.
+type fakeACMEServer struct {
+	*httptest.Server
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	// Called with domain and key authorization to validate a challenge.
+	validate func(domain, keyAuthorization string) error
+
+	mutex      *sync.Mutex
+	nonces     map[string]bool
+	nextNonce  int
+	thumbprint string
+	domains    []string
+	authzValid map[string]bool
+	chain      []byte
+}
+
+func newFakeACMEServer(t *testing.T) *fakeACMEServer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caBytes, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeACMEServer{
+		caKey:      caKey,
+		caCert:     caCert,
+		mutex:      &sync.Mutex{},
+		nonces:     make(map[string]bool),
+		authzValid: make(map[string]bool),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// newNonce - issues a new nonce, the caller must hold the mutex.
+func (s *fakeACMEServer) newNonce() string {
+	s.nextNonce++
+	nonce := fmt.Sprintf("nonce-%d", s.nextNonce)
+	s.nonces[nonce] = true
+	return nonce
+}
+
+// verify - verifies a JWS request, returns the payload.
+func (s *fakeACMEServer) verify(r *http.Request) ([]byte, error) {
+	var jws struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		return nil, err
+	}
+	protectedBytes, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, err
+	}
+	var protected struct {
+		Alg   string          `json:"alg"`
+		Nonce string          `json:"nonce"`
+		URL   string          `json:"url"`
+		JWK   json.RawMessage `json:"jwk"`
+		KID   string          `json:"kid"`
+	}
+	if err = json.Unmarshal(protectedBytes, &protected); err != nil {
+		return nil, err
+	}
+	if !s.nonces[protected.Nonce] {
+		return nil, fmt.Errorf("bad nonce %s", protected.Nonce)
+	}
+	delete(s.nonces, protected.Nonce)
+	if protected.URL != s.URL+r.URL.Path {
+		return nil, fmt.Errorf("url %s does not match request %s", protected.URL, r.URL.Path)
+	}
+	var jwk struct {
+		X string `json:"x"`
+		Y string `json:"y"`
+	}
+	switch {
+	case protected.JWK != nil && r.URL.Path == "/new-account":
+		thumbprint := sha256.Sum256(protected.JWK)
+		s.thumbprint = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+		if err = json.Unmarshal(protected.JWK, &jwk); err != nil {
+			return nil, err
+		}
+	case protected.KID == s.URL+"/account":
+	default:
+		return nil, fmt.Errorf("unexpected key reference")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil || len(signature) != 64 {
+		return nil, fmt.Errorf("malformed signature")
+	}
+	if jwk.X != "" {
+		x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		digest := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+		if !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+			return nil, fmt.Errorf("invalid signature")
+		}
+	}
+	return base64.RawURLEncoding.DecodeString(jws.Payload)
+}
+
+func (s *fakeACMEServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	if r.URL.Path == "/directory" {
+		json.NewEncoder(w).Encode(acmeDirectory{
+			NewNonce:   s.URL + "/new-nonce",
+			NewAccount: s.URL + "/new-account",
+			NewOrder:   s.URL + "/new-order",
+		})
+		return
+	}
+	if r.URL.Path == "/new-nonce" {
+		return
+	}
+	payload, err := s.verify(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(acmeError{Type: "urn:ietf:params:acme:error:malformed", Detail: err.Error()})
+		return
+	}
+	orderStatus := func() acmeOrder {
+		order := acmeOrder{Status: "ready", Finalize: s.URL + "/finalize"}
+		for _, domain := range s.domains {
+			order.Authorizations = append(order.Authorizations, s.URL+"/authz/"+domain)
+			if !s.authzValid[domain] {
+				order.Status = "pending"
+			}
+		}
+		if s.chain != nil {
+			order.Status = "valid"
+			order.Certificate = s.URL + "/cert"
+		}
+		return order
+	}
+	switch {
+	case r.URL.Path == "/new-account":
+		w.Header().Set("Location", s.URL+"/account")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status":"valid"}`))
+	case r.URL.Path == "/new-order":
+		var req struct {
+			Identifiers []acmeIdentifier `json:"identifiers"`
+		}
+		json.Unmarshal(payload, &req)
+		s.domains = nil
+		s.chain = nil
+		for _, id := range req.Identifiers {
+			s.domains = append(s.domains, id.Value)
+		}
+		w.Header().Set("Location", s.URL+"/order")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(orderStatus())
+	case r.URL.Path == "/order":
+		json.NewEncoder(w).Encode(orderStatus())
+	case len(r.URL.Path) > len("/authz/") && r.URL.Path[:len("/authz/")] == "/authz/":
+		domain := r.URL.Path[len("/authz/"):]
+		authz := acmeAuthorization{
+			Status:     "pending",
+			Identifier: acmeIdentifier{Type: "dns", Value: domain},
+			Challenges: []acmeChallenge{
+				{Type: "http-01", URL: s.URL + "/unsupported", Token: "http-token"},
+				{Type: "tls-alpn-01", URL: s.URL + "/challenge/" + domain, Token: "token-" + domain},
+			},
+		}
+		if s.authzValid[domain] {
+			authz.Status = "valid"
+		}
+		json.NewEncoder(w).Encode(authz)
+	case len(r.URL.Path) > len("/challenge/") && r.URL.Path[:len("/challenge/")] == "/challenge/":
+		domain := r.URL.Path[len("/challenge/"):]
+		if err = s.validate(domain, "token-"+domain+"."+s.thumbprint); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(acmeError{Type: "urn:ietf:params:acme:error:unauthorized", Detail: err.Error()})
+			return
+		}
+		s.authzValid[domain] = true
+		w.Write([]byte(`{"status":"valid"}`))
+	case r.URL.Path == "/finalize":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		json.Unmarshal(payload, &req)
+		csrBytes, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(csrBytes)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(acmeError{Type: "urn:ietf:params:acme:error:badCSR", Detail: err.Error()})
+			return
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		}
+		certBytes, err := x509.CreateCertificate(rand.Reader, template, s.caCert, csr.PublicKey, s.caKey)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.chain = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})...)
+		json.NewEncoder(w).Encode(orderStatus())
+	case r.URL.Path == "/cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(s.chain)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// Tests tls-alpn-01 challenge certificates.
+func TestACMEChallengeCert(t *testing.T) {
+	cert, err := newACMEChallengeCert("example.com", "token.thumbprint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = verifyACMEChallengeCert(cert, "example.com", "token.thumbprint"); err != nil {
+		t.Fatal(err)
+	}
+	if err = verifyACMEChallengeCert(cert, "example.com", "token.other"); err == nil {
+		t.Fatal("Expected challenge certificate not to match another key authorization")
+	}
+}
+
+// Tests certificates are obtained from the ACME server and served for
+// the ACME domains.
+func TestACMEManager(t *testing.T) {
+	acmePath, err := ioutil.TempDir("", "minio-acme-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(acmePath)
+
+	server := newFakeACMEServer(t)
+	defer server.Close()
+
+	m, err := newACMEManager(server.URL+"/directory", acmePath, []string{"Example.com", "s3.example.com"}, "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.client.pollInterval = time.Millisecond
+	if !m.needsRenewal() {
+		t.Fatal("Expected certificate to need renewal")
+	}
+
+	// Challenges are validated the way the ACME server would, by
+	// connecting with the acme-tls/1 protocol.
+	server.validate = func(domain, keyAuthorization string) error {
+		cert, err := m.GetCertificate(&tls.ClientHelloInfo{
+			ServerName:      domain,
+			SupportedProtos: []string{acmeALPNProto},
+		})
+		if err != nil {
+			return err
+		}
+		return verifyACMEChallengeCert(cert, domain, keyAuthorization)
+	}
+	if err = m.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if m.needsRenewal() {
+		t.Fatal("Expected certificate not to need renewal")
+	}
+	if len(m.challengeCerts) != 0 {
+		t.Fatal("Expected challenge certificates to be removed")
+	}
+
+	// Certificate is saved and loaded on restart.
+	m, err = newACMEManager(server.URL+"/directory", acmePath, []string{"example.com", "s3.example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.needsRenewal() {
+		t.Fatal("Expected saved certificate to be loaded")
+	}
+
+	fallbackCert := &tls.Certificate{}
+	m.fallback = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return fallbackCert, nil
+	}
+	testCases := []struct {
+		serverName string
+		acmeCert   bool
+	}{
+		// Test case - 1.
+		{"example.com", true},
+		// Test case - 2.
+		{"S3.Example.com.", true},
+		// Test case - 3.
+		// Other host names are served by the fallback.
+		{"other.example.com", false},
+		// Test case - 4.
+		{"", false},
+	}
+	for i, testCase := range testCases {
+		cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: testCase.serverName})
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if (cert != fallbackCert) != testCase.acmeCert {
+			t.Errorf("Test %d: Expected ACME certificate %v", i+1, testCase.acmeCert)
+		}
+	}
+
+	// No challenge is pending anymore.
+	if _, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{acmeALPNProto}}); err == nil {
+		t.Fatal("Expected challenge request to fail")
+	}
+
+	// Renewal is requested once the domains change.
+	m, err = newACMEManager(server.URL+"/directory", acmePath, []string{"example.com", "new.example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.needsRenewal() {
+		t.Fatal("Expected certificate to need renewal for a new domain")
+	}
+}
@@ -0,0 +1,1299 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerVersion - server version and commit it was built from.
+type ServerVersion struct {
+	Version  string `json:"version"`
+	CommitID string `json:"commitID"`
+}
+
+// ServiceStatus - status of the server returned by the admin API.
+type ServiceStatus struct {
+	ServerVersion ServerVersion `json:"serverVersion"`
+	Uptime        time.Duration `json:"uptime"`
+	// Online, read-only or maintenance.
+	Mode string `json:"mode"`
+}
+
+// ServerDiskInfo - usage, health and format of a disk.
+type ServerDiskInfo struct {
+	Endpoint    string `json:"endpoint"`
+	State       string `json:"state"`
+	TotalSpace  int64  `json:"totalSpace"`
+	UsedSpace   int64  `json:"usedSpace"`
+	HealStatus  string `json:"healStatus"`
+	ReadErrors  int64  `json:"readErrors"`
+	WriteErrors int64  `json:"writeErrors"`
+	FormatUUID  string `json:"formatUUID,omitempty"`
+	// Time the last full heal finished while the disk was online.
+	LastHealed *time.Time `json:"lastHealed,omitempty"`
+}
+
+// ServerNodeInfo - disks served by a node, with the connectivity of
+// nodes other than the local one.
+type ServerNodeInfo struct {
+	Addr         string           `json:"addr"`
+	Disks        []ServerDiskInfo `json:"disks"`
+	Connectivity *rpcEndpointInfo `json:"connectivity,omitempty"`
+}
+
+// ServerInfo - information about all nodes and disks of the server.
+type ServerInfo struct {
+	ServerVersion ServerVersion    `json:"serverVersion"`
+	Nodes         []ServerNodeInfo `json:"nodes"`
+}
+
+// SetConfigResult - result of a config update.
+type SetConfigResult struct {
+	// True if some settings only take effect after a restart.
+	RestartRequired bool `json:"restartRequired"`
+}
+
+// Maximum size of a config accepted by the admin API.
+const maxAdminConfigSize = 256 * 1024
+
+// Disk states reported by the admin API.
+const (
+	diskStateOnline  = "online"
+	diskStateOffline = "offline"
+)
+
+// checkAdminRequestAuth - admin requests must be signed with the
+// admin credentials, anonymous and presigned requests are rejected.
+func checkAdminRequestAuth(r *http.Request) APIErrorCode {
+	if getRequestAuthType(r) != authTypeSigned {
+		return ErrAccessDenied
+	}
+	return isReqAuthenticated(r)
+}
+
+// writeAdminJSONResponse - writes a JSON encoded admin API response.
+func writeAdminJSONResponse(w http.ResponseWriter, r *http.Request, response interface{}) {
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to encode admin response.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeSuccessResponse(w, jsonBytes)
+}
+
+// ServiceStatusHandler - GET /minio/admin/v1/service
+// ----------
+// Returns server version, commit and uptime.
+func (adminAPI adminAPIHandlers) ServiceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	status := ServiceStatus{
+		ServerVersion: ServerVersion{
+			Version:  minioVersion,
+			CommitID: minioCommitID,
+		},
+		Uptime: time.Since(globalBootTime),
+		Mode:   globalServerMode.Get(),
+	}
+	writeAdminJSONResponse(w, r, status)
+}
+
+// SetServerModeHandler - PUT /minio/admin/v1/service/mode?mode=
+// ----------
+// Puts the server online, in read-only or in maintenance mode. Writes
+// are rejected in read-only and maintenance mode, which additionally
+// pauses background crawling and purging.
+func (adminAPI adminAPIHandlers) SetServerModeHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err := globalServerMode.Set(r.URL.Query().Get("mode")); err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidServerMode, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// ServiceRestartHandler - POST /minio/admin/v1/service/restart
+// ----------
+// Restarts the server, the response is sent before the server stops
+// accepting requests.
+func (adminAPI adminAPIHandlers) ServiceRestartHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+	w.(http.Flusher).Flush()
+	sendServiceSignal(serviceRestart)
+}
+
+// ServiceUpdateHandler - POST /minio/admin/v1/service/update?experimental=true&local=true
+// ----------
+// Replaces the binaries of all nodes by the latest official or
+// experimental release, updated nodes restart after responding. Returns
+// the result of every node, or only of this node if local is set, as
+// sent by the node the update was requested from.
+func (adminAPI adminAPIHandlers) ServiceUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	experimental := r.URL.Query().Get("experimental") == "true"
+	if r.URL.Query().Get("local") != "true" {
+		writeAdminJSONResponse(w, r, updateNodes(experimental, r.Host))
+		return
+	}
+	result := updateLocalNode(experimental, r.Host)
+	writeAdminJSONResponse(w, r, result)
+	if result.Updated {
+		restartAfterUpdate()
+	}
+}
+
+// ServiceStopHandler - POST /minio/admin/v1/service/stop
+// ----------
+// Stops the server, the response is sent before the server stops
+// accepting requests.
+func (adminAPI adminAPIHandlers) ServiceStopHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+	w.(http.Flusher).Flush()
+	sendServiceSignal(serviceStop)
+}
+
+// GetConfigHandler - GET /minio/admin/v1/config
+// ----------
+// Returns the current server config including credentials.
+func (adminAPI adminAPIHandlers) GetConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	configBytes, err := serverConfig.JSON()
+	if err != nil {
+		errorIfRequest(r, err, "Unable to encode server config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeSuccessResponse(w, configBytes)
+}
+
+// SetConfigHandler - PUT /minio/admin/v1/config
+// ----------
+// Validates and saves a new server config. Credential and region take
+// effect immediately, the response tells whether a restart is required
+// for the remaining settings.
+func (adminAPI adminAPIHandlers) SetConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxAdminConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	configBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAdminConfigSize))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read server config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config := &serverConfigV6{}
+	if err = json.Unmarshal(configBytes, config); err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	if err = validateConfig(config); err != nil {
+		errorIfRequest(r, err, "Invalid server config provided.")
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	result := SetConfigResult{
+		RestartRequired: serverConfig.Update(config),
+	}
+	if err = serverConfig.Save(); err != nil {
+		errorIfRequest(r, err, "Unable to save server config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, result)
+}
+
+// Maximum size of a config bundle accepted by ImportConfigHandler.
+const maxConfigBundleSize = 16 * 1024 * 1024
+
+// ExportConfigHandler - GET /minio/admin/v1/config/export
+// ----------
+// Returns the server config and the configs of all buckets as a single
+// bundle. Secrets are left out unless a passphrase is provided in the
+// X-Minio-Config-Passphrase header, they are then encrypted with it.
+func (adminAPI adminAPIHandlers) ExportConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bundle, err := exportConfigBundle(adminAPI.ObjectAPI, r.Header.Get(configPassphraseHeader))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to export config bundle.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, bundle)
+}
+
+// ImportConfigHandler - PUT /minio/admin/v1/config/import
+// ----------
+// Validates and saves a config bundle exported by ExportConfigHandler.
+// Encrypted secrets are decrypted with the passphrase provided in the
+// X-Minio-Config-Passphrase header, secrets left out of the bundle are
+// kept from the current config. Missing buckets are created.
+func (adminAPI adminAPIHandlers) ImportConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxConfigBundleSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	bundle := &configBundle{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxConfigBundleSize)).Decode(bundle); err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	skipped, err := prepareConfigBundle(bundle, r.Header.Get(configPassphraseHeader))
+	if err != nil {
+		errorIfRequest(r, err, "Invalid config bundle provided.")
+		if err == errConfigBundleSecrets {
+			writeErrorResponse(w, r, ErrAdminConfigBundleSecrets, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		}
+		return
+	}
+	result, err := importConfigBundle(adminAPI.ObjectAPI, bundle, skipped)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to import config bundle.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, result)
+}
+
+// DataUsageInfoHandler - GET /minio/admin/v1/datausage
+// ----------
+// Returns object count and total size per bucket and top level prefix
+// as computed by the last finished run of the background crawler.
+func (adminAPI adminAPIHandlers) DataUsageInfoHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	usage, err := loadDataUsage(adminAPI.ObjectAPI)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to load data usage.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, usage)
+}
+
+// getStatsCount - returns the count query parameter of the stats
+// handlers, zero if it is not set.
+func getStatsCount(r *http.Request) (int, APIErrorCode) {
+	value := r.URL.Query().Get("count")
+	if value == "" {
+		return 0, ErrNone
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, ErrInvalidQueryParams
+	}
+	return count, ErrNone
+}
+
+// TopAPIsHandler - GET /minio/admin/v1/stats/apis?count=10
+// ----------
+// Returns request counts, error counts and latency histograms of the
+// count S3 APIs with the most requests, all APIs if count is not set.
+func (adminAPI adminAPIHandlers) TopAPIsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	count, s3Error := getStatsCount(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalAPIStats.top(false, count))
+}
+
+// TopBucketsHandler - GET /minio/admin/v1/stats/buckets?count=10
+// ----------
+// Returns request counts, error counts and latency histograms of the
+// count buckets with the most requests, all buckets if count is not
+// set.
+func (adminAPI adminAPIHandlers) TopBucketsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	count, s3Error := getStatsCount(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalAPIStats.top(true, count))
+}
+
+// topLocksResponse - long held locks and long running requests.
+type topLocksResponse struct {
+	Locks    []nsLockInfo      `json:"locks"`
+	Requests []inflightRequest `json:"requests"`
+}
+
+// TopLocksHandler - GET /minio/admin/v1/locks?olderThan=60
+// ----------
+// Returns the namespace locks held by this server and the requests it
+// is serving for at least olderThan seconds, oldest first. Helps to
+// find stuck uploads and leaked locks.
+func (adminAPI adminAPIHandlers) TopLocksHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	var olderThan time.Duration
+	if value := r.URL.Query().Get("olderThan"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+			return
+		}
+		olderThan = time.Duration(seconds) * time.Second
+	}
+	writeAdminJSONResponse(w, r, topLocksResponse{
+		Locks:    nsMutex.heldLocks(olderThan),
+		Requests: globalInflightRequests.olderThan(olderThan),
+	})
+}
+
+// BufferPoolStatsHandler - GET /minio/admin/v1/bufferpools
+// ----------
+// Returns how often the buffers of the data path were reused and
+// allocated, per buffer size.
+func (adminAPI adminAPIHandlers) BufferPoolStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalBufferPools.Stats())
+}
+
+// getBandwidth - returns the bandwidth query parameter in
+// bytes per second, 0 if it is not given.
+func getBandwidth(r *http.Request) (int64, APIErrorCode) {
+	value := r.URL.Query().Get("bandwidth")
+	if value == "" {
+		return 0, ErrNone
+	}
+	bandwidth, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || bandwidth < 0 {
+		return 0, ErrInvalidQueryParams
+	}
+	return bandwidth, ErrNone
+}
+
+// RebalanceStatusHandler - GET /minio/admin/v1/rebalance
+// ----------
+// Returns the state and progress of the background rebalance with the
+// usage of every erasure set.
+func (adminAPI adminAPIHandlers) RebalanceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalRebalancer == nil {
+		writeErrorResponse(w, r, ErrAdminRebalanceNotSupported, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalRebalancer.Status())
+}
+
+// StartRebalanceHandler - POST /minio/admin/v1/rebalance/start?bandwidth=
+// ----------
+// Starts moving objects to the erasure sets they hash to in the
+// background, at most bandwidth bytes per second if given. Run after
+// adding disks to spread existing objects onto the new sets.
+func (adminAPI adminAPIHandlers) StartRebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalRebalancer == nil {
+		writeErrorResponse(w, r, ErrAdminRebalanceNotSupported, r.URL.Path)
+		return
+	}
+	// Moving objects writes.
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bandwidth, s3Error := getBandwidth(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err := globalRebalancer.Start(bandwidth); err != nil {
+		errorIfRequest(r, err, "Unable to start rebalance.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// PauseRebalanceHandler - POST /minio/admin/v1/rebalance/pause
+// ----------
+// Pauses the running rebalance after the object being moved.
+func (adminAPI adminAPIHandlers) PauseRebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalRebalancer == nil {
+		writeErrorResponse(w, r, ErrAdminRebalanceNotSupported, r.URL.Path)
+		return
+	}
+	if err := globalRebalancer.Pause(); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// ResumeRebalanceHandler - POST /minio/admin/v1/rebalance/resume
+// ----------
+// Continues a paused rebalance where it stopped.
+func (adminAPI adminAPIHandlers) ResumeRebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalRebalancer == nil {
+		writeErrorResponse(w, r, ErrAdminRebalanceNotSupported, r.URL.Path)
+		return
+	}
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err := globalRebalancer.Resume(); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// FullHealStatusHandler - GET /minio/admin/v1/heal
+// ----------
+// Returns the state and progress of the background full heal.
+func (adminAPI adminAPIHandlers) FullHealStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalFullHealer == nil {
+		writeErrorResponse(w, r, ErrAdminHealNotSupported, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalFullHealer.Status())
+}
+
+// StartFullHealHandler - POST /minio/admin/v1/heal/start?bandwidth=
+// ----------
+// Starts healing the format, the buckets and all objects in the
+// background, at most bandwidth bytes per second if given. Run after
+// replacing a disk to restore its data.
+func (adminAPI adminAPIHandlers) StartFullHealHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalFullHealer == nil {
+		writeErrorResponse(w, r, ErrAdminHealNotSupported, r.URL.Path)
+		return
+	}
+	bandwidth, s3Error := getBandwidth(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err := globalFullHealer.Start(bandwidth); err != nil {
+		errorIfRequest(r, err, "Unable to start full heal.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// PauseFullHealHandler - POST /minio/admin/v1/heal/pause
+// ----------
+// Pauses the running full heal after the object being healed.
+func (adminAPI adminAPIHandlers) PauseFullHealHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalFullHealer == nil {
+		writeErrorResponse(w, r, ErrAdminHealNotSupported, r.URL.Path)
+		return
+	}
+	if err := globalFullHealer.Pause(); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// ResumeFullHealHandler - POST /minio/admin/v1/heal/resume
+// ----------
+// Continues a paused or failed full heal where it stopped.
+func (adminAPI adminAPIHandlers) ResumeFullHealHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalFullHealer == nil {
+		writeErrorResponse(w, r, ErrAdminHealNotSupported, r.URL.Path)
+		return
+	}
+	if err := globalFullHealer.Resume(); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// FullHealHistoryHandler - GET /minio/admin/v1/heal/history
+// ----------
+// Returns when every disk and bucket was last fully healed, with the
+// objects which failed to heal and remain degraded.
+func (adminAPI adminAPIHandlers) FullHealHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalFullHealer == nil {
+		writeErrorResponse(w, r, ErrAdminHealNotSupported, r.URL.Path)
+		return
+	}
+	history, err := loadHealHistory(adminAPI.ObjectAPI)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to load heal history.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if globalMRF != nil {
+		history.Queued = len(globalMRF.pending())
+	}
+	writeAdminJSONResponse(w, r, history)
+}
+
+// KMSRotationStatusHandler - GET /minio/admin/v1/kms/rotate
+// ----------
+// Returns the state and progress of the background key rotation.
+func (adminAPI adminAPIHandlers) KMSRotationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalKeyRotator.Status())
+}
+
+// StartKMSRotationHandler - POST /minio/admin/v1/kms/rotate/start?from=&to=
+// ----------
+// Starts re-sealing the keys of objects encrypted with master key from
+// with master key to in the background, the current master key if not
+// given. Object data is not re-encrypted. Rotating a key to itself
+// re-seals with its latest version.
+func (adminAPI adminAPIHandlers) StartKMSRotationHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Re-sealing object keys writes their metadata.
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	query := r.URL.Query()
+	if err := globalKeyRotator.Start(query.Get("from"), query.Get("to")); err != nil {
+		errorIfRequest(r, err, "Unable to start key rotation.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// PauseKMSRotationHandler - POST /minio/admin/v1/kms/rotate/pause
+// ----------
+// Pauses the running key rotation after the object key being rotated.
+func (adminAPI adminAPIHandlers) PauseKMSRotationHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err := globalKeyRotator.Pause(); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// ResumeKMSRotationHandler - POST /minio/admin/v1/kms/rotate/resume
+// ----------
+// Continues a paused or failed key rotation where it stopped.
+func (adminAPI adminAPIHandlers) ResumeKMSRotationHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err := globalKeyRotator.Resume(); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// DecommissionSetHandler - POST /minio/admin/v1/rebalance/decommission?set=&bandwidth=
+// ----------
+// Stops placing objects on the erasure set with the given index and
+// drains it in the background, at most bandwidth bytes per second if
+// given. The set is removed from the layout once it is empty, its disks
+// can be retired after restarting the servers without them.
+func (adminAPI adminAPIHandlers) DecommissionSetHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalRebalancer == nil {
+		writeErrorResponse(w, r, ErrAdminRebalanceNotSupported, r.URL.Path)
+		return
+	}
+	index, err := strconv.Atoi(r.URL.Query().Get("set"))
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+		return
+	}
+	bandwidth, s3Error := getBandwidth(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Draining the set writes.
+	if s3Error = globalServerMode.checkWrite(); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err = globalRebalancer.Decommission(index, bandwidth); err != nil {
+		errorIfRequest(r, err, "Unable to decommission erasure set.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// SetSmallObjectSetsHandler - POST /minio/admin/v1/rebalance/tier?sets=&size=
+// ----------
+// Places objects of at most size bytes, by the length declared on
+// upload, on the comma separated erasure set indices, e.g. sets on
+// NVMe disks, and larger objects and multipart uploads on the other
+// sets. Objects are found on either tier on read, start a rebalance to
+// move existing objects to their tier. An empty list of sets places
+// objects on all sets again.
+func (adminAPI adminAPIHandlers) SetSmallObjectSetsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if globalRebalancer == nil {
+		writeErrorResponse(w, r, ErrAdminRebalanceNotSupported, r.URL.Path)
+		return
+	}
+	query := r.URL.Query()
+	var indices []int
+	var size int64
+	if value := query.Get("sets"); value != "" {
+		for _, set := range strings.Split(value, ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(set))
+			if err != nil {
+				writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+				return
+			}
+			indices = append(indices, index)
+		}
+		var err error
+		if size, err = strconv.ParseInt(query.Get("size"), 10, 64); err != nil {
+			writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+			return
+		}
+	}
+	if err := globalRebalancer.sets.setSmallObjectSets(indices, size); err != nil {
+		errorIfRequest(r, err, "Unable to set small object sets.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// StartProfilingHandler - POST /minio/admin/v1/profiling/start?profilerType=cpu,mem
+// ----------
+// Starts the comma separated list of profilers, supported types are
+// cpu, mem, block and goroutine. Profilers which are still running are
+// stopped and their data is discarded.
+func (adminAPI adminAPIHandlers) StartProfilingHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	profilerTypes := strings.Split(r.URL.Query().Get("profilerType"), ",")
+	if err := globalProfilers.Start(profilerTypes); err != nil {
+		if err == errProfilerInvalidType {
+			writeErrorResponse(w, r, ErrAdminInvalidProfilerType, r.URL.Path)
+			return
+		}
+		errorIfRequest(r, err, "Unable to start profiling.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// DownloadProfilingHandler - GET /minio/admin/v1/profiling/download
+// ----------
+// Stops all running profilers and returns their data as a zip archive.
+func (adminAPI adminAPIHandlers) DownloadProfilingHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	buffer := new(bytes.Buffer)
+	if err := globalProfilers.Download(buffer); err != nil {
+		if err == errProfilerNotEnabled {
+			writeErrorResponse(w, r, ErrAdminProfilerNotEnabled, r.URL.Path)
+			return
+		}
+		errorIfRequest(r, err, "Unable to download profiling data.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=profiling.zip")
+	writeSuccessResponse(w, buffer.Bytes())
+}
+
+// TraceHandler - GET /minio/admin/v1/trace?err=true&verbose=true
+// ----------
+// Streams a JSON encoded summary of every API request until the client
+// disconnects. err restricts the stream to failed requests, verbose
+// includes request and response headers and the first bytes of their
+// bodies.
+func (adminAPI adminAPIHandlers) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	errOnly := r.URL.Query().Get("err") == "true"
+	verbose := r.URL.Query().Get("verbose") == "true"
+
+	sub := globalHTTPTrace.Subscribe(errOnly, verbose)
+	defer globalHTTPTrace.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "application/json")
+	writeSuccessResponse(w, nil)
+	w.(http.Flusher).Flush()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case trace := <-sub.traceCh:
+			if err := encoder.Encode(trace); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// getDiskNodeAddr - returns the address of the node serving a disk,
+// localAddr for local disks.
+func getDiskNodeAddr(endpoint, localAddr string) string {
+	// Same distinction between local and network disks as newStorageAPI.
+	if !strings.ContainsRune(endpoint, ':') || filepath.VolumeName(endpoint) != "" {
+		return localAddr
+	}
+	netAddr, _ := splitNetPath(endpoint)
+	return netAddr
+}
+
+// ServerInfoHandler - GET /minio/admin/v1/info
+// ----------
+// Returns usage, health and format of all disks grouped by the node
+// serving them, local disks are reported under the requested host.
+func (adminAPI adminAPIHandlers) ServerInfoHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	info := ServerInfo{
+		ServerVersion: ServerVersion{
+			Version:  minioVersion,
+			CommitID: minioCommitID,
+		},
+	}
+	// Disks are reported without their last heal if the history is
+	// unreadable.
+	history, err := loadHealHistory(adminAPI.ObjectAPI)
+	errorIfRequest(r, err, "Unable to load heal history.")
+	nodeIndex := make(map[string]int)
+	for _, status := range adminAPI.ObjectAPI.DisksInfo() {
+		diskInfo := ServerDiskInfo{
+			Endpoint:    status.Endpoint,
+			State:       diskStateOffline,
+			HealStatus:  status.HealStatus,
+			FormatUUID:  status.FormatUUID,
+			ReadErrors:  status.ReadErrCount,
+			WriteErrors: status.WriteErrCount,
+		}
+		if result, ok := history.Disks[status.FormatUUID]; ok && status.FormatUUID != "" {
+			diskInfo.LastHealed = &result.Finished
+		}
+		if status.Online {
+			diskInfo.State = diskStateOnline
+			diskInfo.TotalSpace = status.Total
+			diskInfo.UsedSpace = status.Total - status.Free
+		}
+		addr := getDiskNodeAddr(status.Endpoint, r.Host)
+		index, ok := nodeIndex[addr]
+		if !ok {
+			index = len(info.Nodes)
+			nodeIndex[addr] = index
+			info.Nodes = append(info.Nodes, ServerNodeInfo{Addr: addr})
+		}
+		info.Nodes[index].Disks = append(info.Nodes[index].Disks, diskInfo)
+	}
+	for _, endpoint := range getRPCEndpointsInfo() {
+		if index, ok := nodeIndex[endpoint.Addr]; ok {
+			endpoint := endpoint
+			info.Nodes[index].Connectivity = &endpoint
+		}
+	}
+	writeAdminJSONResponse(w, r, info)
+}
+
+// GetReplicationConfigHandler - GET /minio/admin/v1/replication?bucket=
+// ----------
+// Returns the replication target of a bucket.
+func (adminAPI adminAPIHandlers) GetReplicationConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	config, err := readBucketReplicationConfig(bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket replication configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, config)
+}
+
+// SetReplicationConfigHandler - PUT /minio/admin/v1/replication?bucket=
+// ----------
+// Sets the replication target of a bucket, new objects and deletes
+// are mirrored to the target from now on. Existing objects are only
+// copied by a resync.
+func (adminAPI adminAPIHandlers) SetReplicationConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxAdminConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	configBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAdminConfigSize))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket replication configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config := &bucketReplicationConfig{}
+	if err = json.Unmarshal(configBytes, config); err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	if err = validateBucketReplicationConfig(*config); err != nil {
+		errorIfRequest(r, err, "Invalid bucket replication configuration provided.")
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	if err = writeBucketReplicationConfig(bucket, config); err != nil {
+		errorIfRequest(r, err, "Unable to write bucket replication configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	globalReplication.invalidate(bucket)
+	writeSuccessResponse(w, nil)
+}
+
+// RemoveReplicationConfigHandler - DELETE /minio/admin/v1/replication?bucket=
+// ----------
+// Stops replicating a bucket, queued operations are dropped.
+func (adminAPI adminAPIHandlers) RemoveReplicationConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if err := removeBucketReplication(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to remove bucket replication configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	globalReplication.invalidate(bucket)
+	writeSuccessNoContent(w)
+}
+
+// ReplicationStatusHandler - GET /minio/admin/v1/replication/status
+// ----------
+// Returns pending, replicated and failed operations and the replication
+// lag per bucket since the server started.
+func (adminAPI adminAPIHandlers) ReplicationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, globalReplication.Stats())
+}
+
+// ReplicationResyncHandler - POST /minio/admin/v1/replication/resync?bucket=
+// ----------
+// Copies all objects of a bucket which are missing or differ on the
+// replication target in the background, e.g. after the target was
+// unreachable or a new target was configured.
+func (adminAPI adminAPIHandlers) ReplicationResyncHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if err := globalReplication.Resync(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to start replication resync.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetTrashConfigHandler - GET /minio/admin/v1/trash/config?bucket=
+// ----------
+// Returns the trash retention of a bucket.
+func (adminAPI adminAPIHandlers) GetTrashConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	config, err := readBucketTrashConfig(bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket trash configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, config)
+}
+
+// SetTrashConfigHandler - PUT /minio/admin/v1/trash/config?bucket=
+// ----------
+// Sets the trash retention of a bucket, deleted objects are moved to
+// the trash from now on and purged once the retention expired.
+func (adminAPI adminAPIHandlers) SetTrashConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxAdminConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	configBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAdminConfigSize))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket trash configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config := &bucketTrashConfig{}
+	if err = json.Unmarshal(configBytes, config); err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	if err = validateBucketTrashConfig(*config); err != nil {
+		errorIfRequest(r, err, "Invalid bucket trash configuration provided.")
+		writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+		return
+	}
+	if err = writeBucketTrashConfig(bucket, config); err != nil {
+		errorIfRequest(r, err, "Unable to write bucket trash configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// RemoveTrashConfigHandler - DELETE /minio/admin/v1/trash/config?bucket=
+// ----------
+// Deletes objects of a bucket right away again, objects already in the
+// trash are purged by the next purge.
+func (adminAPI adminAPIHandlers) RemoveTrashConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if err := removeBucketTrash(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to remove bucket trash configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetIndexConfigHandler - GET /minio/admin/v1/index/config?bucket=
+// ----------
+// Returns the index page configuration of a bucket.
+func (adminAPI adminAPIHandlers) GetIndexConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	config, err := readBucketIndexConfig(bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket index page configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, config)
+}
+
+// SetIndexConfigHandler - PUT /minio/admin/v1/index/config?bucket=
+// ----------
+// Enables the index page of a bucket, browsers listing the bucket
+// anonymously get an index page if the bucket policy allows it.
+func (adminAPI adminAPIHandlers) SetIndexConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxAdminConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	configBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAdminConfigSize))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket index page configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config := &bucketIndexConfig{}
+	if len(configBytes) > 0 {
+		if err = json.Unmarshal(configBytes, config); err != nil {
+			writeErrorResponse(w, r, ErrAdminInvalidConfig, r.URL.Path)
+			return
+		}
+	}
+	if err = writeBucketIndexConfig(bucket, config); err != nil {
+		errorIfRequest(r, err, "Unable to write bucket index page configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// RemoveIndexConfigHandler - DELETE /minio/admin/v1/index/config?bucket=
+// ----------
+// Disables the index page of a bucket, anonymous listings are answered
+// with the regular listing again.
+func (adminAPI adminAPIHandlers) RemoveIndexConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if err := removeBucketIndex(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to remove bucket index page configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// ListTrashHandler - GET /minio/admin/v1/trash?bucket=
+// ----------
+// Returns the deleted objects in the trash of a bucket, the most
+// recently deleted first.
+func (adminAPI adminAPIHandlers) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	entries, err := listTrash(adminAPI.ObjectAPI, bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to list bucket trash.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, entries)
+}
+
+// RestoreTrashHandler - POST /minio/admin/v1/trash/restore?bucket=&id=
+// ----------
+// Moves a deleted object back to its bucket, returns the restored
+// trash entry.
+func (adminAPI adminAPIHandlers) RestoreTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	entry, err := restoreTrash(adminAPI.ObjectAPI, bucket, r.URL.Query().Get("id"))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to restore deleted object.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeAdminJSONResponse(w, r, entry)
+}
+
+// PurgeTrashHandler - DELETE /minio/admin/v1/trash?bucket=[&id=]
+// ----------
+// Permanently deletes the object with id in the trash of a bucket,
+// all objects in the trash if no id is given.
+func (adminAPI adminAPIHandlers) PurgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := checkAdminRequestAuth(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := adminAPI.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	var err error
+	if id := r.URL.Query().Get("id"); id != "" {
+		if _, err = readTrashEntry(adminAPI.ObjectAPI, bucket, id); err == nil {
+			err = purgeTrashEntry(adminAPI.ObjectAPI, bucket, id)
+		}
+	} else {
+		err = purgeTrash(adminAPI.ObjectAPI, bucket, time.Now().UTC())
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to purge bucket trash.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
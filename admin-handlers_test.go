@@ -0,0 +1,234 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests service control through the admin API.
+func TestAdminServiceControl(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.ServiceStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ServerVersion.Version != minioVersion || status.ServerVersion.CommitID != minioCommitID {
+		t.Fatalf("Unexpected server version %#v", status.ServerVersion)
+	}
+	if status.Uptime <= 0 {
+		t.Fatalf("Expected positive uptime, but instead found %s", status.Uptime)
+	}
+
+	testCases := []struct {
+		control func() error
+		signal  serviceSignal
+	}{
+		// Test case - 1.
+		{client.ServiceRestart, serviceRestart},
+		// Test case - 2.
+		{client.ServiceStop, serviceStop},
+	}
+	for i, testCase := range testCases {
+		if err = testCase.control(); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		select {
+		case signal := <-globalServiceSignalCh:
+			if signal != testCase.signal {
+				t.Errorf("Test %d: Expected signal %d, but instead found %d", i+1, testCase.signal, signal)
+			}
+		default:
+			t.Errorf("Test %d: Expected service signal to be sent", i+1)
+		}
+	}
+
+	// Requests signed with other credentials are rejected.
+	client, err = madmin.New(endpoint, "invalid-access-key", testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.ServiceStop(); madmin.ToErrorResponse(err).Code != "InvalidAccessKeyID" {
+		t.Fatalf("Expected InvalidAccessKeyID, but instead found %v", err)
+	}
+
+	// Anonymous requests are rejected.
+	resp, err := http.Post(testServer.Server.URL+adminAPIPathPrefix+"/service/stop", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status %d, but instead found %d", http.StatusForbidden, resp.StatusCode)
+	}
+	select {
+	case <-globalServiceSignalCh:
+		t.Fatal("Expected no service signal to be sent")
+	default:
+	}
+}
+
+// Tests disks are reported by the node serving them.
+func TestGetDiskNodeAddr(t *testing.T) {
+	testCases := []struct {
+		endpoint string
+		addr     string
+	}{
+		// Test case - 1.
+		{"/mnt/disk1", "localhost:9000"},
+		// Test case - 2.
+		{"192.168.1.10:9000:/mnt/disk1", "192.168.1.10:9000"},
+		// Test case - 3.
+		{"node1:/mnt/disk1", "node1"},
+	}
+	for i, testCase := range testCases {
+		if addr := getDiskNodeAddr(testCase.endpoint, "localhost:9000"); addr != testCase.addr {
+			t.Errorf("Test %d: Expected %s, but instead found %s", i+1, testCase.addr, addr)
+		}
+	}
+}
+
+// Tests server info reports all disks, including offline disks.
+func TestAdminServerInfo(t *testing.T) {
+	for _, instanceType := range []string{"FS", "XL"} {
+		testServer := StartTestServer(t, instanceType)
+		endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+		client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		// Take one disk offline.
+		offlineDisk := ""
+		if len(testServer.Disks) > 1 {
+			offlineDisk = testServer.Disks[0]
+			removeAll(offlineDisk)
+		}
+
+		info, err := client.ServerInfo()
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if len(info.Nodes) != 1 || info.Nodes[0].Addr != endpoint {
+			t.Fatalf("%s: Expected disks to be served by %s, but instead found %#v", instanceType, endpoint, info.Nodes)
+		}
+		disks := info.Nodes[0].Disks
+		if len(disks) != len(testServer.Disks) {
+			t.Fatalf("%s: Expected %d disks, but instead found %d", instanceType, len(testServer.Disks), len(disks))
+		}
+		for i, disk := range disks {
+			if disk.Endpoint != testServer.Disks[i] {
+				t.Errorf("%s: Expected disk %s, but instead found %s", instanceType, testServer.Disks[i], disk.Endpoint)
+			}
+			if disk.Endpoint == offlineDisk {
+				if disk.State != madmin.DiskStateOffline || disk.HealStatus != healStatusUnknown {
+					t.Errorf("%s: Expected disk %s to be offline, but instead found %#v", instanceType, disk.Endpoint, disk)
+				}
+				continue
+			}
+			if disk.State != madmin.DiskStateOnline || disk.HealStatus != healStatusOK || disk.TotalSpace <= 0 {
+				t.Errorf("%s: Expected disk %s to be online, but instead found %#v", instanceType, disk.Endpoint, disk)
+			}
+			if instanceType == "XL" && disk.FormatUUID == "" {
+				t.Errorf("%s: Expected disk %s to have a format UUID", instanceType, disk.Endpoint)
+			}
+		}
+		testServer.Stop()
+	}
+}
+
+// Tests server config can be retrieved and updated through the admin API.
+func TestAdminConfig(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configBytes, err := client.GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := serverConfigV6{}
+	if err = json.Unmarshal(configBytes, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Credential.AccessKeyID != testServer.AccessKey || config.Region != serverConfig.GetRegion() {
+		t.Fatalf("Unexpected server config %s", string(configBytes))
+	}
+
+	testCases := []struct {
+		update          func(config *serverConfigV6)
+		restartRequired bool
+		errCode         string
+	}{
+		// Test case - 1.
+		// Region is applied immediately.
+		{func(config *serverConfigV6) { config.Region = "eu-west-1" }, false, ""},
+		// Test case - 2.
+		// Logger changes require a restart.
+		{func(config *serverConfigV6) { config.Logger.Console.Level = "debug" }, true, ""},
+		// Test case - 3.
+		{func(config *serverConfigV6) { config.Version = "1" }, false, "XMinioAdminInvalidConfig"},
+		// Test case - 4.
+		{func(config *serverConfigV6) { config.Credential.AccessKeyID = "a" }, false, "XMinioAdminInvalidConfig"},
+		// Test case - 5.
+		{func(config *serverConfigV6) { config.Logger.Console.Level = "unknown" }, false, "XMinioAdminInvalidConfig"},
+		// Test case - 6.
+		{func(config *serverConfigV6) { config.Region = "" }, false, "XMinioAdminInvalidConfig"},
+	}
+	for i, testCase := range testCases {
+		newConfig := config
+		testCase.update(&newConfig)
+		newConfigBytes, err := json.Marshal(&newConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := client.SetConfig(newConfigBytes)
+		if testCase.errCode != "" {
+			if madmin.ToErrorResponse(err).Code != testCase.errCode {
+				t.Errorf("Test %d: Expected %s, but instead found %v", i+1, testCase.errCode, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if result.RestartRequired != testCase.restartRequired {
+			t.Errorf("Test %d: Expected restart required %t, but instead found %t", i+1, testCase.restartRequired, result.RestartRequired)
+		}
+		if serverConfig.GetRegion() != newConfig.Region {
+			t.Errorf("Test %d: Expected region %s, but instead found %s", i+1, newConfig.Region, serverConfig.GetRegion())
+		}
+		// Subsequent requests are signed for the new region.
+		client.SetRegion(newConfig.Region)
+		config = newConfig
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import router "github.com/gorilla/mux"
+
+// Admin API is served under this path, versioned independently of
+// the S3 API.
+const adminAPIPathPrefix = reservedBucket + "/admin/v1"
+
+// adminAPIHandlers provides HTTP handlers for the Minio admin API.
+type adminAPIHandlers struct {
+	ObjectAPI ObjectLayer
+}
+
+// registerAdminRouter - registers admin API routes, must be registered
+// before the web router which serves everything else under reservedBucket.
+func registerAdminRouter(mux *router.Router, adminAPI adminAPIHandlers) {
+	// Admin router.
+	adminRouter := mux.NewRoute().PathPrefix(adminAPIPathPrefix).Subrouter()
+
+	/// Service operations
+
+	// Service status.
+	adminRouter.Methods("GET").Path("/service").HandlerFunc(adminAPI.ServiceStatusHandler)
+	// Service restart.
+	adminRouter.Methods("POST").Path("/service/restart").HandlerFunc(adminAPI.ServiceRestartHandler)
+	// Service stop.
+	adminRouter.Methods("POST").Path("/service/stop").HandlerFunc(adminAPI.ServiceStopHandler)
+	// Update all nodes.
+	adminRouter.Methods("POST").Path("/service/update").HandlerFunc(adminAPI.ServiceUpdateHandler)
+	// Set server mode.
+	adminRouter.Methods("PUT").Path("/service/mode").HandlerFunc(adminAPI.SetServerModeHandler).Queries("mode", "{mode:.*}")
+
+	/// Config operations
+
+	// Get config.
+	adminRouter.Methods("GET").Path("/config").HandlerFunc(adminAPI.GetConfigHandler)
+	// Set config.
+	adminRouter.Methods("PUT").Path("/config").HandlerFunc(adminAPI.SetConfigHandler)
+	// Export config bundle.
+	adminRouter.Methods("GET").Path("/config/export").HandlerFunc(adminAPI.ExportConfigHandler)
+	// Import config bundle.
+	adminRouter.Methods("PUT").Path("/config/import").HandlerFunc(adminAPI.ImportConfigHandler)
+
+	/// Server operations
+
+	// Server info.
+	adminRouter.Methods("GET").Path("/info").HandlerFunc(adminAPI.ServerInfoHandler)
+	// Data usage.
+	adminRouter.Methods("GET").Path("/datausage").HandlerFunc(adminAPI.DataUsageInfoHandler)
+	// Buffer pool stats.
+	adminRouter.Methods("GET").Path("/bufferpools").HandlerFunc(adminAPI.BufferPoolStatsHandler)
+	// Top APIs by requests.
+	adminRouter.Methods("GET").Path("/stats/apis").HandlerFunc(adminAPI.TopAPIsHandler)
+	// Top buckets by requests.
+	adminRouter.Methods("GET").Path("/stats/buckets").HandlerFunc(adminAPI.TopBucketsHandler)
+	// Long held locks and long running requests.
+	adminRouter.Methods("GET").Path("/locks").HandlerFunc(adminAPI.TopLocksHandler)
+	// Start profiling.
+	adminRouter.Methods("POST").Path("/profiling/start").HandlerFunc(adminAPI.StartProfilingHandler).Queries("profilerType", "{profilerType:.*}")
+	// Download profiling data.
+	adminRouter.Methods("GET").Path("/profiling/download").HandlerFunc(adminAPI.DownloadProfilingHandler)
+	// Trace requests.
+	adminRouter.Methods("GET").Path("/trace").HandlerFunc(adminAPI.TraceHandler)
+
+	/// Rebalance operations
+
+	// Rebalance status.
+	adminRouter.Methods("GET").Path("/rebalance").HandlerFunc(adminAPI.RebalanceStatusHandler)
+	// Start rebalance.
+	adminRouter.Methods("POST").Path("/rebalance/start").HandlerFunc(adminAPI.StartRebalanceHandler)
+	// Pause rebalance.
+	adminRouter.Methods("POST").Path("/rebalance/pause").HandlerFunc(adminAPI.PauseRebalanceHandler)
+	// Resume rebalance.
+	adminRouter.Methods("POST").Path("/rebalance/resume").HandlerFunc(adminAPI.ResumeRebalanceHandler)
+	// Decommission an erasure set.
+	adminRouter.Methods("POST").Path("/rebalance/decommission").HandlerFunc(adminAPI.DecommissionSetHandler).Queries("set", "{set:.*}")
+	// Place small objects on some sets.
+	adminRouter.Methods("POST").Path("/rebalance/tier").HandlerFunc(adminAPI.SetSmallObjectSetsHandler).Queries("sets", "{sets:.*}")
+
+	/// Full heal operations
+
+	// Full heal status.
+	adminRouter.Methods("GET").Path("/heal").HandlerFunc(adminAPI.FullHealStatusHandler)
+	// Start full heal.
+	adminRouter.Methods("POST").Path("/heal/start").HandlerFunc(adminAPI.StartFullHealHandler)
+	// Pause full heal.
+	adminRouter.Methods("POST").Path("/heal/pause").HandlerFunc(adminAPI.PauseFullHealHandler)
+	// Resume full heal.
+	adminRouter.Methods("POST").Path("/heal/resume").HandlerFunc(adminAPI.ResumeFullHealHandler)
+	// Results of past full heals.
+	adminRouter.Methods("GET").Path("/heal/history").HandlerFunc(adminAPI.FullHealHistoryHandler)
+
+	/// Key rotation operations
+
+	// Key rotation status.
+	adminRouter.Methods("GET").Path("/kms/rotate").HandlerFunc(adminAPI.KMSRotationStatusHandler)
+	// Start key rotation.
+	adminRouter.Methods("POST").Path("/kms/rotate/start").HandlerFunc(adminAPI.StartKMSRotationHandler).Queries("from", "{from:.+}")
+	// Pause key rotation.
+	adminRouter.Methods("POST").Path("/kms/rotate/pause").HandlerFunc(adminAPI.PauseKMSRotationHandler)
+	// Resume key rotation.
+	adminRouter.Methods("POST").Path("/kms/rotate/resume").HandlerFunc(adminAPI.ResumeKMSRotationHandler)
+
+	/// Replication operations
+
+	// Replication status of all buckets.
+	adminRouter.Methods("GET").Path("/replication/status").HandlerFunc(adminAPI.ReplicationStatusHandler)
+	// Resync bucket replication.
+	adminRouter.Methods("POST").Path("/replication/resync").HandlerFunc(adminAPI.ReplicationResyncHandler).Queries("bucket", "{bucket:.*}")
+	// Get bucket replication config.
+	adminRouter.Methods("GET").Path("/replication").HandlerFunc(adminAPI.GetReplicationConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Set bucket replication config.
+	adminRouter.Methods("PUT").Path("/replication").HandlerFunc(adminAPI.SetReplicationConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Remove bucket replication config.
+	adminRouter.Methods("DELETE").Path("/replication").HandlerFunc(adminAPI.RemoveReplicationConfigHandler).Queries("bucket", "{bucket:.*}")
+
+	/// Trash operations
+
+	// Get bucket trash config.
+	adminRouter.Methods("GET").Path("/trash/config").HandlerFunc(adminAPI.GetTrashConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Set bucket trash config.
+	adminRouter.Methods("PUT").Path("/trash/config").HandlerFunc(adminAPI.SetTrashConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Remove bucket trash config.
+	adminRouter.Methods("DELETE").Path("/trash/config").HandlerFunc(adminAPI.RemoveTrashConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Restore a deleted object.
+	adminRouter.Methods("POST").Path("/trash/restore").HandlerFunc(adminAPI.RestoreTrashHandler).Queries("bucket", "{bucket:.*}", "id", "{id:.*}")
+	// List deleted objects of a bucket.
+	adminRouter.Methods("GET").Path("/trash").HandlerFunc(adminAPI.ListTrashHandler).Queries("bucket", "{bucket:.*}")
+	// Purge deleted objects of a bucket.
+	adminRouter.Methods("DELETE").Path("/trash").HandlerFunc(adminAPI.PurgeTrashHandler).Queries("bucket", "{bucket:.*}")
+
+	/// Index page operations
+
+	// Get bucket index page config.
+	adminRouter.Methods("GET").Path("/index/config").HandlerFunc(adminAPI.GetIndexConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Set bucket index page config.
+	adminRouter.Methods("PUT").Path("/index/config").HandlerFunc(adminAPI.SetIndexConfigHandler).Queries("bucket", "{bucket:.*}")
+	// Remove bucket index page config.
+	adminRouter.Methods("DELETE").Path("/index/config").HandlerFunc(adminAPI.RemoveIndexConfigHandler).Queries("bucket", "{bucket:.*}")
+}
@@ -17,7 +17,10 @@
 package main
 
 import (
+	"context"
 	"encoding/xml"
+	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -36,6 +39,7 @@ type APIErrorResponse struct {
 	Key        string
 	BucketName string
 	Resource   string
+	Region     string `xml:",omitempty"`
 	RequestID  string `xml:"RequestId"`
 	HostID     string `xml:"HostId"`
 }
@@ -51,6 +55,8 @@ const (
 	ErrBucketAlreadyExists
 	ErrEntityTooSmall
 	ErrEntityTooLarge
+	ErrObjectTooLarge
+	ErrPartTooLarge
 	ErrIncompleteBody
 	ErrInternalError
 	ErrInvalidAccessKeyID
@@ -60,7 +66,9 @@ const (
 	ErrInvalidMaxKeys
 	ErrInvalidMaxUploads
 	ErrInvalidMaxParts
+	ErrInvalidPartNumber
 	ErrInvalidPartNumberMarker
+	ErrInvalidEncodingMethod
 	ErrInvalidRequestBody
 	ErrInvalidCopySource
 	ErrInvalidCopyDest
@@ -98,18 +106,76 @@ const (
 	ErrMalformedExpires
 	ErrAuthHeaderEmpty
 	ErrExpiredPresignRequest
+	ErrRequestNotReadyYet
 	ErrMissingDateHeader
 	ErrInvalidQuerySignatureAlgo
 	ErrInvalidQueryParams
 	ErrBucketAlreadyOwnedByYou
+	ErrObjectLocked
+	ErrNoSuchObjectLockConfiguration
+	ErrInvalidBucketObjectLockConfiguration
+	ErrObjectLockInvalidHeaders
+	ErrPastObjectLockRetainDate
+	ErrInvalidRetentionPeriod
+	ErrInvalidEncryptionMethod
+	ErrNoSuchBucketEncryptionConfiguration
+	ErrInvalidBucketEncryptionConfiguration
+	ErrIncorrectContinuationToken
+	ErrAnonymousResponseHeaders
+	ErrInvalidLocationConstraint
+	ErrMissingSelectExpression
+	ErrExpressionTooLong
+	ErrInvalidExpressionType
+	ErrInvalidCompressionFormat
+	ErrInvalidFileHeaderInfo
+	ErrInvalidJSONType
+	ErrInvalidQuoteFields
+	ErrInvalidRequestParameter
+	ErrObjectSerializationConflict
+	ErrParseSelectFailure
+	ErrNoSuchReplicationConfiguration
+	ErrInvalidObjectState
+	ErrSlowDown
+	ErrContentSHA256Mismatch
+	ErrInvalidContentSHA256
+	ErrInvalidChecksum
+	ErrMultipleChecksums
+	ErrInvalidObjectAttributes
+	ErrServiceUnavailable
 	// Add new error codes here.
 
 	// Minio extended errors.
 	ErrReadQuorum
 	ErrWriteQuorum
 	ErrStorageFull
+	ErrClientDisconnected
 	ErrObjectExistsAsDirectory
 	ErrPolicyNesting
+	ErrKMSNotConfigured
+	ErrKMSKeyNotFound
+	ErrObjectEncryptionKey
+	ErrAdminInvalidConfig
+	ErrAdminInvalidProfilerType
+	ErrAdminProfilerNotEnabled
+	ErrAdminNoSuchTrashConfig
+	ErrAdminNoSuchIndexConfig
+	ErrAdminNoSuchTrashEntry
+	ErrAdminTrashObjectExists
+	ErrAdminInvalidServerMode
+	ErrAdminRebalanceNotSupported
+	ErrAdminRebalanceRunning
+	ErrAdminRebalanceNotRunning
+	ErrAdminInvalidDecommission
+	ErrAdminInvalidSmallObjectSets
+	ErrAdminKMSRotationRunning
+	ErrAdminKMSRotationNotRunning
+	ErrAdminHealNotSupported
+	ErrAdminHealRunning
+	ErrAdminHealNotRunning
+	ErrAdminConfigBundleSecrets
+	ErrServerReadOnly
+	ErrServerMaintenance
+	ErrCorruptedFormat
 )
 
 // error code to APIError structure, these fields carry respective
@@ -145,6 +211,16 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Argument maxParts must be an integer between 1 and 10000.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidPartNumber: {
+		Code:           "InvalidArgument",
+		Description:    "Part number must be an integer between 1 and %d, inclusive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidEncodingMethod: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid Encoding Method specified in Request",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidPartNumberMarker: {
 		Code:           "InvalidArgument",
 		Description:    "Argument partNumberMarker must be an integer.",
@@ -180,6 +256,18 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Your proposed upload exceeds the maximum allowed object size.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	// Size limits are configurable, getAPIError fills in the ones in
+	// effect.
+	ErrObjectTooLarge: {
+		Code:           "EntityTooLarge",
+		Description:    "Your proposed upload exceeds the maximum allowed object size of %d bytes.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPartTooLarge: {
+		Code:           "EntityTooLarge",
+		Description:    "Your proposed upload exceeds the maximum allowed part size of %d bytes.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrIncompleteBody: {
 		Code:           "IncompleteBody",
 		Description:    "You did not provide the number of bytes specified by the Content-Length HTTP header.",
@@ -385,6 +473,11 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Request has expired.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrRequestNotReadyYet: {
+		Code:           "AccessDenied",
+		Description:    "Request is not valid yet.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
 	ErrInvalidQueryParams: {
 		Code:           "AuthorizationQueryParametersError",
 		Description:    "Query-string authentication version 4 requires the X-Amz-Algorithm, X-Amz-Credential, X-Amz-Signature, X-Amz-Date, X-Amz-SignedHeaders, and X-Amz-Expires parameters.",
@@ -395,12 +488,173 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Your previous request to create the named bucket succeeded and you already own it.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "Object is WORM protected and cannot be overwritten or deleted.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchObjectLockConfiguration: {
+		Code:           "NoSuchObjectLockConfiguration",
+		Description:    "The specified object does not have an ObjectLock configuration.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidBucketObjectLockConfiguration: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing ObjectLockConfiguration.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLockInvalidHeaders: {
+		Code:           "InvalidRequest",
+		Description:    "x-amz-object-lock-retain-until-date and x-amz-object-lock-mode must both be supplied.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPastObjectLockRetainDate: {
+		Code:           "InvalidRequest",
+		Description:    "The retain until date must be in the future.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidRetentionPeriod: {
+		Code:           "InvalidRetentionPeriod",
+		Description:    "The retention period specified is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidEncryptionMethod: {
+		Code:           "InvalidEncryptionAlgorithmError",
+		Description:    "The encryption request you specified is not valid. Supported values: AES256, aws:kms.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchBucketEncryptionConfiguration: {
+		Code:           "ServerSideEncryptionConfigurationNotFoundError",
+		Description:    "The server side encryption configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidBucketEncryptionConfiguration: {
+		Code:           "InvalidArgument",
+		Description:    "Server side encryption configuration must have exactly one rule with a supported algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrIncorrectContinuationToken: {
+		Code:           "InvalidArgument",
+		Description:    "The continuation token provided is incorrect.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAnonymousResponseHeaders: {
+		Code:           "InvalidRequest",
+		Description:    "Request specific response headers cannot be used for anonymous GET requests.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidLocationConstraint: {
+		Code:           "InvalidLocationConstraint",
+		Description:    "The specified location constraint is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMissingSelectExpression: {
+		Code:           "MissingRequiredParameter",
+		Description:    "The SelectRequest entity is missing a required parameter. Check the service documentation and try again.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrExpressionTooLong: {
+		Code:           "ExpressionTooLong",
+		Description:    "The SQL expression is too long: The maximum byte-length for the SQL expression is 256 KB.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidExpressionType: {
+		Code:           "InvalidExpressionType",
+		Description:    "The ExpressionType is invalid. Only SQL expressions are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCompressionFormat: {
+		Code:           "InvalidCompressionFormat",
+		Description:    "The file is not in a supported compression format. Only GZIP and BZIP2 are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidFileHeaderInfo: {
+		Code:           "InvalidFileHeaderInfo",
+		Description:    "The FileHeaderInfo is invalid. Only NONE, USE, and IGNORE are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidJSONType: {
+		Code:           "InvalidJsonType",
+		Description:    "The JsonType is invalid. Only DOCUMENT and LINES are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidQuoteFields: {
+		Code:           "InvalidQuoteFields",
+		Description:    "The QuoteFields is invalid. Only ALWAYS and ASNEEDED are supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidRequestParameter: {
+		Code:           "InvalidRequestParameter",
+		Description:    "The value of a parameter in SelectRequest element is invalid. Check the service API documentation and try again.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectSerializationConflict: {
+		Code:           "ObjectSerializationConflict",
+		Description:    "The InputSerialization and OutputSerialization elements must specify exactly one format.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrParseSelectFailure: {
+		Code:           "ParseSelectFailure",
+		Description:    "The SQL expression contains a syntax error or an unsupported operation.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchReplicationConfiguration: {
+		Code:           "ReplicationConfigurationNotFoundError",
+		Description:    "The replication configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidObjectState: {
+		Code:           "InvalidObjectState",
+		Description:    "The operation is not valid for the current state of the object.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrSlowDown: {
+		Code:           "SlowDown",
+		Description:    "Please reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrContentSHA256Mismatch: {
+		Code:           "XAmzContentSHA256Mismatch",
+		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidContentSHA256: {
+		Code:           "InvalidArgument",
+		Description:    "x-amz-content-sha256 must be UNSIGNED-PAYLOAD or a valid sha256 value.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidChecksum: {
+		Code:           "InvalidRequest",
+		Description:    "Value for x-amz-checksum header is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMultipleChecksums: {
+		Code:           "InvalidRequest",
+		Description:    "Expecting a single x-amz-checksum- header. Multiple checksum Types are not allowed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectAttributes: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid attribute name specified.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrServiceUnavailable: {
+		Code:           "ServiceUnavailable",
+		Description:    "Reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
 	/// Minio extensions.
 	ErrStorageFull: {
 		Code:           "XMinioStorageFull",
 		Description:    "Storage backend has reached its minimum free disk threshold. Please delete few objects to proceed.",
 		HTTPStatusCode: http.StatusInternalServerError,
 	},
+	ErrClientDisconnected: {
+		Code:        "XMinioClientDisconnected",
+		Description: "Client disconnected before the request was completed.",
+		// Non-standard status used by nginx for the same condition.
+		HTTPStatusCode: 499,
+	},
 	ErrObjectExistsAsDirectory: {
 		Code:           "XMinioObjectExistsAsDirectory",
 		Description:    "Object name already exists as a directory.",
@@ -421,6 +675,131 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Policy nesting conflict has occurred.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrKMSNotConfigured: {
+		Code:           "XMinioKMSNotConfigured",
+		Description:    "Server side encryption requires a key management service to be configured.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrKMSKeyNotFound: {
+		Code:           "KMS.NotFoundException",
+		Description:    "The master key was not found by the key management service.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectEncryptionKey: {
+		Code:           "XMinioObjectEncryptionKey",
+		Description:    "Unable to retrieve the encryption key of the object from the key management service.",
+		HTTPStatusCode: http.StatusInternalServerError,
+	},
+	ErrAdminInvalidConfig: {
+		Code:           "XMinioAdminInvalidConfig",
+		Description:    "The configuration provided is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidProfilerType: {
+		Code:           "XMinioAdminInvalidProfilerType",
+		Description:    "The profiler type provided is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminProfilerNotEnabled: {
+		Code:           "XMinioAdminProfilerNotEnabled",
+		Description:    "Profiling needs to be started before its data can be downloaded.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchTrashConfig: {
+		Code:           "XMinioAdminNoSuchTrashConfig",
+		Description:    "The trash configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminNoSuchIndexConfig: {
+		Code:           "XMinioAdminNoSuchIndexConfig",
+		Description:    "The index page configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminNoSuchTrashEntry: {
+		Code:           "XMinioAdminNoSuchTrashEntry",
+		Description:    "The deleted object was not found in the trash.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminTrashObjectExists: {
+		Code:           "XMinioAdminTrashObjectExists",
+		Description:    "An object with the same name exists, the deleted object cannot be restored.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminInvalidServerMode: {
+		Code:           "XMinioAdminInvalidServerMode",
+		Description:    "The server mode provided is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminRebalanceNotSupported: {
+		Code:           "XMinioAdminRebalanceNotSupported",
+		Description:    "Rebalancing is only supported by servers with multiple erasure sets.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminRebalanceRunning: {
+		Code:           "XMinioAdminRebalanceRunning",
+		Description:    "A rebalance is already running.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminRebalanceNotRunning: {
+		Code:           "XMinioAdminRebalanceNotRunning",
+		Description:    "There is no running or paused rebalance.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminKMSRotationRunning: {
+		Code:           "XMinioAdminKMSRotationRunning",
+		Description:    "A key rotation is already running.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminKMSRotationNotRunning: {
+		Code:           "XMinioAdminKMSRotationNotRunning",
+		Description:    "There is no running, paused or failed key rotation.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminHealNotSupported: {
+		Code:           "XMinioAdminHealNotSupported",
+		Description:    "Healing is only supported by erasure coded servers.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminHealRunning: {
+		Code:           "XMinioAdminHealRunning",
+		Description:    "A full heal is already running.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminHealNotRunning: {
+		Code:           "XMinioAdminHealNotRunning",
+		Description:    "There is no running, paused or failed full heal.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrAdminInvalidDecommission: {
+		Code:           "XMinioAdminInvalidDecommission",
+		Description:    "The erasure set does not exist, was removed or is the last set objects can be placed on.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidSmallObjectSets: {
+		Code:           "XMinioAdminInvalidSmallObjectSets",
+		Description:    "The small object sets do not exist, were removed or leave no set for large objects, or the size is not positive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminConfigBundleSecrets: {
+		Code:           "XMinioAdminConfigBundleSecrets",
+		Description:    "The secrets of the config bundle cannot be decrypted with the provided passphrase.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrServerReadOnly: {
+		Code:           "XMinioServerReadOnly",
+		Description:    "Server is in read-only mode, writes are not allowed.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrServerMaintenance: {
+		Code:           "XMinioServerMaintenance",
+		Description:    "Server is in maintenance mode, writes are not allowed.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrCorruptedFormat: {
+		Code:           "XMinioCorruptedFormat",
+		Description:    "Storage backend format is corrupted or the disks are not formatted.",
+		HTTPStatusCode: http.StatusInternalServerError,
+	},
 	// Add your error structure here.
 }
 
@@ -435,6 +814,64 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 	if err == errSignatureMismatch {
 		return ErrSignatureDoesNotMatch
 	}
+	// Verify if the underlying error is payload checksum mismatch.
+	if err == errContentSHA256Mismatch {
+		return ErrContentSHA256Mismatch
+	}
+	// Storage errors which reached the handlers without being
+	// converted by toObjectErr, SDKs retry based on these codes.
+	switch err {
+	case errVolumeNotFound:
+		return ErrNoSuchBucket
+	case errVolumeNotEmpty:
+		return ErrBucketNotEmpty
+	case errVolumeExists:
+		return ErrBucketAlreadyOwnedByYou
+	case errVolumeAccessDenied:
+		return ErrAccessDenied
+	case errFileNotFound, errFileNameTooLong, errFileNameInvalid:
+		return ErrNoSuchKey
+	case errIsNotRegular, errFileAccessDenied:
+		return ErrObjectExistsAsDirectory
+	case errDiskFull:
+		return ErrStorageFull
+	case errXLReadQuorum:
+		return ErrReadQuorum
+	case errXLWriteQuorum:
+		return ErrWriteQuorum
+	case errDiskNotFound, errFaultyDisk, errSomeDiskOffline:
+		return ErrServiceUnavailable
+	case errCorruptedFormat, errUnformattedDisk:
+		return ErrCorruptedFormat
+	case io.ErrUnexpectedEOF, io.ErrShortWrite:
+		return ErrIncompleteBody
+	case errKMSNotConfigured:
+		return ErrKMSNotConfigured
+	case errKMSKeyNotFound:
+		return ErrKMSKeyNotFound
+	case errEncryptionNotSupported:
+		return ErrNotImplemented
+	case errKMSRotationRunning:
+		return ErrAdminKMSRotationRunning
+	case errKMSRotationNotRunning:
+		return ErrAdminKMSRotationNotRunning
+	case errFullHealRunning:
+		return ErrAdminHealRunning
+	case errFullHealNotRunning:
+		return ErrAdminHealNotRunning
+	case errRebalanceRunning:
+		return ErrAdminRebalanceRunning
+	case errRebalanceNotRunning:
+		return ErrAdminRebalanceNotRunning
+	case errInvalidDecommission:
+		return ErrAdminInvalidDecommission
+	case errInvalidSmallObjectSets:
+		return ErrAdminInvalidSmallObjectSets
+	case errConfigBundleSecrets:
+		return ErrAdminConfigBundleSecrets
+	case context.Canceled:
+		return ErrClientDisconnected
+	}
 	switch err.(type) {
 	case StorageFull:
 		apiErr = ErrStorageFull
@@ -452,20 +889,48 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrBucketNotEmpty
 	case BucketExists:
 		apiErr = ErrBucketAlreadyOwnedByYou
+	case BucketAlreadyExists:
+		apiErr = ErrBucketAlreadyExists
 	case ObjectNotFound:
 		apiErr = ErrNoSuchKey
 	case ObjectNameInvalid:
 		apiErr = ErrNoSuchKey
-	case InvalidUploadID:
+	case UnExpectedDataSize:
+		apiErr = ErrIncompleteBody
+	case InvalidUploadID, MalformedUploadID:
 		apiErr = ErrNoSuchUpload
 	case InvalidPart:
 		apiErr = ErrInvalidPart
+	case InvalidPartOrder:
+		apiErr = ErrInvalidPartOrder
 	case InsufficientWriteQuorum:
 		apiErr = ErrWriteQuorum
 	case InsufficientReadQuorum:
 		apiErr = ErrReadQuorum
 	case PartTooSmall:
 		apiErr = ErrEntityTooSmall
+	case ObjectTooLarge:
+		apiErr = ErrObjectTooLarge
+	case ObjectTooManyAppends:
+		apiErr = ErrInvalidObjectState
+	case UnsupportedDelimiter, InvalidUploadIDKeyCombination, InvalidMarkerPrefixCombination:
+		apiErr = ErrNotImplemented
+	case BucketPolicyNotFound:
+		apiErr = ErrNoSuchBucketPolicy
+	case BucketObjectLockConfigNotFound:
+		apiErr = ErrNoSuchObjectLockConfiguration
+	case BucketEncryptionConfigNotFound:
+		apiErr = ErrNoSuchBucketEncryptionConfiguration
+	case BucketReplicationConfigNotFound:
+		apiErr = ErrNoSuchReplicationConfiguration
+	case BucketTrashConfigNotFound:
+		apiErr = ErrAdminNoSuchTrashConfig
+	case BucketIndexConfigNotFound:
+		apiErr = ErrAdminNoSuchIndexConfig
+	case TrashEntryNotFound:
+		apiErr = ErrAdminNoSuchTrashEntry
+	case TrashObjectExists:
+		apiErr = ErrAdminTrashObjectExists
 	default:
 		apiErr = ErrInternalError
 	}
@@ -474,21 +939,29 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 
 // getAPIError provides API Error for input API error code.
 func getAPIError(code APIErrorCode) APIError {
-	return errorCodeResponse[code]
+	apiErr := errorCodeResponse[code]
+	switch code {
+	case ErrObjectTooLarge:
+		apiErr.Description = fmt.Sprintf(apiErr.Description, getLimitsConfig().MaxObjectSize)
+	case ErrPartTooLarge:
+		apiErr.Description = fmt.Sprintf(apiErr.Description, getLimitsConfig().MaxPartSize)
+	case ErrInvalidPartNumber:
+		apiErr.Description = fmt.Sprintf(apiErr.Description, getLimitsConfig().MaxParts)
+	}
+	return apiErr
 }
 
-// getErrorResponse gets in standard error and resource value and
-// provides a encodable populated response values
-func getAPIErrorResponse(err APIError, resource string) APIErrorResponse {
+// getErrorResponse gets in standard error, resource and request ID
+// values and provides a encodable populated response values
+func getAPIErrorResponse(err APIError, resource, requestID string) APIErrorResponse {
 	var data = APIErrorResponse{}
 	data.Code = err.Code
 	data.Message = err.Description
 	if resource != "" {
 		data.Resource = resource
 	}
-	// TODO implement this in future
-	data.RequestID = "3L137"
-	data.HostID = "3L137"
+	data.RequestID = requestID
+	data.HostID = globalHostID
 
 	return data
 }
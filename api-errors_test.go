@@ -0,0 +1,65 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// Tests internal errors are translated to the expected S3 error codes.
+func TestToAPIErrorCode(t *testing.T) {
+	testCases := []struct {
+		err        error
+		code       string
+		statusCode int
+	}{
+		// Storage errors.
+		{errVolumeNotFound, "NoSuchBucket", http.StatusNotFound},
+		{errVolumeNotEmpty, "BucketNotEmpty", http.StatusConflict},
+		{errFileNotFound, "NoSuchKey", http.StatusNotFound},
+		{errFileNameTooLong, "NoSuchKey", http.StatusNotFound},
+		{errDiskFull, "XMinioStorageFull", http.StatusInternalServerError},
+		{errXLReadQuorum, "XMinioReadQuorum", http.StatusServiceUnavailable},
+		{errXLWriteQuorum, "XMinioWriteQuorum", http.StatusServiceUnavailable},
+		{errDiskNotFound, "ServiceUnavailable", http.StatusServiceUnavailable},
+		{errFaultyDisk, "ServiceUnavailable", http.StatusServiceUnavailable},
+		{errCorruptedFormat, "XMinioCorruptedFormat", http.StatusInternalServerError},
+		{errUnformattedDisk, "XMinioCorruptedFormat", http.StatusInternalServerError},
+		{io.ErrUnexpectedEOF, "IncompleteBody", http.StatusBadRequest},
+		// Object layer errors.
+		{toObjectErr(errFileNameTooLong, "bucket", "object"), "NoSuchKey", http.StatusNotFound},
+		{toObjectErr(errXLReadQuorum), "XMinioReadQuorum", http.StatusServiceUnavailable},
+		{BucketPolicyNotFound{Bucket: "bucket"}, "NoSuchBucketPolicy", http.StatusNotFound},
+		{MalformedUploadID{UploadID: "id"}, "NoSuchUpload", http.StatusNotFound},
+		{InvalidPartOrder{UploadID: "id"}, "InvalidPartOrder", http.StatusBadRequest},
+		{UnsupportedDelimiter{Delimiter: "#"}, "NotImplemented", http.StatusNotImplemented},
+		// Unknown errors.
+		{errors.New("unknown"), "InternalError", http.StatusInternalServerError},
+	}
+	for i, testCase := range testCases {
+		apiErr := getAPIError(toAPIErrorCode(testCase.err))
+		if apiErr.Code != testCase.code {
+			t.Errorf("Test %d: Expected code %s, but instead found %s", i+1, testCase.code, apiErr.Code)
+		}
+		if apiErr.HTTPStatusCode != testCase.statusCode {
+			t.Errorf("Test %d: Expected status %d, but instead found %d", i+1, testCase.statusCode, apiErr.HTTPStatusCode)
+		}
+	}
+}
@@ -18,11 +18,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/xml"
+	"fmt"
 	"net/http"
+	"os"
 	"runtime"
 	"strconv"
+	"strings"
 )
 
 //// helpers
@@ -40,10 +46,52 @@ func generateRequestID() []byte {
 	return alpha
 }
 
+// globalHostID - identifies the host in the X-Amz-Id-2 header and in
+// error responses, derived from the host name to stay the same across
+// restarts.
+var globalHostID = generateHostID()
+
+// generateHostID - Generate host id
+func generateHostID() string {
+	hostName, _ := os.Hostname()
+	sum := sha256.Sum256([]byte(hostName))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// requestIDKey - context key of the request ID.
+type requestIDKey struct{}
+
+// getRequestID - returns the request ID assigned by the request ID
+// handler, empty if the request was not served through it.
+func getRequestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// requestIDHandler - assigns a unique request ID to every request,
+// returned in the X-Amz-Request-Id header and logged with errors,
+// traces and audit entries.
+type requestIDHandler struct {
+	handler http.Handler
+}
+
+func setRequestIDHandler(h http.Handler) http.Handler {
+	return requestIDHandler{h}
+}
+
+func (h requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := string(generateRequestID())
+	w.Header().Set("X-Amz-Request-Id", requestID)
+	h.handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID)))
+}
+
 // Write http common headers
 func setCommonHeaders(w http.ResponseWriter) {
-	// Set unique request ID for each reply.
-	w.Header().Set("X-Amz-Request-Id", string(generateRequestID()))
+	// Set unique request ID for each reply, unless already assigned.
+	if w.Header().Get("X-Amz-Request-Id") == "" {
+		w.Header().Set("X-Amz-Request-Id", string(generateRequestID()))
+	}
+	w.Header().Set("X-Amz-Id-2", globalHostID)
 	w.Header().Set("Server", ("Minio/" + minioReleaseTag + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"))
 	w.Header().Set("Accept-Ranges", "bytes")
 }
@@ -57,6 +105,64 @@ func encodeResponse(response interface{}) []byte {
 	return bytesBuffer.Bytes()
 }
 
+// xmlControlMark - private use character marking control characters
+// in listed names, encoding/xml replaces them by U+FFFD otherwise.
+const xmlControlMark = '\uF8FF'
+
+// markXMLControlChars - returns name with the control characters XML
+// text cannot carry replaced by xmlControlMark followed by the
+// character moved into the printable range, marks in name are doubled.
+func markXMLControlChars(name string) string {
+	if strings.IndexFunc(name, func(r rune) bool {
+		return r == xmlControlMark || r < 0x20 && r != '\t' && r != '\n' && r != '\r'
+	}) == -1 {
+		return name
+	}
+	var marked strings.Builder
+	for _, r := range name {
+		switch {
+		case r == xmlControlMark:
+			marked.WriteRune(xmlControlMark)
+			marked.WriteRune(xmlControlMark)
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r':
+			marked.WriteRune(xmlControlMark)
+			marked.WriteRune(r + 0x40)
+		default:
+			marked.WriteRune(r)
+		}
+	}
+	return marked.String()
+}
+
+// encodeListResponse - like encodeResponse for responses with names
+// marked by markXMLControlChars, control characters are written as
+// character references like S3 does.
+func encodeListResponse(response interface{}) []byte {
+	encoded := encodeResponse(response)
+	mark := string(xmlControlMark)
+	if !bytes.Contains(encoded, []byte(mark)) {
+		return encoded
+	}
+	var unmarked bytes.Buffer
+	for s := string(encoded); ; {
+		i := strings.Index(s, mark)
+		if i == -1 || i+len(mark) >= len(s) {
+			unmarked.WriteString(s)
+			break
+		}
+		unmarked.WriteString(s[:i])
+		s = s[i+len(mark):]
+		if strings.HasPrefix(s, mark) {
+			unmarked.WriteString(mark)
+			s = s[len(mark):]
+			continue
+		}
+		fmt.Fprintf(&unmarked, "&#x%X;", s[0]-0x40)
+		s = s[1:]
+	}
+	return unmarked.Bytes()
+}
+
 // Write object header
 func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *httpRange) {
 	// set common headers
@@ -17,7 +17,13 @@
 package main
 
 import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	router "github.com/gorilla/mux"
 )
 
 func TestGenerateRequestID(t *testing.T) {
@@ -38,3 +44,66 @@ func TestGenerateRequestID(t *testing.T) {
 		}
 	}
 }
+
+// Tests error responses carry the request ID, host ID and the resource
+// they refer to.
+func TestErrorResponseRequestID(t *testing.T) {
+	mux := router.NewRouter()
+	mux.Methods("GET").Path("/{bucket}/{object:.+}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
+	})
+	handler := setRequestIDHandler(mux)
+
+	req, err := http.NewRequest("GET", "http://localhost:9000/bucket/dir/object", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	errorResponse := APIErrorResponse{}
+	if err = xml.NewDecoder(rec.Body).Decode(&errorResponse); err != nil {
+		t.Fatal(err)
+	}
+	requestID := rec.Header().Get("X-Amz-Request-Id")
+	if requestID == "" || errorResponse.RequestID != requestID {
+		t.Errorf("Expected request ID %s, but instead found %s", requestID, errorResponse.RequestID)
+	}
+	if errorResponse.HostID != globalHostID || rec.Header().Get("X-Amz-Id-2") != globalHostID {
+		t.Errorf("Expected host ID %s, but instead found %s", globalHostID, errorResponse.HostID)
+	}
+	if errorResponse.Resource != "/bucket/dir/object" {
+		t.Errorf("Expected resource /bucket/dir/object, but instead found %s", errorResponse.Resource)
+	}
+	if errorResponse.BucketName != "bucket" || errorResponse.Key != "dir/object" {
+		t.Errorf("Expected bucket and key bucket/dir/object, but instead found %s/%s", errorResponse.BucketName, errorResponse.Key)
+	}
+}
+
+// Tests listed keys are URL encoded on request and control characters
+// are written as character references otherwise.
+func TestEncodeListResponse(t *testing.T) {
+	resp := ListObjectsInfo{
+		Objects:  []ObjectInfo{{Name: "a\x01b"}, {Name: "c\uf8ffd"}, {Name: "e f+g/ä\t"}},
+		Prefixes: []string{"dir\x1f/"},
+	}
+	encoded := string(encodeListResponse(generateListObjectsResponse("bucket", "", "", "/", 1000, "", resp)))
+	for _, expected := range []string{"<Key>a&#x1;b</Key>", "<Key>c\uf8ffd</Key>", "<Key>e f+g/ä&#x9;</Key>", "<Prefix>dir&#x1F;/</Prefix>", "<EncodingType></EncodingType>"} {
+		if !strings.Contains(encoded, expected) {
+			t.Errorf("Expected %q in %q", expected, encoded)
+		}
+	}
+
+	encoded = string(encodeListResponse(generateListObjectsV2Response("bucket", "e f", "", "", "/", false, 1000, "url", resp)))
+	for _, expected := range []string{"<Key>a%01b</Key>", "<Key>c%EF%A3%BFd</Key>", "<Key>e%20f%2Bg/%C3%A4%09</Key>", "<Prefix>dir%1F/</Prefix>", "<Prefix>e%20f</Prefix>", "<EncodingType>url</EncodingType>"} {
+		if !strings.Contains(encoded, expected) {
+			t.Errorf("Expected %q in %q", expected, encoded)
+		}
+	}
+
+	uploads := ListMultipartsInfo{Uploads: []uploadMetadata{{Object: "a b", UploadID: "id"}}}
+	encoded = string(encodeListResponse(generateListMultipartUploadsResponse("bucket", "url", uploads)))
+	if !strings.Contains(encoded, "<Key>a%20b</Key>") {
+		t.Errorf("Expected URL encoded key in %q", encoded)
+	}
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"encoding/base64"
 	"net/url"
 	"strconv"
 )
@@ -36,7 +37,7 @@ func getListObjectsV1Args(values url.Values) (prefix, marker, delimiter string,
 }
 
 // Parse bucket url queries for ListObjects V2.
-func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, maxkeys int, encodingType string) {
+func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, fetchOwner bool, maxkeys int, encodingType string) {
 	prefix = values.Get("prefix")
 	startAfter = values.Get("start-after")
 	delimiter = values.Get("delimiter")
@@ -47,9 +48,24 @@ func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimit
 	}
 	encodingType = values.Get("encoding-type")
 	token = values.Get("continuation-token")
+	fetchOwner = values.Get("fetch-owner") == "true"
 	return
 }
 
+// ListObjectsV2 continuation tokens are opaque to clients, the marker
+// of the next listing is base64 encoded.
+
+// encodeContinuationToken - returns the continuation token of marker.
+func encodeContinuationToken(marker string) string {
+	return base64.StdEncoding.EncodeToString([]byte(marker))
+}
+
+// decodeContinuationToken - returns the marker of token.
+func decodeContinuationToken(token string) (string, error) {
+	marker, err := base64.StdEncoding.DecodeString(token)
+	return string(marker), err
+}
+
 // Parse bucket url queries
 func getBucketResources(values url.Values) (listType int, prefix, marker, delimiter string, maxkeys int, encodingType string) {
 	if values.Get("list-type") != "" {
@@ -18,9 +18,13 @@ package main
 
 import (
 	"encoding/xml"
+	"fmt"
 	"net/http"
 	"path"
+	"strings"
 	"time"
+
+	mux "github.com/gorilla/mux"
 )
 
 const (
@@ -28,6 +32,7 @@ const (
 	maxObjectList  = 1000                       // Limit number of objects in a listObjectsResponse.
 	maxUploadsList = 1000                       // Limit number of uploads in a listUploadsResponse.
 	maxPartsList   = 1000                       // Limit number of parts in a listPartsResponse.
+	maxDeleteList  = 1000                       // Limit number of objects deleted in a deleteObjectsRequest.
 )
 
 // LocationResponse - format for location response.
@@ -81,10 +86,13 @@ type ListObjectsV2Response struct {
 	// A flag that indicates whether or not ListObjects returned all of the results
 	// that satisfied the search criteria.
 	IsTruncated bool
-	StartAfter  string
+	StartAfter  string `xml:",omitempty"`
 	MaxKeys     int
 	Name        string
 
+	// Number of keys and common prefixes in the response.
+	KeyCount int
+
 	// When response is truncated (the IsTruncated element value in the response
 	// is true), you can use the key name in this field as marker in the subsequent
 	// request to get next set of objects. Server lists objects in alphabetical
@@ -92,8 +100,8 @@ type ListObjectsV2Response struct {
 	// specified. If response does not include the NextMaker and it is truncated,
 	// you can use the value of the last Key in the response as the marker in the
 	// subsequent request to get the next set of object keys.
-	ContinuationToken     string
-	NextContinuationToken string
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
 	Prefix                string
 }
 
@@ -175,6 +183,17 @@ type CommonPrefix struct {
 type Bucket struct {
 	Name         string
 	CreationDate string // time string of format "2006-01-02T15:04:05.000Z"
+
+	// Only set if requested and the bucket was crawled already.
+	Usage *BucketUsage `xml:",omitempty"`
+}
+
+// BucketUsage container for the object count and total size of a
+// bucket, as computed by the last data usage crawl.
+type BucketUsage struct {
+	ObjectsCount uint64
+	Size         uint64
+	LastUpdate   string // time string of format "2006-01-02T15:04:05.000Z"
 }
 
 // Object container for object metadata
@@ -184,7 +203,8 @@ type Object struct {
 	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
 	Size         int64
 
-	Owner Owner
+	// Not set for ListObjectsV2 unless requested.
+	Owner *Owner `xml:",omitempty"`
 
 	// The class of storage used to store the object.
 	StorageClass string
@@ -291,8 +311,48 @@ func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 	return data
 }
 
+// setListBucketsUsage - adds the usage computed by the last data usage
+// crawl to the buckets of a ListBuckets response, buckets created after
+// the crawl have no usage.
+func setListBucketsUsage(data *ListBucketsResponse, usage dataUsageInfo) {
+	if usage.LastUpdate.IsZero() {
+		return
+	}
+	for i, bucket := range data.Buckets.Buckets {
+		bucketUsage, ok := usage.Buckets[bucket.Name]
+		if !ok {
+			continue
+		}
+		data.Buckets.Buckets[i].Usage = &BucketUsage{
+			ObjectsCount: bucketUsage.ObjectsCount,
+			Size:         bucketUsage.Size,
+			LastUpdate:   usage.LastUpdate.Format(timeFormatAMZ),
+		}
+	}
+}
+
+// s3EncodeName - encodes a key, prefix, marker or delimiter of a
+// listing response. With encoding type "url" all but unreserved
+// characters and "/" are percent-encoded, otherwise characters XML
+// text cannot carry are marked for encodeListResponse.
+func s3EncodeName(name, encodingType string) string {
+	if encodingType != "url" {
+		return markXMLControlChars(name)
+	}
+	var encoded strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) != -1 {
+			encoded.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", c)
+	}
+	return encoded.String()
+}
+
 // generates an ListObjects response for the said bucket with other enumerated options.
-func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
+func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKeys int, encodingType string, resp ListObjectsInfo) ListObjectsResponse {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
@@ -306,30 +366,30 @@ func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKe
 		if object.Name == "" {
 			continue
 		}
-		content.Key = object.Name
+		content.Key = s3EncodeName(object.Name, encodingType)
 		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZ)
 		if object.MD5Sum != "" {
 			content.ETag = "\"" + object.MD5Sum + "\""
 		}
 		content.Size = object.Size
 		content.StorageClass = "STANDARD"
-		content.Owner = owner
+		content.Owner = &owner
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 
-	data.Prefix = prefix
-	data.Marker = marker
-	data.Delimiter = delimiter
+	data.EncodingType = encodingType
+	data.Prefix = s3EncodeName(prefix, encodingType)
+	data.Marker = s3EncodeName(marker, encodingType)
+	data.Delimiter = s3EncodeName(delimiter, encodingType)
 	data.MaxKeys = maxKeys
 
-	data.NextMarker = resp.NextMarker
+	data.NextMarker = s3EncodeName(resp.NextMarker, encodingType)
 	data.IsTruncated = resp.IsTruncated
 	for _, prefix := range resp.Prefixes {
 		var prefixItem = CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = s3EncodeName(prefix, encodingType)
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
@@ -337,7 +397,7 @@ func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKe
 }
 
 // generates an ListObjects response for the said bucket with other enumerated options.
-func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsV2Response {
+func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter string, fetchOwner bool, maxKeys int, encodingType string, resp ListObjectsInfo) ListObjectsV2Response {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
@@ -351,33 +411,43 @@ func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter
 		if object.Name == "" {
 			continue
 		}
-		content.Key = object.Name
+		content.Key = s3EncodeName(object.Name, encodingType)
 		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZ)
 		if object.MD5Sum != "" {
 			content.ETag = "\"" + object.MD5Sum + "\""
 		}
 		content.Size = object.Size
 		content.StorageClass = "STANDARD"
-		content.Owner = owner
+		if fetchOwner {
+			content.Owner = &owner
+		}
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 
-	data.StartAfter = startAfter
-	data.Delimiter = delimiter
-	data.Prefix = prefix
+	data.EncodingType = encodingType
+	data.StartAfter = s3EncodeName(startAfter, encodingType)
+	data.Delimiter = s3EncodeName(delimiter, encodingType)
+	data.Prefix = s3EncodeName(prefix, encodingType)
 	data.MaxKeys = maxKeys
-	data.ContinuationToken = token
-	data.NextContinuationToken = resp.NextMarker
+	data.ContinuationToken = markXMLControlChars(token)
 	data.IsTruncated = resp.IsTruncated
+	if resp.IsTruncated {
+		// Object layers set NextMarker only for delimited listings.
+		nextMarker := resp.NextMarker
+		if nextMarker == "" && len(resp.Objects) > 0 {
+			nextMarker = resp.Objects[len(resp.Objects)-1].Name
+		}
+		data.NextContinuationToken = encodeContinuationToken(nextMarker)
+	}
 	for _, prefix := range resp.Prefixes {
 		var prefixItem = CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = s3EncodeName(prefix, encodingType)
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
+	data.KeyCount = len(data.Contents) + len(data.CommonPrefixes)
 	return data
 }
 
@@ -410,11 +480,10 @@ func generateCompleteMultpartUploadResponse(bucket, key, location, etag string)
 
 // generateListPartsResult
 func generateListPartsResponse(partsInfo ListPartsInfo) ListPartsResponse {
-	// TODO - support EncodingType in xml decoding
 	listPartsResponse := ListPartsResponse{}
 	listPartsResponse.Bucket = partsInfo.Bucket
-	listPartsResponse.Key = partsInfo.Object
-	listPartsResponse.UploadID = partsInfo.UploadID
+	listPartsResponse.Key = s3EncodeName(partsInfo.Object, "")
+	listPartsResponse.UploadID = markXMLControlChars(partsInfo.UploadID)
 	listPartsResponse.StorageClass = "STANDARD"
 	listPartsResponse.Initiator.ID = "minio"
 	listPartsResponse.Initiator.DisplayName = "minio"
@@ -439,29 +508,29 @@ func generateListPartsResponse(partsInfo ListPartsInfo) ListPartsResponse {
 }
 
 // generateListMultipartUploadsResponse
-func generateListMultipartUploadsResponse(bucket string, multipartsInfo ListMultipartsInfo) ListMultipartUploadsResponse {
+func generateListMultipartUploadsResponse(bucket, encodingType string, multipartsInfo ListMultipartsInfo) ListMultipartUploadsResponse {
 	listMultipartUploadsResponse := ListMultipartUploadsResponse{}
 	listMultipartUploadsResponse.Bucket = bucket
-	listMultipartUploadsResponse.Delimiter = multipartsInfo.Delimiter
+	listMultipartUploadsResponse.Delimiter = s3EncodeName(multipartsInfo.Delimiter, encodingType)
 	listMultipartUploadsResponse.IsTruncated = multipartsInfo.IsTruncated
-	listMultipartUploadsResponse.EncodingType = multipartsInfo.EncodingType
-	listMultipartUploadsResponse.Prefix = multipartsInfo.Prefix
-	listMultipartUploadsResponse.KeyMarker = multipartsInfo.KeyMarker
-	listMultipartUploadsResponse.NextKeyMarker = multipartsInfo.NextKeyMarker
+	listMultipartUploadsResponse.EncodingType = encodingType
+	listMultipartUploadsResponse.Prefix = s3EncodeName(multipartsInfo.Prefix, encodingType)
+	listMultipartUploadsResponse.KeyMarker = s3EncodeName(multipartsInfo.KeyMarker, encodingType)
+	listMultipartUploadsResponse.NextKeyMarker = s3EncodeName(multipartsInfo.NextKeyMarker, encodingType)
 	listMultipartUploadsResponse.MaxUploads = multipartsInfo.MaxUploads
-	listMultipartUploadsResponse.NextUploadIDMarker = multipartsInfo.NextUploadIDMarker
-	listMultipartUploadsResponse.UploadIDMarker = multipartsInfo.UploadIDMarker
+	listMultipartUploadsResponse.NextUploadIDMarker = markXMLControlChars(multipartsInfo.NextUploadIDMarker)
+	listMultipartUploadsResponse.UploadIDMarker = markXMLControlChars(multipartsInfo.UploadIDMarker)
 	listMultipartUploadsResponse.CommonPrefixes = make([]CommonPrefix, len(multipartsInfo.CommonPrefixes))
 	for index, commonPrefix := range multipartsInfo.CommonPrefixes {
 		listMultipartUploadsResponse.CommonPrefixes[index] = CommonPrefix{
-			Prefix: commonPrefix,
+			Prefix: s3EncodeName(commonPrefix, encodingType),
 		}
 	}
 	listMultipartUploadsResponse.Uploads = make([]Upload, len(multipartsInfo.Uploads))
 	for index, upload := range multipartsInfo.Uploads {
 		newUpload := Upload{}
 		newUpload.UploadID = upload.UploadID
-		newUpload.Key = upload.Object
+		newUpload.Key = s3EncodeName(upload.Object, encodingType)
 		newUpload.Initiated = upload.Initiated.UTC().Format(timeFormatAMZ)
 		listMultipartUploadsResponse.Uploads[index] = newUpload
 	}
@@ -506,8 +575,18 @@ func writeErrorResponse(w http.ResponseWriter, req *http.Request, errorCode APIE
 }
 
 func writeErrorResponseNoHeader(w http.ResponseWriter, req *http.Request, error APIError, resource string) {
-	// generate error response
-	errorResponse := getAPIErrorResponse(error, resource)
+	// generate error response, the request ID was set along with the
+	// common headers.
+	errorResponse := getAPIErrorResponse(error, resource, w.Header().Get("X-Amz-Request-Id"))
+	// Name the bucket and object the error refers to.
+	vars := mux.Vars(req)
+	errorResponse.BucketName = vars["bucket"]
+	errorResponse.Key = vars["object"]
+	// Region errors report the region the request has to be signed for.
+	if error.Code == errorCodeResponse[ErrAuthorizationHeaderMalformed].Code {
+		errorResponse.Region = getRequestRegion(req)
+		errorResponse.Message = fmt.Sprintf("The authorization header is malformed; the region is wrong; expecting '%s'.", errorResponse.Region)
+	}
 	encodedErrorResponse := encodeResponse(errorResponse)
 	// HEAD should have no body, do not attempt to write to it
 	if req.Method != "HEAD" {
@@ -34,53 +34,87 @@ func registerAPIRouter(mux *router.Router, api objectAPIHandlers) {
 	/// Object operations
 
 	// HeadObject
-	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(api.HeadObjectHandler)
+	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(collectAPIStats("HeadObject", api.HeadObjectHandler))
 	// PutObjectPart
-	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(collectAPIStats("PutObjectPart", api.PutObjectPartHandler)).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	// ListObjectPxarts
-	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.ListObjectPartsHandler).Queries("uploadId", "{uploadId:.*}")
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(collectAPIStats("ListObjectParts", api.ListObjectPartsHandler)).Queries("uploadId", "{uploadId:.*}")
 	// CompleteMultipartUpload
-	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.CompleteMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(collectAPIStats("CompleteMultipartUpload", api.CompleteMultipartUploadHandler)).Queries("uploadId", "{uploadId:.*}")
 	// NewMultipartUpload
-	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.NewMultipartUploadHandler).Queries("uploads", "")
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(collectAPIStats("NewMultipartUpload", api.NewMultipartUploadHandler)).Queries("uploads", "")
+	// SelectObjectContent
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(collectAPIStats("SelectObjectContent", api.SelectObjectContentHandler)).Queries("select", "", "select-type", "2")
+	// RestoreObject
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(collectAPIStats("RestoreObject", api.RestoreObjectHandler)).Queries("restore", "")
 	// AbortMultipartUpload
-	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.AbortMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(collectAPIStats("AbortMultipartUpload", api.AbortMultipartUploadHandler)).Queries("uploadId", "{uploadId:.*}")
+	// GetObjectRetention
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(collectAPIStats("GetObjectRetention", api.GetObjectRetentionHandler)).Queries("retention", "")
+	// GetObjectLegalHold
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(collectAPIStats("GetObjectLegalHold", api.GetObjectLegalHoldHandler)).Queries("legal-hold", "")
+	// GetObjectAttributes
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(collectAPIStats("GetObjectAttributes", api.GetObjectAttributesHandler)).Queries("attributes", "")
+	// GetObjectACL
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(collectAPIStats("GetObjectACL", api.GetObjectACLHandler)).Queries("acl", "")
 	// GetObject
-	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectHandler)
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(collectAPIStats("GetObject", api.GetObjectHandler))
 	// CopyObject
-	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/).*?").HandlerFunc(api.CopyObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/).*?").HandlerFunc(collectAPIStats("CopyObject", api.CopyObjectHandler))
+	// PutObjectRetention
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(collectAPIStats("PutObjectRetention", api.PutObjectRetentionHandler)).Queries("retention", "")
+	// PutObjectLegalHold
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(collectAPIStats("PutObjectLegalHold", api.PutObjectLegalHoldHandler)).Queries("legal-hold", "")
+	// PutObjectACL
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(collectAPIStats("PutObjectACL", api.PutObjectACLHandler)).Queries("acl", "")
+	// AppendObject
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(collectAPIStats("AppendObject", api.AppendObjectHandler)).Queries("append", "")
 	// PutObject
-	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(collectAPIStats("PutObject", api.PutObjectHandler))
 	// DeleteObject
-	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.DeleteObjectHandler)
+	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(collectAPIStats("DeleteObject", api.DeleteObjectHandler))
 
 	/// Bucket operations
 
 	// GetBucketLocation
-	bucket.Methods("GET").HandlerFunc(api.GetBucketLocationHandler).Queries("location", "")
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("GetBucketLocation", api.GetBucketLocationHandler)).Queries("location", "")
 	// GetBucketPolicy
-	bucket.Methods("GET").HandlerFunc(api.GetBucketPolicyHandler).Queries("policy", "")
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("GetBucketPolicy", api.GetBucketPolicyHandler)).Queries("policy", "")
+	// GetBucketObjectLockConfig
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("GetBucketObjectLockConfig", api.GetBucketObjectLockConfigHandler)).Queries("object-lock", "")
+	// GetBucketEncryption
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("GetBucketEncryption", api.GetBucketEncryptionHandler)).Queries("encryption", "")
+	// GetBucketACL
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("GetBucketACL", api.GetBucketACLHandler)).Queries("acl", "")
 	// ListMultipartUploads
-	bucket.Methods("GET").HandlerFunc(api.ListMultipartUploadsHandler).Queries("uploads", "")
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("ListMultipartUploads", api.ListMultipartUploadsHandler)).Queries("uploads", "")
 	// ListObjects
-	bucket.Methods("GET").HandlerFunc(api.ListObjectsHandler)
+	bucket.Methods("GET").HandlerFunc(collectAPIStats("ListObjects", api.ListObjectsHandler))
 	// PutBucketPolicy
-	bucket.Methods("PUT").HandlerFunc(api.PutBucketPolicyHandler).Queries("policy", "")
+	bucket.Methods("PUT").HandlerFunc(collectAPIStats("PutBucketPolicy", api.PutBucketPolicyHandler)).Queries("policy", "")
+	// PutBucketObjectLockConfig
+	bucket.Methods("PUT").HandlerFunc(collectAPIStats("PutBucketObjectLockConfig", api.PutBucketObjectLockConfigHandler)).Queries("object-lock", "")
+	// PutBucketEncryption
+	bucket.Methods("PUT").HandlerFunc(collectAPIStats("PutBucketEncryption", api.PutBucketEncryptionHandler)).Queries("encryption", "")
+	// PutBucketACL
+	bucket.Methods("PUT").HandlerFunc(collectAPIStats("PutBucketACL", api.PutBucketACLHandler)).Queries("acl", "")
 	// PutBucket
-	bucket.Methods("PUT").HandlerFunc(api.PutBucketHandler)
+	bucket.Methods("PUT").HandlerFunc(collectAPIStats("PutBucket", api.PutBucketHandler))
 	// HeadBucket
-	bucket.Methods("HEAD").HandlerFunc(api.HeadBucketHandler)
+	bucket.Methods("HEAD").HandlerFunc(collectAPIStats("HeadBucket", api.HeadBucketHandler))
 	// PostPolicy
-	bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(api.PostPolicyBucketHandler)
+	bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(collectAPIStats("PostPolicyBucket", api.PostPolicyBucketHandler))
 	// DeleteMultipleObjects
-	bucket.Methods("POST").HandlerFunc(api.DeleteMultipleObjectsHandler)
+	bucket.Methods("POST").HandlerFunc(collectAPIStats("DeleteMultipleObjects", api.DeleteMultipleObjectsHandler)).Queries("delete", "")
+	// DeleteBucketEncryption
+	bucket.Methods("DELETE").HandlerFunc(collectAPIStats("DeleteBucketEncryption", api.DeleteBucketEncryptionHandler)).Queries("encryption", "")
 	// DeleteBucketPolicy
-	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketPolicyHandler).Queries("policy", "")
+	bucket.Methods("DELETE").HandlerFunc(collectAPIStats("DeleteBucketPolicy", api.DeleteBucketPolicyHandler)).Queries("policy", "")
 	// DeleteBucket
-	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketHandler)
+	bucket.Methods("DELETE").HandlerFunc(collectAPIStats("DeleteBucket", api.DeleteBucketHandler))
 
 	/// Root operation
 
 	// ListBuckets
-	apiRouter.Methods("GET").HandlerFunc(api.ListBucketsHandler)
+	apiRouter.Methods("GET").HandlerFunc(collectAPIStats("ListBuckets", api.ListBucketsHandler))
 }
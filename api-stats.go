@@ -0,0 +1,246 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	// API statistics stored in minioMetaBucket.
+	apiStatsFile = "api-stats.json"
+
+	// Maximum number of buckets statistics are kept for, requests for
+	// further buckets, which are mostly for buckets which do not
+	// exist, are only accounted per API.
+	maxAPIStatsBuckets = 10000
+)
+
+// Interval API statistics are saved at.
+var apiStatsSaveInterval = 5 * time.Minute
+
+// Upper bounds of the latency histogram buckets, the last bucket
+// counts all slower requests.
+var apiLatencyBounds = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// apiStatsEntry - request statistics of an API or a bucket.
+type apiStatsEntry struct {
+	Name          string        `json:"name"`
+	Requests      uint64        `json:"requests"`
+	ClientErrors  uint64        `json:"clientErrors"`
+	ServerErrors  uint64        `json:"serverErrors"`
+	TotalDuration time.Duration `json:"totalDuration"`
+	// Requests per latency histogram bucket.
+	Latency []uint64 `json:"latency"`
+}
+
+// add - accounts a request.
+func (e *apiStatsEntry) add(statusCode int, duration time.Duration) {
+	e.Requests++
+	if statusCode >= http.StatusInternalServerError {
+		e.ServerErrors++
+	} else if statusCode >= http.StatusBadRequest {
+		e.ClientErrors++
+	}
+	e.TotalDuration += duration
+	if len(e.Latency) != len(apiLatencyBounds)+1 {
+		e.Latency = make([]uint64, len(apiLatencyBounds)+1)
+	}
+	i := sort.Search(len(apiLatencyBounds), func(i int) bool {
+		return duration <= apiLatencyBounds[i]
+	})
+	e.Latency[i]++
+}
+
+// apiStatsInfo - request statistics per API and per bucket.
+type apiStatsInfo struct {
+	// Time statistics were first collected.
+	Since   time.Time                 `json:"since"`
+	APIs    map[string]*apiStatsEntry `json:"apis"`
+	Buckets map[string]*apiStatsEntry `json:"buckets"`
+}
+
+// apiStatsResponse - the busiest APIs or buckets.
+type apiStatsResponse struct {
+	Since time.Time `json:"since"`
+	// Upper bounds of the latency histogram buckets.
+	LatencyBounds []time.Duration `json:"latencyBounds"`
+	Stats         []apiStatsEntry `json:"stats"`
+}
+
+// apiStats - collects request statistics in memory.
+type apiStats struct {
+	mutex sync.Mutex
+	info  apiStatsInfo
+	// Set if statistics changed since they were last saved.
+	changed bool
+}
+
+// newAPIStats - returns empty statistics.
+func newAPIStats() *apiStats {
+	return &apiStats{
+		info: apiStatsInfo{
+			Since:   time.Now().UTC(),
+			APIs:    make(map[string]*apiStatsEntry),
+			Buckets: make(map[string]*apiStatsEntry),
+		},
+	}
+}
+
+// Global API statistics.
+var globalAPIStats = newAPIStats()
+
+// add - accounts a request of api for bucket, bucket is empty for
+// requests which are not for a bucket.
+func (s *apiStats) add(api, bucket string, statusCode int, duration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.changed = true
+
+	entry, ok := s.info.APIs[api]
+	if !ok {
+		entry = &apiStatsEntry{Name: api}
+		s.info.APIs[api] = entry
+	}
+	entry.add(statusCode, duration)
+
+	if bucket == "" {
+		return
+	}
+	entry, ok = s.info.Buckets[bucket]
+	if !ok {
+		if len(s.info.Buckets) >= maxAPIStatsBuckets {
+			return
+		}
+		entry = &apiStatsEntry{Name: bucket}
+		s.info.Buckets[bucket] = entry
+	}
+	entry.add(statusCode, duration)
+}
+
+// top - returns the count entries with the most requests, all if count
+// is not positive.
+func (s *apiStats) top(buckets bool, count int) apiStatsResponse {
+	s.mutex.Lock()
+	entries := s.info.APIs
+	if buckets {
+		entries = s.info.Buckets
+	}
+	response := apiStatsResponse{
+		Since:         s.info.Since,
+		LatencyBounds: apiLatencyBounds,
+		Stats:         make([]apiStatsEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		e := *entry
+		e.Latency = append([]uint64(nil), entry.Latency...)
+		response.Stats = append(response.Stats, e)
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(response.Stats, func(i, j int) bool {
+		if response.Stats[i].Requests != response.Stats[j].Requests {
+			return response.Stats[i].Requests > response.Stats[j].Requests
+		}
+		return response.Stats[i].Name < response.Stats[j].Name
+	})
+	if count > 0 && count < len(response.Stats) {
+		response.Stats = response.Stats[:count]
+	}
+	return response
+}
+
+// load - restores statistics saved by a previous run of the server.
+func (s *apiStats) load(objAPI ObjectLayer) error {
+	data, err := loadMetaFile(objAPI, apiStatsFile)
+	if err == errFileNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	info := apiStatsInfo{}
+	if err = json.Unmarshal(data, &info); err != nil {
+		return err
+	}
+	if info.APIs == nil {
+		info.APIs = make(map[string]*apiStatsEntry)
+	}
+	if info.Buckets == nil {
+		info.Buckets = make(map[string]*apiStatsEntry)
+	}
+	s.mutex.Lock()
+	s.info = info
+	s.changed = false
+	s.mutex.Unlock()
+	return nil
+}
+
+// save - saves statistics if they changed since they were last saved.
+func (s *apiStats) save(objAPI ObjectLayer) error {
+	s.mutex.Lock()
+	if !s.changed {
+		s.mutex.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(s.info)
+	s.changed = false
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return saveMetaFile(objAPI, apiStatsFile, data)
+}
+
+// runAPIStatsSaver - saves statistics periodically until doneCh is
+// closed.
+func runAPIStatsSaver(objAPI ObjectLayer, doneCh <-chan struct{}) {
+	ticker := time.NewTicker(apiStatsSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			errorIf(globalAPIStats.save(objAPI), "Unable to save API statistics.")
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// collectAPIStats - accounts every request served by f to api and the
+// bucket of the request.
+func collectAPIStats(api string, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &traceResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		f(sw, r)
+		globalAPIStats.add(api, router.Vars(r)["bucket"], sw.statusCode, time.Since(start))
+	}
+}
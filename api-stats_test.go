@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests requests are accounted per API and bucket.
+func TestAPIStatsAdd(t *testing.T) {
+	stats := newAPIStats()
+	stats.add("GetObject", "bucket", http.StatusOK, time.Millisecond)
+	stats.add("GetObject", "bucket", http.StatusNotFound, 20*time.Millisecond)
+	stats.add("GetObject", "other", http.StatusInternalServerError, time.Minute)
+	stats.add("ListBuckets", "", http.StatusOK, time.Millisecond)
+
+	apis := stats.top(false, 0)
+	if len(apis.Stats) != 2 || apis.Stats[0].Name != "GetObject" || apis.Stats[1].Name != "ListBuckets" {
+		t.Fatalf("Unexpected API stats %#v", apis.Stats)
+	}
+	get := apis.Stats[0]
+	if get.Requests != 3 || get.ClientErrors != 1 || get.ServerErrors != 1 {
+		t.Fatalf("Unexpected GetObject stats %#v", get)
+	}
+	if get.TotalDuration != time.Minute+21*time.Millisecond {
+		t.Fatalf("Unexpected total duration %s", get.TotalDuration)
+	}
+	if len(get.Latency) != len(apiLatencyBounds)+1 || get.Latency[0] != 1 || get.Latency[1] != 1 || get.Latency[len(apiLatencyBounds)] != 1 {
+		t.Fatalf("Unexpected latency histogram %v", get.Latency)
+	}
+
+	buckets := stats.top(true, 1)
+	if len(buckets.Stats) != 1 || buckets.Stats[0].Name != "bucket" || buckets.Stats[0].Requests != 2 {
+		t.Fatalf("Unexpected bucket stats %#v", buckets.Stats)
+	}
+}
+
+// Tests statistics survive a restart.
+func TestAPIStatsSaveLoad(t *testing.T) {
+	objAPI, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+
+	stats := newAPIStats()
+	stats.add("PutObject", "bucket", http.StatusOK, time.Millisecond)
+	if err = stats.save(objAPI); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newAPIStats()
+	if err = loaded.load(objAPI); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.info.Since.Equal(stats.info.Since) {
+		t.Fatalf("Expected since %s, got %s", stats.info.Since, loaded.info.Since)
+	}
+	buckets := loaded.top(true, 0)
+	if len(buckets.Stats) != 1 || buckets.Stats[0].Requests != 1 {
+		t.Fatalf("Unexpected bucket stats %#v", buckets.Stats)
+	}
+}
+
+// Tests the admin API returns statistics of served requests.
+func TestAdminTopStats(t *testing.T) {
+	globalAPIStats = newAPIStats()
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/bucket", "/bucket/object", "/"} {
+		method := "PUT"
+		if path == "/" {
+			method = "GET"
+		}
+		request, err := newTestRequest(method, testServer.Server.URL+path, 0, nil, testServer.AccessKey, testServer.SecretKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := (&http.Client{}).Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+
+	apis, err := client.TopAPIs(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apis.Stats) != 3 || len(apis.LatencyBounds) != len(apiLatencyBounds) {
+		t.Fatalf("Unexpected API stats %#v", apis)
+	}
+	buckets, err := client.TopBuckets(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets.Stats) != 1 || buckets.Stats[0].Name != "bucket" || buckets.Stats[0].Requests != 2 {
+		t.Fatalf("Unexpected bucket stats %#v", buckets)
+	}
+}
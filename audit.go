@@ -0,0 +1,495 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Version of the audit entry format.
+	auditEntryVersion = "1"
+
+	// Directory inside the config directory where audit entries are
+	// queued while the webhook target is unreachable.
+	auditQueueDir = "audit"
+
+	// Maximum number of queued audit entries, newer entries are
+	// dropped once the queue is full.
+	auditQueueLimit = 100000
+
+	// Number of audit entries buffered in memory before they are
+	// spilled to the queue directly.
+	auditEntryBuffer = 10000
+)
+
+// Interval in which queued audit entries are resent.
+var auditRetryInterval = 5 * time.Second
+
+// auditConfig - audit logging configuration.
+type auditConfig struct {
+	Webhook auditWebhookConfig `json:"webhook"`
+	File    auditFileConfig    `json:"file"`
+	// Add new audit targets here.
+}
+
+// auditWebhookConfig - sends every audit entry as JSON in a POST
+// request to Endpoint.
+type auditWebhookConfig struct {
+	Enable   bool   `json:"enable"`
+	Endpoint string `json:"endpoint"`
+}
+
+// auditFileConfig - appends every audit entry as a line of JSON to
+// Filename.
+type auditFileConfig struct {
+	Enable   bool   `json:"enable"`
+	Filename string `json:"fileName"`
+}
+
+// auditEntry - audit record of a single API call.
+type auditEntry struct {
+	Version    string        `json:"version"`
+	Time       time.Time     `json:"time"`
+	RequestID  string        `json:"requestID"`
+	API        string        `json:"api"`
+	Bucket     string        `json:"bucket,omitempty"`
+	Object     string        `json:"object,omitempty"`
+	ShareID    string        `json:"shareID,omitempty"`
+	AccessKey  string        `json:"accessKey,omitempty"`
+	RemoteIP   string        `json:"remoteIP"`
+	StatusCode int           `json:"statusCode"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// auditTarget - destination of audit entries.
+type auditTarget interface {
+	Send(entry auditEntry) error
+}
+
+// auditFileTarget - appends audit entries to a local file.
+type auditFileTarget struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// newAuditFileTarget - opens filename for appending audit entries.
+func newAuditFileTarget(filename string) (*auditFileTarget, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditFileTarget{file: file}, nil
+}
+
+// Send - appends entry as a line of JSON.
+func (t *auditFileTarget) Send(entry auditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, err = t.file.Write(append(data, '\n'))
+	return err
+}
+
+// auditWebhookTarget - sends audit entries to a HTTP endpoint, entries
+// are queued on disk while the endpoint is unreachable and resent in
+// order once it is back.
+type auditWebhookTarget struct {
+	endpoint string
+	client   *http.Client
+	queueDir string
+
+	// Serializes sending and queueing to preserve order.
+	mutex sync.Mutex
+	seq   uint64
+	// Number of queued entries and whether the last entry failed to
+	// send, new entries are queued right away while either is set.
+	queueLen int
+	failed   bool
+}
+
+// newAuditWebhookTarget - returns a webhook target queueing entries
+// in queueDir.
+func newAuditWebhookTarget(endpoint, queueDir string) (*auditWebhookTarget, error) {
+	if err := os.MkdirAll(queueDir, 0700); err != nil {
+		return nil, err
+	}
+	names, err := readDir(queueDir)
+	if err != nil {
+		return nil, err
+	}
+	return &auditWebhookTarget{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queueDir: queueDir,
+		queueLen: len(names),
+	}, nil
+}
+
+// post - sends a JSON encoded audit entry to the endpoint.
+func (t *auditWebhookTarget) post(data []byte) error {
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Audit webhook ‘%s’ returned ‘%s’", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// queued - returns the names of all queued entries, oldest first.
+func (t *auditWebhookTarget) queued() ([]string, error) {
+	names, err := readDir(t.queueDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// enqueue - stores a JSON encoded audit entry in the queue, must be
+// called with the mutex held.
+func (t *auditWebhookTarget) enqueue(data []byte) error {
+	if t.queueLen >= auditQueueLimit {
+		return fmt.Errorf("Audit queue ‘%s’ is full", t.queueDir)
+	}
+	t.seq++
+	name := fmt.Sprintf("%020d-%020d.json", time.Now().UnixNano(), t.seq)
+	if err := ioutil.WriteFile(filepath.Join(t.queueDir, name), data, 0600); err != nil {
+		return err
+	}
+	t.queueLen++
+	return nil
+}
+
+// flush - resends queued entries in order, stops at the first failure.
+// Entries are sent without holding the mutex so Send keeps queueing
+// meanwhile, flush itself must not be called concurrently.
+func (t *auditWebhookTarget) flush() error {
+	names, err := t.queued()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		path := filepath.Join(t.queueDir, name)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err = t.post(data); err != nil {
+			return err
+		}
+		t.mutex.Lock()
+		if err = os.Remove(path); err == nil {
+			t.queueLen--
+		}
+		t.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Entries are sent right away again once the queue is empty.
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.queueLen <= 0 {
+		t.queueLen = 0
+		t.failed = false
+	}
+	return nil
+}
+
+// Send - sends entry to the endpoint, queues it if the endpoint is
+// unreachable or older entries are still queued. Queued entries are
+// resent by retry.
+func (t *auditWebhookTarget) Send(entry auditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.queueLen == 0 && !t.failed {
+		if err = t.post(data); err == nil {
+			return nil
+		}
+		t.failed = true
+	}
+	if qerr := t.enqueue(data); qerr != nil {
+		return qerr
+	}
+	return err
+}
+
+// retry - resends queued entries every interval until doneCh is closed.
+func (t *auditWebhookTarget) retry(interval time.Duration, doneCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.flush()
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// auditLogger - sends audit entries to all configured targets without
+// blocking API calls.
+type auditLogger struct {
+	targets []auditTarget
+	entryCh chan auditEntry
+	// Tracks entries which are not sent yet.
+	pending sync.WaitGroup
+}
+
+// newAuditLogger - returns a logger sending to targets, entries are
+// sent until doneCh is closed.
+func newAuditLogger(targets []auditTarget, doneCh <-chan struct{}) *auditLogger {
+	l := &auditLogger{
+		targets: targets,
+		entryCh: make(chan auditEntry, auditEntryBuffer),
+	}
+	go l.run(doneCh)
+	return l
+}
+
+func (l *auditLogger) run(doneCh <-chan struct{}) {
+	for {
+		select {
+		case entry := <-l.entryCh:
+			for _, target := range l.targets {
+				errorIf(target.Send(entry), "Unable to send audit entry.")
+			}
+			l.pending.Done()
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// Log - hands entry to the targets, entries are dropped while the
+// buffer is full.
+func (l *auditLogger) Log(entry auditEntry) {
+	l.pending.Add(1)
+	select {
+	case l.entryCh <- entry:
+	default:
+		l.pending.Done()
+		errorIf(fmt.Errorf("Audit buffer is full"), "Dropped audit entry for request ‘%s’.", entry.RequestID)
+	}
+}
+
+// Flush - waits up to timeout until all logged entries were handed to
+// the targets. Entries a webhook target could not send are queued.
+func (l *auditLogger) Flush(timeout time.Duration) error {
+	doneCh := make(chan struct{})
+	go func() {
+		l.pending.Wait()
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("Audit entries still pending after %s", timeout)
+	}
+}
+
+// Global audit logger, nil if audit logging is disabled.
+var globalAuditLogger *auditLogger
+
+// initAuditLogger - initializes the audit targets from the config.
+func initAuditLogger() {
+	acfg := serverConfig.GetAudit()
+	var targets []auditTarget
+	if acfg.File.Enable {
+		target, err := newAuditFileTarget(acfg.File.Filename)
+		fatalIf(err, "Unable to open audit log file.")
+		targets = append(targets, target)
+	}
+	if acfg.Webhook.Enable {
+		target, err := newAuditWebhookTarget(acfg.Webhook.Endpoint, filepath.Join(mustGetConfigPath(), auditQueueDir))
+		fatalIf(err, "Unable to initialize audit webhook.")
+		go target.retry(auditRetryInterval, nil)
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return
+	}
+	globalAuditLogger = newAuditLogger(targets, nil)
+}
+
+// Sub-resources which select the S3 API of a bucket or object request,
+// checked in the order of the API router.
+var (
+	auditBucketAPIs = []struct {
+		method, query, api string
+	}{
+		{"GET", "location", "GetBucketLocation"},
+		{"GET", "policy", "GetBucketPolicy"},
+		{"GET", "object-lock", "GetBucketObjectLockConfig"},
+		{"GET", "encryption", "GetBucketEncryption"},
+		{"GET", "uploads", "ListMultipartUploads"},
+		{"GET", "", "ListObjects"},
+		{"PUT", "policy", "PutBucketPolicy"},
+		{"PUT", "object-lock", "PutBucketObjectLockConfig"},
+		{"PUT", "encryption", "PutBucketEncryption"},
+		{"PUT", "", "PutBucket"},
+		{"HEAD", "", "HeadBucket"},
+		{"POST", "", "DeleteMultipleObjects"},
+		{"DELETE", "policy", "DeleteBucketPolicy"},
+		{"DELETE", "encryption", "DeleteBucketEncryption"},
+		{"DELETE", "", "DeleteBucket"},
+	}
+	auditObjectAPIs = []struct {
+		method, query, api string
+	}{
+		{"HEAD", "", "HeadObject"},
+		{"GET", "uploadId", "ListObjectParts"},
+		{"GET", "retention", "GetObjectRetention"},
+		{"GET", "legal-hold", "GetObjectLegalHold"},
+		{"GET", "", "GetObject"},
+		{"PUT", "uploadId", "PutObjectPart"},
+		{"PUT", "retention", "PutObjectRetention"},
+		{"PUT", "legal-hold", "PutObjectLegalHold"},
+		{"PUT", "", "PutObject"},
+		{"POST", "uploadId", "CompleteMultipartUpload"},
+		{"POST", "uploads", "NewMultipartUpload"},
+		{"DELETE", "uploadId", "AbortMultipartUpload"},
+		{"DELETE", "", "DeleteObject"},
+	}
+)
+
+// getAuditAPI - returns the S3 API name, bucket and object of a request.
+func getAuditAPI(r *http.Request) (api, bucket, object string) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "" {
+		return "ListBuckets", "", ""
+	}
+	splits := strings.SplitN(path, "/", 2)
+	bucket = splits[0]
+	if len(splits) == 2 {
+		object = splits[1]
+	}
+	apis := auditBucketAPIs
+	// Browser uploads are multipart form posts.
+	if object == "" && r.Method == "POST" && strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return "PostPolicy", bucket, object
+	}
+	if object != "" {
+		apis = auditObjectAPIs
+		// Copy is a put with a copy source.
+		if r.Method == "PUT" && r.Header.Get("X-Amz-Copy-Source") != "" && r.URL.Query().Get("uploadId") == "" {
+			return "CopyObject", bucket, object
+		}
+	}
+	query := r.URL.Query()
+	for _, a := range apis {
+		if a.method != r.Method {
+			continue
+		}
+		if _, ok := query[a.query]; a.query == "" || ok {
+			return a.api, bucket, object
+		}
+	}
+	return "Unknown", bucket, object
+}
+
+// auditHandler - logs an audit entry for every S3 API call.
+type auditHandler struct {
+	handler http.Handler
+}
+
+func setAuditHandler(h http.Handler) http.Handler {
+	return auditHandler{h}
+}
+
+func (h auditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Browser, admin and RPC requests are not S3 API calls.
+	if globalAuditLogger == nil || strings.HasPrefix(r.URL.Path, reservedBucket+"/") {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	entry := auditEntry{
+		Version:   auditEntryVersion,
+		Time:      time.Now().UTC(),
+		RequestID: getRequestID(r),
+		AccessKey: getRequestAccessKey(r),
+		RemoteIP:  r.RemoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.RemoteIP = host
+	}
+	entry.API, entry.Bucket, entry.Object = getAuditAPI(r)
+
+	rw := &traceResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	h.handler.ServeHTTP(rw, r)
+
+	entry.StatusCode = rw.statusCode
+	entry.Duration = time.Since(entry.Time)
+	globalAuditLogger.Log(entry)
+}
+
+// logFileAudit - logs an audit entry for an operation of the SFTP
+// front-end, api is the S3 API the operation corresponds to.
+func logFileAudit(api, bucket, object, accessKey string, remoteAddr net.Addr, started time.Time, err error) {
+	if globalAuditLogger == nil {
+		return
+	}
+	entry := auditEntry{
+		Version:    auditEntryVersion,
+		Time:       started,
+		RequestID:  string(generateRequestID()),
+		API:        api,
+		Bucket:     bucket,
+		Object:     object,
+		AccessKey:  accessKey,
+		RemoteIP:   remoteAddr.String(),
+		StatusCode: http.StatusOK,
+		Duration:   time.Since(started),
+	}
+	if host, _, sErr := net.SplitHostPort(entry.RemoteIP); sErr == nil {
+		entry.RemoteIP = host
+	}
+	if err != nil {
+		code := toAPIErrorCode(err)
+		if apiErr, ok := err.(fileAPIError); ok {
+			code = APIErrorCode(apiErr)
+		} else if err == errFilePermissionDenied {
+			code = ErrAccessDenied
+		}
+		entry.StatusCode = getAPIError(code).HTTPStatusCode
+	}
+	globalAuditLogger.Log(entry)
+}
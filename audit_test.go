@@ -0,0 +1,204 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Tests S3 API names are derived from requests.
+func TestGetAuditAPI(t *testing.T) {
+	testCases := []struct {
+		method string
+		url    string
+		header http.Header
+		api    string
+		bucket string
+		object string
+	}{
+		// Test case - 1.
+		{"GET", "/", nil, "ListBuckets", "", ""},
+		// Test case - 2.
+		{"GET", "/bucket", nil, "ListObjects", "bucket", ""},
+		// Test case - 3.
+		{"GET", "/bucket?location", nil, "GetBucketLocation", "bucket", ""},
+		// Test case - 4.
+		{"PUT", "/bucket", nil, "PutBucket", "bucket", ""},
+		// Test case - 5.
+		{"POST", "/bucket?delete", nil, "DeleteMultipleObjects", "bucket", ""},
+		// Test case - 6.
+		{"POST", "/bucket", http.Header{"Content-Type": []string{"multipart/form-data; boundary=x"}}, "PostPolicy", "bucket", ""},
+		// Test case - 7.
+		{"GET", "/bucket/dir/object", nil, "GetObject", "bucket", "dir/object"},
+		// Test case - 8.
+		{"PUT", "/bucket/object?partNumber=1&uploadId=id", nil, "PutObjectPart", "bucket", "object"},
+		// Test case - 9.
+		{"PUT", "/bucket/object", http.Header{"X-Amz-Copy-Source": []string{"/src/object"}}, "CopyObject", "bucket", "object"},
+		// Test case - 10.
+		{"POST", "/bucket/object?uploads", nil, "NewMultipartUpload", "bucket", "object"},
+		// Test case - 11.
+		{"DELETE", "/bucket/object?uploadId=id", nil, "AbortMultipartUpload", "bucket", "object"},
+		// Test case - 12.
+		{"PATCH", "/bucket/object", nil, "Unknown", "bucket", "object"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.url, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		for k, v := range testCase.header {
+			req.Header[k] = v
+		}
+		api, bucket, object := getAuditAPI(req)
+		if api != testCase.api || bucket != testCase.bucket || object != testCase.object {
+			t.Errorf("Test %d: Expected %s %s %s, but instead found %s %s %s", i+1,
+				testCase.api, testCase.bucket, testCase.object, api, bucket, object)
+		}
+	}
+}
+
+// Tests audit entries are appended to a file.
+func TestAuditFileTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-audit-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	target, err := newAuditFileTarget(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, requestID := range []string{"1", "2"} {
+		if err = target.Send(auditEntry{RequestID: requestID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file, err := os.Open(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var requestIDs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		requestIDs = append(requestIDs, entry.RequestID)
+	}
+	if len(requestIDs) != 2 || requestIDs[0] != "1" || requestIDs[1] != "2" {
+		t.Fatalf("Unexpected audit entries %v", requestIDs)
+	}
+}
+
+// Tests audit entries are queued while the webhook is unreachable and
+// resent in order.
+func TestAuditWebhookTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-audit-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	var mutex sync.Mutex
+	online := false
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var entry auditEntry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requestIDs = append(requestIDs, entry.RequestID)
+	}))
+	defer server.Close()
+
+	target, err := newAuditWebhookTarget(server.URL, filepath.Join(dir, auditQueueDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = target.Send(auditEntry{RequestID: "1"}); err == nil {
+		t.Fatal("Expected sending to an unreachable webhook to fail")
+	}
+	// Once sending failed entries are queued without being sent.
+	if err = target.Send(auditEntry{RequestID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := target.queued(); len(names) != 2 {
+		t.Fatalf("Expected 2 queued audit entries, but instead found %d", len(names))
+	}
+	if err = target.flush(); err == nil {
+		t.Fatal("Expected flushing to an unreachable webhook to fail")
+	}
+
+	mutex.Lock()
+	online = true
+	mutex.Unlock()
+	// Entries are queued behind older entries.
+	if err = target.Send(auditEntry{RequestID: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requestIDs) != 0 {
+		t.Fatalf("Expected no audit entries sent before the queue is flushed, but instead found %v", requestIDs)
+	}
+	if err = target.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := target.queued(); len(names) != 0 {
+		t.Fatalf("Expected no queued audit entries, but instead found %d", len(names))
+	}
+	// Entries are sent right away once the queue is empty.
+	if err = target.Send(auditEntry{RequestID: "4"}); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := target.queued(); len(names) != 0 {
+		t.Fatalf("Expected no queued audit entries, but instead found %d", len(names))
+	}
+	if len(requestIDs) != 4 || requestIDs[0] != "1" || requestIDs[1] != "2" || requestIDs[2] != "3" || requestIDs[3] != "4" {
+		t.Fatalf("Expected audit entries in order, but instead found %v", requestIDs)
+	}
+
+	// Entries queued by a previous run are counted.
+	mutex.Lock()
+	online = false
+	mutex.Unlock()
+	if err = target.Send(auditEntry{RequestID: "5"}); err == nil {
+		t.Fatal("Expected sending to an unreachable webhook to fail")
+	}
+	target, err = newAuditWebhookTarget(server.URL, filepath.Join(dir, auditQueueDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.queueLen != 1 {
+		t.Fatalf("Expected 1 queued audit entry, but instead found %d", target.queueLen)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Size of the nonce the credentials of other nodes are verified with.
+const bootstrapNonceSize = 32
+
+// getCredentialMAC - returns the MAC over nonce proving the credentials
+// of this node, nothing derived from the secret key alone is sent.
+func getCredentialMAC(nonce []byte) []byte {
+	cred := serverConfig.GetCredential()
+	mac := hmac.New(sha256.New, []byte(cred.SecretAccessKey))
+	mac.Write(nonce)
+	mac.Write([]byte(cred.AccessKeyID))
+	return mac.Sum(nil)
+}
+
+// getBootstrapInfo - returns the bootstrap parameters of this node, the
+// credentials are proven by a MAC over the nonce of the caller.
+func getBootstrapInfo(disks []string, nonce []byte) BootstrapInfo {
+	return BootstrapInfo{
+		Version:       minioVersion,
+		CommitID:      minioCommitID,
+		Disks:         disks,
+		CredentialMAC: getCredentialMAC(nonce),
+	}
+}
+
+// isNetworkDisk - returns true if disk is served by another node, the
+// same distinction as newStorageAPI.
+func isNetworkDisk(disk string) bool {
+	return strings.ContainsRune(disk, ':') && filepath.VolumeName(disk) == ""
+}
+
+// getNetPath - returns the path of a network disk on its node.
+func getNetPath(disk string) string {
+	_, netPath := splitNetPath(disk)
+	return netPath
+}
+
+// compareBootstrapInfo - returns the differences between the parameters
+// of this node and of the node at addr. Disks are compared by position,
+// a disk of the node at addr has to be local on it and its network
+// disks have to be network disks with the same paths here. Versions may
+// differ during rolling upgrades, the storage RPC protocol is
+// negotiated on connect.
+func compareBootstrapInfo(local, remote BootstrapInfo, addr string) []string {
+	var diffs []string
+	if !hmac.Equal(local.CredentialMAC, remote.CredentialMAC) {
+		diffs = append(diffs, fmt.Sprintf("%s has different credentials", addr))
+	}
+	if len(local.Disks) != len(remote.Disks) {
+		return append(diffs, fmt.Sprintf("%s was started with %d disks, this node with %d",
+			addr, len(remote.Disks), len(local.Disks)))
+	}
+	for i, disk := range local.Disks {
+		remoteDisk := remote.Disks[i]
+		var ok bool
+		switch {
+		case !isNetworkDisk(disk):
+			// Local disks of this node are network disks there.
+			ok = isNetworkDisk(remoteDisk) && getNetPath(remoteDisk) == disk
+		case strings.HasPrefix(disk, addr+":"):
+			// Disks of the node are local there.
+			ok = !isNetworkDisk(remoteDisk) && remoteDisk == getNetPath(disk)
+		default:
+			// Disks of other nodes, addresses may be spelled
+			// differently.
+			ok = isNetworkDisk(remoteDisk) && getNetPath(remoteDisk) == getNetPath(disk)
+		}
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("disk %d is %s on this node and %s on %s", i+1, disk, remoteDisk, addr))
+		}
+	}
+	return diffs
+}
+
+// verifyBootstrap - compares the bootstrap parameters of all nodes
+// serving network disks with this node, fails with all differences
+// found. Unreachable nodes are skipped, they verify the deployment when
+// they start.
+func verifyBootstrap(disks []string) error {
+	// Nodes answer with a MAC over a fresh nonce, answers of an
+	// earlier verification cannot be replayed.
+	nonce := make([]byte, bootstrapNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	local := getBootstrapInfo(disks, nonce)
+	checked := make(map[string]struct{})
+	var diffs []string
+	for _, disk := range disks {
+		if !isNetworkDisk(disk) {
+			continue
+		}
+		netAddr, _ := splitNetPath(disk)
+		if _, ok := checked[netAddr]; ok {
+			continue
+		}
+		checked[netAddr] = struct{}{}
+
+		storage, err := newRPCClient(disk)
+		if err != nil {
+			errorIf(err, "Unable to verify the parameters of %s.", netAddr)
+			continue
+		}
+		remote, err := storage.(*networkStorage).BootstrapInfo(nonce)
+		if err == errRPCVersionUnsupported {
+			log.Warnf("%s runs an older version which cannot be verified, upgrade all nodes.", netAddr)
+			continue
+		}
+		if err != nil {
+			errorIf(err, "Unable to verify the parameters of %s.", netAddr)
+			continue
+		}
+		if remote.Version != local.Version || remote.CommitID != local.CommitID {
+			log.Warnf("%s runs version %s, this node runs %s, finish upgrading all nodes.", netAddr, remote.Version, local.Version)
+		}
+		diffs = append(diffs, compareBootstrapInfo(local, remote, netAddr)...)
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("Nodes were started differently: %s", strings.Join(diffs, "; "))
+	}
+	return nil
+}
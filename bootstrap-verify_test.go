@@ -0,0 +1,131 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/hmac"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests differences between nodes are found.
+func TestCompareBootstrapInfo(t *testing.T) {
+	local := BootstrapInfo{
+		Version:       "1",
+		CommitID:      "a",
+		Disks:         []string{"/disk1", "node2:9000:/disk2", "node3:9000:/disk3"},
+		CredentialMAC: []byte("h"),
+	}
+	testCases := []struct {
+		remote BootstrapInfo
+		diffs  []string
+	}{
+		// Same deployment seen from node2, node3 spelled differently.
+		{BootstrapInfo{Version: "1", CommitID: "a", CredentialMAC: []byte("h"),
+			Disks: []string{"node1:9000:/disk1", "/disk2", "10.0.0.3:9000:/disk3"}}, nil},
+		// Different credentials, versions differ during upgrades.
+		{BootstrapInfo{Version: "2", CommitID: "b", CredentialMAC: []byte("x"),
+			Disks: []string{"node1:9000:/disk1", "/disk2", "node3:9000:/disk3"}},
+			[]string{"different credentials"}},
+		// Different number of disks.
+		{BootstrapInfo{Version: "1", CommitID: "a", CredentialMAC: []byte("h"),
+			Disks: []string{"node1:9000:/disk1", "/disk2"}},
+			[]string{"started with 2 disks"}},
+		// Swapped disks.
+		{BootstrapInfo{Version: "1", CommitID: "a", CredentialMAC: []byte("h"),
+			Disks: []string{"/disk2", "node1:9000:/disk1", "node3:9000:/disk3"}},
+			[]string{"disk 1 is /disk1", "disk 2 is node2:9000:/disk2"}},
+	}
+	for i, testCase := range testCases {
+		diffs := compareBootstrapInfo(local, testCase.remote, "node2:9000")
+		if len(diffs) != len(testCase.diffs) {
+			t.Errorf("Test %d: expected %d differences, got %q", i+1, len(testCase.diffs), diffs)
+			continue
+		}
+		for j, diff := range diffs {
+			if !strings.Contains(diff, testCase.diffs[j]) {
+				t.Errorf("Test %d: expected %q, got %q", i+1, testCase.diffs[j], diff)
+			}
+		}
+	}
+}
+
+// Tests nodes serving network disks are verified on start.
+func TestVerifyBootstrap(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	disk, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(disk)
+	storageRPC, err := newRPCServer(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := router.NewRouter()
+	registerStorageRPCRouter(mux, storageRPC)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	disks := []string{"/local", addr + ":" + disk}
+	storageRPC.exportPaths = []string{"node1:9000:/local", disk}
+	if err = verifyBootstrap(disks); err != nil {
+		t.Fatal(err)
+	}
+	storageRPC.exportPaths = []string{disk, "node1:9000:/local"}
+	if err = verifyBootstrap(disks); err == nil || !strings.Contains(err.Error(), "disk 1 is /local") {
+		t.Fatalf("Expected swapped disks to be reported, got %v", err)
+	}
+}
+
+// Tests the credentials are proven by a MAC bound to the nonce.
+func TestGetCredentialMAC(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	mac := getCredentialMAC([]byte("nonce1"))
+	if !hmac.Equal(mac, getCredentialMAC([]byte("nonce1"))) {
+		t.Fatal("Expected MACs over the same nonce to match")
+	}
+	if hmac.Equal(mac, getCredentialMAC([]byte("nonce2"))) {
+		t.Fatal("Expected MACs over different nonces to differ")
+	}
+	cred := serverConfig.GetCredential()
+	cred.SecretAccessKey = "other-secret-access-key"
+	serverConfig.SetCredential(cred)
+	if hmac.Equal(mac, getCredentialMAC([]byte("nonce1"))) {
+		t.Fatal("Expected MACs of different credentials to differ")
+	}
+}
@@ -0,0 +1,226 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Canned ACLs supported by Put Bucket and Put Object ACL, they are
+// translated into statements of the bucket policy.
+const (
+	aclPrivate         = "private"
+	aclPublicRead      = "public-read"
+	aclPublicReadWrite = "public-read-write"
+)
+
+// ACL grantees and permissions.
+const (
+	aclAllUsersURI     = "http://acs.amazonaws.com/groups/global/AllUsers"
+	aclXMLSchemaNS     = "http://www.w3.org/2001/XMLSchema-instance"
+	aclFullControl     = "FULL_CONTROL"
+	aclRead            = "READ"
+	aclWrite           = "WRITE"
+	aclGranteeUser     = "CanonicalUser"
+	aclGranteeGroup    = "Group"
+	amzACL             = "X-Amz-Acl"
+	amzGrantHeaderPref = "X-Amz-Grant-"
+)
+
+// Maximum size of an access control policy request.
+const maxACLRequestSize = 64 * 1024
+
+// Owner of all buckets and objects.
+var aclOwner = Owner{ID: "minio", DisplayName: "minio"}
+
+// Grantee - user or group an ACL grant applies to.
+type Grantee struct {
+	XMLNS        string `xml:"xmlns:xsi,attr,omitempty"`
+	Type         string `xml:"xsi:type,attr,omitempty"`
+	ID           string `xml:",omitempty"`
+	DisplayName  string `xml:",omitempty"`
+	URI          string `xml:",omitempty"`
+	EmailAddress string `xml:",omitempty"`
+}
+
+// Grant - permission granted to a grantee.
+type Grant struct {
+	Grantee    Grantee
+	Permission string
+}
+
+// AccessControlPolicy - ACL of a bucket or an object.
+type AccessControlPolicy struct {
+	XMLName           xml.Name `xml:"AccessControlPolicy" json:"-"`
+	Owner             Owner
+	AccessControlList struct {
+		Grants []Grant `xml:"Grant"`
+	}
+}
+
+// generateACLResponse - returns the ACL granting the owner full control
+// and everyone read and/or write access.
+func generateACLResponse(read, write bool) AccessControlPolicy {
+	acl := AccessControlPolicy{Owner: aclOwner}
+	grant := func(grantee Grantee, permission string) {
+		grantee.XMLNS = aclXMLSchemaNS
+		acl.AccessControlList.Grants = append(acl.AccessControlList.Grants, Grant{grantee, permission})
+	}
+	grant(Grantee{Type: aclGranteeUser, ID: aclOwner.ID, DisplayName: aclOwner.DisplayName}, aclFullControl)
+	if read {
+		grant(Grantee{Type: aclGranteeGroup, URI: aclAllUsersURI}, aclRead)
+	}
+	if write {
+		grant(Grantee{Type: aclGranteeGroup, URI: aclAllUsersURI}, aclWrite)
+	}
+	return acl
+}
+
+// parseCannedACL - returns the public access granted by a canned ACL.
+func parseCannedACL(acl string) (read, write bool, s3Error APIErrorCode) {
+	switch acl {
+	case aclPrivate:
+		return false, false, ErrNone
+	case aclPublicRead:
+		return true, false, ErrNone
+	case aclPublicReadWrite:
+		return true, true, ErrNone
+	}
+	return false, false, ErrNotImplemented
+}
+
+// parseACL - returns the public access granted by an access control
+// policy, only policies equivalent to a canned ACL are supported.
+func parseACL(acl AccessControlPolicy) (read, write bool, s3Error APIErrorCode) {
+	for _, grant := range acl.AccessControlList.Grants {
+		grantee := grant.Grantee
+		switch {
+		case grantee.URI == aclAllUsersURI && grant.Permission == aclRead:
+			read = true
+		case grantee.URI == aclAllUsersURI && grant.Permission == aclWrite:
+			write = true
+		case grantee.ID == aclOwner.ID && grant.Permission == aclFullControl:
+			// Owner always has full control.
+		default:
+			return false, false, ErrNotImplemented
+		}
+	}
+	return read, write, ErrNone
+}
+
+// parseACLRequest - returns the public access requested by the canned
+// ACL header or the access control policy in the request body.
+func parseACLRequest(r *http.Request) (read, write bool, s3Error APIErrorCode) {
+	for header := range r.Header {
+		if strings.HasPrefix(header, amzGrantHeaderPref) {
+			return false, false, ErrNotImplemented
+		}
+	}
+	if cannedACL := r.Header.Get(amzACL); cannedACL != "" {
+		return parseCannedACL(cannedACL)
+	}
+	if !contains(r.TransferEncoding, "chunked") {
+		if r.ContentLength == -1 || r.ContentLength == 0 {
+			return false, false, ErrMissingContentLength
+		}
+		if r.ContentLength > maxACLRequestSize {
+			return false, false, ErrEntityTooLarge
+		}
+	}
+	aclBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxACLRequestSize))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read access control policy.")
+		return false, false, ErrInternalError
+	}
+	acl := AccessControlPolicy{}
+	if err = xml.Unmarshal(aclBytes, &acl); err != nil {
+		return false, false, ErrMalformedXML
+	}
+	return parseACL(acl)
+}
+
+// cannedPolicyACL - returns the bucket policy equivalent to the access.
+func cannedPolicyACL(read, write bool) string {
+	switch {
+	case read && write:
+		return bucketPolicyReadWrite
+	case read:
+		return bucketPolicyReadOnly
+	case write:
+		return bucketPolicyWriteOnly
+	}
+	return bucketPolicyNone
+}
+
+// getBucketACL - returns the public access of a bucket granted by its
+// canned bucket policy.
+func getBucketACL(statements []policyStatement, bucket string) (read, write bool) {
+	switch getCannedPolicy(statements, bucket, "") {
+	case bucketPolicyReadWrite:
+		return true, true
+	case bucketPolicyReadOnly:
+		return true, false
+	case bucketPolicyWriteOnly:
+		return false, true
+	}
+	return false, false
+}
+
+// setBucketACL - replaces the canned bucket policy of a bucket.
+func setBucketACL(statements []policyStatement, bucket string, read, write bool) []policyStatement {
+	return setCannedPolicy(statements, cannedPolicyACL(read, write), bucket, "")
+}
+
+// isObjectACLStatement - returns if statement is the ACL of object.
+func isObjectACLStatement(statement policyStatement, bucket, object string) bool {
+	return len(statement.Resources) == 1 && statement.Resources[0] == AWSResourcePrefix+bucket+"/"+object
+}
+
+// getObjectACL - returns if everyone may read the object.
+func getObjectACL(statements []policyStatement, bucket, object string) (read bool) {
+	for _, statement := range statements {
+		if statement.Effect == "Allow" && isObjectACLStatement(statement, bucket, object) &&
+			contains(statement.Actions, "s3:GetObject") {
+			return true
+		}
+	}
+	return false
+}
+
+// setObjectACL - replaces the ACL statement of an object, write access
+// has no meaning for objects and is ignored like S3 does.
+func setObjectACL(statements []policyStatement, bucket, object string, read bool) []policyStatement {
+	var newStatements []policyStatement
+	for _, statement := range statements {
+		if !isObjectACLStatement(statement, bucket, object) {
+			newStatements = append(newStatements, statement)
+		}
+	}
+	if !read {
+		return newStatements
+	}
+	return append(newStatements, policyStatement{
+		Effect:    "Allow",
+		Principal: policyUser{AWS: []string{"*"}},
+		Actions:   []string{"s3:GetObject"},
+		Resources: []string{AWSResourcePrefix + bucket + "/" + object},
+	})
+}
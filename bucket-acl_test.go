@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// Tests canned ACLs survive encoding and parsing of access control policies.
+func TestParseACL(t *testing.T) {
+	testCases := []struct {
+		cannedACL string
+		read      bool
+		write     bool
+	}{
+		// Test case - 1.
+		{aclPrivate, false, false},
+		// Test case - 2.
+		{aclPublicRead, true, false},
+		// Test case - 3.
+		{aclPublicReadWrite, true, true},
+	}
+	for i, testCase := range testCases {
+		read, write, s3Error := parseCannedACL(testCase.cannedACL)
+		if s3Error != ErrNone {
+			t.Fatalf("Test %d: Unexpected error %d", i+1, s3Error)
+		}
+		aclBytes, err := xml.Marshal(generateACLResponse(read, write))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !strings.Contains(string(aclBytes), `xsi:type="CanonicalUser"`) {
+			t.Errorf("Test %d: Expected grantee type in %s", i+1, aclBytes)
+		}
+		acl := AccessControlPolicy{}
+		if err = xml.Unmarshal(aclBytes, &acl); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		read, write, s3Error = parseACL(acl)
+		if s3Error != ErrNone {
+			t.Fatalf("Test %d: Unexpected error %d", i+1, s3Error)
+		}
+		if read != testCase.read || write != testCase.write {
+			t.Errorf("Test %d: Expected read %t write %t, but instead found read %t write %t", i+1, testCase.read, testCase.write, read, write)
+		}
+	}
+
+	if _, _, s3Error := parseCannedACL("authenticated-read"); s3Error != ErrNotImplemented {
+		t.Errorf("Expected unsupported canned ACL to fail, but instead found %d", s3Error)
+	}
+	acl := AccessControlPolicy{}
+	acl.AccessControlList.Grants = []Grant{{Grantee: Grantee{ID: "other"}, Permission: aclRead}}
+	if _, _, s3Error := parseACL(acl); s3Error != ErrNotImplemented {
+		t.Errorf("Expected grant to another user to fail, but instead found %d", s3Error)
+	}
+}
+
+// Tests bucket and object ACLs are translated into the bucket policy.
+func TestSetBucketAndObjectACL(t *testing.T) {
+	bucket := "acl-bucket"
+	statements := setBucketACL(nil, bucket, true, false)
+	if read, write := getBucketACL(statements, bucket); !read || write {
+		t.Fatalf("Expected bucket to be public-read, but instead found read %t write %t", read, write)
+	}
+
+	// Object ACLs of objects sharing a prefix must not be rejected as nesting.
+	statements = setObjectACL(statements, bucket, "photo", true)
+	statements = setObjectACL(statements, bucket, "photo2", true)
+	policy := BucketPolicy{Version: "2012-10-17", Statements: statements}
+	if s3Error := checkBucketPolicyResources(bucket, policy); s3Error != ErrNone {
+		t.Fatalf("Expected policy to be valid, but instead found %d", s3Error)
+	}
+	if !getObjectACL(statements, bucket, "photo") || getObjectACL(statements, bucket, "photo3") {
+		t.Fatal("Expected only photo and photo2 to be public-read")
+	}
+
+	statements = setObjectACL(statements, bucket, "photo", false)
+	if getObjectACL(statements, bucket, "photo") || !getObjectACL(statements, bucket, "photo2") {
+		t.Fatal("Expected only photo2 to be public-read")
+	}
+	statements = setBucketACL(statements, bucket, false, false)
+	if read, write := getBucketACL(statements, bucket); read || write {
+		t.Fatalf("Expected bucket to be private, but instead found read %t write %t", read, write)
+	}
+	if !getObjectACL(statements, bucket, "photo2") {
+		t.Fatal("Expected object ACL to be kept when bucket ACL changes")
+	}
+}
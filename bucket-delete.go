@@ -0,0 +1,335 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Buckets are deleted by moving their entries below a temporary
+// directory of minioMetaBucket on every disk first, entries are removed
+// only once the bucket was moved on all disks. If moving fails on any
+// disk the entries are moved back, a bucket is deleted everywhere or
+// not at all.
+const (
+	// Header of DeleteBucket requests deleting a bucket with all its
+	// objects and uploads.
+	minioForceDeleteHeader = "X-Minio-Force-Delete"
+
+	// Directory of a deletion the objects of a bucket are moved to.
+	deletedBucketDataDir = "data"
+)
+
+// Directories of minioMetaBucket holding entries of a bucket, moved
+// with it when it is deleted. Deleted objects in the trash are only
+// dropped if the bucket is deleted with its objects.
+var (
+	deletedBucketMetaDirs      = []string{bucketMetaPrefix, mpartMetaPrefix, objectLockMetaPrefix}
+	forceDeletedBucketMetaDirs = []string{bucketMetaPrefix, mpartMetaPrefix, objectLockMetaPrefix, trashMetaPrefix}
+)
+
+// bucketForceDeleter - object layers which can delete a bucket with all
+// its objects and uploads.
+type bucketForceDeleter interface {
+	DeleteBucketForce(bucket string) error
+}
+
+// isForceDelete - returns true if a DeleteBucket request asks to delete
+// the objects of the bucket as well.
+func isForceDelete(value string) bool {
+	return strings.EqualFold(value, "true")
+}
+
+// checkForceDeleteLocks - returns ErrObjectLocked if a forced deletion
+// of bucket would delete objects which may not be deleted, in WORM
+// mode or under retention or legal hold.
+func checkForceDeleteLocks(objAPI ObjectLayer, bucket string, r *http.Request) APIErrorCode {
+	if globalWORMEnabled {
+		return ErrObjectLocked
+	}
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return toAPIErrorCode(err)
+		}
+		for _, object := range result.Objects {
+			if s3Error := enforceObjectLock(objAPI, bucket, object.Name, r); s3Error != ErrNone {
+				return s3Error
+			}
+			marker = object.Name
+		}
+		if !result.IsTruncated {
+			return ErrNone
+		}
+	}
+}
+
+// removeBucketConfigs - removes all configurations of a bucket and of
+// its objects, such as their keys and checksums.
+func removeBucketConfigs(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	if err = deleteEtcdBucketConfigs(bucket); err != nil {
+		return err
+	}
+	return os.RemoveAll(bucketConfigPath)
+}
+
+// moveBucketToTmp - moves the objects and uploads of a bucket on disk
+// below tmpPath in minioMetaBucket and removes the bucket. Returns
+// errVolumeNotEmpty before anything is moved if the bucket has objects
+// and force is not set.
+func moveBucketToTmp(disk StorageAPI, bucket, tmpPath string, force bool) error {
+	entries, err := disk.ListDir(bucket, "")
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && !force {
+		return errVolumeNotEmpty
+	}
+	for _, entry := range entries {
+		if err = disk.RenameFile(bucket, entry, minioMetaBucket, pathJoin(tmpPath, deletedBucketDataDir, entry)); err != nil {
+			return err
+		}
+	}
+	metaDirs := deletedBucketMetaDirs
+	if force {
+		metaDirs = forceDeletedBucketMetaDirs
+	}
+	for _, dir := range metaDirs {
+		err = disk.RenameFile(minioMetaBucket, retainSlash(pathJoin(dir, bucket)), minioMetaBucket, retainSlash(pathJoin(tmpPath, dir)))
+		if err != nil && err != errFileNotFound {
+			return err
+		}
+	}
+	return disk.DeleteVol(bucket)
+}
+
+// restoreBucketFromTmp - moves the entries of a bucket moved by
+// moveBucketToTmp back, also if it failed half way.
+func restoreBucketFromTmp(disk StorageAPI, bucket, tmpPath string) error {
+	if err := disk.MakeVol(bucket); err != nil && err != errVolumeExists {
+		return err
+	}
+	dataDir := retainSlash(pathJoin(tmpPath, deletedBucketDataDir))
+	entries, err := disk.ListDir(minioMetaBucket, dataDir)
+	if err != nil && err != errFileNotFound {
+		return err
+	}
+	for _, entry := range entries {
+		if err = disk.RenameFile(minioMetaBucket, pathJoin(dataDir, entry), bucket, entry); err != nil {
+			return err
+		}
+	}
+	for _, dir := range forceDeletedBucketMetaDirs {
+		err = disk.RenameFile(minioMetaBucket, retainSlash(pathJoin(tmpPath, dir)), minioMetaBucket, retainSlash(pathJoin(dir, bucket)))
+		if err != nil && err != errFileNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// runBucketPurge - runs the removal of the entries of a deleted
+// bucket, in the background so deletion does not wait for it. Tests
+// replace it to purge before the deletion returns.
+var runBucketPurge = func(purge func()) { go purge() }
+
+// purgeDeletedBucket - removes the entries of a deleted bucket moved
+// below tmpPath on disks. Entries left by a server stopped meanwhile
+// are removed with the temporary directory on the next start.
+func purgeDeletedBucket(disks []StorageAPI, tmpPath string) {
+	runBucketPurge(func() {
+		var wg = &sync.WaitGroup{}
+		for _, disk := range disks {
+			if disk == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(disk StorageAPI) {
+				defer wg.Done()
+				errorIf(cleanupDir(disk, minioMetaBucket, tmpPath), "Unable to remove deleted bucket entries in %s.", tmpPath)
+			}(disk)
+		}
+		wg.Wait()
+	})
+}
+
+// prepareDeleteBucket - moves a bucket below tmpPath on all disks, must
+// be called with the namespace lock of the bucket held. The bucket is
+// moved back if it has objects and force is not set or it could not be
+// moved on every disk holding it.
+func (xl xlObjects) prepareDeleteBucket(bucket, tmpPath string, force bool) error {
+	var wg = &sync.WaitGroup{}
+	var dErrs = make([]error, len(xl.storageDisks))
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			dErrs[index] = errDiskNotFound
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			dErrs[index] = moveBucketToTmp(disk, bucket, tmpPath, force)
+		}(index, disk)
+	}
+	wg.Wait()
+
+	// Disks which do not have the bucket or are offline have nothing
+	// to undo, any other error undoes the deletion on all disks.
+	var volumeNotFoundErrCnt int
+	var firstErr error
+	for _, err := range dErrs {
+		switch err {
+		case nil:
+		case errVolumeNotFound, errDiskNotFound, errFaultyDisk:
+			volumeNotFoundErrCnt++
+		case errVolumeNotEmpty:
+			firstErr = err
+		default:
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		xl.undoDeleteBucket(bucket, tmpPath, dErrs)
+		return toObjectErr(firstErr, bucket)
+	}
+	if volumeNotFoundErrCnt == len(xl.storageDisks) {
+		return toObjectErr(errVolumeNotFound, bucket)
+	}
+	return nil
+}
+
+// undoDeleteBucket - moves a bucket moved by prepareDeleteBucket back
+// on the disks which held it, dErrs are the errors of the move.
+func (xl xlObjects) undoDeleteBucket(bucket, tmpPath string, dErrs []error) {
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		if dErrs != nil {
+			switch dErrs[index] {
+			case errVolumeNotFound, errDiskNotFound, errFaultyDisk:
+				continue
+			}
+		}
+		errorIf(restoreBucketFromTmp(disk, bucket, tmpPath), "Unable to restore bucket %s.", bucket)
+	}
+}
+
+// deleteBucket - deletes a bucket on all disks, with its objects if
+// force is set.
+func (xl xlObjects) deleteBucket(bucket string, force bool) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+
+	nsMutex.Lock(bucket, "")
+	defer nsMutex.Unlock(bucket, "")
+
+	tmpPath := pathJoin(tmpMetaPrefix, getUUID())
+	if err := xl.prepareDeleteBucket(bucket, tmpPath, force); err != nil {
+		return err
+	}
+	xl.metaCache.InvalidateBucket(bucket)
+	purgeDeletedBucket(xl.storageDisks, tmpPath)
+	return nil
+}
+
+// DeleteBucketForce - deletes a bucket with all its objects and
+// uploads.
+func (xl xlObjects) DeleteBucketForce(bucket string) error {
+	return xl.deleteBucket(bucket, true)
+}
+
+// deleteBucket - deletes a bucket, with its objects if force is set.
+func (fs fsObjects) deleteBucket(bucket string, force bool) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+
+	nsMutex.Lock(bucket, "")
+	defer nsMutex.Unlock(bucket, "")
+
+	tmpPath := pathJoin(tmpMetaPrefix, getUUID())
+	if err := moveBucketToTmp(fs.storage, bucket, tmpPath, force); err != nil {
+		if err != errVolumeNotFound {
+			errorIf(restoreBucketFromTmp(fs.storage, bucket, tmpPath), "Unable to restore bucket %s.", bucket)
+		}
+		return toObjectErr(err, bucket)
+	}
+	purgeDeletedBucket([]StorageAPI{fs.storage}, tmpPath)
+	return nil
+}
+
+// DeleteBucketForce - deletes a bucket with all its objects and
+// uploads.
+func (fs fsObjects) DeleteBucketForce(bucket string) error {
+	return fs.deleteBucket(bucket, true)
+}
+
+// deleteBucket - deletes a bucket on all sets, with its objects if
+// force is set. The bucket is moved back on all sets if it cannot be
+// deleted on one of them.
+func (s *xlSets) deleteBucket(bucket string, force bool) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+
+	nsMutex.Lock(bucket, "")
+	defer nsMutex.Unlock(bucket, "")
+
+	tmpPath := pathJoin(tmpMetaPrefix, getUUID())
+	var prepared []xlObjects
+	for _, set := range s.sets {
+		err := set.prepareDeleteBucket(bucket, tmpPath, force)
+		if _, ok := err.(BucketNotFound); ok {
+			continue
+		}
+		if err != nil {
+			for _, xl := range prepared {
+				xl.undoDeleteBucket(bucket, tmpPath, nil)
+			}
+			return err
+		}
+		prepared = append(prepared, set)
+	}
+	if len(prepared) == 0 {
+		return BucketNotFound{Bucket: bucket}
+	}
+	for _, xl := range prepared {
+		xl.metaCache.InvalidateBucket(bucket)
+		purgeDeletedBucket(xl.storageDisks, tmpPath)
+	}
+	return nil
+}
+
+// DeleteBucketForce - deletes a bucket with all its objects and uploads
+// on all sets.
+func (s *xlSets) DeleteBucketForce(bucket string) error {
+	return s.deleteBucket(bucket, true)
+}
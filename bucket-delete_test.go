@@ -0,0 +1,226 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func init() {
+	// Tests purge deleted buckets before the deletion returns, a purge
+	// still running would race with later tests.
+	runBucketPurge = func(purge func()) { purge() }
+}
+
+// Tests buckets with objects are only deleted if forced, with their
+// objects and uploads.
+func TestDeleteBucketForce(t *testing.T) {
+	ExecObjectLayerTest(t, testDeleteBucketForce)
+}
+
+func testDeleteBucketForce(obj ObjectLayer, instanceType string, t *testing.T) {
+	initNSLock()
+	if err := obj.MakeBucket("bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := []byte("hello")
+	for _, object := range []string{"object", "dir/object"} {
+		if _, err := obj.PutObject(context.Background(), "bucket", object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	if _, err := obj.NewMultipartUpload("bucket", "upload", nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	if err := obj.DeleteBucket("bucket"); err != (BucketNotEmpty{Bucket: "bucket"}) {
+		t.Fatalf("%s: expected BucketNotEmpty, got %v", instanceType, err)
+	}
+	if _, err := obj.GetObjectInfo("bucket", "dir/object"); err != nil {
+		t.Fatalf("%s: object lost by refused deletion: %v", instanceType, err)
+	}
+
+	deleter, ok := obj.(bucketForceDeleter)
+	if !ok {
+		t.Fatalf("%s: forced deletion not supported", instanceType)
+	}
+	if err := deleter.DeleteBucketForce("bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err := obj.GetBucketInfo("bucket"); err != (BucketNotFound{Bucket: "bucket"}) {
+		t.Fatalf("%s: expected BucketNotFound, got %v", instanceType, err)
+	}
+	if err := deleter.DeleteBucketForce("bucket"); err != (BucketNotFound{Bucket: "bucket"}) {
+		t.Fatalf("%s: expected BucketNotFound, got %v", instanceType, err)
+	}
+
+	// A recreated bucket has none of the deleted objects and uploads.
+	if err := obj.MakeBucket("bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	result, err := obj.ListObjects("bucket", "", "", "", 10)
+	if err != nil || len(result.Objects) != 0 {
+		t.Fatalf("%s: unexpected objects %v: %v", instanceType, result.Objects, err)
+	}
+	if _, err = obj.GetObjectInfo("bucket", "object"); err == nil {
+		t.Fatalf("%s: deleted object found", instanceType)
+	}
+	uploads, err := obj.ListMultipartUploads("bucket", "", "", "", "", 10)
+	if err != nil || len(uploads.Uploads) != 0 {
+		t.Fatalf("%s: unexpected uploads %v: %v", instanceType, uploads.Uploads, err)
+	}
+
+	// Uploads do not keep a bucket from being deleted.
+	if _, err = obj.NewMultipartUpload("bucket", "upload", nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = obj.DeleteBucket("bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = obj.MakeBucket("bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if uploads, err = obj.ListMultipartUploads("bucket", "", "", "", "", 10); err != nil || len(uploads.Uploads) != 0 {
+		t.Fatalf("%s: unexpected uploads %v: %v", instanceType, uploads.Uploads, err)
+	}
+}
+
+// Tests a bucket which is not empty on one disk is kept on all disks.
+func TestXLDeleteBucketAtomic(t *testing.T) {
+	initNSLock()
+	obj, disks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	xl := obj.(xlObjects)
+	if err = obj.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err = xl.storageDisks[3].AppendFile(context.Background(), "bucket", "stray", []byte("stray")); err != nil {
+		t.Fatal(err)
+	}
+	if err = obj.DeleteBucket("bucket"); err != (BucketNotEmpty{Bucket: "bucket"}) {
+		t.Fatalf("Expected BucketNotEmpty, got %v", err)
+	}
+	for i, disk := range xl.storageDisks {
+		if _, err = disk.StatVol("bucket"); err != nil {
+			t.Fatalf("Bucket removed from disk %d: %v", i, err)
+		}
+	}
+	if _, err = xl.storageDisks[3].StatFile("bucket", "stray"); err != nil {
+		t.Fatal(err)
+	}
+	if err = xl.DeleteBucketForce("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for i, disk := range xl.storageDisks {
+		if _, err = disk.StatVol("bucket"); err != errVolumeNotFound {
+			t.Fatalf("Bucket left on disk %d: %v", i, err)
+		}
+	}
+}
+
+// Tests a bucket with objects on one erasure set is kept on all sets.
+func TestXLSetsDeleteBucket(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := objAPI.(*xlSets)
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	if _, err = objAPI.PutObject(context.Background(), "bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.DeleteBucket("bucket"); err != (BucketNotEmpty{Bucket: "bucket"}) {
+		t.Fatalf("Expected BucketNotEmpty, got %v", err)
+	}
+	for i, set := range sets.sets {
+		if _, err = set.GetBucketInfo("bucket"); err != nil {
+			t.Fatalf("Bucket removed from set %d: %v", i, err)
+		}
+	}
+	if err = sets.DeleteBucketForce("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for i, set := range sets.sets {
+		if _, err = set.GetBucketInfo("bucket"); err == nil {
+			t.Fatalf("Bucket left on set %d", i)
+		}
+	}
+}
+
+// Tests forced deletion is refused for buckets with locked objects and
+// in WORM mode.
+func TestCheckForceDeleteLocks(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	ExecObjectLayerTest(t, testCheckForceDeleteLocks)
+}
+
+func testCheckForceDeleteLocks(obj ObjectLayer, instanceType string, t *testing.T) {
+	if err := obj.MakeBucket("bucket"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	defer removeBucketObjectLock("bucket")
+	data := []byte("hello")
+	if _, err := obj.PutObject(context.Background(), "bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	r, err := http.NewRequest("DELETE", "/bucket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3Error := checkForceDeleteLocks(obj, "bucket", r); s3Error != ErrNone {
+		t.Fatalf("%s: expected no locked objects, got %v", instanceType, s3Error)
+	}
+
+	globalWORMEnabled = true
+	s3Error := checkForceDeleteLocks(obj, "bucket", r)
+	globalWORMEnabled = false
+	if s3Error != ErrObjectLocked {
+		t.Fatalf("%s: expected ErrObjectLocked in WORM mode, got %v", instanceType, s3Error)
+	}
+
+	if err = writeObjectLockInfo(obj, "bucket", "object", objectLockInfo{LegalHold: true}); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if s3Error = checkForceDeleteLocks(obj, "bucket", r); s3Error != ErrObjectLocked {
+		t.Fatalf("%s: expected ErrObjectLocked under legal hold, got %v", instanceType, s3Error)
+	}
+}
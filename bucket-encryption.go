@@ -0,0 +1,278 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	mux "github.com/gorilla/mux"
+)
+
+const (
+	// Default encryption configuration file saved in bucket config path.
+	bucketEncryptionConfigFile = "encryption.xml"
+
+	// Maximum size of a bucket encryption configuration request.
+	maxBucketEncryptionConfigSize = 4 * 1024
+)
+
+// ApplySSEByDefault - default encryption applied to new objects, the
+// master key can only be selected for SSE-KMS.
+type ApplySSEByDefault struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty"`
+}
+
+// SSERule - bucket encryption rule.
+type SSERule struct {
+	ApplySSEByDefault ApplySSEByDefault `xml:"ApplyServerSideEncryptionByDefault"`
+}
+
+// BucketEncryptionConfiguration - bucket default encryption configuration.
+type BucketEncryptionConfiguration struct {
+	XMLName xml.Name  `xml:"ServerSideEncryptionConfiguration"`
+	Rules   []SSERule `xml:"Rule"`
+}
+
+// parseBucketEncryptionConfig - parses and validates bucket encryption
+// configuration, exactly one rule is supported.
+func parseBucketEncryptionConfig(configBytes []byte) (*BucketEncryptionConfiguration, APIErrorCode) {
+	config := &BucketEncryptionConfiguration{}
+	if err := xml.Unmarshal(configBytes, config); err != nil {
+		return nil, ErrMalformedXML
+	}
+	if len(config.Rules) != 1 {
+		return nil, ErrInvalidBucketEncryptionConfiguration
+	}
+	sse := config.Rules[0].ApplySSEByDefault
+	switch sse.SSEAlgorithm {
+	case sseAlgorithmAES256:
+		if sse.KMSMasterKeyID != "" {
+			return nil, ErrInvalidBucketEncryptionConfiguration
+		}
+	case sseAlgorithmKMS:
+	default:
+		return nil, ErrInvalidEncryptionMethod
+	}
+	return config, ErrNone
+}
+
+// readBucketEncryptionConfig - read bucket default encryption configuration.
+func readBucketEncryptionConfig(bucket string) (*BucketEncryptionConfiguration, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, bucketEncryptionConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, BucketEncryptionConfigNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	config := &BucketEncryptionConfiguration{}
+	if err = xml.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeBucketEncryptionConfig - save bucket default encryption configuration.
+func writeBucketEncryptionConfig(bucket string, config *BucketEncryptionConfiguration) error {
+	// Verify if bucket path legal
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	configBytes, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfigFile(filepath.Join(bucketConfigPath, bucketEncryptionConfigFile), configBytes)
+}
+
+// removeBucketEncryptionConfig - remove default encryption configuration,
+// keys of existing encrypted objects are kept.
+func removeBucketEncryptionConfig(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return removeBucketConfigFile(filepath.Join(bucketConfigPath, bucketEncryptionConfigFile))
+}
+
+// checkKMSKey - verifies the key management service knows the master
+// key of an encryption configuration by generating a data key with it.
+func checkKMSKey(config *BucketEncryptionConfiguration) error {
+	sse := config.Rules[0].ApplySSEByDefault
+	if sse.SSEAlgorithm != sseAlgorithmKMS || sse.KMSMasterKeyID == "" {
+		return nil
+	}
+	_, _, err := globalKMS.GenerateKey(getKMSKeyID(sse.KMSMasterKeyID), nil)
+	return err
+}
+
+// PutBucketEncryptionHandler - PUT Bucket encryption.
+// ----------
+// Sets the default server side encryption applied to all new objects
+// which do not request encryption explicitly.
+func (api objectAPIHandlers) PutBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// Default encryption is useless without a key management service.
+	if globalKMS == nil {
+		writeErrorResponse(w, r, ErrKMSNotConfigured, r.URL.Path)
+		return
+	}
+
+	if !contains(r.TransferEncoding, "chunked") {
+		if r.ContentLength == -1 || r.ContentLength == 0 {
+			writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
+			return
+		}
+		if r.ContentLength > maxBucketEncryptionConfigSize {
+			writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+			return
+		}
+	}
+	configBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketEncryptionConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read bucket encryption configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config, s3Error := parseBucketEncryptionConfig(configBytes)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if err = checkKMSKey(config); err != nil {
+		errorIf(err, "Unable to generate a data key with the master key.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	if err = writeBucketEncryptionConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write bucket encryption configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetBucketEncryptionHandler - GET Bucket encryption.
+func (api objectAPIHandlers) GetBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketEncryptionConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read bucket encryption configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeResponse(config)
+	setCommonHeaders(w)
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
+// DeleteBucketEncryptionHandler - DELETE Bucket encryption.
+// ----------
+// Removes the default encryption of a bucket, existing objects stay
+// encrypted.
+func (api objectAPIHandlers) DeleteBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	if err := removeBucketEncryptionConfig(bucket); err != nil {
+		errorIf(err, "Unable to remove bucket encryption configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
@@ -0,0 +1,48 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests parsing of bucket default encryption configurations.
+func TestParseBucketEncryptionConfig(t *testing.T) {
+	testCases := []struct {
+		config string
+		s3Err  APIErrorCode
+	}{
+		// Test case - 1.
+		{`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`, ErrNone},
+		// Test case - 2.
+		{`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`, ErrNone},
+		// Test case - 3.
+		{`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm><KMSMasterKeyID>my-key</KMSMasterKeyID></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`, ErrNone},
+		// Test case - 4.
+		// Master key without SSE-KMS.
+		{`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm><KMSMasterKeyID>my-key</KMSMasterKeyID></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`, ErrInvalidBucketEncryptionConfiguration},
+		// Test case - 5.
+		{`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>DES</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`, ErrInvalidEncryptionMethod},
+		// Test case - 6.
+		{`<ServerSideEncryptionConfiguration></ServerSideEncryptionConfiguration>`, ErrInvalidBucketEncryptionConfiguration},
+		// Test case - 7.
+		{`<ServerSideEncryptionConfiguration>`, ErrMalformedXML},
+	}
+	for i, testCase := range testCases {
+		if _, s3Err := parseBucketEncryptionConfig([]byte(testCase.config)); s3Err != testCase.s3Err {
+			t.Errorf("Test %d: Expected %d, but got %d", i+1, testCase.s3Err, s3Err)
+		}
+	}
+}
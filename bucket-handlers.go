@@ -27,6 +27,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	mux "github.com/gorilla/mux"
 )
@@ -117,15 +118,21 @@ func (api objectAPIHandlers) GetBucketLocationHandler(w http.ResponseWriter, r *
 	}
 
 	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
-		errorIf(err, "Unable to fetch bucket info.")
+		errorIfRequest(r, err, "Unable to fetch bucket info.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
 
+	// Get region of the bucket.
+	region, err := readBucketLocation(bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket location.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
 	// Generate response.
 	encodedSuccessResponse := encodeResponse(LocationResponse{})
-	// Get current region.
-	region := serverConfig.GetRegion()
 	if region != "us-east-1" {
 		encodedSuccessResponse = encodeResponse(LocationResponse{
 			Location: region,
@@ -165,28 +172,24 @@ func (api objectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter,
 		}
 	}
 
-	prefix, keyMarker, uploadIDMarker, delimiter, maxUploads, _ := getBucketMultipartResources(r.URL.Query())
+	prefix, keyMarker, uploadIDMarker, delimiter, maxUploads, encodingType := getBucketMultipartResources(r.URL.Query())
 	if maxUploads < 0 {
 		writeErrorResponse(w, r, ErrInvalidMaxUploads, r.URL.Path)
 		return
 	}
-	if keyMarker != "" {
-		// Marker not common with prefix is not implemented.
-		if !strings.HasPrefix(keyMarker, prefix) {
-			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
-			return
-		}
+	if encodingType != "" && encodingType != "url" {
+		writeErrorResponse(w, r, ErrInvalidEncodingMethod, r.URL.Path)
+		return
 	}
-
 	listMultipartsInfo, err := api.ObjectAPI.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
 	if err != nil {
-		errorIf(err, "Unable to list multipart uploads.")
+		errorIfRequest(r, err, "Unable to list multipart uploads.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
 	// generate response
-	response := generateListMultipartUploadsResponse(bucket, listMultipartsInfo)
-	encodedSuccessResponse := encodeResponse(response)
+	response := generateListMultipartUploadsResponse(bucket, encodingType, listMultipartsInfo)
+	encodedSuccessResponse := encodeListResponse(response)
 	// write headers.
 	setCommonHeaders(w)
 	// write success response.
@@ -214,35 +217,42 @@ func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.R
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
+		// Browsers of public buckets get the index page, if enabled.
+		if serveBucketIndex(w, r, api.ObjectAPI, bucket) {
+			return
+		}
 	case authTypeSigned, authTypePresigned:
 		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
 	}
-	var prefix, marker, token, delimiter, startAfter string
+	var prefix, marker, token, delimiter, startAfter, encodingType string
 	var maxkeys int
-	var listV2 bool
-	// TODO handle encoding type.
+	var listV2, fetchOwner bool
 	if r.URL.Query().Get("list-type") == "2" {
 		listV2 = true
-		prefix, token, startAfter, delimiter, maxkeys, _ = getListObjectsV2Args(r.URL.Query())
+		prefix, token, startAfter, delimiter, fetchOwner, maxkeys, encodingType = getListObjectsV2Args(r.URL.Query())
 		// For ListV2 "start-after" is considered only if "continuation-token" is empty.
 		if token == "" {
 			marker = startAfter
 		} else {
-			marker = token
+			var err error
+			if marker, err = decodeContinuationToken(token); err != nil {
+				writeErrorResponse(w, r, ErrIncorrectContinuationToken, r.URL.Path)
+				return
+			}
 		}
 	} else {
-		prefix, marker, delimiter, maxkeys, _ = getListObjectsV1Args(r.URL.Query())
+		prefix, marker, delimiter, maxkeys, encodingType = getListObjectsV1Args(r.URL.Query())
 	}
 	if maxkeys < 0 {
 		writeErrorResponse(w, r, ErrInvalidMaxKeys, r.URL.Path)
 		return
 	}
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != "/" {
-		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+	// Only URL encoding of the listed keys is supported.
+	if encodingType != "" && encodingType != "url" {
+		writeErrorResponse(w, r, ErrInvalidEncodingMethod, r.URL.Path)
 		return
 	}
 	// If marker is set unescape.
@@ -257,14 +267,16 @@ func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.R
 	listObjectsInfo, err := api.ObjectAPI.ListObjects(bucket, prefix, marker, delimiter, maxkeys)
 
 	if err == nil {
+		// Report plain sizes of encrypted objects.
+		setListedObjectSizes(bucket, listObjectsInfo.Objects)
 		var encodedSuccessResponse []byte
 		// generate response
 		if listV2 {
-			response := generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, maxkeys, listObjectsInfo)
-			encodedSuccessResponse = encodeResponse(response)
+			response := generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, fetchOwner, maxkeys, encodingType, listObjectsInfo)
+			encodedSuccessResponse = encodeListResponse(response)
 		} else {
-			response := generateListObjectsResponse(bucket, prefix, marker, delimiter, maxkeys, listObjectsInfo)
-			encodedSuccessResponse = encodeResponse(response)
+			response := generateListObjectsResponse(bucket, prefix, marker, delimiter, maxkeys, encodingType, listObjectsInfo)
+			encodedSuccessResponse = encodeListResponse(response)
 		}
 		// Write headers
 		setCommonHeaders(w)
@@ -272,14 +284,16 @@ func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.R
 		writeSuccessResponse(w, encodedSuccessResponse)
 		return
 	}
-	errorIf(err, "Unable to list objects.")
+	errorIfRequest(r, err, "Unable to list objects.")
 	writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 }
 
 // ListBucketsHandler - GET Service
 // -----------
 // This implementation of the GET operation returns a list of all buckets
-// owned by the authenticated sender of the request.
+// owned by the authenticated sender of the request. With "usage=true"
+// object count and total size of each bucket from the last data usage
+// crawl are included.
 func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.Request) {
 	// List buckets does not support bucket policies.
 	switch getRequestAuthType(r) {
@@ -319,6 +333,16 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 	if err == nil {
 		// generate response
 		response := generateListBucketsResponse(bucketsInfo)
+		// Bucket usage is a Minio extension, only added on request.
+		if r.URL.Query().Get("usage") == "true" {
+			usage, err := loadDataUsage(api.ObjectAPI)
+			if err != nil {
+				errorIfRequest(r, err, "Unable to load data usage.")
+				writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+				return
+			}
+			setListBucketsUsage(&response, usage)
+		}
 		encodedSuccessResponse := encodeResponse(response)
 		// write headers
 		setCommonHeaders(w)
@@ -326,10 +350,40 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 		writeSuccessResponse(w, encodedSuccessResponse)
 		return
 	}
-	errorIf(err, "Unable to list buckets.")
+	errorIfRequest(r, err, "Unable to list buckets.")
 	writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 }
 
+// Limits of a multi-object delete request.
+const (
+	// Maximum size of the XML body listing the objects.
+	maxDeleteObjectsSize = 2 * 1024 * 1024
+
+	// Number of objects deleted concurrently.
+	deleteObjectsConcurrency = 16
+)
+
+// deleteObject - deletes a single object of a multi-object delete
+// request, objects protected by object lock are not deleted.
+func (api objectAPIHandlers) deleteObject(bucket, object string, r *http.Request) APIErrorCode {
+	if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		return s3Error
+	}
+	if err := deleteObjectOrTrash(api.ObjectAPI, bucket, object); err != nil {
+		// Deleting an object which does not exist is not an error.
+		if _, ok := err.(ObjectNotFound); ok {
+			return ErrNone
+		}
+		errorIfRequest(r, err, "Unable to delete object.")
+		return toAPIErrorCode(err)
+	}
+	// Object is gone, remove any expired retention left behind.
+	removeObjectLockInfo(api.ObjectAPI, bucket, object)
+	// Mirror the delete to the replication target.
+	queueReplication(r, bucket, object, true)
+	return ErrNone
+}
+
 // DeleteMultipleObjectsHandler - deletes multiple objects.
 func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -359,6 +413,10 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
 		return
 	}
+	if r.ContentLength > maxDeleteObjectsSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
 
 	// Content-Md5 is requied should be set
 	// http://docs.aws.amazon.com/AmazonS3/latest/API/multiobjectdeleteapi.html
@@ -372,36 +430,65 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 
 	// Read incoming body XML bytes.
 	if _, err := io.ReadFull(r.Body, deleteXMLBytes); err != nil {
-		errorIf(err, "Unable to read HTTP body.")
+		errorIfRequest(r, err, "Unable to read HTTP body.")
 		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		return
 	}
 
+	// Verify Content-Md5 against the body, signed requests were
+	// verified already while authenticating.
+	if r.Header.Get("Content-Md5") != base64.StdEncoding.EncodeToString(sumMD5(deleteXMLBytes)) {
+		writeErrorResponse(w, r, ErrBadDigest, r.URL.Path)
+		return
+	}
+
 	// Unmarshal list of keys to be deleted.
 	deleteObjects := &DeleteObjectsRequest{}
 	if err := xml.Unmarshal(deleteXMLBytes, deleteObjects); err != nil {
-		errorIf(err, "Unable to unmarshal delete objects request XML.")
+		errorIfRequest(r, err, "Unable to unmarshal delete objects request XML.")
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	// At most 1000 objects can be deleted in a single request.
+	if len(deleteObjects.Objects) == 0 || len(deleteObjects.Objects) > maxDeleteList {
 		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
 		return
 	}
 
+	// Delete all the objects concurrently, errors are collected
+	// per object.
+	errs := make([]APIErrorCode, len(deleteObjects.Objects))
+	objectCh := make(chan int)
+	var wg = &sync.WaitGroup{}
+	for i := 0; i < deleteObjectsConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range objectCh {
+				errs[index] = api.deleteObject(bucket, deleteObjects.Objects[index].ObjectName, r)
+			}
+		}()
+	}
+	for index := range deleteObjects.Objects {
+		objectCh <- index
+	}
+	close(objectCh)
+	wg.Wait()
+
 	var deleteErrors []DeleteError
 	var deletedObjects []ObjectIdentifier
-	// Loop through all the objects and delete them sequentially.
-	for _, object := range deleteObjects.Objects {
-		err := api.ObjectAPI.DeleteObject(bucket, object.ObjectName)
-		if err == nil {
-			deletedObjects = append(deletedObjects, ObjectIdentifier{
-				ObjectName: object.ObjectName,
-			})
-		} else {
-			errorIf(err, "Unable to delete object.")
+	for index, object := range deleteObjects.Objects {
+		if errs[index] != ErrNone {
 			deleteErrors = append(deleteErrors, DeleteError{
-				Code:    errorCodeResponse[toAPIErrorCode(err)].Code,
-				Message: errorCodeResponse[toAPIErrorCode(err)].Description,
+				Code:    errorCodeResponse[errs[index]].Code,
+				Message: errorCodeResponse[errs[index]].Description,
 				Key:     object.ObjectName,
 			})
+			continue
 		}
+		deletedObjects = append(deletedObjects, ObjectIdentifier{
+			ObjectName: object.ObjectName,
+		})
 	}
 	// Generate response
 	response := generateMultiDeleteResponse(deleteObjects.Quiet, deletedObjects, deleteErrors)
@@ -432,50 +519,69 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	// the location value in the request body should match the Region in serverConfig.
-	// other values of location are not accepted.
-	// make bucket fails in such cases.
-	errCode := isValidLocationContraint(r.Body, serverConfig.GetRegion())
+	// The location value in the request body is the region of the
+	// new bucket, defaults to the Region in serverConfig.
+	location, errCode := parseLocationConstraint(r.Body, serverConfig.GetRegion())
 	if errCode != ErrNone {
 		writeErrorResponse(w, r, errCode, r.URL.Path)
 		return
 	}
-	// Make bucket.
-	err := api.ObjectAPI.MakeBucket(bucket)
+	// Make bucket, registered with the federation if enabled.
+	err := makeFederatedBucket(api.ObjectAPI, bucket)
 	if err != nil {
-		errorIf(err, "Unable to create a bucket.")
+		errorIfRequest(r, err, "Unable to create a bucket.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	// Save the region of the bucket.
+	if err = writeBucketLocation(bucket, location); err != nil {
+		errorIfRequest(r, err, "Unable to save bucket location.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	// Enable object lock if requested during bucket creation.
+	if strings.ToLower(r.Header.Get(amzObjectLockEnabled)) == "true" {
+		if err = writeBucketObjectLockConfig(bucket, &ObjectLockConfiguration{
+			ObjectLockEnabled: "Enabled",
+		}); err != nil {
+			errorIfRequest(r, err, "Unable to enable object lock.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
 	// Make sure to add Location information here only for bucket
 	w.Header().Set("Location", getLocation(r))
 	writeSuccessResponse(w, nil)
 }
 
+// Maximum size of a POST policy form field other than the file.
+const maxFormFieldSize = 1 << 20
+
+// extractHTTPFormValues - returns the form values and the file of a
+// POST policy request. The file is streamed from the request instead of
+// being buffered, it must be the last field of the form as fields after
+// it are ignored like S3 does.
 func extractHTTPFormValues(reader *multipart.Reader) (io.Reader, map[string]string, error) {
 	/// HTML Form values
 	formValues := make(map[string]string)
-	filePart := new(bytes.Buffer)
-	var err error
-	for err == nil {
-		var part *multipart.Part
-		part, err = reader.NextPart()
-		if part != nil {
-			if part.FileName() == "" {
-				var buffer []byte
-				buffer, err = ioutil.ReadAll(part)
-				if err != nil {
-					return nil, nil, err
-				}
-				formValues[http.CanonicalHeaderKey(part.FormName())] = string(buffer)
-			} else {
-				if _, err = io.Copy(filePart, part); err != nil {
-					return nil, nil, err
-				}
-			}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			// No file, an empty object is created.
+			return bytes.NewReader(nil), formValues, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if part.FileName() != "" {
+			return part, formValues, nil
+		}
+		buffer, err := ioutil.ReadAll(io.LimitReader(part, maxFormFieldSize))
+		if err != nil {
+			return nil, nil, err
 		}
+		formValues[http.CanonicalHeaderKey(part.FormName())] = string(buffer)
 	}
-	return filePart, formValues, nil
 }
 
 // PostPolicyBucketHandler - POST policy
@@ -487,14 +593,14 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	// be loaded in memory, the remaining being put in temporary files.
 	reader, err := r.MultipartReader()
 	if err != nil {
-		errorIf(err, "Unable to initialize multipart reader.")
+		errorIfRequest(r, err, "Unable to initialize multipart reader.")
 		writeErrorResponse(w, r, ErrMalformedPOSTRequest, r.URL.Path)
 		return
 	}
 
 	fileBody, formValues, err := extractHTTPFormValues(reader)
 	if err != nil {
-		errorIf(err, "Unable to parse form values.")
+		errorIfRequest(r, err, "Unable to parse form values.")
 		writeErrorResponse(w, r, ErrMalformedPOSTRequest, r.URL.Path)
 		return
 	}
@@ -513,19 +619,59 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Verify if existing object is not protected by object lock.
+	if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Bucket default retention is applied on the new object.
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	// Bucket default encryption is applied on the new object.
+	sse, encrypt, s3Error := getObjectEncryptionRequest(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
 	// Save metadata.
 	metadata := make(map[string]string)
 	// Nothing to store right now.
 
-	md5Sum, err := api.ObjectAPI.PutObject(bucket, object, -1, fileBody, metadata)
+	// Verify if the new object is to be compressed.
+	compress := getObjectCompressionRequest(api.ObjectAPI, object, metadata, encrypt)
+
+	var md5Sum string
+	if encrypt {
+		md5Sum, err = putEncryptedObject(r.Context(), api.ObjectAPI, bucket, object, sse, -1, fileBody, metadata)
+	} else if compress {
+		md5Sum, err = putCompressedObject(r.Context(), api.ObjectAPI, bucket, object, fileBody, metadata)
+	} else {
+		md5Sum, err = api.ObjectAPI.PutObject(r.Context(), bucket, object, -1, fileBody, metadata)
+	}
 	if err != nil {
-		errorIf(err, "Unable to create object.")
+		errorIfRequest(r, err, "Unable to create object.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	// Save retention and legal hold for the new object.
+	if err = writeObjectLockInfo(api.ObjectAPI, bucket, object, lockInfo); err != nil {
+		errorIfRequest(r, err, "Unable to save object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
 	if md5Sum != "" {
 		w.Header().Set("ETag", "\""+md5Sum+"\"")
 	}
+	if encrypt {
+		setObjectEncryptionHeaders(w, sse.Algorithm, sse.KeyID)
+	}
+	// Mirror the new object to the replication target.
+	queueReplication(r, bucket, object, false)
 	encodedSuccessResponse := encodeResponse(PostResponse{
 		Location: getObjectLocation(bucket, object), // TODO Full URL is preferred
 		Bucket:   bucket,
@@ -565,10 +711,17 @@ func (api objectAPIHandlers) HeadBucketHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
-		errorIf(err, "Unable to fetch bucket info.")
+		errorIfRequest(r, err, "Unable to fetch bucket info.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	// Backup products check for object lock before writing backups.
+	if region := getBucketRegion(bucket); region != "" {
+		w.Header().Set("X-Amz-Bucket-Region", region)
+	}
+	if isObjectLockEnabled(bucket) {
+		w.Header().Set(amzObjectLockEnabled, "true")
+	}
 	writeSuccessResponse(w, nil)
 }
 
@@ -589,8 +742,26 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 		}
 	}
 
-	if err := api.ObjectAPI.DeleteBucket(bucket); err != nil {
-		errorIf(err, "Unable to delete a bucket.")
+	// Buckets are deleted with their objects on request, as an extension.
+	force := isForceDelete(r.Header.Get(minioForceDeleteHeader))
+	var err error
+	if force {
+		deleter, ok := api.ObjectAPI.(bucketForceDeleter)
+		if !ok {
+			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+			return
+		}
+		// Objects under object lock are not deleted with the bucket.
+		if s3Error := checkForceDeleteLocks(api.ObjectAPI, bucket, r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+		err = deleter.DeleteBucketForce(bucket)
+	} else {
+		err = api.ObjectAPI.DeleteBucket(bucket)
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to delete a bucket.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
@@ -598,6 +769,35 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 	// Delete bucket access policy, if present - ignore any errors.
 	removeBucketPolicy(bucket)
 
+	// Delete bucket object lock configuration, if present - ignore any errors.
+	removeBucketObjectLock(bucket)
+
+	// Delete bucket encryption configuration, if present - ignore any errors.
+	removeBucketEncryptionConfig(bucket)
+
+	// Delete bucket location, if present - ignore any errors.
+	removeBucketLocation(bucket)
+
+	// Delete bucket replication configuration, if present - ignore any errors.
+	removeBucketReplication(bucket)
+
+	// Delete bucket index page configuration, if present - ignore any errors.
+	removeBucketIndex(bucket)
+
+	// Delete all remaining configurations including those of the deleted
+	// objects, if the bucket was deleted with its objects.
+	if force {
+		errorIfRequest(r, removeBucketConfigs(bucket), "Unable to remove bucket configurations.")
+	}
+	if globalReplication != nil {
+		globalReplication.invalidate(bucket)
+	}
+
+	// Remove the bucket from the federation.
+	if globalFederation != nil {
+		errorIfRequest(r, globalFederation.Delete(bucket), "Unable to remove bucket DNS records.")
+	}
+
 	// Write success response.
 	writeSuccessNoContent(w)
 }
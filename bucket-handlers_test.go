@@ -0,0 +1,58 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"testing"
+)
+
+// Tests form values and the streamed file of POST policy requests.
+func TestExtractHTTPFormValues(t *testing.T) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("key", "object")
+	writer.WriteField("policy", "policy")
+	fileWriter, err := writer.CreateFormFile("file", "upload.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileWriter.Write([]byte("hello world"))
+	// Fields after the file are ignored.
+	writer.WriteField("ignored", "value")
+	writer.Close()
+
+	fileBody, formValues, err := extractHTTPFormValues(multipart.NewReader(body, writer.Boundary()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formValues["Key"] != "object" || formValues["Policy"] != "policy" {
+		t.Errorf("Unexpected form values %v", formValues)
+	}
+	if _, ok := formValues["Ignored"]; ok {
+		t.Error("Expected fields after the file to be ignored")
+	}
+	data, err := ioutil.ReadAll(fileBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("Expected file content hello world, but instead found %s", data)
+	}
+}
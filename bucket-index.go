@@ -0,0 +1,210 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Index page configuration file saved in bucket config path.
+const bucketIndexConfigFile = "index.json"
+
+// bucketIndexConfig - buckets with an index configuration serve an
+// index page to browsers listing them anonymously, provided the bucket
+// policy allows public listing.
+type bucketIndexConfig struct {
+	// Title of the index page, defaults to the bucket name.
+	Title string `json:"title,omitempty"`
+}
+
+// readBucketIndexConfig - read bucket index page configuration.
+func readBucketIndexConfig(bucket string) (*bucketIndexConfig, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, bucketIndexConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, BucketIndexConfigNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	config := &bucketIndexConfig{}
+	if err = json.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeBucketIndexConfig - save bucket index page configuration.
+func writeBucketIndexConfig(bucket string, config *bucketIndexConfig) error {
+	// Verify if bucket path legal
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfigFile(filepath.Join(bucketConfigPath, bucketIndexConfigFile), configBytes)
+}
+
+// removeBucketIndex - remove bucket index page configuration.
+func removeBucketIndex(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return removeBucketConfigFile(filepath.Join(bucketConfigPath, bucketIndexConfigFile))
+}
+
+// getBucketIndexFormat - returns the index page format accepted by
+// the request, "html" for browsers and "json" for scripts. Returns an
+// empty string for S3 clients which are answered with the regular
+// listing.
+func getBucketIndexFormat(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/html"):
+		return "html"
+	case strings.Contains(accept, "application/json"):
+		return "json"
+	}
+	return ""
+}
+
+// bucketIndexObject - object listed on an index page.
+type bucketIndexObject struct {
+	Name         string    `json:"name"`
+	URL          string    `json:"url"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// bucketIndex - a page of the index of a bucket.
+type bucketIndex struct {
+	Title      string              `json:"title"`
+	Bucket     string              `json:"bucket"`
+	Prefix     string              `json:"prefix"`
+	Prefixes   []string            `json:"prefixes"`
+	Objects    []bucketIndexObject `json:"objects"`
+	NextMarker string              `json:"nextMarker,omitempty"`
+}
+
+// Index page served to browsers.
+var bucketIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}/{{.Prefix}}</title></head>
+<body>
+<h1>{{.Title}}/{{.Prefix}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Last modified</th></tr>
+{{range .Prefixes}}<tr><td><a href="?prefix={{.}}">{{.}}</a></td><td>-</td><td>-</td></tr>
+{{end}}{{range .Objects}}<tr><td><a href="{{.URL}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.LastModified.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+{{end}}</table>
+{{if .NextMarker}}<p><a href="?prefix={{.Prefix}}&amp;marker={{.NextMarker}}">Next page</a></p>
+{{end}}</body>
+</html>
+`))
+
+// getBucketIndex - lists a page of the index of a bucket, one level
+// below prefix.
+func getBucketIndex(objAPI ObjectLayer, bucket, prefix, marker string, config *bucketIndexConfig) (bucketIndex, error) {
+	index := bucketIndex{
+		Title:    config.Title,
+		Bucket:   bucket,
+		Prefix:   prefix,
+		Prefixes: []string{},
+		Objects:  []bucketIndexObject{},
+	}
+	if index.Title == "" {
+		index.Title = bucket
+	}
+	if marker != "" && !strings.HasPrefix(marker, prefix) {
+		marker = ""
+	}
+	result, err := objAPI.ListObjects(bucket, prefix, marker, slashSeparator, maxObjectList)
+	if err != nil {
+		return index, err
+	}
+	// Report plain sizes of encrypted objects.
+	setListedObjectSizes(bucket, result.Objects)
+	index.Prefixes = append(index.Prefixes, result.Prefixes...)
+	for _, object := range result.Objects {
+		index.Objects = append(index.Objects, bucketIndexObject{
+			Name:         object.Name,
+			URL:          "/" + bucket + "/" + getURLEncodedName(object.Name),
+			Size:         object.Size,
+			LastModified: object.ModTime.UTC(),
+		})
+	}
+	if result.IsTruncated {
+		index.NextMarker = result.NextMarker
+	}
+	return index, nil
+}
+
+// serveBucketIndex - answers an anonymous listing of a bucket with its
+// index page if the bucket has an index configuration and the request
+// comes from a browser or script. Returns false if the request is to be
+// answered with the regular listing.
+func serveBucketIndex(w http.ResponseWriter, r *http.Request, objAPI ObjectLayer, bucket string) bool {
+	format := getBucketIndexFormat(r)
+	if format == "" {
+		return false
+	}
+	config, err := readBucketIndexConfig(bucket)
+	if err != nil {
+		return false
+	}
+	query := r.URL.Query()
+	index, err := getBucketIndex(objAPI, bucket, query.Get("prefix"), query.Get("marker"), config)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to list bucket index.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return true
+	}
+	setCommonHeaders(w)
+	if format == "json" {
+		writeAdminJSONResponse(w, r, index)
+		return true
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	errorIfRequest(r, bucketIndexTemplate.Execute(w, index), "Unable to write bucket index.")
+	return true
+}
@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests the index page format accepted by a request.
+func TestGetBucketIndexFormat(t *testing.T) {
+	testCases := []struct {
+		accept string
+		format string
+	}{
+		{"", ""},
+		{"*/*", ""},
+		{"application/xml", ""},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html"},
+		{"application/json", "json"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "/bucket", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", testCase.accept)
+		if format := getBucketIndexFormat(req); format != testCase.format {
+			t.Errorf("Test %d: Expected format %q, but got %q", i+1, testCase.format, format)
+		}
+	}
+}
+
+// Wrapper for calling testBucketIndex for both XL and FS.
+func TestBucketIndex(t *testing.T) {
+	ExecObjectLayerTest(t, testBucketIndex)
+}
+
+// Tests buckets with an index configuration serve index pages.
+func testBucketIndex(obj ObjectLayer, instanceType string, t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	bucket := "index-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for _, object := range []string{"release/app-1.0.tar.gz", "README <draft>.txt"} {
+		if _, err = obj.PutObject(context.Background(), bucket, object, 5, bytes.NewReader([]byte("hello")), nil); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	serve := func(url, accept string) (*httptest.ResponseRecorder, bool) {
+		req, rerr := http.NewRequest("GET", url, nil)
+		if rerr != nil {
+			t.Fatalf("%s: %s", instanceType, rerr)
+		}
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		return rec, serveBucketIndex(rec, req, obj, bucket)
+	}
+
+	// Buckets without index configuration are listed regularly.
+	if _, ok := serve("/"+bucket, "text/html"); ok {
+		t.Fatalf("%s: Expected no index page without configuration", instanceType)
+	}
+
+	if err = writeBucketIndexConfig(bucket, &bucketIndexConfig{Title: "Downloads"}); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	// S3 clients are listed regularly.
+	if _, ok := serve("/"+bucket, ""); ok {
+		t.Fatalf("%s: Expected no index page for S3 clients", instanceType)
+	}
+
+	rec, ok := serve("/"+bucket, "text/html")
+	if !ok || rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected index page, but got %v %d", instanceType, ok, rec.Code)
+	}
+	page := rec.Body.String()
+	for _, expected := range []string{
+		"<title>Downloads/</title>",
+		`href="?prefix=release%2f"`,
+		`href="/index-bucket/README%20%3Cdraft%3E.txt"`,
+		"README &lt;draft&gt;.txt",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("%s: Expected index page to contain %s, but got %s", instanceType, expected, page)
+		}
+	}
+
+	rec, ok = serve("/"+bucket+"?prefix=release/", "application/json")
+	if !ok || rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected index, but got %v %d", instanceType, ok, rec.Code)
+	}
+	var index bucketIndex
+	if err = json.Unmarshal(rec.Body.Bytes(), &index); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if index.Prefix != "release/" || len(index.Prefixes) != 0 || len(index.Objects) != 1 ||
+		index.Objects[0].Name != "release/app-1.0.tar.gz" || index.Objects[0].Size != 5 {
+		t.Errorf("%s: Unexpected index %+v", instanceType, index)
+	}
+
+	// Removing the configuration disables the index page again.
+	if err = removeBucketIndex(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, ok = serve("/"+bucket, "text/html"); ok {
+		t.Fatalf("%s: Expected no index page after removing configuration", instanceType)
+	}
+}
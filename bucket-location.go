@@ -0,0 +1,110 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Region of a bucket chosen by the LocationConstraint on bucket
+// creation, saved in bucket config path.
+const bucketLocationConfigFile = "location.xml"
+
+// validRegion - region names consist of alphanumeric words separated
+// by dashes, e.g. "us-east-1" or "EU".
+var validRegion = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
+
+// readBucketLocation - read region saved for a bucket, buckets created
+// before regions were saved are in the server region.
+func readBucketLocation(bucket string) (string, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return "", err
+	}
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, bucketLocationConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return serverConfig.GetRegion(), nil
+		}
+		return "", err
+	}
+	config := createBucketLocationConfiguration{}
+	if err = xml.Unmarshal(configBytes, &config); err != nil {
+		return "", err
+	}
+	return config.Location, nil
+}
+
+// writeBucketLocation - save region of a bucket.
+func writeBucketLocation(bucket, location string) error {
+	// Verify if bucket path legal
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	configBytes, err := xml.Marshal(createBucketLocationConfiguration{Location: location})
+	if err != nil {
+		return err
+	}
+	return writeBucketConfigFile(filepath.Join(bucketConfigPath, bucketLocationConfigFile), configBytes)
+}
+
+// removeBucketLocation - remove region saved for a bucket.
+func removeBucketLocation(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return removeBucketConfigFile(filepath.Join(bucketConfigPath, bucketLocationConfigFile))
+}
+
+// getRequestRegion - returns the region a request has to be signed
+// for, requests on a bucket are signed for the region of the bucket.
+func getRequestRegion(r *http.Request) string {
+	return getBucketRegion(strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0])
+}
+
+// getBucketRegion - returns the region of a bucket, the server region
+// if bucket is not a valid bucket name.
+func getBucketRegion(bucket string) string {
+	if !IsValidBucketName(bucket) {
+		return serverConfig.GetRegion()
+	}
+	location, err := readBucketLocation(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read location of bucket %s.", bucket)
+		return serverConfig.GetRegion()
+	}
+	return location
+}
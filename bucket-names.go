@@ -0,0 +1,72 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Bucket name validation modes. By default bucket names have to be DNS
+// compliant, so that buckets can be addressed as virtual hosts. Legacy
+// names may have uppercase letters and underscores and be up to 255
+// characters long, as once allowed by S3, to keep buckets created with
+// such names accessible.
+const bucketNamesLegacy = "legacy"
+
+// Bucket names matching the S3 naming rules, DNS compliant names are
+// additionally checked by isDNSBucketName.
+var (
+	validBucket       = regexp.MustCompile(`^[a-z0-9][a-z0-9\.\-]{1,61}[a-z0-9]$`)
+	validLegacyBucket = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9\.\-_]{1,253}[A-Za-z0-9]$`)
+)
+
+// reservedBucketNames - names of buckets colliding with the paths the
+// server serves itself, e.g. its admin API and health probes, and with
+// its metadata volume.
+var reservedBucketNames = map[string]struct{}{
+	path.Base(reservedBucket): {},
+	minioMetaBucket:           {},
+}
+
+// getBucketNamesMode - returns the bucket name validation mode of the
+// server, the default mode if there is no server config.
+func getBucketNamesMode() string {
+	if serverConfig == nil {
+		return ""
+	}
+	return serverConfig.GetBucketNames()
+}
+
+// isDNSBucketName - returns true if the labels of a bucket name neither
+// are empty nor start or end with a dash, and the name does not look
+// like an IP address.
+func isDNSBucketName(bucket string) bool {
+	if strings.Contains(bucket, "..") || strings.Contains(bucket, ".-") || strings.Contains(bucket, "-.") {
+		return false
+	}
+	return net.ParseIP(bucket) == nil
+}
+
+// isReservedBucketName - returns true if users cannot create or access
+// a bucket with the name.
+func isReservedBucketName(bucket string) bool {
+	_, ok := reservedBucketNames[strings.ToLower(bucket)]
+	return ok
+}
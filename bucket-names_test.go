@@ -0,0 +1,71 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Tests bucket names are validated according to the mode.
+func TestBucketNamesModes(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	defer serverConfig.SetBucketNames("")
+
+	testCases := []struct {
+		bucket string
+		dns    bool
+		legacy bool
+	}{
+		{"bucket", true, true},
+		{"my.bucket-1", true, true},
+		{"Bucket", false, true},
+		{"my_bucket", false, true},
+		{"two..dots", false, true},
+		{"192.168.5.4", false, true},
+		{strings.Repeat("a", 64), false, true},
+		{strings.Repeat("a", 256), false, false},
+		{"ab", false, false},
+		{"-bucket", false, false},
+		{"bucket_", false, false},
+		{"minio", false, false},
+		{"MINIO", false, false},
+		{minioMetaBucket, false, false},
+	}
+	for i, testCase := range testCases {
+		for _, mode := range []struct {
+			name  string
+			valid bool
+		}{
+			{"", testCase.dns},
+			{bucketNamesLegacy, testCase.legacy},
+		} {
+			serverConfig.SetBucketNames(mode.name)
+			if valid := IsValidBucketName(testCase.bucket); valid != mode.valid {
+				t.Errorf("Test %d: Expected %q to be valid %v in mode %q, but instead found %v", i+1, testCase.bucket, mode.valid, mode.name, valid)
+			}
+		}
+	}
+}
@@ -0,0 +1,346 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	mux "github.com/gorilla/mux"
+)
+
+// readObjectLockRequest - reads object lock related request body up
+// to maxObjectLockConfigSize.
+func readObjectLockRequest(r *http.Request) ([]byte, APIErrorCode) {
+	if !contains(r.TransferEncoding, "chunked") {
+		if r.ContentLength == -1 || r.ContentLength == 0 {
+			return nil, ErrMissingContentLength
+		}
+		if r.ContentLength > maxObjectLockConfigSize {
+			return nil, ErrEntityTooLarge
+		}
+	}
+	buf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxObjectLockConfigSize))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object lock request.")
+		return nil, ErrInternalError
+	}
+	return buf, ErrNone
+}
+
+// PutBucketObjectLockConfigHandler - PUT Bucket object lock configuration.
+// ----------
+// Enables object lock on a bucket and optionally sets the default
+// retention applied to all new objects.
+func (api objectAPIHandlers) PutBucketObjectLockConfigHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	configBytes, s3Error := readObjectLockRequest(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	config, s3Error := parseObjectLockConfig(configBytes)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	if err := writeBucketObjectLockConfig(bucket, config); err != nil {
+		errorIfRequest(r, err, "Unable to write object lock configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetBucketObjectLockConfigHandler - GET Bucket object lock configuration.
+func (api objectAPIHandlers) GetBucketObjectLockConfigHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketObjectLockConfig(bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object lock configuration.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeResponse(config)
+	setCommonHeaders(w)
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
+// PutObjectRetentionHandler - PUT Object retention.
+// ----------
+// Compliance mode retention cannot be shortened or removed, governance
+// mode retention can only be relaxed with the bypass governance header.
+func (api objectAPIHandlers) PutObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if !isObjectLockEnabled(bucket) {
+		writeErrorResponse(w, r, ErrInvalidBucketObjectLockConfiguration, r.URL.Path)
+		return
+	}
+	if _, err := api.ObjectAPI.GetObjectInfo(bucket, object); err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	retentionBytes, s3Error := readObjectLockRequest(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	retention := &ObjectRetention{}
+	if err := xml.Unmarshal(retentionBytes, retention); err != nil {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	if !isValidRetentionMode(retention.Mode) {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	retainUntil, err := time.Parse(time.RFC3339, retention.RetainUntilDate)
+	if err != nil {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	now := time.Now().UTC()
+	if !retainUntil.After(now) {
+		writeErrorResponse(w, r, ErrPastObjectLockRetainDate, r.URL.Path)
+		return
+	}
+
+	lockInfo, err := readObjectLockInfo(api.ObjectAPI, bucket, object)
+	if err != nil && err != errObjectLockInfoNotFound {
+		errorIfRequest(r, err, "Unable to read object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	// Active retention can always be extended, any other change
+	// requires the retention to be relaxable.
+	if lockInfo.Mode != "" && now.Before(lockInfo.RetainUntil) {
+		extended := retention.Mode == lockInfo.Mode && !retainUntil.Before(lockInfo.RetainUntil)
+		if !extended {
+			if lockInfo.Mode == retentionCompliance || !isGovernanceBypassed(r) {
+				writeErrorResponse(w, r, ErrObjectLocked, r.URL.Path)
+				return
+			}
+		}
+	}
+
+	lockInfo.Mode = retention.Mode
+	lockInfo.RetainUntil = retainUntil.UTC()
+	if err = writeObjectLockInfo(api.ObjectAPI, bucket, object, lockInfo); err != nil {
+		errorIfRequest(r, err, "Unable to write object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetObjectRetentionHandler - GET Object retention.
+func (api objectAPIHandlers) GetObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetObjectInfo(bucket, object); err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	lockInfo, err := readObjectLockInfo(api.ObjectAPI, bucket, object)
+	if err != nil && err != errObjectLockInfoNotFound {
+		errorIfRequest(r, err, "Unable to read object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	if lockInfo.Mode == "" {
+		writeErrorResponse(w, r, ErrNoSuchObjectLockConfiguration, r.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeResponse(ObjectRetention{
+		Mode:            lockInfo.Mode,
+		RetainUntilDate: lockInfo.RetainUntil.Format(time.RFC3339),
+	})
+	setCommonHeaders(w)
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
+// PutObjectLegalHoldHandler - PUT Object legal hold.
+func (api objectAPIHandlers) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if !isObjectLockEnabled(bucket) {
+		writeErrorResponse(w, r, ErrInvalidBucketObjectLockConfiguration, r.URL.Path)
+		return
+	}
+	if _, err := api.ObjectAPI.GetObjectInfo(bucket, object); err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	legalHoldBytes, s3Error := readObjectLockRequest(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	legalHold := &ObjectLegalHold{}
+	if err := xml.Unmarshal(legalHoldBytes, legalHold); err != nil {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	if legalHold.Status != legalHoldOn && legalHold.Status != legalHoldOff {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+
+	lockInfo, err := readObjectLockInfo(api.ObjectAPI, bucket, object)
+	if err != nil && err != errObjectLockInfoNotFound {
+		errorIfRequest(r, err, "Unable to read object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	lockInfo.LegalHold = legalHold.Status == legalHoldOn
+	if err = writeObjectLockInfo(api.ObjectAPI, bucket, object, lockInfo); err != nil {
+		errorIfRequest(r, err, "Unable to write object legal hold.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetObjectLegalHoldHandler - GET Object legal hold.
+func (api objectAPIHandlers) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetObjectInfo(bucket, object); err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	lockInfo, err := readObjectLockInfo(api.ObjectAPI, bucket, object)
+	if err != nil && err != errObjectLockInfoNotFound {
+		errorIfRequest(r, err, "Unable to read object legal hold.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	status := legalHoldOff
+	if lockInfo.LegalHold {
+		status = legalHoldOn
+	}
+	encodedSuccessResponse := encodeResponse(ObjectLegalHold{Status: status})
+	setCommonHeaders(w)
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
@@ -0,0 +1,419 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Object lock configuration file saved in bucket config path.
+	bucketObjectLockConfigFile = "object-lock.xml"
+
+	// Directory of minioMetaBucket holding per object retention and
+	// legal hold, shared by all nodes.
+	objectLockMetaPrefix = "object-lock"
+
+	// Maximum size of object lock, retention and legal hold requests.
+	maxObjectLockConfigSize = 4 * 1024
+
+	// Internal metadata key of the retention and legal hold requested
+	// when a multipart upload was started.
+	objectLockMetaKey = "object-lock"
+)
+
+// Supported object lock retention modes.
+const (
+	retentionGovernance = "GOVERNANCE"
+	retentionCompliance = "COMPLIANCE"
+)
+
+// Supported object lock legal hold status.
+const (
+	legalHoldOn  = "ON"
+	legalHoldOff = "OFF"
+)
+
+// Object lock related request headers.
+const (
+	amzObjectLockMode        = "X-Amz-Object-Lock-Mode"
+	amzObjectLockRetainUntil = "X-Amz-Object-Lock-Retain-Until-Date"
+	amzObjectLockLegalHold   = "X-Amz-Object-Lock-Legal-Hold"
+	amzObjectLockEnabled     = "X-Amz-Bucket-Object-Lock-Enabled"
+	amzBypassGovernance      = "X-Amz-Bypass-Governance-Retention"
+)
+
+// DefaultRetention - default retention applied on all new objects.
+type DefaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+// ObjectLockRule - container for the default retention rule.
+type ObjectLockRule struct {
+	DefaultRetention DefaultRetention `xml:"DefaultRetention"`
+}
+
+// ObjectLockConfiguration - bucket object lock configuration.
+type ObjectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration" json:"-"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled"`
+	Rule              *ObjectLockRule `xml:"Rule,omitempty"`
+}
+
+// ObjectRetention - object retention request and response.
+type ObjectRetention struct {
+	XMLName         xml.Name `xml:"Retention" json:"-"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"`
+}
+
+// ObjectLegalHold - object legal hold request and response.
+type ObjectLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold" json:"-"`
+	Status  string   `xml:"Status"`
+}
+
+// objectLockInfo - retention and legal hold saved for an object.
+type objectLockInfo struct {
+	Object      string    `json:"object"`
+	Mode        string    `json:"mode,omitempty"`
+	RetainUntil time.Time `json:"retainUntil,omitempty"`
+	LegalHold   bool      `json:"legalHold"`
+}
+
+// isEmpty - returns true if there is neither a retention nor a legal hold.
+func (l objectLockInfo) isEmpty() bool {
+	return l.Mode == "" && !l.LegalHold
+}
+
+// isLocked - returns true if the object is protected from being
+// deleted or overwritten at the given time. Governance retention
+// can be bypassed, compliance retention and legal holds cannot.
+func (l objectLockInfo) isLocked(now time.Time, bypassGovernance bool) bool {
+	if l.LegalHold {
+		return true
+	}
+	if l.Mode == "" || !now.Before(l.RetainUntil) {
+		return false
+	}
+	if l.Mode == retentionGovernance && bypassGovernance {
+		return false
+	}
+	return true
+}
+
+// isValidRetentionMode - validates retention mode.
+func isValidRetentionMode(mode string) bool {
+	return mode == retentionGovernance || mode == retentionCompliance
+}
+
+// parseObjectLockConfig - parses and validates object lock configuration.
+func parseObjectLockConfig(configBytes []byte) (*ObjectLockConfiguration, APIErrorCode) {
+	config := &ObjectLockConfiguration{}
+	if err := xml.Unmarshal(configBytes, config); err != nil {
+		return nil, ErrMalformedXML
+	}
+	if config.ObjectLockEnabled != "Enabled" {
+		return nil, ErrMalformedXML
+	}
+	if config.Rule != nil {
+		retention := config.Rule.DefaultRetention
+		if !isValidRetentionMode(retention.Mode) {
+			return nil, ErrMalformedXML
+		}
+		if retention.Days < 0 || retention.Years < 0 {
+			return nil, ErrInvalidRetentionPeriod
+		}
+		// Exactly one of Days or Years should be specified.
+		if (retention.Days > 0) == (retention.Years > 0) {
+			return nil, ErrMalformedXML
+		}
+	}
+	return config, ErrNone
+}
+
+// defaultLockInfo - returns lock info of the default retention rule
+// for an object created at the given time.
+func (config *ObjectLockConfiguration) defaultLockInfo(now time.Time) objectLockInfo {
+	if config == nil || config.Rule == nil {
+		return objectLockInfo{}
+	}
+	retention := config.Rule.DefaultRetention
+	return objectLockInfo{
+		Mode:        retention.Mode,
+		RetainUntil: now.AddDate(retention.Years, 0, retention.Days),
+	}
+}
+
+// readBucketObjectLockConfig - read bucket object lock configuration.
+func readBucketObjectLockConfig(bucket string) (*ObjectLockConfiguration, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, bucketObjectLockConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, BucketObjectLockConfigNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	config := &ObjectLockConfiguration{}
+	if err = xml.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeBucketObjectLockConfig - save bucket object lock configuration.
+func writeBucketObjectLockConfig(bucket string, config *ObjectLockConfiguration) error {
+	// Verify if bucket path legal
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	configBytes, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfigFile(filepath.Join(bucketConfigPath, bucketObjectLockConfigFile), configBytes)
+}
+
+// removeBucketObjectLock - remove object lock configuration of a
+// bucket, object retention is removed with the bucket.
+func removeBucketObjectLock(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return removeBucketConfigFile(filepath.Join(bucketConfigPath, bucketObjectLockConfigFile))
+}
+
+var errObjectLockInfoNotFound = errors.New("Object has no retention or legal hold")
+
+// getObjectLockInfoPath - object names can be arbitrarily nested,
+// saved retention is addressed by the hash of the object name.
+func getObjectLockInfoPath(bucket, object string) string {
+	sum := sha256.Sum256([]byte(object))
+	return path.Join(objectLockMetaPrefix, bucket, hex.EncodeToString(sum[:])+".json")
+}
+
+// readObjectLockInfo - read retention and legal hold saved for an
+// object, returns errObjectLockInfoNotFound if there is none.
+func readObjectLockInfo(objAPI ObjectLayer, bucket, object string) (objectLockInfo, error) {
+	lockInfoBytes, err := loadMetaFile(objAPI, getObjectLockInfoPath(bucket, object))
+	if err != nil {
+		if err == errFileNotFound || err == errVolumeNotFound {
+			return objectLockInfo{}, errObjectLockInfoNotFound
+		}
+		return objectLockInfo{}, err
+	}
+	lockInfo := objectLockInfo{}
+	if err = json.Unmarshal(lockInfoBytes, &lockInfo); err != nil {
+		return objectLockInfo{}, err
+	}
+	return lockInfo, nil
+}
+
+// writeObjectLockInfo - save retention and legal hold for an object,
+// an empty lock info removes any previously saved entry.
+func writeObjectLockInfo(objAPI ObjectLayer, bucket, object string, lockInfo objectLockInfo) error {
+	if lockInfo.isEmpty() {
+		removeObjectLockInfo(objAPI, bucket, object)
+		return nil
+	}
+	lockInfo.Object = object
+	return saveJSONMetaFile(objAPI, getObjectLockInfoPath(bucket, object), lockInfo)
+}
+
+// removeObjectLockInfo - remove retention and legal hold of an object
+// from all disks.
+func removeObjectLockInfo(objAPI ObjectLayer, bucket, object string) {
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		disk.DeleteFile(minioMetaBucket, getObjectLockInfoPath(bucket, object))
+	}
+}
+
+// isObjectLockEnabled - returns true if object lock is enabled on bucket.
+func isObjectLockEnabled(bucket string) bool {
+	_, err := readBucketObjectLockConfig(bucket)
+	return err == nil
+}
+
+// isGovernanceBypassed - returns true if request asks to bypass
+// governance mode retention.
+func isGovernanceBypassed(r *http.Request) bool {
+	return strings.ToLower(r.Header.Get(amzBypassGovernance)) == "true"
+}
+
+// enforceObjectLock - verifies if an existing object can be deleted
+// or overwritten. In WORM mode no existing object can be modified.
+func enforceObjectLock(objAPI ObjectLayer, bucket, object string, r *http.Request) APIErrorCode {
+	if globalWORMEnabled {
+		if _, err := objAPI.GetObjectInfo(bucket, object); err == nil {
+			return ErrObjectLocked
+		}
+		return ErrNone
+	}
+	lockInfo, err := readObjectLockInfo(objAPI, bucket, object)
+	if err != nil {
+		if err != errObjectLockInfoNotFound {
+			errorIf(err, "Unable to read object retention for %s/%s.", bucket, object)
+			return ErrInternalError
+		}
+		return ErrNone
+	}
+	if !lockInfo.isLocked(time.Now().UTC(), isGovernanceBypassed(r)) {
+		return ErrNone
+	}
+	// Retention is left behind for an object which no longer exists.
+	if _, err = objAPI.GetObjectInfo(bucket, object); err != nil {
+		removeObjectLockInfo(objAPI, bucket, object)
+		return ErrNone
+	}
+	return ErrObjectLocked
+}
+
+// getObjectLockRequestInfo - extracts retention and legal hold to be
+// applied on a newly created object, falls back to the bucket default
+// retention if the request does not specify one.
+func getObjectLockRequestInfo(bucket string, r *http.Request) (objectLockInfo, APIErrorCode) {
+	mode := strings.ToUpper(r.Header.Get(amzObjectLockMode))
+	retainUntil := r.Header.Get(amzObjectLockRetainUntil)
+	legalHold := strings.ToUpper(r.Header.Get(amzObjectLockLegalHold))
+
+	config, err := readBucketObjectLockConfig(bucket)
+	if err != nil {
+		if _, ok := err.(BucketObjectLockConfigNotFound); !ok {
+			errorIf(err, "Unable to read object lock configuration for %s.", bucket)
+			return objectLockInfo{}, ErrInternalError
+		}
+		// Object lock headers are only valid for buckets with object lock enabled.
+		if mode != "" || retainUntil != "" || legalHold != "" {
+			return objectLockInfo{}, ErrInvalidBucketObjectLockConfiguration
+		}
+		return objectLockInfo{}, ErrNone
+	}
+
+	now := time.Now().UTC()
+	lockInfo := config.defaultLockInfo(now)
+	if mode != "" || retainUntil != "" {
+		// Mode and retain until date should be specified together.
+		if mode == "" || retainUntil == "" || !isValidRetentionMode(mode) {
+			return objectLockInfo{}, ErrObjectLockInvalidHeaders
+		}
+		t, err := time.Parse(time.RFC3339, retainUntil)
+		if err != nil {
+			return objectLockInfo{}, ErrObjectLockInvalidHeaders
+		}
+		if !t.After(now) {
+			return objectLockInfo{}, ErrPastObjectLockRetainDate
+		}
+		lockInfo.Mode = mode
+		lockInfo.RetainUntil = t.UTC()
+	}
+	switch legalHold {
+	case "":
+	case legalHoldOn:
+		lockInfo.LegalHold = true
+	case legalHoldOff:
+		lockInfo.LegalHold = false
+	default:
+		return objectLockInfo{}, ErrObjectLockInvalidHeaders
+	}
+	return lockInfo, ErrNone
+}
+
+// hasObjectLockHeaders - returns true if r requests a retention or a
+// legal hold.
+func hasObjectLockHeaders(r *http.Request) bool {
+	return r.Header.Get(amzObjectLockMode) != "" || r.Header.Get(amzObjectLockRetainUntil) != "" ||
+		r.Header.Get(amzObjectLockLegalHold) != ""
+}
+
+// setUploadLockInfo - saves the retention and legal hold requested for
+// a new multipart upload in its metadata, they are applied once the
+// upload is completed.
+func setUploadLockInfo(metadata map[string]string, lockInfo objectLockInfo) error {
+	lockInfoBytes, err := json.Marshal(lockInfo)
+	if err != nil {
+		return err
+	}
+	metadata[objectLockMetaKey] = string(lockInfoBytes)
+	return nil
+}
+
+// getUploadLockInfo - returns the retention and legal hold requested
+// when a multipart upload was started, false if none was requested.
+func getUploadLockInfo(objAPI ObjectLayer, bucket, object, uploadID string) (objectLockInfo, bool, error) {
+	// Only the metadata of the upload is needed, no parts are listed.
+	result, err := objAPI.ListObjectParts(bucket, object, uploadID, 0, 0)
+	if err != nil {
+		return objectLockInfo{}, false, err
+	}
+	value, ok := result.Metadata[objectLockMetaKey]
+	if !ok {
+		return objectLockInfo{}, false, nil
+	}
+	lockInfo := objectLockInfo{}
+	if err = json.Unmarshal([]byte(value), &lockInfo); err != nil {
+		return objectLockInfo{}, false, err
+	}
+	return lockInfo, true, nil
+}
+
+// setObjectLockHeaders - sets retention and legal hold response headers.
+func setObjectLockHeaders(w http.ResponseWriter, objAPI ObjectLayer, bucket, object string) {
+	lockInfo, err := readObjectLockInfo(objAPI, bucket, object)
+	if err != nil {
+		return
+	}
+	if lockInfo.Mode != "" {
+		w.Header().Set(amzObjectLockMode, lockInfo.Mode)
+		w.Header().Set(amzObjectLockRetainUntil, lockInfo.RetainUntil.Format(time.RFC3339))
+	}
+	if lockInfo.LegalHold {
+		w.Header().Set(amzObjectLockLegalHold, legalHoldOn)
+	}
+}
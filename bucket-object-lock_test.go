@@ -0,0 +1,201 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of bucket object lock configuration.
+func TestParseObjectLockConfig(t *testing.T) {
+	testCases := []struct {
+		config       string
+		expectedCode APIErrorCode
+	}{
+		// Test case - 1.
+		// Object lock enabled without a default retention.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`, ErrNone},
+		// Test case - 2.
+		// Default retention in days.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Days>1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`, ErrNone},
+		// Test case - 3.
+		// Default retention in years.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`, ErrNone},
+		// Test case - 4.
+		// Both days and years are not allowed.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>1</Days><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`, ErrMalformedXML},
+		// Test case - 5.
+		// Unknown retention mode.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>UNKNOWN</Mode><Days>1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`, ErrMalformedXML},
+		// Test case - 6.
+		// Object lock cannot be disabled.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Disabled</ObjectLockEnabled></ObjectLockConfiguration>`, ErrMalformedXML},
+		// Test case - 7.
+		// Invalid XML.
+		{`<ObjectLockConfiguration>`, ErrMalformedXML},
+		// Test case - 8.
+		// Negative retention periods.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>-1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`, ErrInvalidRetentionPeriod},
+		// Test case - 9.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Days>1</Days><Years>-1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`, ErrInvalidRetentionPeriod},
+	}
+	for i, testCase := range testCases {
+		_, actualCode := parseObjectLockConfig([]byte(testCase.config))
+		if testCase.expectedCode != actualCode {
+			t.Errorf("Test %d: Expected the APIErrCode to be %d, but instead found %d", i+1, testCase.expectedCode, actualCode)
+		}
+	}
+}
+
+// Tests validate if retention and legal hold protect an object.
+func TestObjectLockInfoIsLocked(t *testing.T) {
+	now := time.Now().UTC()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	testCases := []struct {
+		lockInfo         objectLockInfo
+		bypassGovernance bool
+		locked           bool
+	}{
+		// Test case - 1.
+		// No retention at all.
+		{objectLockInfo{}, false, false},
+		// Test case - 2.
+		// Active compliance retention.
+		{objectLockInfo{Mode: retentionCompliance, RetainUntil: future}, false, true},
+		// Test case - 3.
+		// Compliance retention cannot be bypassed.
+		{objectLockInfo{Mode: retentionCompliance, RetainUntil: future}, true, true},
+		// Test case - 4.
+		// Expired compliance retention.
+		{objectLockInfo{Mode: retentionCompliance, RetainUntil: past}, false, false},
+		// Test case - 5.
+		// Active governance retention.
+		{objectLockInfo{Mode: retentionGovernance, RetainUntil: future}, false, true},
+		// Test case - 6.
+		// Governance retention can be bypassed.
+		{objectLockInfo{Mode: retentionGovernance, RetainUntil: future}, true, false},
+		// Test case - 7.
+		// Legal hold protects irrespective of retention.
+		{objectLockInfo{Mode: retentionGovernance, RetainUntil: past, LegalHold: true}, true, true},
+	}
+	for i, testCase := range testCases {
+		locked := testCase.lockInfo.isLocked(now, testCase.bypassGovernance)
+		if locked != testCase.locked {
+			t.Errorf("Test %d: Expected locked to be %v, but instead found %v", i+1, testCase.locked, locked)
+		}
+	}
+}
+
+// Tests default retention computed from bucket object lock configuration.
+func TestObjectLockDefaultRetention(t *testing.T) {
+	now := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	config := &ObjectLockConfiguration{
+		ObjectLockEnabled: "Enabled",
+		Rule: &ObjectLockRule{
+			DefaultRetention: DefaultRetention{Mode: retentionCompliance, Days: 10},
+		},
+	}
+	lockInfo := config.defaultLockInfo(now)
+	if lockInfo.Mode != retentionCompliance {
+		t.Fatalf("Expected mode %s, but instead found %s", retentionCompliance, lockInfo.Mode)
+	}
+	if !lockInfo.RetainUntil.Equal(now.AddDate(0, 0, 10)) {
+		t.Fatalf("Expected retain until %s, but instead found %s", now.AddDate(0, 0, 10), lockInfo.RetainUntil)
+	}
+
+	// Without a rule no retention is applied.
+	config.Rule = nil
+	if lockInfo = config.defaultLockInfo(now); !lockInfo.isEmpty() {
+		t.Fatalf("Expected empty lock info, but instead found %v", lockInfo)
+	}
+}
+
+func TestUploadLockInfo(t *testing.T) {
+	ExecObjectLayerTest(t, testUploadLockInfo)
+}
+
+// Tests retention requested on upload start is kept with the upload.
+func testUploadLockInfo(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "lock-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	retainUntil := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lockInfo := objectLockInfo{Mode: retentionCompliance, RetainUntil: retainUntil, LegalHold: true}
+	metadata := make(map[string]string)
+	if err := setUploadLockInfo(metadata, lockInfo); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "locked", metadata)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	saved, requested, err := getUploadLockInfo(obj, bucket, "locked", uploadID)
+	if err != nil || !requested || saved != lockInfo {
+		t.Fatalf("%s: Expected %v, but instead found %v %v %v", instanceType, lockInfo, saved, requested, err)
+	}
+
+	// Uploads without retention get the bucket default on completion.
+	if uploadID, err = obj.NewMultipartUpload(bucket, "unlocked", nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, requested, err = getUploadLockInfo(obj, bucket, "unlocked", uploadID); err != nil || requested {
+		t.Fatalf("%s: Expected no retention, but instead found %v %v", instanceType, requested, err)
+	}
+}
+
+func TestObjectLockInfo(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectLockInfo)
+}
+
+// Tests retention and legal hold are saved on all disks of the object
+// layer, so every node sees them.
+func testObjectLockInfo(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket, object := "lock-bucket", "dir/object"
+	if _, err := readObjectLockInfo(obj, bucket, object); err != errObjectLockInfoNotFound {
+		t.Fatalf("%s: Expected %v, but instead found %v", instanceType, errObjectLockInfoNotFound, err)
+	}
+	retainUntil := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lockInfo := objectLockInfo{Mode: retentionCompliance, RetainUntil: retainUntil, LegalHold: true}
+	if err := writeObjectLockInfo(obj, bucket, object, lockInfo); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for i, disk := range getObjectLayerDisks(obj) {
+		if _, err := disk.StatFile(minioMetaBucket, getObjectLockInfoPath(bucket, object)); err != nil {
+			t.Fatalf("%s: Retention missing on disk %d: %s", instanceType, i, err)
+		}
+	}
+	saved, err := readObjectLockInfo(obj, bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	lockInfo.Object = object
+	if saved != lockInfo {
+		t.Fatalf("%s: Expected %v, but instead found %v", instanceType, lockInfo, saved)
+	}
+
+	// Empty lock info removes the saved entry.
+	if err = writeObjectLockInfo(obj, bucket, object, objectLockInfo{}); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = readObjectLockInfo(obj, bucket, object); err != errObjectLockInfoNotFound {
+		t.Fatalf("%s: Expected %v, but instead found %v", instanceType, errObjectLockInfoNotFound, err)
+	}
+}
@@ -0,0 +1,221 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Canned bucket policies for a prefix of a bucket, as set from the
+// web browser.
+const (
+	bucketPolicyNone      = "none"
+	bucketPolicyReadOnly  = "readonly"
+	bucketPolicyWriteOnly = "writeonly"
+	bucketPolicyReadWrite = "readwrite"
+)
+
+// Write only actions.
+var (
+	writeOnlyBucketActions = []string{
+		"s3:GetBucketLocation",
+		"s3:ListBucketMultipartUploads",
+		// Add more bucket level write actions here.
+	}
+	writeOnlyObjectActions = []string{
+		"s3:AbortMultipartUpload",
+		"s3:DeleteObject",
+		"s3:ListMultipartUploadParts",
+		"s3:PutObject",
+		// Add more object level write actions here.
+	}
+)
+
+// Read only actions.
+var (
+	readOnlyBucketActions = []string{
+		"s3:GetBucketLocation",
+		"s3:ListBucket",
+		// Add more bucket level read actions here.
+	}
+	readOnlyObjectActions = []string{
+		"s3:GetObject",
+		// Add more object level read actions here.
+	}
+)
+
+// isValidCannedPolicy - returns if policy is a canned policy.
+func isValidCannedPolicy(policy string) bool {
+	switch policy {
+	case bucketPolicyNone, bucketPolicyReadOnly, bucketPolicyWriteOnly, bucketPolicyReadWrite:
+		return true
+	}
+	return false
+}
+
+// isCannedBucketStatement - returns if statement grants bucket actions
+// for prefix.
+func isCannedBucketStatement(statement policyStatement, bucket, prefix string) bool {
+	if len(statement.Resources) != 1 || statement.Resources[0] != AWSResourcePrefix+bucket {
+		return false
+	}
+	if prefix == "" {
+		return len(statement.Conditions) == 0
+	}
+	return len(statement.Conditions) == 1 && len(statement.Conditions["StringEquals"]) == 1 &&
+		statement.Conditions["StringEquals"]["s3:prefix"] == prefix
+}
+
+// isCannedObjectStatement - returns if statement grants object actions
+// for all objects under prefix.
+func isCannedObjectStatement(statement policyStatement, bucket, prefix string) bool {
+	return len(statement.Resources) == 1 && statement.Resources[0] == AWSResourcePrefix+bucket+"/"+prefix+"*"
+}
+
+// getCannedPolicy - returns the canned policy of prefix in a bucket policy.
+func getCannedPolicy(statements []policyStatement, bucket, prefix string) string {
+	var read, write bool
+	for _, statement := range statements {
+		if statement.Effect != "Allow" || !isCannedObjectStatement(statement, bucket, prefix) {
+			continue
+		}
+		for _, action := range statement.Actions {
+			switch action {
+			case "s3:GetObject":
+				read = true
+			case "s3:PutObject":
+				write = true
+			}
+		}
+	}
+	switch {
+	case read && write:
+		return bucketPolicyReadWrite
+	case read:
+		return bucketPolicyReadOnly
+	case write:
+		return bucketPolicyWriteOnly
+	}
+	return bucketPolicyNone
+}
+
+// setCannedPolicy - replaces the statements of prefix with statements of
+// the canned policy.
+func setCannedPolicy(statements []policyStatement, policy, bucket, prefix string) []policyStatement {
+	var newStatements []policyStatement
+	for _, statement := range statements {
+		if isCannedBucketStatement(statement, bucket, prefix) || isCannedObjectStatement(statement, bucket, prefix) {
+			continue
+		}
+		newStatements = append(newStatements, statement)
+	}
+
+	var bucketActions, objectActions []string
+	if policy == bucketPolicyReadOnly || policy == bucketPolicyReadWrite {
+		bucketActions = append(bucketActions, readOnlyBucketActions...)
+		objectActions = append(objectActions, readOnlyObjectActions...)
+	}
+	if policy == bucketPolicyWriteOnly || policy == bucketPolicyReadWrite {
+		for _, action := range writeOnlyBucketActions {
+			if !contains(bucketActions, action) {
+				bucketActions = append(bucketActions, action)
+			}
+		}
+		objectActions = append(objectActions, writeOnlyObjectActions...)
+	}
+	if len(objectActions) == 0 {
+		return newStatements
+	}
+
+	bucketStatement := policyStatement{
+		Effect:    "Allow",
+		Principal: policyUser{AWS: []string{"*"}},
+		Actions:   bucketActions,
+		Resources: []string{AWSResourcePrefix + bucket},
+	}
+	// Listing is allowed for the prefix only.
+	if prefix != "" {
+		bucketStatement.Conditions = map[string]map[string]string{
+			"StringEquals": {"s3:prefix": prefix},
+		}
+	}
+	objectStatement := policyStatement{
+		Effect:    "Allow",
+		Principal: policyUser{AWS: []string{"*"}},
+		Actions:   objectActions,
+		Resources: []string{AWSResourcePrefix + bucket + "/" + prefix + "*"},
+	}
+	return append(newStatements, bucketStatement, objectStatement)
+}
+
+// readBucketPolicyStatements - returns statements of the bucket policy,
+// no statements if bucket has no policy.
+func readBucketPolicyStatements(bucket string) ([]policyStatement, error) {
+	policyBytes, err := readBucketPolicy(bucket)
+	if err != nil {
+		if _, ok := err.(BucketPolicyNotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	policy, err := parseBucketPolicy(policyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return policy.Statements, nil
+}
+
+// writeBucketPolicyStatements - saves statements as the bucket policy,
+// bucket policy is removed if there are no statements.
+func writeBucketPolicyStatements(bucket string, statements []policyStatement) error {
+	if len(statements) == 0 {
+		if err := removeBucketPolicy(bucket); err != nil {
+			if _, ok := err.(BucketPolicyNotFound); !ok {
+				return err
+			}
+		}
+		return nil
+	}
+	policy := BucketPolicy{Version: "2012-10-17", Statements: statements}
+	if s3Error := checkBucketPolicyResources(bucket, policy); s3Error != ErrNone {
+		return errors.New(getAPIError(s3Error).Description)
+	}
+	policyBytes, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return writeBucketPolicy(bucket, policyBytes)
+}
+
+// cannedPolicyPrefixes - returns all prefixes with canned policies.
+func cannedPolicyPrefixes(statements []policyStatement, bucket string) []string {
+	var prefixes []string
+	resourcePrefix := AWSResourcePrefix + bucket + "/"
+	for _, statement := range statements {
+		if len(statement.Resources) != 1 || !strings.HasPrefix(statement.Resources[0], resourcePrefix) ||
+			!strings.HasSuffix(statement.Resources[0], "*") {
+			continue
+		}
+		prefix := strings.TrimSuffix(strings.TrimPrefix(statement.Resources[0], resourcePrefix), "*")
+		if !contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,85 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Tests setting and getting canned policies of bucket prefixes.
+func TestCannedPolicy(t *testing.T) {
+	bucket := "bucket"
+	testCases := []struct {
+		prefix string
+		policy string
+	}{
+		// Test case - 1.
+		{"", bucketPolicyReadOnly},
+		// Test case - 2.
+		{"", bucketPolicyWriteOnly},
+		// Test case - 3.
+		{"", bucketPolicyReadWrite},
+		// Test case - 4.
+		{"photos/", bucketPolicyReadOnly},
+		// Test case - 5.
+		{"photos/", bucketPolicyReadWrite},
+		// Test case - 6.
+		{"", bucketPolicyNone},
+	}
+	for i, testCase := range testCases {
+		statements := setCannedPolicy(nil, testCase.policy, bucket, testCase.prefix)
+		if policy := getCannedPolicy(statements, bucket, testCase.prefix); policy != testCase.policy {
+			t.Errorf("Test %d: Expected policy %s, but instead found %s", i+1, testCase.policy, policy)
+		}
+		if len(statements) == 0 {
+			continue
+		}
+		// Canned policies are valid bucket policies.
+		policyBytes, err := json.Marshal(BucketPolicy{Version: "2012-10-17", Statements: statements})
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		policy, err := parseBucketPolicy(policyBytes)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if s3Error := checkBucketPolicyResources(bucket, policy); s3Error != ErrNone {
+			t.Errorf("Test %d: Unexpected APIErrCode %d", i+1, s3Error)
+		}
+	}
+
+	// Policies of other prefixes are kept.
+	statements := setCannedPolicy(nil, bucketPolicyReadOnly, bucket, "photos/")
+	statements = setCannedPolicy(statements, bucketPolicyWriteOnly, bucket, "uploads/")
+	if policy := getCannedPolicy(statements, bucket, "photos/"); policy != bucketPolicyReadOnly {
+		t.Errorf("Expected policy %s, but instead found %s", bucketPolicyReadOnly, policy)
+	}
+	if prefixes := cannedPolicyPrefixes(statements, bucket); len(prefixes) != 2 {
+		t.Errorf("Expected 2 prefixes, but instead found %v", prefixes)
+	}
+	statements = setCannedPolicy(statements, bucketPolicyNone, bucket, "photos/")
+	if policy := getCannedPolicy(statements, bucket, "photos/"); policy != bucketPolicyNone {
+		t.Errorf("Expected policy %s, but instead found %s", bucketPolicyNone, policy)
+	}
+	if policy := getCannedPolicy(statements, bucket, "uploads/"); policy != bucketPolicyWriteOnly {
+		t.Errorf("Expected policy %s, but instead found %s", bucketPolicyWriteOnly, policy)
+	}
+	if len(statements) != 2 {
+		t.Errorf("Expected 2 statements, but instead found %d", len(statements))
+	}
+}
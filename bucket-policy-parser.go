@@ -231,6 +231,11 @@ func checkBucketPolicyResources(bucket string, bucketPolicy BucketPolicy) APIErr
 					if strings.Split(resourcePrefix, "/")[0] != bucket {
 						return ErrMalformedPolicy
 					}
+					// Resources of a single object, as set by object ACLs,
+					// cannot nest other resources.
+					if !strings.HasSuffix(resourcePrefix, "*") && resourcePrefix != bucket {
+						continue
+					}
 					// All valid resources collect them separately to verify nesting.
 					resourceMap[resourcePrefix] = struct{}{}
 				}
@@ -49,6 +49,42 @@ func createBucketConfigPath(bucket string) error {
 	return os.MkdirAll(bucketConfigPath, 0700)
 }
 
+// writeBucketConfigFile - replaces a bucket config file, which is
+// shared through etcd if configured.
+func writeBucketConfigFile(name string, data []byte) error {
+	if err := putEtcdBucketConfig(name, data); err != nil {
+		return err
+	}
+	return writeLocalConfigFile(name, data)
+}
+
+// removeBucketConfigFile - removes a bucket config file, also from
+// etcd if configured. Removing a missing file is not an error.
+func removeBucketConfigFile(name string) error {
+	if err := deleteEtcdBucketConfig(name); err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeLocalConfigFile - replaces a config file atomically through a
+// temporary file, processes sharing the config never read a partially
+// written file.
+func writeLocalConfigFile(name string, data []byte) error {
+	tmpName := name + "." + getUUID() + ".tmp"
+	if err := ioutil.WriteFile(tmpName, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, name); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
 // readBucketPolicy - read bucket policy.
 func readBucketPolicy(bucket string) ([]byte, error) {
 	// Verify bucket is valid.
@@ -92,10 +128,7 @@ func removeBucketPolicy(bucket string) error {
 		}
 		return err
 	}
-	if err := os.Remove(bucketPolicyFile); err != nil {
-		return err
-	}
-	return nil
+	return removeBucketConfigFile(bucketPolicyFile)
 }
 
 // writeBucketPolicy - save bucket policy.
@@ -124,5 +157,5 @@ func writeBucketPolicy(bucket string, accessPolicyBytes []byte) error {
 	}
 
 	// Write bucket policy.
-	return ioutil.WriteFile(bucketPolicyFile, accessPolicyBytes, 0600)
+	return writeBucketConfigFile(bucketPolicyFile, accessPolicyBytes)
 }
@@ -0,0 +1,196 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Size of the buffers copying request and response bodies.
+const copyBufferSize = 128 * 1024
+
+// BufferPoolStats - usage of the buffers of one size.
+type BufferPoolStats struct {
+	Size int `json:"size"`
+	// Buffers handed out and returned.
+	Gets int64 `json:"gets"`
+	Puts int64 `json:"puts"`
+	// Buffers allocated because the pool was empty.
+	Allocs int64 `json:"allocs"`
+}
+
+// bufferPool - reuses buffers of a fixed size, idle buffers are
+// released by the garbage collector.
+type bufferPool struct {
+	size   int
+	pool   sync.Pool
+	gets   int64
+	puts   int64
+	allocs int64
+}
+
+func newBufferPool(size int) *bufferPool {
+	p := &bufferPool{size: size}
+	p.pool.New = func() interface{} {
+		atomic.AddInt64(&p.allocs, 1)
+		return make([]byte, size)
+	}
+	return p
+}
+
+// Get - returns a buffer of the pool size.
+func (p *bufferPool) Get() []byte {
+	atomic.AddInt64(&p.gets, 1)
+	return p.pool.Get().([]byte)[:p.size]
+}
+
+// Put - returns a buffer obtained by Get to the pool.
+func (p *bufferPool) Put(buf []byte) {
+	if cap(buf) != p.size {
+		return
+	}
+	atomic.AddInt64(&p.puts, 1)
+	p.pool.Put(buf[:p.size])
+}
+
+// bufferPools - buffer pools by buffer size, buffers of the data path
+// are reused across requests to avoid garbage collection of large
+// concurrent transfers.
+type bufferPools struct {
+	mutex *sync.RWMutex
+	pools map[int]*bufferPool
+	// Buffers still used by operations their callers gave up on, by
+	// the last byte of their backing array. They are not reused until
+	// the operations return.
+	abandoned map[*byte]int
+}
+
+// Shared buffer pools of erasure coded blocks, bitrot hashing and
+// HTTP copies.
+var globalBufferPools = &bufferPools{
+	mutex:     &sync.RWMutex{},
+	pools:     make(map[int]*bufferPool),
+	abandoned: make(map[*byte]int),
+}
+
+// pool - returns the pool of size, created on first use.
+func (p *bufferPools) pool(size int) *bufferPool {
+	p.mutex.RLock()
+	pool, ok := p.pools[size]
+	p.mutex.RUnlock()
+	if ok {
+		return pool
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if pool, ok = p.pools[size]; !ok {
+		pool = newBufferPool(size)
+		p.pools[size] = pool
+	}
+	return pool
+}
+
+// Get - returns a buffer of size, callers only use a few fixed sizes
+// as every size gets its own pool.
+func (p *bufferPools) Get(size int) []byte {
+	return p.pool(size).Get()
+}
+
+// Put - returns a buffer obtained by Get, abandoned buffers are
+// dropped.
+func (p *bufferPools) Put(buf []byte) {
+	if cap(buf) == 0 {
+		return
+	}
+	p.mutex.RLock()
+	pool, ok := p.pools[cap(buf)]
+	_, abandoned := p.abandoned[lastByte(buf)]
+	p.mutex.RUnlock()
+	if ok && !abandoned {
+		pool.Put(buf)
+	}
+}
+
+// Abandon - keeps buf and all slices of its backing array out of the
+// pools until release is called, for buffers still used by an
+// operation its caller stopped waiting for.
+func (p *bufferPools) Abandon(buf []byte) (release func()) {
+	if cap(buf) == 0 {
+		return func() {}
+	}
+	key := lastByte(buf)
+	p.mutex.Lock()
+	p.abandoned[key]++
+	p.mutex.Unlock()
+	return func() {
+		p.mutex.Lock()
+		if p.abandoned[key]--; p.abandoned[key] == 0 {
+			delete(p.abandoned, key)
+		}
+		p.mutex.Unlock()
+	}
+}
+
+// lastByte - returns the last byte of the backing array of buf, shared
+// by all slices of a buffer unlike the first byte.
+func lastByte(buf []byte) *byte {
+	return &buf[:cap(buf)][cap(buf)-1]
+}
+
+// Stats - returns the usage of all pools ordered by buffer size.
+func (p *bufferPools) Stats() []BufferPoolStats {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	stats := []BufferPoolStats{}
+	for _, pool := range p.pools {
+		stats = append(stats, BufferPoolStats{
+			Size:   pool.size,
+			Gets:   atomic.LoadInt64(&pool.gets),
+			Puts:   atomic.LoadInt64(&pool.puts),
+			Allocs: atomic.LoadInt64(&pool.allocs),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Size < stats[j].Size })
+	return stats
+}
+
+// putBuffers - returns all non nil buffers obtained by Get.
+func putBuffers(bufs [][]byte) {
+	for _, buf := range bufs {
+		if buf != nil {
+			globalBufferPools.Put(buf)
+		}
+	}
+}
+
+// copyN - copies n bytes from src to dst with a pooled buffer.
+func copyN(dst io.Writer, src io.Reader, n int64) (int64, error) {
+	buf := globalBufferPools.Get(copyBufferSize)
+	defer globalBufferPools.Put(buf)
+	written, err := io.CopyBuffer(dst, io.LimitReader(src, n), buf)
+	if written == n {
+		return n, nil
+	}
+	if err == nil {
+		// src stopped early.
+		err = io.EOF
+	}
+	return written, err
+}
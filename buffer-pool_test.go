@@ -0,0 +1,86 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Tests buffers are handed out with the requested size and counted.
+func TestBufferPools(t *testing.T) {
+	pools := &bufferPools{
+		mutex:     &sync.RWMutex{},
+		pools:     make(map[int]*bufferPool),
+		abandoned: make(map[*byte]int),
+	}
+	buf := pools.Get(1024)
+	if len(buf) != 1024 {
+		t.Fatalf("Expected buffer of 1024 bytes, but instead found %d", len(buf))
+	}
+	// Sliced buffers are returned to the pool of their capacity.
+	pools.Put(buf[:10])
+	// Buffers of unknown sizes are dropped.
+	pools.Put(make([]byte, 10))
+	pools.Get(1024)
+	pools.Get(64)
+
+	stats := pools.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 pools, but instead found %d", len(stats))
+	}
+	if stats[0].Size != 64 || stats[1].Size != 1024 {
+		t.Fatalf("Expected pools ordered by size, but instead found %v", stats)
+	}
+	if stats[1].Gets != 2 || stats[1].Puts != 1 || stats[1].Allocs < 1 {
+		t.Errorf("Unexpected stats %v", stats[1])
+	}
+
+	// Abandoned buffers and their slices are dropped until released.
+	buf = pools.Get(64)
+	release := pools.Abandon(buf[32:])
+	pools.Put(buf)
+	if stats = pools.Stats(); stats[0].Puts != 0 {
+		t.Fatalf("Expected abandoned buffer to be dropped, but instead found %v", stats[0])
+	}
+	release()
+	pools.Put(buf)
+	if stats = pools.Stats(); stats[0].Puts != 1 {
+		t.Fatalf("Expected released buffer to be reused, but instead found %v", stats[0])
+	}
+	if len(pools.abandoned) != 0 {
+		t.Errorf("Expected no abandoned buffers, but instead found %d", len(pools.abandoned))
+	}
+}
+
+// Tests copies with pooled buffers.
+func TestCopyN(t *testing.T) {
+	dst := new(bytes.Buffer)
+	n, err := copyN(dst, strings.NewReader("hello world"), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || dst.String() != "hello" {
+		t.Errorf("Expected hello, but instead found %d bytes %s", n, dst.String())
+	}
+	if _, err = copyN(dst, strings.NewReader("hello"), 10); err != io.EOF {
+		t.Errorf("Expected %s for short source, but instead found %v", io.EOF, err)
+	}
+}
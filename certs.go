@@ -17,12 +17,27 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/minio/go-homedir"
 )
 
+// certsPath for custom certs path.
+var customCertsPath string
+
+// Sets a new certs path.
+func setGlobalCertsPath(certsPath string) {
+	customCertsPath = certsPath
+}
+
 // createCertsPath create certs path.
 func createCertsPath() error {
 	certsPath, err := getCertsPath()
@@ -34,6 +49,9 @@ func createCertsPath() error {
 
 // getCertsPath get certs path.
 func getCertsPath() (string, error) {
+	if customCertsPath != "" {
+		return customCertsPath, nil
+	}
 	homeDir, err := homedir.Dir()
 	if err != nil {
 		return "", err
@@ -88,3 +106,196 @@ func isSSL() bool {
 	}
 	return false
 }
+
+// certsReloadInterval - interval at which certificates are checked
+// for changes.
+const certsReloadInterval = 10 * time.Second
+
+// errNoCertificate - certs path holds no certificate and key pair.
+var errNoCertificate = errors.New("No certificate and key pair found")
+
+// certManager - serves TLS certificates from the certs path. The
+// certificate and key pair at the top of the certs path is the default
+// certificate, additional pairs in sub-directories are served to
+// clients asking for one of their host names through SNI.
+//
+//	certs/public.crt, certs/private.key
+//	certs/example.com/public.crt, certs/example.com/private.key
+//
+// Certificates are reloaded as soon as any of the files change.
+type certManager struct {
+	certsPath string
+
+	mutex       *sync.RWMutex
+	defaultCert *tls.Certificate
+	sniCerts    []*tls.Certificate
+	modTimes    map[string]time.Time
+}
+
+// newCertManager - loads all certificates in certsPath.
+func newCertManager(certsPath string) (*certManager, error) {
+	m := &certManager{
+		certsPath: certsPath,
+		mutex:     &sync.RWMutex{},
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadCertificate - loads a certificate and key pair, the parsed leaf
+// certificate is kept for host name matching.
+func loadCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// certFiles - returns certificate and key pairs in the certs path, the
+// default pair comes first.
+func (m *certManager) certFiles() [][2]string {
+	var pairs [][2]string
+	isPair := func(dir string) bool {
+		for _, name := range []string{globalMinioCertFile, globalMinioKeyFile} {
+			st, err := os.Stat(filepath.Join(dir, name))
+			if err != nil || !st.Mode().IsRegular() {
+				return false
+			}
+		}
+		return true
+	}
+	if isPair(m.certsPath) {
+		pairs = append(pairs, [2]string{
+			filepath.Join(m.certsPath, globalMinioCertFile),
+			filepath.Join(m.certsPath, globalMinioKeyFile),
+		})
+	}
+	entries, err := ioutil.ReadDir(m.certsPath)
+	if err != nil {
+		return pairs
+	}
+	for _, entry := range entries {
+		dir := filepath.Join(m.certsPath, entry.Name())
+		if entry.IsDir() && isPair(dir) {
+			pairs = append(pairs, [2]string{
+				filepath.Join(dir, globalMinioCertFile),
+				filepath.Join(dir, globalMinioKeyFile),
+			})
+		}
+	}
+	return pairs
+}
+
+// getModTimes - returns modification times of all certificate files.
+func (m *certManager) getModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, pair := range m.certFiles() {
+		for _, file := range pair {
+			if st, err := os.Stat(file); err == nil {
+				modTimes[file] = st.ModTime()
+			}
+		}
+	}
+	return modTimes
+}
+
+// load - (re)loads all certificates, previously loaded certificates are
+// kept if any of the certificates fail to load.
+func (m *certManager) load() error {
+	modTimes := m.getModTimes()
+	pairs := m.certFiles()
+	if len(pairs) == 0 || pairs[0][0] != filepath.Join(m.certsPath, globalMinioCertFile) {
+		return errNoCertificate
+	}
+	var certs []*tls.Certificate
+	for _, pair := range pairs {
+		cert, err := loadCertificate(pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.defaultCert = certs[0]
+	m.sniCerts = certs[1:]
+	m.modTimes = modTimes
+	return nil
+}
+
+// isModified - returns true if any certificate was added, removed or
+// modified since last load.
+func (m *certManager) isModified() bool {
+	modTimes := m.getModTimes()
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if len(modTimes) != len(m.modTimes) {
+		return true
+	}
+	for file, modTime := range modTimes {
+		if lastModTime, ok := m.modTimes[file]; !ok || !lastModTime.Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch - reloads certificates on change, checks every interval until
+// doneCh is closed.
+func (m *certManager) watch(interval time.Duration, doneCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !m.isModified() {
+				continue
+			}
+			errorIf(m.load(), "Unable to reload certificates from %s.", m.certsPath)
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// matchHostName - matches a host name against a certificate name,
+// wildcards are only allowed as the left most label.
+func matchHostName(name, host string) bool {
+	name = strings.ToLower(name)
+	if name == host {
+		return true
+	}
+	if !strings.HasPrefix(name, "*.") {
+		return false
+	}
+	i := strings.Index(host, ".")
+	return i > 0 && host[i:] == name[1:]
+}
+
+// GetCertificate - returns the certificate matching the server name
+// requested by the client, implements tls.Config.GetCertificate.
+func (m *certManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	host := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if host != "" {
+		for _, cert := range m.sniCerts {
+			names := cert.Leaf.DNSNames
+			if len(names) == 0 {
+				names = []string{cert.Leaf.Subject.CommonName}
+			}
+			for _, name := range names {
+				if matchHostName(name, host) {
+					return cert, nil
+				}
+			}
+		}
+	}
+	return m.defaultCert, nil
+}
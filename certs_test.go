@@ -0,0 +1,170 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate - writes a self-signed certificate for names into dir.
+func writeTestCertificate(dir string, names ...string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+	if err = ioutil.WriteFile(filepath.Join(dir, globalMinioCertFile), certPEM, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, globalMinioKeyFile), keyPEM, 0600)
+}
+
+// Tests certificates are served by host name.
+func TestCertManagerGetCertificate(t *testing.T) {
+	certsPath, err := ioutil.TempDir("", "minio-certs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(certsPath)
+
+	if _, err = newCertManager(certsPath); err != errNoCertificate {
+		t.Fatalf("Expected %s, but instead found %v", errNoCertificate, err)
+	}
+	if err = writeTestCertificate(certsPath, "default.local"); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeTestCertificate(filepath.Join(certsPath, "example.com"), "example.com", "*.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeTestCertificate(filepath.Join(certsPath, "minio.io"), "minio.io"); err != nil {
+		t.Fatal(err)
+	}
+	certs, err := newCertManager(certsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		serverName string
+		commonName string
+	}{
+		// Test case - 1.
+		// No SNI, default certificate.
+		{"", "default.local"},
+		// Test case - 2.
+		{"example.com", "example.com"},
+		// Test case - 3.
+		// Wildcard certificate.
+		{"s3.example.com", "example.com"},
+		// Test case - 4.
+		// Wildcards match only one label.
+		{"a.s3.example.com", "default.local"},
+		// Test case - 5.
+		// Host names are case insensitive.
+		{"MINIO.IO", "minio.io"},
+		// Test case - 6.
+		// Unknown host name, default certificate.
+		{"unknown.net", "default.local"},
+	}
+	for i, testCase := range testCases {
+		cert, err := certs.GetCertificate(&tls.ClientHelloInfo{ServerName: testCase.serverName})
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if cert.Leaf.Subject.CommonName != testCase.commonName {
+			t.Errorf("Test %d: Expected certificate %s, but instead found %s", i+1, testCase.commonName, cert.Leaf.Subject.CommonName)
+		}
+	}
+}
+
+// Tests certificates are reloaded on change.
+func TestCertManagerReload(t *testing.T) {
+	certsPath, err := ioutil.TempDir("", "minio-certs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(certsPath)
+
+	if err = writeTestCertificate(certsPath, "old.local"); err != nil {
+		t.Fatal(err)
+	}
+	certs, err := newCertManager(certsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certs.isModified() {
+		t.Fatal("Expected certificates not to be modified")
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go certs.watch(10*time.Millisecond, doneCh)
+
+	if err = writeTestCertificate(certsPath, "new.local"); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure modification time changes on file systems with coarse timestamps.
+	future := time.Now().Add(time.Minute)
+	for _, name := range []string{globalMinioCertFile, globalMinioKeyFile} {
+		if err = os.Chtimes(filepath.Join(certsPath, name), future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		cert, err := certs.GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert.Leaf.Subject.CommonName == "new.local" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Expected certificate to be reloaded")
+}
@@ -0,0 +1,97 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Default maximum difference between the time of signed requests
+	// and the server, the same as S3 allows.
+	defaultMaxClockSkew = 15 * time.Minute
+
+	// Nodes whose clocks differ by more are reported on start, object
+	// versions are picked by modification time.
+	clockSkewWarnThreshold = 5 * time.Second
+)
+
+// isClockSkewed - returns true if t differs from now by more than the
+// allowed skew in either direction.
+func isClockSkewed(t, now time.Time) bool {
+	skew := now.Sub(t)
+	if skew < 0 {
+		skew = -skew
+	}
+	return skew > globalMaxClockSkew
+}
+
+// getClockSkew - returns how far the clock of the server of a network
+// disk is ahead of the local clock, the round trip is assumed to take
+// equally long in both directions.
+func getClockSkew(disk *networkStorage) (time.Duration, error) {
+	start := time.Now().UTC()
+	serverTime, err := disk.ServerTime()
+	if err != nil {
+		return 0, err
+	}
+	roundTrip := time.Since(start)
+	return serverTime.Sub(start.Add(roundTrip / 2)), nil
+}
+
+// checkClockSkew - compares the clocks of the servers of all network
+// disks with the local clock. Nodes whose clocks differ by more than the
+// allowed skew would reject each others requests and refuse to start,
+// unreachable nodes are skipped.
+func checkClockSkew(disks []string) error {
+	checked := make(map[string]struct{})
+	for _, disk := range disks {
+		if !strings.ContainsRune(disk, ':') || filepath.VolumeName(disk) != "" {
+			continue
+		}
+		netAddr, _ := splitNetPath(disk)
+		if _, ok := checked[netAddr]; ok {
+			continue
+		}
+		checked[netAddr] = struct{}{}
+
+		storage, err := newRPCClient(disk)
+		if err != nil {
+			errorIf(err, "Unable to check the clock of %s.", netAddr)
+			continue
+		}
+		netStorage := storage.(*networkStorage)
+		// The connection is kept, disks of the node share it.
+		skew, err := getClockSkew(netStorage)
+		if err != nil {
+			errorIf(err, "Unable to check the clock of %s.", netAddr)
+			continue
+		}
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > globalMaxClockSkew {
+			return fmt.Errorf("Clock of %s differs by %s, more than the allowed %s", netAddr, skew, globalMaxClockSkew)
+		}
+		if skew > clockSkewWarnThreshold {
+			log.Warnf("Clock of %s differs by %s, synchronize the clocks of all nodes.", netAddr, skew)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests request times are accepted within the allowed skew.
+func TestIsClockSkewed(t *testing.T) {
+	now := time.Now().UTC()
+	testCases := []struct {
+		t      time.Time
+		skewed bool
+	}{
+		{now, false},
+		{now.Add(-defaultMaxClockSkew + time.Minute), false},
+		{now.Add(defaultMaxClockSkew - time.Minute), false},
+		{now.Add(-defaultMaxClockSkew - time.Minute), true},
+		{now.Add(defaultMaxClockSkew + time.Minute), true},
+	}
+	for i, testCase := range testCases {
+		if skewed := isClockSkewed(testCase.t, now); skewed != testCase.skewed {
+			t.Errorf("Test %d: expected skewed %v, got %v", i+1, testCase.skewed, skewed)
+		}
+	}
+}
+
+// Tests signed requests from clients with skewed clocks are rejected.
+func TestTimeValidityHandler(t *testing.T) {
+	handler := setTimeValidityHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, offset := range []time.Duration{-time.Hour, time.Hour, time.Minute} {
+		req, err := http.NewRequest("GET", "http://localhost:9000/bucket", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256")
+		req.Header.Set("X-Amz-Date", time.Now().UTC().Add(offset).Format(iso8601Format))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		skewed := rec.Code == http.StatusForbidden && strings.Contains(rec.Body.String(), "RequestTimeTooSkewed")
+		if skewed != (offset != time.Minute) {
+			t.Errorf("Offset %s: unexpected response %d %s", offset, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// Tests clocks of nodes serving network disks are compared on start.
+func TestCheckClockSkew(t *testing.T) {
+	disk, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(disk)
+	storageRPC, err := newRPCServer(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := router.NewRouter()
+	registerStorageRPCRouter(mux, storageRPC)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	netDisk := strings.TrimPrefix(server.URL, "http://") + ":" + disk
+	storage, err := newRPCClient(netDisk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skew, err := getClockSkew(storage.(*networkStorage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew > time.Second || skew < -time.Second {
+		t.Fatalf("Expected no clock skew, got %s", skew)
+	}
+	if err = checkClockSkew([]string{disk, netDisk, netDisk}); err != nil {
+		t.Fatal(err)
+	}
+	storage.(*networkStorage).rpcClient.Close()
+}
@@ -31,6 +31,11 @@ var globalFlags = []cli.Flag{
 		Value: mustGetConfigPath(),
 		Usage: "Path to configuration folder.",
 	},
+	cli.StringFlag{
+		Name:  "certs-dir",
+		Value: mustGetCertsPath(),
+		Usage: "Path to certificates folder, sub-folders hold additional certificates served by host name.",
+	},
 	cli.BoolFlag{
 		Name:  "quiet",
 		Usage: "Suppress chatty output.",
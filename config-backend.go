@@ -0,0 +1,244 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// Config saved in minioMetaBucket, shared by all nodes of the backend.
+const backendConfigFile = "config/config.json"
+
+// Version of the encrypted config format.
+const backendConfigVersion = "1"
+
+// Context the data key of the config is sealed with by the KMS.
+var backendConfigContext = []byte("minio config")
+
+// errBackendConfigDecrypt - config cannot be decrypted with the current
+// root credentials or key management service.
+var errBackendConfigDecrypt = errors.New("Config cannot be decrypted, root credentials or key management service differ from the ones it was saved with")
+
+// globalBackendConfigLayer - object layer the config is saved in, nil
+// until the object layer is initialized and for gateways.
+var globalBackendConfigLayer ObjectLayer
+
+// encryptedConfig - config encrypted with AES-256-GCM. The key is
+// either a data key sealed by the KMS or derived from the root
+// credentials if no KMS is configured.
+type encryptedConfig struct {
+	Version   string `json:"version"`
+	KeyID     string `json:"keyID,omitempty"`
+	SealedKey []byte `json:"sealedKey,omitempty"`
+	Nonce     []byte `json:"nonce"`
+	Data      []byte `json:"data"`
+}
+
+// credentialConfigKey - derives the config key from root credentials.
+func credentialConfigKey(cred credential) (key [32]byte) {
+	mac := hmac.New(sha256.New, []byte(cred.SecretAccessKey))
+	mac.Write([]byte(cred.AccessKeyID))
+	mac.Write(backendConfigContext)
+	copy(key[:], mac.Sum(nil))
+	return key
+}
+
+// newConfigCipher - returns the AEAD encrypting the config with key.
+func newConfigCipher(key [32]byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptConfig - encrypts the JSON encoded config with a new data key
+// from the KMS, or with the key of cred if no KMS is configured.
+func encryptConfig(data []byte, cred credential) (encryptedConfig, error) {
+	config := encryptedConfig{Version: backendConfigVersion}
+	var key [32]byte
+	if globalKMS != nil {
+		var err error
+		config.KeyID = globalKMS.KeyID()
+		key, config.SealedKey, err = globalKMS.GenerateKey(config.KeyID, backendConfigContext)
+		if err != nil {
+			return encryptedConfig{}, err
+		}
+	} else {
+		key = credentialConfigKey(cred)
+	}
+	aead, err := newConfigCipher(key)
+	if err != nil {
+		return encryptedConfig{}, err
+	}
+	config.Nonce = make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, config.Nonce); err != nil {
+		return encryptedConfig{}, err
+	}
+	config.Data = aead.Seal(nil, config.Nonce, data, []byte(config.Version))
+	return config, nil
+}
+
+// decryptConfig - returns the JSON encoded config, cred is used if the
+// config was not encrypted by the KMS.
+func decryptConfig(config encryptedConfig, cred credential) ([]byte, error) {
+	if config.Version != backendConfigVersion {
+		return nil, errBackendConfigDecrypt
+	}
+	var key [32]byte
+	if config.KeyID != "" {
+		if globalKMS == nil {
+			return nil, errKMSNotConfigured
+		}
+		var err error
+		if key, err = globalKMS.UnsealKey(config.KeyID, config.SealedKey, backendConfigContext); err != nil {
+			return nil, err
+		}
+	} else {
+		key = credentialConfigKey(cred)
+	}
+	aead, err := newConfigCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Nonce) != aead.NonceSize() {
+		return nil, errBackendConfigDecrypt
+	}
+	data, err := aead.Open(nil, config.Nonce, config.Data, []byte(config.Version))
+	if err != nil {
+		return nil, errBackendConfigDecrypt
+	}
+	return data, nil
+}
+
+// saveBackendConfig - saves config encrypted in the backend, config
+// must not be locked for writing.
+func saveBackendConfig(objAPI ObjectLayer, config *serverConfigV6) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	encConfig, err := encryptConfig(data, config.Credential)
+	if err != nil {
+		return err
+	}
+	return saveJSONMetaFile(objAPI, backendConfigFile, encConfig)
+}
+
+// loadBackendConfig - loads the config saved in the backend, returns
+// errFileNotFound if there is none.
+func loadBackendConfig(objAPI ObjectLayer, cred credential) (*serverConfigV6, error) {
+	encData, err := loadMetaFile(objAPI, backendConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseEncryptedConfig(encData, cred)
+}
+
+// parseEncryptedConfig - decrypts and validates a config saved in the
+// backend or in etcd.
+func parseEncryptedConfig(encData []byte, cred credential) (*serverConfigV6, error) {
+	encConfig := encryptedConfig{}
+	if err := json.Unmarshal(encData, &encConfig); err != nil {
+		return nil, err
+	}
+	data, err := decryptConfig(encConfig, cred)
+	if err != nil {
+		return nil, err
+	}
+	config := &serverConfigV6{}
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	config.Version = globalMinioConfigVersion
+	config.rwMutex = &sync.RWMutex{}
+	if err = validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// updateSharedConfig - applies a config loaded from the backend or
+// etcd, credentials set by environment variables take precedence.
+func updateSharedConfig(config *serverConfigV6) bool {
+	if isEnvCredential() {
+		config.Credential = serverConfig.GetCredential()
+	}
+	return serverConfig.Update(config)
+}
+
+// isEnvCredential - returns true if the root credentials are set by
+// environment variables.
+func isEnvCredential() bool {
+	return os.Getenv("MINIO_ACCESS_KEY") != "" && os.Getenv("MINIO_SECRET_KEY") != ""
+}
+
+// isBackendOnlyConfig - returns true if the config key does not depend
+// on the local config, the config is then saved in the backend only.
+func isBackendOnlyConfig() bool {
+	return globalKMS != nil || isEnvCredential()
+}
+
+// initBackendConfig - loads the config shared by all nodes from the
+// backend, the local config is saved in the backend if there is none
+// yet. The plaintext local config is removed unless it holds the root
+// credentials the config is encrypted with.
+func initBackendConfig(objAPI ObjectLayer) error {
+	cred := serverConfig.GetCredential()
+	config, err := loadBackendConfig(objAPI, cred)
+	if err == errFileNotFound {
+		if err = saveBackendConfig(objAPI, serverConfig); err != nil {
+			return err
+		}
+		globalBackendConfigLayer = objAPI
+		return removeLocalConfig()
+	}
+	if err != nil {
+		return err
+	}
+	updateSharedConfig(config)
+	globalBackendConfigLayer = objAPI
+	if isBackendOnlyConfig() {
+		return removeLocalConfig()
+	}
+	return serverConfig.Save()
+}
+
+// removeLocalConfig - removes the plaintext local config if it is
+// saved in the backend only.
+func removeLocalConfig() error {
+	if !isBackendOnlyConfig() {
+		return nil
+	}
+	configFile, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(configFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// Tests config is only decrypted with the key it was encrypted with.
+func TestEncryptConfig(t *testing.T) {
+	cred := credential{AccessKeyID: "minio", SecretAccessKey: "minio123"}
+	data := []byte(`{"version":"6"}`)
+
+	encConfig, err := encryptConfig(data, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encConfig.KeyID != "" {
+		t.Fatalf("Expected config to be keyed by credentials, but instead found key ID %s", encConfig.KeyID)
+	}
+	decData, err := decryptConfig(encConfig, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decData) != string(data) {
+		t.Fatalf("Expected %s, but instead found %s", data, decData)
+	}
+	if _, err = decryptConfig(encConfig, credential{AccessKeyID: "minio", SecretAccessKey: "minio456"}); err != errBackendConfigDecrypt {
+		t.Fatalf("Expected %s, but instead found %v", errBackendConfigDecrypt, err)
+	}
+
+	// Config keyed by the KMS does not depend on credentials.
+	kms, err := parseMasterKey("my-key:6368616e676520746869732070617373776f726420746f206120736563726574")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalKMS = kms
+	defer func() { globalKMS = nil }()
+	if encConfig, err = encryptConfig(data, cred); err != nil {
+		t.Fatal(err)
+	}
+	if encConfig.KeyID != "my-key" {
+		t.Fatalf("Expected key ID my-key, but instead found %s", encConfig.KeyID)
+	}
+	if decData, err = decryptConfig(encConfig, credential{}); err != nil {
+		t.Fatal(err)
+	}
+	if string(decData) != string(data) {
+		t.Fatalf("Expected %s, but instead found %s", data, decData)
+	}
+	globalKMS = nil
+	if _, err = decryptConfig(encConfig, cred); err != errKMSNotConfigured {
+		t.Fatalf("Expected %s, but instead found %v", errKMSNotConfigured, err)
+	}
+}
+
+// Wrapper for calling backend config tests for both XL multiple disks and single node setup.
+func TestBackendConfig(t *testing.T) {
+	ExecObjectLayerTest(t, testBackendConfig)
+}
+
+// Tests the local config is saved in the backend and loaded from it.
+func testBackendConfig(obj ObjectLayer, instanceType string, t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer func() { globalBackendConfigLayer = nil }()
+
+	if err = initBackendConfig(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if globalBackendConfigLayer == nil {
+		t.Fatalf("%s: Expected config to be saved in the backend", instanceType)
+	}
+	// Local config holds the credentials the config is encrypted with.
+	if !isConfigFileExists() {
+		t.Fatalf("%s: Expected local config to be kept", instanceType)
+	}
+
+	serverConfig.SetRegion("eu-west-1")
+	if err = serverConfig.Save(); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	config, err := loadBackendConfig(obj, serverConfig.GetCredential())
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if config.GetRegion() != "eu-west-1" {
+		t.Fatalf("%s: Expected region eu-west-1, but instead found %s", instanceType, config.GetRegion())
+	}
+
+	// Config of the backend takes precedence over the local config.
+	serverConfig.SetRegion("us-east-1")
+	if err = initBackendConfig(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if serverConfig.GetRegion() != "eu-west-1" {
+		t.Fatalf("%s: Expected region eu-west-1, but instead found %s", instanceType, serverConfig.GetRegion())
+	}
+
+	// Local config is removed if credentials are set by the environment.
+	cred := serverConfig.GetCredential()
+	os.Setenv("MINIO_ACCESS_KEY", cred.AccessKeyID)
+	os.Setenv("MINIO_SECRET_KEY", cred.SecretAccessKey)
+	defer os.Unsetenv("MINIO_ACCESS_KEY")
+	defer os.Unsetenv("MINIO_SECRET_KEY")
+	if err = initBackendConfig(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if isConfigFileExists() {
+		t.Fatalf("%s: Expected local config to be removed", instanceType)
+	}
+}
@@ -0,0 +1,444 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// Version of the config bundle format.
+	configBundleVersion = "1"
+
+	// Header carrying the passphrase the secrets of a config bundle
+	// are encrypted with.
+	configPassphraseHeader = "X-Minio-Config-Passphrase"
+
+	// Iterations of the key derivation from the bundle passphrase.
+	configBundleKDFIterations = 100000
+)
+
+// Context the bundle secrets are encrypted with.
+var configBundleContext = []byte("minio config bundle")
+
+var (
+	// errInvalidConfigBundle - bundle is malformed or of another version.
+	errInvalidConfigBundle = errors.New("Invalid config bundle")
+
+	// errConfigBundleSecrets - bundle secrets cannot be decrypted, the
+	// passphrase is missing or wrong.
+	errConfigBundleSecrets = errors.New("Config bundle secrets cannot be decrypted with the provided passphrase")
+)
+
+// configBundle - server config and bucket configs of a deployment,
+// exported for disaster recovery or to promote a configuration to
+// another deployment. Secrets are removed from the configs and, if a
+// passphrase was provided, saved encrypted in Secrets.
+type configBundle struct {
+	Version string                         `json:"version"`
+	Server  *serverConfigV6                `json:"server"`
+	Buckets map[string]*bucketConfigBundle `json:"buckets,omitempty"`
+	Secrets *sealedBundleSecrets           `json:"secrets,omitempty"`
+}
+
+// bucketConfigBundle - configs of a bucket, encryption and object lock
+// are XML documents as accepted by the S3 API.
+type bucketConfigBundle struct {
+	Location    string                   `json:"location,omitempty"`
+	Policy      json.RawMessage          `json:"policy,omitempty"`
+	Encryption  string                   `json:"encryption,omitempty"`
+	ObjectLock  string                   `json:"objectLock,omitempty"`
+	Replication *bucketReplicationConfig `json:"replication,omitempty"`
+	Trash       *bucketTrashConfig       `json:"trash,omitempty"`
+}
+
+// bundleSecrets - secrets removed from a config bundle.
+type bundleSecrets struct {
+	SecretKey          string            `json:"secretKey,omitempty"`
+	VaultToken         string            `json:"vaultToken,omitempty"`
+	VaultAppRoleSecret string            `json:"vaultAppRoleSecret,omitempty"`
+	Replication        map[string]string `json:"replication,omitempty"` // Target secret key per bucket.
+}
+
+// sealedBundleSecrets - bundle secrets encrypted with AES-256-GCM, the
+// key is derived from a passphrase and Salt.
+type sealedBundleSecrets struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// configImportResult - result of a config bundle import.
+type configImportResult struct {
+	RestartRequired bool     `json:"restartRequired"`
+	Buckets         []string `json:"buckets,omitempty"` // Buckets whose configs were imported.
+	Created         []string `json:"created,omitempty"` // Buckets created by the import.
+	Skipped         []string `json:"skipped,omitempty"` // Configs which could not be imported.
+}
+
+// passphraseKey - derives the key of the bundle secrets from passphrase
+// with PBKDF2-HMAC-SHA256.
+func passphraseKey(passphrase string, salt []byte) (key [32]byte) {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+	copy(key[:], u)
+	for i := 1; i < configBundleKDFIterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+// sealBundleSecrets - encrypts secrets with a key derived from passphrase.
+func sealBundleSecrets(secrets bundleSecrets, passphrase string) (*sealedBundleSecrets, error) {
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	sealed := &sealedBundleSecrets{Salt: make([]byte, 32)}
+	if _, err = io.ReadFull(rand.Reader, sealed.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := newConfigCipher(passphraseKey(passphrase, sealed.Salt))
+	if err != nil {
+		return nil, err
+	}
+	sealed.Nonce = make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, sealed.Nonce); err != nil {
+		return nil, err
+	}
+	sealed.Data = aead.Seal(nil, sealed.Nonce, data, configBundleContext)
+	return sealed, nil
+}
+
+// openBundleSecrets - decrypts secrets sealed with passphrase.
+func openBundleSecrets(sealed *sealedBundleSecrets, passphrase string) (secrets bundleSecrets, err error) {
+	if passphrase == "" {
+		return secrets, errConfigBundleSecrets
+	}
+	aead, err := newConfigCipher(passphraseKey(passphrase, sealed.Salt))
+	if err != nil {
+		return secrets, err
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return secrets, errConfigBundleSecrets
+	}
+	data, err := aead.Open(nil, sealed.Nonce, sealed.Data, configBundleContext)
+	if err != nil {
+		return secrets, errConfigBundleSecrets
+	}
+	if err = json.Unmarshal(data, &secrets); err != nil {
+		return secrets, errConfigBundleSecrets
+	}
+	return secrets, nil
+}
+
+// takeSecrets - removes all secrets from the bundle and returns them.
+func (b *configBundle) takeSecrets() bundleSecrets {
+	secrets := bundleSecrets{
+		SecretKey:          b.Server.Credential.SecretAccessKey,
+		VaultToken:         b.Server.KMS.Vault.Auth.Token,
+		VaultAppRoleSecret: b.Server.KMS.Vault.Auth.AppRole.Secret,
+		Replication:        make(map[string]string),
+	}
+	b.Server.Credential.SecretAccessKey = ""
+	b.Server.KMS.Vault.Auth.Token = ""
+	b.Server.KMS.Vault.Auth.AppRole.Secret = ""
+	for bucket, config := range b.Buckets {
+		if config.Replication != nil {
+			secrets.Replication[bucket] = config.Replication.SecretKey
+			config.Replication.SecretKey = ""
+		}
+	}
+	return secrets
+}
+
+// restoreSecrets - puts secrets back into the bundle. Secrets missing
+// from secrets are taken from the local configs if they apply, root
+// credentials are kept as a whole.
+func (b *configBundle) restoreSecrets(secrets bundleSecrets) {
+	if secrets.SecretKey != "" {
+		b.Server.Credential.SecretAccessKey = secrets.SecretKey
+	} else {
+		b.Server.Credential = serverConfig.GetCredential()
+	}
+	vault := &b.Server.KMS.Vault
+	local := serverConfig.GetKMS().Vault
+	if vault.Auth.Token = secrets.VaultToken; vault.Auth.Token == "" && vault.Endpoint == local.Endpoint {
+		vault.Auth.Token = local.Auth.Token
+	}
+	vault.Auth.AppRole.Secret = secrets.VaultAppRoleSecret
+	if vault.Auth.AppRole.Secret == "" && vault.Auth.AppRole.ID == local.Auth.AppRole.ID {
+		vault.Auth.AppRole.Secret = local.Auth.AppRole.Secret
+	}
+	for bucket, config := range b.Buckets {
+		target := config.Replication
+		if target == nil {
+			continue
+		}
+		if target.SecretKey = secrets.Replication[bucket]; target.SecretKey != "" {
+			continue
+		}
+		if local, err := readBucketReplicationConfig(bucket); err == nil &&
+			local.Endpoint == target.Endpoint && local.AccessKey == target.AccessKey {
+			target.SecretKey = local.SecretKey
+		}
+	}
+}
+
+// isBucketConfigNotFound - returns true if err tells that a bucket has
+// no such config.
+func isBucketConfigNotFound(err error) bool {
+	switch err.(type) {
+	case BucketPolicyNotFound, BucketEncryptionConfigNotFound, BucketObjectLockConfigNotFound,
+		BucketReplicationConfigNotFound, BucketTrashConfigNotFound:
+		return true
+	}
+	return false
+}
+
+// exportBucketConfig - returns the configs of bucket, nil if it has
+// none besides its location.
+func exportBucketConfig(bucket string) (*bucketConfigBundle, error) {
+	location, err := readBucketLocation(bucket)
+	if err != nil {
+		return nil, err
+	}
+	config := &bucketConfigBundle{Location: location}
+	if config.Policy, err = readBucketPolicy(bucket); err != nil && !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+	encryption, err := readBucketEncryptionConfig(bucket)
+	if err == nil {
+		var data []byte
+		if data, err = xml.Marshal(encryption); err != nil {
+			return nil, err
+		}
+		config.Encryption = string(data)
+	} else if !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+	objectLock, err := readBucketObjectLockConfig(bucket)
+	if err == nil {
+		var data []byte
+		if data, err = xml.Marshal(objectLock); err != nil {
+			return nil, err
+		}
+		config.ObjectLock = string(data)
+	} else if !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+	if config.Replication, err = readBucketReplicationConfig(bucket); err != nil && !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+	if config.Trash, err = readBucketTrashConfig(bucket); err != nil && !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+	return config, nil
+}
+
+// exportConfigBundle - returns the server config and the configs of all
+// buckets. Secrets are encrypted with passphrase, or left out if
+// passphrase is empty.
+func exportConfigBundle(objAPI ObjectLayer, passphrase string) (*configBundle, error) {
+	data, err := serverConfig.JSON()
+	if err != nil {
+		return nil, err
+	}
+	bundle := &configBundle{
+		Version: configBundleVersion,
+		Server:  &serverConfigV6{},
+		Buckets: make(map[string]*bucketConfigBundle),
+	}
+	if err = json.Unmarshal(data, bundle.Server); err != nil {
+		return nil, err
+	}
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		if bundle.Buckets[bucket.Name], err = exportBucketConfig(bucket.Name); err != nil {
+			return nil, err
+		}
+	}
+	secrets := bundle.takeSecrets()
+	if passphrase != "" {
+		if bundle.Secrets, err = sealBundleSecrets(secrets, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
+
+// validateBucketConfigBundle - validates the configs of bucket.
+func validateBucketConfigBundle(bucket string, config *bucketConfigBundle) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if len(config.Policy) > 0 {
+		policy, err := parseBucketPolicy(config.Policy)
+		if err != nil {
+			return err
+		}
+		if checkBucketPolicyResources(bucket, policy) != ErrNone {
+			return fmt.Errorf("Policy of bucket %s has invalid resources", bucket)
+		}
+	}
+	if config.Encryption != "" {
+		if _, s3Error := parseBucketEncryptionConfig([]byte(config.Encryption)); s3Error != ErrNone {
+			return fmt.Errorf("Encryption config of bucket %s is invalid", bucket)
+		}
+	}
+	if config.ObjectLock != "" {
+		if _, s3Error := parseObjectLockConfig([]byte(config.ObjectLock)); s3Error != ErrNone {
+			return fmt.Errorf("Object lock config of bucket %s is invalid", bucket)
+		}
+	}
+	if config.Trash != nil {
+		if err := validateBucketTrashConfig(*config.Trash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareConfigBundle - decrypts the secrets of the bundle with
+// passphrase and validates all configs. Replication targets whose
+// secret is neither in the bundle nor in the local config are dropped
+// and returned.
+func prepareConfigBundle(bundle *configBundle, passphrase string) (skipped []string, err error) {
+	if bundle.Version != configBundleVersion || bundle.Server == nil {
+		return nil, errInvalidConfigBundle
+	}
+	var secrets bundleSecrets
+	if bundle.Secrets != nil {
+		if secrets, err = openBundleSecrets(bundle.Secrets, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	bundle.restoreSecrets(secrets)
+	if err = validateConfig(bundle.Server); err != nil {
+		return nil, err
+	}
+	for bucket, config := range bundle.Buckets {
+		if config == nil {
+			return nil, errInvalidConfigBundle
+		}
+		if err = validateBucketConfigBundle(bucket, config); err != nil {
+			return nil, err
+		}
+		if config.Replication == nil {
+			continue
+		}
+		if config.Replication.SecretKey == "" {
+			skipped = append(skipped, "Replication config of bucket "+bucket+": target secret key is missing")
+			config.Replication = nil
+		} else if err = validateBucketReplicationConfig(*config.Replication); err != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+// importBucketConfig - creates bucket if it does not exist and saves
+// its configs, returns true if the bucket was created.
+func importBucketConfig(objAPI ObjectLayer, bucket string, config *bucketConfigBundle) (created bool, err error) {
+	if _, err = objAPI.GetBucketInfo(bucket); err != nil {
+		if _, ok := err.(BucketNotFound); !ok {
+			return false, err
+		}
+		if err = objAPI.MakeBucket(bucket); err != nil {
+			return false, err
+		}
+		created = true
+		if config.Location != "" {
+			if err = writeBucketLocation(bucket, config.Location); err != nil {
+				return created, err
+			}
+		}
+	}
+	if len(config.Policy) > 0 {
+		if err = writeBucketPolicy(bucket, config.Policy); err != nil {
+			return created, err
+		}
+	}
+	if config.Encryption != "" {
+		encryption, _ := parseBucketEncryptionConfig([]byte(config.Encryption))
+		if err = writeBucketEncryptionConfig(bucket, encryption); err != nil {
+			return created, err
+		}
+	}
+	if config.ObjectLock != "" {
+		objectLock, _ := parseObjectLockConfig([]byte(config.ObjectLock))
+		if err = writeBucketObjectLockConfig(bucket, objectLock); err != nil {
+			return created, err
+		}
+	}
+	if config.Replication != nil {
+		if err = writeBucketReplicationConfig(bucket, config.Replication); err != nil {
+			return created, err
+		}
+		if globalReplication != nil {
+			globalReplication.invalidate(bucket)
+		}
+	}
+	if config.Trash != nil {
+		if err = writeBucketTrashConfig(bucket, config.Trash); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// importConfigBundle - saves the configs of a bundle prepared by
+// prepareConfigBundle. Buckets missing in this deployment are created,
+// configs not in the bundle are left untouched.
+func importConfigBundle(objAPI ObjectLayer, bundle *configBundle, skipped []string) (result configImportResult, err error) {
+	result.Skipped = skipped
+	for bucket, config := range bundle.Buckets {
+		created, err := importBucketConfig(objAPI, bucket, config)
+		if err != nil {
+			return result, err
+		}
+		if created {
+			result.Created = append(result.Created, bucket)
+		}
+		result.Buckets = append(result.Buckets, bucket)
+	}
+	sort.Strings(result.Buckets)
+	sort.Strings(result.Created)
+	result.RestartRequired = serverConfig.Update(bundle.Server)
+	if err = serverConfig.Save(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
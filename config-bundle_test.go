@@ -0,0 +1,178 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// newTestDeployment - sets up a config and an object layer of a new
+// deployment, returns a function removing both.
+func newTestDeployment(t *testing.T) (ObjectLayer, func()) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	obj, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj, func() { removeAll(root); removeAll(fsDir) }
+}
+
+// Tests bundle secrets are only decrypted with the right passphrase.
+func TestBundleSecrets(t *testing.T) {
+	secrets := bundleSecrets{SecretKey: "secret", Replication: map[string]string{"bucket": "target"}}
+	sealed, err := sealBundleSecrets(secrets, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed.Data, []byte("secret")) {
+		t.Fatal("Expected secrets to be encrypted")
+	}
+	for _, passphrase := range []string{"", "wrong"} {
+		if _, err = openBundleSecrets(sealed, passphrase); err != errConfigBundleSecrets {
+			t.Fatalf("Passphrase %q: expected %v, but instead got %v", passphrase, errConfigBundleSecrets, err)
+		}
+	}
+	opened, err := openBundleSecrets(sealed, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.SecretKey != secrets.SecretKey || opened.Replication["bucket"] != "target" {
+		t.Fatalf("Expected %v, but instead got %v", secrets, opened)
+	}
+}
+
+// Tests configs exported from one deployment are imported into another.
+func TestConfigBundle(t *testing.T) {
+	defer os.Setenv("MINIO_ACCESS_KEY", os.Getenv("MINIO_ACCESS_KEY"))
+	defer os.Setenv("MINIO_SECRET_KEY", os.Getenv("MINIO_SECRET_KEY"))
+	os.Unsetenv("MINIO_ACCESS_KEY")
+	os.Unsetenv("MINIO_SECRET_KEY")
+
+	obj, cleanup := newTestDeployment(t)
+	defer cleanup()
+	cred := serverConfig.GetCredential()
+	bucket := "bundle-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	replication := &bucketReplicationConfig{
+		Endpoint:  "http://localhost:9001",
+		Region:    "us-east-1",
+		Bucket:    "target",
+		AccessKey: "replicationaccess",
+		SecretKey: "replicationsecret",
+	}
+	if err := writeBucketReplicationConfig(bucket, replication); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBucketTrashConfig(bucket, &bucketTrashConfig{RetentionDays: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(passphrase string) []byte {
+		bundle, err := exportConfigBundle(obj, passphrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte(cred.SecretAccessKey)) || bytes.Contains(data, []byte(replication.SecretKey)) {
+			t.Fatal("Expected secrets to be removed from the bundle")
+		}
+		return data
+	}
+	sealed, plain := export("passphrase"), export("")
+
+	importBundle := func(obj ObjectLayer, data []byte, passphrase string) (configImportResult, error) {
+		bundle := &configBundle{}
+		if err := json.Unmarshal(data, bundle); err != nil {
+			t.Fatal(err)
+		}
+		skipped, err := prepareConfigBundle(bundle, passphrase)
+		if err != nil {
+			return configImportResult{}, err
+		}
+		return importConfigBundle(obj, bundle, skipped)
+	}
+
+	// Secrets are restored with the passphrase of the export.
+	obj2, cleanup2 := newTestDeployment(t)
+	defer cleanup2()
+	if _, err := importBundle(obj2, sealed, "wrong"); err != errConfigBundleSecrets {
+		t.Fatalf("Expected %v, but instead got %v", errConfigBundleSecrets, err)
+	}
+	result, err := importBundle(obj2, sealed, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Created) != 1 || result.Created[0] != bucket || len(result.Skipped) != 0 {
+		t.Fatalf("Unexpected import result %+v", result)
+	}
+	if serverConfig.GetCredential() != cred {
+		t.Fatal("Expected root credentials to be imported")
+	}
+	if _, err = obj2.GetBucketInfo(bucket); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := readBucketReplicationConfig(bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.SecretKey != replication.SecretKey {
+		t.Fatal("Expected replication secret key to be imported")
+	}
+	trash, err := readBucketTrashConfig(bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trash.RetentionDays != 7 {
+		t.Fatalf("Expected trash retention of 7 days, but instead got %d", trash.RetentionDays)
+	}
+
+	// Without secrets the local ones are kept, replication targets
+	// without a secret are skipped.
+	obj3, cleanup3 := newTestDeployment(t)
+	defer cleanup3()
+	cred3 := serverConfig.GetCredential()
+	if result, err = importBundle(obj3, plain, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 1 {
+		t.Fatalf("Expected replication config to be skipped, but instead got %+v", result)
+	}
+	if serverConfig.GetCredential() != cred3 {
+		t.Fatal("Expected local root credentials to be kept")
+	}
+	if _, err = readBucketReplicationConfig(bucket); err == nil {
+		t.Fatal("Expected no replication config")
+	}
+	if _, err = readBucketTrashConfig(bucket); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,604 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys of the config in etcd, bucket configs are saved below
+// etcdBucketsConfigPrefix as <bucket>/<config file>.
+const (
+	etcdConfigPrefix        = "minio/config/"
+	etcdConfigKey           = etcdConfigPrefix + "config.json"
+	etcdBucketsConfigPrefix = etcdConfigPrefix + "buckets/"
+)
+
+// Time between attempts to restart a failed watch.
+const etcdWatchRetryInterval = 5 * time.Second
+
+// errEtcdKeyNotFound - key does not exist in etcd.
+var errEtcdKeyNotFound = errors.New("Key not found in etcd")
+
+// globalEtcdClient - etcd the config is shared through, nil if the
+// config is not kept in etcd.
+var globalEtcdClient *etcdClient
+
+// etcdClient - client of the JSON gateway of the etcd v3 API.
+type etcdClient struct {
+	endpoints  []string
+	httpClient *http.Client
+
+	// Credential bucket configs are encrypted with, they are encrypted
+	// again once a config with other credentials is saved.
+	mutex *sync.Mutex
+	cred  credential
+}
+
+// etcdKeyValue - key value pair of etcd, keys and values are base64
+// encoded by the JSON gateway.
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+// etcdRangeRequest - request of the keys from Key up to RangeEnd, of
+// Key only if RangeEnd is empty.
+type etcdRangeRequest struct {
+	Key           []byte `json:"key"`
+	RangeEnd      []byte `json:"range_end,omitempty"`
+	StartRevision int64  `json:"start_revision,omitempty,string"`
+}
+
+// etcdRangeResponse - response to a range request.
+type etcdRangeResponse struct {
+	Header struct {
+		Revision int64 `json:"revision,string"`
+	} `json:"header"`
+	Kvs []etcdKeyValue `json:"kvs"`
+}
+
+// etcdWatchEvent - change of a watched key, Type is empty for new
+// values and "DELETE" for deleted keys.
+type etcdWatchEvent struct {
+	Type string       `json:"type,omitempty"`
+	Kv   etcdKeyValue `json:"kv"`
+}
+
+// etcdWatchResponse - message of a watch stream.
+type etcdWatchResponse struct {
+	Result struct {
+		Canceled bool             `json:"canceled"`
+		Events   []etcdWatchEvent `json:"events"`
+	} `json:"result"`
+}
+
+// getPrefixRangeEnd - returns the end of the range of all keys
+// starting with prefix.
+func getPrefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// All keys are in the range.
+	return []byte{0}
+}
+
+// newEtcdClient - returns a client of the comma separated endpoints.
+func newEtcdClient(endpoints string) (*etcdClient, error) {
+	client := &etcdClient{httpClient: &http.Client{}, mutex: &sync.Mutex{}}
+	for _, endpoint := range strings.Split(endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			client.endpoints = append(client.endpoints, strings.TrimSuffix(endpoint, "/"))
+		}
+	}
+	if len(client.endpoints) == 0 {
+		return nil, errInvalidArgument
+	}
+	return client, nil
+}
+
+// post - sends request to the first endpoint answering it, the request
+// is canceled once ctx is done.
+func (c *etcdClient) post(ctx context.Context, path string, request interface{}) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoint := range c.endpoints {
+		var req *http.Request
+		req, err = http.NewRequest("POST", endpoint+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		var resp *http.Response
+		resp, err = c.httpClient.Do(req.WithContext(ctx))
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			err = fmt.Errorf("etcd %s responded with %s", endpoint, resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, err
+}
+
+// getRange - returns the key value pairs of request and the revision
+// of etcd they were read at.
+func (c *etcdClient) getRange(request etcdRangeRequest) ([]etcdKeyValue, int64, error) {
+	resp, err := c.post(context.Background(), "/v3/kv/range", request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	rangeResp := etcdRangeResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&rangeResp); err != nil {
+		return nil, 0, err
+	}
+	return rangeResp.Kvs, rangeResp.Header.Revision, nil
+}
+
+// Get - returns the value of key.
+func (c *etcdClient) Get(key string) ([]byte, error) {
+	kvs, _, err := c.getRange(etcdRangeRequest{Key: []byte(key)})
+	if err != nil {
+		return nil, err
+	}
+	if len(kvs) == 0 {
+		return nil, errEtcdKeyNotFound
+	}
+	return kvs[0].Value, nil
+}
+
+// GetPrefix - returns all keys starting with prefix and the revision
+// of etcd they were read at.
+func (c *etcdClient) GetPrefix(prefix string) ([]etcdKeyValue, int64, error) {
+	return c.getRange(etcdRangeRequest{Key: []byte(prefix), RangeEnd: getPrefixRangeEnd(prefix)})
+}
+
+// Put - sets the value of key.
+func (c *etcdClient) Put(key string, value []byte) error {
+	resp, err := c.post(context.Background(), "/v3/kv/put", etcdKeyValue{Key: []byte(key), Value: value})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Delete - deletes key, deleting a missing key is not an error.
+func (c *etcdClient) Delete(key string) error {
+	resp, err := c.post(context.Background(), "/v3/kv/deleterange", etcdRangeRequest{Key: []byte(key)})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DeletePrefix - deletes all keys starting with prefix.
+func (c *etcdClient) DeletePrefix(prefix string) error {
+	request := etcdRangeRequest{Key: []byte(prefix), RangeEnd: getPrefixRangeEnd(prefix)}
+	resp, err := c.post(context.Background(), "/v3/kv/deleterange", request)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// WatchPrefix - calls fn with every change of a key starting with
+// prefix after revision until doneCh is closed. The watch is restarted
+// if it fails, after sync caught up with the changes missed meanwhile
+// and returned the revision it read.
+func (c *etcdClient) WatchPrefix(prefix string, revision int64, sync func() (int64, error), fn func(event etcdWatchEvent), doneCh <-chan struct{}) {
+	// Streams are only ended by canceling their request.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		err := c.watch(ctx, prefix, revision+1, fn)
+		if ctx.Err() != nil {
+			return
+		}
+		errorIf(err, "Unable to watch %s in etcd.", prefix)
+		for {
+			select {
+			case <-doneCh:
+				return
+			case <-time.After(etcdWatchRetryInterval):
+			}
+			if revision, err = sync(); err == nil {
+				break
+			}
+			errorIf(err, "Unable to read %s from etcd.", prefix)
+		}
+	}
+}
+
+// watch - runs a single watch stream of the keys starting with prefix
+// from startRevision on until ctx is done.
+func (c *etcdClient) watch(ctx context.Context, prefix string, startRevision int64, fn func(event etcdWatchEvent)) error {
+	request := map[string]interface{}{
+		"create_request": etcdRangeRequest{
+			Key:           []byte(prefix),
+			RangeEnd:      getPrefixRangeEnd(prefix),
+			StartRevision: startRevision,
+		},
+	}
+	resp, err := c.post(ctx, "/v3/watch", request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		watchResp := etcdWatchResponse{}
+		if err = decoder.Decode(&watchResp); err != nil {
+			return err
+		}
+		if watchResp.Result.Canceled {
+			return errors.New("etcd canceled the watch")
+		}
+		for _, event := range watchResp.Result.Events {
+			fn(event)
+		}
+	}
+}
+
+// bucketCred - returns the credential bucket configs are encrypted with.
+func (c *etcdClient) bucketCred() credential {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.cred
+}
+
+// setBucketCred - sets the credential bucket configs are encrypted
+// with, returns true if it changed.
+func (c *etcdClient) setBucketCred(cred credential) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	changed := c.cred != cred
+	c.cred = cred
+	return changed
+}
+
+// encryptEtcdValue - encrypts data saved in etcd like the config.
+func encryptEtcdValue(data []byte, cred credential) ([]byte, error) {
+	encConfig, err := encryptConfig(data, cred)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encConfig)
+}
+
+// saveEtcdConfig - saves config encrypted in etcd, config must not be
+// locked for writing. Bucket configs are saved again if the config
+// has other credentials than the ones they are encrypted with.
+func saveEtcdConfig(client *etcdClient, config *serverConfigV6) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	encData, err := encryptEtcdValue(data, config.Credential)
+	if err != nil {
+		return err
+	}
+	if err = client.Put(etcdConfigKey, encData); err != nil {
+		return err
+	}
+	if !client.setBucketCred(config.Credential) {
+		return nil
+	}
+	return saveEtcdBucketConfigs(client, config.Credential)
+}
+
+// applyEtcdConfig - applies a config saved in etcd, returns true if
+// changed settings only take effect after a restart.
+func applyEtcdConfig(client *etcdClient, encData []byte) (bool, error) {
+	config, err := parseEncryptedConfig(encData, serverConfig.GetCredential())
+	if err != nil {
+		return false, err
+	}
+	restartRequired := updateSharedConfig(config)
+	// Servers saving a config with new credentials encrypt the bucket
+	// configs again, etcd reports them after the config.
+	client.setBucketCred(serverConfig.GetCredential())
+	return restartRequired, nil
+}
+
+// getEtcdBucketConfigKey - returns the etcd key of a bucket config
+// file, false if name is not a bucket config file.
+func getEtcdBucketConfigKey(name string) (string, bool) {
+	bucketsConfigPath, err := getBucketsConfigPath()
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(bucketsConfigPath, name)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return etcdBucketsConfigPrefix + filepath.ToSlash(rel), true
+}
+
+// getEtcdBucketConfigFile - returns the bucket config file saved in
+// etcd as key, false if key does not name a bucket config file.
+func getEtcdBucketConfigFile(key string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, etcdBucketsConfigPrefix), "/")
+	if len(parts) != 2 || !IsValidBucketName(parts[0]) || parts[1] == "" || parts[1] == "." || parts[1] == ".." {
+		return "", false
+	}
+	bucketConfigPath, err := getBucketConfigPath(parts[0])
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(bucketConfigPath, parts[1]), true
+}
+
+// putEtcdBucketConfig - saves a bucket config file encrypted in etcd,
+// if bucket configs are shared through etcd.
+func putEtcdBucketConfig(name string, data []byte) error {
+	client := globalEtcdClient
+	if client == nil {
+		return nil
+	}
+	key, ok := getEtcdBucketConfigKey(name)
+	if !ok {
+		return nil
+	}
+	encData, err := encryptEtcdValue(data, client.bucketCred())
+	if err != nil {
+		return err
+	}
+	return client.Put(key, encData)
+}
+
+// deleteEtcdBucketConfig - deletes a bucket config file from etcd, if
+// bucket configs are shared through etcd.
+func deleteEtcdBucketConfig(name string) error {
+	client := globalEtcdClient
+	if client == nil {
+		return nil
+	}
+	key, ok := getEtcdBucketConfigKey(name)
+	if !ok {
+		return nil
+	}
+	return client.Delete(key)
+}
+
+// deleteEtcdBucketConfigs - deletes all config files of a bucket from
+// etcd, if bucket configs are shared through etcd.
+func deleteEtcdBucketConfigs(bucket string) error {
+	client := globalEtcdClient
+	if client == nil {
+		return nil
+	}
+	return client.DeletePrefix(etcdBucketsConfigPrefix + bucket + "/")
+}
+
+// listLocalBucketConfigs - returns all local bucket config files.
+func listLocalBucketConfigs() ([]string, error) {
+	bucketsConfigPath, err := getBucketsConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := ioutil.ReadDir(bucketsConfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, bucket := range buckets {
+		if !bucket.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(bucketsConfigPath, bucket.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			// Temporary files of an unfinished write are skipped.
+			if file.IsDir() || strings.HasSuffix(file.Name(), ".tmp") {
+				continue
+			}
+			names = append(names, filepath.Join(bucketsConfigPath, bucket.Name(), file.Name()))
+		}
+	}
+	return names, nil
+}
+
+// saveEtcdBucketConfigs - saves all local bucket config files in etcd
+// encrypted with cred.
+func saveEtcdBucketConfigs(client *etcdClient, cred credential) error {
+	names, err := listLocalBucketConfigs()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		key, ok := getEtcdBucketConfigKey(name)
+		if !ok {
+			continue
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		encData, err := encryptEtcdValue(data, cred)
+		if err != nil {
+			return err
+		}
+		if err = client.Put(key, encData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEtcdBucketConfig - saves a bucket config file read from etcd
+// in the local config directory.
+func applyEtcdBucketConfig(name string, encData []byte) error {
+	encConfig := encryptedConfig{}
+	if err := json.Unmarshal(encData, &encConfig); err != nil {
+		return err
+	}
+	data, err := decryptConfig(encConfig, serverConfig.GetCredential())
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return err
+	}
+	return writeLocalConfigFile(name, data)
+}
+
+// removeLocalBucketConfig - removes a bucket config file deleted from
+// etcd from the local config directory, and the directory of the
+// bucket once it is empty.
+func removeLocalBucketConfig(name string) error {
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Fails as long as the bucket has other configs.
+	os.Remove(filepath.Dir(name))
+	return nil
+}
+
+// applyEtcdEvent - applies a change of the config or of a bucket
+// config saved by another server.
+func applyEtcdEvent(client *etcdClient, event etcdWatchEvent) {
+	key := string(event.Kv.Key)
+	if key == etcdConfigKey {
+		// Deleted keys are ignored, the current config is kept.
+		if event.Type == "DELETE" {
+			return
+		}
+		restartRequired, err := applyEtcdConfig(client, event.Kv.Value)
+		if err != nil {
+			errorIf(err, "Unable to load config from etcd.")
+			return
+		}
+		if restartRequired {
+			log.Warn("Config changed in etcd, some settings take effect after a restart.")
+		}
+		return
+	}
+	name, ok := getEtcdBucketConfigFile(key)
+	if !ok {
+		return
+	}
+	if event.Type == "DELETE" {
+		errorIf(removeLocalBucketConfig(name), "Unable to remove bucket config %s.", key)
+		return
+	}
+	errorIf(applyEtcdBucketConfig(name, event.Kv.Value), "Unable to load bucket config %s from etcd.", key)
+}
+
+// syncEtcdConfig - applies the config and bucket configs saved in etcd,
+// local bucket configs missing in etcd are removed. Returns the
+// revision of etcd the configs were read at.
+func syncEtcdConfig(client *etcdClient) (int64, error) {
+	kvs, revision, err := client.GetPrefix(etcdConfigPrefix)
+	if err != nil {
+		return 0, err
+	}
+	// The config is applied first, bucket configs are encrypted with
+	// its credentials.
+	for _, kv := range kvs {
+		if string(kv.Key) == etcdConfigKey {
+			applyEtcdEvent(client, etcdWatchEvent{Kv: kv})
+		}
+	}
+	saved := make(map[string]struct{})
+	for _, kv := range kvs {
+		if name, ok := getEtcdBucketConfigFile(string(kv.Key)); ok {
+			saved[name] = struct{}{}
+			applyEtcdEvent(client, etcdWatchEvent{Kv: kv})
+		}
+	}
+	names, err := listLocalBucketConfigs()
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range names {
+		if _, ok := saved[name]; !ok {
+			errorIf(removeLocalBucketConfig(name), "Unable to remove bucket config %s.", name)
+		}
+	}
+	return revision, nil
+}
+
+// initEtcdConfig - shares the config and the bucket configs through
+// etcd if MINIO_ETCD_ENDPOINTS is set. The configs in etcd take
+// precedence over the local ones, which are saved in etcd if there is
+// no config yet. Changes saved by other servers are applied as soon as
+// etcd reports them, until doneCh is closed.
+func initEtcdConfig(doneCh <-chan struct{}) error {
+	endpoints := os.Getenv("MINIO_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		return nil
+	}
+	client, err := newEtcdClient(endpoints)
+	if err != nil {
+		return err
+	}
+	encData, err := client.Get(etcdConfigKey)
+	switch err {
+	case nil:
+		_, err = applyEtcdConfig(client, encData)
+	case errEtcdKeyNotFound:
+		err = saveEtcdConfig(client, serverConfig)
+	}
+	if err != nil {
+		return err
+	}
+	revision, err := syncEtcdConfig(client)
+	if err != nil {
+		return err
+	}
+	globalEtcdClient = client
+
+	go client.WatchPrefix(etcdConfigPrefix, revision, func() (int64, error) {
+		return syncEtcdConfig(client)
+	}, func(event etcdWatchEvent) {
+		applyEtcdEvent(client, event)
+	}, doneCh)
+	return removeLocalConfig()
+}
@@ -0,0 +1,373 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEtcd - serves the etcd v3 JSON gateway for a single process.
+type fakeEtcd struct {
+	mutex    sync.Mutex
+	revision int64
+	kvs      map[string][]byte
+	watchers []fakeEtcdWatcher
+}
+
+// fakeEtcdWatcher - watch stream of the keys in a range.
+type fakeEtcdWatcher struct {
+	request etcdRangeRequest
+	eventCh chan etcdWatchEvent
+}
+
+// inRange - returns true if key is in the range of request.
+func inRange(request etcdRangeRequest, key string) bool {
+	if len(request.RangeEnd) == 0 {
+		return key == string(request.Key)
+	}
+	return key >= string(request.Key) && key < string(request.RangeEnd)
+}
+
+// notify - sends event to all watchers of its key, must be called with
+// the mutex held.
+func (f *fakeEtcd) notify(event etcdWatchEvent) {
+	for _, watcher := range f.watchers {
+		if inRange(watcher.request, string(event.Kv.Key)) {
+			watcher.eventCh <- event
+		}
+	}
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		request := etcdRangeRequest{}
+		json.NewDecoder(r.Body).Decode(&request)
+		resp := etcdRangeResponse{}
+		resp.Header.Revision = f.revision
+		for key, value := range f.kvs {
+			if inRange(request, key) {
+				resp.Kvs = append(resp.Kvs, etcdKeyValue{Key: []byte(key), Value: value})
+			}
+		}
+		f.mutex.Unlock()
+		json.NewEncoder(w).Encode(resp)
+	case "/v3/kv/put":
+		kv := etcdKeyValue{}
+		json.NewDecoder(r.Body).Decode(&kv)
+		f.revision++
+		f.kvs[string(kv.Key)] = kv.Value
+		f.notify(etcdWatchEvent{Kv: kv})
+		f.mutex.Unlock()
+		w.Write([]byte("{}"))
+	case "/v3/kv/deleterange":
+		request := etcdRangeRequest{}
+		json.NewDecoder(r.Body).Decode(&request)
+		f.revision++
+		for key := range f.kvs {
+			if inRange(request, key) {
+				delete(f.kvs, key)
+				f.notify(etcdWatchEvent{Type: "DELETE", Kv: etcdKeyValue{Key: []byte(key)}})
+			}
+		}
+		f.mutex.Unlock()
+		w.Write([]byte("{}"))
+	case "/v3/watch":
+		request := struct {
+			CreateRequest etcdRangeRequest `json:"create_request"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&request)
+		watcher := fakeEtcdWatcher{request: request.CreateRequest, eventCh: make(chan etcdWatchEvent, 100)}
+		f.watchers = append(f.watchers, watcher)
+		f.mutex.Unlock()
+		w.Write([]byte(`{"result":{"created":true}}` + "\n"))
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case event := <-watcher.eventCh:
+				resp := etcdWatchResponse{}
+				resp.Result.Events = append(resp.Result.Events, event)
+				json.NewEncoder(w).Encode(resp)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		f.mutex.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// Tests values are read, written and watched through the JSON gateway.
+func TestEtcdClient(t *testing.T) {
+	server := httptest.NewServer(&fakeEtcd{kvs: make(map[string][]byte)})
+	defer server.Close()
+
+	if _, err := newEtcdClient(" , "); err != errInvalidArgument {
+		t.Fatalf("Expected %s, but instead found %v", errInvalidArgument, err)
+	}
+	// Unreachable endpoints are skipped.
+	client, err := newEtcdClient("http://127.0.0.1:1," + server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Get("prefix/key"); err != errEtcdKeyNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errEtcdKeyNotFound, err)
+	}
+
+	eventCh := make(chan etcdWatchEvent, 3)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	resync := func() (int64, error) { return 0, nil }
+	go client.WatchPrefix("prefix/", 0, resync, func(event etcdWatchEvent) { eventCh <- event }, doneCh)
+	// Give the watch time to be created.
+	time.Sleep(100 * time.Millisecond)
+
+	if err = client.Put("prefix/key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	// Keys outside the prefix are not watched.
+	if err = client.Put("other", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	value, err := client.Get("prefix/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != "value" {
+		t.Fatalf("Expected value, but instead found %s", value)
+	}
+	kvs, revision, err := client.GetPrefix("prefix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || string(kvs[0].Key) != "prefix/key" || revision != 2 {
+		t.Fatalf("Expected prefix/key at revision 2, but instead found %v at revision %d", kvs, revision)
+	}
+	if err = client.DeletePrefix("prefix/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Get("prefix/key"); err != errEtcdKeyNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errEtcdKeyNotFound, err)
+	}
+
+	for _, expected := range []etcdWatchEvent{
+		{Kv: etcdKeyValue{Key: []byte("prefix/key"), Value: []byte("value")}},
+		{Type: "DELETE", Kv: etcdKeyValue{Key: []byte("prefix/key")}},
+	} {
+		select {
+		case event := <-eventCh:
+			if event.Type != expected.Type || string(event.Kv.Key) != string(expected.Kv.Key) || string(event.Kv.Value) != string(expected.Kv.Value) {
+				t.Fatalf("Expected %v, but instead found %v", expected, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected watch to report the change")
+		}
+	}
+}
+
+// Tests the config is saved in etcd and taken from it.
+func TestInitEtcdConfig(t *testing.T) {
+	server := httptest.NewServer(&fakeEtcd{kvs: make(map[string][]byte)})
+	defer server.Close()
+	// Watches are ended before the server is closed.
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("MINIO_ETCD_ENDPOINTS", server.URL)
+	defer os.Unsetenv("MINIO_ETCD_ENDPOINTS")
+	defer func() { globalEtcdClient = nil }()
+
+	serverConfig.SetRegion("eu-west-1")
+	if err = initEtcdConfig(doneCh); err != nil {
+		t.Fatal(err)
+	}
+	if globalEtcdClient == nil {
+		t.Fatal("Expected config to be shared through etcd")
+	}
+
+	// Config in etcd takes precedence over the local config.
+	serverConfig.SetRegion("us-east-1")
+	if err = initEtcdConfig(doneCh); err != nil {
+		t.Fatal(err)
+	}
+	if serverConfig.GetRegion() != "eu-west-1" {
+		t.Fatalf("Expected region eu-west-1, but instead found %s", serverConfig.GetRegion())
+	}
+}
+
+// waitForBucketLocation - waits until the saved region of bucket is
+// location, as changes in etcd are applied in the background.
+func waitForBucketLocation(t *testing.T, bucket, location string) {
+	for i := 0; ; i++ {
+		region, err := readBucketLocation(bucket)
+		if err == nil && region == location {
+			return
+		}
+		if i == 500 {
+			t.Fatalf("Expected region %s, but instead found %s: %v", location, region, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests bucket configs are shared through etcd.
+func TestEtcdBucketConfigs(t *testing.T) {
+	fake := &fakeEtcd{kvs: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bucket configs of the first server are saved in etcd.
+	if err = writeBucketLocation("bucket", "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("MINIO_ETCD_ENDPOINTS", server.URL)
+	defer os.Unsetenv("MINIO_ETCD_ENDPOINTS")
+	defer func() { globalEtcdClient = nil }()
+	if err = initEtcdConfig(doneCh); err != nil {
+		t.Fatal(err)
+	}
+	client := globalEtcdClient
+	key := etcdBucketsConfigPrefix + "bucket/" + bucketLocationConfigFile
+	value, err := client.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bucket configs are encrypted like the config.
+	if strings.Contains(string(value), "eu-west-1") {
+		t.Fatalf("Expected bucket config to be encrypted, but instead found %s", value)
+	}
+
+	// Bucket configs saved by other servers are applied.
+	location, err := xml.Marshal(createBucketLocationConfiguration{Location: "ap-south-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encLocation, err := encryptEtcdValue(location, serverConfig.GetCredential())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey := etcdBucketsConfigPrefix + "other/" + bucketLocationConfigFile
+	if err = client.Put(otherKey, encLocation); err != nil {
+		t.Fatal(err)
+	}
+	waitForBucketLocation(t, "other", "ap-south-1")
+	if err = client.Delete(otherKey); err != nil {
+		t.Fatal(err)
+	}
+	waitForBucketLocation(t, "other", serverConfig.GetRegion())
+
+	// Bucket configs are removed from etcd.
+	if err = removeBucketConfigs("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Get(key); err != errEtcdKeyNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errEtcdKeyNotFound, err)
+	}
+
+	// Local bucket configs missing in etcd are removed on resync.
+	if err = createBucketConfigPath("stale"); err != nil {
+		t.Fatal(err)
+	}
+	bucketConfigPath, err := getBucketConfigPath("stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writeLocalConfigFile(filepath.Join(bucketConfigPath, bucketLocationConfigFile), location); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = syncEtcdConfig(client); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(bucketConfigPath); !os.IsNotExist(err) {
+		t.Fatalf("Expected stale bucket config to be removed, but instead found %v", err)
+	}
+	// Wait for the watch to apply all changes before the next test
+	// replaces the config.
+	if err = client.Put(otherKey, encLocation); err != nil {
+		t.Fatal(err)
+	}
+	waitForBucketLocation(t, "other", "ap-south-1")
+}
+
+// Tests etcd keys are only mapped to files of a bucket config directory.
+func TestGetEtcdBucketConfigFile(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	testCases := []struct {
+		key string
+		ok  bool
+	}{
+		{etcdBucketsConfigPrefix + "bucket/location.xml", true},
+		{etcdBucketsConfigPrefix + "bucket", false},
+		{etcdBucketsConfigPrefix + "bucket/", false},
+		{etcdBucketsConfigPrefix + "bucket/..", false},
+		{etcdBucketsConfigPrefix + "../location.xml", false},
+		{etcdBucketsConfigPrefix + "bucket/dir/location.xml", false},
+	}
+	for i, testCase := range testCases {
+		name, ok := getEtcdBucketConfigFile(testCase.key)
+		if ok != testCase.ok {
+			t.Fatalf("Test %d: Expected %v, but instead found %v", i+1, testCase.ok, ok)
+		}
+		if !ok {
+			continue
+		}
+		// Keys and files map to each other.
+		if key, ok := getEtcdBucketConfigKey(name); !ok || key != testCase.key {
+			t.Fatalf("Test %d: Expected %s, but instead found %s", i+1, testCase.key, key)
+		}
+	}
+}
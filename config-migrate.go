@@ -32,6 +32,10 @@ func migrateConfig() {
 	migrateV2ToV3()
 	// Migrate version '3' to '4'.
 	migrateV3ToV4()
+	// Migrate version '4' to '5'.
+	migrateV4ToV5()
+	// Migrate version '5' to '6'.
+	migrateV5ToV6()
 }
 
 // Version '1' is not supported anymore and deprecated, safe to delete.
@@ -77,10 +81,8 @@ func migrateV2ToV3() {
 		// Region needs to be set for AWS Signature V4.
 		srvConfig.Region = "us-east-1"
 	}
-	srvConfig.Logger.Console = consoleLogger{
-		Enable: true,
-		Level:  "fatal",
-	}
+	srvConfig.Logger.Console.Enable = true
+	srvConfig.Logger.Console.Level = "fatal"
 	flogger := fileLogger{}
 	flogger.Level = "error"
 	if cv2.FileLogger.Filename != "" {
@@ -124,15 +126,18 @@ func migrateV3ToV4() {
 	}
 
 	// Save only the new fields, ignore the rest.
-	srvConfig := &serverConfigV4{}
-	srvConfig.Version = globalMinioConfigVersion
+	srvConfig := &configV4{}
+	srvConfig.Version = "4"
 	srvConfig.Credential = cv3.Credential
 	srvConfig.Region = cv3.Region
 	if srvConfig.Region == "" {
 		// Region needs to be set for AWS Signature Version 4.
 		srvConfig.Region = "us-east-1"
 	}
-	srvConfig.Logger.Console = cv3.Logger.Console
+	srvConfig.Logger.Console = consoleLogger{
+		Enable: cv3.Logger.Console.Enable,
+		Level:  cv3.Logger.Console.Level,
+	}
 	srvConfig.Logger.File = cv3.Logger.File
 	srvConfig.Logger.Syslog = cv3.Logger.Syslog
 
@@ -146,3 +151,74 @@ func migrateV3ToV4() {
 
 	console.Println("Migration from version ‘" + cv3.Version + "’ to ‘" + srvConfig.Version + "’ completed successfully.")
 }
+
+// Version '4' to '5' migrates config, adds a new section for the key
+// management service used for server side encryption.
+func migrateV4ToV5() {
+	cv4, err := loadConfigV4()
+	if err != nil && os.IsNotExist(err) {
+		return
+	}
+	fatalIf(err, "Unable to load config version ‘4’.")
+	if cv4.Version != "4" {
+		return
+	}
+
+	// Copy over fields from V4 into V5 config struct, key management
+	// service is disabled by default.
+	srvConfig := &configV5{}
+	srvConfig.Version = "5"
+	srvConfig.Credential = cv4.Credential
+	srvConfig.Region = cv4.Region
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = "us-east-1"
+	}
+	srvConfig.Logger = cv4.Logger
+
+	qc, err := quick.New(srvConfig)
+	fatalIf(err, "Unable to initialize the quick config.")
+	configFile, err := getConfigFile()
+	fatalIf(err, "Unable to get config file.")
+
+	err = qc.Save(configFile)
+	fatalIf(err, "Failed to migrate config from ‘%s’ to ‘%s’.", cv4.Version, srvConfig.Version)
+
+	console.Println("Migration from version ‘" + cv4.Version + "’ to ‘" + srvConfig.Version + "’ completed successfully.")
+}
+
+// Version '5' to '6' migration adds audit logging configuration.
+func migrateV5ToV6() {
+	cv5, err := loadConfigV5()
+	if err != nil && os.IsNotExist(err) {
+		return
+	}
+	fatalIf(err, "Unable to load config version ‘5’.")
+	if cv5.Version != "5" {
+		return
+	}
+
+	// Copy over fields from V5 into V6 config struct, audit logging
+	// and compression are disabled by default.
+	srvConfig := &serverConfigV6{}
+	srvConfig.Version = globalMinioConfigVersion
+	srvConfig.Credential = cv5.Credential
+	srvConfig.Region = cv5.Region
+	if srvConfig.Region == "" {
+		// Region needs to be set for AWS Signature Version 4.
+		srvConfig.Region = "us-east-1"
+	}
+	srvConfig.Logger = cv5.Logger
+	srvConfig.KMS = cv5.KMS
+	srvConfig.Compression = newCompressionConfig()
+
+	qc, err := quick.New(srvConfig)
+	fatalIf(err, "Unable to initialize the quick config.")
+	configFile, err := getConfigFile()
+	fatalIf(err, "Unable to get config file.")
+
+	err = qc.Save(configFile)
+	fatalIf(err, "Failed to migrate config from ‘%s’ to ‘%s’.", cv5.Version, srvConfig.Version)
+
+	console.Println("Migration from version ‘" + cv5.Version + "’ to ‘" + srvConfig.Version + "’ completed successfully.")
+}
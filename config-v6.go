@@ -0,0 +1,565 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/minio/minio/pkg/quick"
+)
+
+// serverConfigV6 server configuration version '6'.
+type serverConfigV6 struct {
+	Version string `json:"version"`
+
+	// S3 API configuration.
+	Credential credential `json:"credential"`
+	Region     string     `json:"region"`
+
+	// Additional error logging configuration.
+	Logger logger `json:"logger"`
+
+	// Key management service configuration.
+	KMS kmsConfig `json:"kms"`
+
+	// Audit logging configuration.
+	Audit auditConfig `json:"audit"`
+
+	// Object compression configuration.
+	Compression compressionConfig `json:"compression"`
+
+	// Disk cache configuration.
+	Cache cacheConfig `json:"cache"`
+
+	// Bucket federation configuration.
+	Federation federationConfig `json:"federation"`
+
+	// API request limits.
+	API apiConfig `json:"api"`
+
+	// Deadlines of disk operations.
+	Storage storageConfig `json:"storage"`
+
+	// Size limits of uploads.
+	Limits limitsConfig `json:"limits"`
+
+	// Validation of object names, "strict", "lenient" or empty for
+	// the default.
+	ObjectNames string `json:"objectNames"`
+
+	// Validation of bucket names, "legacy" or empty for DNS compliant
+	// names.
+	BucketNames string `json:"bucketNames"`
+
+	// Hadoop S3A compatibility mode.
+	S3A bool `json:"s3a"`
+
+	// Read Write mutex.
+	rwMutex *sync.RWMutex
+}
+
+// initConfig - initialize server config. config version (called only once).
+func initConfig() error {
+	if !isConfigFileExists() {
+		srvCfg := &serverConfigV6{}
+		srvCfg.Version = globalMinioConfigVersion
+		srvCfg.Region = "us-east-1"
+		srvCfg.Credential = mustGenAccessKeys()
+		// Enable console logger by default on a fresh run.
+		srvCfg.Logger.Console = consoleLogger{
+			Enable: true,
+			Level:  "fatal",
+		}
+		srvCfg.Compression = newCompressionConfig()
+		srvCfg.Cache = newCacheConfig()
+		srvCfg.Storage = newStorageConfig()
+		srvCfg.Limits = newLimitsConfig()
+		srvCfg.rwMutex = &sync.RWMutex{}
+		// Create config path.
+		err := createConfigPath()
+		if err != nil {
+			return err
+		}
+
+		// Create certs path.
+		err = createCertsPath()
+		if err != nil {
+			return err
+		}
+
+		// Save the new config globally.
+		serverConfig = srvCfg
+
+		// Save config into file.
+		return serverConfig.Save()
+	}
+	configFile, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(configFile); err != nil {
+		return err
+	}
+	srvCfg := &serverConfigV6{}
+	srvCfg.Version = globalMinioConfigVersion
+	srvCfg.rwMutex = &sync.RWMutex{}
+	qc, err := quick.New(srvCfg)
+	if err != nil {
+		return err
+	}
+	if err := qc.Load(configFile); err != nil {
+		return err
+	}
+	// Save the loaded config globally.
+	serverConfig = srvCfg
+	// Set the version properly after the unmarshalled json is loaded.
+	serverConfig.Version = globalMinioConfigVersion
+	return nil
+}
+
+// serverConfig server config.
+var serverConfig *serverConfigV6
+
+// reload - reloads the config from the config file, picks up changes
+// saved by other server processes sharing the config file.
+func (s *serverConfigV6) reload() error {
+	configFile, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	srvCfg := &serverConfigV6{}
+	srvCfg.Version = globalMinioConfigVersion
+	srvCfg.rwMutex = &sync.RWMutex{}
+	qc, err := quick.New(srvCfg)
+	if err != nil {
+		return err
+	}
+	if err = qc.Load(configFile); err != nil {
+		return err
+	}
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	rwMutex := s.rwMutex
+	*s = *srvCfg
+	s.rwMutex = rwMutex
+	return nil
+}
+
+// GetVersion get current config version.
+func (s serverConfigV6) GetVersion() string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Version
+}
+
+/// Logger related.
+
+// SetFileLogger set new file logger.
+func (s *serverConfigV6) SetFileLogger(flogger fileLogger) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Logger.File = flogger
+}
+
+// GetFileLogger get current file logger.
+func (s serverConfigV6) GetFileLogger() fileLogger {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Logger.File
+}
+
+// SetConsoleLogger set new console logger.
+func (s *serverConfigV6) SetConsoleLogger(clogger consoleLogger) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Logger.Console = clogger
+}
+
+// GetConsoleLogger get current console logger.
+func (s serverConfigV6) GetConsoleLogger() consoleLogger {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Logger.Console
+}
+
+// SetSyslogLogger set new syslog logger.
+func (s *serverConfigV6) SetSyslogLogger(slogger syslogLogger) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Logger.Syslog = slogger
+}
+
+// GetSyslogLogger get current syslog logger.
+func (s *serverConfigV6) GetSyslogLogger() syslogLogger {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Logger.Syslog
+}
+
+// SetHTTPLogger set new http logger.
+func (s *serverConfigV6) SetHTTPLogger(hlogger httpLogger) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Logger.HTTP = hlogger
+}
+
+// GetHTTPLogger get current http logger.
+func (s serverConfigV6) GetHTTPLogger() httpLogger {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Logger.HTTP
+}
+
+/// KMS related.
+
+// SetKMS set new key management service config.
+func (s *serverConfigV6) SetKMS(kms kmsConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.KMS = kms
+}
+
+// GetKMS get current key management service config.
+func (s serverConfigV6) GetKMS() kmsConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.KMS
+}
+
+/// Audit related.
+
+// SetAudit set new audit logging config.
+func (s *serverConfigV6) SetAudit(audit auditConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Audit = audit
+}
+
+// GetAudit get current audit logging config.
+func (s serverConfigV6) GetAudit() auditConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Audit
+}
+
+/// Compression related.
+
+// SetCompression set new object compression config.
+func (s *serverConfigV6) SetCompression(compression compressionConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Compression = compression
+}
+
+// GetCompression get current object compression config.
+func (s serverConfigV6) GetCompression() compressionConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Compression
+}
+
+/// Cache related.
+
+// SetCache set new disk cache config.
+func (s *serverConfigV6) SetCache(cache cacheConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Cache = cache
+}
+
+// GetCache get current disk cache config.
+func (s serverConfigV6) GetCache() cacheConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Cache
+}
+
+/// Federation related.
+
+// SetFederation set new bucket federation config.
+func (s *serverConfigV6) SetFederation(federation federationConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Federation = federation
+}
+
+// GetFederation get current bucket federation config.
+func (s serverConfigV6) GetFederation() federationConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Federation
+}
+
+/// API related.
+
+// SetAPI set new API request limits.
+func (s *serverConfigV6) SetAPI(api apiConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.API = api
+}
+
+// GetAPI get current API request limits.
+func (s serverConfigV6) GetAPI() apiConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.API
+}
+
+/// Storage related.
+
+// SetStorage set new deadlines of disk operations.
+func (s *serverConfigV6) SetStorage(storage storageConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Storage = storage
+}
+
+// GetStorage get current deadlines of disk operations.
+func (s serverConfigV6) GetStorage() storageConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Storage
+}
+
+/// Limits related.
+
+// SetLimits set new size limits of uploads.
+func (s *serverConfigV6) SetLimits(limits limitsConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Limits = limits
+}
+
+// GetLimits get current size limits of uploads.
+func (s serverConfigV6) GetLimits() limitsConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Limits
+}
+
+/// Object names related.
+
+// SetObjectNames set new object name validation mode.
+func (s *serverConfigV6) SetObjectNames(mode string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.ObjectNames = mode
+}
+
+// GetObjectNames get current object name validation mode.
+func (s serverConfigV6) GetObjectNames() string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.ObjectNames
+}
+
+/// Bucket names related.
+
+// SetBucketNames set new bucket name validation mode.
+func (s *serverConfigV6) SetBucketNames(mode string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.BucketNames = mode
+}
+
+// GetBucketNames get current bucket name validation mode.
+func (s serverConfigV6) GetBucketNames() string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.BucketNames
+}
+
+/// S3A compatibility related.
+
+// SetS3A set if the S3A compatibility mode is on.
+func (s *serverConfigV6) SetS3A(s3a bool) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.S3A = s3a
+}
+
+// GetS3A get if the S3A compatibility mode is on.
+func (s serverConfigV6) GetS3A() bool {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.S3A
+}
+
+// SetRegion set new region.
+func (s *serverConfigV6) SetRegion(region string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Region = region
+}
+
+// GetRegion get current region.
+func (s serverConfigV6) GetRegion() string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Region
+}
+
+// SetCredentials set new credentials.
+func (s *serverConfigV6) SetCredential(creds credential) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Credential = creds
+}
+
+// GetCredentials get current credentials.
+func (s serverConfigV6) GetCredential() credential {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Credential
+}
+
+// validateConfig - validates a config provided at runtime.
+func validateConfig(config *serverConfigV6) error {
+	if config.Version != globalMinioConfigVersion {
+		return fmt.Errorf("Config version ‘%s’ does not match server config version ‘%s’", config.Version, globalMinioConfigVersion)
+	}
+	if !isValidAccessKey.MatchString(config.Credential.AccessKeyID) {
+		return errors.New("Invalid access key")
+	}
+	if !isValidSecretKey.MatchString(config.Credential.SecretAccessKey) {
+		return errors.New("Invalid secret key")
+	}
+	if config.Region == "" {
+		return errors.New("Region is missing")
+	}
+	if config.Logger.Console.Enable {
+		if _, err := logrus.ParseLevel(config.Logger.Console.Level); err != nil {
+			return err
+		}
+	}
+	if config.Logger.File.Enable {
+		if config.Logger.File.Filename == "" {
+			return errors.New("File logger file name is missing")
+		}
+		if _, err := logrus.ParseLevel(config.Logger.File.Level); err != nil {
+			return err
+		}
+	}
+	if config.Logger.Syslog.Enable && config.Logger.Syslog.Addr == "" {
+		return errors.New("Syslog logger address is missing")
+	}
+	if config.Logger.HTTP.Enable {
+		if config.Logger.HTTP.Endpoint == "" {
+			return errors.New("HTTP logger endpoint is missing")
+		}
+		if _, err := logrus.ParseLevel(config.Logger.HTTP.Level); err != nil {
+			return err
+		}
+	}
+	if config.KMS.Vault.Enable {
+		if err := validateVaultConfig(config.KMS.Vault); err != nil {
+			return err
+		}
+	}
+	if config.Audit.Webhook.Enable && config.Audit.Webhook.Endpoint == "" {
+		return errors.New("Audit webhook endpoint is missing")
+	}
+	if len(config.Cache.Drives) > 0 && (config.Cache.MaxUse < 0 || config.Cache.MaxUse > 100) {
+		return errors.New("Cache max use must be a percentage between 0 and 100")
+	}
+	if config.API.RequestsMax < 0 || config.API.RequestsPerIP < 0 || config.API.QueueMax < 0 {
+		return errors.New("API request limits must not be negative")
+	}
+	if config.Federation.Enable {
+		if err := validateFederationConfig(config.Federation); err != nil {
+			return err
+		}
+	}
+	if config.Audit.File.Enable && config.Audit.File.Filename == "" {
+		return errors.New("Audit file name is missing")
+	}
+	return nil
+}
+
+// JSON - returns the JSON encoded config.
+func (s *serverConfigV6) JSON() ([]byte, error) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return json.MarshalIndent(s, "", "\t")
+}
+
+// Update - applies all settings of a validated config. Credential,
+// region and compression take effect immediately, returns true if other settings
+// changed which only take effect after a restart.
+func (s *serverConfigV6) Update(config *serverConfigV6) (restartRequired bool) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	restartRequired = !reflect.DeepEqual(s.Logger, config.Logger) || !reflect.DeepEqual(s.KMS, config.KMS) ||
+		!reflect.DeepEqual(s.Audit, config.Audit) || !reflect.DeepEqual(s.Cache, config.Cache) ||
+		!reflect.DeepEqual(s.Federation, config.Federation) || !reflect.DeepEqual(s.API, config.API)
+	s.Credential = config.Credential
+	s.Region = config.Region
+	s.Logger = config.Logger
+	s.KMS = config.KMS
+	s.Audit = config.Audit
+	s.Compression = config.Compression
+	s.Cache = config.Cache
+	s.Federation = config.Federation
+	s.API = config.API
+	return restartRequired
+}
+
+// Save config, the config is saved encrypted in etcd if configured or
+// in the backend once the object layer is initialized.
+func (s serverConfigV6) Save() error {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	if objAPI := globalBackendConfigLayer; objAPI != nil {
+		if err := saveBackendConfig(objAPI, &s); err != nil {
+			return err
+		}
+		if isBackendOnlyConfig() {
+			return nil
+		}
+	}
+	if client := globalEtcdClient; client != nil {
+		if err := saveEtcdConfig(client, &s); err != nil {
+			return err
+		}
+		if isBackendOnlyConfig() {
+			return nil
+		}
+	}
+
+	// get config file.
+	configFile, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+
+	// initialize quick.
+	qc, err := quick.New(&s)
+	if err != nil {
+		return err
+	}
+
+	// Save config file.
+	return qc.Save(configFile)
+}
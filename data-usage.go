@@ -0,0 +1,293 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// Data usage snapshot and crawler state stored in minioMetaBucket.
+	dataUsageFile      = "data-usage.json"
+	dataUsageStateFile = "data-usage-state.json"
+
+	// Number of objects listed per crawler step, progress is saved
+	// after every step.
+	dataUsageListSize = 1000
+)
+
+var (
+	// Time after startup before the first crawl.
+	dataUsageCrawlStartDelay = 5 * time.Minute
+
+	// Time between two crawls.
+	dataUsageCrawlInterval = time.Hour
+
+	// Pause per crawled object, limits the load the crawler puts on
+	// the disks.
+	dataUsageCrawlDelay = time.Millisecond
+)
+
+// prefixUsageInfo - object count and total size of objects.
+type prefixUsageInfo struct {
+	ObjectsCount uint64 `json:"objectsCount"`
+	Size         uint64 `json:"size"`
+}
+
+// bucketUsageInfo - usage of a bucket and each of its top level
+// prefixes.
+type bucketUsageInfo struct {
+	prefixUsageInfo
+	Prefixes map[string]prefixUsageInfo `json:"prefixes,omitempty"`
+}
+
+// dataUsageInfo - usage of all buckets computed by the crawler.
+type dataUsageInfo struct {
+	// Time the crawl finished, zero if no crawl finished yet.
+	LastUpdate time.Time `json:"lastUpdate"`
+
+	prefixUsageInfo
+	Buckets map[string]bucketUsageInfo `json:"buckets"`
+}
+
+// addBucket - accounts a bucket without objects.
+func (d *dataUsageInfo) addBucket(bucket string) {
+	if d.Buckets == nil {
+		d.Buckets = make(map[string]bucketUsageInfo)
+	}
+	d.Buckets[bucket] = d.Buckets[bucket]
+}
+
+// add - accounts an object of size in bucket.
+func (d *dataUsageInfo) add(bucket, object string, size int64) {
+	d.addBucket(bucket)
+	bucketUsage := d.Buckets[bucket]
+	if index := strings.Index(object, slashSeparator); index != -1 {
+		if bucketUsage.Prefixes == nil {
+			bucketUsage.Prefixes = make(map[string]prefixUsageInfo)
+		}
+		prefix := object[:index+1]
+		prefixUsage := bucketUsage.Prefixes[prefix]
+		prefixUsage.ObjectsCount++
+		prefixUsage.Size += uint64(size)
+		bucketUsage.Prefixes[prefix] = prefixUsage
+	}
+	bucketUsage.ObjectsCount++
+	bucketUsage.Size += uint64(size)
+	d.Buckets[bucket] = bucketUsage
+	d.ObjectsCount++
+	d.Size += uint64(size)
+}
+
+// dataUsageCrawlState - progress of an unfinished crawl, allows to
+// resume crawling after a restart.
+type dataUsageCrawlState struct {
+	// Usage of all objects crawled so far.
+	Usage dataUsageInfo `json:"usage"`
+	// Bucket and marker of the next listing.
+	Bucket string `json:"bucket"`
+	Marker string `json:"marker"`
+}
+
+// getObjectLayerDisks - returns the disks backing an object layer.
+func getObjectLayerDisks(objAPI ObjectLayer) []StorageAPI {
+	switch obj := objAPI.(type) {
+	case fsObjects:
+		return []StorageAPI{obj.storage}
+	case xlObjects:
+		return obj.storageDisks
+	case *xlSets:
+		var disks []StorageAPI
+		for _, set := range obj.sets {
+			disks = append(disks, set.storageDisks...)
+		}
+		return disks
+	}
+	return nil
+}
+
+// saveMetaFile - writes data to path in minioMetaBucket on all disks,
+// succeeds if at least one disk was written.
+func saveMetaFile(objAPI ObjectLayer, path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	err := errDiskNotFound
+	saved := false
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		// Purge any existing temporary file, okay to ignore errors here.
+		disk.DeleteFile(minioMetaBucket, tmpPath)
+		if err = disk.AppendFile(context.Background(), minioMetaBucket, tmpPath, data); err != nil {
+			continue
+		}
+		if err = disk.RenameFile(minioMetaBucket, tmpPath, minioMetaBucket, path); err != nil {
+			continue
+		}
+		saved = true
+	}
+	if saved {
+		return nil
+	}
+	return err
+}
+
+// loadMetaFile - reads path in minioMetaBucket from the first disk
+// which has it.
+func loadMetaFile(objAPI ObjectLayer, path string) ([]byte, error) {
+	err := errFileNotFound
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		var data []byte
+		if data, err = readAll(disk, minioMetaBucket, path); err == nil {
+			return data, nil
+		}
+	}
+	return nil, err
+}
+
+// loadDataUsage - returns the usage computed by the last finished
+// crawl, usage is empty if no crawl finished yet.
+func loadDataUsage(objAPI ObjectLayer) (dataUsageInfo, error) {
+	var usage dataUsageInfo
+	data, err := loadMetaFile(objAPI, dataUsageFile)
+	if err == errFileNotFound {
+		return usage, nil
+	}
+	if err != nil {
+		return usage, err
+	}
+	err = json.Unmarshal(data, &usage)
+	return usage, err
+}
+
+// saveJSONMetaFile - saves v JSON encoded to path in minioMetaBucket.
+func saveJSONMetaFile(objAPI ObjectLayer, path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return saveMetaFile(objAPI, path, data)
+}
+
+var errDataUsageCrawlStopped = errors.New("Data usage crawl stopped")
+
+var errDataUsageCrawlPaused = errors.New("Data usage crawl paused for maintenance")
+
+// crawlDataUsage - lists all objects of all buckets, resuming a
+// previously unfinished crawl. Progress is saved after every listing
+// so the crawl can be resumed if the server stops. Returns
+// errDataUsageCrawlStopped if doneCh is closed before the crawl
+// finished, errDataUsageCrawlPaused if the server entered maintenance
+// mode.
+func crawlDataUsage(objAPI ObjectLayer, doneCh <-chan struct{}) (dataUsageInfo, error) {
+	var state dataUsageCrawlState
+	if data, err := loadMetaFile(objAPI, dataUsageStateFile); err == nil {
+		// Start over if the state is unreadable.
+		if err = json.Unmarshal(data, &state); err != nil {
+			state = dataUsageCrawlState{}
+		}
+	}
+
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return dataUsageInfo{}, err
+	}
+	for _, bucket := range buckets {
+		// Buckets are listed in order, skip the ones already crawled.
+		if bucket.Name < state.Bucket {
+			continue
+		}
+		if bucket.Name != state.Bucket {
+			state.Bucket, state.Marker = bucket.Name, ""
+			// Empty buckets are reported too.
+			state.Usage.addBucket(bucket.Name)
+		}
+		for {
+			result, err := objAPI.ListObjects(bucket.Name, "", state.Marker, "", dataUsageListSize)
+			if err != nil {
+				// Bucket may have been deleted meanwhile.
+				if _, ok := err.(BucketNotFound); ok {
+					break
+				}
+				return dataUsageInfo{}, err
+			}
+			for _, object := range result.Objects {
+				state.Usage.add(bucket.Name, object.Name, object.Size)
+				state.Marker = object.Name
+			}
+			errorIf(saveJSONMetaFile(objAPI, dataUsageStateFile, state), "Unable to save data usage crawler state.")
+			// Resumed from the saved state once maintenance is over.
+			if globalServerMode.IsPaused() {
+				return dataUsageInfo{}, errDataUsageCrawlPaused
+			}
+
+			select {
+			case <-doneCh:
+				return dataUsageInfo{}, errDataUsageCrawlStopped
+			case <-time.After(time.Duration(len(result.Objects)) * dataUsageCrawlDelay):
+			}
+			if !result.IsTruncated {
+				break
+			}
+		}
+	}
+
+	usage := state.Usage
+	usage.LastUpdate = time.Now().UTC()
+	if usage.Buckets == nil {
+		usage.Buckets = make(map[string]bucketUsageInfo)
+	}
+	if err = saveJSONMetaFile(objAPI, dataUsageFile, usage); err != nil {
+		return dataUsageInfo{}, err
+	}
+	// Next crawl starts over.
+	errorIf(saveJSONMetaFile(objAPI, dataUsageStateFile, dataUsageCrawlState{}), "Unable to reset data usage crawler state.")
+	return usage, nil
+}
+
+// runDataUsageCrawler - crawls data usage periodically until doneCh is
+// closed.
+func runDataUsageCrawler(objAPI ObjectLayer, doneCh <-chan struct{}) {
+	select {
+	case <-time.After(dataUsageCrawlStartDelay):
+	case <-doneCh:
+		return
+	}
+	for {
+		// No crawls in maintenance mode.
+		if !globalServerMode.IsPaused() {
+			_, err := crawlDataUsage(objAPI, doneCh)
+			if err == errDataUsageCrawlStopped {
+				return
+			}
+			if err != errDataUsageCrawlPaused {
+				errorIf(err, "Unable to crawl data usage.")
+			}
+		}
+		select {
+		case <-time.After(dataUsageCrawlInterval):
+		case <-doneCh:
+			return
+		}
+	}
+}
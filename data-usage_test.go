@@ -0,0 +1,225 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Wrapper for calling data usage tests for both XL multiple disks and single node setup.
+func TestCrawlDataUsage(t *testing.T) {
+	ExecObjectLayerTest(t, testCrawlDataUsage)
+}
+
+// Tests data usage is computed per bucket and top level prefix and
+// unfinished crawls are resumed.
+func testCrawlDataUsage(obj ObjectLayer, instanceType string, t *testing.T) {
+	objects := map[string][]string{
+		"bucket-a": {"a", "dir/b", "dir/sub/c", "other/d"},
+		"bucket-b": {"e"},
+		"bucket-c": nil,
+	}
+	for bucket, names := range objects {
+		if err := obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		for _, name := range names {
+			data := []byte(strings.Repeat("x", len(name)))
+			if _, err := obj.PutObject(context.Background(), bucket, name, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+				t.Fatalf("%s: %s", instanceType, err)
+			}
+		}
+	}
+
+	usage, err := loadDataUsage(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !usage.LastUpdate.IsZero() {
+		t.Fatalf("%s: Expected no data usage before the first crawl", instanceType)
+	}
+
+	if _, err = crawlDataUsage(obj, nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage, err = loadDataUsage(obj); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage.LastUpdate.IsZero() || usage.ObjectsCount != 5 || usage.Size != 1+5+9+7+1 || len(usage.Buckets) != 3 {
+		t.Fatalf("%s: Unexpected data usage %#v", instanceType, usage)
+	}
+
+	testCases := []struct {
+		bucket string
+		prefix string
+		count  uint64
+		size   uint64
+	}{
+		// Test case - 1.
+		{"bucket-a", "", 4, 1 + 5 + 9 + 7},
+		// Test case - 2.
+		{"bucket-a", "dir/", 2, 5 + 9},
+		// Test case - 3.
+		{"bucket-a", "other/", 1, 7},
+		// Test case - 4.
+		{"bucket-b", "", 1, 1},
+		// Test case - 5.
+		{"bucket-c", "", 0, 0},
+	}
+	for i, testCase := range testCases {
+		prefixUsage := usage.Buckets[testCase.bucket].prefixUsageInfo
+		if testCase.prefix != "" {
+			prefixUsage = usage.Buckets[testCase.bucket].Prefixes[testCase.prefix]
+		}
+		if prefixUsage.ObjectsCount != testCase.count || prefixUsage.Size != testCase.size {
+			t.Errorf("%s: Test %d: Expected %d objects of %d bytes, but instead found %d objects of %d bytes", instanceType, i+1,
+				testCase.count, testCase.size, prefixUsage.ObjectsCount, prefixUsage.Size)
+		}
+	}
+
+	// Resume a crawl which stopped after the first object of bucket-a.
+	state := dataUsageCrawlState{Bucket: "bucket-a", Marker: "a"}
+	state.Usage.add("bucket-a", "a", 100)
+	if err = saveJSONMetaFile(obj, dataUsageStateFile, state); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage, err = crawlDataUsage(obj, nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if usage.ObjectsCount != 5 || usage.Size != 100+5+9+7+1 {
+		t.Fatalf("%s: Expected crawl to be resumed, but instead found %#v", instanceType, usage)
+	}
+}
+
+// Tests data usage is served through the admin API.
+func TestAdminDataUsageInfo(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = crawlDataUsage(objAPI, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := client.DataUsageInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.LastUpdate.IsZero() || len(usage.Buckets) != 1 {
+		t.Fatalf("Unexpected data usage %#v", usage)
+	}
+}
+
+// Tests bucket usage is added to ListBuckets on request.
+func TestListBucketsUsage(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range []string{"bucket-a", "bucket-b"} {
+		if err = objAPI.MakeBucket(bucket); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = objAPI.PutObject(context.Background(), "bucket-a", "object", 5, bytes.NewReader([]byte("hello")), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	listBuckets := func(query string) ListBucketsResponse {
+		request, err := newTestRequest("GET", testServer.Server.URL+"/"+query, 0, nil, testServer.AccessKey, testServer.SecretKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := (&http.Client{}).Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("Expected %d, got %d", http.StatusOK, response.StatusCode)
+		}
+		var result ListBucketsResponse
+		if err = xml.NewDecoder(response.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// No usage before the first crawl.
+	result := listBuckets("?usage=true")
+	for _, bucket := range result.Buckets.Buckets {
+		if bucket.Usage != nil {
+			t.Fatalf("Unexpected usage of %s before the first crawl", bucket.Name)
+		}
+	}
+
+	if _, err = crawlDataUsage(objAPI, nil); err != nil {
+		t.Fatal(err)
+	}
+	// Buckets created after the crawl have no usage.
+	if err = objAPI.MakeBucket("bucket-c"); err != nil {
+		t.Fatal(err)
+	}
+
+	result = listBuckets("?usage=true")
+	if len(result.Buckets.Buckets) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(result.Buckets.Buckets))
+	}
+	expected := []*BucketUsage{{ObjectsCount: 1, Size: 5}, {}, nil}
+	for i, bucket := range result.Buckets.Buckets {
+		if expected[i] == nil {
+			if bucket.Usage != nil {
+				t.Errorf("Unexpected usage of %s", bucket.Name)
+			}
+			continue
+		}
+		if bucket.Usage == nil || bucket.Usage.LastUpdate == "" {
+			t.Fatalf("Expected usage of %s", bucket.Name)
+		}
+		if bucket.Usage.ObjectsCount != expected[i].ObjectsCount || bucket.Usage.Size != expected[i].Size {
+			t.Errorf("Expected usage %#v of %s, got %#v", expected[i], bucket.Name, bucket.Usage)
+		}
+	}
+
+	// Usage is opt-in.
+	result = listBuckets("")
+	for _, bucket := range result.Buckets.Buckets {
+		if bucket.Usage != nil {
+			t.Fatalf("Unexpected usage of %s", bucket.Name)
+		}
+	}
+}
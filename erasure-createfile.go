@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"hash"
 	"io"
@@ -27,13 +28,15 @@ import (
 
 // erasureCreateFile - writes an entire stream by erasure coding to
 // all the disks, writes also calculate individual block's checksum
-// for future bit-rot protection.
-func erasureCreateFile(disks []StorageAPI, volume string, path string, partName string, data io.Reader, eInfos []erasureInfo, writeQuorum int) (newEInfos []erasureInfo, size int64, err error) {
+// for future bit-rot protection. Writing stops with ctx.Err() once ctx
+// is done. Disks failing a write are set to nil in disks.
+func erasureCreateFile(ctx context.Context, disks []StorageAPI, volume string, path string, partName string, data io.Reader, eInfos []erasureInfo, writeQuorum int) (newEInfos []erasureInfo, size int64, err error) {
 	// Just pick one eInfo.
 	eInfo := pickValidErasureInfo(eInfos)
 
 	// Allocated blockSized buffer for reading.
-	buf := make([]byte, eInfo.BlockSize)
+	buf := globalBufferPools.Get(int(eInfo.BlockSize))
+	defer globalBufferPools.Put(buf)
 	hashWriters := newHashWriters(len(disks))
 
 	// Read until io.EOF, erasure codes data and writes to all disks.
@@ -47,7 +50,7 @@ func erasureCreateFile(disks []StorageAPI, volume string, path string, partName
 			// data. Will create a 0byte file instead.
 			if size == 0 {
 				blocks = make([][]byte, len(disks))
-				err = appendFile(disks, volume, path, blocks, eInfo.Distribution, hashWriters, writeQuorum)
+				err = appendFile(ctx, disks, volume, path, blocks, eInfo.Distribution, hashWriters, writeQuorum)
 				if err != nil {
 					return nil, 0, err
 				}
@@ -67,7 +70,7 @@ func erasureCreateFile(disks []StorageAPI, volume string, path string, partName
 		}
 
 		// Write to all disks.
-		err = appendFile(disks, volume, path, blocks, eInfo.Distribution, hashWriters, writeQuorum)
+		err = appendFile(ctx, disks, volume, path, blocks, eInfo.Distribution, hashWriters, writeQuorum)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -122,8 +125,10 @@ func encodeData(dataBuffer []byte, dataBlocks, parityBlocks int) ([][]byte, erro
 	return blocks, nil
 }
 
-// appendFile - append data buffer at path.
-func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, distribution []int, hashWriters []hash.Hash, writeQuorum int) (err error) {
+// appendFile - append data buffer at path. Disks failing the append
+// are set to nil in disks, they are skipped by further appends and tell
+// the caller the file is incomplete on them.
+func appendFile(ctx context.Context, disks []StorageAPI, volume, path string, enBlocks [][]byte, distribution []int, hashWriters []hash.Hash, writeQuorum int) (err error) {
 	var wg = &sync.WaitGroup{}
 	var wErrs = make([]error, len(disks))
 	// Write encoded data to quorum disks in parallel.
@@ -137,7 +142,7 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, dist
 			defer wg.Done()
 			// Pick the block from the distribution.
 			blockIndex := distribution[index] - 1
-			wErr := disk.AppendFile(volume, path, enBlocks[blockIndex])
+			wErr := disk.AppendFile(ctx, volume, path, enBlocks[blockIndex])
 			if wErr != nil {
 				wErrs[index] = wErr
 				return
@@ -154,9 +159,19 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, dist
 	// Wait for all the appends to finish.
 	wg.Wait()
 
+	// Appends failed because the caller gave up, not the disks.
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
 	// Do we have write quorum?.
 	if !isQuorum(wErrs, writeQuorum) {
 		return toObjectErr(errXLWriteQuorum, volume, path)
 	}
+	for index, wErr := range wErrs {
+		if wErr != nil {
+			disks[index] = nil
+		}
+	}
 	return nil
 }
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"io"
@@ -29,8 +30,9 @@ import (
 // Erasure coded files are read block by block as per given erasureInfo and data chunks
 // are decoded into a data block. Data block is trimmed for given offset and length,
 // then written to given writer. This function also supports bit-rot detection by
-// verifying checksum of individual block's checksum.
-func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path string, partName string, eInfos []erasureInfo, offset int64, length int64, totalLength int64) (int64, error) {
+// verifying checksum of individual block's checksum. Reading and decoding
+// stop with ctx.Err() once ctx is done.
+func erasureReadFile(ctx context.Context, writer io.Writer, disks []StorageAPI, volume string, path string, partName string, eInfos []erasureInfo, offset int64, length int64, totalLength int64) (int64, error) {
 	// Pick one erasure info.
 	eInfo := pickValidErasureInfo(eInfos)
 
@@ -56,7 +58,7 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 			if verified[diskIndex] {
 				return true
 			}
-			isValid := isValidBlock(orderedDisks[diskIndex], volume, path, orderedBlockCheckSums[diskIndex])
+			isValid := isValidBlock(ctx, orderedDisks[diskIndex], volume, path, orderedBlockCheckSums[diskIndex])
 			verified[diskIndex] = isValid
 			return isValid
 		}
@@ -87,6 +89,8 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 
 		// Each element of enBlocks holds curChunkSize'd amount of data read from its corresponding disk.
 		enBlocks := make([][]byte, len(disks))
+		// Pooled buffers enBlocks were read into, reconstructed blocks are allocated by decodeData.
+		bufs := make([][]byte, len(disks))
 
 		// Figure out the number of disks that are needed for the read.
 		// We will need DataBlocks number of disks if all the data disks are up.
@@ -124,11 +128,12 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 					orderedDisks[index] = nil
 					return
 				}
-				buf := make([]byte, curChunkSize)
+				buf := globalBufferPools.Get(int(chunkSize))[:curChunkSize]
+				bufs[index] = buf
 				// Note that for the offset calculation we have to use chunkSize and not
 				// curChunkSize. If we use curChunkSize for offset calculation then it
 				// can result in wrong offset for the last block.
-				n, err := disk.ReadFile(volume, path, block*chunkSize, buf)
+				n, err := disk.ReadFile(ctx, volume, path, block*chunkSize, buf)
 				if err != nil {
 					// So that we don't read from this disk for the next block.
 					orderedDisks[index] = nil
@@ -144,6 +149,12 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		}
 		wg.Wait()
 
+		// Reads failed because the caller gave up, not the disks.
+		if err := ctx.Err(); err != nil {
+			putBuffers(bufs)
+			return bytesWritten, err
+		}
+
 		// Count number of data and parity blocks that were read.
 		var successDataBlocksCount = 0
 		var successParityBlocksCount = 0
@@ -172,8 +183,9 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 					orderedDisks[index] = nil
 					continue
 				}
-				buf := make([]byte, curChunkSize)
-				n, err := orderedDisks[index].ReadFile(volume, path, block*chunkSize, buf)
+				buf := globalBufferPools.Get(int(chunkSize))[:curChunkSize]
+				bufs[index] = buf
+				n, err := orderedDisks[index].ReadFile(ctx, volume, path, block*chunkSize, buf)
 				if err != nil {
 					// Mark nil so that we don't read from this disk for the next block.
 					orderedDisks[index] = nil
@@ -182,9 +194,14 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 				successParityBlocksCount++
 				enBlocks[index] = buf[:n]
 			}
+			if err := ctx.Err(); err != nil {
+				putBuffers(bufs)
+				return bytesWritten, err
+			}
 			// Reconstruct the missing data blocks.
 			err := decodeData(enBlocks, eInfo.DataBlocks, eInfo.ParityBlocks)
 			if err != nil {
+				putBuffers(bufs)
 				return bytesWritten, err
 			}
 		}
@@ -211,6 +228,7 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		}
 		// Write data blocks.
 		n, err := writeDataBlocks(writer, enBlocks, eInfo.DataBlocks, outOffset, outSize)
+		putBuffers(bufs)
 		if err != nil {
 			return bytesWritten, err
 		}
@@ -256,14 +274,14 @@ func toDiskIndex(blockIdx int, distribution []int) int {
 
 // isValidBlock - calculates the checksum hash for the block and
 // validates if its correct returns true for valid cases, false otherwise.
-func isValidBlock(disk StorageAPI, volume, path string, blockCheckSum checkSumInfo) (ok bool) {
+func isValidBlock(ctx context.Context, disk StorageAPI, volume, path string, blockCheckSum checkSumInfo) (ok bool) {
 	ok = false
 	if disk == nil {
 		return false
 	}
 	// Read everything for a given block and calculate hash.
 	hashWriter := newHash(blockCheckSum.Algorithm)
-	hashBytes, err := hashSum(disk, volume, path, hashWriter)
+	hashBytes, err := hashSum(ctx, disk, volume, path, hashWriter)
 	if err != nil {
 		return ok
 	}
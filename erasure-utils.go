@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"hash"
 	"io"
 
@@ -46,12 +47,13 @@ func newHash(algo string) hash.Hash {
 	}
 }
 
-func hashSum(disk StorageAPI, volume, path string, writer hash.Hash) ([]byte, error) {
+func hashSum(ctx context.Context, disk StorageAPI, volume, path string, writer hash.Hash) ([]byte, error) {
 	startOffset := int64(0)
+	buf := globalBufferPools.Get(blockSizeV1)
+	defer globalBufferPools.Put(buf)
 	// Read until io.EOF.
 	for {
-		buf := make([]byte, blockSizeV1)
-		n, err := disk.ReadFile(volume, path, startOffset, buf)
+		n, err := disk.ReadFile(ctx, volume, path, startOffset, buf)
 		if err == io.EOF {
 			break
 		}
@@ -0,0 +1,321 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// federationConfig - federation of multiple deployments sharing one
+// bucket namespace. Buckets are registered as DNS records in etcd,
+// served by CoreDNS with the etcd plugin for the domain. All federated
+// deployments must share the same credentials.
+type federationConfig struct {
+	Enable bool   `json:"enable"`
+	Domain string `json:"domain"`
+	Etcd   string `json:"etcd"`
+	// Addresses of this deployment registered for its buckets.
+	PublicIPs []string `json:"publicIPs"`
+	// Redirect clients to the deployment owning a bucket instead of
+	// proxying their requests.
+	Redirect bool `json:"redirect"`
+}
+
+// Time to live of bucket DNS records in seconds.
+const bucketDNSTTL = 30
+
+// errBucketDNSNotFound - no DNS records registered for a bucket.
+var errBucketDNSNotFound = errors.New("Bucket DNS record not found")
+
+// globalFederation - bucket DNS of the federation, nil if federation
+// is disabled.
+var globalFederation *bucketDNS
+
+// bucketDNSRecord - SkyDNS service record as understood by the
+// CoreDNS etcd plugin.
+type bucketDNSRecord struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	TTL  uint32 `json:"ttl"`
+}
+
+// etcdNode - node of the etcd v2 keys API.
+type etcdNode struct {
+	Key   string     `json:"key"`
+	Value string     `json:"value"`
+	Dir   bool       `json:"dir"`
+	Nodes []etcdNode `json:"nodes"`
+}
+
+// etcdResponse - response of the etcd v2 keys API.
+type etcdResponse struct {
+	Message string   `json:"message"`
+	Node    etcdNode `json:"node"`
+}
+
+// bucketDNS - registers and looks up the deployment owning a bucket.
+type bucketDNS struct {
+	config federationConfig
+	port   int
+	client *http.Client
+}
+
+// validateFederationConfig - validates the federation configuration
+// without connecting to etcd.
+func validateFederationConfig(config federationConfig) error {
+	if config.Domain == "" {
+		return errors.New("Federation domain is missing")
+	}
+	if _, err := url.Parse(config.Etcd); err != nil || config.Etcd == "" {
+		return fmt.Errorf("Invalid etcd endpoint ‘%s’", config.Etcd)
+	}
+	if len(config.PublicIPs) == 0 {
+		return errors.New("Federation public IPs are missing")
+	}
+	for _, ip := range config.PublicIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("Invalid federation public IP ‘%s’", ip)
+		}
+	}
+	return nil
+}
+
+// newBucketDNS - validates the federation configuration, port is the
+// port this deployment serves on.
+func newBucketDNS(config federationConfig, port string) (*bucketDNS, error) {
+	if err := validateFederationConfig(config); err != nil {
+		return nil, err
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid port ‘%s’", port)
+	}
+	return &bucketDNS{
+		config: config,
+		port:   portNum,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// initFederation - initializes the federation bucket DNS if enabled.
+func initFederation(port string) {
+	config := serverConfig.GetFederation()
+	if !config.Enable {
+		return
+	}
+	dns, err := newBucketDNS(config, port)
+	fatalIf(err, "Unable to initialize bucket federation.")
+	globalFederation = dns
+}
+
+// domainKey - returns the etcd key of the domain, SkyDNS stores
+// names with their labels reversed.
+func (d *bucketDNS) domainKey() string {
+	labels := strings.Split(strings.Trim(d.config.Domain, "."), ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return "/skydns/" + strings.Join(labels, "/")
+}
+
+// do - sends a request to the etcd v2 keys API.
+func (d *bucketDNS) do(method, key string, query url.Values) (*etcdResponse, error) {
+	reqURL := strings.TrimSuffix(d.config.Etcd, "/") + "/v2/keys" + key
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	etcdResp := &etcdResponse{}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		if err = json.NewDecoder(resp.Body).Decode(etcdResp); err != nil {
+			return nil, err
+		}
+		return etcdResp, nil
+	case http.StatusNotFound:
+		return nil, errBucketDNSNotFound
+	default:
+		// Error responses carry a message, ignore malformed ones.
+		json.NewDecoder(resp.Body).Decode(etcdResp)
+		return nil, fmt.Errorf("etcd request failed with ‘%s’: %s", resp.Status, etcdResp.Message)
+	}
+}
+
+// Put - registers this deployment as owner of bucket.
+func (d *bucketDNS) Put(bucket string) error {
+	for _, ip := range d.config.PublicIPs {
+		record, err := json.Marshal(bucketDNSRecord{Host: ip, Port: d.port, TTL: bucketDNSTTL})
+		if err != nil {
+			return err
+		}
+		// One record per address, keys must not contain dots.
+		key := path.Join(d.domainKey(), bucket, strings.Replace(ip, ".", "-", -1))
+		if _, err = d.do("PUT", key, url.Values{"value": {string(record)}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get - returns the DNS records of bucket.
+func (d *bucketDNS) Get(bucket string) ([]bucketDNSRecord, error) {
+	resp, err := d.do("GET", path.Join(d.domainKey(), bucket), url.Values{"recursive": {"true"}})
+	if err != nil {
+		return nil, err
+	}
+	var records []bucketDNSRecord
+	for _, node := range resp.Node.Nodes {
+		var record bucketDNSRecord
+		if node.Dir || json.Unmarshal([]byte(node.Value), &record) != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, errBucketDNSNotFound
+	}
+	return records, nil
+}
+
+// Delete - removes the DNS records of bucket.
+func (d *bucketDNS) Delete(bucket string) error {
+	_, err := d.do("DELETE", path.Join(d.domainKey(), bucket), url.Values{"recursive": {"true"}, "dir": {"true"}})
+	if err == errBucketDNSNotFound {
+		return nil
+	}
+	return err
+}
+
+// isLocal - returns true if the records point to this deployment.
+func (d *bucketDNS) isLocal(records []bucketDNSRecord) bool {
+	for _, record := range records {
+		if record.Port != d.port {
+			continue
+		}
+		for _, ip := range d.config.PublicIPs {
+			if record.Host == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// makeFederatedBucket - creates bucket and registers it in the
+// federation, buckets owned by another deployment cannot be created.
+func makeFederatedBucket(objAPI ObjectLayer, bucket string) error {
+	if globalFederation == nil {
+		return objAPI.MakeBucket(bucket)
+	}
+	records, err := globalFederation.Get(bucket)
+	if err == nil && !globalFederation.isLocal(records) {
+		return BucketAlreadyExists{Bucket: bucket}
+	}
+	if err != nil && err != errBucketDNSNotFound {
+		return err
+	}
+	if err = objAPI.MakeBucket(bucket); err != nil {
+		return err
+	}
+	if err = globalFederation.Put(bucket); err != nil {
+		// Unregistered buckets are not reachable from other deployments.
+		objAPI.DeleteBucket(bucket)
+		return err
+	}
+	return nil
+}
+
+// registerFederatedBuckets - registers all existing buckets, called at
+// startup for buckets created before federation was enabled.
+func registerFederatedBuckets(objAPI ObjectLayer) {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets for federation.")
+		return
+	}
+	for _, bucket := range buckets {
+		records, err := globalFederation.Get(bucket.Name)
+		if err == nil && !globalFederation.isLocal(records) {
+			errorIf(BucketAlreadyExists{Bucket: bucket.Name}, "Bucket is not reachable through the federation.")
+			continue
+		}
+		errorIf(globalFederation.Put(bucket.Name), "Unable to register bucket %s.", bucket.Name)
+	}
+}
+
+// bucketForwardingHandler - forwards requests for buckets owned by
+// another federated deployment.
+type bucketForwardingHandler struct {
+	handler http.Handler
+}
+
+func setBucketForwardingHandler(h http.Handler) http.Handler {
+	return bucketForwardingHandler{handler: h}
+}
+
+func (h bucketForwardingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if globalFederation == nil {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	bucket := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+	// Service requests and bucket creation are always handled locally.
+	if bucket == "" || bucket == path.Base(reservedBucket) || (r.Method == "PUT" && strings.TrimSuffix(r.URL.Path, "/") == "/"+bucket) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	records, err := globalFederation.Get(bucket)
+	if err != nil || globalFederation.isLocal(records) {
+		// Buckets missing from the federation are looked up locally.
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	scheme := "http"
+	if isSSL() {
+		scheme = "https"
+	}
+	target := &url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(records[0].Host, strconv.Itoa(records[0].Port)),
+	}
+	if globalFederation.config.Redirect {
+		http.Redirect(w, r, target.String()+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		return
+	}
+	// The Host header is left untouched, it is part of the signature
+	// verified by the owning deployment.
+	httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+}
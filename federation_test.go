@@ -0,0 +1,276 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestEtcdServer - returns a minimal in-memory etcd v2 keys API.
+func newTestEtcdServer() *httptest.Server {
+	var mutex sync.Mutex
+	keys := make(map[string]string)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/v2/keys")
+		switch r.Method {
+		case "PUT":
+			keys[key] = r.FormValue("value")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(etcdResponse{Node: etcdNode{Key: key, Value: keys[key]}})
+		case "GET":
+			resp := etcdResponse{Node: etcdNode{Key: key, Dir: true}}
+			var names []string
+			for name := range keys {
+				if strings.HasPrefix(name, key+"/") {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(etcdResponse{Message: "Key not found"})
+				return
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				resp.Node.Nodes = append(resp.Node.Nodes, etcdNode{Key: name, Value: keys[name]})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "DELETE":
+			found := false
+			for name := range keys {
+				if strings.HasPrefix(name, key+"/") {
+					delete(keys, name)
+					found = true
+				}
+			}
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(etcdResponse{Message: "Key not found"})
+				return
+			}
+			json.NewEncoder(w).Encode(etcdResponse{Node: etcdNode{Key: key, Dir: true}})
+		}
+	}))
+}
+
+// Tests validation of federation configs.
+func TestValidateFederationConfig(t *testing.T) {
+	testCases := []struct {
+		config     federationConfig
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{federationConfig{Domain: "example.com", Etcd: "http://localhost:2379", PublicIPs: []string{"10.0.0.1"}}, true},
+		// Test case - 2.
+		// Missing domain.
+		{federationConfig{Etcd: "http://localhost:2379", PublicIPs: []string{"10.0.0.1"}}, false},
+		// Test case - 3.
+		// Missing etcd endpoint.
+		{federationConfig{Domain: "example.com", PublicIPs: []string{"10.0.0.1"}}, false},
+		// Test case - 4.
+		// Missing public IPs.
+		{federationConfig{Domain: "example.com", Etcd: "http://localhost:2379"}, false},
+		// Test case - 5.
+		// Public IPs must be IP addresses.
+		{federationConfig{Domain: "example.com", Etcd: "http://localhost:2379", PublicIPs: []string{"minio.example.com"}}, false},
+	}
+	for i, testCase := range testCases {
+		err := validateFederationConfig(testCase.config)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// Tests registration and lookup of bucket DNS records.
+func TestBucketDNS(t *testing.T) {
+	etcd := newTestEtcdServer()
+	defer etcd.Close()
+
+	dns, err := newBucketDNS(federationConfig{
+		Enable:    true,
+		Domain:    "minio.example.com.",
+		Etcd:      etcd.URL,
+		PublicIPs: []string{"10.0.0.1", "10.0.0.2"},
+	}, "9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := dns.domainKey(); key != "/skydns/com/example/minio" {
+		t.Fatalf("Expected /skydns/com/example/minio, but instead found %s", key)
+	}
+
+	if _, err = dns.Get("bucket"); err != errBucketDNSNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errBucketDNSNotFound, err)
+	}
+	if err = dns.Put("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := dns.Get("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Host != "10.0.0.1" || records[1].Host != "10.0.0.2" || records[0].Port != 9000 {
+		t.Fatalf("Unexpected bucket DNS records %v", records)
+	}
+	if !dns.isLocal(records) {
+		t.Fatal("Expected bucket to be local")
+	}
+	if dns.isLocal([]bucketDNSRecord{{Host: "10.0.0.1", Port: 9001}}) {
+		t.Fatal("Expected bucket on another port not to be local")
+	}
+	if err = dns.Delete("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dns.Get("bucket"); err != errBucketDNSNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errBucketDNSNotFound, err)
+	}
+	// Deleting missing records is not an error.
+	if err = dns.Delete("bucket"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests buckets owned by other deployments cannot be created.
+func TestMakeFederatedBucket(t *testing.T) {
+	etcd := newTestEtcdServer()
+	defer etcd.Close()
+	objAPI, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+
+	config := federationConfig{Enable: true, Domain: "example.com", Etcd: etcd.URL, PublicIPs: []string{"10.0.0.1"}}
+	remote, err := newBucketDNS(config, "9001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalFederation, err = newBucketDNS(config, "9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { globalFederation = nil }()
+
+	if err = remote.Put("remote"); err != nil {
+		t.Fatal(err)
+	}
+	if err = makeFederatedBucket(objAPI, "remote"); err != (BucketAlreadyExists{Bucket: "remote"}) {
+		t.Fatalf("Expected BucketAlreadyExists, but instead found %v", err)
+	}
+	if err = makeFederatedBucket(objAPI, "local"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := globalFederation.Get("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !globalFederation.isLocal(records) {
+		t.Fatal("Expected bucket to be registered locally")
+	}
+}
+
+// Tests requests for buckets of other deployments are forwarded.
+func TestBucketForwardingHandler(t *testing.T) {
+	etcd := newTestEtcdServer()
+	defer etcd.Close()
+	remoteServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("remote " + r.Host))
+	}))
+	defer remoteServer.Close()
+	remoteHost, remotePort, err := net.SplitHostPort(remoteServer.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote, err := newBucketDNS(federationConfig{Domain: "example.com", Etcd: etcd.URL, PublicIPs: []string{remoteHost}}, remotePort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Put("remote"); err != nil {
+		t.Fatal(err)
+	}
+	globalFederation, err = newBucketDNS(federationConfig{Domain: "example.com", Etcd: etcd.URL, PublicIPs: []string{"10.0.0.1"}}, "9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { globalFederation = nil }()
+
+	handler := setBucketForwardingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("local"))
+	}))
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		// Test case - 1.
+		{"GET", "/remote/object", "remote minio.example.com"},
+		// Test case - 2.
+		{"GET", "/remote", "remote minio.example.com"},
+		// Test case - 3.
+		// Unregistered bucket.
+		{"GET", "/local/object", "local"},
+		// Test case - 4.
+		// Bucket creation.
+		{"PUT", "/remote/", "local"},
+		// Test case - 5.
+		{"GET", "/", "local"},
+		// Test case - 6.
+		{"GET", reservedBucket + "/webrpc", "local"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://minio.example.com"+testCase.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if body, _ := ioutil.ReadAll(rec.Body); string(body) != testCase.body {
+			t.Errorf("Test %d: Expected %s, but instead found %s", i+1, testCase.body, string(body))
+		}
+	}
+
+	// Clients are redirected if requested.
+	globalFederation.config.Redirect = true
+	req, err := http.NewRequest("GET", "http://minio.example.com/remote/object?versions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("Expected %d, but instead found %d", http.StatusTemporaryRedirect, rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != remoteServer.URL+"/remote/object?versions" {
+		t.Fatalf("Expected redirect to %s, but instead found %s", remoteServer.URL+"/remote/object?versions", location)
+	}
+}
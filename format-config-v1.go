@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -577,7 +578,7 @@ func saveFormatXL(storageDisks []StorageAPI, formats []*formatConfigV1) error {
 			disk.DeleteFile(minioMetaBucket, formatConfigFileTmp)
 
 			// Append file `format.json.tmp`.
-			if err = disk.AppendFile(minioMetaBucket, formatConfigFileTmp, formatBytes); err != nil {
+			if err = disk.AppendFile(context.Background(), minioMetaBucket, formatConfigFileTmp, formatBytes); err != nil {
 				errs[index] = err
 				return
 			}
@@ -641,3 +642,127 @@ func initFormatXL(storageDisks []StorageAPI) (err error) {
 	// Save formats `format.json` across all disks.
 	return saveFormatXL(storageDisks, formats)
 }
+
+// loadDiskFormat - loads format.json of a disk, returns nil and the
+// reason if it has no valid format.
+func loadDiskFormat(disk string) (*formatConfigV1, string) {
+	storage, err := newStorageAPI(disk)
+	if err != nil {
+		return nil, "offline"
+	}
+	format, err := loadFormat(storage)
+	switch err {
+	case nil:
+		if format.Format != "fs" && format.Format != "xl" {
+			return nil, "unsupported format " + format.Format
+		}
+		if format.Format == "xl" && format.XL == nil {
+			return nil, "corrupted format"
+		}
+		return format, format.Format
+	case errUnformattedDisk:
+		return nil, "unformatted"
+	case errCorruptedFormat:
+		return nil, "unformatted, has data"
+	case errDiskNotFound:
+		return nil, "offline"
+	}
+	return nil, err.Error()
+}
+
+// formatKey - identifies a disk in a backend format, 'fs' for the only
+// disk of FS and the disk uuid for XL.
+func formatKey(format *formatConfigV1) string {
+	if format == nil {
+		return ""
+	}
+	if format.Format == "xl" {
+		return format.XL.Disk
+	}
+	return format.Format
+}
+
+// detectBackendFormat - returns the backend format, 'fs' or 'xl', saved
+// in format.json of disks. Fresh disks are formatted as FS if only one
+// disk is supplied, as XL otherwise. If the disks do not match the
+// saved format an error listing the differences is returned, instead
+// of risking to initialize the disks again. Disks split into erasure
+// sets, or more disks than one set supports, are 'xl-sets' whose sets
+// are checked when they are initialized.
+func detectBackendFormat(disks []string) (string, error) {
+	if len(disks) > maxErasureBlocks {
+		return objectLayerXLSets, nil
+	}
+	if _, err := loadXLSetsFormat(disks); err != errFileNotFound {
+		return objectLayerXLSets, nil
+	}
+	return detectDisksFormat(disks)
+}
+
+// detectDisksFormat - returns the backend format of disks forming a
+// single FS or XL backend.
+func detectDisksFormat(disks []string) (string, error) {
+	formats := make([]*formatConfigV1, len(disks))
+	reasons := make([]string, len(disks))
+	for index, disk := range disks {
+		formats[index], reasons[index] = loadDiskFormat(disk)
+	}
+
+	// XL format is saved on all disks, FS disks cannot be part of it.
+	var saved *formatConfigV1
+	for _, format := range formats {
+		if format == nil {
+			continue
+		}
+		if saved == nil || saved.Format == "fs" && format.Format == "xl" {
+			saved = format
+		}
+	}
+	if saved == nil {
+		if len(disks) == 1 {
+			return objectLayerFS, nil
+		}
+		return objectLayerXL, nil
+	}
+	var expected []string
+	if saved.Format == "xl" {
+		expected = saved.XL.JBOD
+	} else {
+		expected = []string{"fs"}
+	}
+
+	// Match the supplied disks with the disks of the saved format.
+	matched := make([]bool, len(disks))
+	mismatch := len(disks) != len(expected)
+	lines := make([]string, 0, len(expected)+len(disks))
+	for _, key := range expected {
+		line := "- " + key
+		for index, format := range formats {
+			if !matched[index] && formatKey(format) == key && format.Format == saved.Format {
+				matched[index] = true
+				line = "  " + key + "  " + disks[index]
+				break
+			}
+		}
+		lines = append(lines, line)
+	}
+	for index, disk := range disks {
+		if matched[index] {
+			continue
+		}
+		// Disks without format are healed, disks of other backends
+		// do not belong here.
+		if formats[index] != nil {
+			mismatch = true
+			if formats[index].Format == "xl" {
+				reasons[index] = "xl " + formats[index].XL.Disk
+			}
+		}
+		lines = append(lines, "+ "+disk+" ("+reasons[index]+")")
+	}
+	if !mismatch {
+		return saved.Format, nil
+	}
+	return "", fmt.Errorf("Disks do not match the backend format saved in %s, refusing to initialize them.\n--- %s (%s, %d disks)\n+++ command line (%d disks)\n%s",
+		formatConfigFile, formatConfigFile, saved.Format, len(expected), len(disks), strings.Join(lines, "\n"))
+}
@@ -16,7 +16,11 @@
 
 package main
 
-import "testing"
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
 
 // generates a valid format.json for XL backend.
 func genFormatXLValid() []*formatConfigV1 {
@@ -233,3 +237,78 @@ func TestSavedUUIDOrder(t *testing.T) {
 		}
 	}
 }
+
+// Tests backend format is detected from format.json of disks.
+func TestDetectBackendFormat(t *testing.T) {
+	_, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+	_, xlDisks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(xlDisks)
+	freshDisks := make([]string, 2)
+	for i := range freshDisks {
+		if freshDisks[i], err = ioutil.TempDir("", "minio-"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer removeRoots(freshDisks)
+
+	// Disks in a different order, with one disk replaced by a fresh disk.
+	reordered := make([]string, len(xlDisks))
+	for i, disk := range xlDisks {
+		reordered[len(xlDisks)-1-i] = disk
+	}
+	reordered[0] = freshDisks[0]
+
+	testCases := []struct {
+		disks  []string
+		format string
+		diff   string
+	}{
+		// Test case - 1.
+		{[]string{fsDir}, "fs", ""},
+		// Test case - 2.
+		{xlDisks, "xl", ""},
+		// Test case - 3.
+		// Fresh disks are formatted depending on their number.
+		{freshDisks[:1], "fs", ""},
+		// Test case - 4.
+		{freshDisks, "xl", ""},
+		// Test case - 5.
+		{reordered, "xl", ""},
+		// Test case - 6.
+		// A disk is missing.
+		{xlDisks[1:], "", "\n- "},
+		// Test case - 7.
+		// A single disk of XL is not started as FS.
+		{xlDisks[:1], "", "\n  "},
+		// Test case - 8.
+		// FS disk is not part of XL.
+		{append(append([]string{}, xlDisks[1:]...), fsDir), "", "\n+ " + fsDir + " (fs)"},
+		// Test case - 9.
+		{[]string{fsDir, freshDisks[0]}, "", "\n+ " + freshDisks[0] + " (unformatted)"},
+	}
+	for i, testCase := range testCases {
+		format, err := detectBackendFormat(testCase.disks)
+		if testCase.diff == "" {
+			if err != nil {
+				t.Fatalf("Test %d: %s", i+1, err)
+			}
+			if format != testCase.format {
+				t.Errorf("Test %d: Expected format %s, but instead found %s", i+1, testCase.format, format)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("Test %d: Expected to fail but passed instead", i+1)
+		}
+		if !strings.Contains(err.Error(), testCase.diff) {
+			t.Errorf("Test %d: Expected %q in error, but instead found %q", i+1, testCase.diff, err)
+		}
+	}
+}
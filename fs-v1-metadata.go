@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"path"
 	"sort"
@@ -18,7 +19,8 @@ type fsMetaV1 struct {
 	Minio   struct {
 		Release string `json:"release"`
 	} `json:"minio"`
-	Parts []objectPartInfo `json:"parts,omitempty"`
+	Parts []objectPartInfo  `json:"parts,omitempty"`
+	Meta  map[string]string `json:"meta,omitempty"`
 }
 
 // ObjectPartIndex - returns the index of matching object part number.
@@ -97,7 +99,7 @@ func writeFSFormatData(storage StorageAPI, fsFormat formatConfigV1) error {
 		return err
 	}
 	// fsFormatJSONFile - format.json file stored in minioMetaBucket(.minio) directory.
-	if err = storage.AppendFile(minioMetaBucket, fsFormatJSONFile, metadataBytes); err != nil {
+	if err = storage.AppendFile(context.Background(), minioMetaBucket, fsFormatJSONFile, metadataBytes); err != nil {
 		return err
 	}
 	return nil
@@ -109,7 +111,68 @@ func (fs fsObjects) writeFSMetadata(bucket, prefix string, fsMeta fsMetaV1) erro
 	if err != nil {
 		return err
 	}
-	if err = fs.storage.AppendFile(bucket, path.Join(prefix, fsMetaJSONFile), metadataBytes); err != nil {
+	if err = fs.storage.AppendFile(context.Background(), bucket, path.Join(prefix, fsMetaJSONFile), metadataBytes); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readObjectMetadata - returns the metadata saved for an object at
+// '.minio/buckets/bucket/object/fs.json', nil if none was saved.
+func (fs fsObjects) readObjectMetadata(bucket, object string) (map[string]string, error) {
+	fsMeta, err := fs.readFSMetadata(minioMetaBucket, path.Join(bucketMetaPrefix, bucket, object))
+	if err != nil {
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fsMeta.Meta, nil
+}
+
+// writeObjectMetadata - saves metadata for an object, replacing any
+// previously saved metadata.
+func (fs fsObjects) writeObjectMetadata(bucket, object string, meta map[string]string) error {
+	fsMeta := newFSMetaV1()
+	fsMeta.Meta = meta
+
+	tempMetaPath := path.Join(tmpMetaPrefix, getUUID())
+	if err := fs.writeFSMetadata(minioMetaBucket, tempMetaPath, fsMeta); err != nil {
+		return err
+	}
+	err := fs.storage.RenameFile(minioMetaBucket, path.Join(tempMetaPath, fsMetaJSONFile), minioMetaBucket, path.Join(bucketMetaPrefix, bucket, object, fsMetaJSONFile))
+	if err != nil {
+		if dErr := fs.storage.DeleteFile(minioMetaBucket, path.Join(tempMetaPath, fsMetaJSONFile)); dErr != nil {
+			return dErr
+		}
+		return err
+	}
+	return nil
+}
+
+// newFSObjectMetadata - returns the metadata saved for a new object,
+// the metadata of the request with the md5sum of the object and the
+// content-type guessed from its extension if none was given.
+func newFSObjectMetadata(object string, metadata map[string]string, md5Hex string) map[string]string {
+	meta := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		if value != "" {
+			meta[key] = value
+		}
+	}
+	meta["md5Sum"] = md5Hex
+	if meta["content-type"] == "" {
+		if contentType := guessContentType(object); contentType != "" {
+			meta["content-type"] = contentType
+		}
+	}
+	return meta
+}
+
+// deleteObjectMetadata - removes saved metadata of an object, if any.
+func (fs fsObjects) deleteObjectMetadata(bucket, object string) error {
+	err := fs.storage.DeleteFile(minioMetaBucket, path.Join(bucketMetaPrefix, bucket, object, fsMetaJSONFile))
+	if err != nil && err != errFileNotFound {
 		return err
 	}
 	return nil
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
@@ -31,18 +32,27 @@ import (
 
 // listMultipartUploads - lists all multipart uploads.
 func (fs fsObjects) listMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
-	result := ListMultipartsInfo{}
+	result := ListMultipartsInfo{
+		MaxUploads:     maxUploads,
+		KeyMarker:      keyMarker,
+		UploadIDMarker: uploadIDMarker,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+	}
+	if maxUploads > maxUploadsList {
+		maxUploads = maxUploadsList
+	}
+
+	keyMarker, uploadIDMarker, done := getMultipartMarkers(prefix, keyMarker, uploadIDMarker)
+	if done {
+		return result, nil
+	}
+
 	recursive := true
 	if delimiter == slashSeparator {
 		recursive = false
 	}
 
-	result.IsTruncated = true
-	result.MaxUploads = maxUploads
-	result.KeyMarker = keyMarker
-	result.Prefix = prefix
-	result.Delimiter = delimiter
-
 	// Not using path.Join() as it strips off the trailing '/'.
 	multipartPrefixPath := pathJoin(mpartMetaPrefix, bucket, prefix)
 	if prefix == "" {
@@ -54,94 +64,44 @@ func (fs fsObjects) listMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 	if keyMarker != "" {
 		multipartMarkerPath = pathJoin(mpartMetaPrefix, bucket, keyMarker)
 	}
-	var uploads []uploadMetadata
-	var err error
-	var eof bool
-	if uploadIDMarker != "" {
-		nsMutex.RLock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, keyMarker))
-		uploads, _, err = listMultipartUploadIDs(bucket, keyMarker, uploadIDMarker, maxUploads, fs.storage)
-		nsMutex.RUnlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, keyMarker))
-		if err != nil {
-			return ListMultipartsInfo{}, err
-		}
-		maxUploads = maxUploads - len(uploads)
-	}
-	if maxUploads > 0 {
-		walker := fs.lookupTreeWalk(listParams{minioMetaBucket, recursive, multipartMarkerPath, multipartPrefixPath})
+
+	// The tree walk is started lazily, listing the uploads of the key
+	// marker alone might fill the page.
+	var walker *treeWalkerFS
+	nextEntry := func() (string, bool, error) {
 		if walker == nil {
-			walker = fs.startTreeWalk(minioMetaBucket, multipartPrefixPath, multipartMarkerPath, recursive, func(bucket, object string) bool {
-				return fs.isMultipartUpload(bucket, object)
-			})
-		}
-		for maxUploads > 0 {
-			walkResult, ok := <-walker.ch
-			if !ok {
-				// Closed channel.
-				eof = true
-				break
-			}
-			// For any walk error return right away.
-			if walkResult.err != nil {
-				// File not found or Disk not found is a valid case.
-				if walkResult.err == errFileNotFound || walkResult.err == errDiskNotFound || walkResult.err == errFaultyDisk {
-					eof = true
-					break
-				}
-				return ListMultipartsInfo{}, err
-			}
-			entry := strings.TrimPrefix(walkResult.entry, retainSlash(pathJoin(mpartMetaPrefix, bucket)))
-			if strings.HasSuffix(walkResult.entry, slashSeparator) {
-				uploads = append(uploads, uploadMetadata{
-					Object: entry,
+			walker = fs.lookupTreeWalk(listParams{minioMetaBucket, recursive, multipartMarkerPath, multipartPrefixPath})
+			if walker == nil {
+				walker = fs.startTreeWalk(minioMetaBucket, multipartPrefixPath, multipartMarkerPath, recursive, func(bucket, object string) bool {
+					return fs.isMultipartUpload(bucket, object)
 				})
-				maxUploads--
-				if maxUploads == 0 {
-					if walkResult.end {
-						eof = true
-						break
-					}
-				}
-				continue
-			}
-			var tmpUploads []uploadMetadata
-			var end bool
-			uploadIDMarker = ""
-			nsMutex.RLock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, entry))
-			tmpUploads, end, err = listMultipartUploadIDs(bucket, entry, uploadIDMarker, maxUploads, fs.storage)
-			nsMutex.RUnlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, entry))
-			if err != nil {
-				return ListMultipartsInfo{}, err
 			}
-			uploads = append(uploads, tmpUploads...)
-			maxUploads -= len(tmpUploads)
-			if walkResult.end && end {
-				eof = true
-				break
+		}
+		walkResult, ok := <-walker.ch
+		if !ok {
+			return "", false, nil
+		}
+		if walkResult.err != nil {
+			// File not found or Disk not found is a valid case.
+			if walkResult.err == errFileNotFound || walkResult.err == errDiskNotFound || walkResult.err == errFaultyDisk {
+				return "", false, nil
 			}
+			return "", false, walkResult.err
 		}
+		return strings.TrimPrefix(walkResult.entry, retainSlash(pathJoin(mpartMetaPrefix, bucket))), true, nil
 	}
-	// Loop through all the received uploads fill in the multiparts result.
-	for _, upload := range uploads {
-		var objectName string
-		var uploadID string
-		if strings.HasSuffix(upload.Object, slashSeparator) {
-			// All directory entries are common prefixes.
-			uploadID = "" // Upload ids are empty for CommonPrefixes.
-			objectName = upload.Object
-			result.CommonPrefixes = append(result.CommonPrefixes, objectName)
-		} else {
-			uploadID = upload.UploadID
-			objectName = upload.Object
-			result.Uploads = append(result.Uploads, upload)
-		}
-		result.NextKeyMarker = objectName
-		result.NextUploadIDMarker = uploadID
+	listUploadIDs := func(object, uploadIDMarker string, count int) ([]uploadMetadata, error) {
+		nsMutex.RLock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
+		defer nsMutex.RUnlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
+		uploads, _, err := listMultipartUploadIDs(bucket, object, uploadIDMarker, count, fs.storage)
+		return uploads, err
 	}
-	result.IsTruncated = !eof
-	if !result.IsTruncated {
-		result.NextKeyMarker = ""
-		result.NextUploadIDMarker = ""
+
+	uploads, truncated, err := listMultipartsPage(keyMarker, uploadIDMarker, maxUploads, nextEntry, listUploadIDs)
+	if err != nil {
+		return ListMultipartsInfo{}, err
 	}
+	fillMultipartsInfo(&result, uploads, truncated)
 	return result, nil
 }
 
@@ -165,20 +125,11 @@ func (fs fsObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 	if !IsValidObjectPrefix(prefix) {
 		return ListMultipartsInfo{}, ObjectNameInvalid{Bucket: bucket, Object: prefix}
 	}
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != slashSeparator {
-		return ListMultipartsInfo{}, UnsupportedDelimiter{
-			Delimiter: delimiter,
-		}
-	}
-	// Verify if marker has prefix.
-	if keyMarker != "" && !strings.HasPrefix(keyMarker, prefix) {
-		return ListMultipartsInfo{}, InvalidMarkerPrefixCombination{
-			Marker: keyMarker,
-			Prefix: prefix,
-		}
+	// Other delimiters than '/' are rolled up from recursive listings.
+	if !isWalkDelimiter(delimiter) {
+		return listMultipartUploadsDelimited(fs.ListMultipartUploads, bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
 	}
-	if uploadIDMarker != "" {
+	if keyMarker != "" && uploadIDMarker != "" {
 		if strings.HasSuffix(keyMarker, slashSeparator) {
 			return ListMultipartsInfo{}, InvalidUploadIDKeyCombination{
 				UploadIDMarker: uploadIDMarker,
@@ -211,8 +162,10 @@ func (fs fsObjects) newMultipartUpload(bucket string, object string, meta map[st
 		meta = make(map[string]string)
 	}
 
-	// Initialize `fs.json` values.
+	// Initialize `fs.json` values, the metadata is saved for the object
+	// once the upload is completed.
 	fsMeta := newFSMetaV1()
+	fsMeta.Meta = meta
 
 	// This lock needs to be held for any changes to the directory contents of ".minio/multipart/object/"
 	nsMutex.Lock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
@@ -246,7 +199,6 @@ func (fs fsObjects) newMultipartUpload(bucket string, object string, meta map[st
 //
 // Implements S3 compatible initiate multipart API.
 func (fs fsObjects) NewMultipartUpload(bucket, object string, meta map[string]string) (string, error) {
-	meta = make(map[string]string) // Reset the meta value, we are not going to save headers for fs.
 	// Verify if bucket name is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
@@ -266,7 +218,7 @@ func (fs fsObjects) NewMultipartUpload(bucket, object string, meta map[string]st
 // an ongoing multipart transaction. Internally incoming data is
 // written to '.minio/tmp' location and safely renamed to
 // '.minio/multipart' for reach parts.
-func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+func (fs fsObjects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
@@ -296,21 +248,31 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	partSuffix := fmt.Sprintf("object%d", partID)
 	tmpPartPath := path.Join(tmpMetaPrefix, uploadID, partSuffix)
 
+	// Preallocate the part file if the size is known.
+	if size > 0 {
+		if err := fs.storage.PrepareFile(minioMetaBucket, tmpPartPath, size); err != nil {
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+
 	// Initialize md5 writer.
 	md5Writer := md5.New()
 
-	var buf = make([]byte, blockSizeV1)
+	buf := globalBufferPools.Get(blockSizeV1)
+	defer globalBufferPools.Put(buf)
 	for {
 		n, err := io.ReadFull(data, buf)
 		if err == io.EOF {
 			break
 		}
 		if err != nil && err != io.ErrUnexpectedEOF {
+			fs.storage.DeleteFile(minioMetaBucket, tmpPartPath)
 			return "", toObjectErr(err, bucket, object)
 		}
 		// Update md5 writer.
 		md5Writer.Write(buf[:n])
-		if err = fs.storage.AppendFile(minioMetaBucket, tmpPartPath, buf[:n]); err != nil {
+		if err = fs.storage.AppendFile(ctx, minioMetaBucket, tmpPartPath, buf[:n]); err != nil {
+			fs.storage.DeleteFile(minioMetaBucket, tmpPartPath)
 			return "", toObjectErr(err, bucket, object)
 		}
 	}
@@ -318,6 +280,8 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
 	if md5Hex != "" {
 		if newMD5Hex != md5Hex {
+			// MD5 mismatch, delete the temporary part.
+			fs.storage.DeleteFile(minioMetaBucket, tmpPartPath)
 			return "", BadDigest{md5Hex, newMD5Hex}
 		}
 	}
@@ -360,6 +324,40 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	return newMD5Hex, nil
 }
 
+// UpdateUploadMetadata - saves the metadata of a multipart upload
+// changed by update in its fs.json.
+func (fs fsObjects) UpdateUploadMetadata(bucket, object, uploadID string, update func(metadata map[string]string) error) error {
+	uploadIDPath := path.Join(mpartMetaPrefix, bucket, object, uploadID)
+	nsMutex.Lock(minioMetaBucket, uploadIDPath)
+	defer nsMutex.Unlock(minioMetaBucket, uploadIDPath)
+
+	if !fs.isUploadIDExists(bucket, object, uploadID) {
+		return InvalidUploadID{UploadID: uploadID}
+	}
+	fsMeta, err := fs.readFSMetadata(minioMetaBucket, uploadIDPath)
+	if err != nil {
+		return toObjectErr(err, minioMetaBucket, uploadIDPath)
+	}
+	if fsMeta.Meta == nil {
+		fsMeta.Meta = make(map[string]string)
+	}
+	if err = update(fsMeta.Meta); err != nil {
+		return err
+	}
+	tempUploadIDPath := path.Join(tmpMetaPrefix, uploadID)
+	if err = fs.writeFSMetadata(minioMetaBucket, tempUploadIDPath, fsMeta); err != nil {
+		return toObjectErr(err, minioMetaBucket, tempUploadIDPath)
+	}
+	err = fs.storage.RenameFile(minioMetaBucket, path.Join(tempUploadIDPath, fsMetaJSONFile), minioMetaBucket, path.Join(uploadIDPath, fsMetaJSONFile))
+	if err != nil {
+		if dErr := fs.storage.DeleteFile(minioMetaBucket, path.Join(tempUploadIDPath, fsMetaJSONFile)); dErr != nil {
+			return toObjectErr(dErr, minioMetaBucket, tempUploadIDPath)
+		}
+		return toObjectErr(err, minioMetaBucket, uploadIDPath)
+	}
+	return nil
+}
+
 // listObjectParts - wrapper scanning through
 // '.minio/multipart/bucket/object/UPLOADID'. Lists all the parts
 // saved inside '.minio/multipart/bucket/object/UPLOADID'.
@@ -372,12 +370,7 @@ func (fs fsObjects) listObjectParts(bucket, object, uploadID string, partNumberM
 		return ListPartsInfo{}, toObjectErr(err, minioMetaBucket, uploadIDPath)
 	}
 	// Only parts with higher part numbers will be listed.
-	partIdx := fsMeta.ObjectPartIndex(partNumberMarker)
-	parts := fsMeta.Parts
-	if partIdx != -1 {
-		parts = fsMeta.Parts[partIdx+1:]
-	}
-	count := maxParts
+	parts, truncated := listPartsPage(fsMeta.Parts, partNumberMarker, maxParts)
 	for _, part := range parts {
 		var fi FileInfo
 		partNamePath := path.Join(mpartMetaPrefix, bucket, object, uploadID, part.Name)
@@ -391,23 +384,22 @@ func (fs fsObjects) listObjectParts(bucket, object, uploadID string, partNumberM
 			LastModified: fi.ModTime,
 			Size:         fi.Size,
 		})
-		count--
-		if count == 0 {
-			break
-		}
 	}
-	// If listed entries are more than maxParts, we set IsTruncated as true.
-	if len(parts) > len(result.Parts) {
+	// Make sure to fill next part number marker if IsTruncated is
+	// true for subsequent listing.
+	if truncated {
 		result.IsTruncated = true
-		// Make sure to fill next part number marker if IsTruncated is
-		// true for subsequent listing.
-		nextPartNumberMarker := result.Parts[len(result.Parts)-1].PartNumber
-		result.NextPartNumberMarker = nextPartNumberMarker
+		result.NextPartNumberMarker = partNumberMarker
+		if len(parts) > 0 {
+			result.NextPartNumberMarker = parts[len(parts)-1].Number
+		}
 	}
 	result.Bucket = bucket
 	result.Object = object
 	result.UploadID = uploadID
 	result.MaxParts = maxParts
+	result.PartNumberMarker = partNumberMarker
+	result.Metadata = fsMeta.Meta
 	return result, nil
 }
 
@@ -486,8 +478,21 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		return "", err
 	}
 
-	tempObj := path.Join(tmpMetaPrefix, uploadID, "object1")
-	var buffer = make([]byte, blockSizeV1)
+	// Temporary object must not collide with parts being uploaded.
+	tempObj := path.Join(tmpMetaPrefix, uploadID, getUUID())
+	buffer := globalBufferPools.Get(blockSizeV1)
+	defer globalBufferPools.Put(buffer)
+
+	// Object size is verified before parts are copied.
+	var objectSize int64
+	for _, part := range parts {
+		if partIdx := fsMeta.ObjectPartIndex(part.PartNumber); partIdx != -1 {
+			objectSize += fsMeta.Parts[partIdx].Size
+		}
+	}
+	if isMaxObjectSize(objectSize) {
+		return "", ObjectTooLarge{Bucket: bucket, Object: object}
+	}
 
 	// Loop through all parts, validate them and then commit to disk.
 	for i, part := range parts {
@@ -509,14 +514,14 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		totalLeft := fsMeta.Parts[partIdx].Size
 		for totalLeft > 0 {
 			var n int64
-			n, err = fs.storage.ReadFile(minioMetaBucket, multipartPartFile, offset, buffer)
+			n, err = fs.storage.ReadFile(context.Background(), minioMetaBucket, multipartPartFile, offset, buffer)
 			if err != nil {
 				if err == errFileNotFound {
 					return "", InvalidPart{}
 				}
 				return "", toObjectErr(err, minioMetaBucket, multipartPartFile)
 			}
-			if err = fs.storage.AppendFile(minioMetaBucket, tempObj, buffer[:n]); err != nil {
+			if err = fs.storage.AppendFile(context.Background(), minioMetaBucket, tempObj, buffer[:n]); err != nil {
 				return "", toObjectErr(err, minioMetaBucket, tempObj)
 			}
 			offset += n
@@ -524,6 +529,11 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		}
 	}
 
+	// Metadata updates must not interleave with replacing the object
+	// and its metadata.
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
 	// Rename the file back to original location, if not delete the temporary object.
 	err = fs.storage.RenameFile(minioMetaBucket, tempObj, bucket, object)
 	if err != nil {
@@ -533,6 +543,12 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		return "", toObjectErr(err, bucket, object)
 	}
 
+	// Save the metadata of the upload with the s3 compatible md5sum, it
+	// cannot be computed from the object.
+	if err = fs.writeObjectMetadata(bucket, object, newFSObjectMetadata(object, fsMeta.Meta, s3MD5)); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
 	// Cleanup all the parts if everything else has been safely committed.
 	if err = cleanupUploadedParts(bucket, object, uploadID, fs.storage); err != nil {
 		return "", err
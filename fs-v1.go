@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"io"
@@ -31,6 +32,12 @@ import (
 	"github.com/minio/minio/pkg/mimedb"
 )
 
+// fileOpener - storage opening files for reading, implemented by local
+// disks.
+type fileOpener interface {
+	OpenFile(volume string, path string, offset int64) (*os.File, error)
+}
+
 // fsObjects - Implements fs object layer.
 type fsObjects struct {
 	storage            StorageAPI
@@ -39,6 +46,15 @@ type fsObjects struct {
 	listObjectMapMutex *sync.Mutex
 }
 
+func init() {
+	registerObjectLayer(objectLayerFS, func(args []string) (ObjectLayer, error) {
+		if len(args) != 1 {
+			return nil, errInvalidArgument
+		}
+		return newFSObjects(args[0])
+	})
+}
+
 // creates format.json, the FS format info in minioMetaBucket.
 func initFormatFS(storageDisk StorageAPI) error {
 	return writeFSFormatData(storageDisk, newFSFormatV1())
@@ -111,6 +127,11 @@ func (fs fsObjects) StorageInfo() StorageInfo {
 	}
 }
 
+// DisksInfo - returns status of the underlying disk.
+func (fs fsObjects) DisksInfo() []DiskStatus {
+	return []DiskStatus{getDiskStatus(fs.physicalDisk, fs.storage)}
+}
+
 /// Bucket operations
 
 // MakeBucket - make a bucket.
@@ -163,30 +184,47 @@ func (fs fsObjects) ListBuckets() ([]BucketInfo, error) {
 	return bucketInfos, nil
 }
 
-// DeleteBucket - delete a bucket.
+// DeleteBucket - deletes a bucket with its uploads, if it has no
+// objects.
 func (fs fsObjects) DeleteBucket(bucket string) error {
-	// Verify if bucket is valid.
-	if !IsValidBucketName(bucket) {
-		return BucketNameInvalid{Bucket: bucket}
-	}
-	if err := fs.storage.DeleteVol(bucket); err != nil {
-		return toObjectErr(err, bucket)
-	}
-	return nil
+	return fs.deleteBucket(bucket, false)
 }
 
 /// Object Operations
 
 // GetObject - get an object.
-func (fs fsObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+func (fs fsObjects) GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) error {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return BucketNameInvalid{Bucket: bucket}
 	}
+	// Directory markers have no content.
+	if isDirMarker(object) {
+		_, err := fs.getDirMarkerInfo(bucket, object)
+		return err
+	}
 	// Verify if object is valid.
 	if !IsValidObjectName(object) {
 		return ObjectNameInvalid{Bucket: bucket, Object: object}
 	}
+	// Local disks copy the file directly to the writer, HTTP responses
+	// send it with sendfile without copying through user space.
+	if opener, ok := fs.storage.(fileOpener); ok && length > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file, err := opener.OpenFile(bucket, object, startOffset)
+		if err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		defer file.Close()
+		if _, err = copyN(writer, file, length); err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		return nil
+	}
+	buf := globalBufferPools.Get(blockSizeV1)
+	defer globalBufferPools.Put(buf)
 	var totalLeft = length
 	for totalLeft > 0 {
 		// Figure out the right blockSize as it was encoded before.
@@ -196,8 +234,7 @@ func (fs fsObjects) GetObject(bucket, object string, startOffset int64, length i
 		} else {
 			curBlockSize = totalLeft
 		}
-		buf := make([]byte, curBlockSize)
-		n, err := fs.storage.ReadFile(bucket, object, startOffset, buf)
+		n, err := fs.storage.ReadFile(ctx, bucket, object, startOffset, buf[:curBlockSize])
 		if err != nil {
 			return toObjectErr(err, bucket, object)
 		}
@@ -217,6 +254,9 @@ func (fs fsObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
 	if !IsValidBucketName(bucket) {
 		return ObjectInfo{}, (BucketNameInvalid{Bucket: bucket})
 	}
+	if isDirMarker(object) {
+		return fs.getDirMarkerInfo(bucket, object)
+	}
 	// Verify if object is valid.
 	if !IsValidObjectName(object) {
 		return ObjectInfo{}, (ObjectNameInvalid{Bucket: bucket, Object: object})
@@ -225,32 +265,61 @@ func (fs fsObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
 	}
+	meta, err := fs.readObjectMetadata(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+	objInfo := newFSObjectInfo(bucket, object, fi, meta)
+	objInfo.IsDir = fi.Mode.IsDir()
+	return objInfo, nil
+}
 
-	// Guess content-type from the extension if possible.
-	contentType := ""
+// guessContentType - returns the content-type of the extension of an
+// object, empty if it is not known.
+func guessContentType(object string) string {
 	if objectExt := filepath.Ext(object); objectExt != "" {
 		if content, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]; ok {
-			contentType = content.ContentType
+			return content.ContentType
 		}
 	}
+	return ""
+}
 
+// newFSObjectInfo - returns the info of an object file with the
+// metadata saved for it in fs.json. Objects written before metadata was
+// saved have their content-type guessed from the extension.
+func newFSObjectInfo(bucket, object string, fi FileInfo, meta map[string]string) ObjectInfo {
+	contentType := meta["content-type"]
+	if contentType == "" {
+		contentType = guessContentType(object)
+	}
+	md5Sum := meta["md5Sum"]
+	if md5Sum == "" && fi.Size == 0 {
+		// Zero byte objects without metadata have the md5 of the
+		// empty content, like directory markers.
+		md5Sum = dirMarkerMD5Sum
+	}
 	return ObjectInfo{
-		Bucket:      bucket,
-		Name:        object,
-		ModTime:     fi.ModTime,
-		Size:        fi.Size,
-		IsDir:       fi.Mode.IsDir(),
-		ContentType: contentType,
-		MD5Sum:      "", // Read from metadata.
-	}, nil
+		Bucket:          bucket,
+		Name:            object,
+		ModTime:         fi.ModTime,
+		Size:            fi.Size,
+		MD5Sum:          md5Sum,
+		ContentType:     contentType,
+		ContentEncoding: meta["content-encoding"],
+		Metadata:        meta,
+	}
 }
 
 // PutObject - create an object.
-func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+func (fs fsObjects) PutObject(ctx context.Context, bucket string, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
 	}
+	if isDirMarker(object) {
+		return fs.putDirMarker(bucket, object, size)
+	}
 	if !IsValidObjectName(object) {
 		return "", ObjectNameInvalid{
 			Bucket: bucket,
@@ -270,25 +339,34 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 
 	if size == 0 {
 		// For size 0 we write a 0byte file.
-		err := fs.storage.AppendFile(minioMetaBucket, tempObj, []byte(""))
+		err := fs.storage.AppendFile(ctx, minioMetaBucket, tempObj, []byte(""))
 		if err != nil {
 			return "", toObjectErr(err, bucket, object)
 		}
 	} else {
+		// Preallocate the temporary file if the size is known.
+		if size > 0 {
+			if err := fs.storage.PrepareFile(minioMetaBucket, tempObj, size); err != nil {
+				return "", toObjectErr(err, bucket, object)
+			}
+		}
 		// Allocate a buffer to Read() the object upload stream.
-		buf := make([]byte, blockSizeV1)
+		buf := globalBufferPools.Get(blockSizeV1)
+		defer globalBufferPools.Put(buf)
 		// Read the buffer till io.EOF and append the read data to
 		// the temporary file.
 		for {
 			n, rErr := data.Read(buf)
 			if rErr != nil && rErr != io.EOF {
+				fs.storage.DeleteFile(minioMetaBucket, tempObj)
 				return "", toObjectErr(rErr, bucket, object)
 			}
 			if n > 0 {
 				// Update md5 writer.
 				md5Writer.Write(buf[:n])
-				wErr := fs.storage.AppendFile(minioMetaBucket, tempObj, buf[:n])
+				wErr := fs.storage.AppendFile(ctx, minioMetaBucket, tempObj, buf[:n])
 				if wErr != nil {
+					fs.storage.DeleteFile(minioMetaBucket, tempObj)
 					return "", toObjectErr(wErr, bucket, object)
 				}
 			}
@@ -306,10 +384,17 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 	}
 	if md5Hex != "" {
 		if newMD5Hex != md5Hex {
+			// MD5 mismatch, delete the temporary object.
+			fs.storage.DeleteFile(minioMetaBucket, tempObj)
 			return "", BadDigest{md5Hex, newMD5Hex}
 		}
 	}
 
+	// Metadata updates must not interleave with replacing the object
+	// and its metadata.
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
 	// Entire object was written to the temp location, now it's safe to rename it
 	// to the actual location.
 	err := fs.storage.RenameFile(minioMetaBucket, tempObj, bucket, object)
@@ -317,21 +402,161 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 		return "", toObjectErr(err, bucket, object)
 	}
 
+	// Objects carry the modification time of their file.
+	if modTime, ok := getObjectModTime(metadata); ok {
+		if err = os.Chtimes(filepath.Join(fs.physicalDisk, bucket, object), modTime, modTime); err != nil {
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+
+	// Save the metadata like XL does in xl.json, replacing metadata
+	// saved for a previous object.
+	if err = fs.writeObjectMetadata(bucket, object, newFSObjectMetadata(object, metadata, newMD5Hex)); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
 	// Return md5sum, successfully wrote object.
 	return newMD5Hex, nil
 }
 
+// UpdateObjectMetadata - saves the metadata of an object changed by
+// update in its fs.json.
+func (fs fsObjects) UpdateObjectMetadata(bucket, object string, update func(metadata map[string]string) error) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	if _, err := fs.storage.StatFile(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	meta, err := fs.readObjectMetadata(bucket, object)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	if err = update(meta); err != nil {
+		return err
+	}
+	if err = fs.writeObjectMetadata(bucket, object, meta); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// AppendObject - appends data to an existing object. Data is written
+// to a temporary file first and appended to the object file only once
+// it was read completely, ctx only cancels the write of the temporary
+// file.
+func (fs fsObjects) AppendObject(ctx context.Context, bucket, object string, size int64, data io.Reader, md5Hex string) (string, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	if _, err := fs.storage.StatFile(bucket, object); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	defer fs.storage.DeleteFile(minioMetaBucket, tempObj)
+
+	// Initialize md5 writer.
+	md5Writer := md5.New()
+
+	// Allocate a buffer to Read() the appended data.
+	buf := globalBufferPools.Get(blockSizeV1)
+	defer globalBufferPools.Put(buf)
+	var tempSize int64
+	for {
+		n, rErr := data.Read(buf)
+		if rErr != nil && rErr != io.EOF {
+			return "", toObjectErr(rErr, bucket, object)
+		}
+		if n > 0 {
+			md5Writer.Write(buf[:n])
+			if wErr := fs.storage.AppendFile(ctx, minioMetaBucket, tempObj, buf[:n]); wErr != nil {
+				return "", toObjectErr(wErr, bucket, object)
+			}
+			tempSize += int64(n)
+		}
+		if rErr == io.EOF {
+			break
+		}
+	}
+	if size >= 0 && tempSize != size {
+		return "", IncompleteBody{}
+	}
+	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
+	if md5Hex != "" && newMD5Hex != md5Hex {
+		return "", BadDigest{md5Hex, newMD5Hex}
+	}
+
+	meta, err := fs.readObjectMetadata(bucket, object)
+	if err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	objectMD5Hex, err := getAppendedMD5(meta["md5Sum"], newMD5Hex)
+	if err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Append the temporary file to the object, not canceled half way
+	// to keep the object consistent.
+	for offset := int64(0); offset < tempSize; {
+		n, rErr := fs.storage.ReadFile(context.Background(), minioMetaBucket, tempObj, offset, buf)
+		if n > 0 {
+			if wErr := fs.storage.AppendFile(context.Background(), bucket, object, buf[:n]); wErr != nil {
+				return "", toObjectErr(wErr, bucket, object)
+			}
+			offset += n
+		}
+		if rErr == io.EOF {
+			break
+		}
+		if rErr != nil {
+			return "", toObjectErr(rErr, bucket, object)
+		}
+	}
+
+	meta["md5Sum"] = objectMD5Hex
+	if err = fs.writeObjectMetadata(bucket, object, meta); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+	return objectMD5Hex, nil
+}
+
 func (fs fsObjects) DeleteObject(bucket, object string) error {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return BucketNameInvalid{Bucket: bucket}
 	}
+	if isDirMarker(object) {
+		return fs.deleteDirMarker(bucket, object)
+	}
 	if !IsValidObjectName(object) {
 		return ObjectNameInvalid{Bucket: bucket, Object: object}
 	}
 	if err := fs.storage.DeleteFile(bucket, object); err != nil {
 		return toObjectErr(err, bucket, object)
 	}
+	if err := fs.deleteObjectMetadata(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
 	return nil
 }
 
@@ -377,12 +602,6 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 	if !IsValidObjectPrefix(prefix) {
 		return ListObjectsInfo{}, ObjectNameInvalid{Bucket: bucket, Object: prefix}
 	}
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != slashSeparator {
-		return ListObjectsInfo{}, UnsupportedDelimiter{
-			Delimiter: delimiter,
-		}
-	}
 	// Verify if marker has prefix.
 	if marker != "" {
 		if !strings.HasPrefix(marker, prefix) {
@@ -412,21 +631,29 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 		maxKeys = maxObjectList
 	}
 
+	// Other delimiters than '/' are rolled up from recursive listings.
+	if !isWalkDelimiter(delimiter) {
+		return listObjectsDelimited(fs.ListObjects, bucket, prefix, marker, delimiter, maxKeys)
+	}
+
 	// Default is recursive, if delimiter is set then list non recursive.
 	recursive := true
 	if delimiter == slashSeparator {
 		recursive = false
 	}
 
-	walker := fs.lookupTreeWalk(listParams{bucket, recursive, marker, prefix})
+	isLeaf := func(bucket, object string) bool {
+		return !strings.HasSuffix(object, slashSeparator)
+	}
+	// Continue the walk of the previous listing if there is one.
+	walker, skipMarker := fs.lookupNearestTreeWalk(listParams{bucket, recursive, marker, prefix})
 	if walker == nil {
-		walker = fs.startTreeWalk(bucket, prefix, marker, recursive, func(bucket, object string) bool {
-			return !strings.HasSuffix(object, slashSeparator)
-		})
+		walker = fs.startTreeWalk(bucket, prefix, marker, recursive, isLeaf)
 	}
 	var fileInfos []FileInfo
 	var eof bool
 	var nextMarker string
+	var skipped int
 	for i := 0; i < maxKeys; {
 		walkResult, ok := <-walker.ch
 		if !ok {
@@ -442,6 +669,20 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 			}
 			return ListObjectsInfo{}, toObjectErr(walkResult.err, bucket, prefix)
 		}
+		// A walk saved at an earlier marker skips up to the marker,
+		// the abandoned walker times out by itself.
+		if skipMarker != "" && walkResult.entry <= skipMarker {
+			if walkResult.end {
+				eof = true
+				break
+			}
+			if skipped++; skipped > maxWalkSkip {
+				walker = fs.startTreeWalk(bucket, prefix, marker, recursive, isLeaf)
+				skipMarker = ""
+			}
+			continue
+		}
+		skipMarker = ""
 		fileInfo, err := entryToFileInfo(walkResult.entry)
 		if err != nil {
 			return ListObjectsInfo{}, nil
@@ -455,7 +696,9 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 		i++
 	}
 	params := listParams{bucket, recursive, nextMarker, prefix}
-	if !eof {
+	// A saved walk misses objects written to directories it already
+	// listed, the S3A mode walks afresh for every page.
+	if !eof && !isS3ACompat() {
 		fs.saveTreeWalk(params, walker)
 	}
 
@@ -464,17 +707,30 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 		// With delimiter set we fill in NextMarker and Prefixes.
 		if delimiter == slashSeparator {
 			result.NextMarker = fileInfo.Name
-			if fileInfo.Mode.IsDir() {
+			if fileInfo.Mode.IsDir() && fileInfo.Name != prefix {
 				result.Prefixes = append(result.Prefixes, fileInfo.Name)
 				continue
 			}
 		}
-		result.Objects = append(result.Objects, ObjectInfo{
-			Name:    fileInfo.Name,
-			ModTime: fileInfo.ModTime,
-			Size:    fileInfo.Size,
-			IsDir:   false,
-		})
+		// Directories listed otherwise are directory markers.
+		if fileInfo.Mode.IsDir() {
+			objInfo, err := fs.getDirMarkerInfo(bucket, fileInfo.Name)
+			if err != nil {
+				// Skip directories which are no longer empty.
+				if _, ok := err.(ObjectNotFound); ok {
+					continue
+				}
+				return ListObjectsInfo{}, err
+			}
+			result.Objects = append(result.Objects, objInfo)
+			continue
+		}
+		meta, err := fs.readObjectMetadata(bucket, fileInfo.Name)
+		if err != nil {
+			return ListObjectsInfo{}, toObjectErr(err, bucket, fileInfo.Name)
+		}
+		objInfo := newFSObjectInfo(bucket, fileInfo.Name, fileInfo, meta)
+		result.Objects = append(result.Objects, objInfo)
 	}
 	return result, nil
 }
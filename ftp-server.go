@@ -0,0 +1,518 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Time a control connection may stay idle between two commands.
+	ftpIdleTimeout = 5 * time.Minute
+
+	// Time a client is given to open a passive data connection.
+	ftpDataTimeout = 30 * time.Second
+)
+
+var errFTPNoDataConn = errors.New("Use PASV or EPSV first")
+
+var errFTPDataConnPeer = errors.New("Data connection from a different host")
+
+// ftpServer - FTP front-end to an object layer. Users log in with the
+// access and secret key of the server, buckets are the top level
+// directories and prefixes the directories below.
+type ftpServer struct {
+	objAPI ObjectLayer
+	// Enables FTPS through AUTH TLS, nil if no certificates are configured.
+	tlsConfig *tls.Config
+}
+
+// newFTPServer - returns a new FTP server for objAPI.
+func newFTPServer(objAPI ObjectLayer, tlsConfig *tls.Config) *ftpServer {
+	return &ftpServer{
+		objAPI:    objAPI,
+		tlsConfig: tlsConfig,
+	}
+}
+
+// Serve - serves FTP clients accepted on listener until it is closed.
+func (s *ftpServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// ftpConn - state of a control connection.
+type ftpConn struct {
+	server *ftpServer
+	conn   net.Conn
+	reader *bufio.Reader
+
+	user     string
+	loggedIn bool
+	cwd      string
+	// Data connections are encrypted after PROT P.
+	protected bool
+	// Listener of the next passive data connection.
+	dataListener net.Listener
+	// Offset the next RETR starts at, set by REST.
+	restOffset int64
+}
+
+// serveConn - serves commands of a control connection until QUIT.
+func (s *ftpServer) serveConn(conn net.Conn) {
+	c := &ftpConn{
+		server: s,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		cwd:    "/",
+	}
+	defer func() {
+		if c.dataListener != nil {
+			c.dataListener.Close()
+		}
+		c.conn.Close()
+	}()
+
+	c.reply(220, "Minio FTP server ready.")
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(ftpIdleTimeout))
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg := line, ""
+		if index := strings.IndexByte(line, ' '); index != -1 {
+			cmd, arg = line[:index], line[index+1:]
+		}
+		cmd = strings.ToUpper(strings.TrimSpace(cmd))
+		arg = strings.TrimRight(arg, "\r\n")
+		if cmd == "QUIT" {
+			c.reply(221, "Goodbye.")
+			return
+		}
+		c.handle(cmd, arg)
+	}
+}
+
+// reply - writes a single line reply.
+func (c *ftpConn) reply(code int, msg string) {
+	fmt.Fprintf(c.conn, "%d %s\r\n", code, msg)
+}
+
+// replyError - replies with a permanent error for err.
+func (c *ftpConn) replyError(err error) {
+	c.reply(550, err.Error())
+}
+
+// ftpLoginCommands - commands accepted before login.
+var ftpLoginCommands = map[string]struct{}{
+	"USER": {}, "PASS": {}, "AUTH": {}, "PBSZ": {}, "PROT": {},
+	"FEAT": {}, "SYST": {}, "NOOP": {}, "OPTS": {},
+}
+
+// handle - executes a single command.
+func (c *ftpConn) handle(cmd, arg string) {
+	if _, ok := ftpLoginCommands[cmd]; !ok && !c.loggedIn {
+		c.reply(530, "Please login with USER and PASS.")
+		return
+	}
+	switch cmd {
+	case "USER":
+		c.user, c.loggedIn = arg, false
+		c.reply(331, "User name okay, need password.")
+	case "PASS":
+		if !initJWT().Authenticate(c.user, arg) {
+			c.reply(530, "Login incorrect.")
+			return
+		}
+		c.loggedIn = true
+		c.reply(230, "User logged in.")
+	case "AUTH":
+		c.handleAUTH(arg)
+	case "PBSZ":
+		c.reply(200, "PBSZ=0")
+	case "PROT":
+		switch strings.ToUpper(arg) {
+		case "C":
+			c.protected = false
+		case "P":
+			if _, ok := c.conn.(*tls.Conn); !ok {
+				c.reply(503, "Use AUTH TLS first.")
+				return
+			}
+			c.protected = true
+		default:
+			c.reply(504, "Protection level not supported.")
+			return
+		}
+		c.reply(200, "Protection level set.")
+	case "FEAT":
+		features := []string{"EPSV", "PASV", "SIZE", "MDTM", "REST STREAM", "UTF8"}
+		if c.server.tlsConfig != nil {
+			features = append(features, "AUTH TLS", "PBSZ", "PROT")
+		}
+		fmt.Fprintf(c.conn, "211-Features:\r\n %s\r\n211 End\r\n", strings.Join(features, "\r\n "))
+	case "SYST":
+		c.reply(215, "UNIX Type: L8")
+	case "NOOP":
+		c.reply(200, "OK.")
+	case "OPTS":
+		if strings.ToUpper(arg) == "UTF8 ON" {
+			c.reply(200, "UTF8 enabled.")
+			return
+		}
+		c.reply(501, "Option not supported.")
+	case "TYPE", "MODE", "STRU":
+		// Data is always transferred as is.
+		c.reply(200, "OK.")
+	case "PWD", "XPWD":
+		c.reply(257, strconv.Quote(c.cwd)+" is the current directory.")
+	case "CWD", "XCWD":
+		c.handleCWD(arg)
+	case "CDUP", "XCUP":
+		c.handleCWD("..")
+	case "PASV":
+		c.handlePASV(false)
+	case "EPSV":
+		c.handlePASV(true)
+	case "LIST", "NLST":
+		c.handleLIST(arg, cmd == "NLST")
+	case "REST":
+		offset, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || offset < 0 {
+			c.reply(501, "Invalid offset.")
+			return
+		}
+		c.restOffset = offset
+		c.reply(350, "Restarting at "+arg+".")
+	case "RETR":
+		c.handleRETR(arg)
+	case "STOR":
+		c.handleSTOR(arg)
+	case "DELE":
+		c.handleDELE(arg)
+	case "MKD", "XMKD":
+		c.handleMKD(arg)
+	case "RMD", "XRMD":
+		c.handleRMD(arg)
+	case "SIZE", "MDTM":
+		c.handleStat(arg, cmd == "SIZE")
+	default:
+		c.reply(502, "Command not implemented.")
+	}
+}
+
+// handleAUTH - upgrades the control connection to TLS.
+func (c *ftpConn) handleAUTH(arg string) {
+	if c.server.tlsConfig == nil || strings.ToUpper(arg) != "TLS" {
+		c.reply(504, "AUTH type not supported.")
+		return
+	}
+	if _, ok := c.conn.(*tls.Conn); ok {
+		c.reply(503, "Already using TLS.")
+		return
+	}
+	c.reply(234, "AUTH TLS successful.")
+	tlsConn := tls.Server(c.conn, c.server.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		// Connection is unusable, the next read fails.
+		c.conn.Close()
+		return
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+}
+
+// resolve - returns bucket and object of an FTP path relative to the
+// current directory.
+func (c *ftpConn) resolve(arg string) (bucket, object string) {
+	return resolveFilePath(c.cwd, arg)
+}
+
+// isDir - returns true if bucket exists and has objects under the
+// directory prefix.
+func (c *ftpConn) isDir(bucket, prefix string) bool {
+	return isFileDir(c.server.objAPI, bucket, prefix)
+}
+
+// handleCWD - changes the current directory.
+func (c *ftpConn) handleCWD(arg string) {
+	bucket, prefix := c.resolve(arg)
+	if !c.isDir(bucket, prefix) {
+		c.reply(550, "No such directory.")
+		return
+	}
+	c.cwd = path.Join(slashSeparator, bucket, prefix)
+	c.reply(250, "Directory changed to "+c.cwd+".")
+}
+
+// handlePASV - opens a listener for the next data connection on the
+// address the client connected to.
+func (c *ftpConn) handlePASV(extended bool) {
+	if c.dataListener != nil {
+		c.dataListener.Close()
+		c.dataListener = nil
+	}
+	host, _, err := net.SplitHostPort(c.conn.LocalAddr().String())
+	if err != nil {
+		c.reply(425, err.Error())
+		return
+	}
+	ip := net.ParseIP(host).To4()
+	if !extended && ip == nil {
+		c.reply(522, "Use EPSV for IPv6 connections.")
+		return
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		c.reply(425, err.Error())
+		return
+	}
+	c.dataListener = listener
+	port := listener.Addr().(*net.TCPAddr).Port
+	if extended {
+		c.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|).", port))
+		return
+	}
+	c.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d).", ip[0], ip[1], ip[2], ip[3], port>>8, port&0xff))
+}
+
+// openDataConn - accepts the data connection of a transfer, only
+// connections from the host of the control connection are accepted.
+func (c *ftpConn) openDataConn() (net.Conn, error) {
+	if c.dataListener == nil {
+		return nil, errFTPNoDataConn
+	}
+	listener := c.dataListener
+	c.dataListener = nil
+	defer listener.Close()
+
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(ftpDataTimeout))
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	controlHost, _, _ := net.SplitHostPort(c.conn.RemoteAddr().String())
+	dataHost, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	if controlHost != dataHost {
+		conn.Close()
+		return nil, errFTPDataConnPeer
+	}
+	if c.protected {
+		tlsConn := tls.Server(conn, c.server.tlsConfig)
+		if err = tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return conn, nil
+}
+
+// transfer - runs fn on a new data connection with the usual replies.
+func (c *ftpConn) transfer(fn func(conn net.Conn) error) {
+	c.reply(150, "Opening data connection.")
+	conn, err := c.openDataConn()
+	if err != nil {
+		c.reply(425, err.Error())
+		return
+	}
+	err = fn(conn)
+	if cErr := conn.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		c.reply(426, err.Error())
+		return
+	}
+	c.reply(226, "Transfer complete.")
+}
+
+// formatFTPEntry - formats a listing entry like 'ls -l' does.
+func formatFTPEntry(e dirEntry) string {
+	mode := "-rw-r--r--"
+	if e.isDir {
+		mode = "drwxr-xr-x"
+	}
+	modTime := e.modTime.Format("Jan _2 15:04")
+	if time.Since(e.modTime) > 180*24*time.Hour {
+		modTime = e.modTime.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s 1 minio minio %12d %s %s", mode, e.size, modTime, e.name)
+}
+
+// handleLIST - sends a directory listing, NLST sends names only.
+func (c *ftpConn) handleLIST(arg string, namesOnly bool) {
+	// Options like '-la' are not supported and ignored.
+	if strings.HasPrefix(arg, "-") {
+		options := arg
+		arg = ""
+		if index := strings.IndexByte(options, ' '); index != -1 {
+			arg = options[index+1:]
+		}
+	}
+	bucket, object := c.resolve(arg)
+	var entries []dirEntry
+	if c.isDir(bucket, object) {
+		var err error
+		if entries, err = listDirEntries(c.server.objAPI, bucket, object); err != nil {
+			c.replyError(err)
+			return
+		}
+	} else {
+		objInfo, err := getFileInfo(c.server.objAPI, bucket, object)
+		if err != nil {
+			c.replyError(err)
+			return
+		}
+		entries = append(entries, dirEntry{name: path.Base(object), size: objInfo.Size, modTime: objInfo.ModTime})
+	}
+	c.transfer(func(conn net.Conn) error {
+		writer := bufio.NewWriter(conn)
+		for _, entry := range entries {
+			if namesOnly {
+				fmt.Fprintf(writer, "%s\r\n", entry.name)
+			} else {
+				fmt.Fprintf(writer, "%s\r\n", formatFTPEntry(entry))
+			}
+		}
+		return writer.Flush()
+	})
+}
+
+// handleStat - replies with the size or modification time of a file.
+func (c *ftpConn) handleStat(arg string, size bool) {
+	bucket, object := c.resolve(arg)
+	objInfo, err := getFileInfo(c.server.objAPI, bucket, object)
+	if err != nil {
+		c.replyError(err)
+		return
+	}
+	if size {
+		c.reply(213, strconv.FormatInt(objInfo.Size, 10))
+		return
+	}
+	c.reply(213, objInfo.ModTime.UTC().Format("20060102150405"))
+}
+
+// handleRETR - sends a file starting at the offset set by REST.
+func (c *ftpConn) handleRETR(arg string) {
+	offset := c.restOffset
+	c.restOffset = 0
+
+	objAPI := c.server.objAPI
+	bucket, object := c.resolve(arg)
+	if bucket == "" || object == "" || isReservedBucket(bucket) {
+		c.replyError(ObjectNotFound{Bucket: bucket, Object: object})
+		return
+	}
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		c.replyError(err)
+		return
+	}
+	plainInfo, err := getPlainObjectInfo(bucket, objInfo)
+	if err != nil {
+		c.replyError(err)
+		return
+	}
+	if offset > plainInfo.Size {
+		c.reply(554, "Offset beyond the end of the file.")
+		return
+	}
+	c.transfer(func(conn net.Conn) error {
+		return getObjectContentRange(context.Background(), objAPI, bucket, &objInfo, offset, -1, conn)
+	})
+}
+
+// handleSTOR - creates an object from the uploaded file, encrypted and
+// compressed like uploads through the S3 API are.
+func (c *ftpConn) handleSTOR(arg string) {
+	bucket, object := c.resolve(arg)
+	put, err := newFilePut(c.server.objAPI, bucket, object)
+	if err != nil {
+		c.replyError(err)
+		return
+	}
+	c.transfer(func(conn net.Conn) error {
+		return put.put(conn)
+	})
+}
+
+// handleDELE - deletes a file, moved to the trash if configured.
+func (c *ftpConn) handleDELE(arg string) {
+	bucket, object := c.resolve(arg)
+	if err := checkFileWrite(c.server.objAPI, bucket, object); err != nil {
+		c.replyError(err)
+		return
+	}
+	if err := deleteObjectOrTrash(c.server.objAPI, bucket, object); err != nil {
+		c.replyError(err)
+		return
+	}
+	c.reply(250, "File deleted.")
+}
+
+// handleMKD - creates a bucket at the top level, directories below are
+// prefixes which exist as soon as a file is stored in them.
+func (c *ftpConn) handleMKD(arg string) {
+	bucket, prefix := c.resolve(arg)
+	if err := checkDirWrite(bucket); err != nil {
+		c.replyError(err)
+		return
+	}
+	if prefix == "" {
+		if err := c.server.objAPI.MakeBucket(bucket); err != nil {
+			c.replyError(err)
+			return
+		}
+	}
+	c.reply(257, strconv.Quote(path.Join(slashSeparator, bucket, prefix))+" created.")
+}
+
+// handleRMD - removes an empty bucket, directories below vanish with
+// their last file.
+func (c *ftpConn) handleRMD(arg string) {
+	bucket, prefix := c.resolve(arg)
+	if err := checkDirWrite(bucket); err != nil {
+		c.replyError(err)
+		return
+	}
+	if prefix == "" {
+		if err := c.server.objAPI.DeleteBucket(bucket); err != nil {
+			c.replyError(err)
+			return
+		}
+	} else if c.isDir(bucket, prefix) {
+		c.reply(550, "Directory not empty.")
+		return
+	}
+	c.reply(250, "Directory removed.")
+}
@@ -0,0 +1,166 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// ftpTestClient - minimal FTP client driving the server in tests.
+type ftpTestClient struct {
+	t    *testing.T
+	conn *textproto.Conn
+}
+
+// cmd - sends a command and checks the reply code.
+func (c *ftpTestClient) cmd(expectCode int, format string, args ...interface{}) string {
+	id, err := c.conn.Cmd(format, args...)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.conn.StartResponse(id)
+	defer c.conn.EndResponse(id)
+	_, msg, err := c.conn.ReadResponse(expectCode)
+	if err != nil {
+		c.t.Fatalf("%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return msg
+}
+
+// dataConn - opens a passive data connection.
+func (c *ftpTestClient) dataConn() net.Conn {
+	msg := c.cmd(229, "EPSV")
+	var port int
+	if _, err := fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port); err != nil {
+		c.t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return conn
+}
+
+// store - uploads data to name.
+func (c *ftpTestClient) store(name string, data []byte) {
+	conn := c.dataConn()
+	c.cmd(150, "STOR %s", name)
+	if _, err := conn.Write(data); err != nil {
+		c.t.Fatal(err)
+	}
+	conn.Close()
+	if _, _, err := c.conn.ReadResponse(226); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read - downloads the reply of a transfer command.
+func (c *ftpTestClient) read(format string, args ...interface{}) []byte {
+	conn := c.dataConn()
+	defer conn.Close()
+	c.cmd(150, format, args...)
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, _, err = c.conn.ReadResponse(226); err != nil {
+		c.t.Fatal(err)
+	}
+	return data
+}
+
+// Tests a session of an FTP client uploading, listing, downloading and
+// deleting files.
+func TestFTPServer(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go newFTPServer(objAPI, nil).Serve(listener)
+
+	conn, err := textproto.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &ftpTestClient{t: t, conn: conn}
+	if _, _, err = conn.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+
+	// Commands require a login.
+	c.cmd(530, "PWD")
+	c.cmd(331, "USER %s", testServer.AccessKey)
+	c.cmd(530, "PASS invalid-secret")
+	c.cmd(331, "USER %s", testServer.AccessKey)
+	c.cmd(230, "PASS %s", testServer.SecretKey)
+
+	c.cmd(257, "MKD bucket")
+	c.cmd(550, "CWD missing")
+	c.cmd(250, "CWD bucket")
+	c.cmd(257, "PWD")
+
+	data := []byte("scanned document")
+	c.store("dir/scan.pdf", data)
+	c.store("/bucket/top.txt", []byte("top"))
+
+	if names := string(c.read("NLST")); names != "top.txt\r\ndir\r\n" {
+		t.Fatalf("Unexpected names %q", names)
+	}
+	if listing := string(c.read("LIST dir")); !strings.HasPrefix(listing, "-rw-r--r--") || !strings.HasSuffix(listing, " scan.pdf\r\n") {
+		t.Fatalf("Unexpected listing %q", listing)
+	}
+	if listing := string(c.read("LIST /")); !strings.HasPrefix(listing, "drwxr-xr-x") || !strings.HasSuffix(listing, " bucket\r\n") {
+		t.Fatalf("Unexpected listing %q", listing)
+	}
+
+	c.cmd(250, "CWD dir")
+	if size := c.cmd(213, "SIZE scan.pdf"); size != fmt.Sprint(len(data)) {
+		t.Fatalf("Expected size %d, got %s", len(data), size)
+	}
+	if got := c.read("RETR scan.pdf"); !bytes.Equal(got, data) {
+		t.Fatalf("Expected %q, got %q", data, got)
+	}
+	c.cmd(350, "REST 8")
+	if got := c.read("RETR scan.pdf"); !bytes.Equal(got, data[8:]) {
+		t.Fatalf("Expected %q, got %q", data[8:], got)
+	}
+
+	c.cmd(250, "CDUP")
+	c.cmd(550, "RMD dir")
+	c.cmd(250, "DELE dir/scan.pdf")
+	c.cmd(550, "SIZE dir/scan.pdf")
+	c.cmd(250, "DELE top.txt")
+	c.cmd(250, "RMD /bucket")
+	if _, err = objAPI.GetBucketInfo("bucket"); err == nil {
+		t.Fatal("Expected bucket to be removed")
+	}
+	c.cmd(221, "QUIT")
+}
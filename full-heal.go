@@ -0,0 +1,370 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Full heal state in minioMetaBucket.
+const fullHealStateFile = "full-heal.json"
+
+// Number of objects listed at once by a full heal.
+const fullHealListSize = 1000
+
+// Full heal statuses.
+const (
+	fullHealRunning  = "running"
+	fullHealPaused   = "paused"
+	fullHealFinished = "finished"
+	fullHealFailed   = "failed"
+)
+
+// Interval the progress of a running full heal is saved at.
+var fullHealSaveInterval = 10 * time.Second
+
+var (
+	// errFullHealRunning - full heal cannot be started, one is running.
+	errFullHealRunning = errors.New("Full heal already running")
+
+	// errFullHealNotRunning - full heal cannot be paused or resumed.
+	errFullHealNotRunning = errors.New("No full heal running, paused or failed")
+
+	// errFullHealStopped - full heal was paused or started over.
+	errFullHealStopped = errors.New("Full heal stopped")
+
+	// errFullHealMaintenance - full heal was paused as healing writes,
+	// which are rejected during maintenance.
+	errFullHealMaintenance = errors.New("Full heal paused for maintenance")
+)
+
+// backendHealer - implemented by object layers which can be healed as
+// a whole.
+type backendHealer interface {
+	objectHealer
+	// HealFormat - restores `format.json` of replaced disks, fails
+	// with errSomeDiskOffline if not all disks are online.
+	HealFormat() error
+	// ListBucketsHeal - lists the buckets of all disks, including the
+	// ones replaced disks miss.
+	ListBucketsHeal() ([]BucketInfo, error)
+	// HealBucket - creates the bucket on the disks which miss it,
+	// fails with errSomeDiskOffline if not all disks are online.
+	HealBucket(bucket string) error
+}
+
+// fullHealProgress - position and counters of a full heal, objects are
+// healed bucket by bucket in the order they are listed in.
+type fullHealProgress struct {
+	Bucket string `json:"bucket,omitempty"`
+	// Last object of Bucket which was healed.
+	Marker  string `json:"marker,omitempty"`
+	Buckets int64  `json:"buckets"`
+	Scanned int64  `json:"scanned"`
+	Healed  int64  `json:"healed"`
+	Failed  int64  `json:"failed"`
+	// Bytes of the healed objects.
+	Bytes int64 `json:"bytes"`
+	// Result of Bucket so far, saved to the heal history once all its
+	// objects were healed.
+	BucketResult healBucketResult `json:"bucketResult"`
+}
+
+// fullHealStatus - state of the background full heal.
+type fullHealStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Bytes healed per second, unlimited if 0.
+	Bandwidth int64            `json:"bandwidth"`
+	Started   time.Time        `json:"started"`
+	Updated   time.Time        `json:"updated"`
+	Progress  fullHealProgress `json:"progress"`
+}
+
+// fullHealer - heals the format, the buckets and all objects of the
+// backend in the background, e.g. after a disk was replaced.
+type fullHealer struct {
+	mutex  sync.Mutex
+	objAPI ObjectLayer
+	healer backendHealer
+	status fullHealStatus
+	// Increased whenever a heal goroutine is started, older goroutines
+	// stop on their next object.
+	run   int
+	saved time.Time
+	// Heal goroutines which did not return yet, including their last
+	// save.
+	running sync.WaitGroup
+	// Serializes saves so that the last state marshaled is saved last.
+	saveMutex sync.Mutex
+}
+
+// globalFullHealer - heals the whole backend, nil for object layers
+// which cannot heal.
+var globalFullHealer *fullHealer
+
+// newFullHealer - loads the saved full heal state of objAPI and
+// continues a full heal which was running when the server stopped.
+// Returns nil if objAPI cannot heal.
+func newFullHealer(objAPI ObjectLayer) (*fullHealer, error) {
+	healer, ok := objAPI.(backendHealer)
+	if !ok {
+		return nil, nil
+	}
+	h := &fullHealer{objAPI: objAPI, healer: healer}
+	data, err := loadMetaFile(objAPI, fullHealStateFile)
+	if err == errFileNotFound {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &h.status); err != nil {
+		return nil, err
+	}
+	if h.status.Status == fullHealRunning {
+		h.mutex.Lock()
+		h.startLocked()
+		h.mutex.Unlock()
+	}
+	return h, nil
+}
+
+// Start - starts a full heal from the first bucket, healing at most
+// bandwidth bytes per second if bandwidth is not 0. A paused or failed
+// full heal is started over.
+func (h *fullHealer) Start(bandwidth int64) error {
+	h.mutex.Lock()
+	if h.status.Status == fullHealRunning {
+		h.mutex.Unlock()
+		return errFullHealRunning
+	}
+	now := time.Now().UTC()
+	h.status = fullHealStatus{
+		Status:    fullHealRunning,
+		Bandwidth: bandwidth,
+		Started:   now,
+		Updated:   now,
+	}
+	h.startLocked()
+	h.mutex.Unlock()
+	return h.save()
+}
+
+// Pause - pauses a running full heal, it stops after the object being
+// healed.
+func (h *fullHealer) Pause() error {
+	h.mutex.Lock()
+	if h.status.Status != fullHealRunning {
+		h.mutex.Unlock()
+		return errFullHealNotRunning
+	}
+	h.status.Status = fullHealPaused
+	h.status.Updated = time.Now().UTC()
+	h.mutex.Unlock()
+	return h.save()
+}
+
+// Resume - continues a paused or failed full heal where it stopped.
+func (h *fullHealer) Resume() error {
+	h.mutex.Lock()
+	if h.status.Status != fullHealPaused && h.status.Status != fullHealFailed {
+		h.mutex.Unlock()
+		return errFullHealNotRunning
+	}
+	h.status.Status = fullHealRunning
+	h.status.Error = ""
+	h.status.Updated = time.Now().UTC()
+	h.startLocked()
+	h.mutex.Unlock()
+	return h.save()
+}
+
+// Status - returns the full heal state.
+func (h *fullHealer) Status() fullHealStatus {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.status
+}
+
+// startLocked - starts a heal goroutine continuing at the saved
+// progress, must be called with the mutex held.
+func (h *fullHealer) startLocked() {
+	h.run++
+	h.running.Add(1)
+	go h.heal(h.run, h.status.Progress)
+}
+
+// heal - heals the backend until everything is healed or the full heal
+// is paused or started over.
+func (h *fullHealer) heal(run int, progress fullHealProgress) {
+	defer h.running.Done()
+	err := healBackend(h.objAPI, h.healer, &progress, func(size int64) error {
+		return h.next(run, progress, size)
+	})
+	h.mutex.Lock()
+	if run != h.run || err == errFullHealStopped {
+		h.mutex.Unlock()
+		return
+	}
+	h.status.Progress = progress
+	h.status.Updated = time.Now().UTC()
+	switch err {
+	case nil:
+		h.status.Status = fullHealFinished
+		errorIf(saveFullHealResult(h.objAPI, h.status.Started, progress.Failed), "Unable to save heal history.")
+	case errFullHealMaintenance:
+		h.status.Status = fullHealPaused
+		h.status.Error = err.Error()
+	default:
+		errorIf(err, "Unable to heal the backend.")
+		h.status.Status = fullHealFailed
+		h.status.Error = err.Error()
+	}
+	h.mutex.Unlock()
+	errorIf(h.save(), "Unable to save full heal state.")
+}
+
+// next - records progress after an object of size bytes was healed and
+// throttles to the bandwidth, returns errFullHealStopped if the full
+// heal of run was paused or started over.
+func (h *fullHealer) next(run int, progress fullHealProgress, size int64) error {
+	h.mutex.Lock()
+	if run != h.run || h.status.Status != fullHealRunning {
+		h.mutex.Unlock()
+		return errFullHealStopped
+	}
+	h.status.Progress = progress
+	h.status.Updated = time.Now().UTC()
+	bandwidth := h.status.Bandwidth
+	save := time.Since(h.saved) >= fullHealSaveInterval
+	h.mutex.Unlock()
+
+	if save {
+		errorIf(h.save(), "Unable to save full heal state.")
+	}
+	if bandwidth > 0 && size > 0 {
+		time.Sleep(time.Duration(float64(size) / float64(bandwidth) * float64(time.Second)))
+	}
+	return nil
+}
+
+// save - saves the full heal state.
+func (h *fullHealer) save() error {
+	h.saveMutex.Lock()
+	defer h.saveMutex.Unlock()
+	h.mutex.Lock()
+	data, err := json.Marshal(h.status)
+	h.saved = time.Now()
+	h.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return saveMetaFile(h.objAPI, fullHealStateFile, data)
+}
+
+// healBackend - heals the format, then every bucket and its objects,
+// continuing after progress. next is called after every object with
+// the bytes healed, the heal stops if it fails. Objects which fail to
+// heal are counted and skipped, a disk going offline fails the heal.
+// The result of every healed bucket is saved to the heal history.
+func healBackend(objAPI ObjectLayer, healer backendHealer, progress *fullHealProgress, next func(size int64) error) error {
+	if err := healer.HealFormat(); err != nil {
+		return err
+	}
+	// Buckets are listed from all disks, a replaced disk which is read
+	// from has none.
+	buckets, err := healer.ListBucketsHeal()
+	if err != nil {
+		return err
+	}
+	// Buckets are listed in order, skip the ones already healed.
+	for _, bucket := range buckets {
+		if bucket.Name < progress.Bucket {
+			continue
+		}
+		if bucket.Name != progress.Bucket {
+			if err = healer.HealBucket(bucket.Name); err != nil {
+				// Bucket may have been deleted meanwhile.
+				if _, ok := err.(BucketNotFound); ok {
+					continue
+				}
+				return err
+			}
+			progress.Bucket, progress.Marker = bucket.Name, ""
+			progress.Buckets++
+			progress.BucketResult = healBucketResult{Started: time.Now().UTC()}
+		}
+		deleted := false
+		for {
+			result, err := objAPI.ListObjects(bucket.Name, "", progress.Marker, "", fullHealListSize)
+			if err != nil {
+				if _, ok := err.(BucketNotFound); ok {
+					deleted = true
+					break
+				}
+				return err
+			}
+			for _, object := range result.Objects {
+				// Healing writes, which are rejected during maintenance.
+				if !serverModeAllowsWrites() {
+					return errFullHealMaintenance
+				}
+				var size int64
+				progress.Scanned++
+				progress.BucketResult.Scanned++
+				// Directory markers are empty directories, there is
+				// no object to heal.
+				if !isDirMarker(object.Name) {
+					err = healer.HealObject(bucket.Name, object.Name)
+					switch err.(type) {
+					case nil:
+						progress.Healed++
+						progress.Bytes += object.Size
+						progress.BucketResult.Healed++
+						progress.BucketResult.Bytes += object.Size
+						size = object.Size
+					case ObjectNotFound:
+						// Deleted meanwhile.
+					default:
+						if err == errSomeDiskOffline {
+							return err
+						}
+						errorIf(err, "Unable to heal %s.", pathJoin(bucket.Name, object.Name))
+						progress.Failed++
+						progress.BucketResult.addDegraded(object.Name)
+					}
+				}
+				progress.Marker = object.Name
+				if err = next(size); err != nil {
+					return err
+				}
+			}
+			if !result.IsTruncated {
+				break
+			}
+		}
+		if !deleted {
+			progress.BucketResult.Finished = time.Now().UTC()
+			errorIf(saveHealBucketResult(objAPI, bucket.Name, progress.BucketResult), "Unable to save heal history of bucket %s.", bucket.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// waitFullHeal - waits until the full heal is no longer running and
+// its goroutines returned.
+func waitFullHeal(t *testing.T, h *fullHealer) fullHealStatus {
+	for i := 0; i < 1000; i++ {
+		if status := h.Status(); status.Status != fullHealRunning {
+			h.running.Wait()
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Full heal did not stop")
+	return fullHealStatus{}
+}
+
+// Tests pausing, resuming and finishing a full heal of erasure sets
+// after a disk was wiped.
+func TestFullHealer(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Replace the first disk by an empty one.
+	if err = os.RemoveAll(disks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(disks[0], 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := newFullHealer(objAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.Pause(); err != errFullHealNotRunning {
+		t.Fatalf("Expected %v, got %v", errFullHealNotRunning, err)
+	}
+
+	// Throttled to 10 objects per second.
+	if err = h.Start(int64(len(data)) * 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.Start(0); err != errFullHealRunning {
+		t.Fatalf("Expected %v, got %v", errFullHealRunning, err)
+	}
+	if err = h.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	status := waitFullHeal(t, h)
+	if status.Status != fullHealPaused {
+		t.Fatalf("Expected paused full heal, got %#v", status)
+	}
+
+	// The paused state survives a restart.
+	h, err = newFullHealer(objAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status = h.Status(); status.Status != fullHealPaused {
+		t.Fatalf("Expected paused full heal after reload, got %#v", status)
+	}
+	if err = h.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	status = waitFullHeal(t, h)
+	if status.Status != fullHealFinished || status.Progress.Buckets != 1 || status.Progress.Failed != 0 ||
+		status.Progress.Scanned != 20 || status.Progress.Healed != 20 || status.Progress.Bytes != int64(20*len(data)) {
+		t.Fatalf("Expected finished full heal, got %#v", status)
+	}
+	if err = h.Resume(); err != errFullHealNotRunning {
+		t.Fatalf("Expected %v, got %v", errFullHealNotRunning, err)
+	}
+
+	// The result is saved to the heal history of the disks and buckets.
+	history, err := loadHealHistory(objAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Disks) != len(disks) {
+		t.Fatalf("Expected results of %d disks, got %d", len(disks), len(history.Disks))
+	}
+	bucketResult := history.Buckets["bucket"]
+	if bucketResult.Finished.IsZero() || bucketResult.Scanned != 20 || bucketResult.Healed != 20 || len(bucketResult.Degraded) != 0 {
+		t.Fatalf("Unexpected result of bucket %#v", bucketResult)
+	}
+
+	// The wiped disk holds the format, the bucket and the objects again.
+	disk, err := newPosix(disks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loadFormat(disk); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = disk.StatVol("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	sets := objAPI.(*xlSets)
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		// Objects of the other set are not on the disk.
+		if _, err = sets.sets[0].getObjectInfo("bucket", object); err != nil {
+			continue
+		}
+		if _, err = disk.StatFile("bucket", path.Join(object, xlMetaJSONFile)); err != nil {
+			t.Fatalf("Object %s not healed: %v", object, err)
+		}
+	}
+
+	// The layout holds the uuid of the new disk, the server starts.
+	if _, err = newObjectLayer(disks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests that object layers which cannot heal have no full healer.
+func TestFullHealerFS(t *testing.T) {
+	objAPI, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+	h, err := newFullHealer(objAPI)
+	if err != nil || h != nil {
+		t.Fatalf("Expected no full healer, got %v, %v", h, err)
+	}
+}
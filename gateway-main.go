@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+
+	"github.com/minio/cli"
+)
+
+var gatewayCmd = cli.Command{
+	Name:  "gateway",
+	Usage: "Start object storage gateway.",
+	Subcommands: []cli.Command{
+		gatewayS3Cmd,
+		gatewayNASCmd,
+	},
+}
+
+var gatewayS3Cmd = cli.Command{
+	Name:  "s3",
+	Usage: "Start object storage gateway to an S3 compatible endpoint.",
+	Flags: append(serverFlags,
+		cli.StringFlag{
+			Name:  "region",
+			Value: gatewayS3DefaultRegion,
+			Usage: "Region new buckets are created in on the remote endpoint.",
+		},
+	),
+	Action: gatewayS3Main,
+	CustomHelpTemplate: `NAME:
+  minio gateway {{.Name}} - {{.Usage}}
+
+USAGE:
+  minio gateway {{.Name}} [OPTIONS] [ENDPOINT]
+
+OPTIONS:
+  {{range .Flags}}{{.}}
+  {{end}}
+ENVIRONMENT VARIABLES:
+  MINIO_ACCESS_KEY: Access key string of 5 to 20 characters in length.
+  MINIO_SECRET_KEY: Secret key string of 8 to 40 characters in length.
+  MINIO_GATEWAY_ACCESS_KEY: Access key of the remote endpoint, defaults to MINIO_ACCESS_KEY.
+  MINIO_GATEWAY_SECRET_KEY: Secret key of the remote endpoint, defaults to MINIO_SECRET_KEY.
+  MINIO_ETCD_ENDPOINTS: Comma separated etcd endpoints to share the config and bucket configs through.
+
+EXAMPLES:
+  1. Start minio gateway to AWS S3, clients use the AWS credentials.
+      $ export MINIO_ACCESS_KEY=aws_access_key
+      $ export MINIO_SECRET_KEY=aws_secret_key
+      $ minio gateway {{.Name}}
+
+  2. Start minio gateway to another S3 compatible endpoint with separate remote credentials.
+      $ export MINIO_GATEWAY_ACCESS_KEY=remote_access_key
+      $ export MINIO_GATEWAY_SECRET_KEY=remote_secret_key
+      $ minio gateway {{.Name}} https://play.minio.io:9000
+`,
+}
+
+// Default endpoint and region of the S3 gateway.
+const (
+	gatewayS3DefaultEndpoint = "https://s3.amazonaws.com"
+	gatewayS3DefaultRegion   = "us-east-1"
+)
+
+// getGatewayCredential - returns the credentials requests to the remote
+// endpoint are signed with. Without static remote credentials the
+// server credentials are passed through, following any changes.
+func getGatewayCredential() (func() credential, error) {
+	accessKey := os.Getenv("MINIO_GATEWAY_ACCESS_KEY")
+	secretKey := os.Getenv("MINIO_GATEWAY_SECRET_KEY")
+	if accessKey == "" && secretKey == "" {
+		return func() credential { return serverConfig.GetCredential() }, nil
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, errInvalidArgument
+	}
+	cred := credential{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+	}
+	return func() credential { return cred }, nil
+}
+
+func gatewayS3Main(c *cli.Context) {
+	if c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "s3", 1)
+	}
+
+	// Initialize server config.
+	initServerConfig(c)
+
+	objAPI, err := newRegisteredObjectLayer(objectLayerS3, []string{c.Args().First(), c.String("region")})
+	fatalIf(err, "Unable to initialize S3 gateway.")
+
+	startServer(c, serverCmdConfig{
+		objectLayer: objAPI,
+		backend:     objectLayerS3,
+	})
+}
@@ -0,0 +1,129 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+var gatewayNASCmd = cli.Command{
+	Name:   "nas",
+	Usage:  "Start object storage gateway to a shared NFS or SMB mount.",
+	Flags:  serverFlags,
+	Action: gatewayNASMain,
+	CustomHelpTemplate: `NAME:
+  minio gateway {{.Name}} - {{.Usage}}
+
+USAGE:
+  minio gateway {{.Name}} [OPTIONS] PATH
+
+OPTIONS:
+  {{range .Flags}}{{.}}
+  {{end}}
+ENVIRONMENT VARIABLES:
+  MINIO_ACCESS_KEY: Access key string of 5 to 20 characters in length.
+  MINIO_SECRET_KEY: Secret key string of 8 to 40 characters in length.
+  MINIO_ETCD_ENDPOINTS: Comma separated etcd endpoints to share the config and bucket configs through.
+
+EXAMPLES:
+  1. Start minio gateway to a NFS mount, run the same command on every host sharing the mount.
+      $ minio gateway {{.Name}} /mnt/shared
+`,
+}
+
+const (
+	// Backend name of NAS gateways in the startup information.
+	gatewayNAS = "nas"
+	// Config directory of NAS gateways on the share.
+	gatewayNASConfigDir = "config"
+	// Lock files of NAS gateways on the share.
+	gatewayNASLocksDir = "locks"
+	// Interval to check for config changes of other NAS gateways.
+	gatewayNASConfigReloadInterval = 10 * time.Second
+)
+
+// watchSharedConfig - reloads the config whenever it has been saved,
+// possibly by another gateway process sharing it.
+func watchSharedConfig(interval time.Duration, doneCh <-chan struct{}) {
+	var modTime time.Time
+	if fi, err := os.Stat(mustGetConfigFile()); err == nil {
+		modTime = fi.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fi, err := os.Stat(mustGetConfigFile())
+			if err != nil || fi.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = fi.ModTime()
+			errorIf(serverConfig.reload(), "Unable to reload shared config.")
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// initGatewayNAS - moves all state of the server onto the share, any
+// number of gateway processes on other hosts can then serve the share.
+func initGatewayNAS(sharePath string) error {
+	metaPath := filepath.Join(sharePath, minioMetaBucket)
+
+	// Config and bucket configs are shared.
+	setGlobalConfigPath(filepath.Join(metaPath, gatewayNASConfigDir))
+	if err := initConfig(); err != nil {
+		return err
+	}
+
+	// Multipart uploads are coordinated through lock files.
+	fileLocker, err := newNSFileLocker(filepath.Join(metaPath, gatewayNASLocksDir))
+	if err != nil {
+		return err
+	}
+	nsMutex.fileLocker = fileLocker
+	return nil
+}
+
+func gatewayNASMain(c *cli.Context) {
+	if !c.Args().Present() || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "nas", 1)
+	}
+	sharePath := c.Args().First()
+
+	err := initGatewayNAS(sharePath)
+	fatalIf(err, "Unable to initialize NAS gateway state on %s.", sharePath)
+
+	// Initialize server config.
+	initServerConfig(c)
+
+	// Share is served by the FS object layer.
+	objAPI, err := newRegisteredObjectLayer(objectLayerFS, []string{sharePath})
+	fatalIf(err, "Unable to initialize NAS gateway.")
+
+	go watchSharedConfig(gatewayNASConfigReloadInterval, nil)
+
+	startServer(c, serverCmdConfig{
+		objectLayer: objAPI,
+		backend:     gatewayNAS,
+	})
+}
@@ -0,0 +1,154 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests NAS gateways keep their state on the share.
+func TestInitGatewayNAS(t *testing.T) {
+	sharePath, err := ioutil.TempDir("", "minio-nas-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(sharePath)
+	defer setGlobalConfigPath(customConfigPath)
+	defer func() {
+		nsMutex.fileLocker = nil
+	}()
+
+	initNSLock()
+	if err = initGatewayNAS(sharePath); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(sharePath, minioMetaBucket, gatewayNASConfigDir, globalMinioConfigFile)
+	if _, err = os.Stat(configFile); err != nil {
+		t.Fatalf("Expected config on the share, but instead found %v", err)
+	}
+	if nsMutex.fileLocker == nil || nsMutex.fileLocker.dir != filepath.Join(sharePath, minioMetaBucket, gatewayNASLocksDir) {
+		t.Fatal("Expected lock files on the share")
+	}
+
+	// Changes saved by another gateway are reloaded.
+	serverConfig.SetRegion("us-west-1")
+	if err = serverConfig.Save(); err != nil {
+		t.Fatal(err)
+	}
+	serverConfig.SetRegion("us-east-1")
+	if err = serverConfig.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if region := serverConfig.GetRegion(); region != "us-west-1" {
+		t.Fatalf("Expected region us-west-1, but instead found %s", region)
+	}
+
+	// Meta bucket locks are shared, others are not.
+	nsMutex.Lock(minioMetaBucket, "multipart/bucket/object")
+	if _, err = os.Stat(nsMutex.fileLocker.lockFile(pathJoin(minioMetaBucket, "multipart/bucket/object"))); err != nil {
+		t.Fatalf("Expected lock file, but instead found %v", err)
+	}
+	nsMutex.Unlock(minioMetaBucket, "multipart/bucket/object")
+	nsMutex.Lock("bucket", "object")
+	if _, err = os.Stat(nsMutex.fileLocker.lockFile(pathJoin("bucket", "object"))); !os.IsNotExist(err) {
+		t.Fatalf("Expected no lock file, but instead found %v", err)
+	}
+	nsMutex.Unlock("bucket", "object")
+}
+
+// Tests only stale temporary entries are removed on start when other
+// processes share the disk.
+func TestFSHouseKeepingShared(t *testing.T) {
+	sharePath, err := ioutil.TempDir("", "minio-nas-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(sharePath)
+	defer func() {
+		nsMutex.fileLocker = nil
+	}()
+
+	initNSLock()
+	nsMutex.fileLocker, err = newNSFileLocker(filepath.Join(sharePath, minioMetaBucket, gatewayNASLocksDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := newPosix(sharePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fsHouseKeeping(storage); err != nil {
+		t.Fatal(err)
+	}
+	inFlight := pathJoin(tmpMetaPrefix, "upload", "part")
+	stale := pathJoin(tmpMetaPrefix, "crashed")
+	for _, name := range []string{inFlight, stale} {
+		if err = storage.AppendFile(context.Background(), minioMetaBucket, name, []byte("data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	staleTime := time.Now().Add(-sharedTmpStaleAfter - time.Minute)
+	if err = os.Chtimes(filepath.Join(sharePath, minioMetaBucket, stale), staleTime, staleTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = fsHouseKeeping(storage); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = storage.StatFile(minioMetaBucket, inFlight); err != nil {
+		t.Fatalf("Expected in-flight entry to be kept, but instead found %v", err)
+	}
+	if _, err = storage.StatFile(minioMetaBucket, stale); err != errFileNotFound {
+		t.Fatalf("Expected stale entry to be removed, but instead found %v", err)
+	}
+}
+
+// Tests bucket config files are replaced without leaving temporary
+// files behind.
+func TestWriteBucketConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-bucket-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	name := filepath.Join(dir, "config.json")
+	for _, data := range []string{`{"first":true}`, `{}`} {
+		if err = writeBucketConfigFile(name, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Fatalf("Expected %s, but instead found %s", data, got)
+		}
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected only the config file, but instead found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,344 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// s3Objects - implements the object layer on top of a remote S3
+// compatible endpoint. All Minio features implemented above the object
+// layer keep their state locally in the config directory.
+type s3Objects struct {
+	client *s3Client
+}
+
+func init() {
+	// Arguments are the endpoint and the region, both optional.
+	registerObjectLayer(objectLayerS3, func(args []string) (ObjectLayer, error) {
+		endpoint, region := gatewayS3DefaultEndpoint, gatewayS3DefaultRegion
+		if len(args) > 0 && args[0] != "" {
+			endpoint = args[0]
+		}
+		if len(args) > 1 && args[1] != "" {
+			region = args[1]
+		}
+		getCredential, err := getGatewayCredential()
+		if err != nil {
+			return nil, errors.New("Both MINIO_GATEWAY_ACCESS_KEY and MINIO_GATEWAY_SECRET_KEY should be set")
+		}
+		return newS3Gateway(endpoint, region, getCredential)
+	})
+}
+
+// newS3Gateway - initializes the object layer of the S3 gateway to
+// endpoint, new buckets are created in region.
+func newS3Gateway(endpoint, region string, getCredential func() credential) (ObjectLayer, error) {
+	client, err := newS3Client(endpoint, region, getCredential)
+	if err != nil {
+		return nil, err
+	}
+	return s3Objects{client: client}, nil
+}
+
+// s3ToObjectErr - converts errors of the remote endpoint to object
+// layer errors, params are bucket, object and upload id.
+func s3ToObjectErr(err error, params ...string) error {
+	clientErr, ok := err.(s3ClientError)
+	if !ok {
+		return err
+	}
+	var bucket, object, uploadID string
+	if len(params) >= 1 {
+		bucket = params[0]
+	}
+	if len(params) >= 2 {
+		object = params[1]
+	}
+	if len(params) >= 3 {
+		uploadID = params[2]
+	}
+	switch clientErr.Code {
+	case "NoSuchBucket":
+		return BucketNotFound{Bucket: bucket}
+	case "NoSuchKey":
+		if object == "" {
+			return BucketNotFound{Bucket: bucket}
+		}
+		return ObjectNotFound{Bucket: bucket, Object: object}
+	case "BucketAlreadyExists", "BucketAlreadyOwnedByYou":
+		return BucketExists{Bucket: bucket}
+	case "BucketNotEmpty":
+		return BucketNotEmpty{Bucket: bucket}
+	case "InvalidBucketName":
+		return BucketNameInvalid{Bucket: bucket}
+	case "KeyTooLong", "XMinioInvalidObjectName":
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	case "NoSuchUpload":
+		return InvalidUploadID{UploadID: uploadID}
+	case "InvalidPart":
+		return InvalidPart{}
+	case "InvalidPartOrder":
+		return InvalidPartOrder{UploadID: uploadID}
+	case "EntityTooSmall":
+		return PartTooSmall{}
+	case "BadDigest", "InvalidDigest":
+		return BadDigest{}
+	case "IncompleteBody":
+		return IncompleteBody{Bucket: bucket, Object: object}
+	}
+	return err
+}
+
+// StorageInfo - capacity of the remote endpoint is unknown.
+func (l s3Objects) StorageInfo() StorageInfo {
+	return StorageInfo{}
+}
+
+// DisksInfo - gateways have no local disks.
+func (l s3Objects) DisksInfo() []DiskStatus {
+	return nil
+}
+
+// MakeBucket - creates a bucket on the remote endpoint.
+func (l s3Objects) MakeBucket(bucket string) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	return s3ToObjectErr(l.client.makeBucket(bucket), bucket)
+}
+
+// GetBucketInfo - returns info of a bucket, buckets are looked up in
+// the list of all buckets which is the only place to find the creation
+// date.
+func (l s3Objects) GetBucketInfo(bucket string) (BucketInfo, error) {
+	if !IsValidBucketName(bucket) {
+		return BucketInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	buckets, err := l.client.listBuckets()
+	if err != nil {
+		return BucketInfo{}, s3ToObjectErr(err, bucket)
+	}
+	for _, bucketInfo := range buckets {
+		if bucketInfo.Name == bucket {
+			return bucketInfo, nil
+		}
+	}
+	return BucketInfo{}, BucketNotFound{Bucket: bucket}
+}
+
+// ListBuckets - lists all buckets of the remote endpoint.
+func (l s3Objects) ListBuckets() ([]BucketInfo, error) {
+	buckets, err := l.client.listBuckets()
+	if err != nil {
+		return nil, s3ToObjectErr(err)
+	}
+	var bucketInfos []BucketInfo
+	for _, bucketInfo := range buckets {
+		// Skip buckets which cannot be served.
+		if !IsValidBucketName(bucketInfo.Name) {
+			continue
+		}
+		bucketInfos = append(bucketInfos, bucketInfo)
+	}
+	sort.Sort(byBucketName(bucketInfos))
+	return bucketInfos, nil
+}
+
+// DeleteBucket - deletes a bucket on the remote endpoint.
+func (l s3Objects) DeleteBucket(bucket string) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	return s3ToObjectErr(l.client.deleteBucket(bucket), bucket)
+}
+
+// ListObjects - lists objects of a bucket on the remote endpoint.
+func (l s3Objects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	if !IsValidBucketName(bucket) {
+		return ListObjectsInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	result, err := l.client.listObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return ListObjectsInfo{}, s3ToObjectErr(err, bucket)
+	}
+	return result, nil
+}
+
+// GetObject - writes length bytes of an object from startOffset.
+func (l s3Objects) GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) && !isDirMarker(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	return s3ToObjectErr(l.client.getObject(ctx, bucket, object, startOffset, length, writer), bucket, object)
+}
+
+// GetObjectInfo - returns info of an object on the remote endpoint.
+func (l s3Objects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	if !IsValidBucketName(bucket) {
+		return ObjectInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) && !isDirMarker(object) {
+		return ObjectInfo{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	objInfo, err := l.client.statObject(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, s3ToObjectErr(err, bucket, object)
+	}
+	return objInfo, nil
+}
+
+// PutObject - uploads an object to the remote endpoint. Objects of
+// unknown size are staged in a temporary file first since the remote
+// endpoint requires the size upfront.
+func (l s3Objects) PutObject(ctx context.Context, bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) && !isDirMarker(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	if size < 0 {
+		tmpFile, err := ioutil.TempFile("", "minio-gateway-")
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}()
+		if size, err = io.Copy(tmpFile, data); err != nil {
+			return "", err
+		}
+		if _, err = tmpFile.Seek(0, 0); err != nil {
+			return "", err
+		}
+		data = tmpFile
+	}
+	// Like other object layers only size bytes are read.
+	md5Sum, err := l.client.putObject(ctx, bucket, object, size, io.LimitReader(data, size), metadata)
+	if err != nil {
+		return "", s3ToObjectErr(err, bucket, object)
+	}
+	return md5Sum, nil
+}
+
+// DeleteObject - deletes an object on the remote endpoint.
+func (l s3Objects) DeleteObject(bucket, object string) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) && !isDirMarker(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	// Deleting a missing object succeeds on S3, object layers fail.
+	if _, err := l.client.statObject(bucket, object); err != nil {
+		return s3ToObjectErr(err, bucket, object)
+	}
+	return s3ToObjectErr(l.client.deleteObject(bucket, object), bucket, object)
+}
+
+// ListMultipartUploads - lists incomplete uploads on the remote endpoint.
+func (l s3Objects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	if !IsValidBucketName(bucket) {
+		return ListMultipartsInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	result, err := l.client.listMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+	if err != nil {
+		return ListMultipartsInfo{}, s3ToObjectErr(err, bucket)
+	}
+	return result, nil
+}
+
+// NewMultipartUpload - initiates a multipart upload on the remote endpoint.
+func (l s3Objects) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	uploadID, err := l.client.newMultipartUpload(bucket, object, metadata)
+	if err != nil {
+		return "", s3ToObjectErr(err, bucket, object)
+	}
+	return uploadID, nil
+}
+
+// PutObjectPart - uploads a part to the remote endpoint.
+func (l s3Objects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	md5Sum, err := l.client.putObjectPart(ctx, bucket, object, uploadID, partID, size, io.LimitReader(data, size), md5Hex)
+	if err != nil {
+		return "", s3ToObjectErr(err, bucket, object, uploadID)
+	}
+	return md5Sum, nil
+}
+
+// ListObjectParts - lists uploaded parts on the remote endpoint.
+func (l s3Objects) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (ListPartsInfo, error) {
+	if !IsValidBucketName(bucket) {
+		return ListPartsInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return ListPartsInfo{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	result, err := l.client.listObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+	if err != nil {
+		return ListPartsInfo{}, s3ToObjectErr(err, bucket, object, uploadID)
+	}
+	return result, nil
+}
+
+// AbortMultipartUpload - aborts a multipart upload on the remote endpoint.
+func (l s3Objects) AbortMultipartUpload(bucket, object, uploadID string) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	return s3ToObjectErr(l.client.abortMultipartUpload(bucket, object, uploadID), bucket, object, uploadID)
+}
+
+// CompleteMultipartUpload - completes a multipart upload on the remote endpoint.
+func (l s3Objects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (string, error) {
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	md5Sum, err := l.client.completeMultipartUpload(bucket, object, uploadID, uploadedParts)
+	if err != nil {
+		return "", s3ToObjectErr(err, bucket, object, uploadID)
+	}
+	return md5Sum, nil
+}
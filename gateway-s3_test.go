@@ -0,0 +1,41 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestS3GatewayAPISuite(c *C) {
+	var testServers []TestServer
+
+	// Each gateway is backed by a new remote server, credentials are
+	// passed through.
+	create := func() ObjectLayer {
+		testServer := StartTestServer(c, "FS")
+		testServers = append(testServers, testServer)
+		getCredential, err := getGatewayCredential()
+		c.Check(err, IsNil)
+		objAPI, err := newS3Gateway(testServer.Server.URL, "us-east-1", getCredential)
+		c.Check(err, IsNil)
+		return objAPI
+	}
+	APITestSuite(c, create)
+	for _, testServer := range testServers {
+		testServer.Stop()
+	}
+}
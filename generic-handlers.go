@@ -17,7 +17,9 @@
 package main
 
 import (
+	"net"
 	"net/http"
+	"net/url"
 	"path"
 	"regexp"
 	"strings"
@@ -45,6 +47,34 @@ type redirectHandler struct {
 	locationPrefix string
 }
 
+// Redirects plain HTTP requests to the HTTPS server listening on port.
+type httpsRedirectHandler struct {
+	port string
+}
+
+func (h httpsRedirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		// Host header without a port.
+		host = r.Host
+	}
+	if h.port != "443" {
+		host = net.JoinHostPort(host, h.port)
+	}
+	location := url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+	// Preserve method and body of anything other than GET and HEAD.
+	status := http.StatusTemporaryRedirect
+	if r.Method == "GET" || r.Method == "HEAD" {
+		status = http.StatusMovedPermanently
+	}
+	http.Redirect(w, r, location.String(), status)
+}
+
 // Reserved bucket.
 const (
 	reservedBucket = "/minio"
@@ -178,9 +208,8 @@ func (h timeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			writeErrorResponse(w, r, apiErr, r.URL.Path)
 			return
 		}
-		// Verify if the request date header is more than 5minutes
-		// late, reject such clients.
-		if time.Now().UTC().Sub(amzDate)/time.Minute > time.Duration(5)*time.Minute {
+		// Reject clients whose clock is too far ahead or behind.
+		if isClockSkewed(amzDate, time.Now().UTC()) {
 			writeErrorResponse(w, r, ErrRequestTimeTooSkewed, r.URL.Path)
 			return
 		}
@@ -272,7 +301,6 @@ func ignoreNotImplementedObjectResources(req *http.Request) bool {
 
 // List of not implemented bucket queries
 var notimplementedBucketResourceNames = map[string]bool{
-	"acl":            true,
 	"cors":           true,
 	"lifecycle":      true,
 	"logging":        true,
@@ -288,6 +316,5 @@ var notimplementedBucketResourceNames = map[string]bool{
 // List of not implemented object queries
 var notimplementedObjectResourceNames = map[string]bool{
 	"torrent": true,
-	"acl":     true,
 	"policy":  true,
 }
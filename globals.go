@@ -16,7 +16,11 @@
 
 package main
 
-import "github.com/fatih/color"
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
 
 // Global constants for Minio.
 const (
@@ -25,7 +29,7 @@ const (
 
 // minio configuration related constants.
 const (
-	globalMinioConfigVersion = "4"
+	globalMinioConfigVersion = "6"
 	globalMinioConfigDir     = ".minio"
 	globalMinioCertsDir      = ".minio/certs"
 	globalMinioCertFile      = "public.crt"
@@ -38,9 +42,21 @@ const (
 var (
 	globalQuiet = false // Quiet flag set via command line
 	globalTrace = false // Trace flag set via environment setting.
+	// WORM flag set via command line, disallows overwriting and
+	// deleting existing objects.
+	globalWORMEnabled = false
+	// Meta volume locations of disks set via command line, by absolute
+	// disk path. Other disks hold their meta volume themselves.
+	globalMetaDisks = map[string]string{}
+	// Maximum difference between the time of signed requests and the
+	// server set via command line.
+	globalMaxClockSkew = defaultMaxClockSkew
 	// Add new global flags here.
 )
 
+// globalBootTime - time the server was started at.
+var globalBootTime = time.Now().UTC()
+
 // global colors.
 var (
 	colorMagenta = color.New(color.FgMagenta, color.Bold).SprintfFunc()
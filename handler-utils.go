@@ -20,35 +20,27 @@ import (
 	"io"
 )
 
-// validates location constraint from the request body.
-// the location value in the request body should match the Region in serverConfig.
-// other values of location are not accepted.
-// make bucket fails in such cases.
-func isValidLocationContraint(reqBody io.Reader, serverRegion string) APIErrorCode {
+// parseLocationConstraint - returns the location constraint from the
+// request body, any valid region name is accepted as bucket region.
+func parseLocationConstraint(reqBody io.Reader, serverRegion string) (location string, errCode APIErrorCode) {
 	var locationContraint createBucketLocationConfiguration
-	var errCode APIErrorCode
-	errCode = ErrNone
 	e := xmlDecoder(reqBody, &locationContraint)
 	if e != nil {
 		if e == io.EOF {
-			// Do nothing.
-			// failed due to empty body. The location will be set to default value from the serverConfig.
-			// this is valid.
-			errCode = ErrNone
-		} else {
-			// Failed due to malformed configuration.
-			errCode = ErrMalformedXML
-			//writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
-		}
-	} else {
-		// Region obtained from the body.
-		// It should be equal to Region in serverConfig.
-		// Else ErrInvalidRegion returned.
-		// For empty value location will be to set to  default value from the serverConfig.
-		if locationContraint.Location != "" && serverRegion != locationContraint.Location {
-			//writeErrorResponse(w, r, ErrInvalidRegion, r.URL.Path)
-			errCode = ErrInvalidRegion
+			// Failed due to empty body. The location will be set to
+			// default value from the serverConfig, this is valid.
+			return serverRegion, ErrNone
 		}
+		// Failed due to malformed configuration.
+		return "", ErrMalformedXML
+	}
+	// Region obtained from the body, for empty value location will be
+	// set to default value from the serverConfig.
+	if locationContraint.Location == "" {
+		return serverRegion, ErrNone
+	}
+	if !validRegion.MatchString(locationContraint.Location) {
+		return "", ErrInvalidLocationConstraint
 	}
-	return errCode
+	return locationContraint.Location, ErrNone
 }
@@ -25,7 +25,7 @@ import (
 )
 
 // Tests validate bucket LocationConstraint.
-func TestIsValidLocationContraint(t *testing.T) {
+func TestParseLocationConstraint(t *testing.T) {
 	// generates the input request with XML bucket configuration set to the request body.
 	createExpectedRequest := func(req *http.Request, location string) (*http.Request, error) {
 		createBucketConfig := createBucketLocationConfiguration{}
@@ -43,24 +43,33 @@ func TestIsValidLocationContraint(t *testing.T) {
 	testCases := []struct {
 		locationForInputRequest string
 		serverConfigRegion      string
+		expectedLocation        string
 		expectedCode            APIErrorCode
 	}{
 		// Test case - 1.
-		{"us-east-1", "us-east-1", ErrNone},
+		{"us-east-1", "us-east-1", "us-east-1", ErrNone},
 		// Test case - 2.
 		// In case of empty request body ErrNone is returned.
-		{"", "us-east-1", ErrNone},
+		{"", "us-east-1", "us-east-1", ErrNone},
 		// Test case - 3.
-		{"eu-central-1", "us-east-1", ErrInvalidRegion},
+		// Buckets can be created in any region.
+		{"eu-central-1", "us-east-1", "eu-central-1", ErrNone},
+		// Test case - 4.
+		{"EU", "us-east-1", "EU", ErrNone},
+		// Test case - 5.
+		{"eu central", "us-east-1", "", ErrInvalidLocationConstraint},
 	}
 	for i, testCase := range testCases {
 		inputRequest, e := createExpectedRequest(&http.Request{}, testCase.locationForInputRequest)
 		if e != nil {
 			t.Fatalf("Test %d: Failed to Marshal bucket configuration", i+1)
 		}
-		actualCode := isValidLocationContraint(inputRequest.Body, testCase.serverConfigRegion)
+		location, actualCode := parseLocationConstraint(inputRequest.Body, testCase.serverConfigRegion)
 		if testCase.expectedCode != actualCode {
 			t.Errorf("Test %d: Expected the APIErrCode to be %d, but instead found %d", i+1, testCase.expectedCode, actualCode)
 		}
+		if testCase.expectedLocation != location {
+			t.Errorf("Test %d: Expected the location to be %s, but instead found %s", i+1, testCase.expectedLocation, location)
+		}
 	}
 }
@@ -0,0 +1,163 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// Results of past full heals in minioMetaBucket, by disk uuid and
+	// by bucket.
+	healHistoryDisksFile   = "heal/disks.json"
+	healHistoryBucketsFile = "heal/buckets.json"
+
+	// Number of objects recorded as degraded per bucket, further
+	// failures are only counted.
+	healDegradedLimit = 1000
+)
+
+// healDiskResult - last full heal which finished while the disk was
+// online. Replaced disks get a new uuid and have no result until the
+// next full heal finished.
+type healDiskResult struct {
+	Endpoint string    `json:"endpoint"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Objects of all buckets which failed to heal.
+	Failed int64 `json:"failed"`
+}
+
+// healBucketResult - last full heal of a bucket.
+type healBucketResult struct {
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Scanned  int64     `json:"scanned"`
+	Healed   int64     `json:"healed"`
+	Failed   int64     `json:"failed"`
+	Bytes    int64     `json:"bytes"`
+	// Objects which failed to heal and remain degraded, at most
+	// healDegradedLimit.
+	Degraded []string `json:"degraded,omitempty"`
+}
+
+// addDegraded - records an object which failed to heal.
+func (r *healBucketResult) addDegraded(object string) {
+	r.Failed++
+	if len(r.Degraded) < healDegradedLimit {
+		r.Degraded = append(r.Degraded, object)
+	}
+}
+
+// healHistory - results of past full heals.
+type healHistory struct {
+	Disks   map[string]healDiskResult   `json:"disks"`
+	Buckets map[string]healBucketResult `json:"buckets"`
+	// Objects queued for healing after writes which failed on some
+	// disks, only filled when reporting the history.
+	Queued int `json:"queued"`
+}
+
+// Serializes updates of the heal history files.
+var healHistoryMutex = &sync.Mutex{}
+
+// loadHealHistoryFile - loads a heal history file into v, v is left
+// as it is if there is no file yet.
+func loadHealHistoryFile(objAPI ObjectLayer, path string, v interface{}) error {
+	data, err := loadMetaFile(objAPI, path)
+	if err == errFileNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// loadHealHistory - returns the results of past full heals.
+func loadHealHistory(objAPI ObjectLayer) (healHistory, error) {
+	history := healHistory{
+		Disks:   make(map[string]healDiskResult),
+		Buckets: make(map[string]healBucketResult),
+	}
+	if err := loadHealHistoryFile(objAPI, healHistoryDisksFile, &history.Disks); err != nil {
+		return healHistory{}, err
+	}
+	if err := loadHealHistoryFile(objAPI, healHistoryBucketsFile, &history.Buckets); err != nil {
+		return healHistory{}, err
+	}
+	return history, nil
+}
+
+// saveHealBucketResult - records the result of healing a bucket.
+func saveHealBucketResult(objAPI ObjectLayer, bucket string, result healBucketResult) error {
+	healHistoryMutex.Lock()
+	defer healHistoryMutex.Unlock()
+	buckets := make(map[string]healBucketResult)
+	if err := loadHealHistoryFile(objAPI, healHistoryBucketsFile, &buckets); err != nil {
+		return err
+	}
+	buckets[bucket] = result
+	return saveJSONMetaFile(objAPI, healHistoryBucketsFile, buckets)
+}
+
+// saveFullHealResult - records a finished full heal for all online
+// disks and drops the results of deleted buckets.
+func saveFullHealResult(objAPI ObjectLayer, started time.Time, failed int64) error {
+	healHistoryMutex.Lock()
+	defer healHistoryMutex.Unlock()
+	disks := make(map[string]healDiskResult)
+	if err := loadHealHistoryFile(objAPI, healHistoryDisksFile, &disks); err != nil {
+		return err
+	}
+	finished := time.Now().UTC()
+	for _, status := range objAPI.DisksInfo() {
+		if !status.Online || status.FormatUUID == "" {
+			continue
+		}
+		disks[status.FormatUUID] = healDiskResult{
+			Endpoint: status.Endpoint,
+			Started:  started,
+			Finished: finished,
+			Failed:   failed,
+		}
+	}
+	if err := saveJSONMetaFile(objAPI, healHistoryDisksFile, disks); err != nil {
+		return err
+	}
+
+	buckets := make(map[string]healBucketResult)
+	if err := loadHealHistoryFile(objAPI, healHistoryBucketsFile, &buckets); err != nil {
+		return err
+	}
+	bucketInfos, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool)
+	for _, bucketInfo := range bucketInfos {
+		exists[bucketInfo.Name] = true
+	}
+	for bucket := range buckets {
+		if !exists[bucket] {
+			delete(buckets, bucket)
+		}
+	}
+	return saveJSONMetaFile(objAPI, healHistoryBucketsFile, buckets)
+}
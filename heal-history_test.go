@@ -0,0 +1,83 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Tests saving and loading the results of full heals.
+func TestHealHistory(t *testing.T) {
+	initNSLock()
+	objAPI, disks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := loadHealHistory(objAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Disks) != 0 || len(history.Buckets) != 0 {
+		t.Fatalf("Expected an empty history, got %#v", history)
+	}
+
+	// Only a limited number of degraded objects is recorded.
+	var result healBucketResult
+	for i := 0; i < healDegradedLimit+1; i++ {
+		result.addDegraded(fmt.Sprintf("object-%d", i))
+	}
+	if result.Failed != healDegradedLimit+1 || len(result.Degraded) != healDegradedLimit {
+		t.Fatalf("Expected %d failed and %d degraded objects, got %d and %d", healDegradedLimit+1, healDegradedLimit, result.Failed, len(result.Degraded))
+	}
+	for _, bucket := range []string{"bucket", "deleted"} {
+		if err = saveHealBucketResult(objAPI, bucket, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Finished full heals are recorded for every disk, results of
+	// deleted buckets are dropped.
+	started := time.Now().UTC()
+	if err = saveFullHealResult(objAPI, started, result.Failed); err != nil {
+		t.Fatal(err)
+	}
+	history, err = loadHealHistory(objAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Disks) != len(disks) {
+		t.Fatalf("Expected results of %d disks, got %d", len(disks), len(history.Disks))
+	}
+	for uuid, diskResult := range history.Disks {
+		if !diskResult.Started.Equal(started) || diskResult.Finished.Before(started) || diskResult.Failed != result.Failed {
+			t.Errorf("Unexpected result of disk %s: %#v", uuid, diskResult)
+		}
+	}
+	if _, ok := history.Buckets["deleted"]; ok || len(history.Buckets) != 1 {
+		t.Fatalf("Expected only the result of bucket, got %v", history.Buckets)
+	}
+	if len(history.Buckets["bucket"].Degraded) != healDegradedLimit {
+		t.Fatalf("Expected %d degraded objects, got %d", healDegradedLimit, len(history.Buckets["bucket"].Degraded))
+	}
+}
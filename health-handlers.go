@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+
+	router "github.com/gorilla/mux"
+)
+
+// Health probes are served under this path.
+const healthCheckPathPrefix = reservedBucket + "/health"
+
+// healthCheckHandlers provides HTTP handlers for liveness and readiness
+// probes, e.g. of Kubernetes or load balancers.
+type healthCheckHandlers struct {
+	ObjectAPI ObjectLayer
+}
+
+// registerHealthCheckRouter - registers health check routes, must be
+// registered before the web router which serves everything else under
+// reservedBucket.
+func registerHealthCheckRouter(mux *router.Router, health healthCheckHandlers) {
+	// Health check router.
+	healthRouter := mux.NewRoute().PathPrefix(healthCheckPathPrefix).Subrouter()
+
+	// Liveness probe.
+	healthRouter.Methods("GET", "HEAD").Path("/live").HandlerFunc(health.LivenessCheckHandler)
+	// Readiness probe.
+	healthRouter.Methods("GET", "HEAD").Path("/ready").HandlerFunc(health.ReadinessCheckHandler)
+}
+
+// LivenessCheckHandler - GET /minio/health/live
+// ----------
+// Always succeeds while the server is able to answer requests.
+func (health healthCheckHandlers) LivenessCheckHandler(w http.ResponseWriter, r *http.Request) {
+	writeSuccessResponse(w, nil)
+}
+
+// ReadinessCheckHandler - GET /minio/health/ready
+// ----------
+// Succeeds if enough disks are online and formatted to serve reads,
+// otherwise returns 503 Service Unavailable.
+func (health healthCheckHandlers) ReadinessCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if !isObjectLayerReady(health.ObjectAPI) {
+		setCommonHeaders(w)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// isObjectLayerReady - returns true if the disks which are online and
+// formatted satisfy the read quorum of the object layer. FS requires
+// its only disk.
+func isObjectLayerReady(objAPI ObjectLayer) bool {
+	// Every erasure set has to be ready.
+	if sets, ok := objAPI.(*xlSets); ok {
+		for _, set := range sets.sets {
+			if !isObjectLayerReady(set) {
+				return false
+			}
+		}
+		return true
+	}
+	readQuorum := 1
+	if xl, ok := objAPI.(xlObjects); ok {
+		readQuorum = xl.readQuorum
+	}
+	onlineDisks := 0
+	for _, disk := range objAPI.DisksInfo() {
+		if disk.Online && disk.HealStatus == healStatusOK {
+			onlineDisks++
+		}
+	}
+	return onlineDisks >= readQuorum
+}
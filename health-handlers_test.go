@@ -0,0 +1,71 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Tests liveness and readiness probes with enough and too few disks.
+func TestHealthCheckHandlers(t *testing.T) {
+	for _, instanceType := range []string{"FS", "XL"} {
+		testServer := StartTestServer(t, instanceType)
+
+		testCases := []struct {
+			path       string
+			statusCode int
+		}{
+			// Test case - 1.
+			{"/live", http.StatusOK},
+			// Test case - 2.
+			{"/ready", http.StatusOK},
+		}
+		for i, testCase := range testCases {
+			resp, err := http.Get(testServer.Server.URL + healthCheckPathPrefix + testCase.path)
+			if err != nil {
+				t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != testCase.statusCode {
+				t.Errorf("%s: Test %d: Expected status %d, but instead found %d", instanceType, i+1, testCase.statusCode, resp.StatusCode)
+			}
+		}
+
+		// Take disks offline until read quorum is lost.
+		for _, disk := range testServer.Disks[:len(testServer.Disks)/2+len(testServer.Disks)%2] {
+			removeAll(disk)
+		}
+		resp, err := http.Get(testServer.Server.URL + healthCheckPathPrefix + "/ready")
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: Expected status %d, but instead found %d", instanceType, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		// Liveness does not depend on the disks.
+		resp, err = http.Get(testServer.Server.URL + healthCheckPathPrefix + "/live")
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: Expected status %d, but instead found %d", instanceType, http.StatusOK, resp.StatusCode)
+		}
+		testServer.Stop()
+	}
+}
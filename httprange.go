@@ -19,12 +19,21 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
 	"strconv"
 	"strings"
 )
 
 const (
 	b = "bytes="
+
+	// Maximum number of ranges served in a single multipart/byteranges
+	// response.
+	maxRanges = 100
 )
 
 // InvalidRange - invalid range
@@ -102,6 +111,87 @@ func (r *httpRange) parse(ra string) error {
 	return nil
 }
 
+// isMultipleRanges - returns true if the Range header requests more
+// than one range.
+func isMultipleRanges(hrange string) bool {
+	return strings.HasPrefix(hrange, b) && strings.Contains(hrange, ",")
+}
+
+// Grab all ranges from request header, every range is parsed as if it
+// was requested on its own. Unsatisfiable ranges are ignored as per
+// RFC 7233, the request fails only if none of the ranges is satisfiable.
+func getRequestedRanges(hrange string, size int64) ([]*httpRange, error) {
+	if !strings.HasPrefix(hrange, b) {
+		return nil, InvalidRange{}
+	}
+	var ranges []*httpRange
+	for _, ra := range strings.Split(hrange[len(b):], ",") {
+		ra = strings.TrimSpace(ra)
+		// Empty list elements are allowed by RFC 7233.
+		if ra == "" {
+			continue
+		}
+		r := &httpRange{size: size}
+		if err := r.parse(ra); err != nil || r.length <= 0 {
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 || len(ranges) > maxRanges {
+		return nil, InvalidRange{}
+	}
+	return ranges, nil
+}
+
+// rangePartHeader - returns the header of a multipart/byteranges part.
+func rangePartHeader(r *httpRange, contentType string) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("Content-Range", r.String())
+	return header
+}
+
+// writeMultipartRanges - writes a multipart/byteranges response with a
+// part for every range, getObject writes the data of a single range.
+func writeMultipartRanges(w http.ResponseWriter, ranges []*httpRange, contentType string, getObject func(startOffset, length int64, writer io.Writer) error) error {
+	// Content length is calculated upfront from the part headers.
+	counter := &countWriter{Writer: ioutil.Discard}
+	mw := multipart.NewWriter(counter)
+	var length int64
+	for _, r := range ranges {
+		if _, err := mw.CreatePart(rangePartHeader(r, contentType)); err != nil {
+			return err
+		}
+		length += r.length
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	length += counter.n
+
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(http.StatusPartialContent)
+
+	boundary := mw.Boundary()
+	mw = multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		part, err := mw.CreatePart(rangePartHeader(r, contentType))
+		if err != nil {
+			return err
+		}
+		if err = getObject(r.start, r.length, part); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
 // parseRange parses a Range header string as per RFC 2616.
 func (r *httpRange) parseRange(s string) error {
 	if s == "" {
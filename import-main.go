@@ -0,0 +1,219 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/console"
+	"github.com/minio/minio/pkg/mimedb"
+)
+
+var importCmd = cli.Command{
+	Name:  "import",
+	Usage: "Import a local directory into a bucket.",
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "watch",
+			Usage: "Keep importing new and changed files at this interval.",
+		},
+	},
+	Action: importMain,
+	CustomHelpTemplate: `NAME:
+  minio {{.Name}} - {{.Usage}}
+
+USAGE:
+  minio {{.Name}} [OPTIONS] DIRECTORY BUCKET[/PREFIX] PATH...
+
+  Files are written to the object layer of the server PATHs directly,
+  keeping their modification times. Files which were imported before
+  and did not change since are skipped. Import into erasure coded
+  PATHs only while the server is stopped.
+
+OPTIONS:
+  {{range .Flags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Import a directory into the bucket photos of a minio server.
+      $ minio {{.Name}} /home/user/photos photos /home/shared
+
+  2. Mirror new and changed files of a directory every minute.
+      $ minio {{.Name}} --watch 1m /var/log/app logs/app /home/shared
+`,
+}
+
+// Number of files imported concurrently.
+const importWorkers = 8
+
+// importStats - result of an import run.
+type importStats struct {
+	Imported int64
+	Skipped  int64
+	Failed   int64
+	Size     int64
+}
+
+// isImported - returns true if the object of a file was imported
+// before and the file did not change since.
+func isImported(objAPI ObjectLayer, bucket, object string, fi os.FileInfo) bool {
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return false
+	}
+	return objInfo.Size == fi.Size() && objInfo.ModTime.Unix() == fi.ModTime().Unix()
+}
+
+// importFile - writes a file as object keeping its modification time.
+func importFile(objAPI ObjectLayer, bucket, object, filePath string, fi os.FileInfo) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	metadata := map[string]string{
+		objectModTimeKey: fi.ModTime().UTC().Format(time.RFC3339Nano),
+	}
+	if objectExt := filepath.Ext(filePath); objectExt != "" {
+		if content, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]; ok {
+			metadata["content-type"] = content.ContentType
+		}
+	}
+	_, err = objAPI.PutObject(context.Background(), bucket, object, fi.Size(), file, metadata)
+	return err
+}
+
+// importDir - imports all regular files below dir as objects under
+// prefix, files which did not change since the last import are skipped.
+func importDir(objAPI ObjectLayer, dir, bucket, prefix string) (importStats, error) {
+	type importJob struct {
+		path   string
+		object string
+		info   os.FileInfo
+	}
+	var stats importStats
+	var mutex sync.Mutex
+	jobCh := make(chan importJob)
+	var wg sync.WaitGroup
+	for i := 0; i < importWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				if isImported(objAPI, bucket, job.object, job.info) {
+					mutex.Lock()
+					stats.Skipped++
+					mutex.Unlock()
+					continue
+				}
+				err := importFile(objAPI, bucket, job.object, job.path, job.info)
+				errorIf(err, "Unable to import %s.", job.path)
+				mutex.Lock()
+				if err != nil {
+					stats.Failed++
+				} else {
+					stats.Imported++
+					stats.Size += job.info.Size()
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	err := filepath.Walk(dir, func(filePath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// Unreadable files and directories are skipped.
+			errorIf(err, "Unable to import %s.", filePath)
+			mutex.Lock()
+			stats.Failed++
+			mutex.Unlock()
+			return nil
+		}
+		// Symbolic links and other special files are not imported.
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		jobCh <- importJob{
+			path:   filePath,
+			object: prefix + filepath.ToSlash(relPath),
+			info:   fi,
+		}
+		return nil
+	})
+	close(jobCh)
+	wg.Wait()
+	return stats, err
+}
+
+// checkImportSyntax - validates the import arguments, returns the
+// source directory, bucket and object prefix.
+func checkImportSyntax(c *cli.Context) (dir, bucket, prefix string) {
+	if len(c.Args()) < 3 {
+		cli.ShowCommandHelpAndExit(c, "import", 1)
+	}
+	dir = c.Args().Get(0)
+	fi, err := os.Stat(dir)
+	fatalIf(err, "Unable to access %s.", dir)
+	if !fi.IsDir() {
+		fatalIf(errInvalidArgument, "%s is not a directory.", dir)
+	}
+	bucket = c.Args().Get(1)
+	if i := strings.Index(bucket, slashSeparator); i >= 0 {
+		bucket, prefix = bucket[:i], bucket[i+1:]
+	}
+	if !IsValidBucketName(bucket) {
+		fatalIf(BucketNameInvalid{Bucket: bucket}, "Invalid bucket name.")
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, slashSeparator) {
+		prefix += slashSeparator
+	}
+	return dir, bucket, prefix
+}
+
+func importMain(c *cli.Context) {
+	dir, bucket, prefix := checkImportSyntax(c)
+
+	objAPI, err := newObjectLayer(c.Args()[2:])
+	fatalIf(err, "Unable to initialize object layer.")
+	if err = objAPI.MakeBucket(bucket); err != nil {
+		if _, ok := err.(BucketExists); !ok {
+			fatalIf(err, "Unable to create bucket %s.", bucket)
+		}
+	}
+
+	for {
+		start := time.Now()
+		stats, err := importDir(objAPI, dir, bucket, prefix)
+		fatalIf(err, "Unable to import %s.", dir)
+		console.Printf("Imported %d files (%s) in %s, %d unchanged, %d failed.\n", stats.Imported,
+			humanize.IBytes(uint64(stats.Size)), time.Since(start).Round(time.Millisecond), stats.Skipped, stats.Failed)
+		if c.Duration("watch") <= 0 {
+			return
+		}
+		time.Sleep(c.Duration("watch"))
+	}
+}
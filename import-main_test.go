@@ -0,0 +1,106 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Wrapper for calling import tests for both XL multiple disks and single node setup.
+func TestImportDir(t *testing.T) {
+	ExecObjectLayerTest(t, testImportDir)
+}
+
+// Tests files are imported with their modification times and unchanged
+// files are skipped by later imports.
+func testImportDir(obj ObjectLayer, instanceType string, t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-import-")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(dir)
+	modTime := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := map[string]string{
+		"index.html":      "<html></html>",
+		"docs/readme.txt": "hello",
+		"docs/a/b/c.json": "{}",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if err = ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if err = os.Chtimes(filePath, modTime, modTime); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	if err = obj.MakeBucket("bucket"); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	stats, err := importDir(obj, dir, "bucket", "site/")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if stats.Imported != 3 || stats.Skipped != 0 || stats.Failed != 0 || stats.Size != 20 {
+		t.Fatalf("%s: Unexpected import stats %+v", instanceType, stats)
+	}
+	for name, content := range files {
+		objInfo, err := obj.GetObjectInfo("bucket", "site/"+name)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if !objInfo.ModTime.Equal(modTime) {
+			t.Errorf("%s: %s: Expected modification time %s, but instead found %s", instanceType, name, modTime, objInfo.ModTime)
+		}
+		buffer := new(bytes.Buffer)
+		if err = obj.GetObject(context.Background(), "bucket", "site/"+name, 0, objInfo.Size, buffer); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if buffer.String() != content {
+			t.Errorf("%s: %s: Expected %s, but instead found %s", instanceType, name, content, buffer.String())
+		}
+	}
+	objInfo, err := obj.GetObjectInfo("bucket", "site/index.html")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.ContentType != "text/html" {
+		t.Errorf("%s: Expected content type text/html, but instead found %s", instanceType, objInfo.ContentType)
+	}
+
+	// Only changed files are imported again.
+	if err = ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>new</html>"), 0600); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	stats, err = importDir(obj, dir, "bucket", "site/")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if stats.Imported != 1 || stats.Skipped != 2 || stats.Failed != 0 {
+		t.Fatalf("%s: Unexpected import stats %+v", instanceType, stats)
+	}
+}
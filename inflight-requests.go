@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// inflightRequest - a request which is being served.
+type inflightRequest struct {
+	RequestID  string        `json:"requestID"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	RemoteAddr string        `json:"remoteAddr"`
+	AccessKey  string        `json:"accessKey,omitempty"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// inflightRequests - tracks the requests being served.
+type inflightRequests struct {
+	mutex    sync.Mutex
+	nextID   uint64
+	requests map[uint64]*inflightRequest
+}
+
+// Global in-flight requests.
+var globalInflightRequests = &inflightRequests{
+	requests: make(map[uint64]*inflightRequest),
+}
+
+// add - tracks r until the returned function is called.
+func (i *inflightRequests) add(r *http.Request) func() {
+	request := &inflightRequest{
+		RequestID:  getRequestID(r),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+		AccessKey:  getRequestAccessKey(r),
+		Start:      time.Now().UTC(),
+	}
+
+	i.mutex.Lock()
+	id := i.nextID
+	i.nextID++
+	i.requests[id] = request
+	i.mutex.Unlock()
+
+	return func() {
+		i.mutex.Lock()
+		delete(i.requests, id)
+		i.mutex.Unlock()
+	}
+}
+
+// olderThan - returns the requests served for at least olderThan,
+// longest running first.
+func (i *inflightRequests) olderThan(olderThan time.Duration) []inflightRequest {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	requests := []inflightRequest{}
+	now := time.Now().UTC()
+	for _, request := range i.requests {
+		duration := now.Sub(request.Start)
+		if duration < olderThan {
+			continue
+		}
+		r := *request
+		r.Duration = duration
+		requests = append(requests, r)
+	}
+	sort.Slice(requests, func(a, b int) bool {
+		return requests[a].Start.Before(requests[b].Start)
+	})
+	return requests
+}
+
+// inflightHandler - tracks every request while it is served.
+type inflightHandler struct {
+	handler http.Handler
+}
+
+func setInflightHandler(h http.Handler) http.Handler {
+	return inflightHandler{h}
+}
+
+func (h inflightHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	done := globalInflightRequests.add(r)
+	defer done()
+	h.handler.ServeHTTP(w, r)
+}
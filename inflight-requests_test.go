@@ -0,0 +1,95 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests requests are tracked only while they are served.
+func TestInflightHandler(t *testing.T) {
+	requests := globalInflightRequests.olderThan(0)
+	served := false
+	handler := setInflightHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+		inflight := globalInflightRequests.olderThan(0)
+		if len(inflight) != len(requests)+1 {
+			t.Fatalf("Expected %d in-flight requests, got %d", len(requests)+1, len(inflight))
+		}
+		if len(globalInflightRequests.olderThan(time.Hour)) != 0 {
+			t.Fatal("Expected no requests served for an hour")
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/bucket/object", nil))
+	if !served {
+		t.Fatal("Request was not served")
+	}
+	if inflight := globalInflightRequests.olderThan(0); len(inflight) != len(requests) {
+		t.Fatalf("Expected %d in-flight requests, got %d", len(requests), len(inflight))
+	}
+}
+
+// Tests the admin API lists long held locks and the request itself.
+func TestAdminTopLocks(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nsMutex.Lock("bucket", "object")
+	defer nsMutex.Unlock("bucket", "object")
+
+	info, err := client.TopLocks(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, lock := range info.Locks {
+		if lock.Volume == "bucket" && lock.Path == "object" && lock.WriteLock {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Held lock not listed in %#v", info.Locks)
+	}
+	found = false
+	for _, request := range info.Requests {
+		if strings.HasSuffix(request.Path, "/locks") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Admin request not listed in %#v", info.Requests)
+	}
+
+	info, err = client.TopLocks(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Locks) != 0 || len(info.Requests) != 0 {
+		t.Fatalf("Expected nothing older than an hour, got %#v", info)
+	}
+}
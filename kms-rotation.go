@@ -0,0 +1,400 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Key rotation state in the config directory.
+const kmsRotationStateFile = "kms-rotation.json"
+
+// Key rotation statuses.
+const (
+	kmsRotationRunning  = "running"
+	kmsRotationPaused   = "paused"
+	kmsRotationFinished = "finished"
+	kmsRotationFailed   = "failed"
+)
+
+// Interval the progress of a running key rotation is saved at.
+var kmsRotationSaveInterval = 10 * time.Second
+
+var (
+	// errKMSRotationRunning - key rotation cannot be started, one is running.
+	errKMSRotationRunning = errors.New("Key rotation already running")
+
+	// errKMSRotationNotRunning - key rotation cannot be paused or resumed.
+	errKMSRotationNotRunning = errors.New("No key rotation running, paused or failed")
+
+	// errKMSRotationStopped - key rotation was paused or started over.
+	errKMSRotationStopped = errors.New("Key rotation stopped")
+
+	// errKMSRotationMaintenance - key rotation was paused as saving
+	// the metadata writes, which is rejected in read-only and
+	// maintenance mode.
+	errKMSRotationMaintenance = errors.New("Key rotation paused while writes are rejected")
+
+	// errObjectKeySkipped - object key is not sealed with the master
+	// key being rotated, the metadata is left as is.
+	errObjectKeySkipped = errors.New("Object key is sealed with another master key")
+)
+
+// kmsRotationProgress - position and counters of a key rotation,
+// object keys are rotated bucket by bucket, those of the objects in
+// the order they are listed first and those of multipart uploads next.
+type kmsRotationProgress struct {
+	Bucket string `json:"bucket,omitempty"`
+	// Objects of Bucket are done, its uploads are being rotated.
+	Uploads bool `json:"uploads,omitempty"`
+	// Last object or object of an upload of Bucket which was rotated.
+	Marker string `json:"marker,omitempty"`
+	// Last upload of Marker which was rotated.
+	UploadIDMarker string `json:"uploadIdMarker,omitempty"`
+	Rotated        int64  `json:"rotated"`
+	// Object keys sealed with other master keys.
+	Skipped int64 `json:"skipped"`
+	Failed  int64 `json:"failed"`
+}
+
+// kmsRotationStatus - state of the background key rotation.
+type kmsRotationStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Object keys sealed with master key From are re-sealed with
+	// master key To, with its latest version if both are the same.
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Started  time.Time           `json:"started"`
+	Updated  time.Time           `json:"updated"`
+	Progress kmsRotationProgress `json:"progress"`
+}
+
+// keyRotator - re-seals object keys with another master key in the
+// background, object data is not re-encrypted.
+type keyRotator struct {
+	mutex  sync.Mutex
+	objAPI ObjectLayer
+	status kmsRotationStatus
+	// Increased whenever a rotation goroutine is started, older
+	// goroutines stop on their next object.
+	run   int
+	saved time.Time
+	// Serializes saves so that the last state marshaled is saved last.
+	saveMutex sync.Mutex
+}
+
+// globalKeyRotator - rotates the master key of object keys.
+var globalKeyRotator *keyRotator
+
+// newKeyRotator - loads the saved key rotation state and continues a
+// rotation which was running when the server stopped.
+func newKeyRotator(objAPI ObjectLayer) (*keyRotator, error) {
+	r := &keyRotator{objAPI: objAPI}
+	data, err := ioutil.ReadFile(filepath.Join(mustGetConfigPath(), kmsRotationStateFile))
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &r.status); err != nil {
+		return nil, err
+	}
+	if r.status.Status == kmsRotationRunning {
+		r.mutex.Lock()
+		r.startLocked()
+		r.mutex.Unlock()
+	}
+	return r, nil
+}
+
+// Start - starts re-sealing object keys sealed with master key from
+// with master key to, which defaults to the current master key. A
+// paused or failed rotation is started over.
+func (r *keyRotator) Start(from, to string) error {
+	if globalKMS == nil {
+		return errKMSNotConfigured
+	}
+	if from == "" {
+		return errInvalidArgument
+	}
+	if to == "" {
+		to = globalKMS.KeyID()
+	}
+	r.mutex.Lock()
+	if r.status.Status == kmsRotationRunning {
+		r.mutex.Unlock()
+		return errKMSRotationRunning
+	}
+	now := time.Now().UTC()
+	r.status = kmsRotationStatus{
+		Status:  kmsRotationRunning,
+		From:    from,
+		To:      to,
+		Started: now,
+		Updated: now,
+	}
+	r.startLocked()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Pause - pauses a running key rotation, it stops after the object key
+// being rotated.
+func (r *keyRotator) Pause() error {
+	r.mutex.Lock()
+	if r.status.Status != kmsRotationRunning {
+		r.mutex.Unlock()
+		return errKMSRotationNotRunning
+	}
+	r.status.Status = kmsRotationPaused
+	r.status.Updated = time.Now().UTC()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Resume - continues a paused or failed key rotation where it stopped.
+func (r *keyRotator) Resume() error {
+	r.mutex.Lock()
+	if r.status.Status != kmsRotationPaused && r.status.Status != kmsRotationFailed {
+		r.mutex.Unlock()
+		return errKMSRotationNotRunning
+	}
+	r.status.Status = kmsRotationRunning
+	r.status.Error = ""
+	r.status.Updated = time.Now().UTC()
+	r.startLocked()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Status - returns the key rotation state.
+func (r *keyRotator) Status() kmsRotationStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.status
+}
+
+// startLocked - starts a rotation goroutine continuing at the saved
+// progress, must be called with the mutex held.
+func (r *keyRotator) startLocked() {
+	r.run++
+	go r.rotate(r.run, r.status.From, r.status.To, r.status.Progress)
+}
+
+// rotate - re-seals object keys until all are rotated or the rotation
+// is paused or started over.
+func (r *keyRotator) rotate(run int, from, to string, progress kmsRotationProgress) {
+	err := rotateObjectKeys(r.objAPI, from, to, &progress, func() error {
+		return r.next(run, progress)
+	})
+	r.mutex.Lock()
+	if run != r.run || err == errKMSRotationStopped {
+		r.mutex.Unlock()
+		return
+	}
+	r.status.Progress = progress
+	r.status.Updated = time.Now().UTC()
+	switch err {
+	case nil:
+		r.status.Status = kmsRotationFinished
+	case errKMSRotationMaintenance:
+		r.status.Status = kmsRotationPaused
+		r.status.Error = err.Error()
+	default:
+		errorIf(err, "Unable to rotate object keys.")
+		r.status.Status = kmsRotationFailed
+		r.status.Error = err.Error()
+	}
+	r.mutex.Unlock()
+	errorIf(r.save(), "Unable to save key rotation state.")
+}
+
+// next - records progress after an object key was rotated, returns
+// errKMSRotationStopped if the rotation of run was paused or started
+// over.
+func (r *keyRotator) next(run int, progress kmsRotationProgress) error {
+	r.mutex.Lock()
+	if run != r.run || r.status.Status != kmsRotationRunning {
+		r.mutex.Unlock()
+		return errKMSRotationStopped
+	}
+	r.status.Progress = progress
+	r.status.Updated = time.Now().UTC()
+	save := time.Since(r.saved) >= kmsRotationSaveInterval
+	r.mutex.Unlock()
+
+	if save {
+		errorIf(r.save(), "Unable to save key rotation state.")
+	}
+	return nil
+}
+
+// save - saves the key rotation state.
+func (r *keyRotator) save() error {
+	r.saveMutex.Lock()
+	defer r.saveMutex.Unlock()
+	r.mutex.Lock()
+	data, err := json.Marshal(r.status)
+	r.saved = time.Now()
+	r.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeLocalConfigFile(filepath.Join(mustGetConfigPath(), kmsRotationStateFile), data)
+}
+
+// rotateMetadataKey - re-seals the object key in the metadata of object
+// saved by update and counts it in progress. Objects and uploads which
+// are not encrypted or were deleted meanwhile are not counted.
+func rotateMetadataKey(bucket, object, from, to string, progress *kmsRotationProgress, update func(func(metadata map[string]string) error) error) {
+	encrypted := false
+	err := update(func(metadata map[string]string) error {
+		info, ok, err := getObjectEncryptionInfo(object, metadata)
+		if err != nil {
+			return err
+		}
+		if encrypted = ok; !encrypted {
+			return errObjectKeySkipped
+		}
+		rotated, err := rotateObjectKey(bucket, &info, from, to)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			return errObjectKeySkipped
+		}
+		return setObjectEncryptionInfo(metadata, info)
+	})
+	switch err.(type) {
+	case nil:
+		progress.Rotated++
+		return
+	case ObjectNotFound, InvalidUploadID:
+		return
+	}
+	if err == errObjectKeySkipped {
+		if encrypted {
+			progress.Skipped++
+		}
+		return
+	}
+	errorIf(err, "Unable to rotate object key of %s/%s.", bucket, object)
+	progress.Failed++
+}
+
+// rotateObjectKeys - re-seals object keys of all buckets sealed with
+// master key from with master key to, continuing after progress. next
+// is called after every object and upload, the rotation stops if it
+// fails or when writes are rejected.
+func rotateObjectKeys(objAPI ObjectLayer, from, to string, progress *kmsRotationProgress, next func() error) error {
+	updater, ok := getMetadataUpdater(objAPI)
+	if !ok {
+		// Objects of other object layers are never encrypted.
+		return nil
+	}
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	for _, bucket := range buckets {
+		if bucket.Name < progress.Bucket {
+			continue
+		}
+		if bucket.Name != progress.Bucket {
+			progress.Bucket, progress.Uploads = bucket.Name, false
+			progress.Marker, progress.UploadIDMarker = "", ""
+		}
+		if !progress.Uploads {
+			if err = rotateBucketObjectKeys(objAPI, updater, bucket.Name, from, to, progress, next); err != nil {
+				return err
+			}
+			progress.Uploads, progress.Marker = true, ""
+		}
+		if err = rotateBucketUploadKeys(objAPI, updater, bucket.Name, from, to, progress, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotateBucketObjectKeys - re-seals the object keys of the objects of
+// bucket listed after progress.
+func rotateBucketObjectKeys(objAPI ObjectLayer, updater objectMetadataUpdater, bucket, from, to string, progress *kmsRotationProgress, next func() error) error {
+	for {
+		result, err := objAPI.ListObjects(bucket, "", progress.Marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, object := range result.Objects {
+			if !serverModeAllowsWrites() {
+				return errKMSRotationMaintenance
+			}
+			progress.Marker = object.Name
+			// Objects which are not encrypted are not updated.
+			if _, ok := object.Metadata[objectEncryptionMetaKey]; !ok {
+				continue
+			}
+			name := object.Name
+			rotateMetadataKey(bucket, name, from, to, progress, func(update func(map[string]string) error) error {
+				return updater.UpdateObjectMetadata(bucket, name, update)
+			})
+			if err = next(); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+	}
+}
+
+// rotateBucketUploadKeys - re-seals the object keys of the multipart
+// uploads of bucket listed after progress.
+func rotateBucketUploadKeys(objAPI ObjectLayer, updater objectMetadataUpdater, bucket, from, to string, progress *kmsRotationProgress, next func() error) error {
+	for {
+		result, err := objAPI.ListMultipartUploads(bucket, "", progress.Marker, progress.UploadIDMarker, "", maxUploadsList)
+		if err != nil {
+			return err
+		}
+		for _, upload := range result.Uploads {
+			if !serverModeAllowsWrites() {
+				return errKMSRotationMaintenance
+			}
+			progress.Marker, progress.UploadIDMarker = upload.Object, upload.UploadID
+			object, uploadID := upload.Object, upload.UploadID
+			rotateMetadataKey(bucket, object, from, to, progress, func(update func(map[string]string) error) error {
+				return updater.UpdateUploadMetadata(bucket, object, uploadID, update)
+			})
+			if err = next(); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+	}
+}
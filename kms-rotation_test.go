@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// Wrapper for calling testKeyRotation for both XL and FS.
+func TestKeyRotation(t *testing.T) {
+	ExecObjectLayerTest(t, testKeyRotation)
+}
+
+// Tests object keys are re-sealed with the current master key, the
+// rotation continues where it stopped.
+func testKeyRotation(obj ObjectLayer, instanceType string, t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	defer func() { globalKMS = nil }()
+
+	oldKey := "old-key:6368616e676520746869732070617373776f726420746f206120736563726574"
+	newKey := "new-key:7368616e676520746869732070617373776f726420746f206120736563726574"
+	if globalKMS, err = parseMasterKey(oldKey); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	bucket := "rotation-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := []byte("hello, rotation")
+	objects := []string{"a", "b", "dir/c"}
+	for _, object := range objects {
+		sse := objectEncryptionRequest{Algorithm: sseAlgorithmAES256}
+		if _, err = putEncryptedObject(context.Background(), obj, bucket, object, sse, int64(len(data)), bytes.NewReader(data), map[string]string{}); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	// New master key with the old one retired.
+	if globalKMS, err = parseMasterKey(newKey + "," + oldKey); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	// Rotation stopped after the first object key.
+	var progress kmsRotationProgress
+	err = rotateObjectKeys(obj, "old-key", "new-key", &progress, func() error { return errKMSRotationStopped })
+	if err != errKMSRotationStopped || progress.Rotated != 1 {
+		t.Fatalf("%s: Expected one rotated key, but got %+v %v", instanceType, progress, err)
+	}
+	// Nothing is rotated while writes are rejected.
+	defer globalServerMode.Set(serverModeOnline)
+	if err = globalServerMode.Set(serverModeReadOnly); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	err = rotateObjectKeys(obj, "old-key", "new-key", &progress, func() error { return nil })
+	if err != errKMSRotationMaintenance || progress.Rotated != 1 {
+		t.Fatalf("%s: Expected rotation paused while read-only, but got %+v %v", instanceType, progress, err)
+	}
+	if err = globalServerMode.Set(serverModeOnline); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Rotator continues at the saved progress.
+	r, err := newKeyRotator(obj)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = r.Resume(); err != errKMSRotationNotRunning {
+		t.Fatalf("%s: Expected %v, but got %v", instanceType, errKMSRotationNotRunning, err)
+	}
+	r.status = kmsRotationStatus{Status: kmsRotationPaused, From: "old-key", To: "new-key", Progress: progress}
+	if err = r.Resume(); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	status := r.Status()
+	for i := 0; i < 100 && status.Status == kmsRotationRunning; i++ {
+		time.Sleep(50 * time.Millisecond)
+		status = r.Status()
+	}
+	if status.Status != kmsRotationFinished || status.Progress.Rotated != 3 || status.Progress.Failed != 0 {
+		t.Fatalf("%s: Unexpected rotation status %+v", instanceType, status)
+	}
+	if err = r.Start("old-key", ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for i := 0; i < 100 && r.Status().Status == kmsRotationRunning; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if status = r.Status(); status.To != "new-key" || status.Progress.Rotated != 0 || status.Progress.Skipped != 3 {
+		t.Fatalf("%s: Expected all keys to be rotated already, but got %+v", instanceType, status)
+	}
+
+	// Objects are readable without the old master key.
+	if globalKMS, err = parseMasterKey(newKey); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for _, object := range objects {
+		objInfo, err := obj.GetObjectInfo(bucket, object)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		info, encrypted, err := getObjectEncryption(bucket, &objInfo)
+		if err != nil || !encrypted || info.KeyID != "new-key" {
+			t.Fatalf("%s: Unexpected encryption of %s: %+v %v %v", instanceType, object, info, encrypted, err)
+		}
+		objectKey, err := info.unsealKey(bucket)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		var buffer bytes.Buffer
+		if err = getDecryptedObject(context.Background(), obj, bucket, object, info, objectKey, 0, objInfo.Size, &buffer); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Errorf("%s: Expected %q, but got %q", instanceType, data, buffer.Bytes())
+		}
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported Vault authentication types.
+const (
+	vaultAuthToken   = "token"
+	vaultAuthAppRole = "approle"
+)
+
+// vaultConfig - Hashicorp Vault transit secrets engine configuration.
+type vaultConfig struct {
+	Enable   bool   `json:"enable"`
+	Endpoint string `json:"endpoint"`
+	Auth     struct {
+		Type    string `json:"type"`
+		Token   string `json:"token,omitempty"`
+		AppRole struct {
+			ID     string `json:"id"`
+			Secret string `json:"secret"`
+		} `json:"approle"`
+	} `json:"auth"`
+	Key struct {
+		Name string `json:"name"`
+	} `json:"key"`
+}
+
+// errVaultPermissionDenied - Vault rejected the current token.
+var errVaultPermissionDenied = errors.New("Vault permission denied")
+
+// vaultKMS - key management service backed by the transit secrets
+// engine of Hashicorp Vault.
+type vaultKMS struct {
+	config vaultConfig
+	client *http.Client
+
+	mutex *sync.Mutex
+	token string
+}
+
+// vaultResponse - generic Vault API response.
+type vaultResponse struct {
+	Errors []string `json:"errors"`
+	Data   struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"data"`
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+// validateVaultConfig - validates the Vault configuration without
+// connecting to Vault.
+func validateVaultConfig(config vaultConfig) error {
+	if _, err := url.Parse(config.Endpoint); err != nil || config.Endpoint == "" {
+		return fmt.Errorf("Invalid Vault endpoint ‘%s’", config.Endpoint)
+	}
+	if config.Key.Name == "" {
+		return errors.New("Vault master key name is missing")
+	}
+	switch config.Auth.Type {
+	case vaultAuthToken:
+		if config.Auth.Token == "" {
+			return errors.New("Vault token is missing")
+		}
+	case vaultAuthAppRole:
+		if config.Auth.AppRole.ID == "" || config.Auth.AppRole.Secret == "" {
+			return errors.New("Vault AppRole ID or secret is missing")
+		}
+	default:
+		return fmt.Errorf("Unsupported Vault authentication type ‘%s’", config.Auth.Type)
+	}
+	return nil
+}
+
+// newVaultKMS - validates the Vault configuration and authenticates
+// with Vault.
+func newVaultKMS(config vaultConfig) (*vaultKMS, error) {
+	if err := validateVaultConfig(config); err != nil {
+		return nil, err
+	}
+	kms := &vaultKMS{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		mutex:  &sync.Mutex{},
+	}
+	if config.Auth.Type == vaultAuthToken {
+		kms.token = config.Auth.Token
+		return kms, nil
+	}
+	if err := kms.login(); err != nil {
+		return nil, err
+	}
+	return kms, nil
+}
+
+// login - fetches a new client token using AppRole credentials.
+func (kms *vaultKMS) login() error {
+	payload := map[string]string{
+		"role_id":   kms.config.Auth.AppRole.ID,
+		"secret_id": kms.config.Auth.AppRole.Secret,
+	}
+	resp, err := kms.post("/v1/auth/approle/login", "", payload)
+	if err != nil {
+		return err
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("Vault AppRole login returned no token")
+	}
+	kms.mutex.Lock()
+	kms.token = resp.Auth.ClientToken
+	kms.mutex.Unlock()
+	return nil
+}
+
+// post - sends a JSON payload to the Vault API.
+func (kms *vaultKMS) post(path, token string, payload interface{}) (*vaultResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", strings.TrimSuffix(kms.config.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	httpResp, err := kms.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &vaultResponse{}
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+		if err = json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	case http.StatusForbidden:
+		return nil, errVaultPermissionDenied
+	default:
+		// Error responses carry a list of errors, ignore malformed ones.
+		json.NewDecoder(httpResp.Body).Decode(resp)
+		return nil, fmt.Errorf("Vault request failed with ‘%s’: %s", httpResp.Status, strings.Join(resp.Errors, ", "))
+	}
+}
+
+// transit - sends a request to the transit secrets engine, renewing
+// the client token once if it has expired.
+func (kms *vaultKMS) transit(path string, payload interface{}) (*vaultResponse, error) {
+	kms.mutex.Lock()
+	token := kms.token
+	kms.mutex.Unlock()
+
+	resp, err := kms.post(path, token, payload)
+	if err == errVaultPermissionDenied && kms.config.Auth.Type == vaultAuthAppRole {
+		if err = kms.login(); err != nil {
+			return nil, err
+		}
+		kms.mutex.Lock()
+		token = kms.token
+		kms.mutex.Unlock()
+		resp, err = kms.post(path, token, payload)
+	}
+	return resp, err
+}
+
+// KeyID - returns the name of the configured Vault master key.
+func (kms *vaultKMS) KeyID() string {
+	return kms.config.Key.Name
+}
+
+// GenerateKey - generates a new data key sealed by Vault.
+func (kms *vaultKMS) GenerateKey(keyID string, context []byte) (key [32]byte, sealedKey []byte, err error) {
+	payload := map[string]string{
+		"context": base64.StdEncoding.EncodeToString(context),
+	}
+	resp, err := kms.transit("/v1/transit/datakey/plaintext/"+url.QueryEscape(keyID), payload)
+	if err != nil {
+		return key, nil, err
+	}
+	plainKey, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil || len(plainKey) != len(key) {
+		return key, nil, errKMSInvalidSealedKey
+	}
+	copy(key[:], plainKey)
+	return key, []byte(resp.Data.Ciphertext), nil
+}
+
+// UnsealKey - decrypts a sealed data key using Vault.
+func (kms *vaultKMS) UnsealKey(keyID string, sealedKey []byte, context []byte) (key [32]byte, err error) {
+	payload := map[string]string{
+		"ciphertext": string(sealedKey),
+		"context":    base64.StdEncoding.EncodeToString(context),
+	}
+	resp, err := kms.transit("/v1/transit/decrypt/"+url.QueryEscape(keyID), payload)
+	if err != nil {
+		return key, err
+	}
+	plainKey, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil || len(plainKey) != len(key) {
+		return key, errKMSInvalidSealedKey
+	}
+	copy(key[:], plainKey)
+	return key, nil
+}
+
+// SealKey - encrypts a data key with the Vault master key.
+func (kms *vaultKMS) SealKey(keyID string, key [32]byte, context []byte) ([]byte, error) {
+	payload := map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(key[:]),
+		"context":   base64.StdEncoding.EncodeToString(context),
+	}
+	resp, err := kms.transit("/v1/transit/encrypt/"+url.QueryEscape(keyID), payload)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+// UpdateKey - rewraps a sealed data key with the latest version of
+// the Vault master key, without exposing the data key.
+func (kms *vaultKMS) UpdateKey(keyID string, sealedKey []byte, context []byte) ([]byte, error) {
+	payload := map[string]string{
+		"ciphertext": string(sealedKey),
+		"context":    base64.StdEncoding.EncodeToString(context),
+	}
+	resp, err := kms.transit("/v1/transit/rewrap/"+url.QueryEscape(keyID), payload)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
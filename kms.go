@@ -0,0 +1,206 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// kmsConfig carries configuration for the supported key management
+// services. Currently supported services are
+//
+//   - vault (Hashicorp Vault transit secrets engine)
+//
+// Alternatively a static master key can be provided through the
+// environment variable ‘MINIO_SSE_MASTER_KEY’.
+type kmsConfig struct {
+	Vault vaultConfig `json:"vault"`
+	// Add new key management services here.
+}
+
+// KMS - key management service, generates data keys and seals them
+// with a master key which never leaves the key management service.
+type KMS interface {
+	// KeyID - returns the ID of the default master key.
+	KeyID() string
+
+	// GenerateKey - generates a new random data key, returns the
+	// plain data key and the data key sealed with the master key
+	// referenced by keyID. The same context must be provided to
+	// unseal the data key again.
+	GenerateKey(keyID string, context []byte) (key [32]byte, sealedKey []byte, err error)
+
+	// UnsealKey - unseals a data key sealed by GenerateKey.
+	UnsealKey(keyID string, sealedKey []byte, context []byte) (key [32]byte, err error)
+
+	// UpdateKey - re-seals a sealed data key with the latest version
+	// of the master key referenced by keyID. The data key itself is
+	// not changed, so data encrypted with it stays readable.
+	UpdateKey(keyID string, sealedKey []byte, context []byte) (rotatedKey []byte, err error)
+
+	// SealKey - seals a data key with the master key referenced by
+	// keyID, used to move data keys to another master key.
+	SealKey(keyID string, key [32]byte, context []byte) (sealedKey []byte, err error)
+}
+
+// globalKMS is the key management service used for server side
+// encryption, nil if no key management service is configured.
+var globalKMS KMS
+
+// errKMSNotConfigured - no key management service is configured.
+var errKMSNotConfigured = errors.New("Key management service is not configured")
+
+// errKMSKeyNotFound - requested master key is not known to the key
+// management service.
+var errKMSKeyNotFound = errors.New("Master key not found")
+
+// errKMSInvalidSealedKey - sealed data key is malformed or was not
+// sealed by this master key.
+var errKMSInvalidSealedKey = errors.New("Sealed data key is invalid")
+
+// initKMS - initialize key management service from the config or the
+// environment, the environment takes precedence.
+func initKMS() {
+	if masterKey := os.Getenv("MINIO_SSE_MASTER_KEY"); masterKey != "" {
+		kms, err := parseMasterKey(masterKey)
+		fatalIf(err, "Unable to parse ‘MINIO_SSE_MASTER_KEY’.")
+		globalKMS = kms
+		return
+	}
+	vcfg := serverConfig.GetKMS().Vault
+	if !vcfg.Enable {
+		return
+	}
+	kms, err := newVaultKMS(vcfg)
+	fatalIf(err, "Unable to initialize Vault key management service.")
+	globalKMS = kms
+}
+
+// masterKeyKMS - key management service backed by static master keys,
+// meant for deployments without an external KMS. New data keys are
+// sealed with the first key, the others are retired keys kept until
+// all data keys were rotated away from them.
+type masterKeyKMS struct {
+	keyID      string
+	masterKeys map[string][32]byte
+}
+
+// parseMasterKey - parses a comma separated list of master keys of the
+// form '<key-id>:<hex-key>' where hex-key is the hex representation of
+// a 256 bit key.
+func parseMasterKey(masterKey string) (KMS, error) {
+	kms := &masterKeyKMS{masterKeys: make(map[string][32]byte)}
+	for _, entry := range strings.Split(masterKey, ",") {
+		splits := strings.SplitN(entry, ":", 2)
+		if len(splits) != 2 || splits[0] == "" {
+			return nil, errInvalidArgument
+		}
+		keyBytes, err := hex.DecodeString(splits[1])
+		if err != nil || len(keyBytes) != 32 {
+			return nil, errInvalidArgument
+		}
+		if _, ok := kms.masterKeys[splits[0]]; ok {
+			return nil, errInvalidArgument
+		}
+		var key [32]byte
+		copy(key[:], keyBytes)
+		kms.masterKeys[splits[0]] = key
+		if kms.keyID == "" {
+			kms.keyID = splits[0]
+		}
+	}
+	return kms, nil
+}
+
+// KeyID - returns the ID of the current master key.
+func (kms *masterKeyKMS) KeyID() string {
+	return kms.keyID
+}
+
+// sealingCipher - returns the AEAD used to seal data keys, derived
+// from the master key and the key ID.
+func (kms *masterKeyKMS) sealingCipher(keyID string) (cipher.AEAD, error) {
+	masterKey, ok := kms.masterKeys[keyID]
+	if !ok {
+		return nil, errKMSKeyNotFound
+	}
+	mac := hmac.New(sha256.New, masterKey[:])
+	mac.Write([]byte(keyID))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// GenerateKey - generates a new data key sealed with the master key.
+func (kms *masterKeyKMS) GenerateKey(keyID string, context []byte) (key [32]byte, sealedKey []byte, err error) {
+	if _, err = io.ReadFull(rand.Reader, key[:]); err != nil {
+		return key, nil, err
+	}
+	sealedKey, err = kms.SealKey(keyID, key, context)
+	return key, sealedKey, err
+}
+
+// SealKey - seals a data key with the master key.
+func (kms *masterKeyKMS) SealKey(keyID string, key [32]byte, context []byte) ([]byte, error) {
+	aead, err := kms.sealingCipher(keyID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, key[:], context), nil
+}
+
+// UnsealKey - unseals a data key sealed with the master key.
+func (kms *masterKeyKMS) UnsealKey(keyID string, sealedKey []byte, context []byte) (key [32]byte, err error) {
+	aead, err := kms.sealingCipher(keyID)
+	if err != nil {
+		return key, err
+	}
+	if len(sealedKey) != aead.NonceSize()+len(key)+aead.Overhead() {
+		return key, errKMSInvalidSealedKey
+	}
+	nonce, ciphertext := sealedKey[:aead.NonceSize()], sealedKey[aead.NonceSize():]
+	plainKey, err := aead.Open(nil, nonce, ciphertext, context)
+	if err != nil {
+		return key, errKMSInvalidSealedKey
+	}
+	copy(key[:], plainKey)
+	return key, nil
+}
+
+// UpdateKey - a static master key has no versions, the sealed key is
+// verified and returned as is.
+func (kms *masterKeyKMS) UpdateKey(keyID string, sealedKey []byte, context []byte) ([]byte, error) {
+	if _, err := kms.UnsealKey(keyID, sealedKey, context); err != nil {
+		return nil, err
+	}
+	return sealedKey, nil
+}
@@ -26,6 +26,8 @@ import (
 type consoleLogger struct {
 	Enable bool   `json:"enable"`
 	Level  string `json:"level"`
+	// Log JSON instead of text, e.g. for log collectors.
+	JSON bool `json:"json"`
 }
 
 // enable console logger.
@@ -43,4 +45,7 @@ func enableConsoleLogger() {
 	fatalIf(err, "Unknown log level found in the config file.")
 
 	log.Level = lvl
+	if clogger.JSON {
+		log.Formatter = &logrus.JSONFormatter{}
+	}
 }
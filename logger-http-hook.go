@@ -0,0 +1,106 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Number of log entries buffered for the http logger, entries are
+// dropped while the endpoint cannot keep up.
+const httpLoggerBuffer = 1000
+
+// httpLogger - sends JSON log entries in POST requests to Endpoint.
+type httpLogger struct {
+	Enable   bool   `json:"enable"`
+	Endpoint string `json:"endpoint"`
+	Level    string `json:"level"`
+}
+
+// httpHook - logrus hook shipping log entries to a http endpoint.
+type httpHook struct {
+	endpoint  string
+	client    *http.Client
+	levels    []logrus.Level
+	formatter logrus.Formatter
+	entryCh   chan []byte
+}
+
+func enableHTTPLogger() {
+	hlogger := serverConfig.GetHTTPLogger()
+	if !hlogger.Enable || hlogger.Endpoint == "" {
+		return
+	}
+
+	lvl, err := logrus.ParseLevel(hlogger.Level)
+	fatalIf(err, "Unknown log level found in the config file.")
+
+	// Add a http hook.
+	log.Hooks.Add(newHTTPHook(hlogger.Endpoint, lvl))
+	if lvl > log.Level {
+		log.Level = lvl // Minimum log level.
+	}
+}
+
+// newHTTPHook - returns a hook sending entries up to level to endpoint.
+func newHTTPHook(endpoint string, level logrus.Level) *httpHook {
+	hook := &httpHook{
+		endpoint:  endpoint,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		formatter: &logrus.JSONFormatter{},
+		entryCh:   make(chan []byte, httpLoggerBuffer),
+	}
+	for lvl := logrus.PanicLevel; lvl <= level; lvl++ {
+		hook.levels = append(hook.levels, lvl)
+	}
+	go hook.send()
+	return hook
+}
+
+// send - posts queued entries to the endpoint, entries are not retried
+// to never block logging.
+func (hook *httpHook) send() {
+	for line := range hook.entryCh {
+		resp, err := hook.client.Post(hook.endpoint, "application/json", bytes.NewReader(line))
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
+// Fire - queues the JSON formatted entry.
+func (hook *httpHook) Fire(entry *logrus.Entry) error {
+	line, err := hook.formatter.Format(entry)
+	if err != nil {
+		return fmt.Errorf("Unable to read entry, %v", err)
+	}
+	select {
+	case hook.entryCh <- line:
+	default:
+	}
+	return nil
+}
+
+// Levels - indicate log levels supported.
+func (hook *httpHook) Levels() []logrus.Level {
+	return hook.levels
+}
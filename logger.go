@@ -19,6 +19,8 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"net/http"
 	"os"
 	"reflect"
 	"runtime"
@@ -40,11 +42,13 @@ var log = logrus.New() // Default console logger.
 //   - console [default]
 //   - file
 //   - syslog
+//   - http
 //
 type logger struct {
 	Console consoleLogger `json:"console"`
 	File    fileLogger    `json:"file"`
 	Syslog  syslogLogger  `json:"syslog"`
+	HTTP    httpLogger    `json:"http"`
 	// Add new loggers here.
 }
 
@@ -86,20 +90,38 @@ func stackInfo() string {
 	return strings.Replace(stackBuf.String(), minioGOPATH+"/src/", "", -1)
 }
 
-// errorIf synonymous with fatalIf but doesn't exit on error != nil
-func errorIf(err error, msg string, data ...interface{}) {
-	if err == nil {
-		return
-	}
-	sysInfo := sysInfo()
+// errorFields - returns the structured fields logged for err.
+func errorFields(err error) logrus.Fields {
 	fields := logrus.Fields{
 		"cause":   err.Error(),
 		"type":    reflect.TypeOf(err),
-		"sysInfo": sysInfo,
+		"sysInfo": sysInfo(),
 	}
 	if globalTrace {
 		fields["stack"] = "\n" + stackInfo()
 	}
+	return fields
+}
+
+// errorIf synonymous with fatalIf but doesn't exit on error != nil
+func errorIf(err error, msg string, data ...interface{}) {
+	if err == nil {
+		return
+	}
+	log.WithFields(errorFields(err)).Errorf(msg, data...)
+}
+
+// errorIfRequest - same as errorIf but also logs the API call and its
+// request ID to correlate the error with audit and trace output.
+func errorIfRequest(r *http.Request, err error, msg string, data ...interface{}) {
+	// Clients going away is not an error of the server.
+	if err == nil || err == context.Canceled {
+		return
+	}
+	fields := errorFields(err)
+	fields["requestID"] = getRequestID(r)
+	fields["method"] = r.Method
+	fields["path"] = r.URL.Path
 	log.WithFields(fields).Errorf(msg, data...)
 }
 
@@ -108,14 +130,5 @@ func fatalIf(err error, msg string, data ...interface{}) {
 	if err == nil {
 		return
 	}
-	sysInfo := sysInfo()
-	fields := logrus.Fields{
-		"cause":   err.Error(),
-		"type":    reflect.TypeOf(err),
-		"sysInfo": sysInfo,
-	}
-	if globalTrace {
-		fields["stack"] = "\n" + stackInfo()
-	}
-	log.WithFields(fields).Fatalf(msg, data...)
+	log.WithFields(errorFields(err)).Fatalf(msg, data...)
 }
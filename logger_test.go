@@ -18,8 +18,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 
 	"github.com/Sirupsen/logrus"
 
@@ -45,3 +48,44 @@ func (s *LoggerSuite) TestLogger(c *C) {
 	c.Assert(ok, Equals, true)
 	c.Assert(msg, Equals, "Fake error")
 }
+
+func (s *LoggerSuite) TestLoggerRequest(c *C) {
+	var buffer bytes.Buffer
+	var fields logrus.Fields
+	log.Out = &buffer
+	log.Formatter = new(logrus.JSONFormatter)
+
+	req, err := http.NewRequest("GET", "http://localhost:9000/bucket/object", nil)
+	c.Assert(err, IsNil)
+	req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, "3L137"))
+
+	errorIfRequest(req, errors.New("Fake error"), "Failed with error.")
+	err = json.Unmarshal(buffer.Bytes(), &fields)
+	c.Assert(err, IsNil)
+	c.Assert(fields["level"], Equals, "error")
+	c.Assert(fields["requestID"], Equals, "3L137")
+	c.Assert(fields["path"], Equals, "/bucket/object")
+}
+
+func (s *LoggerSuite) TestHTTPHook(c *C) {
+	entryCh := make(chan logrus.Fields, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fields logrus.Fields
+		if err := json.NewDecoder(r.Body).Decode(&fields); err == nil {
+			entryCh <- fields
+		}
+	}))
+	defer server.Close()
+
+	hook := newHTTPHook(server.URL, logrus.ErrorLevel)
+	c.Assert(hook.Levels(), DeepEquals, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel})
+
+	entry := logrus.NewEntry(logrus.New()).WithField("cause", "Fake error")
+	entry.Message = "Failed with error."
+	entry.Level = logrus.ErrorLevel
+	c.Assert(hook.Fire(entry), IsNil)
+
+	fields := <-entryCh
+	c.Assert(fields["msg"], Equals, "Failed with error.")
+	c.Assert(fields["cause"], Equals, "Fake error")
+}
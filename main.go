@@ -83,6 +83,7 @@ func enableLoggers() {
 	// Enable all loggers here.
 	enableConsoleLogger()
 	enableFileLogger()
+	enableHTTPLogger()
 
 	// Add your logger here.
 }
@@ -111,6 +112,10 @@ func findClosestCommands(command string) []string {
 func registerApp() *cli.App {
 	// Register all commands.
 	registerCommand(serverCmd)
+	registerCommand(gatewayCmd)
+	registerCommand(importCmd)
+	registerCommand(rebalanceCmd)
+	registerCommand(xlMetaCmd)
 	registerCommand(versionCmd)
 	registerCommand(updateCmd)
 
@@ -158,6 +163,9 @@ func main() {
 		// Sets new config folder.
 		setGlobalConfigPath(c.GlobalString("config-dir"))
 
+		// Sets new certs folder.
+		setGlobalCertsPath(c.GlobalString("certs-dir"))
+
 		// Valid input arguments to main.
 		checkMainSyntax(c)
 
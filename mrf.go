@@ -0,0 +1,215 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// Objects waiting to be healed, stored in minioMetaBucket.
+	mrfFile = "mrf.json"
+
+	// Number of objects waiting to be healed before new ones are
+	// dropped, they are left to a full heal then.
+	mrfQueueSize = 10000
+)
+
+var (
+	// Time between two passes over the objects waiting to be healed,
+	// the queue is saved after every pass.
+	mrfHealInterval = time.Minute
+
+	// Pause per healed object, limits the load healing puts on the
+	// disks.
+	mrfHealDelay = 10 * time.Millisecond
+)
+
+// objectHealer - implemented by object layers which can heal objects.
+type objectHealer interface {
+	// HealObject - rewrites the object on all disks, fails with
+	// errSomeDiskOffline if not all disks can be written.
+	HealObject(bucket, object string) error
+}
+
+// mrfEntry - object written while some disks failed.
+type mrfEntry struct {
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+	// Time the object was queued last, it is healed again if it was
+	// queued while being healed.
+	Queued time.Time `json:"queued"`
+	// Heal attempts which failed so far.
+	Failures  int    `json:"failures,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// mrfSys - most recently failed writes, objects written while some
+// disks failed are healed in the background instead of waiting for a
+// full heal. The queue survives restarts.
+type mrfSys struct {
+	healer objectHealer
+	objAPI ObjectLayer
+
+	mutex   *sync.Mutex
+	entries map[string]mrfEntry
+	dirty   bool
+}
+
+// globalMRF - heal queue of the server, nil for object layers which
+// cannot heal.
+var globalMRF *mrfSys
+
+// newMRFSys - returns the heal queue of objAPI loaded from the backend,
+// nil if objAPI cannot heal objects. An unreadable queue is started
+// over, its objects are left to a full heal.
+func newMRFSys(objAPI ObjectLayer) *mrfSys {
+	healer, ok := objAPI.(objectHealer)
+	if !ok {
+		return nil
+	}
+	sys := &mrfSys{
+		healer:  healer,
+		objAPI:  objAPI,
+		mutex:   &sync.Mutex{},
+		entries: make(map[string]mrfEntry),
+	}
+	data, err := loadMetaFile(objAPI, mrfFile)
+	if err != nil {
+		if err != errFileNotFound {
+			errorIf(err, "Unable to load heal queue.")
+		}
+		return sys
+	}
+	var entries []mrfEntry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		errorIf(err, "Unable to load heal queue.")
+		return sys
+	}
+	for _, entry := range entries {
+		sys.entries[pathJoin(entry.Bucket, entry.Object)] = entry
+	}
+	return sys
+}
+
+// queueHeal - queues an object written while some disks failed for
+// healing.
+func queueHeal(bucket, object string) {
+	if globalMRF == nil {
+		return
+	}
+	globalMRF.enqueue(bucket, object)
+}
+
+// enqueue - queues an object for healing.
+func (sys *mrfSys) enqueue(bucket, object string) {
+	key := pathJoin(bucket, object)
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+	entry, ok := sys.entries[key]
+	if !ok && len(sys.entries) >= mrfQueueSize {
+		errorIf(errors.New("Heal queue is full"), "Unable to queue %s for healing.", key)
+		return
+	}
+	entry.Bucket, entry.Object = bucket, object
+	entry.Queued = time.Now().UTC()
+	sys.entries[key] = entry
+	sys.dirty = true
+}
+
+// pending - returns the objects waiting to be healed.
+func (sys *mrfSys) pending() []mrfEntry {
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+	entries := make([]mrfEntry, 0, len(sys.entries))
+	for _, entry := range sys.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// save - saves the queue to the backend if it changed.
+func (sys *mrfSys) save() error {
+	sys.mutex.Lock()
+	if !sys.dirty {
+		sys.mutex.Unlock()
+		return nil
+	}
+	sys.dirty = false
+	sys.mutex.Unlock()
+	if err := saveJSONMetaFile(sys.objAPI, mrfFile, sys.pending()); err != nil {
+		sys.mutex.Lock()
+		sys.dirty = true
+		sys.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// healPending - heals all queued objects once. Objects are dropped from
+// the queue once healed or deleted, objects which cannot be healed yet
+// stay queued for the next pass.
+func (sys *mrfSys) healPending(doneCh <-chan struct{}) {
+	for _, entry := range sys.pending() {
+		// Healing writes, which are rejected during maintenance.
+		if !serverModeAllowsWrites() {
+			return
+		}
+		err := sys.healer.HealObject(entry.Bucket, entry.Object)
+		if _, ok := err.(ObjectNotFound); ok {
+			err = nil
+		}
+		key := pathJoin(entry.Bucket, entry.Object)
+		sys.mutex.Lock()
+		queued, ok := sys.entries[key]
+		if ok && err == nil && queued.Queued.Equal(entry.Queued) {
+			delete(sys.entries, key)
+		} else if ok && err != nil {
+			queued.Failures++
+			queued.LastError = err.Error()
+			sys.entries[key] = queued
+		}
+		sys.dirty = true
+		sys.mutex.Unlock()
+		// Offline disks are common, they are not worth logging per object.
+		if err != nil && err != errSomeDiskOffline {
+			errorIf(err, "Unable to heal %s.", key)
+		}
+
+		select {
+		case <-doneCh:
+			return
+		case <-time.After(mrfHealDelay):
+		}
+	}
+}
+
+// run - heals queued objects until doneCh is closed.
+func (sys *mrfSys) run(doneCh <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(mrfHealInterval):
+			sys.healPending(doneCh)
+			errorIf(sys.save(), "Unable to save heal queue.")
+		case <-doneCh:
+			return
+		}
+	}
+}
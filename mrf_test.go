@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+)
+
+// Tests objects written while a disk failed are queued, healed and
+// dropped from the queue.
+func TestMRF(t *testing.T) {
+	obj, disks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+	xl := obj.(xlObjects)
+
+	globalMRF = newMRFSys(obj)
+	if globalMRF == nil {
+		t.Fatal("Expected a heal queue for XL")
+	}
+	defer func() { globalMRF = nil }()
+
+	bucket := "mrf-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		object string
+		size   int
+	}{
+		// Inlined into `xl.json`.
+		{"small", 5},
+		// Written to part files.
+		{"dir/large", xlInlineDataThreshold + 1},
+	}
+	for i, testCase := range testCases {
+		data := bytes.Repeat([]byte("a"), testCase.size)
+
+		// Write with the first disk failed.
+		failedXL := xl
+		failedXL.storageDisks = append([]StorageAPI(nil), xl.storageDisks...)
+		failedXL.storageDisks[0] = nil
+		if _, err = failedXL.PutObject(context.Background(), bucket, testCase.object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		pending := globalMRF.pending()
+		if len(pending) != 1 || pending[0].Bucket != bucket || pending[0].Object != testCase.object {
+			t.Fatalf("Test %d: Expected %s to be queued, but instead found %v", i+1, testCase.object, pending)
+		}
+
+		// Healing fails while a disk is still offline.
+		if err = failedXL.HealObject(bucket, testCase.object); err != errSomeDiskOffline {
+			t.Fatalf("Test %d: Expected %s, but instead found %v", i+1, errSomeDiskOffline, err)
+		}
+
+		globalMRF.healPending(nil)
+		if pending = globalMRF.pending(); len(pending) != 0 {
+			t.Fatalf("Test %d: Expected empty queue, but instead found %v", i+1, pending)
+		}
+		if _, err = os.Stat(path.Join(disks[0], bucket, testCase.object, xlMetaJSONFile)); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+
+		// The object is readable with the healed disk and just enough
+		// other disks for read quorum.
+		degradedXL := xl
+		degradedXL.storageDisks = append([]StorageAPI(nil), xl.storageDisks...)
+		for index := 1; index < len(degradedXL.storageDisks)-degradedXL.readQuorum+1; index++ {
+			degradedXL.storageDisks[index] = nil
+		}
+		degradedXL.metaCache = newXLMetaCache(xlMetaCacheSize)
+		var buffer bytes.Buffer
+		if err = degradedXL.GetObject(context.Background(), bucket, testCase.object, 0, int64(len(data)), &buffer); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Fatalf("Test %d: Healed object differs from the written one", i+1)
+		}
+	}
+
+	// Deleted objects are dropped from the queue.
+	queueHeal(bucket, "deleted")
+	globalMRF.healPending(nil)
+	if pending := globalMRF.pending(); len(pending) != 0 {
+		t.Fatalf("Expected empty queue, but instead found %v", pending)
+	}
+
+	// The queue survives restarts.
+	queueHeal(bucket, "queued")
+	if err = globalMRF.save(); err != nil {
+		t.Fatal(err)
+	}
+	pending := newMRFSys(obj).pending()
+	if len(pending) != 1 || pending[0].Object != "queued" {
+		t.Fatalf("Expected queued object to be loaded, but instead found %v", pending)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// Lock files not refreshed for this long are left behind by
+	// crashed processes and are removed.
+	nsFileLockStaleAfter = 2 * time.Minute
+	// Lock files are refreshed while held.
+	nsFileLockRefreshInterval = 30 * time.Second
+	// Interval to retry locks held by other processes.
+	nsFileLockRetryInterval = 10 * time.Millisecond
+)
+
+// nsFileLock - lock file held by this process.
+type nsFileLock struct {
+	token  string
+	doneCh chan struct{}
+}
+
+// nsFileLocker - locks names across processes sharing a file system by
+// exclusively creating lock files in dir. Unlike advisory locks this
+// works reliably over NFS.
+type nsFileLocker struct {
+	dir        string
+	staleAfter time.Duration
+
+	mutex sync.Mutex
+	locks map[string]nsFileLock
+}
+
+// newNSFileLocker - initializes a locker with lock files in dir.
+func newNSFileLocker(dir string) (*nsFileLocker, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &nsFileLocker{
+		dir:        dir,
+		staleAfter: nsFileLockStaleAfter,
+		locks:      make(map[string]nsFileLock),
+	}, nil
+}
+
+// lockFile - returns the lock file of name.
+func (l *nsFileLocker) lockFile(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(l.dir, hex.EncodeToString(sum[:])+".lock")
+}
+
+// lock - blocks until the lock of name is acquired.
+func (l *nsFileLocker) lock(name string) {
+	lockFile := l.lockFile(name)
+	token := getUUID()
+	for {
+		file, err := os.OpenFile(lockFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = file.WriteString(token)
+			file.Close()
+			if err == nil {
+				break
+			}
+			os.Remove(lockFile)
+		} else if !os.IsExist(err) {
+			errorIf(err, "Unable to create lock file %s.", lockFile)
+		} else if fi, serr := os.Stat(lockFile); serr == nil && time.Since(fi.ModTime()) > l.staleAfter {
+			// Lock holder has gone away.
+			os.Remove(lockFile)
+			continue
+		}
+		time.Sleep(nsFileLockRetryInterval)
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(nsFileLockRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				now := time.Now()
+				os.Chtimes(lockFile, now, now)
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	l.mutex.Lock()
+	l.locks[name] = nsFileLock{token: token, doneCh: doneCh}
+	l.mutex.Unlock()
+}
+
+// unlock - releases the lock of name, lock files which have been taken
+// over by another process in the meantime are left alone.
+func (l *nsFileLocker) unlock(name string) {
+	l.mutex.Lock()
+	fileLock, ok := l.locks[name]
+	delete(l.locks, name)
+	l.mutex.Unlock()
+	if !ok {
+		return
+	}
+	close(fileLock.doneCh)
+
+	lockFile := l.lockFile(name)
+	if token, err := ioutil.ReadFile(lockFile); err == nil && string(token) == fileLock.token {
+		os.Remove(lockFile)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests lock files are exclusive across lockers sharing a directory.
+func TestNSFileLocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-locks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	// Lockers of two processes.
+	locker1, err := newNSFileLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locker2, err := newNSFileLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locker1.lock("bucket/object")
+	lockedCh := make(chan struct{})
+	go func() {
+		locker2.lock("bucket/object")
+		close(lockedCh)
+	}()
+
+	// Other names are not blocked.
+	locker2.lock("bucket/other")
+	locker2.unlock("bucket/other")
+
+	select {
+	case <-lockedCh:
+		t.Fatal("Expected lock to be held by the first locker")
+	case <-time.After(100 * time.Millisecond):
+	}
+	locker1.unlock("bucket/object")
+	select {
+	case <-lockedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected lock to be acquired after unlock")
+	}
+	locker2.unlock("bucket/object")
+
+	if _, err = os.Stat(locker1.lockFile("bucket/object")); !os.IsNotExist(err) {
+		t.Fatalf("Expected lock file to be removed, but instead found %v", err)
+	}
+}
+
+// Tests lock files of crashed processes are taken over.
+func TestNSFileLockerStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-locks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	locker1, err := newNSFileLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locker2, err := newNSFileLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locker1.lock("bucket/object")
+	lockFile := locker1.lockFile("bucket/object")
+	past := time.Now().Add(-2 * nsFileLockStaleAfter)
+	if err = os.Chtimes(lockFile, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	lockedCh := make(chan struct{})
+	go func() {
+		locker2.lock("bucket/object")
+		close(lockedCh)
+	}()
+	select {
+	case <-lockedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected stale lock to be taken over")
+	}
+
+	// Lock file taken over is not removed by the previous holder.
+	locker1.unlock("bucket/object")
+	if _, err = os.Stat(lockFile); err != nil {
+		t.Fatalf("Expected lock file of the new holder, but instead found %v", err)
+	}
+	locker2.unlock("bucket/object")
+	if _, err = os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Fatalf("Expected lock file to be removed, but instead found %v", err)
+	}
+}
@@ -18,7 +18,9 @@ package main
 
 import (
 	"errors"
+	"sort"
 	"sync"
+	"time"
 )
 
 // nsParam - carries name space resource.
@@ -31,6 +33,12 @@ type nsParam struct {
 type nsLock struct {
 	*sync.RWMutex
 	ref uint
+
+	// Number of holders, whether the lock is held for writes and
+	// since when it is held, all protected by the mutex of the map.
+	holders   uint
+	writeLock bool
+	since     time.Time
 }
 
 // nsLockMap - namespace lock map, provides primitives to Lock,
@@ -38,6 +46,10 @@ type nsLock struct {
 type nsLockMap struct {
 	lockMap map[nsParam]*nsLock
 	mutex   *sync.Mutex
+
+	// Locks of the meta bucket are shared with other processes
+	// through lock files if set.
+	fileLocker *nsFileLocker
 }
 
 // Global name space lock.
@@ -74,16 +86,35 @@ func (n *nsLockMap) lock(volume, path string, readLock bool) {
 	} else {
 		nsLk.Lock()
 	}
+
+	n.mutex.Lock()
+	if nsLk.holders == 0 {
+		nsLk.writeLock = !readLock
+		nsLk.since = time.Now().UTC()
+	}
+	nsLk.holders++
+	n.mutex.Unlock()
+
+	if n.fileLocker != nil && volume == minioMetaBucket {
+		n.fileLocker.lock(pathJoin(volume, path))
+	}
 }
 
 // Unlock the namespace resource.
 func (n *nsLockMap) unlock(volume, path string, readLock bool) {
+	if n.fileLocker != nil && volume == minioMetaBucket {
+		n.fileLocker.unlock(pathJoin(volume, path))
+	}
+
 	// nsLk.Unlock() will not block, hence locking the map for the entire function is fine.
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
 
 	param := nsParam{volume, path}
 	if nsLk, found := n.lockMap[param]; found {
+		if nsLk.holders != 0 {
+			nsLk.holders--
+		}
 		if readLock {
 			nsLk.RUnlock()
 		} else {
@@ -126,3 +157,40 @@ func (n *nsLockMap) RUnlock(volume, path string) {
 	readLock := true
 	n.unlock(volume, path, readLock)
 }
+
+// nsLockInfo - a held namespace lock.
+type nsLockInfo struct {
+	Volume    string    `json:"volume"`
+	Path      string    `json:"path"`
+	WriteLock bool      `json:"writeLock"`
+	Since     time.Time `json:"since"`
+	Holders   uint      `json:"holders"`
+	Waiters   uint      `json:"waiters"`
+}
+
+// heldLocks - returns the locks held for at least olderThan, oldest
+// first.
+func (n *nsLockMap) heldLocks(olderThan time.Duration) []nsLockInfo {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	locks := []nsLockInfo{}
+	now := time.Now().UTC()
+	for param, nsLk := range n.lockMap {
+		if nsLk.holders == 0 || now.Sub(nsLk.since) < olderThan {
+			continue
+		}
+		locks = append(locks, nsLockInfo{
+			Volume:    param.volume,
+			Path:      param.path,
+			WriteLock: nsLk.writeLock,
+			Since:     nsLk.since,
+			Holders:   nsLk.holders,
+			Waiters:   nsLk.ref - nsLk.holders,
+		})
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Since.Before(locks[j].Since)
+	})
+	return locks
+}
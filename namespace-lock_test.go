@@ -16,7 +16,10 @@
 
 package main
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // Tests functionality provided by namespace lock.
 func TestNamespaceLockTest(t *testing.T) {
@@ -124,3 +127,54 @@ func TestNamespaceLockTest(t *testing.T) {
 		t.Errorf("Lock map not found.")
 	}
 }
+
+// Tests held locks are listed with their holders and waiters.
+func TestNamespaceHeldLocks(t *testing.T) {
+	initNSLock()
+
+	nsMutex.RLock("bucket", "object")
+	nsMutex.RLock("bucket", "object")
+	nsMutex.Lock("bucket", "other")
+	locked := make(chan struct{})
+	go func() {
+		nsMutex.Lock("bucket", "other")
+		close(locked)
+	}()
+	// Wait for the writer to wait for the lock.
+	for {
+		nsMutex.mutex.Lock()
+		ref := nsMutex.lockMap[nsParam{"bucket", "other"}].ref
+		nsMutex.mutex.Unlock()
+		if ref == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	locks := nsMutex.heldLocks(0)
+	if len(locks) != 2 {
+		t.Fatalf("Expected 2 held locks, got %#v", locks)
+	}
+	read, write := locks[0], locks[1]
+	if read.Path != "object" {
+		read, write = write, read
+	}
+	if read.WriteLock || read.Holders != 2 || read.Waiters != 0 {
+		t.Fatalf("Unexpected read lock %#v", read)
+	}
+	if !write.WriteLock || write.Holders != 1 || write.Waiters != 1 {
+		t.Fatalf("Unexpected write lock %#v", write)
+	}
+	if locks = nsMutex.heldLocks(time.Hour); len(locks) != 0 {
+		t.Fatalf("Expected no locks held for an hour, got %#v", locks)
+	}
+
+	nsMutex.Unlock("bucket", "other")
+	<-locked
+	nsMutex.Unlock("bucket", "other")
+	nsMutex.RUnlock("bucket", "object")
+	nsMutex.RUnlock("bucket", "object")
+	if locks = nsMutex.heldLocks(0); len(locks) != 0 {
+		t.Fatalf("Expected no held locks, got %#v", locks)
+	}
+}
@@ -0,0 +1,812 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ONC RPC and NFSv3 constants, see RFC 5531 and RFC 1813.
+const (
+	rpcVersion      = 2
+	rpcCall         = 0
+	rpcReply        = 1
+	rpcAccepted     = 0
+	rpcDenied       = 1
+	rpcMismatch     = 0
+	rpcSuccess      = 0
+	rpcProgUnavail  = 1
+	rpcProgMismatch = 2
+	rpcProcUnavail  = 3
+	rpcGarbageArgs  = 4
+
+	mountProgram = 100005
+	mountVersion = 3
+	nfsProgram   = 100003
+	nfsVersion   = 3
+
+	nfs3OK             = 0
+	nfs3ErrNoEnt       = 2
+	nfs3ErrIO          = 5
+	nfs3ErrAccess      = 13
+	nfs3ErrNotDir      = 20
+	nfs3ErrIsDir       = 21
+	nfs3ErrROFS        = 30
+	nfs3ErrNameTooLong = 63
+	nfs3ErrStale       = 70
+	nfs3ErrBadHandle   = 10001
+	nfs3ErrBadCookie   = 10003
+	nfs3ErrNotSupp     = 10004
+	nfs3ErrTooSmall    = 10005
+
+	nfs3Reg = 1
+	nfs3Dir = 2
+)
+
+const (
+	// Largest RPC request accepted, a read-only export receives no data.
+	nfsMaxRequestSize = 64 * 1024
+
+	// Largest READ served and preferred by clients.
+	nfsMaxReadSize = 1024 * 1024
+
+	// Size of the file handles, a hash of the path they refer to.
+	nfsHandleSize = 16
+)
+
+var errNFSRecordTooLarge = errors.New("RPC record too large")
+
+// nfsFile - a file or directory of an exported bucket, name is the
+// object or prefix without the trailing slash, empty for the bucket.
+type nfsFile struct {
+	bucket string
+	name   string
+	isDir  bool
+}
+
+// nfsServer - read-only NFSv3 and MOUNT server exporting buckets of an
+// object layer. Both programs are served on the same TCP port, clients
+// mount with the port, mountport and nolock options as there is no
+// portmapper and no lock manager.
+type nfsServer struct {
+	objAPI  ObjectLayer
+	exports map[string]struct{}
+
+	// File handles handed out, handles are not persisted and become
+	// stale when the server restarts.
+	mu      sync.RWMutex
+	handles map[[nfsHandleSize]byte]nfsFile
+}
+
+// newNFSServer - returns a new NFS server exporting buckets of objAPI.
+func newNFSServer(objAPI ObjectLayer, buckets []string) *nfsServer {
+	exports := make(map[string]struct{})
+	for _, bucket := range buckets {
+		exports[bucket] = struct{}{}
+	}
+	return &nfsServer{
+		objAPI:  objAPI,
+		exports: exports,
+		handles: make(map[[nfsHandleSize]byte]nfsFile),
+	}
+}
+
+// Serve - serves NFS clients accepted on listener until it is closed.
+func (s *nfsServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn - serves RPC calls of a connection, calls are executed
+// concurrently and replied to as they complete.
+func (s *nfsServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	reader := bufio.NewReader(conn)
+	for {
+		record, err := readRPCRecord(reader)
+		if err != nil {
+			return
+		}
+		go func() {
+			reply := s.handleCall(record)
+			if reply == nil {
+				return
+			}
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			conn.Write(reply)
+		}()
+	}
+}
+
+// readRPCRecord - reads all fragments of an RPC record.
+func readRPCRecord(reader io.Reader) ([]byte, error) {
+	var record []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return nil, err
+		}
+		mark := binary.BigEndian.Uint32(header[:])
+		size := int(mark & 0x7fffffff)
+		if len(record)+size > nfsMaxRequestSize {
+			return nil, errNFSRecordTooLarge
+		}
+		fragment := make([]byte, size)
+		if _, err := io.ReadFull(reader, fragment); err != nil {
+			return nil, err
+		}
+		record = append(record, fragment...)
+		if mark&0x80000000 != 0 {
+			return record, nil
+		}
+	}
+}
+
+// xdrWriter - encodes XDR values, see RFC 4506.
+type xdrWriter struct {
+	bytes.Buffer
+}
+
+func (w *xdrWriter) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	w.Write(b[:])
+}
+
+func (w *xdrWriter) uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	w.Write(b[:])
+}
+
+func (w *xdrWriter) bool(v bool) {
+	if v {
+		w.uint32(1)
+	} else {
+		w.uint32(0)
+	}
+}
+
+func (w *xdrWriter) opaque(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.Write(b)
+	w.Write(make([]byte, (4-len(b)%4)%4))
+}
+
+func (w *xdrWriter) string(s string) {
+	w.opaque([]byte(s))
+}
+
+// xdrReader - decodes XDR values, the first error is kept and zero
+// values are returned after it.
+type xdrReader struct {
+	data []byte
+	err  error
+}
+
+var errXDRShort = errors.New("XDR data too short")
+
+func (r *xdrReader) next(n int) []byte {
+	if r.err != nil || n < 0 || len(r.data) < n {
+		r.err = errXDRShort
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *xdrReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *xdrReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *xdrReader) opaque() []byte {
+	size := int(r.uint32())
+	if r.err != nil || size > len(r.data) {
+		r.err = errXDRShort
+		return nil
+	}
+	b := r.next(size)
+	r.next((4 - size%4) % 4)
+	return b
+}
+
+func (r *xdrReader) string() string {
+	return string(r.opaque())
+}
+
+// handleCall - executes an RPC call and returns the reply record, nil
+// if the call cannot be parsed.
+func (s *nfsServer) handleCall(record []byte) []byte {
+	r := &xdrReader{data: record}
+	xid := r.uint32()
+	msgType := r.uint32()
+	version := r.uint32()
+	program := r.uint32()
+	programVersion := r.uint32()
+	proc := r.uint32()
+	// Credentials are ignored, the export is read-only and served to
+	// any host which can reach the listener.
+	r.uint32()
+	r.opaque()
+	r.uint32()
+	r.opaque()
+	if r.err != nil || msgType != rpcCall {
+		return nil
+	}
+
+	w := &xdrWriter{}
+	// Room for the record mark.
+	w.uint32(0)
+	w.uint32(xid)
+	w.uint32(rpcReply)
+	if version != rpcVersion {
+		w.uint32(rpcDenied)
+		w.uint32(rpcMismatch)
+		w.uint32(rpcVersion)
+		w.uint32(rpcVersion)
+		return finishRPCRecord(w)
+	}
+	w.uint32(rpcAccepted)
+	// AUTH_NONE verifier.
+	w.uint32(0)
+	w.uint32(0)
+
+	results := &xdrWriter{}
+	acceptStat := uint32(rpcSuccess)
+	switch {
+	case program == mountProgram && programVersion == mountVersion:
+		acceptStat = s.handleMount(proc, r, results)
+	case program == nfsProgram && programVersion == nfsVersion:
+		acceptStat = s.handleNFS(proc, r, results)
+	case program == mountProgram || program == nfsProgram:
+		acceptStat = rpcProgMismatch
+		results.uint32(3)
+		results.uint32(3)
+	default:
+		acceptStat = rpcProgUnavail
+	}
+	if r.err != nil {
+		acceptStat = rpcGarbageArgs
+		results.Reset()
+	}
+	w.uint32(acceptStat)
+	w.Write(results.Bytes())
+	return finishRPCRecord(w)
+}
+
+// finishRPCRecord - sets the record mark of a reply of a single fragment.
+func finishRPCRecord(w *xdrWriter) []byte {
+	record := w.Bytes()
+	binary.BigEndian.PutUint32(record, 0x80000000|uint32(len(record)-4))
+	return record
+}
+
+// handleMount - executes a call of the MOUNT program.
+func (s *nfsServer) handleMount(proc uint32, r *xdrReader, w *xdrWriter) uint32 {
+	switch proc {
+	case 0:
+		// NULL
+	case 1:
+		// MNT
+		bucket := strings.Trim(r.string(), slashSeparator)
+		if _, ok := s.exports[bucket]; !ok || strings.Contains(bucket, slashSeparator) {
+			w.uint32(nfs3ErrAccess)
+			return rpcSuccess
+		}
+		if _, err := s.objAPI.GetBucketInfo(bucket); err != nil {
+			w.uint32(nfsStatus(err))
+			return rpcSuccess
+		}
+		w.uint32(nfs3OK)
+		w.opaque(s.handle(nfsFile{bucket: bucket, isDir: true}))
+		// AUTH_UNIX is accepted, but not verified.
+		w.uint32(1)
+		w.uint32(1)
+	case 2:
+		// DUMP, mounts are not tracked.
+		w.bool(false)
+	case 3, 4:
+		// UMNT and UMNTALL
+	case 5:
+		// EXPORT
+		for bucket := range s.exports {
+			w.bool(true)
+			w.string(slashSeparator + bucket)
+			w.bool(false)
+		}
+		w.bool(false)
+	default:
+		return rpcProcUnavail
+	}
+	return rpcSuccess
+}
+
+// nfsWriteProcs - procedures modifying the file system, answered with
+// NFS3ERR_ROFS followed by the number of empty attributes of their
+// failure result.
+var nfsWriteProcs = map[uint32]int{
+	2:  2, // SETATTR
+	7:  2, // WRITE
+	8:  2, // CREATE
+	9:  2, // MKDIR
+	10: 2, // SYMLINK
+	11: 2, // MKNOD
+	12: 2, // REMOVE
+	13: 2, // RMDIR
+	14: 4, // RENAME
+	15: 3, // LINK
+	21: 2, // COMMIT
+}
+
+// handleNFS - executes a call of the NFS program.
+func (s *nfsServer) handleNFS(proc uint32, r *xdrReader, w *xdrWriter) uint32 {
+	if attrs, ok := nfsWriteProcs[proc]; ok {
+		w.uint32(nfs3ErrROFS)
+		for i := 0; i < attrs; i++ {
+			w.bool(false)
+		}
+		return rpcSuccess
+	}
+	switch proc {
+	case 0:
+		// NULL
+	case 1:
+		s.handleGetAttr(r, w)
+	case 3:
+		s.handleLookup(r, w)
+	case 4:
+		s.handleAccess(r, w)
+	case 5:
+		// READLINK, there are no symbolic links.
+		r.opaque()
+		w.uint32(nfs3ErrNotSupp)
+		w.bool(false)
+	case 6:
+		s.handleRead(r, w)
+	case 16:
+		s.handleReadDir(r, w, false)
+	case 17:
+		s.handleReadDir(r, w, true)
+	case 18:
+		s.handleFSStat(r, w)
+	case 19:
+		s.handleFSInfo(r, w)
+	case 20:
+		s.handlePathConf(r, w)
+	default:
+		return rpcProcUnavail
+	}
+	return rpcSuccess
+}
+
+// handle - returns the file handle of file.
+func (s *nfsServer) handle(file nfsFile) []byte {
+	kind := "f"
+	if file.isDir {
+		kind = "d"
+	}
+	sum := sha256.Sum256([]byte(kind + file.bucket + slashSeparator + file.name))
+	var key [nfsHandleSize]byte
+	copy(key[:], sum[:])
+
+	s.mu.RLock()
+	_, ok := s.handles[key]
+	s.mu.RUnlock()
+	if !ok {
+		s.mu.Lock()
+		s.handles[key] = file
+		s.mu.Unlock()
+	}
+	return key[:]
+}
+
+// lookupHandle - returns the file of a handle.
+func (s *nfsServer) lookupHandle(handle []byte) (nfsFile, uint32) {
+	if len(handle) != nfsHandleSize {
+		return nfsFile{}, nfs3ErrBadHandle
+	}
+	var key [nfsHandleSize]byte
+	copy(key[:], handle)
+	s.mu.RLock()
+	file, ok := s.handles[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nfsFile{}, nfs3ErrStale
+	}
+	return file, nfs3OK
+}
+
+// nfsStatus - returns the NFS status of an object layer error.
+func nfsStatus(err error) uint32 {
+	switch err.(type) {
+	case BucketNotFound, ObjectNotFound, BucketNameInvalid, ObjectNameInvalid:
+		return nfs3ErrNoEnt
+	}
+	return nfs3ErrIO
+}
+
+// nfsAttr - attributes of a file.
+type nfsAttr struct {
+	handle  []byte
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// getAttr - returns the attributes of a file, directories carry the
+// creation time of their bucket as prefixes have no time of their own.
+func (s *nfsServer) getAttr(file nfsFile) (nfsAttr, uint32) {
+	attr := nfsAttr{handle: s.handle(file), isDir: file.isDir}
+	if file.isDir {
+		bucketInfo, err := s.objAPI.GetBucketInfo(file.bucket)
+		if err != nil {
+			return attr, nfsStatus(err)
+		}
+		if file.name != "" && !s.isDir(file.bucket, file.name) {
+			return attr, nfs3ErrNoEnt
+		}
+		attr.modTime = bucketInfo.Created
+		return attr, nfs3OK
+	}
+	objInfo, err := s.objAPI.GetObjectInfo(file.bucket, file.name)
+	if err == nil {
+		objInfo, err = getPlainObjectInfo(file.bucket, objInfo)
+	}
+	if err != nil {
+		return attr, nfsStatus(err)
+	}
+	attr.size = objInfo.Size
+	attr.modTime = objInfo.ModTime
+	return attr, nfs3OK
+}
+
+// isDir - returns true if there are objects under the directory prefix.
+func (s *nfsServer) isDir(bucket, prefix string) bool {
+	result, err := s.objAPI.ListObjects(bucket, prefix+slashSeparator, "", slashSeparator, 1)
+	return err == nil && (len(result.Objects) > 0 || len(result.Prefixes) > 0)
+}
+
+// writeFattr - encodes attributes as fattr3.
+func writeFattr(w *xdrWriter, attr nfsAttr) {
+	if attr.isDir {
+		w.uint32(nfs3Dir)
+		w.uint32(0555)
+		w.uint32(2)
+	} else {
+		w.uint32(nfs3Reg)
+		w.uint32(0444)
+		w.uint32(1)
+	}
+	// Files are owned by root.
+	w.uint32(0)
+	w.uint32(0)
+	w.uint64(uint64(attr.size))
+	w.uint64(uint64(attr.size))
+	// rdev
+	w.uint32(0)
+	w.uint32(0)
+	// fsid
+	w.uint64(0)
+	// fileid
+	w.uint64(binary.BigEndian.Uint64(attr.handle))
+	for i := 0; i < 3; i++ {
+		w.uint32(uint32(attr.modTime.Unix()))
+		w.uint32(uint32(attr.modTime.Nanosecond()))
+	}
+}
+
+// writePostOpAttr - encodes post_op_attr, attributes are only present
+// if status is NFS3_OK.
+func writePostOpAttr(w *xdrWriter, attr nfsAttr, status uint32) {
+	w.bool(status == nfs3OK)
+	if status == nfs3OK {
+		writeFattr(w, attr)
+	}
+}
+
+// readFile - reads a file handle argument and returns its attributes.
+func (s *nfsServer) readFile(r *xdrReader) (nfsFile, nfsAttr, uint32) {
+	file, status := s.lookupHandle(r.opaque())
+	if status != nfs3OK {
+		return file, nfsAttr{}, status
+	}
+	attr, status := s.getAttr(file)
+	return file, attr, status
+}
+
+// handleGetAttr - GETATTR
+func (s *nfsServer) handleGetAttr(r *xdrReader, w *xdrWriter) {
+	_, attr, status := s.readFile(r)
+	w.uint32(status)
+	if status == nfs3OK {
+		writeFattr(w, attr)
+	}
+}
+
+// handleLookup - LOOKUP, objects take precedence over prefixes of the
+// same name.
+func (s *nfsServer) handleLookup(r *xdrReader, w *xdrWriter) {
+	dir, dirAttr, status := s.readFile(r)
+	name := r.string()
+	if status == nfs3OK && !dir.isDir {
+		status = nfs3ErrNotDir
+	}
+	if status != nfs3OK {
+		w.uint32(status)
+		writePostOpAttr(w, dirAttr, status)
+		return
+	}
+	var file nfsFile
+	switch name {
+	case ".":
+		file = dir
+	case "..":
+		file = dir
+		if dir.name != "" {
+			file.name = strings.TrimSuffix(path.Dir(dir.name), ".")
+		}
+	default:
+		if strings.Contains(name, slashSeparator) || name == "" {
+			status = nfs3ErrNoEnt
+			break
+		}
+		if len(name) > 255 {
+			status = nfs3ErrNameTooLong
+			break
+		}
+		file = nfsFile{bucket: dir.bucket, name: path.Join(dir.name, name)}
+		if _, err := s.objAPI.GetObjectInfo(file.bucket, file.name); err != nil {
+			file.isDir = true
+		}
+	}
+	var attr nfsAttr
+	if status == nfs3OK {
+		attr, status = s.getAttr(file)
+	}
+	w.uint32(status)
+	if status == nfs3OK {
+		w.opaque(attr.handle)
+		writePostOpAttr(w, attr, status)
+	}
+	writePostOpAttr(w, dirAttr, nfs3OK)
+}
+
+// handleAccess - ACCESS, files can be read and directories looked up.
+func (s *nfsServer) handleAccess(r *xdrReader, w *xdrWriter) {
+	_, attr, status := s.readFile(r)
+	access := r.uint32()
+	w.uint32(status)
+	writePostOpAttr(w, attr, status)
+	if status != nfs3OK {
+		return
+	}
+	// ACCESS3_READ, ACCESS3_LOOKUP and ACCESS3_EXECUTE
+	granted := uint32(0x01)
+	if attr.isDir {
+		granted |= 0x02 | 0x20
+	}
+	w.uint32(access & granted)
+}
+
+// handleRead - READ
+func (s *nfsServer) handleRead(r *xdrReader, w *xdrWriter) {
+	file, status := s.lookupHandle(r.opaque())
+	offset := r.uint64()
+	count := int64(r.uint32())
+	if count > nfsMaxReadSize {
+		count = nfsMaxReadSize
+	}
+	if status == nfs3OK && file.isDir {
+		status = nfs3ErrIsDir
+	}
+	var objInfo ObjectInfo
+	if status == nfs3OK {
+		var err error
+		if objInfo, err = s.objAPI.GetObjectInfo(file.bucket, file.name); err != nil {
+			status = nfsStatus(err)
+		}
+	}
+	if offset > math.MaxInt64 {
+		offset = math.MaxInt64
+	}
+	var data bytes.Buffer
+	if status == nfs3OK {
+		// Nothing is read at offsets beyond the end.
+		if err := getObjectContentRange(context.Background(), s.objAPI, file.bucket, &objInfo, int64(offset), count, &data); err != nil {
+			errorIf(err, "Unable to read %s/%s for NFS client.", file.bucket, file.name)
+			status = nfs3ErrIO
+		}
+	}
+	attr := nfsAttr{size: objInfo.Size, modTime: objInfo.ModTime}
+	if status == nfs3OK {
+		attr.handle = s.handle(file)
+	}
+	w.uint32(status)
+	writePostOpAttr(w, attr, status)
+	if status != nfs3OK {
+		return
+	}
+	w.uint32(uint32(data.Len()))
+	w.bool(int64(offset)+int64(data.Len()) >= objInfo.Size)
+	w.opaque(data.Bytes())
+}
+
+// handleReadDir - READDIR and READDIRPLUS, cookies are positions in the
+// listing of the directory.
+func (s *nfsServer) handleReadDir(r *xdrReader, w *xdrWriter, plus bool) {
+	dir, dirAttr, status := s.readFile(r)
+	cookie := r.uint64()
+	// Cookie verifier is not checked, listings are not versioned.
+	r.next(8)
+	count := int(r.uint32())
+	if plus {
+		// Only the total size of the reply is taken into account.
+		count = int(r.uint32())
+	}
+	if status == nfs3OK && !dir.isDir {
+		status = nfs3ErrNotDir
+	}
+	var entries []dirEntry
+	if status == nfs3OK {
+		var err error
+		if entries, err = listDirEntries(s.objAPI, dir.bucket, dir.name); err != nil {
+			status = nfsStatus(err)
+		}
+	}
+	if status == nfs3OK && cookie > uint64(len(entries)) {
+		status = nfs3ErrBadCookie
+	}
+	w.uint32(status)
+	writePostOpAttr(w, dirAttr, status)
+	if status != nfs3OK {
+		return
+	}
+	// Cookie verifier.
+	w.uint64(0)
+
+	list := &xdrWriter{}
+	// Size of the reply without entries.
+	size := 128
+	i := int(cookie)
+	for ; i < len(entries); i++ {
+		entry := entries[i]
+		file := nfsFile{bucket: dir.bucket, name: path.Join(dir.name, entry.name), isDir: entry.isDir}
+		attr := nfsAttr{handle: s.handle(file), isDir: entry.isDir, size: entry.size, modTime: entry.modTime}
+		if entry.isDir {
+			attr.modTime = dirAttr.modTime
+		}
+		item := &xdrWriter{}
+		item.bool(true)
+		item.uint64(binary.BigEndian.Uint64(attr.handle))
+		item.string(entry.name)
+		item.uint64(uint64(i + 1))
+		if plus {
+			writePostOpAttr(item, attr, nfs3OK)
+			item.bool(true)
+			item.opaque(attr.handle)
+		}
+		if size+item.Len() > count {
+			break
+		}
+		size += item.Len()
+		list.Write(item.Bytes())
+	}
+	if i < len(entries) && list.Len() == 0 {
+		w.Truncate(0)
+		w.uint32(nfs3ErrTooSmall)
+		writePostOpAttr(w, dirAttr, nfs3OK)
+		return
+	}
+	w.Write(list.Bytes())
+	w.bool(false)
+	w.bool(i == len(entries))
+}
+
+// handleFSStat - FSSTAT, no space is available to clients of a
+// read-only export.
+func (s *nfsServer) handleFSStat(r *xdrReader, w *xdrWriter) {
+	_, attr, status := s.readFile(r)
+	w.uint32(status)
+	writePostOpAttr(w, attr, status)
+	if status != nfs3OK {
+		return
+	}
+	storageInfo := s.objAPI.StorageInfo()
+	w.uint64(uint64(storageInfo.Total))
+	w.uint64(uint64(storageInfo.Free))
+	w.uint64(0)
+	w.uint64(0)
+	w.uint64(0)
+	w.uint64(0)
+	// Invariant for 0 seconds.
+	w.uint32(0)
+}
+
+// handleFSInfo - FSINFO
+func (s *nfsServer) handleFSInfo(r *xdrReader, w *xdrWriter) {
+	_, attr, status := s.readFile(r)
+	w.uint32(status)
+	writePostOpAttr(w, attr, status)
+	if status != nfs3OK {
+		return
+	}
+	// rtmax, rtpref and rtmult
+	w.uint32(nfsMaxReadSize)
+	w.uint32(nfsMaxReadSize)
+	w.uint32(4096)
+	// wtmax, wtpref and wtmult
+	w.uint32(nfsMaxRequestSize)
+	w.uint32(nfsMaxRequestSize)
+	w.uint32(4096)
+	// dtpref
+	w.uint32(64 * 1024)
+	// maxfilesize
+	w.uint64(uint64(getLimitsConfig().MaxObjectSize))
+	// time_delta of 1ns
+	w.uint32(0)
+	w.uint32(1)
+	// FSF3_HOMOGENEOUS
+	w.uint32(0x08)
+}
+
+// handlePathConf - PATHCONF
+func (s *nfsServer) handlePathConf(r *xdrReader, w *xdrWriter) {
+	_, attr, status := s.readFile(r)
+	w.uint32(status)
+	writePostOpAttr(w, attr, status)
+	if status != nfs3OK {
+		return
+	}
+	// linkmax and name_max
+	w.uint32(1)
+	w.uint32(255)
+	// no_trunc, chown_restricted, case_insensitive and case_preserving
+	w.bool(true)
+	w.bool(true)
+	w.bool(false)
+	w.bool(true)
+}
@@ -0,0 +1,222 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// nfsTestClient - minimal RPC client driving the server in tests.
+type nfsTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	xid  uint32
+}
+
+// call - sends a call and returns the reader of its results after
+// checking the accept status.
+func (c *nfsTestClient) call(program, version, proc uint32, args func(w *xdrWriter), expectStat uint32) *xdrReader {
+	c.xid++
+	w := &xdrWriter{}
+	w.uint32(0)
+	w.uint32(c.xid)
+	w.uint32(rpcCall)
+	w.uint32(rpcVersion)
+	w.uint32(program)
+	w.uint32(version)
+	w.uint32(proc)
+	// AUTH_NONE credentials and verifier.
+	for i := 0; i < 4; i++ {
+		w.uint32(0)
+	}
+	if args != nil {
+		args(w)
+	}
+	if _, err := c.conn.Write(finishRPCRecord(w)); err != nil {
+		c.t.Fatal(err)
+	}
+	record, err := readRPCRecord(c.conn)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	r := &xdrReader{data: record}
+	if xid := r.uint32(); xid != c.xid {
+		c.t.Fatalf("Expected xid %d, got %d", c.xid, xid)
+	}
+	if r.uint32() != rpcReply || r.uint32() != rpcAccepted {
+		c.t.Fatal("Expected accepted reply")
+	}
+	r.uint32()
+	r.opaque()
+	if stat := r.uint32(); stat != expectStat {
+		c.t.Fatalf("Expected accept status %d, got %d", expectStat, stat)
+	}
+	return r
+}
+
+// nfs - calls an NFS procedure and checks its status.
+func (c *nfsTestClient) nfs(proc uint32, args func(w *xdrWriter), expectStatus uint32) *xdrReader {
+	r := c.call(nfsProgram, nfsVersion, proc, args, rpcSuccess)
+	if status := r.uint32(); status != expectStatus {
+		c.t.Fatalf("Procedure %d: expected status %d, got %d", proc, expectStatus, status)
+	}
+	return r
+}
+
+// readFattr - decodes fattr3 and returns the type and size.
+func readFattr(r *xdrReader) (fileType uint32, size uint64) {
+	fileType = r.uint32()
+	r.next(16)
+	size = r.uint64()
+	r.next(56)
+	return fileType, size
+}
+
+// Tests a client mounting an exported bucket, looking up, listing and
+// reading files, and being refused writes.
+func TestNFSServer(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range []string{"photos", "private"} {
+		if err = objAPI.MakeBucket(bucket); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := []byte("holiday picture")
+	if _, err = objAPI.PutObject(context.Background(), "photos", "2016/beach.jpg", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go newNFSServer(objAPI, []string{"photos"}).Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &nfsTestClient{t: t, conn: conn}
+
+	// Only exported buckets can be mounted.
+	mount := func(path string) *xdrReader {
+		return c.call(mountProgram, mountVersion, 1, func(w *xdrWriter) { w.string(path) }, rpcSuccess)
+	}
+	if status := mount("/private").uint32(); status != nfs3ErrAccess {
+		t.Fatalf("Expected status %d, got %d", nfs3ErrAccess, status)
+	}
+	r := mount("/photos")
+	if status := r.uint32(); status != nfs3OK {
+		t.Fatalf("Expected status %d, got %d", nfs3OK, status)
+	}
+	root := r.opaque()
+
+	lookup := func(dir []byte, name string, expectStatus uint32) []byte {
+		r := c.nfs(3, func(w *xdrWriter) {
+			w.opaque(dir)
+			w.string(name)
+		}, expectStatus)
+		if expectStatus != nfs3OK {
+			return nil
+		}
+		return r.opaque()
+	}
+	dir := lookup(root, "2016", nfs3OK)
+	lookup(root, "2017", nfs3ErrNoEnt)
+	file := lookup(dir, "beach.jpg", nfs3OK)
+	if parent := lookup(dir, "..", nfs3OK); !bytes.Equal(parent, root) {
+		t.Fatal("Expected parent of directory to be the root")
+	}
+
+	if fileType, size := readFattr(c.nfs(1, func(w *xdrWriter) { w.opaque(file) }, nfs3OK)); fileType != nfs3Reg || size != uint64(len(data)) {
+		t.Fatalf("Unexpected attributes type %d size %d", fileType, size)
+	}
+	if fileType, _ := readFattr(c.nfs(1, func(w *xdrWriter) { w.opaque(dir) }, nfs3OK)); fileType != nfs3Dir {
+		t.Fatalf("Expected directory, got type %d", fileType)
+	}
+	c.nfs(1, func(w *xdrWriter) { w.opaque(make([]byte, nfsHandleSize)) }, nfs3ErrStale)
+
+	// Read a range and the end of the file.
+	read := func(offset uint64, count uint32) ([]byte, bool) {
+		r := c.nfs(6, func(w *xdrWriter) {
+			w.opaque(file)
+			w.uint64(offset)
+			w.uint32(count)
+		}, nfs3OK)
+		if r.uint32() == 1 {
+			readFattr(r)
+		}
+		r.uint32()
+		eof := r.uint32() == 1
+		return r.opaque(), eof
+	}
+	if got, eof := read(8, 3); string(got) != "pic" || eof {
+		t.Fatalf("Unexpected read %q eof %v", got, eof)
+	}
+	if got, eof := read(8, 100); string(got) != "picture" || !eof {
+		t.Fatalf("Unexpected read %q eof %v", got, eof)
+	}
+
+	// List the directory with attributes and handles.
+	r = c.nfs(17, func(w *xdrWriter) {
+		w.opaque(dir)
+		w.uint64(0)
+		w.uint64(0)
+		w.uint32(4096)
+		w.uint32(32768)
+	}, nfs3OK)
+	if r.uint32() == 1 {
+		readFattr(r)
+	}
+	r.uint64()
+	var names []string
+	for r.uint32() == 1 {
+		r.uint64()
+		names = append(names, r.string())
+		r.uint64()
+		if r.uint32() == 1 {
+			readFattr(r)
+		}
+		if handle := r.next(4); binary.BigEndian.Uint32(handle) == 1 {
+			r.opaque()
+		}
+	}
+	if eof := r.uint32() == 1; !eof || len(names) != 1 || names[0] != "beach.jpg" {
+		t.Fatalf("Unexpected listing %v eof %v", names, eof)
+	}
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	// Writes are refused.
+	c.nfs(12, func(w *xdrWriter) {
+		w.opaque(dir)
+		w.string("beach.jpg")
+	}, nfs3ErrROFS)
+	c.call(100021, 4, 0, nil, rpcProgUnavail)
+}
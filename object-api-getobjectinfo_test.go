@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"io/ioutil"
@@ -37,7 +38,7 @@ func testGetObjectInfo(obj ObjectLayer, instanceType string, t *testing.T) {
 	if err != nil {
 		t.Fatalf("%s : %s", instanceType, err.Error())
 	}
-	_, err = obj.PutObject("test-getobjectinfo", "Asia/asiapics.jpg", int64(len("asiapics")), bytes.NewBufferString("asiapics"), nil)
+	_, err = obj.PutObject(context.Background(), "test-getobjectinfo", "Asia/asiapics.jpg", int64(len("asiapics")), bytes.NewBufferString("asiapics"), nil)
 	if err != nil {
 		t.Fatalf("%s : %s", instanceType, err.Error())
 	}
@@ -135,7 +136,7 @@ func BenchmarkGetObjectFS(b *testing.B) {
 	metadata := make(map[string]string)
 	for i := 0; i < 10; i++ {
 		metadata["md5Sum"] = hex.EncodeToString(hasher.Sum(nil))
-		_, err = obj.PutObject("bucket", "object"+strconv.Itoa(i), int64(len(text)), bytes.NewBufferString(text), metadata)
+		_, err = obj.PutObject(context.Background(), "bucket", "object"+strconv.Itoa(i), int64(len(text)), bytes.NewBufferString(text), metadata)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -144,7 +145,7 @@ func BenchmarkGetObjectFS(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		var buffer = new(bytes.Buffer)
-		err = obj.GetObject("bucket", "object"+strconv.Itoa(i%10), 0, int64(len([]byte(text))), buffer)
+		err = obj.GetObject(context.Background(), "bucket", "object"+strconv.Itoa(i%10), 0, int64(len([]byte(text))), buffer)
 		if err != nil {
 			b.Error(err)
 		}
@@ -18,9 +18,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"path"
 	"strings"
 	"testing"
 )
@@ -197,7 +199,7 @@ func testObjectAPIPutObjectPart(obj ObjectLayer, instanceType string, t *testing
 	}
 
 	for i, testCase := range failCases {
-		actualMd5Hex, actualErr := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, testCase.intputDataSize,
+		actualMd5Hex, actualErr := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, testCase.intputDataSize,
 			bytes.NewBufferString(testCase.inputReaderData), testCase.inputMd5)
 		// All are test cases above are expected to fail.
 
@@ -332,7 +334,7 @@ func testListMultipartUploads(obj ObjectLayer, instanceType string, t *testing.T
 	}
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, err := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, testCase.intputDataSize,
+		_, err := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, testCase.intputDataSize,
 			bytes.NewBufferString(testCase.inputReaderData), testCase.inputMd5)
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err.Error())
@@ -956,14 +958,12 @@ func testListMultipartUploads(obj ObjectLayer, instanceType string, t *testing.T
 		{"volatile-bucket-1", "", "", "", "", 0, ListMultipartsInfo{}, BucketNotFound{Bucket: "volatile-bucket-1"}, false},
 		{"volatile-bucket-2", "", "", "", "", 0, ListMultipartsInfo{}, BucketNotFound{Bucket: "volatile-bucket-2"}, false},
 		{"volatile-bucket-3", "", "", "", "", 0, ListMultipartsInfo{}, BucketNotFound{Bucket: "volatile-bucket-3"}, false},
-		// Valid, existing bucket, but sending invalid delimeter values (Test number 8-9).
-		// Empty string < "" > and forward slash < / > are the ony two valid arguments for delimeter.
-		{bucketNames[0], "", "", "", "*", 0, ListMultipartsInfo{}, fmt.Errorf("delimiter '%s' is not supported", "*"), false},
-		{bucketNames[0], "", "", "", "-", 0, ListMultipartsInfo{}, fmt.Errorf("delimiter '%s' is not supported", "-"), false},
-		// Testing for failure cases with both perfix and marker (Test number 10).
-		// The prefix and marker combination to be valid it should satisy strings.HasPrefix(marker, prefix).
-		{bucketNames[0], "asia", "europe-object", "", "", 0, ListMultipartsInfo{},
-			fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", "europe-object", "asia"), false},
+		// Valid, existing bucket, with delimiters other than '/' (Test number 8-9).
+		{bucketNames[0], "", "", "", "*", 0, ListMultipartsInfo{Delimiter: "*", IsTruncated: true}, nil, true},
+		{bucketNames[0], "", "", "", "-", 0, ListMultipartsInfo{Delimiter: "-", IsTruncated: true}, nil, true},
+		// Testing for a marker sorting after all keys with the prefix (Test number 10).
+		// Nothing is listed after such a marker.
+		{bucketNames[0], "asia", "europe-object", "", "", 0, ListMultipartsInfo{KeyMarker: "europe-object", Prefix: "asia"}, nil, true},
 		// Setting an invalid combination of uploadIDMarker and Marker (Test number 11-12).
 		{bucketNames[0], "asia", "asia/europe/", "abc", "", 0, ListMultipartsInfo{},
 			fmt.Errorf("Invalid combination of uploadID marker '%s' and marker '%s'", "abc", "asia/europe/"), false},
@@ -1175,7 +1175,7 @@ func testListObjectParts(obj ObjectLayer, instanceType string, t *testing.T) {
 	}
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, testCase := range createPartCases {
-		_, err := obj.PutObjectPart(testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, testCase.intputDataSize,
+		_, err := obj.PutObjectPart(context.Background(), testCase.bucketName, testCase.objName, testCase.uploadID, testCase.PartID, testCase.intputDataSize,
 			bytes.NewBufferString(testCase.inputReaderData), testCase.inputMd5)
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err.Error())
@@ -1240,11 +1240,12 @@ func testListObjectParts(obj ObjectLayer, instanceType string, t *testing.T) {
 		},
 		// partinfos - 2.
 		{
-			Bucket:      bucketNames[0],
-			Object:      objectNames[0],
-			MaxParts:    2,
-			IsTruncated: false,
-			UploadID:    uploadIDs[0],
+			Bucket:           bucketNames[0],
+			Object:           objectNames[0],
+			MaxParts:         2,
+			PartNumberMarker: 3,
+			IsTruncated:      false,
+			UploadID:         uploadIDs[0],
 			Parts: []partInfo{
 				{
 					PartNumber: 4,
@@ -1421,7 +1422,7 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t *
 	}
 	// Iterating over creatPartCases to generate multipart chunks.
 	for _, part := range parts {
-		_, err = obj.PutObjectPart(part.bucketName, part.objName, part.uploadID, part.PartID, part.intputDataSize,
+		_, err = obj.PutObjectPart(context.Background(), part.bucketName, part.objName, part.uploadID, part.PartID, part.intputDataSize,
 			bytes.NewBufferString(part.inputReaderData), part.inputMd5)
 		if err != nil {
 			t.Fatalf("%s : %s", instanceType, err)
@@ -1547,3 +1548,121 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t *
 		}
 	}
 }
+
+// Wrapper for calling multipart ETag tests for both XL multiple disks and single node setup.
+func TestObjectMultipartETag(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectMultipartETag)
+}
+
+// Tests the s3 compatible md5sum of multipart objects is saved with the object.
+func testObjectMultipartETag(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket, object := "bucket", "object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	partsData := [][]byte{
+		bytes.Repeat([]byte("a"), 5*1024*1024),
+		[]byte("abcd"),
+	}
+	var parts []completePart
+	var partsMD5 []byte
+	for i, data := range partsData {
+		md5Sum := md5.Sum(data)
+		partsMD5 = append(partsMD5, md5Sum[:]...)
+		etag, err := obj.PutObjectPart(context.Background(), bucket, object, uploadID, i+1, int64(len(data)), bytes.NewReader(data), hex.EncodeToString(md5Sum[:]))
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: etag})
+	}
+	md5Sum := md5.Sum(partsMD5)
+	s3MD5 := hex.EncodeToString(md5Sum[:]) + "-2"
+
+	etag, err := obj.CompleteMultipartUpload(bucket, object, uploadID, parts)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if etag != s3MD5 {
+		t.Fatalf("%s: Expected %s, but instead found %s", instanceType, s3MD5, etag)
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.MD5Sum != s3MD5 {
+		t.Fatalf("%s: Expected %s, but instead found %s", instanceType, s3MD5, objInfo.MD5Sum)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].MD5Sum != s3MD5 {
+		t.Fatalf("%s: Expected %s to be listed with %s", instanceType, object, s3MD5)
+	}
+
+	// Overwritten objects do not keep the md5sum.
+	if _, err = obj.PutObject(context.Background(), bucket, object, 4, bytes.NewReader([]byte("abcd")), nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, object); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.MD5Sum == s3MD5 {
+		t.Fatalf("%s: Expected md5sum of the overwritten object to be removed", instanceType)
+	}
+}
+
+// Wrapper for calling bad digest tests for both XL multiple disks and single node setup.
+func TestObjectBadDigest(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectBadDigest)
+}
+
+// Tests data not matching its md5sum is rejected and not left behind.
+func testObjectBadDigest(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket, object := "bucket", "object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := []byte("abcd")
+	md5Sum := md5.Sum([]byte("abce"))
+	md5Hex := hex.EncodeToString(md5Sum[:])
+
+	_, err := obj.PutObject(context.Background(), bucket, object, int64(len(data)), bytes.NewReader(data), map[string]string{"md5Sum": md5Hex})
+	if _, ok := err.(BadDigest); !ok {
+		t.Fatalf("%s: Expected BadDigest, but instead found %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, object); err == nil {
+		t.Fatalf("%s: Expected object not to be created", instanceType)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	_, err = obj.PutObjectPart(context.Background(), bucket, object, uploadID, 1, int64(len(data)), bytes.NewReader(data), md5Hex)
+	if _, ok := err.(BadDigest); !ok {
+		t.Fatalf("%s: Expected BadDigest, but instead found %v", instanceType, err)
+	}
+
+	var disk StorageAPI
+	switch objAPI := obj.(type) {
+	case fsObjects:
+		disk = objAPI.storage
+	case xlObjects:
+		disk = objAPI.storageDisks[0]
+	}
+	entries, err := disk.ListDir(minioMetaBucket, tmpMetaPrefix)
+	if err != nil && err != errFileNotFound {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for _, entry := range entries {
+		files, err := disk.ListDir(minioMetaBucket, path.Join(tmpMetaPrefix, entry))
+		if err == nil && len(files) > 0 {
+			t.Fatalf("%s: Expected temporary data to be removed, but found %v", instanceType, files)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	mux "github.com/gorilla/mux"
+)
+
+// objectAppender - implemented by object layers which can append data
+// to existing objects.
+type objectAppender interface {
+	// AppendObject - appends size bytes of data to an existing object,
+	// returns the new md5sum of the whole object. Reading data stops
+	// once ctx is done.
+	AppendObject(ctx context.Context, bucket, object string, size int64, data io.Reader, md5Hex string) (md5 string, err error)
+}
+
+// getAppendedMD5 - returns the md5sum of an object after appending data
+// with appendMD5Hex to it. Like for multipart objects it is the md5sum
+// of the concatenated md5sums followed by the number of pieces the
+// object consists of. Objects without md5sum count as no pieces.
+func getAppendedMD5(md5Hex, appendMD5Hex string) (string, error) {
+	var count int
+	if md5Hex != "" {
+		count = 1
+		if index := strings.LastIndex(md5Hex, "-"); index != -1 {
+			var err error
+			if count, err = strconv.Atoi(md5Hex[index+1:]); err != nil {
+				return "", err
+			}
+			md5Hex = md5Hex[:index]
+		}
+	}
+	md5Bytes, err := hex.DecodeString(md5Hex)
+	if err != nil {
+		return "", err
+	}
+	appendMD5Bytes, err := hex.DecodeString(appendMD5Hex)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(append(md5Bytes, appendMD5Bytes...))
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), count+1), nil
+}
+
+// AppendObjectHandler - PUT Object append
+// ----------
+// Minio extension appending the request body to an object, the object
+// is created if it does not exist yet. Encrypted and compressed objects
+// cannot be appended to.
+func (api objectAPIHandlers) AppendObjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	// Get Content-Md5 sent by client and verify if valid
+	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
+		return
+	}
+	/// if Content-Length is unknown/missing, deny the request
+	size := r.ContentLength
+	if size == -1 {
+		writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
+		return
+	}
+	/// maximum Upload size for objects in a single operation
+	if isMaxObjectSize(size) {
+		writeErrorResponse(w, r, ErrObjectTooLarge, r.URL.Path)
+		return
+	}
+
+	authType := getRequestAuthType(r)
+	switch authType {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		// Signature is verified while the payload is read.
+	}
+
+	appender, ok := api.ObjectAPI.(objectAppender)
+	if !ok {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	exists := err == nil
+	if err != nil {
+		if _, ok = err.(ObjectNotFound); !ok {
+			errorIfRequest(r, err, "Unable to fetch object info.")
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			return
+		}
+	}
+	if exists {
+		_, encrypted, eErr := getObjectEncryption(bucket, &objInfo)
+		_, compressed, cErr := getObjectCompression(bucket, &objInfo)
+		if eErr != nil || cErr != nil {
+			errorIfRequest(r, eErr, "Unable to read object encryption info.")
+			errorIfRequest(r, cErr, "Unable to read object compression info.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+		if encrypted || compressed {
+			writeErrorResponse(w, r, ErrInvalidObjectState, r.URL.Path)
+			return
+		}
+		// Appends are limited by the maximum object size as a whole.
+		if isMaxObjectSize(objInfo.Size + size) {
+			writeErrorResponse(w, r, ErrObjectTooLarge, r.URL.Path)
+			return
+		}
+	}
+	// Verify if existing object is not protected by object lock.
+	if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	var reader io.Reader = r.Body
+	var pipeReader *io.PipeReader
+	var wg = &sync.WaitGroup{}
+	if authType != authTypeAnonymous {
+		// Initialize a pipe for data pipe line.
+		var writer *io.PipeWriter
+		pipeReader, writer = io.Pipe()
+		reader = pipeReader
+		// Start writing in a routine.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shaWriter := sha256.New()
+			multiWriter := io.MultiWriter(shaWriter, writer)
+			if _, wErr := copyN(multiWriter, r.Body, size); wErr != nil {
+				// Pipe closed.
+				if wErr == io.ErrClosedPipe {
+					return
+				}
+				errorIfRequest(r, wErr, "Unable to read from HTTP body.")
+				writer.CloseWithError(wErr)
+				return
+			}
+			shaPayload := shaWriter.Sum(nil)
+			validateRegion := true // Validate region.
+			var s3Error APIErrorCode
+			if isRequestSignatureV4(r) {
+				s3Error = doesSignatureMatch(hex.EncodeToString(shaPayload), r, validateRegion)
+			} else if isRequestPresignedSignatureV4(r) {
+				s3Error = doesPresignedSignatureMatch(hex.EncodeToString(shaPayload), r, validateRegion)
+			}
+			if s3Error != ErrNone {
+				var sErr error
+				if s3Error == ErrSignatureDoesNotMatch {
+					sErr = errSignatureMismatch
+				} else if s3Error == ErrContentSHA256Mismatch {
+					sErr = errContentSHA256Mismatch
+				} else {
+					sErr = fmt.Errorf("%v", getAPIError(s3Error))
+				}
+				writer.CloseWithError(sErr)
+				return
+			}
+			writer.Close()
+		}()
+	}
+
+	var md5Sum string
+	md5Hex := hex.EncodeToString(md5Bytes)
+	if exists {
+		md5Sum, err = appender.AppendObject(r.Context(), bucket, object, size, reader, md5Hex)
+	} else {
+		md5Sum, err = api.ObjectAPI.PutObject(r.Context(), bucket, object, size, reader, map[string]string{"md5Sum": md5Hex})
+	}
+	if pipeReader != nil {
+		// Close the pipe.
+		pipeReader.Close()
+		// Wait for all the routines to finish.
+		wg.Wait()
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to append to an object.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	// Checksum of the previous data no longer matches.
+	if _, hasChecksum := objInfo.Metadata[objectChecksumMetaKey]; exists && hasChecksum {
+		err = removeObjectChecksum(api.ObjectAPI, bucket, object)
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to remove object info.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	if md5Sum != "" {
+		w.Header().Set("ETag", "\""+md5Sum+"\"")
+	}
+	// Mirror the appended object to the replication target.
+	queueReplication(r, bucket, object, false)
+	writeSuccessResponse(w, nil)
+}
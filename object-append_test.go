@@ -0,0 +1,174 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// Tests md5sums of appended objects.
+func TestGetAppendedMD5(t *testing.T) {
+	md5A := hex.EncodeToString(sumMD5([]byte("a")))
+	md5B := hex.EncodeToString(sumMD5([]byte("b")))
+	sum := func(data ...string) string {
+		var buf []byte
+		for _, md5Hex := range data {
+			md5Bytes, _ := hex.DecodeString(md5Hex)
+			buf = append(buf, md5Bytes...)
+		}
+		md5Sum := md5.Sum(buf)
+		return hex.EncodeToString(md5Sum[:])
+	}
+
+	testCases := []struct {
+		md5Hex     string
+		expected   string
+		shouldPass bool
+	}{
+		// Objects without md5sum.
+		{"", sum(md5B) + "-1", true},
+		// Single part objects.
+		{md5A, sum(md5A, md5B) + "-2", true},
+		// Multipart and appended objects.
+		{md5A + "-4", sum(md5A, md5B) + "-5", true},
+		// Invalid md5sums.
+		{"zz", "", false},
+		{md5A + "-x", "", false},
+	}
+	for i, testCase := range testCases {
+		md5Hex, err := getAppendedMD5(testCase.md5Hex, md5B)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: unexpected error %s", i+1, err)
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+		if md5Hex != testCase.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.expected, md5Hex)
+		}
+	}
+}
+
+// Wrapper for calling testAppendObject for both XL and FS.
+func TestAppendObject(t *testing.T) {
+	ExecObjectLayerTest(t, testAppendObject)
+}
+
+// Tests data is appended to existing objects.
+func testAppendObject(obj ObjectLayer, instanceType string, t *testing.T) {
+	appender, ok := obj.(objectAppender)
+	if !ok {
+		t.Fatalf("%s: Expected object layer to support appends", instanceType)
+	}
+	bucket, object := "bucket", "log"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := appender.AppendObject(context.Background(), bucket, object, 1, bytes.NewReader([]byte("a")), ""); err == nil {
+		t.Fatalf("%s: Expected appending to a missing object to fail", instanceType)
+	}
+
+	// Small objects start inline in XL, large pieces are written to part files.
+	pieces := [][]byte{
+		[]byte("hello"),
+		[]byte(", world"),
+		bytes.Repeat([]byte("x"), 2*xlInlineDataThreshold),
+		{},
+	}
+	md5Hex, err := obj.PutObject(context.Background(), bucket, object, int64(len(pieces[0])), bytes.NewReader(pieces[0]), nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	expected := append([]byte{}, pieces[0]...)
+	for _, piece := range pieces[1:] {
+		pieceMD5Hex := hex.EncodeToString(sumMD5(piece))
+		if md5Hex, err = appender.AppendObject(context.Background(), bucket, object, int64(len(piece)), bytes.NewReader(piece), pieceMD5Hex); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		expected = append(expected, piece...)
+
+		objInfo, err := obj.GetObjectInfo(bucket, object)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if objInfo.Size != int64(len(expected)) || objInfo.MD5Sum != md5Hex {
+			t.Fatalf("%s: Unexpected object info %#v", instanceType, objInfo)
+		}
+		var buf bytes.Buffer
+		if err = obj.GetObject(context.Background(), bucket, object, 0, objInfo.Size, &buf); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Fatalf("%s: Unexpected object content after appending %d bytes", instanceType, len(piece))
+		}
+	}
+
+	// Data not matching its md5sum is not appended.
+	if _, err = appender.AppendObject(context.Background(), bucket, object, 1, bytes.NewReader([]byte("a")), hex.EncodeToString(sumMD5([]byte("b")))); err == nil {
+		t.Fatalf("%s: Expected bad digest error", instanceType)
+	}
+	if objInfo, _ := obj.GetObjectInfo(bucket, object); objInfo.Size != int64(len(expected)) {
+		t.Fatalf("%s: Expected size %d, got %d", instanceType, len(expected), objInfo.Size)
+	}
+}
+
+// Tests objects are created and appended to through the API.
+func TestAppendObjectHandler(t *testing.T) {
+	for _, instanceType := range []string{"FS", "XL"} {
+		testServer := StartTestServer(t, instanceType)
+		bucketURL := testServer.Server.URL + "/bucket"
+		doRequest := func(method, urlStr string, data []byte) *http.Response {
+			request, err := newTestRequest(method, urlStr, int64(len(data)), bytes.NewReader(data), testServer.AccessKey, testServer.SecretKey)
+			if err != nil {
+				t.Fatalf("%s: %s", instanceType, err)
+			}
+			response, err := (&http.Client{}).Do(request)
+			if err != nil {
+				t.Fatalf("%s: %s", instanceType, err)
+			}
+			return response
+		}
+		if response := doRequest("PUT", bucketURL, nil); response.StatusCode != http.StatusOK {
+			t.Fatalf("%s: Expected %d, got %d", instanceType, http.StatusOK, response.StatusCode)
+		}
+		// The first append creates the object.
+		for _, line := range []string{"line 1\n", "line 2\n", "line 3\n"} {
+			response := doRequest("PUT", bucketURL+"/log?append", []byte(line))
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("%s: Expected %d, got %d", instanceType, http.StatusOK, response.StatusCode)
+			}
+			if response.Header.Get("ETag") == "" {
+				t.Errorf("%s: Expected an ETag", instanceType)
+			}
+		}
+		response := doRequest("GET", bucketURL+"/log", nil)
+		data, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if string(data) != "line 1\nline 2\nline 3\n" {
+			t.Errorf("%s: Unexpected object content %q", instanceType, data)
+		}
+		testServer.Stop()
+	}
+}
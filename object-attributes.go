@@ -0,0 +1,169 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Requested object attributes are listed in this header.
+const amzObjectAttributes = "X-Amz-Object-Attributes"
+
+// ObjectChecksum - additional checksum of an object.
+type ObjectChecksum struct {
+	ChecksumCRC32  string `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+}
+
+// ObjectParts - parts of a multipart object.
+type ObjectParts struct {
+	TotalPartsCount int `xml:"TotalPartsCount"`
+}
+
+// GetObjectAttributesResponse - requested attributes of an object.
+type GetObjectAttributesResponse struct {
+	XMLName      xml.Name        `xml:"http://s3.amazonaws.com/doc/2006-03-01/ GetObjectAttributesResponse" json:"-"`
+	ETag         string          `xml:"ETag,omitempty"`
+	Checksum     *ObjectChecksum `xml:"Checksum,omitempty"`
+	ObjectParts  *ObjectParts    `xml:"ObjectParts,omitempty"`
+	StorageClass string          `xml:"StorageClass,omitempty"`
+	ObjectSize   *int64          `xml:"ObjectSize,omitempty"`
+}
+
+// parseObjectAttributes - returns the set of requested attributes.
+func parseObjectAttributes(attributes string) (map[string]bool, APIErrorCode) {
+	requested := make(map[string]bool)
+	for _, attribute := range strings.Split(attributes, ",") {
+		attribute = strings.TrimSpace(attribute)
+		switch attribute {
+		case "ETag", "Checksum", "ObjectParts", "StorageClass", "ObjectSize":
+			requested[attribute] = true
+		default:
+			return nil, ErrInvalidObjectAttributes
+		}
+	}
+	return requested, ErrNone
+}
+
+// getObjectPartsCount - returns the number of parts of a multipart
+// object from its s3 compatible md5sum, zero for other objects.
+func getObjectPartsCount(md5Sum string) int {
+	i := strings.LastIndex(md5Sum, "-")
+	if i == -1 {
+		return 0
+	}
+	count, err := strconv.Atoi(md5Sum[i+1:])
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// GetObjectAttributesHandler - GET Object attributes
+// ----------
+// Returns the attributes of an object listed in the
+// X-Amz-Object-Attributes header, without returning its data.
+func (api objectAPIHandlers) GetObjectAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	requested, s3Error := parseObjectAttributes(r.Header.Get(amzObjectAttributes))
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// Checksum is looked up before the size is adjusted.
+	checksum, hasChecksum, err := getObjectChecksum(bucket, objInfo)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object checksum.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	// Encrypted and compressed objects have their plain size.
+	_, encrypted, err := getObjectEncryption(bucket, &objInfo)
+	if err == nil && !encrypted {
+		_, _, err = getObjectCompression(bucket, &objInfo)
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object info.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	response := GetObjectAttributesResponse{}
+	if requested["ETag"] {
+		response.ETag = objInfo.MD5Sum
+	}
+	if requested["Checksum"] && hasChecksum {
+		response.Checksum = &ObjectChecksum{}
+		switch checksum.Algorithm {
+		case "CRC32":
+			response.Checksum.ChecksumCRC32 = checksum.Checksum
+		case "CRC32C":
+			response.Checksum.ChecksumCRC32C = checksum.Checksum
+		case "SHA1":
+			response.Checksum.ChecksumSHA1 = checksum.Checksum
+		case "SHA256":
+			response.Checksum.ChecksumSHA256 = checksum.Checksum
+		}
+	}
+	if count := getObjectPartsCount(objInfo.MD5Sum); requested["ObjectParts"] && count > 0 {
+		response.ObjectParts = &ObjectParts{TotalPartsCount: count}
+	}
+	if requested["StorageClass"] {
+		response.StorageClass = "STANDARD"
+	}
+	if requested["ObjectSize"] {
+		response.ObjectSize = &objInfo.Size
+	}
+	w.Header().Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+	writeSuccessResponse(w, encodeResponse(response))
+}
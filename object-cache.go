@@ -0,0 +1,377 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio/pkg/disk"
+)
+
+const (
+	// Default maximum usage in percent of a cache drive before
+	// least recently used entries are evicted.
+	cacheDefaultMaxUse = 80
+
+	// Files of a cache entry.
+	cacheDataFile = "data"
+	cacheInfoFile = "cache.json"
+
+	// Directory on each cache drive holding entries being filled.
+	cacheTmpDir = "tmp"
+)
+
+// cacheConfig - disk cache configuration, objects matching one of the
+// exclude patterns 'bucket/object' are never cached.
+type cacheConfig struct {
+	Drives  []string `json:"drives"`
+	Exclude []string `json:"exclude"`
+	MaxUse  int      `json:"maxuse"`
+}
+
+// newCacheConfig - returns the default cache config, caching is
+// disabled until cache drives are configured.
+func newCacheConfig() cacheConfig {
+	return cacheConfig{
+		Drives:  []string{},
+		Exclude: []string{},
+		MaxUse:  cacheDefaultMaxUse,
+	}
+}
+
+// cacheInfo - backend object version a cache entry was filled from.
+type cacheInfo struct {
+	Bucket  string    `json:"bucket"`
+	Object  string    `json:"object"`
+	Size    int64     `json:"size"`
+	MD5Sum  string    `json:"md5Sum"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// isValid - returns true if the cache entry still matches the
+// current backend object.
+func (c cacheInfo) isValid(objInfo ObjectInfo) bool {
+	return c.Bucket == objInfo.Bucket && c.Object == objInfo.Name &&
+		c.Size == objInfo.Size && c.MD5Sum == objInfo.MD5Sum && c.ModTime.Equal(objInfo.ModTime)
+}
+
+// cacheDrive - cache entries stored on a single local drive.
+type cacheDrive struct {
+	dir    string
+	maxUse int
+	// Returns the used space of the drive in percent.
+	usage func(dir string) (int, error)
+	// Set while eviction is running.
+	purging int32
+}
+
+// getDiskUsage - returns the used space of the disk holding dir in percent.
+func getDiskUsage(dir string) (int, error) {
+	info, err := disk.GetInfo(dir)
+	if err != nil {
+		return 0, err
+	}
+	if info.Total <= 0 {
+		return 0, nil
+	}
+	return int(100 - info.Free*100/info.Total), nil
+}
+
+// newCacheDrive - initializes a cache drive, entries left over from
+// an interrupted fill are removed.
+func newCacheDrive(dir string, maxUse int) (*cacheDrive, error) {
+	if err := os.RemoveAll(filepath.Join(dir, cacheTmpDir)); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, cacheTmpDir), 0700); err != nil {
+		return nil, err
+	}
+	return &cacheDrive{dir: dir, maxUse: maxUse, usage: getDiskUsage}, nil
+}
+
+// entryDir - returns the directory of the cache entry of an object.
+func (d *cacheDrive) entryDir(bucket, object string) string {
+	sum := sha256.Sum256([]byte(path.Join(bucket, object)))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// remove - removes the cache entry of an object.
+func (d *cacheDrive) remove(bucket, object string) {
+	os.RemoveAll(d.entryDir(bucket, object))
+}
+
+// get - serves the requested range from the cache entry of an object,
+// returns false if there is no valid entry for the backend object.
+func (d *cacheDrive) get(objInfo ObjectInfo, startOffset, length int64, writer io.Writer) (bool, error) {
+	entryDir := d.entryDir(objInfo.Bucket, objInfo.Name)
+	infoBytes, err := ioutil.ReadFile(filepath.Join(entryDir, cacheInfoFile))
+	if err != nil {
+		return false, nil
+	}
+	var info cacheInfo
+	if err = json.Unmarshal(infoBytes, &info); err != nil || !info.isValid(objInfo) {
+		// Stale entries are dropped right away.
+		os.RemoveAll(entryDir)
+		return false, nil
+	}
+	dataPath := filepath.Join(entryDir, cacheDataFile)
+	file, err := os.Open(dataPath)
+	if err != nil {
+		return false, nil
+	}
+	defer file.Close()
+	if _, err = file.Seek(startOffset, os.SEEK_SET); err != nil {
+		return false, nil
+	}
+	// Access time is tracked by the modification time of the data
+	// file, atime is often disabled on cache drives.
+	now := time.Now()
+	os.Chtimes(dataPath, now, now)
+	if _, err = copyN(writer, file, length); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// cacheWriter - writes into a cache file, the first error stops
+// writing without failing the client request.
+type cacheWriter struct {
+	file *os.File
+	err  error
+}
+
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	if w.err == nil {
+		_, w.err = w.file.Write(p)
+	}
+	return len(p), nil
+}
+
+// fill - streams the whole object from the backend to writer and
+// stores it as new cache entry.
+func (d *cacheDrive) fill(ctx context.Context, objAPI ObjectLayer, objInfo ObjectInfo, writer io.Writer) error {
+	tmpDir := filepath.Join(d.dir, cacheTmpDir, getUUID())
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return objAPI.GetObject(ctx, objInfo.Bucket, objInfo.Name, 0, objInfo.Size, writer)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file, err := os.Create(filepath.Join(tmpDir, cacheDataFile))
+	if err != nil {
+		return objAPI.GetObject(ctx, objInfo.Bucket, objInfo.Name, 0, objInfo.Size, writer)
+	}
+	cw := &cacheWriter{file: file}
+	err = objAPI.GetObject(ctx, objInfo.Bucket, objInfo.Name, 0, objInfo.Size, io.MultiWriter(writer, cw))
+	if cerr := file.Close(); cw.err == nil {
+		cw.err = cerr
+	}
+	if err != nil || cw.err != nil {
+		return err
+	}
+
+	info := cacheInfo{
+		Bucket:  objInfo.Bucket,
+		Object:  objInfo.Name,
+		Size:    objInfo.Size,
+		MD5Sum:  objInfo.MD5Sum,
+		ModTime: objInfo.ModTime,
+	}
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return nil
+	}
+	if err = ioutil.WriteFile(filepath.Join(tmpDir, cacheInfoFile), infoBytes, 0600); err != nil {
+		return nil
+	}
+	entryDir := d.entryDir(objInfo.Bucket, objInfo.Name)
+	os.RemoveAll(entryDir)
+	if err = os.Rename(tmpDir, entryDir); err != nil {
+		return nil
+	}
+	go d.purge()
+	return nil
+}
+
+// cacheEntry - cache entry directory and its last access time.
+type cacheEntry struct {
+	dir        string
+	accessTime time.Time
+}
+
+// byAccessTime - sorts cache entries, least recently used first.
+type byAccessTime []cacheEntry
+
+func (b byAccessTime) Len() int           { return len(b) }
+func (b byAccessTime) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byAccessTime) Less(i, j int) bool { return b[i].accessTime.Before(b[j].accessTime) }
+
+// purge - evicts least recently used entries until the drive usage
+// drops below the configured maximum.
+func (d *cacheDrive) purge() {
+	// Only one eviction at a time per drive.
+	if !atomic.CompareAndSwapInt32(&d.purging, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&d.purging, 0)
+
+	usage, err := d.usage(d.dir)
+	if err != nil || usage <= d.maxUse {
+		return
+	}
+	entries, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+	var cacheEntries []cacheEntry
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == cacheTmpDir {
+			continue
+		}
+		dir := filepath.Join(d.dir, entry.Name())
+		fi, err := os.Stat(filepath.Join(dir, cacheDataFile))
+		if err != nil {
+			// Incomplete entry.
+			os.RemoveAll(dir)
+			continue
+		}
+		cacheEntries = append(cacheEntries, cacheEntry{dir, fi.ModTime()})
+	}
+	sort.Sort(byAccessTime(cacheEntries))
+	for _, entry := range cacheEntries {
+		if err = os.RemoveAll(entry.dir); err != nil {
+			return
+		}
+		if usage, err = d.usage(d.dir); err != nil || usage <= d.maxUse {
+			return
+		}
+	}
+}
+
+// cacheObjects - serves repeated reads of whole objects from local
+// cache drives, all other operations pass through to the backend.
+// Cache entries are validated against the backend object on every
+// read, so objects changed by other clients are never served stale.
+type cacheObjects struct {
+	ObjectLayer
+	drives  []*cacheDrive
+	exclude []string
+}
+
+// newCacheObjects - wraps backend with a disk cache according to config.
+func newCacheObjects(backend ObjectLayer, config cacheConfig) (ObjectLayer, error) {
+	if len(config.Drives) == 0 {
+		return nil, errors.New("No cache drives configured")
+	}
+	maxUse := config.MaxUse
+	if maxUse == 0 {
+		maxUse = cacheDefaultMaxUse
+	}
+	if maxUse < 0 || maxUse > 100 {
+		return nil, fmt.Errorf("Invalid cache max use %d", config.MaxUse)
+	}
+	c := cacheObjects{
+		ObjectLayer: backend,
+		exclude:     config.Exclude,
+	}
+	for _, dir := range config.Drives {
+		drive, err := newCacheDrive(dir, maxUse)
+		if err != nil {
+			return nil, err
+		}
+		c.drives = append(c.drives, drive)
+	}
+	return c, nil
+}
+
+// isExcluded - returns true if the object must not be cached.
+func (c cacheObjects) isExcluded(bucket, object string) bool {
+	for _, pattern := range c.exclude {
+		if resourceMatch(pattern, path.Join(bucket, object)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getDrive - returns the cache drive holding the entry of an object.
+func (c cacheObjects) getDrive(bucket, object string) *cacheDrive {
+	sum := sha256.Sum256([]byte(path.Join(bucket, object)))
+	return c.drives[binary.BigEndian.Uint32(sum[:4])%uint32(len(c.drives))]
+}
+
+// GetObject - serves the object from the cache if the cache entry
+// matches the backend object, reads of whole objects fill the cache.
+func (c cacheObjects) GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	if c.isExcluded(bucket, object) {
+		return c.ObjectLayer.GetObject(ctx, bucket, object, startOffset, length, writer)
+	}
+	drive := c.getDrive(bucket, object)
+	objInfo, err := c.ObjectLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			drive.remove(bucket, object)
+		}
+		return err
+	}
+	if startOffset < 0 || length < 0 || startOffset+length > objInfo.Size {
+		return c.ObjectLayer.GetObject(ctx, bucket, object, startOffset, length, writer)
+	}
+	if hit, err := drive.get(objInfo, startOffset, length, writer); hit {
+		return err
+	}
+	// Partial reads are not worth a round trip of the whole object.
+	if startOffset != 0 || length != objInfo.Size {
+		return c.ObjectLayer.GetObject(ctx, bucket, object, startOffset, length, writer)
+	}
+	return drive.fill(ctx, c.ObjectLayer, objInfo, writer)
+}
+
+// PutObject - writes the object to the backend and drops its cache entry.
+func (c cacheObjects) PutObject(ctx context.Context, bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	md5Sum, err := c.ObjectLayer.PutObject(ctx, bucket, object, size, data, metadata)
+	c.getDrive(bucket, object).remove(bucket, object)
+	return md5Sum, err
+}
+
+// DeleteObject - deletes the object on the backend and drops its cache entry.
+func (c cacheObjects) DeleteObject(bucket, object string) error {
+	err := c.ObjectLayer.DeleteObject(bucket, object)
+	c.getDrive(bucket, object).remove(bucket, object)
+	return err
+}
+
+// CompleteMultipartUpload - completes the upload on the backend and
+// drops the cache entry of the object.
+func (c cacheObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (string, error) {
+	md5Sum, err := c.ObjectLayer.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+	c.getDrive(bucket, object).remove(bucket, object)
+	return md5Sum, err
+}
@@ -0,0 +1,183 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests objects are served from the cache while they match the backend object.
+func TestCacheObjects(t *testing.T) {
+	backend, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+	cacheDir, err := ioutil.TempDir("", "minio-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(cacheDir)
+
+	objAPI, err := newCacheObjects(backend, cacheConfig{
+		Drives:  []string{cacheDir},
+		Exclude: []string{"bucket/*.tmp"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := objAPI.(cacheObjects)
+	drive := cache.getDrive("bucket", "object")
+
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"object", "object.tmp"} {
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, 5, strings.NewReader("hello"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	getObject := func(object string, startOffset, length int64) string {
+		buffer := new(bytes.Buffer)
+		if err = objAPI.GetObject(context.Background(), "bucket", object, startOffset, length, buffer); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.String()
+	}
+	isCached := func(object string) bool {
+		_, err = os.Stat(filepath.Join(drive.entryDir("bucket", object), cacheInfoFile))
+		return err == nil
+	}
+
+	// Range reads do not fill the cache.
+	if data := getObject("object", 1, 3); data != "ell" {
+		t.Fatalf("Expected ell, but instead found %s", data)
+	}
+	if isCached("object") {
+		t.Fatal("Expected range read not to be cached")
+	}
+	if data := getObject("object", 0, 5); data != "hello" {
+		t.Fatalf("Expected hello, but instead found %s", data)
+	}
+	if !isCached("object") {
+		t.Fatal("Expected object to be cached")
+	}
+
+	// Mark the cached data to tell where reads are served from.
+	dataPath := filepath.Join(drive.entryDir("bucket", "object"), cacheDataFile)
+	if err = ioutil.WriteFile(dataPath, []byte("HELLO"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data := getObject("object", 0, 5); data != "HELLO" {
+		t.Fatalf("Expected object to be served from cache, but instead found %s", data)
+	}
+	if data := getObject("object", 1, 3); data != "ELL" {
+		t.Fatalf("Expected range to be served from cache, but instead found %s", data)
+	}
+
+	// Objects changed behind the cache are not served stale.
+	if _, err = backend.PutObject(context.Background(), "bucket", "object", 5, strings.NewReader("world"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data := getObject("object", 0, 5); data != "world" {
+		t.Fatalf("Expected world, but instead found %s", data)
+	}
+
+	// Writes through the cache drop the cache entry.
+	if _, err = objAPI.PutObject(context.Background(), "bucket", "object", 3, strings.NewReader("new"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if isCached("object") {
+		t.Fatal("Expected cache entry to be removed")
+	}
+	getObject("object", 0, 3)
+	if err = objAPI.DeleteObject("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	if isCached("object") {
+		t.Fatal("Expected cache entry to be removed")
+	}
+
+	// Excluded objects are never cached.
+	if data := getObject("object.tmp", 0, 5); data != "hello" {
+		t.Fatalf("Expected hello, but instead found %s", data)
+	}
+	if isCached("object.tmp") {
+		t.Fatal("Expected excluded object not to be cached")
+	}
+}
+
+// Tests least recently used entries are evicted first.
+func TestCacheDrivePurge(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minio-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(cacheDir)
+
+	drive, err := newCacheDrive(cacheDir, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every entry takes up 20% of the drive.
+	drive.usage = func(dir string) (int, error) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return 0, err
+		}
+		return (len(entries) - 1) * 20, nil
+	}
+
+	objects := []string{"a", "b", "c", "d"}
+	for i, object := range objects {
+		entryDir := drive.entryDir("bucket", object)
+		if err = os.MkdirAll(entryDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		dataPath := filepath.Join(entryDir, cacheDataFile)
+		if err = ioutil.WriteFile(dataPath, []byte(object), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// "b" was used least recently, "c" after it.
+		accessTime := time.Now().Add(time.Duration(i) * time.Minute)
+		switch object {
+		case "b":
+			accessTime = time.Now().Add(-2 * time.Hour)
+		case "c":
+			accessTime = time.Now().Add(-time.Hour)
+		}
+		if err = os.Chtimes(dataPath, accessTime, accessTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	drive.purge()
+	for _, object := range objects {
+		_, err = os.Stat(drive.entryDir("bucket", object))
+		evicted := os.IsNotExist(err)
+		if evicted != (object == "b" || object == "c") {
+			t.Errorf("Object %s: Expected evicted to be %t", object, !evicted)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Clients may send an additional checksum of the payload in one of the
+// x-amz-checksum-* headers when creating an object. The checksum is
+// verified while the object is written and saved in the metadata of
+// the object, it is dropped once data is appended to the object.
+const (
+	// Internal metadata key holding the checksum of an object.
+	objectChecksumMetaKey = "checksum"
+
+	// Checksums are returned only if requested with this header.
+	amzChecksumMode = "X-Amz-Checksum-Mode"
+)
+
+// checksumAlgorithms - supported checksum algorithms.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"CRC32":  func() hash.Hash { return crc32.NewIEEE() },
+	"CRC32C": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+}
+
+// getChecksumHeader - returns the header carrying a checksum.
+func getChecksumHeader(algorithm string) string {
+	return http.CanonicalHeaderKey("x-amz-checksum-" + strings.ToLower(algorithm))
+}
+
+// errInvalidChecksumInfo - checksum saved with an object is invalid.
+var errInvalidChecksumInfo = errors.New("Checksum info of the object is invalid")
+
+// objectChecksumInfo - additional checksum of an object.
+type objectChecksumInfo struct {
+	Algorithm string `json:"algorithm"`
+	Checksum  string `json:"checksum"`
+}
+
+// getObjectChecksumRequest - returns the checksum sent along with a new
+// object, returns false if none was sent.
+func getObjectChecksumRequest(r *http.Request) (objectChecksumInfo, bool, APIErrorCode) {
+	var info objectChecksumInfo
+	for algorithm, newHash := range checksumAlgorithms {
+		checksum := r.Header.Get(getChecksumHeader(algorithm))
+		if checksum == "" {
+			continue
+		}
+		// Only a single checksum is allowed.
+		if info.Algorithm != "" {
+			return objectChecksumInfo{}, false, ErrMultipleChecksums
+		}
+		sum, err := base64.StdEncoding.DecodeString(checksum)
+		if err != nil || len(sum) != newHash().Size() {
+			return objectChecksumInfo{}, false, ErrInvalidChecksum
+		}
+		info = objectChecksumInfo{Algorithm: algorithm, Checksum: checksum}
+	}
+	return info, info.Algorithm != "", ErrNone
+}
+
+// checksumReader - verifies the checksum of the data read, once size
+// bytes were read or at EOF if size is unknown.
+type checksumReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	checksum string
+	size     int64
+	n        int64
+	verified bool
+}
+
+// newChecksumReader - returns a reader verifying the checksum in info.
+func newChecksumReader(reader io.Reader, size int64, info objectChecksumInfo) *checksumReader {
+	return &checksumReader{
+		reader:   reader,
+		hash:     checksumAlgorithms[info.Algorithm](),
+		checksum: info.Checksum,
+		size:     size,
+	}
+}
+
+// verify - returns BadDigest if the data read does not match the checksum.
+func (c *checksumReader) verify() error {
+	c.verified = true
+	if checksum := base64.StdEncoding.EncodeToString(c.hash.Sum(nil)); checksum != c.checksum {
+		return BadDigest{c.checksum, checksum}
+	}
+	return nil
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.hash.Write(p[:n])
+	c.n += int64(n)
+	if !c.verified && (err == io.EOF || c.size >= 0 && c.n >= c.size) {
+		// Last bytes are held back, readers copying exactly size
+		// bytes would otherwise not see the error.
+		if vErr := c.verify(); vErr != nil {
+			return 0, vErr
+		}
+	}
+	return n, err
+}
+
+// setObjectChecksumInfo - saves the checksum in the metadata of a new
+// object.
+func setObjectChecksumInfo(metadata map[string]string, info objectChecksumInfo) error {
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	metadata[objectChecksumMetaKey] = string(infoBytes)
+	return nil
+}
+
+// getObjectChecksum - returns the checksum of an object, returns false
+// if none was saved for the object.
+func getObjectChecksum(bucket string, objInfo ObjectInfo) (objectChecksumInfo, bool, error) {
+	value, ok := objInfo.Metadata[objectChecksumMetaKey]
+	if !ok {
+		return objectChecksumInfo{}, false, nil
+	}
+	info := objectChecksumInfo{}
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		return objectChecksumInfo{}, false, errInvalidChecksumInfo
+	}
+	if _, ok = checksumAlgorithms[info.Algorithm]; !ok {
+		return objectChecksumInfo{}, false, errInvalidChecksumInfo
+	}
+	return info, true, nil
+}
+
+// removeObjectChecksum - drops the checksum of an object whose data was
+// changed, object layers not saving metadata have no checksums.
+func removeObjectChecksum(objAPI ObjectLayer, bucket, object string) error {
+	updater, ok := getMetadataUpdater(objAPI)
+	if !ok {
+		return nil
+	}
+	return updater.UpdateObjectMetadata(bucket, object, func(metadata map[string]string) error {
+		delete(metadata, objectChecksumMetaKey)
+		return nil
+	})
+}
+
+// setObjectChecksumHeaders - sets the checksum header of an object.
+func setObjectChecksumHeaders(w http.ResponseWriter, info objectChecksumInfo) {
+	w.Header().Set(getChecksumHeader(info.Algorithm), info.Checksum)
+}
@@ -22,11 +22,16 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 const (
 	// Block size used for all internal operations version 1.
 	blockSizeV1 = 10 * 1024 * 1024 // 10MiB.
+
+	// Temporary entries of processes sharing a disk which have not been
+	// written to for this long are left behind and removed on start.
+	sharedTmpStaleAfter = 24 * time.Hour
 )
 
 // Register callback functions that needs to be called when process shutsdown.
@@ -49,6 +54,11 @@ func fsHouseKeeping(storageDisk StorageAPI) error {
 			return err
 		}
 	}
+	// Temp entries may be in use by other processes sharing the disk,
+	// only stale ones are removed.
+	if nsMutex != nil && nsMutex.fileLocker != nil {
+		return cleanupStaleDir(storageDisk, minioMetaBucket, tmpMetaPrefix, sharedTmpStaleAfter)
+	}
 	// Cleanup all temp entries upon start.
 	err = cleanupDir(storageDisk, minioMetaBucket, tmpMetaPrefix)
 	if err != nil {
@@ -67,6 +77,39 @@ func newStorageAPI(disk string) (storage StorageAPI, err error) {
 	return newRPCClient(disk)
 }
 
+// getDiskStatus - returns usage, health and format of disk, disk is
+// reported offline if it is nil or cannot be reached.
+func getDiskStatus(endpoint string, disk StorageAPI) DiskStatus {
+	status := DiskStatus{
+		Endpoint:   endpoint,
+		HealStatus: healStatusUnknown,
+	}
+	if disk == nil {
+		return status
+	}
+	info, err := disk.DiskInfo()
+	if err != nil {
+		return status
+	}
+	status.Online = true
+	status.DiskInfo = info
+	format, err := loadFormat(disk)
+	switch err {
+	case nil:
+		status.HealStatus = healStatusOK
+		if format.XL != nil {
+			status.FormatUUID = format.XL.Disk
+		}
+	case errUnformattedDisk:
+		status.HealStatus = healStatusUnformatted
+	case errCorruptedFormat:
+		status.HealStatus = healStatusCorrupted
+	default:
+		errorIf(err, "Unable to load format of %s.", endpoint)
+	}
+	return status
+}
+
 // House keeping code needed for XL.
 func xlHouseKeeping(storageDisks []StorageAPI) error {
 	// This happens for the first time, but keep this here since this
@@ -149,3 +192,39 @@ func cleanupDir(storage StorageAPI, volume, dirPath string) error {
 	err := delFunc(retainSlash(pathJoin(dirPath)))
 	return err
 }
+
+// cleanupStaleDir - removes files of a directory recursively which have
+// not been modified for staleAfter.
+func cleanupStaleDir(storage StorageAPI, volume, dirPath string, staleAfter time.Duration) error {
+	var delFunc func(string) error
+	delFunc = func(entryPath string) error {
+		if !strings.HasSuffix(entryPath, slashSeparator) {
+			fi, err := storage.StatFile(volume, entryPath)
+			if err == errFileNotFound {
+				// Removed by another process meanwhile.
+				return nil
+			}
+			if err != nil || time.Since(fi.ModTime) < staleAfter {
+				return err
+			}
+			if err = storage.DeleteFile(volume, entryPath); err != errFileNotFound {
+				return err
+			}
+			return nil
+		}
+		entries, err := storage.ListDir(volume, entryPath)
+		if err != nil {
+			if err == errFileNotFound {
+				return nil
+			}
+			return err
+		}
+		for _, entry := range entries {
+			if err = delFunc(pathJoin(entryPath, entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return delFunc(retainSlash(pathJoin(dirPath)))
+}
@@ -0,0 +1,343 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// Objects matching the configured extensions or content types are
+// compressed before they are handed to the object layer, the object
+// layer only sees the compressed data. Every block of plain data is
+// compressed as a separate stream, the codec, the plain size and the
+// offsets of the compressed blocks are saved in the metadata of the
+// object so compressed and plain objects can be mixed in a bucket and
+// range requests only decompress the blocks they cover. Objects
+// compressed before blocks were introduced are a single stream.
+const (
+	// Codec used for newly compressed objects.
+	compressionAlgorithmDeflate = "deflate"
+
+	// Internal metadata key holding the compression info of an object.
+	objectCompressionMetaKey = "compression"
+)
+
+var (
+	// errInvalidCompressionInfo - compression info saved with an
+	// object does not match the object.
+	errInvalidCompressionInfo = errors.New("Compression info of the object is invalid")
+
+	// errCompressionNotSupported - the object layer cannot save the
+	// compression info with objects.
+	errCompressionNotSupported = errors.New("Compression is not supported by the backend")
+
+	// errObjectOverwritten - object was overwritten while its info
+	// was updated.
+	errObjectOverwritten = errors.New("Object was overwritten")
+)
+
+// Size of plain data compressed as a separate stream.
+var compressionBlockSize int64 = 1024 * 1024
+
+// compressionConfig - object compression configuration.
+type compressionConfig struct {
+	Enable     bool     `json:"enable"`
+	Extensions []string `json:"extensions"`
+	MimeTypes  []string `json:"mimeTypes"`
+}
+
+// newCompressionConfig - returns the default compression config, text
+// formats are compressed once compression is enabled.
+func newCompressionConfig() compressionConfig {
+	return compressionConfig{
+		Enable:     false,
+		Extensions: []string{".txt", ".log", ".csv", ".json", ".xml"},
+		MimeTypes:  []string{"text/*", "application/json", "application/xml"},
+	}
+}
+
+// objectCompressionInfo - codec and sizes of a compressed object, the
+// sizes are unknown until all data is compressed.
+type objectCompressionInfo struct {
+	Algorithm      string `json:"algorithm"`
+	Size           int64  `json:"size"`
+	CompressedSize int64  `json:"compressedSize"`
+	// Plain size of the blocks and offsets of the compressed blocks,
+	// no blocks for objects compressed as a single stream.
+	BlockSize int64   `json:"blockSize,omitempty"`
+	Blocks    []int64 `json:"blocks,omitempty"`
+}
+
+// isCompressible - returns true if a new object is to be compressed
+// according to the compression config, objects already carrying a
+// content encoding are stored as is.
+func isCompressible(config compressionConfig, object, contentType, contentEncoding string) bool {
+	if !config.Enable || contentEncoding != "" {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(object))
+	for _, extension := range config.Extensions {
+		if ext != "" && ext == strings.ToLower(extension) {
+			return true
+		}
+	}
+	// Ignore any parameters, e.g. "text/plain; charset=utf-8".
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if contentType == "" {
+		return false
+	}
+	for _, mimeType := range config.MimeTypes {
+		mimeType = strings.ToLower(mimeType)
+		if strings.HasSuffix(mimeType, "/*") {
+			if strings.HasPrefix(contentType, strings.TrimSuffix(mimeType, "*")) {
+				return true
+			}
+		} else if contentType == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// getObjectCompressionRequest - returns true if a newly created object
+// is to be compressed, encrypted objects are never compressed. Objects
+// are only compressed by object layers saving the compression info
+// with them.
+func getObjectCompressionRequest(objAPI ObjectLayer, object string, metadata map[string]string, encrypt bool) bool {
+	if encrypt || serverConfig == nil {
+		return false
+	}
+	if _, ok := getMetadataUpdater(objAPI); !ok {
+		return false
+	}
+	return isCompressible(serverConfig.GetCompression(), object, metadata["content-type"], metadata["content-encoding"])
+}
+
+// countWriter - counts the bytes written to the underlying writer.
+type countWriter struct {
+	io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// setObjectCompressionInfo - saves compression info in the metadata of
+// a new object.
+func setObjectCompressionInfo(metadata map[string]string, info objectCompressionInfo) error {
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	metadata[objectCompressionMetaKey] = string(infoBytes)
+	return nil
+}
+
+// getObjectCompressionInfo - returns the compression info saved in the
+// metadata of an object, false if it is not compressed.
+func getObjectCompressionInfo(metadata map[string]string) (objectCompressionInfo, bool, error) {
+	value, ok := metadata[objectCompressionMetaKey]
+	if !ok {
+		return objectCompressionInfo{}, false, nil
+	}
+	info := objectCompressionInfo{}
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		return objectCompressionInfo{}, false, errInvalidCompressionInfo
+	}
+	if info.Algorithm != compressionAlgorithmDeflate {
+		return objectCompressionInfo{}, false, errInvalidCompressionInfo
+	}
+	return info, true, nil
+}
+
+// putCompressedObject - creates a compressed object, the md5sum in
+// metadata is verified against the plain data. The object is created
+// with sizes unknown, they are saved once all data is compressed, it
+// cannot be read in between.
+func putCompressedObject(ctx context.Context, objAPI ObjectLayer, bucket, object string, reader io.Reader, metadata map[string]string) (string, error) {
+	updater, ok := getMetadataUpdater(objAPI)
+	if !ok {
+		return "", errCompressionNotSupported
+	}
+	md5Hex := metadata["md5Sum"]
+	// Object layer only sees the compressed data.
+	delete(metadata, "md5Sum")
+
+	info := objectCompressionInfo{
+		Algorithm:      compressionAlgorithmDeflate,
+		Size:           -1,
+		CompressedSize: -1,
+		BlockSize:      compressionBlockSize,
+	}
+	if err := setObjectCompressionInfo(metadata, info); err != nil {
+		return "", err
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		counter := &countWriter{Writer: pipeWriter}
+		zw, err := flate.NewWriter(counter, flate.BestSpeed)
+		if err != nil {
+			pipeWriter.CloseWithError(err)
+			return
+		}
+		md5Writer := md5.New()
+		teeReader := io.TeeReader(reader, md5Writer)
+		var size int64
+		for {
+			blockOffset := counter.n
+			zw.Reset(counter)
+			n, err := io.CopyN(zw, teeReader, info.BlockSize)
+			size += n
+			if err != nil && err != io.EOF {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			// Every object has at least one block.
+			if n == 0 && len(info.Blocks) > 0 {
+				break
+			}
+			info.Blocks = append(info.Blocks, blockOffset)
+			if cerr := zw.Close(); cerr != nil {
+				pipeWriter.CloseWithError(cerr)
+				return
+			}
+			if err == io.EOF {
+				break
+			}
+		}
+		newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
+		if md5Hex != "" && newMD5Hex != md5Hex {
+			pipeWriter.CloseWithError(BadDigest{md5Hex, newMD5Hex})
+			return
+		}
+		info.Size = size
+		info.CompressedSize = counter.n
+		pipeWriter.Close()
+	}()
+	md5Sum, err := objAPI.PutObject(ctx, bucket, object, -1, pipeReader, metadata)
+	// Unblock the compressing routine if the object layer failed early.
+	pipeReader.Close()
+	<-doneCh
+	if err != nil {
+		return "", err
+	}
+	err = updater.UpdateObjectMetadata(bucket, object, func(metadata map[string]string) error {
+		// Object was overwritten in the meantime, it is left as is.
+		if metadata["md5Sum"] != md5Sum {
+			return errObjectOverwritten
+		}
+		return setObjectCompressionInfo(metadata, info)
+	})
+	if err != nil && err != errObjectOverwritten {
+		return "", err
+	}
+	return md5Sum, nil
+}
+
+// deflateBlocksReader - decompresses consecutive deflate streams.
+type deflateBlocksReader struct {
+	// Buffered so the decompressor does not read beyond the end of a
+	// stream.
+	reader *bufio.Reader
+	zr     io.ReadCloser
+}
+
+func (d *deflateBlocksReader) Read(p []byte) (int, error) {
+	for {
+		if d.zr == nil {
+			// No more streams at the end of the data.
+			if _, err := d.reader.Peek(1); err != nil {
+				return 0, err
+			}
+			d.zr = flate.NewReader(d.reader)
+		}
+		n, err := d.zr.Read(p)
+		if err == io.EOF {
+			d.zr.Close()
+			d.zr = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// getCompressedObject - writes length bytes of plain data starting at
+// startOffset, only the blocks covering the range are decompressed.
+func getCompressedObject(ctx context.Context, objAPI ObjectLayer, bucket, object string, info objectCompressionInfo, startOffset, length int64, writer io.Writer) error {
+	offset, endOffset := int64(0), info.CompressedSize
+	if len(info.Blocks) > 0 && info.BlockSize > 0 {
+		block := startOffset / info.BlockSize
+		if block >= int64(len(info.Blocks)) {
+			block = int64(len(info.Blocks)) - 1
+		}
+		offset = info.Blocks[block]
+		startOffset -= block * info.BlockSize
+		if length > 0 {
+			lastBlock := (block*info.BlockSize + startOffset + length - 1) / info.BlockSize
+			if lastBlock+1 < int64(len(info.Blocks)) {
+				endOffset = info.Blocks[lastBlock+1]
+			}
+		}
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	go func() {
+		pipeWriter.CloseWithError(objAPI.GetObject(ctx, bucket, object, offset, endOffset-offset, pipeWriter))
+	}()
+	zr := &deflateBlocksReader{reader: bufio.NewReader(pipeReader)}
+	if _, err := io.CopyN(ioutil.Discard, zr, startOffset); err != nil {
+		return err
+	}
+	_, err := copyN(writer, zr, length)
+	return err
+}
+
+// getObjectCompression - returns the compression info of an object and
+// changes the object size to its plain size, returns false if the
+// object is not compressed. Compression info not matching the object
+// fails, the object is never served as plain data.
+func getObjectCompression(bucket string, objInfo *ObjectInfo) (objectCompressionInfo, bool, error) {
+	info, compressed, err := getObjectCompressionInfo(objInfo.Metadata)
+	if err != nil || !compressed {
+		return objectCompressionInfo{}, false, err
+	}
+	// Sizes are unknown while the object is still being compressed.
+	if info.Size < 0 || info.CompressedSize != objInfo.Size {
+		return objectCompressionInfo{}, false, errInvalidCompressionInfo
+	}
+	objInfo.Size = info.Size
+	return info, true, nil
+}
@@ -0,0 +1,193 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+)
+
+// Tests objects are selected for compression by the compression config.
+func TestIsCompressible(t *testing.T) {
+	config := newCompressionConfig()
+	config.Enable = true
+	testCases := []struct {
+		config          compressionConfig
+		object          string
+		contentType     string
+		contentEncoding string
+		compressible    bool
+	}{
+		// Test case - 1.
+		// Compression disabled.
+		{newCompressionConfig(), "object.txt", "text/plain", "", false},
+		// Test case - 2.
+		{config, "object.txt", "", "", true},
+		// Test case - 3.
+		// Extensions are case insensitive.
+		{config, "dir/OBJECT.CSV", "", "", true},
+		// Test case - 4.
+		// Wildcard content type with parameters.
+		{config, "object", "text/html; charset=utf-8", "", true},
+		// Test case - 5.
+		{config, "object", "application/json", "", true},
+		// Test case - 6.
+		{config, "object.jpg", "image/jpeg", "", false},
+		// Test case - 7.
+		// Objects with a content encoding are stored as is.
+		{config, "object.txt", "text/plain", "gzip", false},
+		// Test case - 8.
+		{config, "object", "", "", false},
+	}
+	for i, testCase := range testCases {
+		compressible := isCompressible(testCase.config, testCase.object, testCase.contentType, testCase.contentEncoding)
+		if compressible != testCase.compressible {
+			t.Errorf("Test %d: Expected %t, but instead found %t", i+1, testCase.compressible, compressible)
+		}
+	}
+}
+
+// Wrapper for calling compressed object tests for both XL multiple disks and single node setup.
+func TestCompressedObject(t *testing.T) {
+	ExecObjectLayerTest(t, testCompressedObject)
+}
+
+// Tests compressed objects are stored compressed and any range can be read back.
+func testCompressedObject(obj ObjectLayer, instanceType string, t *testing.T) {
+	// Compress in small blocks to read ranges across blocks.
+	defer func(blockSize int64) { compressionBlockSize = blockSize }(compressionBlockSize)
+	compressionBlockSize = 4096
+
+	bucket, object := "bucket", "object.txt"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	data := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz012345"), 4096)
+	md5Sum := md5.Sum(data)
+
+	// Data is verified against a wrong md5sum.
+	metadata := map[string]string{"md5Sum": hex.EncodeToString(md5Sum[:1])}
+	if _, err := putCompressedObject(context.Background(), obj, bucket, object, bytes.NewReader(data), metadata); err == nil {
+		t.Fatalf("%s: Expected BadDigest error", instanceType)
+	}
+
+	metadata = map[string]string{"md5Sum": hex.EncodeToString(md5Sum[:])}
+	if _, err := putCompressedObject(context.Background(), obj, bucket, object, bytes.NewReader(data), metadata); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.Size >= int64(len(data)) {
+		t.Fatalf("%s: Expected object to be compressed, but found size %d", instanceType, objInfo.Size)
+	}
+	info, compressed, err := getObjectCompression(bucket, &objInfo)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !compressed || objInfo.Size != int64(len(data)) {
+		t.Fatalf("%s: Expected compressed object of size %d, but instead found size %d", instanceType, len(data), objInfo.Size)
+	}
+	if len(info.Blocks) != len(data)/4096 {
+		t.Fatalf("%s: Expected %d blocks, but found %d", instanceType, len(data)/4096, len(info.Blocks))
+	}
+
+	testCases := []struct {
+		startOffset int64
+		length      int64
+	}{
+		// Test case - 1.
+		// Entire object.
+		{0, int64(len(data))},
+		// Test case - 2.
+		{10, 100},
+		// Test case - 3.
+		// Last bytes of the object.
+		{int64(len(data)) - 5, 5},
+		// Test case - 4.
+		// Range across blocks.
+		{4000, 10000},
+		// Test case - 5.
+		// Exactly one block.
+		{8192, 4096},
+	}
+	for i, testCase := range testCases {
+		buffer := new(bytes.Buffer)
+		err = getCompressedObject(context.Background(), obj, bucket, object, info, testCase.startOffset, testCase.length, buffer)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		expected := data[testCase.startOffset : testCase.startOffset+testCase.length]
+		if !bytes.Equal(buffer.Bytes(), expected) {
+			t.Errorf("%s: Test %d: Decompressed data does not match", instanceType, i+1)
+		}
+	}
+
+	// Objects compressed as a single stream are still readable.
+	compressedData := new(bytes.Buffer)
+	zw, err := flate.NewWriter(compressedData, flate.BestSpeed)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = zw.Write(data); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.PutObject(context.Background(), bucket, "legacy.txt", int64(compressedData.Len()), bytes.NewReader(compressedData.Bytes()), nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	legacyInfo := objectCompressionInfo{
+		Algorithm:      compressionAlgorithmDeflate,
+		Size:           int64(len(data)),
+		CompressedSize: int64(compressedData.Len()),
+	}
+	buffer := new(bytes.Buffer)
+	if err = getCompressedObject(context.Background(), obj, bucket, "legacy.txt", legacyInfo, 5000, 100, buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data[5000:5100]) {
+		t.Errorf("%s: Decompressed data of single stream object does not match", instanceType)
+	}
+
+	// Overwritten objects are no longer reported as compressed.
+	if _, err = obj.PutObject(context.Background(), bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, object); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, compressed, err = getObjectCompression(bucket, &objInfo); err != nil || compressed {
+		t.Fatalf("%s: Expected object not to be compressed: %v", instanceType, err)
+	}
+
+	// Compression info not matching the object fails.
+	objInfo.Metadata = map[string]string{}
+	if err = setObjectCompressionInfo(objInfo.Metadata, info); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, _, err = getObjectCompression(bucket, &objInfo); err != errInvalidCompressionInfo {
+		t.Fatalf("%s: Expected %s, but instead found %v", instanceType, errInvalidCompressionInfo, err)
+	}
+}
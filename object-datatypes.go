@@ -26,6 +26,36 @@ type StorageInfo struct {
 	Free int64
 }
 
+// Heal status of a disk.
+const (
+	// Disk is formatted and consistent.
+	healStatusOK = "ok"
+	// Disk is missing its format and needs to be healed.
+	healStatusUnformatted = "unformatted"
+	// Disk holds data but its format is corrupted.
+	healStatusCorrupted = "corrupted"
+	// Disk is offline, heal status cannot be determined.
+	healStatusUnknown = "unknown"
+)
+
+// DiskStatus - represents usage, health and format of a disk.
+type DiskStatus struct {
+	// Disk path, prefixed with the node address for network disks.
+	Endpoint string
+
+	// Online is false if the disk cannot be reached.
+	Online bool
+
+	// Usage and error statistics of the disk.
+	DiskInfo
+
+	// Disk UUID assigned in the backend format, empty for FS.
+	FormatUUID string
+
+	// One of the heal status values.
+	HealStatus string
+}
+
 // BucketInfo - represents bucket metadata.
 type BucketInfo struct {
 	// Name of the bucket.
@@ -62,6 +92,10 @@ type ObjectInfo struct {
 	// what decoding mechanisms must be applied to obtain the object referenced
 	// by the Content-Type header field.
 	ContentEncoding string
+
+	// Metadata saved with the object, includes internal keys which
+	// are never sent to clients.
+	Metadata map[string]string
 }
 
 // ListPartsInfo - represents list of all parts.
@@ -95,7 +129,10 @@ type ListPartsInfo struct {
 	// List of all parts.
 	Parts []partInfo
 
-	EncodingType string // Not supported yet.
+	// Metadata of the upload, saved with the object once completed.
+	Metadata map[string]string
+
+	EncodingType string // Not used, keys are encoded by the handlers.
 }
 
 // ListMultipartsInfo - represnets bucket resources for incomplete multipart uploads.
@@ -142,7 +179,7 @@ type ListMultipartsInfo struct {
 	// next occurrence of the string specified by delimiter.
 	CommonPrefixes []string
 
-	EncodingType string // Not supported yet.
+	EncodingType string // Not used, keys are encoded by the handlers.
 }
 
 // ListObjectsInfo - container for list objects.
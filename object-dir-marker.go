@@ -0,0 +1,198 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Directory markers are zero byte objects whose names end with "/",
+// consoles and Hadoop create them to keep empty directories. A marker
+// is kept as an empty directory, directories holding objects are not
+// markers. Delimited listings roll markers up into prefixes like any
+// other directory, recursive listings and listings of the marker
+// itself list them as zero byte objects.
+
+// dirMarkerMD5Sum - md5 of the empty content of directory markers.
+const dirMarkerMD5Sum = "d41d8cd98f00b204e9800998ecf8427e"
+
+// isDirMarker - returns if object names a directory marker.
+func isDirMarker(object string) bool {
+	if !strings.HasSuffix(object, slashSeparator) {
+		return false
+	}
+	return IsValidObjectName(strings.TrimSuffix(object, slashSeparator))
+}
+
+// dirMarkerInfo - returns the info of the directory marker object.
+func dirMarkerInfo(bucket, object string, modTime time.Time) ObjectInfo {
+	return ObjectInfo{
+		Bucket:  bucket,
+		Name:    object,
+		ModTime: modTime,
+		MD5Sum:  dirMarkerMD5Sum,
+	}
+}
+
+// statDirMarker - returns the info of the directory of the directory
+// marker object on disk, errFileNotFound unless it is empty.
+func statDirMarker(disk StorageAPI, bucket, object string) (FileInfo, error) {
+	fi, err := disk.StatFile(bucket, object)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if !fi.Mode.IsDir() {
+		return FileInfo{}, errFileNotFound
+	}
+	entries, err := disk.ListDir(bucket, object)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if len(entries) > 0 {
+		return FileInfo{}, errFileNotFound
+	}
+	return fi, nil
+}
+
+// putDirMarker - creates the directory marker object.
+func (fs fsObjects) putDirMarker(bucket, object string, size int64) (string, error) {
+	if size != 0 {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	if err := fs.storage.MakeDir(bucket, object); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+	return dirMarkerMD5Sum, nil
+}
+
+// getDirMarkerInfo - returns the info of the directory marker object.
+func (fs fsObjects) getDirMarkerInfo(bucket, object string) (ObjectInfo, error) {
+	fi, err := statDirMarker(fs.storage, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+	return dirMarkerInfo(bucket, object, fi.ModTime), nil
+}
+
+// deleteDirMarker - deletes the directory marker object.
+func (fs fsObjects) deleteDirMarker(bucket, object string) error {
+	if _, err := statDirMarker(fs.storage, bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	if err := fs.storage.DeleteFile(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// putDirMarker - creates the directory marker object on all disks.
+func (xl xlObjects) putDirMarker(bucket, object string, size int64) (string, error) {
+	if size != 0 {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Objects are directories as well, a marker cannot be created at
+	// or below an object.
+	dir := strings.TrimSuffix(object, slashSeparator)
+	if xl.isObject(bucket, dir) || xl.parentDirIsObject(bucket, path.Dir(dir)) {
+		return "", toObjectErr(errFileAccessDenied, bucket, object)
+	}
+
+	var wg = &sync.WaitGroup{}
+	var dErrs = make([]error, len(xl.storageDisks))
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			dErrs[index] = errDiskNotFound
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			dErrs[index] = disk.MakeDir(bucket, object)
+		}(index, disk)
+	}
+	wg.Wait()
+
+	if !isQuorum(dErrs, xl.writeQuorum) {
+		return "", toObjectErr(errXLWriteQuorum, bucket, object)
+	}
+	for _, err := range dErrs {
+		if err != nil && err != errDiskNotFound {
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+	return dirMarkerMD5Sum, nil
+}
+
+// getDirMarkerInfo - returns the info of the directory marker object
+// from the first disk which has it.
+func (xl xlObjects) getDirMarkerInfo(bucket, object string) (ObjectInfo, error) {
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+	for _, disk := range xl.getLoadBalancedQuorumDisks() {
+		if disk == nil {
+			continue
+		}
+		fi, err := statDirMarker(disk, bucket, object)
+		if err == nil {
+			return dirMarkerInfo(bucket, object, fi.ModTime), nil
+		}
+		// Ignore for file not found, disk not found or faulty disk.
+		if err == errFileNotFound || err == errDiskNotFound || err == errFaultyDisk {
+			continue
+		}
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+	return ObjectInfo{}, ObjectNotFound{Bucket: bucket, Object: object}
+}
+
+// deleteDirMarker - deletes the directory marker object on all disks.
+func (xl xlObjects) deleteDirMarker(bucket, object string) error {
+	if _, err := xl.getDirMarkerInfo(bucket, object); err != nil {
+		return err
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	var wg = &sync.WaitGroup{}
+	var dErrs = make([]error, len(xl.storageDisks))
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			dErrs[index] = errDiskNotFound
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			// Directories holding objects are left as they are.
+			if err := disk.DeleteFile(bucket, object); err != nil && err != errFileNotFound {
+				dErrs[index] = err
+			}
+		}(index, disk)
+	}
+	wg.Wait()
+
+	if !isQuorum(dErrs, xl.writeQuorum) {
+		return toObjectErr(errXLWriteQuorum, bucket, object)
+	}
+	return nil
+}
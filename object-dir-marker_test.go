@@ -0,0 +1,126 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// Wrapper for calling testDirMarkers for both XL and single node setup.
+func TestDirMarkers(t *testing.T) {
+	ExecObjectLayerTest(t, testDirMarkers)
+}
+
+// Tests listings of directory markers and zero byte objects.
+func testDirMarkers(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for object, data := range map[string]string{"a/empty/": "", "a/file": "data", "zero": ""} {
+		if _, err := obj.PutObject(context.Background(), bucket, object, int64(len(data)), bytes.NewBufferString(data), nil); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	objectNames := func(result ListObjectsInfo) (names []string) {
+		for _, objInfo := range result.Objects {
+			names = append(names, objInfo.Name)
+		}
+		return names
+	}
+
+	// Recursive listings list markers as zero byte objects.
+	result, err := obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if names := objectNames(result); !reflect.DeepEqual(names, []string{"a/empty/", "a/file", "zero"}) {
+		t.Fatalf("%s: Unexpected objects %v", instanceType, names)
+	}
+	for _, objInfo := range []ObjectInfo{result.Objects[0], result.Objects[2]} {
+		if objInfo.Size != 0 || objInfo.MD5Sum != dirMarkerMD5Sum {
+			t.Errorf("%s: Expected %s to be empty with md5 %s, got %d bytes with md5 %s", instanceType, objInfo.Name, dirMarkerMD5Sum, objInfo.Size, objInfo.MD5Sum)
+		}
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, "zero")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if objInfo.MD5Sum != dirMarkerMD5Sum {
+		t.Errorf("%s: Expected md5 %s, got %s", instanceType, dirMarkerMD5Sum, objInfo.MD5Sum)
+	}
+
+	// Markers are listed once in paged listings.
+	var names []string
+	marker := ""
+	for {
+		result, err = obj.ListObjects(bucket, "", marker, "", 1)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		names = append(names, objectNames(result)...)
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+	if !reflect.DeepEqual(names, []string{"a/empty/", "a/file", "zero"}) {
+		t.Errorf("%s: Unexpected paged objects %v", instanceType, names)
+	}
+
+	// Delimited listings list markers as prefixes.
+	result, err = obj.ListObjects(bucket, "a/", "", slashSeparator, 10)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(result.Prefixes, []string{"a/empty/"}) || !reflect.DeepEqual(objectNames(result), []string{"a/file"}) {
+		t.Errorf("%s: Unexpected prefixes %v and objects %v", instanceType, result.Prefixes, objectNames(result))
+	}
+
+	// Listings of the marker itself list the marker.
+	for _, delimiter := range []string{"", slashSeparator} {
+		result, err = obj.ListObjects(bucket, "a/empty/", "", delimiter, 10)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if len(result.Prefixes) != 0 || !reflect.DeepEqual(objectNames(result), []string{"a/empty/"}) {
+			t.Errorf("%s: Unexpected prefixes %v and objects %v", instanceType, result.Prefixes, objectNames(result))
+		}
+		result, err = obj.ListObjects(bucket, "a/empty/", "a/empty/", delimiter, 10)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if len(result.Objects) != 0 {
+			t.Errorf("%s: Expected nothing after the marker, got %v", instanceType, objectNames(result))
+		}
+	}
+
+	// Deleted markers are no longer listed.
+	if err = obj.DeleteObject(bucket, "a/empty/"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	result, err = obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if names := objectNames(result); !reflect.DeepEqual(names, []string{"a/file", "zero"}) {
+		t.Errorf("%s: Unexpected objects %v", instanceType, names)
+	}
+}
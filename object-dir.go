@@ -0,0 +1,218 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var errFilePermissionDenied = errors.New("Permission denied.")
+
+// fileRequest - file transfers carry no headers, helpers shared with
+// the S3 API see a request without any so that bucket defaults apply.
+var fileRequest = &http.Request{Header: make(http.Header), URL: &url.URL{}}
+
+// fileAPIError - an S3 API error of a file operation, e.g. a write
+// rejected by the server mode or object lock.
+type fileAPIError APIErrorCode
+
+func (e fileAPIError) Error() string {
+	return getAPIError(APIErrorCode(e)).Description
+}
+
+// dirEntry - a file or directory of the file system view of the object
+// layer served by the FTP, SFTP and NFS front-ends.
+type dirEntry struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// listDirEntries - returns the entries of a directory, buckets at the
+// top level and objects and prefixes below. Prefixes have no time of
+// their own and are listed with the current time.
+func listDirEntries(objAPI ObjectLayer, bucket, prefix string) ([]dirEntry, error) {
+	var entries []dirEntry
+	if bucket == "" {
+		buckets, err := objAPI.ListBuckets()
+		if err != nil {
+			return nil, err
+		}
+		for _, bucket := range buckets {
+			entries = append(entries, dirEntry{name: bucket.Name, modTime: bucket.Created, isDir: true})
+		}
+		return entries, nil
+	}
+	if prefix != "" {
+		prefix += slashSeparator
+	}
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, prefix, marker, slashSeparator, maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		// Encrypted and compressed objects are listed with their plain size.
+		setListedObjectSizes(bucket, result.Objects)
+		for _, objInfo := range result.Objects {
+			name := strings.TrimPrefix(objInfo.Name, prefix)
+			if name == "" {
+				continue
+			}
+			entries = append(entries, dirEntry{name: name, size: objInfo.Size, modTime: objInfo.ModTime})
+		}
+		for _, objPrefix := range result.Prefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(objPrefix, prefix), slashSeparator)
+			entries = append(entries, dirEntry{name: name, modTime: time.Now().UTC(), isDir: true})
+		}
+		if !result.IsTruncated {
+			return entries, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// resolveFilePath - returns bucket and object of a file path relative
+// to the directory cwd. Buckets are the top level directories.
+func resolveFilePath(cwd, p string) (bucket, object string) {
+	if !path.IsAbs(p) {
+		p = path.Join(cwd, p)
+	}
+	p = strings.TrimPrefix(path.Clean(p), slashSeparator)
+	if index := strings.Index(p, slashSeparator); index != -1 {
+		return p[:index], p[index+1:]
+	}
+	return p, ""
+}
+
+// isFileDir - returns true if bucket exists and has objects under the
+// directory prefix.
+func isFileDir(objAPI ObjectLayer, bucket, prefix string) bool {
+	if bucket == "" {
+		return true
+	}
+	if isReservedBucket(bucket) {
+		return false
+	}
+	if prefix == "" {
+		_, err := objAPI.GetBucketInfo(bucket)
+		return err == nil
+	}
+	result, err := objAPI.ListObjects(bucket, prefix+slashSeparator, "", slashSeparator, 1)
+	return err == nil && (len(result.Objects) > 0 || len(result.Prefixes) > 0)
+}
+
+// getFileInfo - returns the info of an object with its plain size.
+func getFileInfo(objAPI ObjectLayer, bucket, object string) (ObjectInfo, error) {
+	if bucket == "" || object == "" || isReservedBucket(bucket) {
+		return ObjectInfo{}, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return getPlainObjectInfo(bucket, objInfo)
+}
+
+// checkFileWrite - returns an error if the file cannot be written or
+// deleted.
+func checkFileWrite(objAPI ObjectLayer, bucket, object string) error {
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		return fileAPIError(s3Error)
+	}
+	if bucket == "" || object == "" || isReservedBucket(bucket) {
+		return errFilePermissionDenied
+	}
+	if s3Error := enforceObjectLock(objAPI, bucket, object, fileRequest); s3Error != ErrNone {
+		return fileAPIError(s3Error)
+	}
+	return nil
+}
+
+// checkDirWrite - returns an error if the directory cannot be created
+// or removed, only buckets at the top level can.
+func checkDirWrite(bucket string) error {
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		return fileAPIError(s3Error)
+	}
+	if bucket == "" || isReservedBucket(bucket) {
+		return errFilePermissionDenied
+	}
+	return nil
+}
+
+// filePut - upload of a file, checked before its data is read.
+type filePut struct {
+	objAPI         ObjectLayer
+	bucket, object string
+	lockInfo       objectLockInfo
+	sse            objectEncryptionRequest
+	encrypt        bool
+	compress       bool
+	metadata       map[string]string
+}
+
+// newFilePut - returns an upload of object, encrypted and compressed
+// like uploads through the S3 API are.
+func newFilePut(objAPI ObjectLayer, bucket, object string) (*filePut, error) {
+	if err := checkFileWrite(objAPI, bucket, object); err != nil {
+		return nil, err
+	}
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, fileRequest)
+	if s3Error != ErrNone {
+		return nil, fileAPIError(s3Error)
+	}
+	sse, encrypt, s3Error := getObjectEncryptionRequest(bucket, fileRequest)
+	if s3Error != ErrNone {
+		return nil, fileAPIError(s3Error)
+	}
+	metadata := make(map[string]string)
+	return &filePut{
+		objAPI:   objAPI,
+		bucket:   bucket,
+		object:   object,
+		lockInfo: lockInfo,
+		sse:      sse,
+		encrypt:  encrypt,
+		compress: getObjectCompressionRequest(objAPI, object, metadata, encrypt),
+		metadata: metadata,
+	}, nil
+}
+
+// put - creates the object from data.
+func (p *filePut) put(data io.Reader) error {
+	var err error
+	if p.encrypt {
+		_, err = putEncryptedObject(context.Background(), p.objAPI, p.bucket, p.object, p.sse, -1, data, p.metadata)
+	} else if p.compress {
+		_, err = putCompressedObject(context.Background(), p.objAPI, p.bucket, p.object, data, p.metadata)
+	} else {
+		_, err = p.objAPI.PutObject(context.Background(), p.bucket, p.object, -1, data, p.metadata)
+	}
+	if err != nil {
+		return err
+	}
+	// Save retention and legal hold for the new object.
+	return writeObjectLockInfo(p.objAPI, p.bucket, p.object, p.lockInfo)
+}
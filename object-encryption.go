@@ -0,0 +1,700 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Encrypted objects are stored as one or more encrypted streams, a
+// single stream for regular objects and one stream per part for
+// multipart objects. Every stream starts with a random header from
+// which the stream key is derived, followed by the data split into
+// packages, each package is sealed with AES-256-GCM.
+//
+//	| header (32 bytes) | package 0 | package 1 | ... | package n |
+//
+// Each package holds at most 64KiB of plain data followed by a 16
+// byte authentication tag, the package sequence number is used as
+// nonce. The object key itself is generated and sealed by the key
+// management service.
+const (
+	// Size of the random header at the start of every stream.
+	encryptionHeaderSize = 32
+
+	// Maximum size of plain data sealed as one package.
+	encryptionPackageSize = 64 * 1024
+
+	// Size of the authentication tag appended to every package.
+	encryptionTagSize = 16
+
+	// Internal metadata key of the encryption info of an object or
+	// of a multipart upload, never set from request headers.
+	objectEncryptionMetaKey = "encryption"
+)
+
+// Server side encryption headers.
+const (
+	amzServerSideEncryption         = "X-Amz-Server-Side-Encryption"
+	amzServerSideEncryptionKMSKeyID = "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"
+	sseAlgorithmAES256              = "AES256"
+	sseAlgorithmKMS                 = "aws:kms"
+)
+
+var (
+	// errObjectTampered - encrypted object data failed authentication.
+	errObjectTampered = errors.New("The requested object was modified and may be compromised")
+
+	// errInvalidEncryptionInfo - encryption info saved with an object
+	// does not match the object.
+	errInvalidEncryptionInfo = errors.New("Encryption info of the object is invalid")
+
+	// errEncryptionNotSupported - the object layer cannot save the
+	// encryption info with objects.
+	errEncryptionNotSupported = errors.New("Server side encryption is not supported by the backend")
+)
+
+// objectEncryptionPart - plain size of an encrypted stream, every part
+// of a multipart object is encrypted as a separate stream.
+type objectEncryptionPart struct {
+	PartNumber int   `json:"partNumber"`
+	Size       int64 `json:"size"`
+}
+
+// objectEncryptionInfo - sealed object key and layout of an encrypted
+// object, saved json encoded in the metadata of the object. The plain
+// size of objects uploaded with unknown size is -1, it follows from
+// their encrypted size.
+type objectEncryptionInfo struct {
+	// Name of the object the key is sealed for, not saved.
+	Object    string                 `json:"-"`
+	Algorithm string                 `json:"algorithm"`
+	KeyID     string                 `json:"keyId"`
+	SealedKey []byte                 `json:"sealedKey"`
+	Size      int64                  `json:"size"`
+	Parts     []objectEncryptionPart `json:"parts,omitempty"`
+}
+
+// encryptedSize - returns the size of an encrypted stream holding size
+// bytes of plain data, -1 if size is unknown.
+func encryptedSize(size int64) int64 {
+	if size < 0 {
+		return -1
+	}
+	packages := (size + encryptionPackageSize - 1) / encryptionPackageSize
+	return encryptionHeaderSize + size + packages*encryptionTagSize
+}
+
+// streams - returns the plain sizes of all encrypted streams.
+func (info objectEncryptionInfo) streams() []objectEncryptionPart {
+	if len(info.Parts) == 0 {
+		return []objectEncryptionPart{{Size: info.Size}}
+	}
+	return info.Parts
+}
+
+// encryptedSize - returns the total size of the encrypted object.
+func (info objectEncryptionInfo) encryptedSize() int64 {
+	var size int64
+	for _, stream := range info.streams() {
+		size += encryptedSize(stream.Size)
+	}
+	return size
+}
+
+// encryptionContext - binds a sealed object key to the object, a sealed
+// key copied over to another object cannot be unsealed.
+func encryptionContext(bucket, object string) []byte {
+	return []byte(bucket + "/" + object)
+}
+
+// objectEncryptionRequest - server side encryption of a new object,
+// KeyID names the master key sealing the object key.
+type objectEncryptionRequest struct {
+	Algorithm string
+	KeyID     string
+}
+
+// newObjectEncryptionInfo - generates a new object key using the key
+// management service.
+func newObjectEncryptionInfo(bucket, object string, sse objectEncryptionRequest) (objectEncryptionInfo, [32]byte, error) {
+	var objectKey [32]byte
+	if globalKMS == nil {
+		return objectEncryptionInfo{}, objectKey, errKMSNotConfigured
+	}
+	keyID := sse.KeyID
+	if keyID == "" {
+		keyID = globalKMS.KeyID()
+	}
+	objectKey, sealedKey, err := globalKMS.GenerateKey(keyID, encryptionContext(bucket, object))
+	if err != nil {
+		return objectEncryptionInfo{}, objectKey, err
+	}
+	return objectEncryptionInfo{
+		Object:    object,
+		Algorithm: sse.Algorithm,
+		KeyID:     keyID,
+		SealedKey: sealedKey,
+		Size:      -1,
+	}, objectKey, nil
+}
+
+// setObjectEncryptionInfo - saves encryption info in the metadata of a
+// new object or multipart upload.
+func setObjectEncryptionInfo(metadata map[string]string, info objectEncryptionInfo) error {
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	metadata[objectEncryptionMetaKey] = string(infoBytes)
+	return nil
+}
+
+// getObjectEncryptionInfo - returns the encryption info saved in the
+// metadata of object, false if it is not encrypted.
+func getObjectEncryptionInfo(object string, metadata map[string]string) (objectEncryptionInfo, bool, error) {
+	value, ok := metadata[objectEncryptionMetaKey]
+	if !ok {
+		return objectEncryptionInfo{}, false, nil
+	}
+	info := objectEncryptionInfo{}
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		return objectEncryptionInfo{}, false, errInvalidEncryptionInfo
+	}
+	if info.KeyID == "" || len(info.SealedKey) == 0 {
+		return objectEncryptionInfo{}, false, errInvalidEncryptionInfo
+	}
+	info.Object = object
+	return info, true, nil
+}
+
+// unsealKey - unseals the object key using the key management service.
+func (info objectEncryptionInfo) unsealKey(bucket string) ([32]byte, error) {
+	if globalKMS == nil {
+		return [32]byte{}, errKMSNotConfigured
+	}
+	return globalKMS.UnsealKey(info.KeyID, info.SealedKey, encryptionContext(bucket, info.Object))
+}
+
+// newStreamCipher - derives the stream key from the object key and the
+// random stream header.
+func newStreamCipher(objectKey [32]byte, header []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, objectKey[:])
+	mac.Write(header)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// packageNonce - returns the nonce of a package, nonces never repeat
+// for a stream key since every stream has its own random header.
+func packageNonce(sequence uint32) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce[8:], sequence)
+	return nonce
+}
+
+// encryptReader - encrypts plain data read from the underlying reader,
+// optionally verifying the md5sum of the plain data.
+type encryptReader struct {
+	reader   io.Reader
+	aead     cipher.AEAD
+	sequence uint32
+
+	header  []byte
+	plain   []byte
+	sealed  []byte
+	pending []byte
+	eof     bool
+
+	md5Writer hash.Hash
+	md5Hex    string
+	size      int64
+}
+
+// newEncryptReader - returns a new encrypted stream of reader. If
+// md5Hex is not empty, the md5sum of the plain data is verified.
+func newEncryptReader(reader io.Reader, objectKey [32]byte, md5Hex string) (*encryptReader, error) {
+	header := make([]byte, encryptionHeaderSize)
+	if _, err := io.ReadFull(rand.Reader, header); err != nil {
+		return nil, err
+	}
+	aead, err := newStreamCipher(objectKey, header)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptReader{
+		reader:    reader,
+		aead:      aead,
+		header:    header,
+		plain:     make([]byte, encryptionPackageSize),
+		sealed:    make([]byte, 0, encryptionPackageSize+encryptionTagSize),
+		md5Writer: md5.New(),
+		md5Hex:    md5Hex,
+	}, nil
+}
+
+// Size - returns the number of plain bytes encrypted so far.
+func (e *encryptReader) Size() int64 {
+	return e.size
+}
+
+// Read - implements io.Reader.
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 {
+		if e.eof {
+			return 0, io.EOF
+		}
+		if err := e.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+// fill - seals the next package.
+func (e *encryptReader) fill() error {
+	if e.header != nil {
+		e.pending, e.header = e.header, nil
+		return nil
+	}
+	n, err := io.ReadFull(e.reader, e.plain)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		e.eof = true
+	} else if err != nil {
+		return err
+	}
+	if n > 0 {
+		e.md5Writer.Write(e.plain[:n])
+		e.size += int64(n)
+		e.pending = e.aead.Seal(e.sealed[:0], packageNonce(e.sequence), e.plain[:n], nil)
+		e.sequence++
+	}
+	if e.eof && e.md5Hex != "" {
+		if newMD5Hex := hex.EncodeToString(e.md5Writer.Sum(nil)); newMD5Hex != e.md5Hex {
+			return BadDigest{e.md5Hex, newMD5Hex}
+		}
+	}
+	return nil
+}
+
+// decryptWriter - decrypts packages written to it and writes the
+// requested range of plain data to the underlying writer.
+type decryptWriter struct {
+	writer   io.Writer
+	aead     cipher.AEAD
+	sequence uint32
+
+	buffer []byte
+	n      int
+
+	skip      int64
+	remaining int64
+}
+
+// Write - implements io.Writer.
+func (d *decryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		c := copy(d.buffer[d.n:], p)
+		d.n += c
+		p = p[c:]
+		if d.n == len(d.buffer) {
+			if err := d.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush - opens the buffered package.
+func (d *decryptWriter) flush() error {
+	if d.n == 0 {
+		return nil
+	}
+	plain, err := d.aead.Open(d.buffer[:0], packageNonce(d.sequence), d.buffer[:d.n], nil)
+	if err != nil {
+		return errObjectTampered
+	}
+	d.sequence++
+	d.n = 0
+	if d.skip > 0 {
+		if d.skip > int64(len(plain)) {
+			d.skip = int64(len(plain))
+		}
+		plain = plain[d.skip:]
+		d.skip = 0
+	}
+	if int64(len(plain)) > d.remaining {
+		plain = plain[:d.remaining]
+	}
+	d.remaining -= int64(len(plain))
+	_, err = d.writer.Write(plain)
+	return err
+}
+
+// Close - opens the last, possibly short, package.
+func (d *decryptWriter) Close() error {
+	return d.flush()
+}
+
+// getDecryptedObject - writes length bytes of plain data starting at
+// startOffset of an encrypted object to writer.
+func getDecryptedObject(ctx context.Context, objAPI ObjectLayer, bucket, object string, info objectEncryptionInfo, objectKey [32]byte, startOffset, length int64, writer io.Writer) error {
+	// Offset of the current stream in the encrypted object.
+	var streamOffset int64
+	for _, stream := range info.streams() {
+		if length <= 0 {
+			break
+		}
+		if startOffset >= stream.Size {
+			startOffset -= stream.Size
+			streamOffset += encryptedSize(stream.Size)
+			continue
+		}
+		n := stream.Size - startOffset
+		if n > length {
+			n = length
+		}
+		if err := getDecryptedStream(ctx, objAPI, bucket, object, objectKey, streamOffset, stream.Size, startOffset, n, writer); err != nil {
+			return err
+		}
+		length -= n
+		startOffset = 0
+		streamOffset += encryptedSize(stream.Size)
+	}
+	return nil
+}
+
+// getDecryptedStream - decrypts only the packages of a stream covering
+// the requested range.
+func getDecryptedStream(ctx context.Context, objAPI ObjectLayer, bucket, object string, objectKey [32]byte, streamOffset, streamSize, offset, length int64, writer io.Writer) error {
+	header := &bytes.Buffer{}
+	if err := objAPI.GetObject(ctx, bucket, object, streamOffset, encryptionHeaderSize, header); err != nil {
+		return err
+	}
+	aead, err := newStreamCipher(objectKey, header.Bytes())
+	if err != nil {
+		return err
+	}
+	firstPackage := offset / encryptionPackageSize
+	lastPackage := (offset + length - 1) / encryptionPackageSize
+	decWriter := &decryptWriter{
+		writer:    writer,
+		aead:      aead,
+		sequence:  uint32(firstPackage),
+		buffer:    make([]byte, encryptionPackageSize+encryptionTagSize),
+		skip:      offset - firstPackage*encryptionPackageSize,
+		remaining: length,
+	}
+	encOffset := streamOffset + encryptionHeaderSize + firstPackage*(encryptionPackageSize+encryptionTagSize)
+	encLength := (lastPackage - firstPackage + 1) * (encryptionPackageSize + encryptionTagSize)
+	// Last package of a stream can be shorter.
+	if streamEnd := streamOffset + encryptedSize(streamSize); encOffset+encLength > streamEnd {
+		encLength = streamEnd - encOffset
+	}
+	if err = objAPI.GetObject(ctx, bucket, object, encOffset, encLength, decWriter); err != nil {
+		return err
+	}
+	return decWriter.Close()
+}
+
+// putEncryptedObject - creates an object encrypted with a new object
+// key, the md5sum in metadata is verified against the plain data.
+func putEncryptedObject(ctx context.Context, objAPI ObjectLayer, bucket, object string, sse objectEncryptionRequest, size int64, reader io.Reader, metadata map[string]string) (string, error) {
+	if err := checkObjectEncryptionSupport(objAPI); err != nil {
+		return "", err
+	}
+	info, objectKey, err := newObjectEncryptionInfo(bucket, object, sse)
+	if err != nil {
+		return "", err
+	}
+	encReader, err := newEncryptReader(reader, objectKey, metadata["md5Sum"])
+	if err != nil {
+		return "", err
+	}
+	// Object layer only sees the encrypted data.
+	delete(metadata, "md5Sum")
+	info.Size = size
+	if err = setObjectEncryptionInfo(metadata, info); err != nil {
+		return "", err
+	}
+	return objAPI.PutObject(ctx, bucket, object, encryptedSize(size), encReader, metadata)
+}
+
+// putEncryptedObjectPart - uploads a part encrypted with the object key
+// of the multipart upload.
+func putEncryptedObjectPart(ctx context.Context, objAPI ObjectLayer, bucket, object, uploadID string, partID int, size int64, reader io.Reader, md5Hex string, info objectEncryptionInfo) (string, error) {
+	objectKey, err := info.unsealKey(bucket)
+	if err != nil {
+		return "", err
+	}
+	encReader, err := newEncryptReader(reader, objectKey, md5Hex)
+	if err != nil {
+		return "", err
+	}
+	return objAPI.PutObjectPart(ctx, bucket, object, uploadID, partID, encryptedSize(size), encReader, "")
+}
+
+// getUploadEncryption - returns the encryption info of a multipart
+// upload, false if its parts are not encrypted.
+func getUploadEncryption(objAPI ObjectLayer, bucket, object, uploadID string) (objectEncryptionInfo, bool, error) {
+	// Only the metadata of the upload is needed, no parts are listed.
+	result, err := objAPI.ListObjectParts(bucket, object, uploadID, 0, 0)
+	if err != nil {
+		return objectEncryptionInfo{}, false, err
+	}
+	return getObjectEncryptionInfo(object, result.Metadata)
+}
+
+// saveUploadEncryptionParts - saves the plain sizes of the parts to be
+// completed in the encryption info of a multipart upload, it is saved
+// with the object once the upload is completed.
+func saveUploadEncryptionParts(objAPI ObjectLayer, bucket, object, uploadID string, info objectEncryptionInfo, parts []completePart) error {
+	updater, ok := getMetadataUpdater(objAPI)
+	if !ok {
+		return errEncryptionNotSupported
+	}
+	encParts, err := getEncryptedObjectParts(objAPI, bucket, object, uploadID, parts)
+	if err != nil {
+		return err
+	}
+	info.Parts, info.Size = encParts, 0
+	for _, part := range encParts {
+		info.Size += part.Size
+	}
+	return updater.UpdateUploadMetadata(bucket, object, uploadID, func(metadata map[string]string) error {
+		return setObjectEncryptionInfo(metadata, info)
+	})
+}
+
+// getEncryptedObjectParts - returns plain sizes of all parts to be
+// completed, parts are listed before they are merged into the object.
+func getEncryptedObjectParts(objAPI ObjectLayer, bucket, object, uploadID string, parts []completePart) ([]objectEncryptionPart, error) {
+	partSizes := make(map[int]int64)
+	partNumberMarker := 0
+	for {
+		listPartsInfo, err := objAPI.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxPartsList)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range listPartsInfo.Parts {
+			partSizes[part.PartNumber] = part.Size
+		}
+		if !listPartsInfo.IsTruncated {
+			break
+		}
+		partNumberMarker = listPartsInfo.NextPartNumberMarker
+	}
+	var encParts []objectEncryptionPart
+	for _, part := range parts {
+		size, ok := partSizes[part.PartNumber]
+		if !ok {
+			return nil, InvalidPart{}
+		}
+		plainSize, err := decryptedSize(size)
+		if err != nil {
+			return nil, err
+		}
+		encParts = append(encParts, objectEncryptionPart{
+			PartNumber: part.PartNumber,
+			Size:       plainSize,
+		})
+	}
+	return encParts, nil
+}
+
+// decryptedSize - returns the plain size of an encrypted stream.
+func decryptedSize(size int64) (int64, error) {
+	size -= encryptionHeaderSize
+	if size < 0 {
+		return 0, errObjectTampered
+	}
+	packages := size / (encryptionPackageSize + encryptionTagSize)
+	lastPackage := size % (encryptionPackageSize + encryptionTagSize)
+	if lastPackage == 0 {
+		return packages * encryptionPackageSize, nil
+	}
+	if lastPackage <= encryptionTagSize {
+		return 0, errObjectTampered
+	}
+	return packages*encryptionPackageSize + lastPackage - encryptionTagSize, nil
+}
+
+// getKMSKeyID - returns the master key id of a key id or key ARN.
+func getKMSKeyID(keyID string) string {
+	if strings.HasPrefix(keyID, "arn:aws:kms:") {
+		if i := strings.LastIndex(keyID, ":key/"); i >= 0 {
+			return keyID[i+len(":key/"):]
+		}
+	}
+	return keyID
+}
+
+// getObjectEncryptionRequest - returns true if a newly created object
+// is to be encrypted, either requested explicitly or by the bucket
+// default encryption configuration. The master key of SSE-KMS defaults
+// to the key of the key management service.
+func getObjectEncryptionRequest(bucket string, r *http.Request) (objectEncryptionRequest, bool, APIErrorCode) {
+	var sse objectEncryptionRequest
+	if algorithm, ok := r.Header[amzServerSideEncryption]; ok {
+		if len(algorithm) != 1 || (algorithm[0] != sseAlgorithmAES256 && algorithm[0] != sseAlgorithmKMS) {
+			return objectEncryptionRequest{}, false, ErrInvalidEncryptionMethod
+		}
+		sse.Algorithm = algorithm[0]
+		sse.KeyID = getKMSKeyID(r.Header.Get(amzServerSideEncryptionKMSKeyID))
+		// Master keys can only be selected with SSE-KMS.
+		if sse.Algorithm != sseAlgorithmKMS && sse.KeyID != "" {
+			return objectEncryptionRequest{}, false, ErrInvalidEncryptionMethod
+		}
+	} else {
+		config, err := readBucketEncryptionConfig(bucket)
+		if err != nil {
+			if _, ok := err.(BucketEncryptionConfigNotFound); !ok {
+				errorIf(err, "Unable to read encryption configuration for %s.", bucket)
+				return objectEncryptionRequest{}, false, ErrInternalError
+			}
+			return sse, false, ErrNone
+		}
+		sse.Algorithm = config.Rules[0].ApplySSEByDefault.SSEAlgorithm
+		sse.KeyID = getKMSKeyID(config.Rules[0].ApplySSEByDefault.KMSMasterKeyID)
+	}
+	if globalKMS == nil {
+		return objectEncryptionRequest{}, false, ErrKMSNotConfigured
+	}
+	if sse.Algorithm == sseAlgorithmKMS && sse.KeyID == "" {
+		sse.KeyID = globalKMS.KeyID()
+	}
+	return sse, true, ErrNone
+}
+
+// getObjectEncryption - returns the encryption info of an object and
+// changes the object size to its plain size, returns false if the
+// object is not encrypted. Encryption info not matching the object
+// fails, the object is never served as plain data.
+func getObjectEncryption(bucket string, objInfo *ObjectInfo) (objectEncryptionInfo, bool, error) {
+	info, encrypted, err := getObjectEncryptionInfo(objInfo.Name, objInfo.Metadata)
+	if err != nil || !encrypted {
+		return objectEncryptionInfo{}, false, err
+	}
+	if info.Size < 0 && len(info.Parts) == 0 {
+		if info.Size, err = decryptedSize(objInfo.Size); err != nil {
+			return objectEncryptionInfo{}, false, errInvalidEncryptionInfo
+		}
+	}
+	if info.encryptedSize() != objInfo.Size {
+		return objectEncryptionInfo{}, false, errInvalidEncryptionInfo
+	}
+	objInfo.Size = info.Size
+	return info, true, nil
+}
+
+// checkObjectEncryptionSupport - objects can only be encrypted by
+// object layers saving the encryption info with them.
+func checkObjectEncryptionSupport(objAPI ObjectLayer) error {
+	if _, ok := getMetadataUpdater(objAPI); !ok {
+		return errEncryptionNotSupported
+	}
+	return nil
+}
+
+// getPlainObjectInfo - returns objInfo with the plain size of an
+// encrypted or compressed object.
+func getPlainObjectInfo(bucket string, objInfo ObjectInfo) (ObjectInfo, error) {
+	if _, encrypted, err := getObjectEncryption(bucket, &objInfo); err != nil || encrypted {
+		return objInfo, err
+	}
+	_, _, err := getObjectCompression(bucket, &objInfo)
+	return objInfo, err
+}
+
+// setListedObjectSizes - lists report plain sizes of encrypted and
+// compressed objects.
+func setListedObjectSizes(bucket string, objects []ObjectInfo) {
+	for i := range objects {
+		_, encrypted, err := getObjectEncryption(bucket, &objects[i])
+		if err != nil {
+			errorIf(err, "Unable to read object encryption info for %s/%s.", bucket, objects[i].Name)
+		}
+		if encrypted {
+			continue
+		}
+		if _, _, err = getObjectCompression(bucket, &objects[i]); err != nil {
+			errorIf(err, "Unable to read object compression info for %s/%s.", bucket, objects[i].Name)
+		}
+	}
+}
+
+// setObjectEncryptionHeaders - sets server side encryption response
+// headers, the master key is only reported for SSE-KMS.
+func setObjectEncryptionHeaders(w http.ResponseWriter, algorithm, keyID string) {
+	w.Header().Set(amzServerSideEncryption, algorithm)
+	if algorithm == sseAlgorithmKMS {
+		w.Header().Set(amzServerSideEncryptionKMSKeyID, keyID)
+	}
+}
+
+// rotateObjectKey - re-seals the object key of encryption info with
+// master key to, if it is sealed with master key from. The latest
+// version of the master key is used if both are the same. Object data
+// is not re-encrypted. Returns false if the object key is sealed with
+// another master key.
+func rotateObjectKey(bucket string, info *objectEncryptionInfo, from, to string) (bool, error) {
+	if globalKMS == nil {
+		return false, errKMSNotConfigured
+	}
+	if info.KeyID != from {
+		return false, nil
+	}
+	context := encryptionContext(bucket, info.Object)
+	var sealedKey []byte
+	var err error
+	if from == to {
+		sealedKey, err = globalKMS.UpdateKey(from, info.SealedKey, context)
+	} else {
+		var objectKey [32]byte
+		if objectKey, err = globalKMS.UnsealKey(from, info.SealedKey, context); err == nil {
+			sealedKey, err = globalKMS.SealKey(to, objectKey, context)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	info.KeyID = to
+	info.SealedKey = sealedKey
+	return true, nil
+}
@@ -0,0 +1,337 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+)
+
+// Tests validate parsing of static master keys.
+func TestParseMasterKey(t *testing.T) {
+	testCases := []struct {
+		masterKey  string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{"my-key:6368616e676520746869732070617373776f726420746f206120736563726574", true},
+		// Test case - 2.
+		// Missing key ID.
+		{":6368616e676520746869732070617373776f726420746f206120736563726574", false},
+		// Test case - 3.
+		// Key is not hex encoded.
+		{"my-key:change-this-password-to-a-secret", false},
+		// Test case - 4.
+		// Key is not 256 bits.
+		{"my-key:6368616e6765", false},
+		// Test case - 5.
+		// Missing separator.
+		{"6368616e676520746869732070617373776f726420746f206120736563726574", false},
+		// Test case - 6.
+		// Current and retired key.
+		{"new-key:6368616e676520746869732070617373776f726420746f206120736563726574,my-key:6368616e676520746869732070617373776f726420746f206120736563726574", true},
+		// Test case - 7.
+		// Duplicate key ID.
+		{"my-key:6368616e676520746869732070617373776f726420746f206120736563726574,my-key:6368616e676520746869732070617373776f726420746f206120736563726574", false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseMasterKey(testCase.masterKey)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// Tests sealing and unsealing data keys with a static master key.
+func TestMasterKeyKMS(t *testing.T) {
+	kms, err := parseMasterKey("my-key:6368616e676520746869732070617373776f726420746f206120736563726574")
+	if err != nil {
+		t.Fatal(err)
+	}
+	context := encryptionContext("bucket", "object")
+	key, sealedKey, err := kms.GenerateKey(kms.KeyID(), context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsealedKey, err := kms.UnsealKey(kms.KeyID(), sealedKey, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsealedKey != key {
+		t.Fatal("Unsealed key does not match the generated key")
+	}
+	// Sealed key cannot be used for another object.
+	if _, err = kms.UnsealKey(kms.KeyID(), sealedKey, encryptionContext("bucket", "other")); err != errKMSInvalidSealedKey {
+		t.Fatalf("Expected %s, but instead found %v", errKMSInvalidSealedKey, err)
+	}
+	// Unknown master key.
+	if _, err = kms.UnsealKey("other-key", sealedKey, context); err != errKMSKeyNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errKMSKeyNotFound, err)
+	}
+	// Rotated key can still be unsealed.
+	rotatedKey, err := kms.UpdateKey(kms.KeyID(), sealedKey, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsealedKey, err = kms.UnsealKey(kms.KeyID(), rotatedKey, context); err != nil || unsealedKey != key {
+		t.Fatalf("Unable to unseal rotated key: %v", err)
+	}
+}
+
+// Tests size calculation of encrypted streams.
+func TestEncryptedSize(t *testing.T) {
+	testCases := []struct {
+		size          int64
+		encryptedSize int64
+	}{
+		// Test case - 1.
+		{0, encryptionHeaderSize},
+		// Test case - 2.
+		{1, encryptionHeaderSize + 1 + encryptionTagSize},
+		// Test case - 3.
+		{encryptionPackageSize, encryptionHeaderSize + encryptionPackageSize + encryptionTagSize},
+		// Test case - 4.
+		{encryptionPackageSize + 1, encryptionHeaderSize + encryptionPackageSize + 1 + 2*encryptionTagSize},
+		// Test case - 5.
+		{-1, -1},
+	}
+	for i, testCase := range testCases {
+		encSize := encryptedSize(testCase.size)
+		if encSize != testCase.encryptedSize {
+			t.Errorf("Test %d: Expected encrypted size %d, but instead found %d", i+1, testCase.encryptedSize, encSize)
+		}
+		if testCase.size < 0 {
+			continue
+		}
+		size, err := decryptedSize(encSize)
+		if err != nil {
+			t.Errorf("Test %d: Unexpected error: %s", i+1, err)
+		}
+		if size != testCase.size {
+			t.Errorf("Test %d: Expected decrypted size %d, but instead found %d", i+1, testCase.size, size)
+		}
+	}
+}
+
+// Tests encryption info saved with an object not matching the object
+// fails instead of serving the object as plain data.
+func TestGetObjectEncryption(t *testing.T) {
+	validInfo := `{"algorithm":"AES256","keyId":"my-key","sealedKey":"c2VhbGVk","size":100}`
+	testCases := []struct {
+		metadata  map[string]string
+		size      int64
+		encrypted bool
+		plainSize int64
+		err       error
+	}{
+		// Test case - 1.
+		// Object not encrypted.
+		{map[string]string{"md5Sum": "abc"}, 100, false, 100, nil},
+		// Test case - 2.
+		// Valid encryption info.
+		{map[string]string{objectEncryptionMetaKey: validInfo}, encryptedSize(100), true, 100, nil},
+		// Test case - 3.
+		// Size of the object not matching the encryption info.
+		{map[string]string{objectEncryptionMetaKey: validInfo}, 100, false, 100, errInvalidEncryptionInfo},
+		// Test case - 4.
+		// Corrupted encryption info.
+		{map[string]string{objectEncryptionMetaKey: "{"}, encryptedSize(100), false, encryptedSize(100), errInvalidEncryptionInfo},
+		// Test case - 5.
+		// Sealed key missing.
+		{map[string]string{objectEncryptionMetaKey: `{"algorithm":"AES256","keyId":"my-key","size":100}`}, encryptedSize(100), false, encryptedSize(100), errInvalidEncryptionInfo},
+	}
+	for i, testCase := range testCases {
+		objInfo := ObjectInfo{Bucket: "bucket", Name: "object", Size: testCase.size, Metadata: testCase.metadata}
+		_, encrypted, err := getObjectEncryption("bucket", &objInfo)
+		if err != testCase.err {
+			t.Errorf("Test %d: Expected error %v, but instead found %v", i+1, testCase.err, err)
+		}
+		if encrypted != testCase.encrypted {
+			t.Errorf("Test %d: Expected encrypted %t, but instead found %t", i+1, testCase.encrypted, encrypted)
+		}
+		if objInfo.Size != testCase.plainSize {
+			t.Errorf("Test %d: Expected size %d, but instead found %d", i+1, testCase.plainSize, objInfo.Size)
+		}
+	}
+}
+
+// Wrapper for calling encrypted object tests for both XL multiple disks and single node setup.
+func TestEncryptedObject(t *testing.T) {
+	ExecObjectLayerTest(t, testEncryptedObject)
+}
+
+// Tests encrypted objects are stored encrypted and any range can be read back.
+func testEncryptedObject(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket, object := "bucket", "object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	data := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz012345"), (3*encryptionPackageSize+100)/32)
+	md5Sum := md5.Sum(data)
+
+	var objectKey [32]byte
+	copy(objectKey[:], "0123456789abcdef0123456789abcdef")
+
+	// Data is verified against a wrong md5sum.
+	encReader, err := newEncryptReader(bytes.NewReader(data), objectKey, hex.EncodeToString(md5Sum[:1]))
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.PutObject(context.Background(), bucket, object, encryptedSize(int64(len(data))), encReader, nil); err == nil {
+		t.Fatalf("%s: Expected BadDigest error", instanceType)
+	}
+
+	encReader, err = newEncryptReader(bytes.NewReader(data), objectKey, hex.EncodeToString(md5Sum[:]))
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.PutObject(context.Background(), bucket, object, encryptedSize(int64(len(data))), encReader, nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.Size != encryptedSize(int64(len(data))) {
+		t.Fatalf("%s: Expected object size %d, but instead found %d", instanceType, encryptedSize(int64(len(data))), objInfo.Size)
+	}
+	info := objectEncryptionInfo{Object: object, Size: encReader.Size()}
+
+	testCases := []struct {
+		startOffset int64
+		length      int64
+	}{
+		// Test case - 1.
+		// Entire object.
+		{0, int64(len(data))},
+		// Test case - 2.
+		// Within first package.
+		{10, 100},
+		// Test case - 3.
+		// Across package boundary.
+		{encryptionPackageSize - 10, 20},
+		// Test case - 4.
+		// Exactly one package.
+		{encryptionPackageSize, encryptionPackageSize},
+		// Test case - 5.
+		// Last bytes of the object.
+		{int64(len(data)) - 5, 5},
+	}
+	for i, testCase := range testCases {
+		buffer := new(bytes.Buffer)
+		err = getDecryptedObject(context.Background(), obj, bucket, object, info, objectKey, testCase.startOffset, testCase.length, buffer)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		expected := data[testCase.startOffset : testCase.startOffset+testCase.length]
+		if !bytes.Equal(buffer.Bytes(), expected) {
+			t.Errorf("%s: Test %d: Decrypted data does not match", instanceType, i+1)
+		}
+	}
+
+	// Object cannot be decrypted with another key.
+	var wrongKey [32]byte
+	err = getDecryptedObject(context.Background(), obj, bucket, object, info, wrongKey, 0, int64(len(data)), new(bytes.Buffer))
+	if err != errObjectTampered {
+		t.Fatalf("%s: Expected %s, but instead found %v", instanceType, errObjectTampered, err)
+	}
+}
+
+// Tests encryption of new objects requested by headers or the bucket
+// default encryption configuration.
+func TestGetObjectEncryptionRequest(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	kms, err := parseMasterKey("my-key:6368616e676520746869732070617373776f726420746f206120736563726574")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalKMS = kms
+	defer func() { globalKMS = nil }()
+
+	encryptionConfig := func(algorithm, keyID string) *BucketEncryptionConfiguration {
+		return &BucketEncryptionConfiguration{Rules: []SSERule{{ApplySSEByDefault: ApplySSEByDefault{SSEAlgorithm: algorithm, KMSMasterKeyID: keyID}}}}
+	}
+	testCases := []struct {
+		headers map[string]string
+		config  *BucketEncryptionConfiguration
+		sse     objectEncryptionRequest
+		encrypt bool
+		s3Err   APIErrorCode
+	}{
+		// Test case - 1.
+		// No encryption requested.
+		{nil, nil, objectEncryptionRequest{}, false, ErrNone},
+		// Test case - 2.
+		{map[string]string{amzServerSideEncryption: "AES256"}, nil, objectEncryptionRequest{Algorithm: "AES256"}, true, ErrNone},
+		// Test case - 3.
+		// SSE-KMS defaults to the key of the key management service.
+		{map[string]string{amzServerSideEncryption: "aws:kms"}, nil, objectEncryptionRequest{Algorithm: "aws:kms", KeyID: "my-key"}, true, ErrNone},
+		// Test case - 4.
+		{map[string]string{amzServerSideEncryption: "aws:kms", amzServerSideEncryptionKMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/other-key"}, nil, objectEncryptionRequest{Algorithm: "aws:kms", KeyID: "other-key"}, true, ErrNone},
+		// Test case - 5.
+		// Master key without SSE-KMS.
+		{map[string]string{amzServerSideEncryption: "AES256", amzServerSideEncryptionKMSKeyID: "my-key"}, nil, objectEncryptionRequest{}, false, ErrInvalidEncryptionMethod},
+		// Test case - 6.
+		{map[string]string{amzServerSideEncryption: "DES"}, nil, objectEncryptionRequest{}, false, ErrInvalidEncryptionMethod},
+		// Test case - 7.
+		// Bucket default encryption.
+		{nil, encryptionConfig("AES256", ""), objectEncryptionRequest{Algorithm: "AES256"}, true, ErrNone},
+		// Test case - 8.
+		{nil, encryptionConfig("aws:kms", "my-key"), objectEncryptionRequest{Algorithm: "aws:kms", KeyID: "my-key"}, true, ErrNone},
+		// Test case - 9.
+		// Headers take precedence over the bucket default.
+		{map[string]string{amzServerSideEncryption: "AES256"}, encryptionConfig("aws:kms", "my-key"), objectEncryptionRequest{Algorithm: "AES256"}, true, ErrNone},
+	}
+	for i, testCase := range testCases {
+		bucket := "encryption-bucket"
+		if err = removeBucketEncryptionConfig(bucket); err != nil {
+			t.Fatal(err)
+		}
+		if testCase.config != nil {
+			if err = writeBucketEncryptionConfig(bucket, testCase.config); err != nil {
+				t.Fatal(err)
+			}
+		}
+		req, err := newTestRequest("PUT", "http://localhost:9000/"+bucket+"/object", 0, nil, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range testCase.headers {
+			req.Header.Set(k, v)
+		}
+		sse, encrypt, s3Err := getObjectEncryptionRequest(bucket, req)
+		if s3Err != testCase.s3Err {
+			t.Fatalf("Test %d: Expected %d, but got %d", i+1, testCase.s3Err, s3Err)
+		}
+		if encrypt != testCase.encrypt || sse != testCase.sse {
+			t.Errorf("Test %d: Expected %+v %v, but got %+v %v", i+1, testCase.sse, testCase.encrypt, sse, encrypt)
+		}
+	}
+}
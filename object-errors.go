@@ -54,7 +54,7 @@ func toObjectErr(err error, params ...string) error {
 				Object: params[1],
 			}
 		}
-	case errFileNameTooLong:
+	case errFileNameTooLong, errFileNameInvalid:
 		if len(params) >= 2 {
 			return ObjectNameInvalid{
 				Bucket: params[0],
@@ -133,6 +133,13 @@ func (e BucketExists) Error() string {
 	return "Bucket exists: " + e.Bucket
 }
 
+// BucketAlreadyExists bucket is owned by another federated cluster.
+type BucketAlreadyExists GenericError
+
+func (e BucketAlreadyExists) Error() string {
+	return "Bucket already exists in another cluster: " + e.Bucket
+}
+
 // BadDigest - Content-MD5 you specified did not match what we received.
 type BadDigest struct {
 	ExpectedMD5   string
@@ -177,6 +184,57 @@ func (e BucketPolicyNotFound) Error() string {
 	return "No bucket policy found for bucket: " + e.Bucket
 }
 
+// BucketObjectLockConfigNotFound - no object lock configuration found.
+type BucketObjectLockConfigNotFound GenericError
+
+func (e BucketObjectLockConfigNotFound) Error() string {
+	return "No object lock configuration found for bucket: " + e.Bucket
+}
+
+// BucketEncryptionConfigNotFound - no default encryption configuration found.
+type BucketEncryptionConfigNotFound GenericError
+
+func (e BucketEncryptionConfigNotFound) Error() string {
+	return "No encryption configuration found for bucket: " + e.Bucket
+}
+
+// BucketReplicationConfigNotFound - no replication configuration found.
+type BucketReplicationConfigNotFound GenericError
+
+func (e BucketReplicationConfigNotFound) Error() string {
+	return "No replication configuration found for bucket: " + e.Bucket
+}
+
+// BucketTrashConfigNotFound - no trash configuration found.
+type BucketTrashConfigNotFound GenericError
+
+func (e BucketTrashConfigNotFound) Error() string {
+	return "No trash configuration found for bucket: " + e.Bucket
+}
+
+// BucketIndexConfigNotFound - no index page configuration found.
+type BucketIndexConfigNotFound GenericError
+
+func (e BucketIndexConfigNotFound) Error() string {
+	return "No index page configuration found for bucket: " + e.Bucket
+}
+
+// TrashEntryNotFound - deleted object is not in the trash, Object
+// holds the id of the trash entry.
+type TrashEntryNotFound GenericError
+
+func (e TrashEntryNotFound) Error() string {
+	return "Trash entry not found: " + e.Bucket + "#" + e.Object
+}
+
+// TrashObjectExists - a deleted object cannot be restored since an
+// object with its name exists.
+type TrashObjectExists GenericError
+
+func (e TrashObjectExists) Error() string {
+	return "Object already exists: " + e.Bucket + "#" + e.Object
+}
+
 /// Bucket related errors.
 
 // BucketNameInvalid - bucketname provided is invalid.
@@ -256,3 +314,19 @@ type PartTooSmall struct{}
 func (e PartTooSmall) Error() string {
 	return "Part size should be atleast 5MB"
 }
+
+// ObjectTooLarge - error if an object would exceed the maximum object
+// size.
+type ObjectTooLarge GenericError
+
+func (e ObjectTooLarge) Error() string {
+	return "Object exceeds the maximum object size: " + e.Bucket + "#" + e.Object
+}
+
+// ObjectTooManyAppends - error if an object already consists of the
+// maximum number of parts and cannot be appended to.
+type ObjectTooManyAppends GenericError
+
+func (e ObjectTooManyAppends) Error() string {
+	return "Object cannot be appended to anymore: " + e.Bucket + "#" + e.Object
+}
@@ -38,8 +38,21 @@ import (
 var supportedGetReqParams = map[string]string{
 	"response-expires":             "Expires",
 	"response-content-type":        "Content-Type",
+	"response-content-language":    "Content-Language",
 	"response-cache-control":       "Cache-Control",
 	"response-content-disposition": "Content-Disposition",
+	"response-content-encoding":    "Content-Encoding",
+}
+
+// hasGetRespHeaders - returns true if any response headers are
+// overridden by request parameters.
+func hasGetRespHeaders(reqParams url.Values) bool {
+	for k := range reqParams {
+		if _, ok := supportedGetReqParams[k]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 // setGetRespHeaders - set any requested parameters as response headers.
@@ -89,16 +102,30 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
+		// Response headers can only be overridden by signed requests.
+		if hasGetRespHeaders(r.URL.Query()) {
+			writeErrorResponse(w, r, ErrAnonymousResponseHeaders, r.URL.Path)
+			return
+		}
 	case authTypePresigned, authTypeSigned:
 		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
+		// Share links used up or expired are rejected.
+		if s3Error := checkShareLink(r, api.ObjectAPI, bucket, object); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+	// SOSAPI objects are answered by the server.
+	if serveVeeamSOSAPIObject(w, r, api.ObjectAPI, bucket, object) {
+		return
 	}
 	// Fetch object stat info.
 	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
 	if err != nil {
-		errorIf(err, "Unable to fetch object info.")
+		errorIfRequest(r, err, "Unable to fetch object info.")
 		apiErr := toAPIErrorCode(err)
 		if apiErr == ErrNoSuchKey {
 			apiErr = errAllowableObjectNotFound(bucket, r)
@@ -107,6 +134,33 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Encrypted objects are served with their plain size.
+	sseInfo, encrypted, err := getObjectEncryption(bucket, &objInfo)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object encryption info.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	var objectKey [32]byte
+	if encrypted {
+		if objectKey, err = sseInfo.unsealKey(bucket); err != nil {
+			errorIfRequest(r, err, "Unable to unseal object key.")
+			writeErrorResponse(w, r, ErrObjectEncryptionKey, r.URL.Path)
+			return
+		}
+	}
+
+	// Compressed objects are served with their plain size.
+	var compInfo objectCompressionInfo
+	var compressed bool
+	if !encrypted {
+		if compInfo, compressed, err = getObjectCompression(bucket, &objInfo); err != nil {
+			errorIfRequest(r, err, "Unable to read object compression info.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
+
 	// Verify 'If-Modified-Since' and 'If-Unmodified-Since'.
 	lastModified := objInfo.ModTime
 	if checkLastModified(w, r, lastModified) {
@@ -117,30 +171,183 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Multiple ranges are served as a multipart/byteranges response.
 	var hrange *httpRange
-	hrange, err = getRequestedRange(r.Header.Get("Range"), objInfo.Size)
+	var ranges []*httpRange
+	if isMultipleRanges(r.Header.Get("Range")) {
+		ranges, err = getRequestedRanges(r.Header.Get("Range"), objInfo.Size)
+	} else {
+		hrange, err = getRequestedRange(r.Header.Get("Range"), objInfo.Size)
+	}
 	if err != nil {
 		writeErrorResponse(w, r, ErrInvalidRange, r.URL.Path)
 		return
 	}
 
+	// Set object retention and legal hold headers.
+	setObjectLockHeaders(w, api.ObjectAPI, bucket, object)
+
+	// Set server side encryption headers.
+	if encrypted {
+		setObjectEncryptionHeaders(w, sseInfo.Algorithm, sseInfo.KeyID)
+	}
+
 	// Set standard object headers.
 	setObjectHeaders(w, objInfo, hrange)
 
 	// Set any additional requested response headers.
 	setGetRespHeaders(w, r.URL.Query())
 
+	// getObject - writes length bytes of the object at startOffset.
+	getObject := func(startOffset, length int64, writer io.Writer) error {
+		if encrypted {
+			return getDecryptedObject(r.Context(), api.ObjectAPI, bucket, object, sseInfo, objectKey, startOffset, length, writer)
+		} else if compressed {
+			return getCompressedObject(r.Context(), api.ObjectAPI, bucket, object, compInfo, startOffset, length, writer)
+		}
+		return api.ObjectAPI.GetObject(r.Context(), bucket, object, startOffset, length, writer)
+	}
+
 	// Get the object.
-	startOffset := hrange.start
-	length := hrange.length
-	if length == 0 {
-		length = objInfo.Size - startOffset
+	if len(ranges) > 0 {
+		err = writeMultipartRanges(w, ranges, w.Header().Get("Content-Type"), getObject)
+	} else {
+		startOffset := hrange.start
+		length := hrange.length
+		if length == 0 {
+			length = objInfo.Size - startOffset
+		}
+		err = getObject(startOffset, length, w)
 	}
-	if err := api.ObjectAPI.GetObject(bucket, object, startOffset, length, w); err != nil {
-		errorIf(err, "Writing to client failed.")
+	if err != nil {
+		errorIfRequest(r, err, "Writing to client failed.")
 		// Do not send error response here, client would have already died.
 		return
 	}
+
+	// Only downloads of the whole object count towards the share link.
+	if r.Header.Get("Range") == "" {
+		redeemShareDownload(r, api.ObjectAPI, bucket, object)
+	}
+}
+
+// SelectObjectContentHandler - POST Object?select&select-type=2
+// ----------
+// This implementation of the POST operation filters the contents of a
+// CSV or JSON object with a SQL expression, results are streamed as
+// event stream messages.
+func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if r.ContentLength <= 0 {
+		writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxSelectRequestSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	reqBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read HTTP body.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	selectReq, s3Error := parseSelectRequest(reqBytes)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	query, err := parseSelectQuery(selectReq.Expression)
+	if err != nil {
+		writeErrorResponse(w, r, ErrParseSelectFailure, r.URL.Path)
+		return
+	}
+
+	// Fetch object stat info.
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		apiErr := toAPIErrorCode(err)
+		if apiErr == ErrNoSuchKey {
+			apiErr = errAllowableObjectNotFound(bucket, r)
+		}
+		writeErrorResponse(w, r, apiErr, r.URL.Path)
+		return
+	}
+
+	// Encrypted and compressed objects are queried in plain.
+	sseInfo, encrypted, err := getObjectEncryption(bucket, &objInfo)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object encryption info.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	var objectKey [32]byte
+	if encrypted {
+		if objectKey, err = sseInfo.unsealKey(bucket); err != nil {
+			errorIfRequest(r, err, "Unable to unseal object key.")
+			writeErrorResponse(w, r, ErrObjectEncryptionKey, r.URL.Path)
+			return
+		}
+	}
+	var compInfo objectCompressionInfo
+	var compressed bool
+	if !encrypted {
+		if compInfo, compressed, err = getObjectCompression(bucket, &objInfo); err != nil {
+			errorIfRequest(r, err, "Unable to read object compression info.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	go func() {
+		var gerr error
+		if encrypted {
+			gerr = getDecryptedObject(r.Context(), api.ObjectAPI, bucket, object, sseInfo, objectKey, 0, objInfo.Size, pipeWriter)
+		} else if compressed {
+			gerr = getCompressedObject(r.Context(), api.ObjectAPI, bucket, object, compInfo, 0, objInfo.Size, pipeWriter)
+		} else {
+			gerr = api.ObjectAPI.GetObject(r.Context(), bucket, object, 0, objInfo.Size, pipeWriter)
+		}
+		pipeWriter.CloseWithError(gerr)
+	}()
+
+	// Errors after this point are sent as error messages.
+	setCommonHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	messages := newSelectMessageWriter(w)
+	if err = runSelect(selectReq, query, pipeReader, messages); err != nil {
+		if selectErr, ok := err.(selectError); ok {
+			messages.writeError(selectErr.code, selectErr.message)
+			return
+		}
+		errorIfRequest(r, err, "Unable to run select query.")
+		apiErr := getAPIError(ErrInternalError)
+		messages.writeError(apiErr.Code, apiErr.Description)
+	}
 }
 
 var unixEpochTime = time.Unix(0, 0)
@@ -264,9 +471,14 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	// SOSAPI objects are answered by the server.
+	if serveVeeamSOSAPIObject(w, r, api.ObjectAPI, bucket, object) {
+		return
+	}
+
 	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
 	if err != nil {
-		errorIf(err, "Unable to fetch object info.")
+		errorIfRequest(r, err, "Unable to fetch object info.")
 		apiErr := toAPIErrorCode(err)
 		if apiErr == ErrNoSuchKey {
 			apiErr = errAllowableObjectNotFound(bucket, r)
@@ -275,6 +487,23 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Encrypted objects are served with their plain size.
+	sseInfo, encrypted, err := getObjectEncryption(bucket, &objInfo)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object encryption info.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	// Compressed objects are served with their plain size.
+	if !encrypted {
+		if _, _, err = getObjectCompression(bucket, &objInfo); err != nil {
+			errorIfRequest(r, err, "Unable to read object compression info.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
+
 	// Verify 'If-Modified-Since' and 'If-Unmodified-Since'.
 	lastModified := objInfo.ModTime
 	if checkLastModified(w, r, lastModified) {
@@ -286,6 +515,27 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Set object retention and legal hold headers.
+	setObjectLockHeaders(w, api.ObjectAPI, bucket, object)
+
+	// Set server side encryption headers.
+	if encrypted {
+		setObjectEncryptionHeaders(w, sseInfo.Algorithm, sseInfo.KeyID)
+	}
+
+	// Set checksum headers, only if requested.
+	if r.Header.Get(amzChecksumMode) == "ENABLED" {
+		checksum, ok, err := getObjectChecksum(bucket, objInfo)
+		if err != nil {
+			errorIfRequest(r, err, "Unable to read object checksum.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+		if ok {
+			setObjectChecksumHeaders(w, checksum)
+		}
+	}
+
 	// Set standard object headers.
 	setObjectHeaders(w, objInfo, nil)
 
@@ -352,10 +602,35 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	objInfo, err := api.ObjectAPI.GetObjectInfo(sourceBucket, sourceObject)
 	if err != nil {
-		errorIf(err, "Unable to fetch object info.")
+		errorIfRequest(r, err, "Unable to fetch object info.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), objectSource)
 		return
 	}
+	// Encrypted source objects are copied with their plain data.
+	srcSSEInfo, srcEncrypted, err := getObjectEncryption(sourceBucket, &objInfo)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object encryption info.")
+		writeErrorResponse(w, r, ErrInternalError, objectSource)
+		return
+	}
+	var srcObjectKey [32]byte
+	if srcEncrypted {
+		if srcObjectKey, err = srcSSEInfo.unsealKey(sourceBucket); err != nil {
+			errorIfRequest(r, err, "Unable to unseal object key.")
+			writeErrorResponse(w, r, ErrObjectEncryptionKey, objectSource)
+			return
+		}
+	}
+	// Compressed source objects are copied with their plain data.
+	var srcCompInfo objectCompressionInfo
+	var srcCompressed bool
+	if !srcEncrypted {
+		if srcCompInfo, srcCompressed, err = getObjectCompression(sourceBucket, &objInfo); err != nil {
+			errorIfRequest(r, err, "Unable to read object compression info.")
+			writeErrorResponse(w, r, ErrInternalError, objectSource)
+			return
+		}
+	}
 	// Verify before writing.
 
 	// Verify x-amz-copy-source-if-modified-since and
@@ -373,7 +648,27 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	/// maximum Upload size for object in a single CopyObject operation.
 	if isMaxObjectSize(objInfo.Size) {
-		writeErrorResponse(w, r, ErrEntityTooLarge, objectSource)
+		writeErrorResponse(w, r, ErrObjectTooLarge, objectSource)
+		return
+	}
+
+	// Verify if destination object is not protected by object lock.
+	if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	// Retention and legal hold to be applied on the new object.
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	// Verify if the new object is to be encrypted.
+	sse, encrypt, s3Error := getObjectEncryptionRequest(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
 		return
 	}
 
@@ -381,9 +676,16 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 	go func() {
 		startOffset := int64(0) // Read the whole file.
 		// Get the object.
-		gErr := api.ObjectAPI.GetObject(sourceBucket, sourceObject, startOffset, objInfo.Size, pipeWriter)
+		var gErr error
+		if srcEncrypted {
+			gErr = getDecryptedObject(r.Context(), api.ObjectAPI, sourceBucket, sourceObject, srcSSEInfo, srcObjectKey, startOffset, objInfo.Size, pipeWriter)
+		} else if srcCompressed {
+			gErr = getCompressedObject(r.Context(), api.ObjectAPI, sourceBucket, sourceObject, srcCompInfo, startOffset, objInfo.Size, pipeWriter)
+		} else {
+			gErr = api.ObjectAPI.GetObject(r.Context(), sourceBucket, sourceObject, startOffset, objInfo.Size, pipeWriter)
+		}
 		if gErr != nil {
-			errorIf(gErr, "Unable to read an object.")
+			errorIfRequest(r, gErr, "Unable to read an object.")
 			pipeWriter.CloseWithError(gErr)
 			return
 		}
@@ -401,25 +703,48 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 	// Do not set `md5sum` as CopyObject will not keep the
 	// same md5sum as the source.
 
+	// Verify if the new object is to be compressed.
+	compress := getObjectCompressionRequest(api.ObjectAPI, object, metadata, encrypt)
+
 	// Create the object.
-	md5Sum, err := api.ObjectAPI.PutObject(bucket, object, size, pipeReader, metadata)
+	var md5Sum string
+	if encrypt {
+		md5Sum, err = putEncryptedObject(r.Context(), api.ObjectAPI, bucket, object, sse, size, pipeReader, metadata)
+	} else if compress {
+		md5Sum, err = putCompressedObject(r.Context(), api.ObjectAPI, bucket, object, pipeReader, metadata)
+	} else {
+		md5Sum, err = api.ObjectAPI.PutObject(r.Context(), bucket, object, size, pipeReader, metadata)
+	}
 	if err != nil {
-		errorIf(err, "Unable to create an object.")
+		errorIfRequest(r, err, "Unable to create an object.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
 
+	// Save retention and legal hold for the new object.
+	if err = writeObjectLockInfo(api.ObjectAPI, bucket, object, lockInfo); err != nil {
+		errorIfRequest(r, err, "Unable to save object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
 	objInfo, err = api.ObjectAPI.GetObjectInfo(bucket, object)
 	if err != nil {
-		errorIf(err, "Unable to fetch object info.")
+		errorIfRequest(r, err, "Unable to fetch object info.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
 
+	// Mirror the new object to the replication target.
+	queueReplication(r, bucket, object, false)
+
 	response := generateCopyObjectResponse(md5Sum, objInfo.ModTime)
 	encodedSuccessResponse := encodeResponse(response)
 	// write headers
 	setCommonHeaders(w)
+	if encrypt {
+		setObjectEncryptionHeaders(w, sse.Algorithm, sse.KeyID)
+	}
 	// write success response.
 	writeSuccessResponse(w, encodedSuccessResponse)
 	// Explicitly close the reader, to avoid fd leaks.
@@ -535,7 +860,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	// Get Content-Md5 sent by client and verify if valid
 	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
 	if err != nil {
-		errorIf(err, "Unable to validate content-md5 format.")
+		errorIfRequest(r, err, "Unable to validate content-md5 format.")
 		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
 		return
 	}
@@ -547,10 +872,42 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	}
 	/// maximum Upload size for objects in a single operation
 	if isMaxObjectSize(size) {
-		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		writeErrorResponse(w, r, ErrObjectTooLarge, r.URL.Path)
 		return
 	}
 
+	// Retention and legal hold to be applied on the new object.
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	// Verify if the new object is to be encrypted.
+	sse, encrypt, s3Error := getObjectEncryptionRequest(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	// Additional checksum is verified while the object is written.
+	checksum, hasChecksum, s3Error := getObjectChecksumRequest(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if hasChecksum {
+		checksumReader := newChecksumReader(r.Body, size, checksum)
+		// Empty objects are not read by the object layer.
+		if size == 0 {
+			if err = checksumReader.verify(); err != nil {
+				writeErrorResponse(w, r, ErrBadDigest, r.URL.Path)
+				return
+			}
+		}
+		r.Body = ioutil.NopCloser(checksumReader)
+	}
+
 	// Save metadata.
 	metadata := make(map[string]string)
 	// Make sure we hex encode md5sum here.
@@ -566,6 +923,17 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			metadata[cKey] = r.Header.Get(cKey)
 		}
 	}
+	// Checksum is saved with the object once it was verified.
+	if hasChecksum {
+		if err = setObjectChecksumInfo(metadata, checksum); err != nil {
+			errorIfRequest(r, err, "Unable to save object checksum.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
+
+	// Verify if the new object is to be compressed.
+	compress := getObjectCompressionRequest(api.ObjectAPI, object, metadata, encrypt)
 
 	var md5Sum string
 	switch getRequestAuthType(r) {
@@ -579,9 +947,25 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
+		// Verify if existing object is not protected by object lock.
+		if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
 		// Create anonymous object.
-		md5Sum, err = api.ObjectAPI.PutObject(bucket, object, size, r.Body, metadata)
+		if encrypt {
+			md5Sum, err = putEncryptedObject(r.Context(), api.ObjectAPI, bucket, object, sse, size, r.Body, metadata)
+		} else if compress {
+			md5Sum, err = putCompressedObject(r.Context(), api.ObjectAPI, bucket, object, r.Body, metadata)
+		} else {
+			md5Sum, err = api.ObjectAPI.PutObject(r.Context(), bucket, object, size, r.Body, metadata)
+		}
 	case authTypePresigned, authTypeSigned:
+		// Verify if existing object is not protected by object lock.
+		if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
 		// Initialize a pipe for data pipe line.
 		reader, writer := io.Pipe()
 		var wg = &sync.WaitGroup{}
@@ -591,12 +975,12 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			defer wg.Done()
 			shaWriter := sha256.New()
 			multiWriter := io.MultiWriter(shaWriter, writer)
-			if _, wErr := io.CopyN(multiWriter, r.Body, size); wErr != nil {
+			if _, wErr := copyN(multiWriter, r.Body, size); wErr != nil {
 				// Pipe closed.
 				if wErr == io.ErrClosedPipe {
 					return
 				}
-				errorIf(wErr, "Unable to read from HTTP body.")
+				errorIfRequest(r, wErr, "Unable to read from HTTP body.")
 				writer.CloseWithError(wErr)
 				return
 			}
@@ -612,6 +996,8 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			if s3Error != ErrNone {
 				if s3Error == ErrSignatureDoesNotMatch {
 					sErr = errSignatureMismatch
+				} else if s3Error == ErrContentSHA256Mismatch {
+					sErr = errContentSHA256Mismatch
 				} else {
 					sErr = fmt.Errorf("%v", getAPIError(s3Error))
 				}
@@ -622,20 +1008,40 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		}()
 
 		// Create object.
-		md5Sum, err = api.ObjectAPI.PutObject(bucket, object, size, reader, metadata)
+		if encrypt {
+			md5Sum, err = putEncryptedObject(r.Context(), api.ObjectAPI, bucket, object, sse, size, reader, metadata)
+		} else if compress {
+			md5Sum, err = putCompressedObject(r.Context(), api.ObjectAPI, bucket, object, reader, metadata)
+		} else {
+			md5Sum, err = api.ObjectAPI.PutObject(r.Context(), bucket, object, size, reader, metadata)
+		}
 		// Close the pipe.
 		reader.Close()
 		// Wait for all the routines to finish.
 		wg.Wait()
 	}
 	if err != nil {
-		errorIf(err, "Unable to create an object.")
+		errorIfRequest(r, err, "Unable to create an object.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	// Save retention and legal hold for the new object.
+	if err = writeObjectLockInfo(api.ObjectAPI, bucket, object, lockInfo); err != nil {
+		errorIfRequest(r, err, "Unable to save object retention.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
 	if md5Sum != "" {
 		w.Header().Set("ETag", "\""+md5Sum+"\"")
 	}
+	if encrypt {
+		setObjectEncryptionHeaders(w, sse.Algorithm, sse.KeyID)
+	}
+	if hasChecksum {
+		setObjectChecksumHeaders(w, checksum)
+	}
+	// Mirror the new object to the replication target.
+	queueReplication(r, bucket, object, false)
 	writeSuccessResponse(w, nil)
 }
 
@@ -680,9 +1086,49 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		}
 	}
 
+	// Retention and legal hold requested for the new object are saved
+	// with the upload and applied once it is completed.
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if hasObjectLockHeaders(r) {
+		if err := setUploadLockInfo(metadata, lockInfo); err != nil {
+			errorIfRequest(r, err, "Unable to save object retention.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
+
+	// Verify if the new object is to be encrypted, all parts are
+	// encrypted with the same object key.
+	sse, encrypt, s3Error := getObjectEncryptionRequest(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if encrypt {
+		if err := checkObjectEncryptionSupport(api.ObjectAPI); err != nil {
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			return
+		}
+		sseInfo, _, err := newObjectEncryptionInfo(bucket, object, sse)
+		if err != nil {
+			errorIfRequest(r, err, "Unable to generate object key.")
+			writeErrorResponse(w, r, ErrObjectEncryptionKey, r.URL.Path)
+			return
+		}
+		if err = setObjectEncryptionInfo(metadata, sseInfo); err != nil {
+			errorIfRequest(r, err, "Unable to save object encryption info.")
+			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+			return
+		}
+	}
+
 	uploadID, err := api.ObjectAPI.NewMultipartUpload(bucket, object, metadata)
 	if err != nil {
-		errorIf(err, "Unable to initiate new multipart upload id.")
+		errorIfRequest(r, err, "Unable to initiate new multipart upload id.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
@@ -691,6 +1137,9 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 	encodedSuccessResponse := encodeResponse(response)
 	// write headers
 	setCommonHeaders(w)
+	if encrypt {
+		setObjectEncryptionHeaders(w, sse.Algorithm, sse.KeyID)
+	}
 	// write success response.
 	writeSuccessResponse(w, encodedSuccessResponse)
 }
@@ -715,9 +1164,9 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	/// maximum Upload size for multipart objects in a single operation
-	if isMaxObjectSize(size) {
-		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+	/// maximum Upload size for parts of multipart objects
+	if isMaxPartSize(size) {
+		writeErrorResponse(w, r, ErrPartTooLarge, r.URL.Path)
 		return
 	}
 
@@ -731,8 +1180,16 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 	}
 
 	// check partID with maximum part ID for multipart objects
-	if isMaxPartID(partID) {
-		writeErrorResponse(w, r, ErrInvalidMaxParts, r.URL.Path)
+	if partID < 1 || isMaxPartID(partID) {
+		writeErrorResponse(w, r, ErrInvalidPartNumber, r.URL.Path)
+		return
+	}
+
+	// Parts of an encrypted multipart upload are encrypted with its object key.
+	sseInfo, encrypted, err := getUploadEncryption(api.ObjectAPI, bucket, object, uploadID)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object encryption info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
 
@@ -751,7 +1208,11 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		// No need to verify signature, anonymous request access is
 		// already allowed.
 		hexMD5 := hex.EncodeToString(md5Bytes)
-		partMD5, err = api.ObjectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, hexMD5)
+		if encrypted {
+			partMD5, err = putEncryptedObjectPart(r.Context(), api.ObjectAPI, bucket, object, uploadID, partID, size, r.Body, hexMD5, sseInfo)
+		} else {
+			partMD5, err = api.ObjectAPI.PutObjectPart(r.Context(), bucket, object, uploadID, partID, size, r.Body, hexMD5)
+		}
 	case authTypePresigned, authTypeSigned:
 		// Initialize a pipe for data pipe line.
 		reader, writer := io.Pipe()
@@ -762,12 +1223,12 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			defer wg.Done()
 			shaWriter := sha256.New()
 			multiWriter := io.MultiWriter(shaWriter, writer)
-			if _, wErr := io.CopyN(multiWriter, r.Body, size); wErr != nil {
+			if _, wErr := copyN(multiWriter, r.Body, size); wErr != nil {
 				// Pipe closed, just ignore it.
 				if wErr == io.ErrClosedPipe {
 					return
 				}
-				errorIf(wErr, "Unable to read from HTTP request body.")
+				errorIfRequest(r, wErr, "Unable to read from HTTP request body.")
 				writer.CloseWithError(wErr)
 				return
 			}
@@ -782,6 +1243,8 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			if s3Error != ErrNone {
 				if s3Error == ErrSignatureDoesNotMatch {
 					err = errSignatureMismatch
+				} else if s3Error == ErrContentSHA256Mismatch {
+					err = errContentSHA256Mismatch
 				} else {
 					err = fmt.Errorf("%v", getAPIError(s3Error))
 				}
@@ -792,14 +1255,18 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writer.Close()
 		}()
 		md5SumHex := hex.EncodeToString(md5Bytes)
-		partMD5, err = api.ObjectAPI.PutObjectPart(bucket, object, uploadID, partID, size, reader, md5SumHex)
+		if encrypted {
+			partMD5, err = putEncryptedObjectPart(r.Context(), api.ObjectAPI, bucket, object, uploadID, partID, size, reader, md5SumHex, sseInfo)
+		} else {
+			partMD5, err = api.ObjectAPI.PutObjectPart(r.Context(), bucket, object, uploadID, partID, size, reader, md5SumHex)
+		}
 		// Close the pipe.
 		reader.Close()
 		// Wait for all the routines to finish.
 		wg.Wait()
 	}
 	if err != nil {
-		errorIf(err, "Unable to create object part.")
+		errorIfRequest(r, err, "Unable to create object part.")
 		// Verify if the underlying error is signature mismatch.
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
@@ -807,6 +1274,9 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 	if partMD5 != "" {
 		w.Header().Set("ETag", "\""+partMD5+"\"")
 	}
+	if encrypted {
+		setObjectEncryptionHeaders(w, sseInfo.Algorithm, sseInfo.KeyID)
+	}
 	writeSuccessResponse(w, nil)
 }
 
@@ -836,7 +1306,7 @@ func (api objectAPIHandlers) AbortMultipartUploadHandler(w http.ResponseWriter,
 
 	uploadID, _, _, _ := getObjectResources(r.URL.Query())
 	if err := api.ObjectAPI.AbortMultipartUpload(bucket, object, uploadID); err != nil {
-		errorIf(err, "Unable to abort multipart upload.")
+		errorIfRequest(r, err, "Unable to abort multipart upload.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
@@ -891,12 +1361,12 @@ func (api objectAPIHandlers) ListObjectPartsHandler(w http.ResponseWriter, r *ht
 	}
 	listPartsInfo, err := api.ObjectAPI.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
 	if err != nil {
-		errorIf(err, "Unable to list uploaded parts.")
+		errorIfRequest(r, err, "Unable to list uploaded parts.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
 	response := generateListPartsResponse(listPartsInfo)
-	encodedSuccessResponse := encodeResponse(response)
+	encodedSuccessResponse := encodeListResponse(response)
 	// Write headers.
 	setCommonHeaders(w)
 	// Write success response.
@@ -933,13 +1403,13 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	}
 	completeMultipartBytes, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		errorIf(err, "Unable to complete multipart upload.")
+		errorIfRequest(r, err, "Unable to complete multipart upload.")
 		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		return
 	}
 	complMultipartUpload := &completeMultipartUpload{}
 	if err = xml.Unmarshal(completeMultipartBytes, complMultipartUpload); err != nil {
-		errorIf(err, "Unable to parse complete multipart upload XML.")
+		errorIfRequest(r, err, "Unable to parse complete multipart upload XML.")
 		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
 		return
 	}
@@ -958,6 +1428,50 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		part.ETag = strings.TrimSuffix(part.ETag, "\"")
 		completeParts = append(completeParts, part)
 	}
+	// Verify if existing object is not protected by object lock.
+	if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Bucket default retention is applied on the completed object
+	// unless retention or legal hold was requested on upload start.
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	uploadLockInfo, requested, err := getUploadLockInfo(api.ObjectAPI, bucket, object, uploadID)
+	if _, ok := err.(InvalidUploadID); ok {
+		// Completion fails as well, unless it is retried after the
+		// upload was completed already.
+		err = nil
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object retention.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if requested {
+		lockInfo = uploadLockInfo
+	}
+	// Sizes of encrypted parts are saved, parts are decrypted one by one.
+	sseInfo, encrypted, err := getUploadEncryption(api.ObjectAPI, bucket, object, uploadID)
+	if _, ok := err.(InvalidUploadID); ok {
+		err = nil
+	}
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read object encryption info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if encrypted {
+		if err = saveUploadEncryptionParts(api.ObjectAPI, bucket, object, uploadID, sseInfo, completeParts); err != nil {
+			errorIfRequest(r, err, "Unable to save encrypted parts.")
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			return
+		}
+	}
+
 	// Complete multipart upload.
 	// Send 200 OK
 	setCommonHeaders(w)
@@ -972,12 +1486,29 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 
 	sendWhiteSpaceChars(w, doneCh)
 
+	// Retries of a completion which already succeeded succeed as well.
+	if completedMD5, ok := getCompletedUploadMD5(api.ObjectAPI, bucket, object, completeParts, err); ok {
+		response := generateCompleteMultpartUploadResponse(bucket, object, getLocation(r), completedMD5)
+		w.Write(encodeResponse(response))
+		w.(http.Flusher).Flush()
+		return
+	}
 	if err != nil {
-		errorIf(err, "Unable to complete multipart upload.")
+		errorIfRequest(r, err, "Unable to complete multipart upload.")
 		writeErrorResponseNoHeader(w, r, getAPIError(toAPIErrorCode(err)), r.URL.Path)
 		return
 	}
 
+	// Save retention and legal hold for the new object.
+	if err = writeObjectLockInfo(api.ObjectAPI, bucket, object, lockInfo); err != nil {
+		errorIfRequest(r, err, "Unable to save object retention.")
+		writeErrorResponseNoHeader(w, r, getAPIError(ErrInternalError), r.URL.Path)
+		return
+	}
+
+	// Mirror the new object to the replication target.
+	queueReplication(r, bucket, object, false)
+
 	// Get object location.
 	location := getLocation(r)
 	// Generate complete multipart response.
@@ -1013,9 +1544,20 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 			return
 		}
 	}
+	// Verify if object is not protected by object lock.
+	if s3Error := enforceObjectLock(api.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
 	/// http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectDELETE.html
 	/// Ignore delete object errors, since we are suppposed to reply
 	/// only 204.
-	api.ObjectAPI.DeleteObject(bucket, object)
+	/// Objects of buckets with a trash configuration are moved to the trash.
+	if err := deleteObjectOrTrash(api.ObjectAPI, bucket, object); err == nil {
+		// Object is gone, remove any expired retention left behind.
+		removeObjectLockInfo(api.ObjectAPI, bucket, object)
+		// Mirror the delete to the replication target.
+		queueReplication(r, bucket, object, true)
+	}
 	writeSuccessNoContent(w)
 }
@@ -16,12 +16,16 @@
 
 package main
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 // ObjectLayer implements primitives for object API layer.
 type ObjectLayer interface {
 	// Storage operations.
 	StorageInfo() StorageInfo
+	DisksInfo() []DiskStatus
 
 	// Bucket operations.
 	MakeBucket(bucket string) error
@@ -30,17 +34,42 @@ type ObjectLayer interface {
 	DeleteBucket(bucket string) error
 	ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (result ListObjectsInfo, err error)
 
-	// Object operations.
-	GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) (err error)
+	// Object operations, reads and writes of object data stop once
+	// ctx is done, e.g. when the client disconnects.
+	GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) (err error)
 	GetObjectInfo(bucket, object string) (objInfo ObjectInfo, err error)
-	PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (md5 string, err error)
+	PutObject(ctx context.Context, bucket, object string, size int64, data io.Reader, metadata map[string]string) (md5 string, err error)
 	DeleteObject(bucket, object string) error
 
-	// Multipart operations.
+	// Multipart operations, ctx as for object operations.
 	ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error)
 	NewMultipartUpload(bucket, object string, metadata map[string]string) (uploadID string, err error)
-	PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (md5 string, err error)
+	PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (md5 string, err error)
 	ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (result ListPartsInfo, err error)
 	AbortMultipartUpload(bucket, object, uploadID string) error
 	CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (md5 string, err error)
 }
+
+// objectMetadataUpdater - implemented by object layers which save the
+// metadata of objects and multipart uploads, it is changed without
+// rewriting any data.
+type objectMetadataUpdater interface {
+	// UpdateObjectMetadata - saves the metadata of an object as
+	// changed by update, nothing is saved if update fails.
+	UpdateObjectMetadata(bucket, object string, update func(metadata map[string]string) error) error
+
+	// UpdateUploadMetadata - like UpdateObjectMetadata for an ongoing
+	// multipart upload, its metadata is saved with the object once
+	// the upload is completed.
+	UpdateUploadMetadata(bucket, object, uploadID string, update func(metadata map[string]string) error) error
+}
+
+// getMetadataUpdater - returns the object layer saving the metadata of
+// objects, the disk cache passes updates through to its backend.
+func getMetadataUpdater(objAPI ObjectLayer) (objectMetadataUpdater, bool) {
+	if cache, ok := objAPI.(cacheObjects); ok {
+		objAPI = cache.ObjectLayer
+	}
+	updater, ok := objAPI.(objectMetadataUpdater)
+	return updater, ok
+}
@@ -0,0 +1,79 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// Default size limits of objects and parts, objects are allowed to be
+// larger than on S3.
+const (
+	limitsDefaultMaxObjectSize = 50 * 1024 * 1024 * 1024 * 1024 // 50TiB
+	limitsDefaultMaxPartSize   = 5 * 1024 * 1024 * 1024         // 5GiB
+	limitsDefaultMaxParts      = 10000
+)
+
+// limitsConfig - size limits of uploads, zero values pick the
+// defaults. MaxObjectSize bytes apply to objects uploaded by a single
+// PUT, copied or completed from parts, MaxPartSize bytes and MaxParts
+// apply to the parts of multipart uploads.
+type limitsConfig struct {
+	MaxObjectSize int64 `json:"maxObjectSize"`
+	MaxPartSize   int64 `json:"maxPartSize"`
+	MaxParts      int   `json:"maxParts"`
+}
+
+// newLimitsConfig - returns the default limits config.
+func newLimitsConfig() limitsConfig {
+	return limitsConfig{
+		MaxObjectSize: limitsDefaultMaxObjectSize,
+		MaxPartSize:   limitsDefaultMaxPartSize,
+		MaxParts:      limitsDefaultMaxParts,
+	}
+}
+
+// getLimitsConfig - returns the limits config of the server with zero
+// values replaced by the defaults, the defaults if there is no server
+// config.
+func getLimitsConfig() limitsConfig {
+	if serverConfig == nil {
+		return newLimitsConfig()
+	}
+	limits := serverConfig.GetLimits()
+	if limits.MaxObjectSize <= 0 {
+		limits.MaxObjectSize = limitsDefaultMaxObjectSize
+	}
+	if limits.MaxPartSize <= 0 {
+		limits.MaxPartSize = limitsDefaultMaxPartSize
+	}
+	if limits.MaxParts <= 0 {
+		limits.MaxParts = limitsDefaultMaxParts
+	}
+	return limits
+}
+
+// isMaxObjectSize - verify if size exceeds the maximum object size.
+func isMaxObjectSize(size int64) bool {
+	return size > getLimitsConfig().MaxObjectSize
+}
+
+// isMaxPartSize - verify if size exceeds the maximum part size.
+func isMaxPartSize(size int64) bool {
+	return size > getLimitsConfig().MaxPartSize
+}
+
+// isMaxPartNumber - Check if part ID is greater than the maximum allowed ID.
+func isMaxPartID(partID int) bool {
+	return partID > getLimitsConfig().MaxParts
+}
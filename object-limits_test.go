@@ -0,0 +1,106 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestObjectLimits - tests configured size limits of uploads.
+func TestObjectLimits(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectLimits)
+}
+
+// Tests size limits are enforced and reported.
+func testObjectLimits(obj ObjectLayer, instanceType string, t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer serverConfig.SetLimits(newLimitsConfig())
+
+	// Zero values pick the defaults.
+	serverConfig.SetLimits(limitsConfig{})
+	if limits := getLimitsConfig(); limits != newLimitsConfig() {
+		t.Fatalf("%s: Expected default limits, but instead found %v", instanceType, limits)
+	}
+	if isMaxObjectSize(5*1024*1024*1024*1024 + 1) {
+		t.Fatalf("%s: Expected objects larger than 5TiB to be allowed", instanceType)
+	}
+
+	serverConfig.SetLimits(limitsConfig{
+		MaxObjectSize: 6 * 1024 * 1024,
+		MaxPartSize:   5 * 1024 * 1024,
+		MaxParts:      2,
+	})
+	if !isMaxObjectSize(6*1024*1024+1) || isMaxObjectSize(6*1024*1024) {
+		t.Fatalf("%s: Expected maximum object size of 6MiB", instanceType)
+	}
+	if !isMaxPartSize(5*1024*1024+1) || isMaxPartSize(5*1024*1024) {
+		t.Fatalf("%s: Expected maximum part size of 5MiB", instanceType)
+	}
+	if !isMaxPartID(3) || isMaxPartID(2) {
+		t.Fatalf("%s: Expected maximum of 2 parts", instanceType)
+	}
+	if desc := getAPIError(ErrObjectTooLarge).Description; !strings.Contains(desc, "6291456 bytes") {
+		t.Fatalf("%s: Expected object size limit in %q", instanceType, desc)
+	}
+	if desc := getAPIError(ErrPartTooLarge).Description; !strings.Contains(desc, "5242880 bytes") {
+		t.Fatalf("%s: Expected part size limit in %q", instanceType, desc)
+	}
+	if desc := getAPIError(ErrInvalidPartNumber).Description; !strings.Contains(desc, "between 1 and 2") {
+		t.Fatalf("%s: Expected part count limit in %q", instanceType, desc)
+	}
+
+	// Parts within the limits cannot be completed to an object
+	// exceeding them.
+	bucket, object := "limits-bucket", "object"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("a"), 5*1024*1024)
+	var parts []completePart
+	for partID := 1; partID <= 2; partID++ {
+		md5Hex, pErr := obj.PutObjectPart(context.Background(), bucket, object, uploadID, partID, int64(len(data)), bytes.NewReader(data), "")
+		if pErr != nil {
+			t.Fatalf("%s: %s", instanceType, pErr)
+		}
+		parts = append(parts, completePart{PartNumber: partID, ETag: md5Hex})
+	}
+	_, err = obj.CompleteMultipartUpload(bucket, object, uploadID, parts)
+	if _, ok := err.(ObjectTooLarge); !ok {
+		t.Fatalf("%s: Expected ObjectTooLarge, but instead found %v", instanceType, err)
+	}
+
+	// The upload is completed once the limit allows it.
+	serverConfig.SetLimits(newLimitsConfig())
+	if _, err = obj.CompleteMultipartUpload(bucket, object, uploadID, parts); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Object layers walk their namespace by "/", listings delimited by any
+// other string are computed from recursive listings by rolling keys up
+// into common prefixes here.
+
+// isWalkDelimiter - returns if the object layers list delimiter by
+// walking their namespace.
+func isWalkDelimiter(delimiter string) bool {
+	return delimiter == "" || delimiter == slashSeparator
+}
+
+// getCommonPrefix - returns the common prefix key is rolled up into, up
+// to and including the first delimiter after prefix, empty if key has
+// no delimiter after prefix.
+func getCommonPrefix(prefix, key, delimiter string) string {
+	if !strings.HasPrefix(key, prefix) {
+		return ""
+	}
+	i := strings.Index(key[len(prefix):], delimiter)
+	if i == -1 {
+		return ""
+	}
+	return key[:len(prefix)+i+len(delimiter)]
+}
+
+// skipCommonPrefix - returns the marker to continue a recursive listing
+// with after all keys rolled up into commonPrefix.
+func skipCommonPrefix(commonPrefix string) string {
+	return commonPrefix + string(utf8.MaxRune)
+}
+
+// listObjectsFunc - lists objects like ObjectLayer.ListObjects.
+type listObjectsFunc func(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error)
+
+// listObjectsDelimited - lists at most maxKeys objects and common
+// prefixes delimited by delimiter from recursive listings of list. A
+// marker naming a common prefix skips all keys rolled up into it.
+func listObjectsDelimited(list listObjectsFunc, bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	var result ListObjectsInfo
+	// Common prefix returned last, its keys are skipped.
+	lastPrefix := ""
+	if marker != "" && getCommonPrefix(prefix, marker, delimiter) == marker {
+		lastPrefix = marker
+		marker = skipCommonPrefix(marker)
+	}
+	for {
+		page, err := list(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		for _, objInfo := range page.Objects {
+			commonPrefix := getCommonPrefix(prefix, objInfo.Name, delimiter)
+			if commonPrefix != "" && commonPrefix == lastPrefix {
+				continue
+			}
+			if len(result.Objects)+len(result.Prefixes) == maxKeys {
+				result.IsTruncated = true
+				return result, nil
+			}
+			if commonPrefix != "" {
+				result.Prefixes = append(result.Prefixes, commonPrefix)
+				result.NextMarker = commonPrefix
+				lastPrefix = commonPrefix
+				continue
+			}
+			result.Objects = append(result.Objects, objInfo)
+			result.NextMarker = objInfo.Name
+		}
+		if !page.IsTruncated || len(page.Objects) == 0 {
+			result.NextMarker = ""
+			return result, nil
+		}
+		marker = page.Objects[len(page.Objects)-1].Name
+		// Keys rolled up into the last common prefix are not listed.
+		if lastPrefix != "" && strings.HasPrefix(marker, lastPrefix) {
+			marker = skipCommonPrefix(lastPrefix)
+		}
+	}
+}
+
+// listMultipartUploadsFunc - lists uploads like
+// ObjectLayer.ListMultipartUploads.
+type listMultipartUploadsFunc func(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error)
+
+// listMultipartUploadsDelimited - lists at most maxUploads uploads and
+// common prefixes delimited by delimiter from recursive listings of
+// list. A key marker naming a common prefix skips all uploads of keys
+// rolled up into it.
+func listMultipartUploadsDelimited(list listMultipartUploadsFunc, bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	result := ListMultipartsInfo{
+		KeyMarker:      keyMarker,
+		UploadIDMarker: uploadIDMarker,
+		MaxUploads:     maxUploads,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+	}
+	if maxUploads < 0 || maxUploads > maxUploadsList {
+		maxUploads = maxUploadsList
+	}
+	var uploads []uploadMetadata
+	lastPrefix := ""
+	if keyMarker != "" && getCommonPrefix(prefix, keyMarker, delimiter) == keyMarker {
+		lastPrefix = keyMarker
+		keyMarker, uploadIDMarker = skipCommonPrefix(keyMarker), ""
+	}
+	for {
+		page, err := list(bucket, prefix, keyMarker, uploadIDMarker, "", maxUploadsList)
+		if err != nil {
+			return ListMultipartsInfo{}, err
+		}
+		for _, upload := range page.Uploads {
+			commonPrefix := getCommonPrefix(prefix, upload.Object, delimiter)
+			if commonPrefix != "" && commonPrefix == lastPrefix {
+				continue
+			}
+			if len(uploads) == maxUploads {
+				fillMultipartsInfo(&result, uploads, true)
+				return result, nil
+			}
+			if commonPrefix != "" {
+				uploads = append(uploads, uploadMetadata{Object: commonPrefix})
+				lastPrefix = commonPrefix
+				continue
+			}
+			uploads = append(uploads, upload)
+		}
+		if !page.IsTruncated {
+			fillMultipartsInfo(&result, uploads, false)
+			return result, nil
+		}
+		keyMarker, uploadIDMarker = page.NextKeyMarker, page.NextUploadIDMarker
+		// Uploads of keys rolled up into the last common prefix are
+		// not listed.
+		if lastPrefix != "" && strings.HasPrefix(keyMarker, lastPrefix) {
+			keyMarker, uploadIDMarker = skipCommonPrefix(lastPrefix), ""
+		}
+	}
+}
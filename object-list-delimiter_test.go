@@ -0,0 +1,126 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// Tests keys are rolled up into common prefixes.
+func TestGetCommonPrefix(t *testing.T) {
+	testCases := []struct {
+		prefix, key, delimiter string
+		expected               string
+	}{
+		{"", "a-b-c", "-", "a-"},
+		{"a-", "a-b-c", "-", "a-b-"},
+		{"a-b-", "a-b-c", "-", ""},
+		{"", "a--b", "--", "a--"},
+		{"", "abc", "--", ""},
+		{"b", "a-b", "-", ""},
+	}
+	for i, testCase := range testCases {
+		if commonPrefix := getCommonPrefix(testCase.prefix, testCase.key, testCase.delimiter); commonPrefix != testCase.expected {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, commonPrefix)
+		}
+	}
+}
+
+// Wrapper for calling testListObjectsDelimiter for both XL and single node setup.
+func TestListObjectsDelimiter(t *testing.T) {
+	ExecObjectLayerTest(t, testListObjectsDelimiter)
+}
+
+// Tests listing objects and uploads delimited by other delimiters than '/'.
+func testListObjectsDelimiter(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	for _, object := range []string{"a-1", "a-2", "a-b-3", "b", "c--1", "c--2", "d/e-1", "f"} {
+		if _, err := obj.PutObject(context.Background(), bucket, object, int64(len(object)), bytes.NewBufferString(object), nil); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+
+	testCases := []struct {
+		prefix, delimiter string
+		objects           []string
+		prefixes          []string
+	}{
+		{"", "-", []string{"b", "f"}, []string{"a-", "c-", "d/e-"}},
+		{"a-", "-", []string{"a-1", "a-2"}, []string{"a-b-"}},
+		{"", "--", []string{"a-1", "a-2", "a-b-3", "b", "d/e-1", "f"}, []string{"c--"}},
+		{"d", "-", nil, []string{"d/e-"}},
+	}
+	for i, testCase := range testCases {
+		result, err := obj.ListObjects(bucket, testCase.prefix, "", testCase.delimiter, 1000)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %v", instanceType, i+1, err)
+		}
+		var objects []string
+		for _, objInfo := range result.Objects {
+			objects = append(objects, objInfo.Name)
+		}
+		if !reflect.DeepEqual(objects, testCase.objects) || !reflect.DeepEqual(result.Prefixes, testCase.prefixes) || result.IsTruncated {
+			t.Errorf("%s: Test %d: Expected %v %v, got %v %v", instanceType, i+1, testCase.objects, testCase.prefixes, objects, result.Prefixes)
+		}
+	}
+
+	// Paging with common prefixes as markers lists every entry once.
+	var entries []string
+	marker := ""
+	for {
+		result, err := obj.ListObjects(bucket, "", marker, "-", 1)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		for _, objInfo := range result.Objects {
+			entries = append(entries, objInfo.Name)
+		}
+		entries = append(entries, result.Prefixes...)
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	if expected := []string{"a-", "b", "c-", "d/e-", "f"}; !reflect.DeepEqual(entries, expected) {
+		t.Errorf("%s: Expected %v, got %v", instanceType, expected, entries)
+	}
+
+	for _, object := range []string{"a-1", "a-b-2", "b"} {
+		if _, err := obj.NewMultipartUpload(bucket, object, nil); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	result, err := obj.ListMultipartUploads(bucket, "", "", "", "-", 1)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(result.CommonPrefixes, []string{"a-"}) || len(result.Uploads) != 0 || !result.IsTruncated {
+		t.Fatalf("%s: Expected common prefix a-, got %v %v", instanceType, result.CommonPrefixes, result.Uploads)
+	}
+	if result, err = obj.ListMultipartUploads(bucket, "", result.NextKeyMarker, "", "-", 10); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Uploads) != 1 || result.Uploads[0].Object != "b" || result.IsTruncated {
+		t.Fatalf("%s: Expected the upload of b, got %v", instanceType, result.Uploads)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "strings"
+
+// listUploadIDsFunc - lists at most count uploads of an object, after
+// uploadIDMarker if set.
+type listUploadIDsFunc func(object, uploadIDMarker string, count int) ([]uploadMetadata, error)
+
+// nextUploadEntryFunc - returns the next object with pending uploads or
+// common prefix, in lexical order after the key marker, false at the end.
+type nextUploadEntryFunc func() (entry string, ok bool, err error)
+
+// getMultipartMarkers - returns the key and upload id markers to list
+// uploads with prefix from. Markers sorting before the prefix are
+// ignored, done is true if the markers sort after all keys with prefix.
+func getMultipartMarkers(prefix, keyMarker, uploadIDMarker string) (string, string, bool) {
+	// The upload id marker is ignored without a key marker.
+	if keyMarker == "" {
+		return "", "", false
+	}
+	if strings.HasPrefix(keyMarker, prefix) {
+		return keyMarker, uploadIDMarker, false
+	}
+	if keyMarker < prefix {
+		return "", "", false
+	}
+	return "", "", true
+}
+
+// listMultipartsPage - collects a page of at most maxUploads uploads and
+// common prefixes, the uploads of keyMarker after uploadIDMarker come
+// first. One more entry than requested is looked up to tell if the
+// listing is truncated.
+func listMultipartsPage(keyMarker, uploadIDMarker string, maxUploads int, nextEntry nextUploadEntryFunc, listUploadIDs listUploadIDsFunc) (uploads []uploadMetadata, truncated bool, err error) {
+	count := maxUploads + 1
+	if keyMarker != "" && uploadIDMarker != "" {
+		uploads, err = listUploadIDs(keyMarker, uploadIDMarker, count)
+		if err != nil && err != errFileNotFound {
+			return nil, false, err
+		}
+		count -= len(uploads)
+	}
+	for count > 0 {
+		entry, ok, err := nextEntry()
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			break
+		}
+		// Directories are common prefixes.
+		if strings.HasSuffix(entry, slashSeparator) {
+			uploads = append(uploads, uploadMetadata{Object: entry})
+			count--
+			continue
+		}
+		entryUploads, err := listUploadIDs(entry, "", count)
+		if err != nil {
+			// Uploads completed or aborted meanwhile are skipped.
+			if err == errFileNotFound {
+				continue
+			}
+			return nil, false, err
+		}
+		uploads = append(uploads, entryUploads...)
+		count -= len(entryUploads)
+	}
+	if len(uploads) > maxUploads {
+		return uploads[:maxUploads], true, nil
+	}
+	return uploads, false, nil
+}
+
+// fillMultipartsInfo - fills uploads and common prefixes of a page into
+// result, along with the markers to list the next page from.
+func fillMultipartsInfo(result *ListMultipartsInfo, uploads []uploadMetadata, truncated bool) {
+	for _, upload := range uploads {
+		// Common prefixes have no upload id.
+		if upload.UploadID == "" {
+			result.CommonPrefixes = append(result.CommonPrefixes, upload.Object)
+		} else {
+			result.Uploads = append(result.Uploads, upload)
+		}
+	}
+	result.IsTruncated = truncated
+	if truncated && len(uploads) > 0 {
+		last := uploads[len(uploads)-1]
+		result.NextKeyMarker = last.Object
+		result.NextUploadIDMarker = last.UploadID
+	}
+}
+
+// listPartsPage - returns at most maxParts parts numbered after
+// partNumberMarker, parts are sorted by part number.
+func listPartsPage(parts []objectPartInfo, partNumberMarker, maxParts int) (page []objectPartInfo, truncated bool) {
+	for len(parts) > 0 && parts[0].Number <= partNumberMarker {
+		parts = parts[1:]
+	}
+	if maxParts > maxPartsList {
+		maxParts = maxPartsList
+	}
+	if len(parts) > maxParts {
+		return parts[:maxParts], true
+	}
+	return parts, false
+}
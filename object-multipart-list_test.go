@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Tests markers are adjusted to the prefix.
+func TestGetMultipartMarkers(t *testing.T) {
+	testCases := []struct {
+		prefix, keyMarker, uploadIDMarker string
+		expectedKey, expectedUploadID     string
+		done                              bool
+	}{
+		{"", "", "id", "", "", false},
+		{"photos/", "photos/a", "id", "photos/a", "id", false},
+		{"photos/", "music/a", "id", "", "", false},
+		{"photos/", "videos/a", "id", "", "", true},
+	}
+	for i, testCase := range testCases {
+		keyMarker, uploadIDMarker, done := getMultipartMarkers(testCase.prefix, testCase.keyMarker, testCase.uploadIDMarker)
+		if keyMarker != testCase.expectedKey || uploadIDMarker != testCase.expectedUploadID || done != testCase.done {
+			t.Errorf("Test %d: Expected (%s, %s, %v), got (%s, %s, %v)", i+1, testCase.expectedKey,
+				testCase.expectedUploadID, testCase.done, keyMarker, uploadIDMarker, done)
+		}
+	}
+}
+
+// Tests paging through parts.
+func TestListPartsPage(t *testing.T) {
+	parts := []objectPartInfo{{Number: 1}, {Number: 2}, {Number: 5}, {Number: 7}}
+	testCases := []struct {
+		partNumberMarker, maxParts int
+		expected                   []int
+		truncated                  bool
+	}{
+		{0, 10, []int{1, 2, 5, 7}, false},
+		{0, 2, []int{1, 2}, true},
+		{2, 2, []int{5, 7}, false},
+		// Markers of parts which do not exist are honored.
+		{3, 1, []int{5}, true},
+		{7, 10, nil, false},
+		{0, 0, nil, true},
+	}
+	for i, testCase := range testCases {
+		page, truncated := listPartsPage(parts, testCase.partNumberMarker, testCase.maxParts)
+		var numbers []int
+		for _, part := range page {
+			numbers = append(numbers, part.Number)
+		}
+		if !reflect.DeepEqual(numbers, testCase.expected) || truncated != testCase.truncated {
+			t.Errorf("Test %d: Expected %v %v, got %v %v", i+1, testCase.expected, testCase.truncated, numbers, truncated)
+		}
+	}
+}
+
+// Wrapper for calling testListMultipartUploadsPages for both XL and single node setup.
+func TestListMultipartUploadsPages(t *testing.T) {
+	ExecObjectLayerTest(t, testListMultipartUploadsPages)
+}
+
+// Tests resuming the listing of uploads page by page finds every upload once.
+func testListMultipartUploadsPages(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	var expected []uploadMetadata
+	for _, object := range []string{"a", "b", "dir/c", "dir/d", "e"} {
+		for i := 0; i < 2; i++ {
+			uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+			if err != nil {
+				t.Fatalf("%s: %v", instanceType, err)
+			}
+			expected = append(expected, uploadMetadata{Object: object, UploadID: uploadID})
+		}
+	}
+
+	for _, maxUploads := range []int{1, 2, 3, 4, 10} {
+		var uploads []uploadMetadata
+		keyMarker, uploadIDMarker := "", ""
+		for {
+			result, err := obj.ListMultipartUploads(bucket, "", keyMarker, uploadIDMarker, "", maxUploads)
+			if err != nil {
+				t.Fatalf("%s: %v", instanceType, err)
+			}
+			if len(result.Uploads) > maxUploads {
+				t.Fatalf("%s: Expected at most %d uploads, got %d", instanceType, maxUploads, len(result.Uploads))
+			}
+			uploads = append(uploads, result.Uploads...)
+			if !result.IsTruncated {
+				break
+			}
+			keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+		}
+		if len(uploads) != len(expected) {
+			t.Fatalf("%s: max uploads %d: Expected %d uploads, got %d", instanceType, maxUploads, len(expected), len(uploads))
+		}
+		for i := range uploads {
+			if uploads[i].Object != expected[i].Object || uploads[i].UploadID != expected[i].UploadID {
+				t.Errorf("%s: max uploads %d: Expected upload %v, got %v", instanceType, maxUploads, expected[i], uploads[i])
+			}
+		}
+	}
+
+	// Common prefixes count towards max uploads.
+	result, err := obj.ListMultipartUploads(bucket, "", "b", "", slashSeparator, 1)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(result.CommonPrefixes, []string{"dir/"}) || len(result.Uploads) != 0 || !result.IsTruncated {
+		t.Fatalf("%s: Expected common prefix dir/, got %v %v", instanceType, result.CommonPrefixes, result.Uploads)
+	}
+	if result, err = obj.ListMultipartUploads(bucket, "", result.NextKeyMarker, "", slashSeparator, 10); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Uploads) != 2 || result.Uploads[0].Object != "e" || result.IsTruncated {
+		t.Fatalf("%s: Expected the uploads of e, got %v", instanceType, result.Uploads)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Object name validation modes. By default the names S3 recommends to
+// avoid are rejected and names the platform cannot store fail on
+// write. Strict names can be stored on any platform, which keeps data
+// portable between unix and windows servers. Lenient names are any
+// UTF8 strings, segments the platform cannot store are percent-encoded
+// on disk.
+const (
+	objectNamesStrict  = "strict"
+	objectNamesLenient = "lenient"
+)
+
+// getObjectNamesMode - returns the object name validation mode of the
+// server, the default mode if there is no server config.
+func getObjectNamesMode() string {
+	if serverConfig == nil {
+		return ""
+	}
+	return serverConfig.GetObjectNames()
+}
+
+// isStrictObjectName - returns if object can be stored on any platform
+// and has no control characters.
+func isStrictObjectName(object string) bool {
+	return isValidWindowsPath(object) && strings.IndexFunc(object, unicode.IsControl) == -1
+}
+
+// encodePathName - encodes all segments of pathName which cannot be
+// created on this platform if object names are lenient, pathName is
+// returned as is otherwise.
+func encodePathName(pathName string) string {
+	if getObjectNamesMode() != objectNamesLenient {
+		return pathName
+	}
+	segments := strings.Split(pathName, slashSeparator)
+	for i := range segments {
+		segments[i] = encodePathSegment(segments[i], canCreatePathSegment)
+	}
+	return strings.Join(segments, slashSeparator)
+}
+
+// decodePathName - reverses encodePathName for an entry of a directory
+// listing, keeping a trailing "/".
+func decodePathName(name string) string {
+	if getObjectNamesMode() != objectNamesLenient {
+		return name
+	}
+	if strings.HasSuffix(name, slashSeparator) {
+		return decodePathSegment(strings.TrimSuffix(name, slashSeparator), canCreatePathSegment) + slashSeparator
+	}
+	return decodePathSegment(name, canCreatePathSegment)
+}
+
+// canCreatePathSegment - returns if a file named segment can be created
+// on this platform.
+func canCreatePathSegment(segment string) bool {
+	return checkPathName(segment) == nil
+}
+
+// encodePathSegment - returns segment as is if it can be created,
+// otherwise "%" followed by segment with the offending characters, "%"
+// and trailing dots and spaces percent-encoded. Segments starting with
+// "%" which would be mistaken for encoded ones are encoded as well.
+func encodePathSegment(segment string, canCreate func(string) bool) string {
+	if segment == "" || segment == "." || segment == ".." {
+		return segment
+	}
+	if canCreate(segment) && decodePathSegment(segment, canCreate) == segment {
+		return segment
+	}
+	trailing := len(strings.TrimRight(segment, ". "))
+	var encoded strings.Builder
+	encoded.WriteString("%")
+	for i, r := range segment {
+		if r == '%' || r < 0x20 || r == 0x7f || strings.ContainsRune(`\:*?"<>|`, r) || i >= trailing {
+			fmt.Fprintf(&encoded, "%%%02X", r)
+			continue
+		}
+		encoded.WriteRune(r)
+	}
+	return encoded.String()
+}
+
+// decodePathSegment - reverses encodePathSegment, segments which were
+// not encoded by it are returned as is.
+func decodePathSegment(segment string, canCreate func(string) bool) string {
+	if !strings.HasPrefix(segment, "%") {
+		return segment
+	}
+	decoded, err := url.PathUnescape(segment[1:])
+	if err != nil || encodePathSegment(decoded, canCreate) != segment {
+		return segment
+	}
+	return decoded
+}
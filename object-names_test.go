@@ -0,0 +1,132 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// Tests segments windows cannot store are encoded reversibly.
+func TestEncodePathSegment(t *testing.T) {
+	testCases := []struct {
+		segment string
+		encoded string
+	}{
+		{"object", "object"},
+		{"%41", "%41"},
+		{"100%", "100%"},
+		{"file.", "%file%2E"},
+		{"file. .", "%file%2E%20%2E"},
+		{"a.b", "a.b"},
+		{"a:b", "%a%3Ab"},
+		{"a|b?", "%a%7Cb%3F"},
+		{"tab\t", "%tab%09"},
+		{"CON", "%CON"},
+		{"con.txt", "%con.txt"},
+		// Would be decoded if stored as is.
+		{"%CON", "%%25CON"},
+		{"%a%3Ab", "%%25a%253Ab"},
+	}
+	for i, testCase := range testCases {
+		encoded := encodePathSegment(testCase.segment, isValidWindowsPath)
+		if encoded != testCase.encoded {
+			t.Errorf("Test %d: Expected %q, but instead found %q", i+1, testCase.encoded, encoded)
+		}
+		if !isValidWindowsPath(encoded) {
+			t.Errorf("Test %d: Expected %q to be valid on windows", i+1, encoded)
+		}
+		if decoded := decodePathSegment(encoded, isValidWindowsPath); decoded != testCase.segment {
+			t.Errorf("Test %d: Expected %q, but instead found %q", i+1, testCase.segment, decoded)
+		}
+	}
+}
+
+// Tests object names are validated according to the mode.
+func TestObjectNamesModes(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	defer serverConfig.SetObjectNames("")
+
+	testCases := []struct {
+		object                    string
+		strict, standard, lenient bool
+	}{
+		{"dir/object.txt", true, true, true},
+		{"a:b", false, true, true},
+		{"file.", false, true, true},
+		{"dir /file", false, true, true},
+		{"a\x01b", false, true, true},
+		{"a\x7fb", false, true, true},
+		{"a|b", false, false, true},
+		{"a\\b", false, false, true},
+		{"dir/", false, false, false},
+	}
+	for i, testCase := range testCases {
+		for _, mode := range []struct {
+			name  string
+			valid bool
+		}{
+			{objectNamesStrict, testCase.strict},
+			{"", testCase.standard},
+			{objectNamesLenient, testCase.lenient},
+		} {
+			serverConfig.SetObjectNames(mode.name)
+			if valid := IsValidObjectName(testCase.object); valid != mode.valid {
+				t.Errorf("Test %d: Expected %q to be valid %v in mode %q, but instead found %v", i+1, testCase.object, mode.valid, mode.name, valid)
+			}
+		}
+	}
+
+	// Lenient names round-trip through the posix layer.
+	serverConfig.SetObjectNames(objectNamesLenient)
+	obj, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+	bucket, object := "bucket", "dir. /a|b: "
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	if _, err = obj.PutObject(context.Background(), bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != object {
+		t.Fatalf("Expected %q to be listed, but instead found %v", object, result.Objects)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(context.Background(), bucket, object, 0, int64(len(data)), &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q, but instead found %q", data, buffer.Bytes())
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Object layers of disk formats, detected from format.json of the
+// disks, and of the gateways.
+const (
+	objectLayerFS     = "fs"
+	objectLayerXL     = "xl"
+	objectLayerXLSets = "xl-sets"
+	objectLayerS3     = "s3"
+)
+
+var errObjectLayerNotRegistered = errors.New("Object layer not registered")
+
+// objectLayerFactory - initializes an object layer from the command line
+// arguments it was started with, e.g. disks or a remote endpoint.
+type objectLayerFactory func(args []string) (ObjectLayer, error)
+
+// objectLayerRegistry - object layer implementations by name.
+type objectLayerRegistry struct {
+	mutex     *sync.Mutex
+	factories map[string]objectLayerFactory
+}
+
+// globalObjectLayers - all object layers compiled in, FS, XL and the
+// gateways register themselves from init(). Alternative backends are
+// added the same way without changes to the API handlers.
+var globalObjectLayers = &objectLayerRegistry{
+	mutex:     &sync.Mutex{},
+	factories: make(map[string]objectLayerFactory),
+}
+
+// registerObjectLayer - registers factory under name, registering a
+// name twice is a programming error.
+func registerObjectLayer(name string, factory objectLayerFactory) {
+	globalObjectLayers.mutex.Lock()
+	defer globalObjectLayers.mutex.Unlock()
+	if _, ok := globalObjectLayers.factories[name]; ok {
+		panic(fmt.Sprintf("Object layer %s registered twice", name))
+	}
+	globalObjectLayers.factories[name] = factory
+}
+
+// newRegisteredObjectLayer - initializes the object layer registered
+// under name with args.
+func newRegisteredObjectLayer(name string, args []string) (ObjectLayer, error) {
+	globalObjectLayers.mutex.Lock()
+	factory, ok := globalObjectLayers.factories[name]
+	globalObjectLayers.mutex.Unlock()
+	if !ok {
+		return nil, errObjectLayerNotRegistered
+	}
+	return factory(args)
+}
+
+// registeredObjectLayers - returns the sorted names of all registered
+// object layers.
+func registeredObjectLayers() []string {
+	globalObjectLayers.mutex.Lock()
+	defer globalObjectLayers.mutex.Unlock()
+	names := make([]string, 0, len(globalObjectLayers.factories))
+	for name := range globalObjectLayers.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
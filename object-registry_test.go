@@ -0,0 +1,65 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// Tests object layers are initialized by their registered name.
+func TestObjectLayerRegistry(t *testing.T) {
+	errTestLayer := errors.New("test layer")
+	// Registrations cannot be undone, the name is unique for every run.
+	name := "test-" + getUUID()
+	var gotArgs []string
+	registerObjectLayer(name, func(args []string) (ObjectLayer, error) {
+		gotArgs = args
+		return nil, errTestLayer
+	})
+
+	if _, err := newRegisteredObjectLayer(name, []string{"a", "b"}); err != errTestLayer {
+		t.Fatalf("Expected %s, but instead found %v", errTestLayer, err)
+	}
+	if len(gotArgs) != 2 || gotArgs[0] != "a" || gotArgs[1] != "b" {
+		t.Fatalf("Expected args [a b], but instead found %v", gotArgs)
+	}
+	if _, err := newRegisteredObjectLayer("unknown", nil); err != errObjectLayerNotRegistered {
+		t.Fatalf("Expected %s, but instead found %v", errObjectLayerNotRegistered, err)
+	}
+	if _, err := newRegisteredObjectLayer(objectLayerFS, []string{"a", "b"}); err != errInvalidArgument {
+		t.Fatalf("Expected %s, but instead found %v", errInvalidArgument, err)
+	}
+
+	// FS, XL and the gateways are registered.
+	registered := make(map[string]bool)
+	for _, registeredName := range registeredObjectLayers() {
+		registered[registeredName] = true
+	}
+	for _, expected := range []string{objectLayerFS, objectLayerXL, objectLayerS3, name} {
+		if !registered[expected] {
+			t.Errorf("Expected object layer %s to be registered", expected)
+		}
+	}
+
+	// Registering a name twice panics.
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected registering a name twice to panic")
+		}
+	}()
+	registerObjectLayer(objectLayerFS, nil)
+}
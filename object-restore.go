@@ -0,0 +1,103 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Maximum size of a restore object request.
+const maxRestoreRequestSize = 4 * 1024
+
+// RestoreRequest - days a restored copy of an archived object is kept.
+type RestoreRequest struct {
+	XMLName xml.Name `xml:"RestoreRequest"`
+	Days    int      `xml:"Days"`
+}
+
+// parseRestoreRequest - parses and validates a restore object request.
+func parseRestoreRequest(reqBytes []byte) (*RestoreRequest, APIErrorCode) {
+	restoreReq := &RestoreRequest{}
+	if err := xml.Unmarshal(reqBytes, restoreReq); err != nil {
+		return nil, ErrMalformedXML
+	}
+	if restoreReq.Days < 1 {
+		return nil, ErrMalformedXML
+	}
+	return restoreReq, ErrNone
+}
+
+// RestoreObjectHandler - POST Object restore
+// ----------
+// Restores a temporary copy of an object archived to a remote tier.
+// Objects are never transitioned to a remote tier since lifecycle
+// configurations are not supported, all objects are readable without
+// a restore and restore requests fail with InvalidObjectState like
+// they do on S3 for objects which are not archived.
+func (api objectAPIHandlers) RestoreObjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		if s3Error := enforceBucketPolicy("s3:RestoreObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if r.ContentLength <= 0 {
+		writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
+		return
+	}
+	if r.ContentLength > maxRestoreRequestSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	reqBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read HTTP body.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	if _, s3Error := parseRestoreRequest(reqBytes); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	if _, err = api.ObjectAPI.GetObjectInfo(bucket, object); err != nil {
+		errorIfRequest(r, err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeErrorResponse(w, r, ErrInvalidObjectState, r.URL.Path)
+}
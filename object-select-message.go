@@ -0,0 +1,203 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Select results are streamed in the event stream encoding, every
+// message is framed as
+//
+//   <total length:4> <headers length:4> <prelude crc:4> <headers> <payload> <message crc:4>
+//
+// https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectSELECTContent.html
+
+const (
+	// Records are sent when this many bytes are buffered.
+	maxSelectRecordsMessageSize = 128 * 1024
+
+	// Interval of keep alive messages while records are filtered.
+	selectKeepAliveInterval = 5 * time.Second
+
+	// Event stream header value type of strings.
+	eventStreamStringHeader = 7
+)
+
+// eventStreamHeader - header of an event stream message.
+type eventStreamHeader struct {
+	name  string
+	value string
+}
+
+// encodeEventStreamMessage - frames headers and payload as an event
+// stream message.
+func encodeEventStreamMessage(headers []eventStreamHeader, payload []byte) []byte {
+	var headerBytes bytes.Buffer
+	for _, header := range headers {
+		headerBytes.WriteByte(byte(len(header.name)))
+		headerBytes.WriteString(header.name)
+		headerBytes.WriteByte(eventStreamStringHeader)
+		binary.Write(&headerBytes, binary.BigEndian, uint16(len(header.value)))
+		headerBytes.WriteString(header.value)
+	}
+
+	var message bytes.Buffer
+	totalLength := 12 + headerBytes.Len() + len(payload) + 4
+	binary.Write(&message, binary.BigEndian, uint32(totalLength))
+	binary.Write(&message, binary.BigEndian, uint32(headerBytes.Len()))
+	binary.Write(&message, binary.BigEndian, crc32.ChecksumIEEE(message.Bytes()))
+	message.Write(headerBytes.Bytes())
+	message.Write(payload)
+	binary.Write(&message, binary.BigEndian, crc32.ChecksumIEEE(message.Bytes()))
+	return message.Bytes()
+}
+
+// selectStats - statistics of a select request.
+type selectStats struct {
+	XMLName        xml.Name `xml:"Stats"`
+	BytesScanned   int64    `xml:"BytesScanned"`
+	BytesProcessed int64    `xml:"BytesProcessed"`
+	BytesReturned  int64    `xml:"BytesReturned"`
+}
+
+// selectProgress - progress of a select request.
+type selectProgress struct {
+	XMLName        xml.Name `xml:"Progress"`
+	BytesScanned   int64    `xml:"BytesScanned"`
+	BytesProcessed int64    `xml:"BytesProcessed"`
+	BytesReturned  int64    `xml:"BytesReturned"`
+}
+
+// selectMessageWriter - writes select results as event stream messages.
+type selectMessageWriter struct {
+	writer io.Writer
+	// Buffered records not sent yet.
+	records bytes.Buffer
+	// Bytes of records sent.
+	bytesReturned int64
+	// Time the last message was sent.
+	lastMessage time.Time
+}
+
+func newSelectMessageWriter(writer io.Writer) *selectMessageWriter {
+	return &selectMessageWriter{writer: writer, lastMessage: time.Now()}
+}
+
+// writeMessage - writes a message and flushes it to the client.
+func (s *selectMessageWriter) writeMessage(headers []eventStreamHeader, payload []byte) error {
+	if _, err := s.writer.Write(encodeEventStreamMessage(headers, payload)); err != nil {
+		return err
+	}
+	if flusher, ok := s.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	s.lastMessage = time.Now()
+	return nil
+}
+
+// writeRecord - buffers a record, records are sent in batches.
+func (s *selectMessageWriter) writeRecord(record []byte) error {
+	s.records.Write(record)
+	if s.records.Len() >= maxSelectRecordsMessageSize {
+		return s.flushRecords()
+	}
+	return nil
+}
+
+// flushRecords - sends buffered records in a Records message.
+func (s *selectMessageWriter) flushRecords() error {
+	if s.records.Len() == 0 {
+		return nil
+	}
+	s.bytesReturned += int64(s.records.Len())
+	err := s.writeMessage([]eventStreamHeader{
+		{":event-type", "Records"},
+		{":content-type", "application/octet-stream"},
+		{":message-type", "event"},
+	}, s.records.Bytes())
+	s.records.Reset()
+	return err
+}
+
+// keepAlive - sends a Progress message, or a Cont message if progress
+// is not requested, when no message was sent for a while.
+func (s *selectMessageWriter) keepAlive(progress bool, bytesScanned, bytesProcessed int64) error {
+	if time.Since(s.lastMessage) < selectKeepAliveInterval {
+		return nil
+	}
+	if !progress {
+		return s.writeMessage([]eventStreamHeader{
+			{":event-type", "Cont"},
+			{":message-type", "event"},
+		}, nil)
+	}
+	payload, err := xml.Marshal(selectProgress{
+		BytesScanned:   bytesScanned,
+		BytesProcessed: bytesProcessed,
+		BytesReturned:  s.bytesReturned,
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeMessage([]eventStreamHeader{
+		{":event-type", "Progress"},
+		{":content-type", "text/xml"},
+		{":message-type", "event"},
+	}, payload)
+}
+
+// finish - sends remaining records, a Stats message and the End message.
+func (s *selectMessageWriter) finish(bytesScanned, bytesProcessed int64) error {
+	if err := s.flushRecords(); err != nil {
+		return err
+	}
+	payload, err := xml.Marshal(selectStats{
+		BytesScanned:   bytesScanned,
+		BytesProcessed: bytesProcessed,
+		BytesReturned:  s.bytesReturned,
+	})
+	if err != nil {
+		return err
+	}
+	if err = s.writeMessage([]eventStreamHeader{
+		{":event-type", "Stats"},
+		{":content-type", "text/xml"},
+		{":message-type", "event"},
+	}, payload); err != nil {
+		return err
+	}
+	return s.writeMessage([]eventStreamHeader{
+		{":event-type", "End"},
+		{":message-type", "event"},
+	}, nil)
+}
+
+// writeError - sends an error message, no more messages follow.
+func (s *selectMessageWriter) writeError(code, message string) error {
+	return s.writeMessage([]eventStreamHeader{
+		{":error-code", code},
+		{":error-message", message},
+		{":message-type", "error"},
+	}, nil)
+}
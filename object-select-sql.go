@@ -0,0 +1,1179 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SQL subset supported by select object content.
+//
+//   SELECT <projection> FROM S3Object [[AS] alias] [WHERE <condition>] [LIMIT <number>]
+//
+// Projection is either '*', a list of expressions with optional aliases
+// or a list of aggregates (COUNT, SUM, AVG, MIN, MAX). Columns are named
+// by header name, JSON key path or position (_1, _2, ...).
+
+// sqlTokenKind - kind of a lexical token.
+type sqlTokenKind int
+
+const (
+	sqlTokenEOF sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenOperator
+)
+
+// sqlToken - lexical token of a SQL expression.
+type sqlToken struct {
+	kind  sqlTokenKind
+	value string
+}
+
+// errSQLUnterminated - string or quoted identifier is not terminated.
+var errSQLUnterminated = errors.New("Unterminated string or quoted identifier in SQL expression")
+
+// tokenizeSQL - splits a SQL expression into tokens.
+func tokenizeSQL(expression string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			// Quotes are escaped by doubling them.
+			var value []rune
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == c {
+					if j+1 < len(runes) && runes[j+1] == c {
+						value = append(value, c)
+						j++
+						continue
+					}
+					break
+				}
+				value = append(value, runes[j])
+			}
+			if j >= len(runes) {
+				return nil, errSQLUnterminated
+			}
+			kind := sqlTokenString
+			if c == '"' {
+				kind = sqlTokenQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{kind, string(value)})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			// Exponent.
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				k := j + 1
+				if k < len(runes) && (runes[k] == '+' || runes[k] == '-') {
+					k++
+				}
+				if k < len(runes) && unicode.IsDigit(runes[k]) {
+					for j = k; j < len(runes) && unicode.IsDigit(runes[j]); j++ {
+					}
+				}
+			}
+			tokens = append(tokens, sqlToken{sqlTokenNumber, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, sqlToken{sqlTokenIdent, string(runes[i:j])})
+			i = j
+		default:
+			// Two character operators first.
+			if i+1 < len(runes) {
+				switch op := string(runes[i : i+2]); op {
+				case "<=", ">=", "<>", "!=":
+					tokens = append(tokens, sqlToken{sqlTokenOperator, op})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("*,().=<>+-/%", c) {
+				return nil, fmt.Errorf("Unexpected character '%c' in SQL expression", c)
+			}
+			tokens = append(tokens, sqlToken{sqlTokenOperator, string(c)})
+			i++
+		}
+	}
+	return append(tokens, sqlToken{kind: sqlTokenEOF}), nil
+}
+
+// sqlProjection - an expression in the select list.
+type sqlProjection struct {
+	expr sqlExpr
+	name string
+}
+
+// selectQuery - parsed select query.
+type selectQuery struct {
+	// Projections, empty for 'SELECT *'.
+	projections []sqlProjection
+	// Alias of S3Object in the FROM clause.
+	alias string
+	// Condition of the WHERE clause, nil if not set.
+	where sqlExpr
+	// Maximum number of records, -1 if not set.
+	limit int64
+	// Set if all projections are aggregates.
+	aggregate bool
+}
+
+// sqlParser - recursive descent parser of SQL expressions.
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+// parseSelectQuery - parses a SQL select expression.
+func parseSelectQuery(expression string) (*selectQuery, error) {
+	tokens, err := tokenizeSQL(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens}
+	return p.parseSelect()
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	token := p.tokens[p.pos]
+	if token.kind != sqlTokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// isKeyword - returns if the next token is the keyword.
+func (p *sqlParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token.kind == sqlTokenIdent && strings.EqualFold(token.value, keyword)
+}
+
+// isOperator - returns if the next token is the operator.
+func (p *sqlParser) isOperator(op string) bool {
+	token := p.peek()
+	return token.kind == sqlTokenOperator && token.value == op
+}
+
+// acceptKeyword - consumes the keyword if it is next.
+func (p *sqlParser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// acceptOperator - consumes the operator if it is next.
+func (p *sqlParser) acceptOperator(op string) bool {
+	if p.isOperator(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.unexpected(keyword)
+	}
+	return nil
+}
+
+func (p *sqlParser) expectOperator(op string) error {
+	if !p.acceptOperator(op) {
+		return p.unexpected("'" + op + "'")
+	}
+	return nil
+}
+
+// unexpected - error for an unexpected token.
+func (p *sqlParser) unexpected(expected string) error {
+	token := p.peek()
+	if token.kind == sqlTokenEOF {
+		return fmt.Errorf("Expected %s, but reached end of SQL expression", expected)
+	}
+	return fmt.Errorf("Expected %s, but found '%s'", expected, token.value)
+}
+
+// Reserved words which cannot be used as column names or aliases.
+var sqlReservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "BETWEEN": true,
+	"IN": true, "IS": true, "NULL": true, "TRUE": true, "FALSE": true,
+}
+
+// parseIdent - parses a plain or quoted identifier.
+func (p *sqlParser) parseIdent() (name string, quoted bool, err error) {
+	token := p.peek()
+	switch {
+	case token.kind == sqlTokenQuotedIdent:
+		p.pos++
+		return token.value, true, nil
+	case token.kind == sqlTokenIdent && !sqlReservedWords[strings.ToUpper(token.value)]:
+		p.pos++
+		return token.value, false, nil
+	}
+	return "", false, p.unexpected("identifier")
+}
+
+// parseAlias - parses an optional alias, AS is optional.
+func (p *sqlParser) parseAlias() (string, error) {
+	if !p.acceptKeyword("AS") {
+		token := p.peek()
+		if token.kind != sqlTokenQuotedIdent && (token.kind != sqlTokenIdent || sqlReservedWords[strings.ToUpper(token.value)]) {
+			return "", nil
+		}
+	}
+	alias, _, err := p.parseIdent()
+	return alias, err
+}
+
+func (p *sqlParser) parseSelect() (*selectQuery, error) {
+	query := &selectQuery{limit: -1}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if !p.acceptOperator("*") {
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			projection := sqlProjection{expr: expr}
+			if projection.name, err = p.parseAlias(); err != nil {
+				return nil, err
+			}
+			query.projections = append(query.projections, projection)
+			if !p.acceptOperator(",") {
+				break
+			}
+		}
+	}
+
+	// Either all or none of the projections are aggregates.
+	aggregates := 0
+	for _, projection := range query.projections {
+		if _, ok := projection.expr.(*sqlAggregate); ok {
+			aggregates++
+		} else if containsAggregate(projection.expr) {
+			return nil, errors.New("Aggregate functions cannot be nested in expressions")
+		}
+	}
+	if aggregates > 0 && aggregates != len(query.projections) {
+		return nil, errors.New("Aggregate and non-aggregate expressions cannot be mixed in the select list")
+	}
+	query.aggregate = aggregates > 0
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	source, _, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(source, "S3Object") {
+		return nil, fmt.Errorf("Expected S3Object, but found '%s'", source)
+	}
+	if query.alias, err = p.parseAlias(); err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if query.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if containsAggregate(query.where) {
+			return nil, errors.New("Aggregate functions cannot be used in the WHERE clause")
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		token := p.next()
+		limit, err := strconv.ParseInt(token.value, 10, 64)
+		if token.kind != sqlTokenNumber || err != nil || limit < 0 {
+			return nil, fmt.Errorf("Invalid LIMIT '%s'", token.value)
+		}
+		query.limit = limit
+	}
+
+	if p.peek().kind != sqlTokenEOF {
+		return nil, p.unexpected("end of SQL expression")
+	}
+	for _, projection := range query.projections {
+		setColumnAlias(projection.expr, query.alias)
+	}
+	setColumnAlias(query.where, query.alias)
+	return query, nil
+}
+
+func (p *sqlParser) parseExpr() (sqlExpr, error) {
+	return p.parseOr()
+}
+
+func (p *sqlParser) parseOr() (sqlExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlLogical{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlLogical{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.acceptKeyword("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlNot{expr: expr}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "!=", "<>", "<=", ">=", "<", ">"} {
+		if p.acceptOperator(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &sqlComparison{op: op, left: left, right: right}, nil
+		}
+	}
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if err = p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &sqlIsNull{expr: left, not: not}, nil
+	}
+	not := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("LIKE"):
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlLike{expr: left, pattern: pattern, not: not}, nil
+	case p.acceptKeyword("BETWEEN"):
+		lower, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		upper, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlBetween{expr: left, lower: lower, upper: upper, not: not}, nil
+	case p.acceptKeyword("IN"):
+		if err = p.expectOperator("("); err != nil {
+			return nil, err
+		}
+		in := &sqlIn{expr: left, not: not}
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, expr)
+			if !p.acceptOperator(",") {
+				break
+			}
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	case not:
+		return nil, p.unexpected("LIKE, BETWEEN or IN")
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+") || p.isOperator("-") {
+		op := p.next().value
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlArithmetic{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*") || p.isOperator("/") || p.isOperator("%") {
+		op := p.next().value
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlArithmetic{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, error) {
+	if p.acceptOperator("-") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlArithmetic{op: "-", left: &sqlLiteral{value: float64(0)}, right: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	token := p.peek()
+	switch token.kind {
+	case sqlTokenNumber:
+		p.pos++
+		value, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number '%s'", token.value)
+		}
+		return &sqlLiteral{value: value}, nil
+	case sqlTokenString:
+		p.pos++
+		return &sqlLiteral{value: token.value}, nil
+	case sqlTokenOperator:
+		if p.acceptOperator("(") {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expectOperator(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		}
+		return nil, p.unexpected("expression")
+	case sqlTokenIdent:
+		switch strings.ToUpper(token.value) {
+		case "NULL":
+			p.pos++
+			return &sqlLiteral{value: nil}, nil
+		case "TRUE":
+			p.pos++
+			return &sqlLiteral{value: true}, nil
+		case "FALSE":
+			p.pos++
+			return &sqlLiteral{value: false}, nil
+		}
+		if p.tokens[p.pos+1].kind == sqlTokenOperator && p.tokens[p.pos+1].value == "(" {
+			return p.parseFunction()
+		}
+	}
+	return p.parseColumn()
+}
+
+// parseColumn - parses a column reference, path elements are separated by '.'.
+func (p *sqlParser) parseColumn() (sqlExpr, error) {
+	column := &sqlColumn{}
+	for {
+		name, quoted, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		column.path = append(column.path, name)
+		column.quoted = append(column.quoted, quoted)
+		if !p.acceptOperator(".") {
+			return column, nil
+		}
+	}
+}
+
+// parseFunction - parses function calls, aggregates and CAST.
+func (p *sqlParser) parseFunction() (sqlExpr, error) {
+	name := strings.ToUpper(p.next().value)
+	p.pos++ // '('
+	var expr sqlExpr
+	var err error
+	switch name {
+	case "COUNT":
+		// COUNT(*) counts all records.
+		if !p.acceptOperator("*") {
+			if expr, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return &sqlAggregate{name: name, expr: expr}, nil
+	case "SUM", "AVG", "MIN", "MAX":
+		if expr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return &sqlAggregate{name: name, expr: expr}, nil
+	case "CAST":
+		if expr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AS"); err != nil {
+			return nil, err
+		}
+		typ := strings.ToUpper(p.next().value)
+		switch typ {
+		case "INT", "INTEGER", "FLOAT", "DECIMAL", "NUMERIC", "STRING", "VARCHAR", "CHAR", "BOOL", "BOOLEAN":
+		default:
+			return nil, fmt.Errorf("Unsupported CAST type '%s'", typ)
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return &sqlCast{expr: expr, typ: typ}, nil
+	case "LOWER", "UPPER", "CHAR_LENGTH", "CHARACTER_LENGTH":
+		if expr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return &sqlFunction{name: name, expr: expr}, nil
+	}
+	return nil, fmt.Errorf("Unsupported function '%s'", name)
+}
+
+// sqlExpr - evaluates to a value for a record, values are nil, bool,
+// float64, string or json values of JSON records.
+type sqlExpr interface {
+	eval(record *selectRecord) (interface{}, error)
+}
+
+// containsAggregate - returns if an expression contains aggregates.
+func containsAggregate(expr sqlExpr) bool {
+	found := false
+	walkSQLExpr(expr, func(e sqlExpr) {
+		if _, ok := e.(*sqlAggregate); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+// setColumnAlias - sets the alias of S3Object on all columns.
+func setColumnAlias(expr sqlExpr, alias string) {
+	walkSQLExpr(expr, func(e sqlExpr) {
+		if column, ok := e.(*sqlColumn); ok {
+			column.alias = alias
+		}
+	})
+}
+
+// walkSQLExpr - calls fn for the expression and all its sub expressions.
+func walkSQLExpr(expr sqlExpr, fn func(sqlExpr)) {
+	if expr == nil {
+		return
+	}
+	fn(expr)
+	switch e := expr.(type) {
+	case *sqlLogical:
+		walkSQLExpr(e.left, fn)
+		walkSQLExpr(e.right, fn)
+	case *sqlNot:
+		walkSQLExpr(e.expr, fn)
+	case *sqlComparison:
+		walkSQLExpr(e.left, fn)
+		walkSQLExpr(e.right, fn)
+	case *sqlArithmetic:
+		walkSQLExpr(e.left, fn)
+		walkSQLExpr(e.right, fn)
+	case *sqlIsNull:
+		walkSQLExpr(e.expr, fn)
+	case *sqlLike:
+		walkSQLExpr(e.expr, fn)
+		walkSQLExpr(e.pattern, fn)
+	case *sqlBetween:
+		walkSQLExpr(e.expr, fn)
+		walkSQLExpr(e.lower, fn)
+		walkSQLExpr(e.upper, fn)
+	case *sqlIn:
+		walkSQLExpr(e.expr, fn)
+		for _, item := range e.list {
+			walkSQLExpr(item, fn)
+		}
+	case *sqlCast:
+		walkSQLExpr(e.expr, fn)
+	case *sqlFunction:
+		walkSQLExpr(e.expr, fn)
+	case *sqlAggregate:
+		walkSQLExpr(e.expr, fn)
+	}
+}
+
+// sqlLiteral - constant value.
+type sqlLiteral struct {
+	value interface{}
+}
+
+func (e *sqlLiteral) eval(record *selectRecord) (interface{}, error) {
+	return e.value, nil
+}
+
+// sqlColumn - reference to a column of a record.
+type sqlColumn struct {
+	path   []string
+	quoted []bool
+	alias  string
+}
+
+// name - name of the column in select output.
+func (e *sqlColumn) name() string {
+	return e.path[len(e.path)-1]
+}
+
+func (e *sqlColumn) eval(record *selectRecord) (interface{}, error) {
+	path, quoted := e.path, e.quoted
+	// Columns may be qualified by the alias of S3Object.
+	if len(path) > 1 && e.alias != "" && strings.EqualFold(path[0], e.alias) {
+		path, quoted = path[1:], quoted[1:]
+	}
+	value, ok := record.get(path[0], quoted[0])
+	if !ok {
+		return nil, nil
+	}
+	for i := 1; i < len(path); i++ {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		if value, ok = lookupJSONKey(object, path[i], quoted[i]); !ok {
+			return nil, nil
+		}
+	}
+	return value, nil
+}
+
+// lookupJSONKey - unquoted names are matched case insensitively.
+func lookupJSONKey(object map[string]interface{}, name string, quoted bool) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	if !quoted {
+		for key, value := range object {
+			if strings.EqualFold(key, name) {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// sqlLogical - AND and OR with three valued logic.
+type sqlLogical struct {
+	op          string
+	left, right sqlExpr
+}
+
+func (e *sqlLogical) eval(record *selectRecord) (interface{}, error) {
+	left, err := e.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	lb, lok := left.(bool)
+	// Short circuit.
+	if lok && lb == (e.op == "OR") {
+		return lb, nil
+	}
+	right, err := e.right.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	rb, rok := right.(bool)
+	if rok && rb == (e.op == "OR") {
+		return rb, nil
+	}
+	if lok && rok {
+		return rb, nil
+	}
+	return nil, nil
+}
+
+// sqlNot - logical negation.
+type sqlNot struct {
+	expr sqlExpr
+}
+
+func (e *sqlNot) eval(record *selectRecord) (interface{}, error) {
+	value, err := e.expr.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := value.(bool); ok {
+		return !b, nil
+	}
+	return nil, nil
+}
+
+// sqlComparison - comparison operators.
+type sqlComparison struct {
+	op          string
+	left, right sqlExpr
+}
+
+func (e *sqlComparison) eval(record *selectRecord) (interface{}, error) {
+	left, err := e.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	cmp, ok := compareSQLValues(left, right)
+	if !ok {
+		return nil, nil
+	}
+	switch e.op {
+	case "=":
+		return cmp == 0, nil
+	case "!=", "<>":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+// sqlArithmetic - arithmetic operators.
+type sqlArithmetic struct {
+	op          string
+	left, right sqlExpr
+}
+
+func (e *sqlArithmetic) eval(record *selectRecord) (interface{}, error) {
+	left, err := e.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	l, lok := toSQLNumber(left)
+	r, rok := toSQLNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("Arithmetic on non-numeric values '%s' and '%s'", formatSQLValue(left), formatSQLValue(right))
+	}
+	switch e.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, errors.New("Division by zero")
+		}
+		return l / r, nil
+	}
+	if r == 0 {
+		return nil, errors.New("Division by zero")
+	}
+	return math.Mod(l, r), nil
+}
+
+// sqlIsNull - IS [NOT] NULL, missing values are null.
+type sqlIsNull struct {
+	expr sqlExpr
+	not  bool
+}
+
+func (e *sqlIsNull) eval(record *selectRecord) (interface{}, error) {
+	value, err := e.expr.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	return (value == nil) != e.not, nil
+}
+
+// sqlLike - [NOT] LIKE with '%' and '_' wildcards.
+type sqlLike struct {
+	expr, pattern sqlExpr
+	not           bool
+	// Compiled pattern of constant patterns.
+	re *regexp.Regexp
+}
+
+// compileLikePattern - converts a LIKE pattern to a regular expression.
+func compileLikePattern(pattern string) (*regexp.Regexp, error) {
+	var re bytes.Buffer
+	re.WriteString("(?s)^")
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+func (e *sqlLike) eval(record *selectRecord) (interface{}, error) {
+	value, err := e.expr.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	re := e.re
+	if re == nil {
+		pattern, err := e.pattern.eval(record)
+		if err != nil {
+			return nil, err
+		}
+		if pattern == nil {
+			return nil, nil
+		}
+		if re, err = compileLikePattern(formatSQLValue(pattern)); err != nil {
+			return nil, err
+		}
+		if _, ok := e.pattern.(*sqlLiteral); ok {
+			e.re = re
+		}
+	}
+	return re.MatchString(formatSQLValue(value)) != e.not, nil
+}
+
+// sqlBetween - [NOT] BETWEEN, bounds are inclusive.
+type sqlBetween struct {
+	expr, lower, upper sqlExpr
+	not                bool
+}
+
+func (e *sqlBetween) eval(record *selectRecord) (interface{}, error) {
+	values := make([]interface{}, 3)
+	for i, expr := range []sqlExpr{e.expr, e.lower, e.upper} {
+		value, err := expr.eval(record)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	lower, lok := compareSQLValues(values[0], values[1])
+	upper, uok := compareSQLValues(values[0], values[2])
+	if !lok || !uok {
+		return nil, nil
+	}
+	return (lower >= 0 && upper <= 0) != e.not, nil
+}
+
+// sqlIn - [NOT] IN a list of values.
+type sqlIn struct {
+	expr sqlExpr
+	list []sqlExpr
+	not  bool
+}
+
+func (e *sqlIn) eval(record *selectRecord) (interface{}, error) {
+	value, err := e.expr.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	for _, expr := range e.list {
+		item, err := expr.eval(record)
+		if err != nil {
+			return nil, err
+		}
+		if cmp, ok := compareSQLValues(value, item); ok && cmp == 0 {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+
+// sqlCast - CAST(expr AS type).
+type sqlCast struct {
+	expr sqlExpr
+	typ  string
+}
+
+func (e *sqlCast) eval(record *selectRecord) (interface{}, error) {
+	value, err := e.expr.eval(record)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	switch e.typ {
+	case "INT", "INTEGER", "FLOAT", "DECIMAL", "NUMERIC":
+		number, ok := toSQLNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("Unable to cast '%s' to %s", formatSQLValue(value), e.typ)
+		}
+		if e.typ == "INT" || e.typ == "INTEGER" {
+			number = math.Trunc(number)
+		}
+		return number, nil
+	case "BOOL", "BOOLEAN":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(formatSQLValue(value)))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to cast '%s' to %s", formatSQLValue(value), e.typ)
+		}
+		return b, nil
+	}
+	return formatSQLValue(value), nil
+}
+
+// sqlFunction - scalar string functions.
+type sqlFunction struct {
+	name string
+	expr sqlExpr
+}
+
+func (e *sqlFunction) eval(record *selectRecord) (interface{}, error) {
+	value, err := e.expr.eval(record)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(formatSQLValue(value)), nil
+	case "UPPER":
+		return strings.ToUpper(formatSQLValue(value)), nil
+	}
+	return float64(len([]rune(formatSQLValue(value)))), nil
+}
+
+// sqlAggregate - aggregate function, accumulates values of all
+// matching records.
+type sqlAggregate struct {
+	name string
+	// Argument, nil for COUNT(*).
+	expr  sqlExpr
+	count int64
+	sum   float64
+	value interface{}
+}
+
+// update - accumulates the value of a record.
+func (e *sqlAggregate) update(record *selectRecord) error {
+	if e.expr == nil {
+		e.count++
+		return nil
+	}
+	value, err := e.expr.eval(record)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+	switch e.name {
+	case "SUM", "AVG":
+		number, ok := toSQLNumber(value)
+		if !ok {
+			return fmt.Errorf("%s of non-numeric value '%s'", e.name, formatSQLValue(value))
+		}
+		e.sum += number
+	case "MIN", "MAX":
+		if e.value == nil {
+			e.value = value
+		} else if cmp, ok := compareSQLValues(value, e.value); ok && (cmp < 0) == (e.name == "MIN") && cmp != 0 {
+			e.value = value
+		}
+	}
+	e.count++
+	return nil
+}
+
+// eval - returns the aggregated value.
+func (e *sqlAggregate) eval(record *selectRecord) (interface{}, error) {
+	switch e.name {
+	case "COUNT":
+		return float64(e.count), nil
+	case "SUM":
+		if e.count == 0 {
+			return nil, nil
+		}
+		return e.sum, nil
+	case "AVG":
+		if e.count == 0 {
+			return nil, nil
+		}
+		return e.sum / float64(e.count), nil
+	}
+	return e.value, nil
+}
+
+// toSQLNumber - converts a value to a number, strings are parsed.
+func toSQLNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// isSQLNumber - returns if a value is a number.
+func isSQLNumber(value interface{}) bool {
+	switch value.(type) {
+	case float64, json.Number:
+		return true
+	}
+	return false
+}
+
+// compareSQLValues - compares two values, values are compared as
+// numbers if either of them is a number. Returns false if the values
+// cannot be compared.
+func compareSQLValues(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if isSQLNumber(a) || isSQLNumber(b) {
+		x, xok := toSQLNumber(a)
+		y, yok := toSQLNumber(b)
+		if xok && yok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		}
+		return 1, true
+	}
+	return strings.Compare(formatSQLValue(a), formatSQLValue(b)), true
+}
+
+// formatSQLValue - formats a value as text, JSON objects and arrays are
+// formatted as JSON.
+func formatSQLValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,607 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Maximum size of a select object content request.
+	maxSelectRequestSize = 1024 * 1024
+
+	// Maximum length of a SQL expression.
+	maxSelectExpressionLength = 256 * 1024
+)
+
+// CSVInput - format of CSV objects.
+type CSVInput struct {
+	FileHeaderInfo       string `xml:"FileHeaderInfo"`
+	RecordDelimiter      string `xml:"RecordDelimiter"`
+	FieldDelimiter       string `xml:"FieldDelimiter"`
+	QuoteCharacter       string `xml:"QuoteCharacter"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter"`
+	Comments             string `xml:"Comments"`
+}
+
+// JSONInput - format of JSON objects.
+type JSONInput struct {
+	Type string `xml:"Type"`
+}
+
+// InputSerialization - format of the object queried.
+type InputSerialization struct {
+	CompressionType string     `xml:"CompressionType"`
+	CSV             *CSVInput  `xml:"CSV"`
+	JSON            *JSONInput `xml:"JSON"`
+}
+
+// CSVOutput - format of CSV results.
+type CSVOutput struct {
+	QuoteFields          string `xml:"QuoteFields"`
+	RecordDelimiter      string `xml:"RecordDelimiter"`
+	FieldDelimiter       string `xml:"FieldDelimiter"`
+	QuoteCharacter       string `xml:"QuoteCharacter"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter"`
+}
+
+// JSONOutput - format of JSON results.
+type JSONOutput struct {
+	RecordDelimiter string `xml:"RecordDelimiter"`
+}
+
+// OutputSerialization - format of the results.
+type OutputSerialization struct {
+	CSV  *CSVOutput  `xml:"CSV"`
+	JSON *JSONOutput `xml:"JSON"`
+}
+
+// RequestProgress - enables periodic progress messages.
+type RequestProgress struct {
+	Enabled bool `xml:"Enabled"`
+}
+
+// SelectObjectContentRequest - select object content request.
+type SelectObjectContentRequest struct {
+	XMLName             xml.Name            `xml:"SelectObjectContentRequest"`
+	Expression          string              `xml:"Expression"`
+	ExpressionType      string              `xml:"ExpressionType"`
+	InputSerialization  InputSerialization  `xml:"InputSerialization"`
+	OutputSerialization OutputSerialization `xml:"OutputSerialization"`
+	RequestProgress     RequestProgress     `xml:"RequestProgress"`
+}
+
+// parseSelectRequest - parses and validates a select object content
+// request, defaults are set for all formatting options not set.
+func parseSelectRequest(reqBytes []byte) (*SelectObjectContentRequest, APIErrorCode) {
+	req := &SelectObjectContentRequest{}
+	if err := xml.Unmarshal(reqBytes, req); err != nil {
+		return nil, ErrMalformedXML
+	}
+	if req.Expression == "" {
+		return nil, ErrMissingSelectExpression
+	}
+	if len(req.Expression) > maxSelectExpressionLength {
+		return nil, ErrExpressionTooLong
+	}
+	if req.ExpressionType != "SQL" {
+		return nil, ErrInvalidExpressionType
+	}
+
+	input := &req.InputSerialization
+	switch input.CompressionType = strings.ToUpper(input.CompressionType); input.CompressionType {
+	case "":
+		input.CompressionType = "NONE"
+	case "NONE", "GZIP", "BZIP2":
+	default:
+		return nil, ErrInvalidCompressionFormat
+	}
+	if (input.CSV == nil) == (input.JSON == nil) {
+		return nil, ErrObjectSerializationConflict
+	}
+	if input.CSV != nil {
+		csvInput := input.CSV
+		switch csvInput.FileHeaderInfo = strings.ToUpper(csvInput.FileHeaderInfo); csvInput.FileHeaderInfo {
+		case "":
+			csvInput.FileHeaderInfo = "NONE"
+		case "NONE", "USE", "IGNORE":
+		default:
+			return nil, ErrInvalidFileHeaderInfo
+		}
+		if csvInput.RecordDelimiter == "" {
+			csvInput.RecordDelimiter = "\n"
+		}
+		if csvInput.FieldDelimiter == "" {
+			csvInput.FieldDelimiter = ","
+		}
+		// Only the standard quote character is supported.
+		if csvInput.QuoteCharacter == "" {
+			csvInput.QuoteCharacter = `"`
+		}
+		if csvInput.QuoteEscapeCharacter == "" {
+			csvInput.QuoteEscapeCharacter = csvInput.QuoteCharacter
+		}
+		if !isSelectRecordDelimiter(csvInput.RecordDelimiter) ||
+			utf8.RuneCountInString(csvInput.FieldDelimiter) != 1 ||
+			csvInput.QuoteCharacter != `"` || csvInput.QuoteEscapeCharacter != `"` ||
+			utf8.RuneCountInString(csvInput.Comments) > 1 {
+			return nil, ErrInvalidRequestParameter
+		}
+	} else {
+		switch input.JSON.Type = strings.ToUpper(input.JSON.Type); input.JSON.Type {
+		case "DOCUMENT", "LINES":
+		default:
+			return nil, ErrInvalidJSONType
+		}
+	}
+
+	output := &req.OutputSerialization
+	if (output.CSV == nil) == (output.JSON == nil) {
+		return nil, ErrObjectSerializationConflict
+	}
+	if output.CSV != nil {
+		csvOutput := output.CSV
+		switch csvOutput.QuoteFields = strings.ToUpper(csvOutput.QuoteFields); csvOutput.QuoteFields {
+		case "":
+			csvOutput.QuoteFields = "ASNEEDED"
+		case "ASNEEDED", "ALWAYS":
+		default:
+			return nil, ErrInvalidQuoteFields
+		}
+		if csvOutput.RecordDelimiter == "" {
+			csvOutput.RecordDelimiter = "\n"
+		}
+		if csvOutput.FieldDelimiter == "" {
+			csvOutput.FieldDelimiter = ","
+		}
+		if csvOutput.QuoteCharacter == "" {
+			csvOutput.QuoteCharacter = `"`
+		}
+		if csvOutput.QuoteEscapeCharacter == "" {
+			csvOutput.QuoteEscapeCharacter = csvOutput.QuoteCharacter
+		}
+	} else if output.JSON.RecordDelimiter == "" {
+		output.JSON.RecordDelimiter = "\n"
+	}
+	return req, ErrNone
+}
+
+// isSelectRecordDelimiter - record delimiters are newlines or a single
+// character.
+func isSelectRecordDelimiter(delimiter string) bool {
+	return delimiter == "\n" || delimiter == "\r\n" || len(delimiter) == 1
+}
+
+// selectError - error while running a select query, sent to the
+// client in an error message.
+type selectError struct {
+	code    string
+	message string
+}
+
+func (e selectError) Error() string {
+	return e.message
+}
+
+// selectRecord - record of a CSV or JSON object. Values are strings for
+// CSV objects and JSON values for JSON objects.
+type selectRecord struct {
+	// Column names, nil if records have no header.
+	names  []string
+	values []interface{}
+}
+
+// get - returns the value of a column by name or position, unquoted
+// names are matched case insensitively.
+func (r *selectRecord) get(name string, quoted bool) (interface{}, bool) {
+	for i, n := range r.names {
+		if n == name {
+			return r.values[i], true
+		}
+	}
+	if !quoted {
+		for i, n := range r.names {
+			if strings.EqualFold(n, name) {
+				return r.values[i], true
+			}
+		}
+	}
+	// Columns are named _1, _2, ... by position.
+	if strings.HasPrefix(name, "_") {
+		var position int
+		for _, c := range name[1:] {
+			if c < '0' || c > '9' {
+				return nil, false
+			}
+			position = position*10 + int(c-'0')
+			if position > len(r.values) {
+				return nil, false
+			}
+		}
+		if position > 0 {
+			return r.values[position-1], true
+		}
+	}
+	return nil, false
+}
+
+// selectRecordReader - reads records of an object, returns io.EOF
+// after the last record.
+type selectRecordReader interface {
+	Read() (*selectRecord, error)
+}
+
+// newSelectRecordReader - returns a record reader for the input format.
+func newSelectRecordReader(input InputSerialization, reader io.Reader) selectRecordReader {
+	if input.CSV != nil {
+		return newCSVRecordReader(*input.CSV, reader)
+	}
+	return newJSONRecordReader(reader)
+}
+
+// delimiterReader - replaces a single character record delimiter by
+// newlines.
+type delimiterReader struct {
+	reader    io.Reader
+	delimiter byte
+}
+
+func (d *delimiterReader) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == d.delimiter {
+			p[i] = '\n'
+		}
+	}
+	return n, err
+}
+
+// csvRecordReader - reads records of CSV objects.
+type csvRecordReader struct {
+	reader *csv.Reader
+	// Set if the first line still has to be read.
+	header         bool
+	fileHeaderInfo string
+	names          []string
+}
+
+func newCSVRecordReader(args CSVInput, reader io.Reader) *csvRecordReader {
+	if args.RecordDelimiter != "\n" && args.RecordDelimiter != "\r\n" {
+		reader = &delimiterReader{reader: reader, delimiter: args.RecordDelimiter[0]}
+	}
+	csvReader := csv.NewReader(bufio.NewReader(reader))
+	csvReader.Comma, _ = utf8.DecodeRuneInString(args.FieldDelimiter)
+	if args.Comments != "" {
+		csvReader.Comment, _ = utf8.DecodeRuneInString(args.Comments)
+	}
+	csvReader.FieldsPerRecord = -1
+	return &csvRecordReader{
+		reader:         csvReader,
+		header:         args.FileHeaderInfo != "NONE",
+		fileHeaderInfo: args.FileHeaderInfo,
+	}
+}
+
+func (c *csvRecordReader) readLine() ([]string, error) {
+	fields, err := c.reader.Read()
+	if err != nil {
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			return nil, selectError{"CSVParsingError", parseErr.Error()}
+		}
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (c *csvRecordReader) Read() (*selectRecord, error) {
+	if c.header {
+		c.header = false
+		names, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if c.fileHeaderInfo == "USE" {
+			c.names = names
+		}
+	}
+	fields, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		values[i] = field
+	}
+	return &selectRecord{names: c.names, values: values}, nil
+}
+
+// jsonRecordReader - reads records of JSON objects, every top level
+// JSON object is a record.
+type jsonRecordReader struct {
+	decoder *json.Decoder
+}
+
+func newJSONRecordReader(reader io.Reader) *jsonRecordReader {
+	return &jsonRecordReader{decoder: json.NewDecoder(bufio.NewReader(reader))}
+}
+
+// jsonParsingError - converts JSON syntax errors to select errors.
+func jsonParsingError(err error) error {
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return selectError{"JSONParsingError", err.Error()}
+	}
+	if err == io.ErrUnexpectedEOF {
+		return selectError{"JSONParsingError", "Unexpected end of JSON input"}
+	}
+	return err
+}
+
+func (j *jsonRecordReader) Read() (*selectRecord, error) {
+	var raw json.RawMessage
+	if err := j.decoder.Decode(&raw); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, jsonParsingError(err)
+	}
+
+	// Keys are read one by one to keep their order.
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, selectError{"JSONParsingError", "JSON records have to be objects"}
+	}
+	record := &selectRecord{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, jsonParsingError(err)
+		}
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			return nil, jsonParsingError(err)
+		}
+		record.names = append(record.names, token.(string))
+		record.values = append(record.values, value)
+	}
+	return record, nil
+}
+
+// selectField - field of a result record.
+type selectField struct {
+	name  string
+	value interface{}
+}
+
+// formatSelectRecord - formats a result record in the output format.
+func formatSelectRecord(output OutputSerialization, fields []selectField) ([]byte, error) {
+	var buf bytes.Buffer
+	if output.CSV != nil {
+		args := output.CSV
+		for i, field := range fields {
+			if i > 0 {
+				buf.WriteString(args.FieldDelimiter)
+			}
+			value := formatSQLValue(field.value)
+			if args.QuoteFields == "ALWAYS" || strings.Contains(value, args.FieldDelimiter) ||
+				strings.Contains(value, args.QuoteCharacter) || strings.ContainsAny(value, "\r\n") ||
+				strings.Contains(value, args.RecordDelimiter) {
+				value = args.QuoteCharacter + strings.Replace(value, args.QuoteCharacter, args.QuoteEscapeCharacter+args.QuoteCharacter, -1) + args.QuoteCharacter
+			}
+			buf.WriteString(value)
+		}
+		buf.WriteString(args.RecordDelimiter)
+		return buf.Bytes(), nil
+	}
+
+	// Missing values are left out of JSON records.
+	buf.WriteByte('{')
+	first := true
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	buf.WriteString(output.JSON.RecordDelimiter)
+	return buf.Bytes(), nil
+}
+
+// projectRecord - evaluates the select list for a record.
+func projectRecord(query *selectQuery, record *selectRecord) ([]selectField, error) {
+	if len(query.projections) == 0 {
+		fields := make([]selectField, len(record.values))
+		for i, value := range record.values {
+			fields[i].value = value
+			if i < len(record.names) {
+				fields[i].name = record.names[i]
+			} else {
+				fields[i].name = "_" + strconv.Itoa(i+1)
+			}
+		}
+		return fields, nil
+	}
+	fields := make([]selectField, len(query.projections))
+	for i, projection := range query.projections {
+		value, err := projection.expr.eval(record)
+		if err != nil {
+			return nil, selectError{"EvaluatorInvalidArguments", err.Error()}
+		}
+		fields[i].value = value
+		switch {
+		case projection.name != "":
+			fields[i].name = projection.name
+		case isSQLColumn(projection.expr):
+			fields[i].name = projection.expr.(*sqlColumn).name()
+		default:
+			fields[i].name = "_" + strconv.Itoa(i+1)
+		}
+	}
+	return fields, nil
+}
+
+func isSQLColumn(expr sqlExpr) bool {
+	_, ok := expr.(*sqlColumn)
+	return ok
+}
+
+// countReader - counts bytes read.
+type countReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decompressReader - returns errors of corrupted compressed objects as
+// select errors.
+type decompressReader struct {
+	reader io.Reader
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	switch err.(type) {
+	case bzip2.StructuralError, flate.CorruptInputError:
+		return n, selectError{"InvalidCompressionFormat", err.Error()}
+	}
+	if err == gzip.ErrChecksum || err == gzip.ErrHeader || err == io.ErrUnexpectedEOF {
+		return n, selectError{"InvalidCompressionFormat", err.Error()}
+	}
+	return n, err
+}
+
+// newDecompressReader - decompresses objects of the compression type.
+func newDecompressReader(compressionType string, reader io.Reader) (io.Reader, error) {
+	switch compressionType {
+	case "GZIP":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			if err == gzip.ErrHeader || err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, selectError{"InvalidCompressionFormat", "The object is not GZIP compressed."}
+			}
+			return nil, err
+		}
+		return &decompressReader{gzipReader}, nil
+	case "BZIP2":
+		return &decompressReader{bzip2.NewReader(reader)}, nil
+	}
+	return reader, nil
+}
+
+// runSelect - runs a select query on an object and streams the results.
+// Compressed objects are decompressed, scanned bytes are counted before
+// and processed bytes after decompression.
+func runSelect(req *SelectObjectContentRequest, query *selectQuery, object io.Reader, messages *selectMessageWriter) error {
+	scanned := &countReader{Reader: object}
+	reader, err := newDecompressReader(req.InputSerialization.CompressionType, scanned)
+	if err != nil {
+		return err
+	}
+	processed := &countReader{Reader: reader}
+	records := newSelectRecordReader(req.InputSerialization, processed)
+
+	var matched int64
+	for query.limit < 0 || matched < query.limit {
+		if err := messages.keepAlive(req.RequestProgress.Enabled, scanned.n, processed.n); err != nil {
+			return err
+		}
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if query.where != nil {
+			ok, err := query.where.eval(record)
+			if err != nil {
+				return selectError{"EvaluatorInvalidArguments", err.Error()}
+			}
+			if ok != true {
+				continue
+			}
+		}
+		matched++
+		if query.aggregate {
+			for _, projection := range query.projections {
+				if err = projection.expr.(*sqlAggregate).update(record); err != nil {
+					return selectError{"EvaluatorInvalidArguments", err.Error()}
+				}
+			}
+			continue
+		}
+		fields, err := projectRecord(query, record)
+		if err != nil {
+			return err
+		}
+		data, err := formatSelectRecord(req.OutputSerialization, fields)
+		if err != nil {
+			return err
+		}
+		if err = messages.writeRecord(data); err != nil {
+			return err
+		}
+	}
+
+	// Aggregates result in a single record.
+	if query.aggregate {
+		fields, err := projectRecord(query, nil)
+		if err != nil {
+			return err
+		}
+		data, err := formatSelectRecord(req.OutputSerialization, fields)
+		if err != nil {
+			return err
+		}
+		if err = messages.writeRecord(data); err != nil {
+			return err
+		}
+	}
+	return messages.finish(scanned.n, processed.n)
+}
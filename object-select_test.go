@@ -0,0 +1,355 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+)
+
+// selectTestMessage - decoded event stream message.
+type selectTestMessage struct {
+	headers map[string]string
+	payload []byte
+}
+
+// readSelectMessages - decodes all event stream messages, checksums
+// are verified.
+func readSelectMessages(reader io.Reader) ([]selectTestMessage, error) {
+	var messages []selectTestMessage
+	for {
+		prelude := make([]byte, 12)
+		if _, err := io.ReadFull(reader, prelude); err != nil {
+			if err == io.EOF {
+				return messages, nil
+			}
+			return nil, err
+		}
+		totalLength := binary.BigEndian.Uint32(prelude[0:4])
+		headersLength := binary.BigEndian.Uint32(prelude[4:8])
+		if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+			return nil, errors.New("prelude checksum mismatch")
+		}
+		rest := make([]byte, totalLength-12)
+		if _, err := io.ReadFull(reader, rest); err != nil {
+			return nil, err
+		}
+		message := append(prelude, rest...)
+		if crc32.ChecksumIEEE(message[:totalLength-4]) != binary.BigEndian.Uint32(message[totalLength-4:]) {
+			return nil, errors.New("message checksum mismatch")
+		}
+		headers := make(map[string]string)
+		headerBytes := rest[:headersLength]
+		for len(headerBytes) > 0 {
+			nameLength := int(headerBytes[0])
+			name := string(headerBytes[1 : 1+nameLength])
+			valueLength := int(binary.BigEndian.Uint16(headerBytes[2+nameLength:]))
+			headers[name] = string(headerBytes[4+nameLength : 4+nameLength+valueLength])
+			headerBytes = headerBytes[4+nameLength+valueLength:]
+		}
+		messages = append(messages, selectTestMessage{headers, rest[headersLength : len(rest)-4]})
+	}
+}
+
+// selectTestRecords - returns records of all Records messages or the
+// error code of an error message.
+func selectTestRecords(messages []selectTestMessage) (records string, errCode string) {
+	for _, message := range messages {
+		if message.headers[":message-type"] == "error" {
+			return records, message.headers[":error-code"]
+		}
+		if message.headers[":event-type"] == "Records" {
+			records += string(message.payload)
+		}
+	}
+	return records, ""
+}
+
+// Tests parsing of SQL expressions.
+func TestParseSelectQuery(t *testing.T) {
+	testCases := []struct {
+		expression string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{"SELECT * FROM S3Object", true},
+		// Test case - 2.
+		{"select s._1, s._2 AS second from s3object s where s._1 = 'a' limit 10", true},
+		// Test case - 3.
+		{"SELECT COUNT(*), SUM(CAST(price AS FLOAT)), MAX(price) FROM S3Object", true},
+		// Test case - 4.
+		{`SELECT "first name" FROM S3Object WHERE age BETWEEN 18 AND 65 AND city IN ('Paris', 'Rome') AND name NOT LIKE 'A%'`, true},
+		// Test case - 5.
+		{"SELECT a.b.c FROM S3Object WHERE a.b IS NOT NULL OR NOT (x + 1) * 2 >= -3", true},
+		// Test case - 6.
+		// Missing FROM.
+		{"SELECT *", false},
+		// Test case - 7.
+		// Unknown table.
+		{"SELECT * FROM table", false},
+		// Test case - 8.
+		// Aggregates mixed with columns.
+		{"SELECT name, COUNT(*) FROM S3Object", false},
+		// Test case - 9.
+		// Aggregates in WHERE clause.
+		{"SELECT * FROM S3Object WHERE COUNT(*) > 1", false},
+		// Test case - 10.
+		// Unterminated string.
+		{"SELECT * FROM S3Object WHERE name = 'abc", false},
+		// Test case - 11.
+		// Invalid LIMIT.
+		{"SELECT * FROM S3Object LIMIT -1", false},
+		// Test case - 12.
+		// Trailing tokens.
+		{"SELECT * FROM S3Object s t", false},
+		// Test case - 13.
+		// Unsupported function.
+		{"SELECT SUBSTRING(name, 1, 2) FROM S3Object", false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseSelectQuery(testCase.expression)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// Tests validation of select requests.
+func TestParseSelectRequest(t *testing.T) {
+	request := func(expressionType, input, output string) []byte {
+		return []byte("<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression>" +
+			"<ExpressionType>" + expressionType + "</ExpressionType>" +
+			"<InputSerialization>" + input + "</InputSerialization>" +
+			"<OutputSerialization>" + output + "</OutputSerialization></SelectObjectContentRequest>")
+	}
+	testCases := []struct {
+		reqBytes     []byte
+		expectedCode APIErrorCode
+	}{
+		// Test case - 1.
+		{request("SQL", "<CSV/>", "<CSV/>"), ErrNone},
+		// Test case - 2.
+		{request("SQL", "<CompressionType>NONE</CompressionType><JSON><Type>LINES</Type></JSON>", "<JSON/>"), ErrNone},
+		// Test case - 3.
+		{request("XPATH", "<CSV/>", "<CSV/>"), ErrInvalidExpressionType},
+		// Test case - 4.
+		{request("SQL", "<CompressionType>ZIP</CompressionType><CSV/>", "<CSV/>"), ErrInvalidCompressionFormat},
+		// Test case - 5.
+		{request("SQL", "<CompressionType>gzip</CompressionType><CSV/>", "<CSV/>"), ErrNone},
+		// Test case - 6.
+		{request("SQL", "<CSV/><JSON><Type>LINES</Type></JSON>", "<CSV/>"), ErrObjectSerializationConflict},
+		// Test case - 7.
+		{request("SQL", "<CSV/>", ""), ErrObjectSerializationConflict},
+		// Test case - 8.
+		{request("SQL", "<CSV><FileHeaderInfo>FIRST</FileHeaderInfo></CSV>", "<CSV/>"), ErrInvalidFileHeaderInfo},
+		// Test case - 9.
+		{request("SQL", "<JSON><Type>ARRAY</Type></JSON>", "<CSV/>"), ErrInvalidJSONType},
+		// Test case - 10.
+		{request("SQL", "<CSV/>", "<CSV><QuoteFields>NEVER</QuoteFields></CSV>"), ErrInvalidQuoteFields},
+		// Test case - 11.
+		{request("SQL", "<CSV><FieldDelimiter>ab</FieldDelimiter></CSV>", "<CSV/>"), ErrInvalidRequestParameter},
+		// Test case - 12.
+		{[]byte("<SelectObjectContentRequest><ExpressionType>SQL</ExpressionType></SelectObjectContentRequest>"), ErrMissingSelectExpression},
+		// Test case - 13.
+		{[]byte("<SelectObjectContentRequest>"), ErrMalformedXML},
+	}
+	for i, testCase := range testCases {
+		_, errCode := parseSelectRequest(testCase.reqBytes)
+		if errCode != testCase.expectedCode {
+			t.Errorf("Test %d: Expected the APIErrCode to be %d, but instead found %d", i+1, testCase.expectedCode, errCode)
+		}
+	}
+}
+
+// Tests running select queries on CSV and JSON objects.
+func TestRunSelect(t *testing.T) {
+	csvObject := "name,age,city\nalice,31,Paris\nbob,25,\"Rome, Italy\"\ncarol,42,Berlin\n"
+	jsonObject := `{"name":"alice","age":31,"address":{"city":"Paris"}}
+{"name":"bob","age":25,"address":{"city":"Rome"}}
+{"name":"carol","age":42,"address":{"city":"Berlin"}}
+`
+	csvInput := "<CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>"
+	jsonInput := "<JSON><Type>LINES</Type></JSON>"
+
+	testCases := []struct {
+		object          string
+		input           string
+		output          string
+		expression      string
+		expectedRecords string
+		expectedErrCode string
+	}{
+		// Test case - 1.
+		{csvObject, csvInput, "<CSV/>", "SELECT * FROM S3Object", "alice,31,Paris\nbob,25,\"Rome, Italy\"\ncarol,42,Berlin\n", ""},
+		// Test case - 2.
+		// Header is read as a record.
+		{csvObject, "<CSV/>", "<CSV/>", "SELECT _1 FROM S3Object LIMIT 2", "name\nalice\n", ""},
+		// Test case - 3.
+		// Header is skipped.
+		{csvObject, "<CSV><FileHeaderInfo>IGNORE</FileHeaderInfo></CSV>", "<CSV/>", "SELECT s._1 FROM S3Object s WHERE s._2 > 30", "alice\ncarol\n", ""},
+		// Test case - 4.
+		{csvObject, csvInput, "<JSON/>", "SELECT name, CAST(age AS INT) + 1 FROM S3Object WHERE city LIKE 'R%'", "{\"name\":\"bob\",\"_2\":26}\n", ""},
+		// Test case - 5.
+		{csvObject, csvInput, "<CSV/>", "SELECT COUNT(*), SUM(age), AVG(age), MIN(name), MAX(age) FROM S3Object", "3,98,32.666666666666664,alice,42\n", ""},
+		// Test case - 6.
+		{csvObject, csvInput, "<CSV><FieldDelimiter>;</FieldDelimiter><QuoteFields>ALWAYS</QuoteFields></CSV>", "SELECT UPPER(name) AS n, age FROM S3Object WHERE name IN ('alice', 'carol') AND age BETWEEN 40 AND 50", "\"CAROL\";\"42\"\n", ""},
+		// Test case - 7.
+		{jsonObject, jsonInput, "<JSON/>", "SELECT * FROM S3Object s WHERE s.address.city = 'Rome'", "{\"name\":\"bob\",\"age\":25,\"address\":{\"city\":\"Rome\"}}\n", ""},
+		// Test case - 8.
+		{jsonObject, jsonInput, "<CSV/>", "SELECT s.name, s.address.city FROM S3Object s WHERE s.age < 40 AND s.missing IS NULL", "alice,Paris\nbob,Rome\n", ""},
+		// Test case - 9.
+		{jsonObject, "<JSON><Type>DOCUMENT</Type></JSON>", "<JSON/>", "SELECT COUNT(name) AS c, MAX(age) AS oldest FROM S3Object WHERE NOT name = 'carol'", "{\"c\":2,\"oldest\":31}\n", ""},
+		// Test case - 10.
+		// Missing values are left out of JSON records.
+		{jsonObject, jsonInput, "<JSON/>", "SELECT name, zip FROM S3Object LIMIT 1", "{\"name\":\"alice\"}\n", ""},
+		// Test case - 11.
+		{"{\"name\": \"alice\"}\n{\"name\": ", jsonInput, "<CSV/>", "SELECT * FROM S3Object", "", "JSONParsingError"},
+		// Test case - 12.
+		{"a,\"b\nc", "<CSV/>", "<CSV/>", "SELECT * FROM S3Object", "", "CSVParsingError"},
+		// Test case - 13.
+		{csvObject, csvInput, "<CSV/>", "SELECT name FROM S3Object WHERE name + 1 > 0", "", "EvaluatorInvalidArguments"},
+		// Test case - 14.
+		// Custom record delimiter.
+		{"a,1|b,2|c,3", "<CSV><RecordDelimiter>|</RecordDelimiter></CSV>", "<CSV><RecordDelimiter>|</RecordDelimiter></CSV>", "SELECT _1 FROM S3Object WHERE _2 <> 2", "a|c|", ""},
+	}
+	for i, testCase := range testCases {
+		reqBytes := []byte("<SelectObjectContentRequest><Expression>" + testCase.expression + "</Expression>" +
+			"<ExpressionType>SQL</ExpressionType>" +
+			"<InputSerialization>" + testCase.input + "</InputSerialization>" +
+			"<OutputSerialization>" + testCase.output + "</OutputSerialization></SelectObjectContentRequest>")
+		reqBytes = bytes.Replace(reqBytes, []byte("<>"), []byte("&lt;&gt;"), -1)
+		reqBytes = bytes.Replace(reqBytes, []byte(" < "), []byte(" &lt; "), -1)
+		req, errCode := parseSelectRequest(reqBytes)
+		if errCode != ErrNone {
+			t.Fatalf("Test %d: Unexpected APIErrCode %d", i+1, errCode)
+		}
+		query, err := parseSelectQuery(req.Expression)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		buffer := new(bytes.Buffer)
+		err = runSelect(req, query, strings.NewReader(testCase.object), newSelectMessageWriter(buffer))
+		if selectErr, ok := err.(selectError); ok {
+			newSelectMessageWriter(buffer).writeError(selectErr.code, selectErr.message)
+		} else if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		messages, err := readSelectMessages(buffer)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		records, selectErrCode := selectTestRecords(messages)
+		if selectErrCode != testCase.expectedErrCode {
+			t.Errorf("Test %d: Expected error code '%s', but instead found '%s'", i+1, testCase.expectedErrCode, selectErrCode)
+		}
+		if records != testCase.expectedRecords {
+			t.Errorf("Test %d: Expected records %q, but instead found %q", i+1, testCase.expectedRecords, records)
+		}
+		if selectErrCode == "" && messages[len(messages)-1].headers[":event-type"] != "End" {
+			t.Errorf("Test %d: Expected End message", i+1)
+		}
+	}
+}
+
+// Tests running select queries on GZIP and BZIP2 compressed objects.
+func TestRunSelectCompressed(t *testing.T) {
+	csvObject := "name,age\nalice,31\nbob,25\ncarol,42\n"
+	var gzipObject bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipObject)
+	gzipWriter.Write([]byte(csvObject))
+	gzipWriter.Close()
+	// Compressed with bzip2, there is no bzip2 writer in the standard library.
+	bzip2Object, err := base64.StdEncoding.DecodeString("QlpoOTFBWSZTWdFqsA4AAA9ZgAAQAAQ+ADqnkAAgACImmRp6jJkFNMjExMTaNgLB6AtkxL0hTK1UNcm75Pi7kinChIaLVYBw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		object          []byte
+		compressionType string
+		expectedRecords string
+		expectedErrCode string
+	}{
+		// Test case - 1.
+		{gzipObject.Bytes(), "GZIP", "alice\ncarol\n", ""},
+		// Test case - 2.
+		{bzip2Object, "BZIP2", "alice\ncarol\n", ""},
+		// Test case - 3.
+		// Object is not compressed.
+		{[]byte(csvObject), "GZIP", "", "InvalidCompressionFormat"},
+		// Test case - 4.
+		{[]byte(csvObject), "BZIP2", "", "InvalidCompressionFormat"},
+		// Test case - 5.
+		// Truncated object.
+		{gzipObject.Bytes()[:gzipObject.Len()-10], "GZIP", "", "InvalidCompressionFormat"},
+	}
+	for i, testCase := range testCases {
+		reqBytes := []byte("<SelectObjectContentRequest><Expression>SELECT name FROM S3Object WHERE age &gt; 30</Expression>" +
+			"<ExpressionType>SQL</ExpressionType>" +
+			"<InputSerialization><CompressionType>" + testCase.compressionType + "</CompressionType>" +
+			"<CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>" +
+			"<OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>")
+		req, errCode := parseSelectRequest(reqBytes)
+		if errCode != ErrNone {
+			t.Fatalf("Test %d: Unexpected APIErrCode %d", i+1, errCode)
+		}
+		query, err := parseSelectQuery(req.Expression)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		buffer := new(bytes.Buffer)
+		err = runSelect(req, query, bytes.NewReader(testCase.object), newSelectMessageWriter(buffer))
+		if selectErr, ok := err.(selectError); ok {
+			newSelectMessageWriter(buffer).writeError(selectErr.code, selectErr.message)
+		} else if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		messages, err := readSelectMessages(buffer)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		records, selectErrCode := selectTestRecords(messages)
+		if selectErrCode != testCase.expectedErrCode {
+			t.Errorf("Test %d: Expected error code '%s', but instead found '%s'", i+1, testCase.expectedErrCode, selectErrCode)
+		}
+		if selectErrCode != "" {
+			continue
+		}
+		if records != testCase.expectedRecords {
+			t.Errorf("Test %d: Expected records %q, but instead found %q", i+1, testCase.expectedRecords, records)
+		}
+		// Processed bytes are counted after decompression.
+		stats := selectStats{}
+		if err = xml.Unmarshal(messages[len(messages)-2].payload, &stats); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if stats.BytesScanned != int64(len(testCase.object)) || stats.BytesProcessed != int64(len(csvObject)) {
+			t.Errorf("Test %d: Unexpected stats %+v", i+1, stats)
+		}
+	}
+}
@@ -21,8 +21,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path"
-	"regexp"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/skyrings/skyring-common/tools/uuid"
@@ -35,23 +35,44 @@ const (
 	mpartMetaPrefix = "multipart"
 	// Tmp meta prefix.
 	tmpMetaPrefix = "tmp"
+	// Bucket meta prefix.
+	bucketMetaPrefix = "buckets"
+	// Internal metadata key of the modification time of a new object,
+	// set only by importing objects, never from request headers.
+	objectModTimeKey = "modTime"
 )
 
-// validBucket regexp.
-var validBucket = regexp.MustCompile(`^[a-z0-9][a-z0-9\.\-]{1,61}[a-z0-9]$`)
+// getObjectModTime - returns the requested modification time of a new
+// object and removes it from metadata.
+func getObjectModTime(metadata map[string]string) (time.Time, bool) {
+	value, ok := metadata[objectModTimeKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	delete(metadata, objectModTimeKey)
+	modTime, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return modTime.UTC(), true
+}
 
 // IsValidBucketName verifies a bucket name in accordance with Amazon's
 // requirements. It must be 3-63 characters long, can contain dashes
 // and periods, but must begin and end with a lowercase letter or a number.
+// Labels separated by periods cannot start or end with a dash and names
+// cannot look like IP addresses. Legacy names are checked by the former
+// US Standard region rules, names reserved by the server are invalid in
+// both modes.
 // See: http://docs.aws.amazon.com/AmazonS3/latest/dev/BucketRestrictions.html
 func IsValidBucketName(bucket string) bool {
-	if len(bucket) < 3 || len(bucket) > 63 {
+	if isReservedBucketName(bucket) {
 		return false
 	}
-	if bucket[0] == '.' || bucket[len(bucket)-1] == '.' {
-		return false
+	if getBucketNamesMode() == bucketNamesLegacy {
+		return validLegacyBucket.MatchString(bucket)
 	}
-	return validBucket.MatchString(bucket)
+	return validBucket.MatchString(bucket) && isDNSBucketName(bucket)
 }
 
 // IsValidObjectName verifies an object name in accordance with Amazon's
@@ -72,7 +93,9 @@ func IsValidBucketName(bucket string) bool {
 // - Grave accent / back tick ("`")
 // - Vertical bar / pipe ("|")
 //
-// Minio does not support object names with trailing "/".
+// Minio does not support object names with trailing "/". Strict
+// object names have to be valid on windows and cannot have control
+// characters, lenient object names may have any characters.
 func IsValidObjectName(object string) bool {
 	if len(object) == 0 {
 		return false
@@ -83,7 +106,13 @@ func IsValidObjectName(object string) bool {
 	if strings.HasPrefix(object, slashSeparator) {
 		return false
 	}
-	return IsValidObjectPrefix(object)
+	if !IsValidObjectPrefix(object) {
+		return false
+	}
+	if getObjectNamesMode() == objectNamesStrict {
+		return isStrictObjectName(object)
+	}
+	return true
 }
 
 // IsValidObjectPrefix verifies whether the prefix is a valid object name.
@@ -96,7 +125,7 @@ func IsValidObjectPrefix(object string) bool {
 		return false
 	}
 	// Reject unsupported characters in object name.
-	if strings.ContainsAny(object, "`^*|\\\"") {
+	if getObjectNamesMode() != objectNamesLenient && strings.ContainsAny(object, "`^*|\\\"") {
 		return false
 	}
 	return true
@@ -60,6 +60,11 @@ func TestIsValidBucketName(t *testing.T) {
 		{"ThisBeginsAndEndsWithUpperCase", false},
 		{"una ñina", false},
 		{"lalalallalallalalalallalallalala-theString-size-is-greater-than-64", false},
+		{"192.168.5.4", false},
+		{"two..dots", false},
+		{"label.-starts-with-a-dash", false},
+		{"label-.ends-with-a-dash", false},
+		{"minio", false},
 	}
 
 	for i, testCase := range testCases {
@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"path"
+	"strings"
+)
+
+// getObjectContent - writes the plain content of an object to writer,
+// encrypted and compressed objects are transparently decoded. objInfo
+// is updated to the plain size of the object.
+func getObjectContent(ctx context.Context, objAPI ObjectLayer, bucket string, objInfo *ObjectInfo, writer io.Writer) error {
+	return getObjectContentRange(ctx, objAPI, bucket, objInfo, 0, -1, writer)
+}
+
+// getObjectContentRange - writes length bytes of the plain content of
+// an object starting at offset to writer, a negative length or one
+// beyond the end writes up to the end. objInfo is updated to the plain
+// size of the object.
+func getObjectContentRange(ctx context.Context, objAPI ObjectLayer, bucket string, objInfo *ObjectInfo, offset, length int64, writer io.Writer) error {
+	sseInfo, encrypted, err := getObjectEncryption(bucket, objInfo)
+	if err != nil {
+		return err
+	}
+	var compInfo objectCompressionInfo
+	var compressed bool
+	if !encrypted {
+		if compInfo, compressed, err = getObjectCompression(bucket, objInfo); err != nil {
+			return err
+		}
+	}
+	if offset >= objInfo.Size {
+		return nil
+	}
+	if length < 0 || offset+length > objInfo.Size {
+		length = objInfo.Size - offset
+	}
+	if encrypted {
+		objectKey, err := sseInfo.unsealKey(bucket)
+		if err != nil {
+			return err
+		}
+		return getDecryptedObject(ctx, objAPI, bucket, objInfo.Name, sseInfo, objectKey, offset, length, writer)
+	}
+	if compressed {
+		return getCompressedObject(ctx, objAPI, bucket, objInfo.Name, compInfo, offset, length, writer)
+	}
+	return objAPI.GetObject(ctx, bucket, objInfo.Name, offset, length, writer)
+}
+
+// zipArchiveName - returns the name of the zip archive of all objects
+// under prefix.
+func zipArchiveName(bucket, prefix string) string {
+	if prefix = strings.TrimSuffix(prefix, slashSeparator); prefix == "" {
+		return bucket + ".zip"
+	}
+	return path.Base(prefix) + ".zip"
+}
+
+// writeZipArchive - streams a zip archive of all objects under prefix
+// to writer. Objects are added as they are listed, relative to the
+// parent directory of prefix, nothing is staged on disk.
+func writeZipArchive(ctx context.Context, objAPI ObjectLayer, bucket, prefix string, writer io.Writer) error {
+	// Archive entries keep the last directory of the prefix.
+	parent := ""
+	if i := strings.LastIndex(strings.TrimSuffix(prefix, slashSeparator), slashSeparator); i >= 0 {
+		parent = prefix[:i+1]
+	}
+	zipWriter := zip.NewWriter(writer)
+	marker := ""
+	for {
+		lo, err := objAPI.ListObjects(bucket, prefix, marker, "", 1000)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range lo.Objects {
+			// Directories are implied by the object names.
+			if strings.HasSuffix(objInfo.Name, slashSeparator) {
+				continue
+			}
+			header := &zip.FileHeader{
+				Name:   strings.TrimPrefix(objInfo.Name, parent),
+				Method: zip.Deflate,
+			}
+			header.SetModTime(objInfo.ModTime)
+			entry, err := zipWriter.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			if err = getObjectContent(ctx, objAPI, bucket, &objInfo, entry); err != nil {
+				return err
+			}
+		}
+		if !lo.IsTruncated {
+			break
+		}
+		marker = lo.NextMarker
+	}
+	return zipWriter.Close()
+}
@@ -0,0 +1,131 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// Tests names of zip archives.
+func TestZipArchiveName(t *testing.T) {
+	testCases := []struct {
+		bucket  string
+		prefix  string
+		zipName string
+	}{
+		// Test case - 1.
+		{"bucket", "", "bucket.zip"},
+		// Test case - 2.
+		{"bucket", "photos/", "photos.zip"},
+		// Test case - 3.
+		{"bucket", "photos/2016/", "2016.zip"},
+		// Test case - 4.
+		// Prefix without trailing slash.
+		{"bucket", "photos/20", "20.zip"},
+	}
+	for i, testCase := range testCases {
+		zipName := zipArchiveName(testCase.bucket, testCase.prefix)
+		if zipName != testCase.zipName {
+			t.Errorf("Test %d: Expected %s, but instead found %s", i+1, testCase.zipName, zipName)
+		}
+	}
+}
+
+// Wrapper for calling zip archive tests for both XL multiple disks and single node setup.
+func TestWriteZipArchive(t *testing.T) {
+	ExecObjectLayerTest(t, testWriteZipArchive)
+}
+
+// Tests zip archives contain all objects under the prefix.
+func testWriteZipArchive(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objects := []string{"photos/2016/a.jpg", "photos/2016/b/c.jpg", "photos/2017/d.jpg", "other"}
+	for _, object := range objects {
+		data := strings.Repeat(object, 100)
+		if _, err := obj.PutObject(context.Background(), bucket, object, int64(len(data)), strings.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	testCases := []struct {
+		prefix  string
+		entries map[string]string
+	}{
+		// Test case - 1.
+		// Entire bucket.
+		{"", map[string]string{
+			"photos/2016/a.jpg":   "photos/2016/a.jpg",
+			"photos/2016/b/c.jpg": "photos/2016/b/c.jpg",
+			"photos/2017/d.jpg":   "photos/2017/d.jpg",
+			"other":               "other",
+		}},
+		// Test case - 2.
+		// Entries are relative to the parent of the prefix.
+		{"photos/2016/", map[string]string{
+			"2016/a.jpg":   "photos/2016/a.jpg",
+			"2016/b/c.jpg": "photos/2016/b/c.jpg",
+		}},
+		// Test case - 3.
+		{"photos/", map[string]string{
+			"photos/2016/a.jpg":   "photos/2016/a.jpg",
+			"photos/2016/b/c.jpg": "photos/2016/b/c.jpg",
+			"photos/2017/d.jpg":   "photos/2017/d.jpg",
+		}},
+		// Test case - 4.
+		// No objects under the prefix.
+		{"videos/", map[string]string{}},
+	}
+	for i, testCase := range testCases {
+		buffer := new(bytes.Buffer)
+		if err := writeZipArchive(context.Background(), obj, bucket, testCase.prefix, buffer); err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if len(zipReader.File) != len(testCase.entries) {
+			t.Fatalf("%s: Test %d: Expected %d entries, but instead found %d", instanceType, i+1, len(testCase.entries), len(zipReader.File))
+		}
+		for _, file := range zipReader.File {
+			object, ok := testCase.entries[file.Name]
+			if !ok {
+				t.Fatalf("%s: Test %d: Unexpected entry %s", instanceType, i+1, file.Name)
+			}
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+			}
+			data, err := ioutil.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+			}
+			if string(data) != strings.Repeat(object, 100) {
+				t.Errorf("%s: Test %d: Content of entry %s does not match", instanceType, i+1, file.Name)
+			}
+		}
+	}
+}
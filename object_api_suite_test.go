@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"io"
@@ -77,6 +78,7 @@ func APITestSuite(c *check.C, create func() ObjectLayer) {
 	testNonExistantBucketOperations(c, create)
 	testBucketRecreateFails(c, create)
 	testPutObject(c, create)
+	testPutObjectCanceled(c, create)
 	testPutObjectInSubdir(c, create)
 	testListBuckets(c, create)
 	testListBucketsOrder(c, create)
@@ -84,6 +86,7 @@ func APITestSuite(c *check.C, create func() ObjectLayer) {
 	testNonExistantObjectInBucket(c, create)
 	testGetDirectoryReturnsObjectNotFound(c, create)
 	testContentType(c, create)
+	testObjectMetadata(c, create)
 	testMultipartObjectCreation(c, create)
 	testMultipartObjectAbort(c, create)
 }
@@ -112,7 +115,7 @@ func testMultipartObjectCreation(c *check.C, create func() ObjectLayer) {
 		expectedMD5Sumhex := hex.EncodeToString(hasher.Sum(nil))
 
 		var calculatedMD5sum string
-		calculatedMD5sum, err = obj.PutObjectPart("bucket", "key", uploadID, i, int64(len(data)), bytes.NewBuffer(data), expectedMD5Sumhex)
+		calculatedMD5sum, err = obj.PutObjectPart(context.Background(), "bucket", "key", uploadID, i, int64(len(data)), bytes.NewBuffer(data), expectedMD5Sumhex)
 		c.Assert(err, check.IsNil)
 		c.Assert(calculatedMD5sum, check.Equals, expectedMD5Sumhex)
 		completedParts.Parts = append(completedParts.Parts, completePart{PartNumber: i, ETag: calculatedMD5sum})
@@ -145,7 +148,7 @@ func testMultipartObjectAbort(c *check.C, create func() ObjectLayer) {
 
 		metadata["md5"] = expectedMD5Sumhex
 		var calculatedMD5sum string
-		calculatedMD5sum, err = obj.PutObjectPart("bucket", "key", uploadID, i, int64(len(randomString)), bytes.NewBufferString(randomString), expectedMD5Sumhex)
+		calculatedMD5sum, err = obj.PutObjectPart(context.Background(), "bucket", "key", uploadID, i, int64(len(randomString)), bytes.NewBufferString(randomString), expectedMD5Sumhex)
 		c.Assert(err, check.IsNil)
 		c.Assert(calculatedMD5sum, check.Equals, expectedMD5Sumhex)
 		parts[i] = expectedMD5Sumhex
@@ -176,14 +179,14 @@ func testMultipleObjectCreation(c *check.C, create func() ObjectLayer) {
 		metadata := make(map[string]string)
 		metadata["md5Sum"] = expectedMD5Sumhex
 		var md5Sum string
-		md5Sum, err = obj.PutObject("bucket", key, int64(len(randomString)), bytes.NewBufferString(randomString), metadata)
+		md5Sum, err = obj.PutObject(context.Background(), "bucket", key, int64(len(randomString)), bytes.NewBufferString(randomString), metadata)
 		c.Assert(err, check.IsNil)
 		c.Assert(md5Sum, check.Equals, expectedMD5Sumhex)
 	}
 
 	for key, value := range objects {
 		var byteBuffer bytes.Buffer
-		err = obj.GetObject("bucket", key, 0, int64(len(value)), &byteBuffer)
+		err = obj.GetObject(context.Background(), "bucket", key, 0, int64(len(value)), &byteBuffer)
 		c.Assert(err, check.IsNil)
 		c.Assert(byteBuffer.Bytes(), check.DeepEquals, value)
 
@@ -204,7 +207,7 @@ func testPaging(c *check.C, create func() ObjectLayer) {
 	// check before paging occurs.
 	for i := 0; i < 5; i++ {
 		key := "obj" + strconv.Itoa(i)
-		_, err = obj.PutObject("bucket", key, int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+		_, err = obj.PutObject(context.Background(), "bucket", key, int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 		c.Assert(err, check.IsNil)
 
 		result, err = obj.ListObjects("bucket", "", "", "", 5)
@@ -215,7 +218,7 @@ func testPaging(c *check.C, create func() ObjectLayer) {
 	// check after paging occurs pages work.
 	for i := 6; i <= 10; i++ {
 		key := "obj" + strconv.Itoa(i)
-		_, err = obj.PutObject("bucket", key, int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+		_, err = obj.PutObject(context.Background(), "bucket", key, int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 		c.Assert(err, check.IsNil)
 		result, err = obj.ListObjects("bucket", "obj", "", "", 5)
 		c.Assert(err, check.IsNil)
@@ -224,9 +227,9 @@ func testPaging(c *check.C, create func() ObjectLayer) {
 	}
 	// check paging with prefix at end returns less objects.
 	{
-		_, err = obj.PutObject("bucket", "newPrefix", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+		_, err = obj.PutObject(context.Background(), "bucket", "newPrefix", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 		c.Assert(err, check.IsNil)
-		_, err = obj.PutObject("bucket", "newPrefix2", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+		_, err = obj.PutObject(context.Background(), "bucket", "newPrefix2", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 		c.Assert(err, check.IsNil)
 		result, err = obj.ListObjects("bucket", "new", "", "", 5)
 		c.Assert(err, check.IsNil)
@@ -246,9 +249,9 @@ func testPaging(c *check.C, create func() ObjectLayer) {
 
 	// check delimited results with delimiter and prefix.
 	{
-		_, err = obj.PutObject("bucket", "this/is/delimited", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+		_, err = obj.PutObject(context.Background(), "bucket", "this/is/delimited", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 		c.Assert(err, check.IsNil)
-		_, err = obj.PutObject("bucket", "this/is/also/a/delimited/file", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+		_, err = obj.PutObject(context.Background(), "bucket", "this/is/also/a/delimited/file", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 		c.Assert(err, check.IsNil)
 		result, err = obj.ListObjects("bucket", "this/is/", "", "/", 10)
 		c.Assert(err, check.IsNil)
@@ -303,15 +306,15 @@ func testObjectOverwriteWorks(c *check.C, create func() ObjectLayer) {
 	err := obj.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
 
-	_, err = obj.PutObject("bucket", "object", int64(len("The list of parts was not in ascending order. The parts list must be specified in order by part number.")), bytes.NewBufferString("The list of parts was not in ascending order. The parts list must be specified in order by part number."), nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "object", int64(len("The list of parts was not in ascending order. The parts list must be specified in order by part number.")), bytes.NewBufferString("The list of parts was not in ascending order. The parts list must be specified in order by part number."), nil)
 	c.Assert(err, check.IsNil)
 
 	length := int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."))
-	_, err = obj.PutObject("bucket", "object", length, bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "object", length, bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 	c.Assert(err, check.IsNil)
 
 	var bytesBuffer bytes.Buffer
-	err = obj.GetObject("bucket", "object", 0, length, &bytesBuffer)
+	err = obj.GetObject(context.Background(), "bucket", "object", 0, length, &bytesBuffer)
 	c.Assert(err, check.IsNil)
 	c.Assert(string(bytesBuffer.Bytes()), check.Equals, "The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")
 }
@@ -319,7 +322,7 @@ func testObjectOverwriteWorks(c *check.C, create func() ObjectLayer) {
 // Tests validate that bucket operation on non-existent bucket fails.
 func testNonExistantBucketOperations(c *check.C, create func() ObjectLayer) {
 	obj := create()
-	_, err := obj.PutObject("bucket1", "object", int64(len("one")), bytes.NewBufferString("one"), nil)
+	_, err := obj.PutObject(context.Background(), "bucket1", "object", int64(len("one")), bytes.NewBufferString("one"), nil)
 	c.Assert(err, check.Not(check.IsNil))
 	c.Assert(err.Error(), check.Equals, "Bucket not found: bucket1")
 }
@@ -345,20 +348,42 @@ func testPutObject(c *check.C, create func() ObjectLayer) {
 	c.Assert(err, check.IsNil)
 
 	var bytesBuffer1 bytes.Buffer
-	_, err = obj.PutObject("bucket", "object", length, readerEOF, nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "object", length, readerEOF, nil)
 	c.Assert(err, check.IsNil)
-	err = obj.GetObject("bucket", "object", 0, length, &bytesBuffer1)
+	err = obj.GetObject(context.Background(), "bucket", "object", 0, length, &bytesBuffer1)
 	c.Assert(err, check.IsNil)
 	c.Assert(len(bytesBuffer1.Bytes()), check.Equals, len(content))
 
 	var bytesBuffer2 bytes.Buffer
-	_, err = obj.PutObject("bucket", "object", length, readerNoEOF, nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "object", length, readerNoEOF, nil)
 	c.Assert(err, check.IsNil)
-	err = obj.GetObject("bucket", "object", 0, length, &bytesBuffer2)
+	err = obj.GetObject(context.Background(), "bucket", "object", 0, length, &bytesBuffer2)
 	c.Assert(err, check.IsNil)
 	c.Assert(len(bytesBuffer2.Bytes()), check.Equals, len(content))
 }
 
+// Tests validate reads and writes stop once the context is canceled.
+func testPutObjectCanceled(c *check.C, create func() ObjectLayer) {
+	obj := create()
+	content := []byte("testcontent")
+	length := int64(len(content))
+	err := obj.MakeBucket("bucket")
+	c.Assert(err, check.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = obj.PutObject(ctx, "bucket", "object", length, bytes.NewReader(content), nil)
+	c.Assert(err, check.Equals, context.Canceled)
+	_, err = obj.GetObjectInfo("bucket", "object")
+	c.Assert(err, check.FitsTypeOf, ObjectNotFound{})
+
+	_, err = obj.PutObject(context.Background(), "bucket", "object", length, bytes.NewReader(content), nil)
+	c.Assert(err, check.IsNil)
+	var buffer bytes.Buffer
+	err = obj.GetObject(ctx, "bucket", "object", 0, length, &buffer)
+	c.Assert(err, check.Equals, context.Canceled)
+}
+
 // Tests validate PutObject with subdirectory prefix.
 func testPutObjectInSubdir(c *check.C, create func() ObjectLayer) {
 	obj := create()
@@ -366,11 +391,11 @@ func testPutObjectInSubdir(c *check.C, create func() ObjectLayer) {
 	c.Assert(err, check.IsNil)
 
 	length := int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."))
-	_, err = obj.PutObject("bucket", "dir1/dir2/object", length, bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "dir1/dir2/object", length, bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 	c.Assert(err, check.IsNil)
 
 	var bytesBuffer bytes.Buffer
-	err = obj.GetObject("bucket", "dir1/dir2/object", 0, length, &bytesBuffer)
+	err = obj.GetObject(context.Background(), "bucket", "dir1/dir2/object", 0, length, &bytesBuffer)
 	c.Assert(err, check.IsNil)
 	c.Assert(len(bytesBuffer.Bytes()), check.Equals, len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."))
 }
@@ -459,7 +484,7 @@ func testGetDirectoryReturnsObjectNotFound(c *check.C, create func() ObjectLayer
 	err := obj.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
 
-	_, err = obj.PutObject("bucket", "dir1/dir3/object", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("One or more of the specified parts could not be found. The part might not have been uploaded, or the specified entity tag might not have matched the part's entity tag."), nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "dir1/dir3/object", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("One or more of the specified parts could not be found. The part might not have been uploaded, or the specified entity tag might not have matched the part's entity tag."), nil)
 	c.Assert(err, check.IsNil)
 
 	_, err = obj.GetObjectInfo("bucket", "dir1")
@@ -474,7 +499,7 @@ func testGetDirectoryReturnsObjectNotFound(c *check.C, create func() ObjectLayer
 
 	_, err = obj.GetObjectInfo("bucket", "dir1/")
 	switch err := err.(type) {
-	case ObjectNameInvalid:
+	case ObjectNotFound:
 		c.Assert(err.Bucket, check.Equals, "bucket")
 		c.Assert(err.Object, check.Equals, "dir1/")
 	default:
@@ -490,9 +515,53 @@ func testContentType(c *check.C, create func() ObjectLayer) {
 	c.Assert(err, check.IsNil)
 
 	// Test empty.
-	_, err = obj.PutObject("bucket", "minio.png", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
+	_, err = obj.PutObject(context.Background(), "bucket", "minio.png", int64(len("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed.")), bytes.NewBufferString("The specified multipart upload does not exist. The upload ID might be invalid, or the multipart upload might have been aborted or completed."), nil)
 	c.Assert(err, check.IsNil)
 	objInfo, err := obj.GetObjectInfo("bucket", "minio.png")
 	c.Assert(err, check.IsNil)
 	c.Assert(objInfo.ContentType, check.Equals, "image/png")
 }
+
+// Tests metadata given on upload is saved with the object.
+func testObjectMetadata(c *check.C, create func() ObjectLayer) {
+	obj := create()
+	err := obj.MakeBucket("bucket")
+	c.Assert(err, check.IsNil)
+
+	data := []byte("hello world")
+	metadata := map[string]string{
+		"content-type":     "text/plain",
+		"x-amz-meta-color": "blue",
+	}
+	md5Hex, err := obj.PutObject(context.Background(), "bucket", "object.png", int64(len(data)), bytes.NewReader(data), metadata)
+	c.Assert(err, check.IsNil)
+	objInfo, err := obj.GetObjectInfo("bucket", "object.png")
+	c.Assert(err, check.IsNil)
+	c.Assert(objInfo.ContentType, check.Equals, "text/plain")
+	c.Assert(objInfo.MD5Sum, check.Equals, md5Hex)
+	// FS saves the metadata in fs.json like XL does in xl.json.
+	if fs, ok := obj.(fsObjects); ok {
+		meta, err := fs.readObjectMetadata("bucket", "object.png")
+		c.Assert(err, check.IsNil)
+		c.Assert(meta["x-amz-meta-color"], check.Equals, "blue")
+	}
+
+	// Overwritten objects do not keep the previous metadata.
+	_, err = obj.PutObject(context.Background(), "bucket", "object.png", int64(len(data)), bytes.NewReader(data), nil)
+	c.Assert(err, check.IsNil)
+	objInfo, err = obj.GetObjectInfo("bucket", "object.png")
+	c.Assert(err, check.IsNil)
+	c.Assert(objInfo.ContentType, check.Equals, "image/png")
+
+	// Metadata of multipart uploads is saved on completion.
+	uploadID, err := obj.NewMultipartUpload("bucket", "multipart", map[string]string{"content-type": "text/csv"})
+	c.Assert(err, check.IsNil)
+	partMD5, err := obj.PutObjectPart(context.Background(), "bucket", "multipart", uploadID, 1, int64(len(data)), bytes.NewReader(data), "")
+	c.Assert(err, check.IsNil)
+	md5Hex, err = obj.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: partMD5}})
+	c.Assert(err, check.IsNil)
+	objInfo, err = obj.GetObjectInfo("bucket", "multipart")
+	c.Assert(err, check.IsNil)
+	c.Assert(objInfo.ContentType, check.Equals, "text/csv")
+	c.Assert(objInfo.MD5Sum, check.Equals, md5Hex)
+}
@@ -0,0 +1,61 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// ErrorResponse - error returned by the server.
+type ErrorResponse struct {
+	XMLName    xml.Name `xml:"Error" json:"-"`
+	Code       string
+	Message    string
+	Resource   string
+	RequestID  string `xml:"RequestId"`
+	HostID     string `xml:"HostId"`
+	StatusCode int    `xml:"-"`
+}
+
+// Error - returns the error message.
+func (e ErrorResponse) Error() string {
+	return e.Message
+}
+
+// ToErrorResponse - returns the ErrorResponse of err, an empty
+// ErrorResponse if err was not returned by the server.
+func ToErrorResponse(err error) ErrorResponse {
+	switch err := err.(type) {
+	case ErrorResponse:
+		return err
+	default:
+		return ErrorResponse{}
+	}
+}
+
+// httpRespToErrorResponse - decodes the error of a failed response.
+func httpRespToErrorResponse(resp *http.Response) error {
+	errResp := ErrorResponse{StatusCode: resp.StatusCode}
+	if err := xml.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		// Responses without body, e.g. HEAD requests.
+		errResp.Code = resp.Status
+		errResp.Message = fmt.Sprintf("Server returned %s", resp.Status)
+	}
+	return errResp
+}
@@ -0,0 +1,139 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package madmin implements a client for the Minio admin API, allowing
+// tooling to manage Minio servers programmatically.
+package madmin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// adminAPIPrefix - path prefix of all admin API requests.
+const adminAPIPrefix = "/minio/admin/v1"
+
+// Client - Minio admin API client, requests are signed with the admin
+// credentials of the server.
+type Client struct {
+	endpointURL     *url.URL
+	accessKeyID     string
+	secretAccessKey string
+
+	// Region used for signing requests.
+	region string
+
+	httpClient *http.Client
+}
+
+// New - returns a new admin client for endpoint, secure selects HTTPS.
+func New(endpoint string, accessKeyID, secretAccessKey string, secure bool) (*Client, error) {
+	if endpoint == "" || strings.Contains(endpoint, "/") {
+		return nil, errors.New("Endpoint must be of the form host[:port]")
+	}
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return &Client{
+		endpointURL:     &url.URL{Scheme: scheme, Host: endpoint},
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		region:          "us-east-1",
+		httpClient:      &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// SetRegion - sets the region requests are signed for, defaults to
+// us-east-1.
+func (c *Client) SetRegion(region string) {
+	c.region = region
+}
+
+// SetCustomTransport - sets a custom transport, e.g. to trust a self
+// signed server certificate.
+func (c *Client) SetCustomTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
+// requestData - admin API request.
+type requestData struct {
+	method      string
+	relPath     string // Path relative to the admin API prefix.
+	queryValues url.Values
+	headers     http.Header
+	content     []byte
+}
+
+// newRequest - returns a signed request.
+func (c *Client) newRequest(reqData requestData) (*http.Request, error) {
+	u := *c.endpointURL
+	u.Path = adminAPIPrefix + reqData.relPath
+	if len(reqData.queryValues) > 0 {
+		u.RawQuery = strings.Replace(reqData.queryValues.Encode(), "+", "%20", -1)
+	}
+	var body io.Reader
+	if reqData.content != nil {
+		body = bytes.NewReader(reqData.content)
+	}
+	req, err := http.NewRequest(reqData.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range reqData.headers {
+		req.Header[k] = v
+	}
+	req.ContentLength = int64(len(reqData.content))
+	payloadHash := sha256.Sum256(reqData.content)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	signV4(req, c.accessKeyID, c.secretAccessKey, c.region, time.Now().UTC())
+	return req, nil
+}
+
+// executeMethod - executes a request, non 2xx responses are returned
+// as ErrorResponse. The caller must close the response body.
+func (c *Client) executeMethod(reqData requestData) (*http.Response, error) {
+	req, err := c.newRequest(reqData)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, httpRespToErrorResponse(resp)
+	}
+	return resp, nil
+}
+
+// closeResponse - drains and closes the response body so the
+// connection can be reused.
+func closeResponse(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
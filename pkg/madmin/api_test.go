@@ -0,0 +1,91 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests requests are signed and errors are decoded.
+func TestExecuteMethod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, signV4Algorithm+" Credential=access-key/") ||
+			!strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>`))
+			return
+		}
+		switch r.URL.Path {
+		case adminAPIPrefix + "/service":
+			w.Write([]byte(`{"serverVersion":{"version":"2016-10-01T00:00:00Z","commitID":"abcdef"},"uptime":1000000000}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(strings.TrimPrefix(server.URL, "http://"), "access-key", "secret-key", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := client.ServiceStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ServerVersion.CommitID != "abcdef" || status.Uptime.Seconds() != 1 {
+		t.Fatalf("Unexpected service status %#v", status)
+	}
+
+	// Error responses without body.
+	err = client.ServiceStop()
+	if errResp := ToErrorResponse(err); errResp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status %d, but instead found %v", http.StatusNotFound, err)
+	}
+
+	// Error responses decoded from the body.
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>`))
+	})
+	err = client.ServiceRestart()
+	if errResp := ToErrorResponse(err); errResp.Code != "AccessDenied" {
+		t.Fatalf("Expected AccessDenied, but instead found %v", err)
+	}
+}
+
+// Tests paths are encoded for the canonical request.
+func TestEncodePath(t *testing.T) {
+	testCases := []struct {
+		path    string
+		encoded string
+	}{
+		// Test case - 1.
+		{"/minio/admin/v1/service", "/minio/admin/v1/service"},
+		// Test case - 2.
+		{"/a b/c+d", "/a%20b/c%2Bd"},
+		// Test case - 3.
+		{"/~user/file_name-1.txt", "/~user/file_name-1.txt"},
+	}
+	for i, testCase := range testCases {
+		if encoded := encodePath(testCase.path); encoded != testCase.encoded {
+			t.Errorf("Test %d: Expected %s, but instead found %s", i+1, testCase.encoded, encoded)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// Header carrying the passphrase the secrets of a config bundle are
+// encrypted with.
+const configPassphraseHeader = "X-Minio-Config-Passphrase"
+
+// SetConfigResult - result of a config update.
+type SetConfigResult struct {
+	// True if some settings only take effect after a restart.
+	RestartRequired bool `json:"restartRequired"`
+}
+
+// ImportConfigResult - result of a config bundle import.
+type ImportConfigResult struct {
+	// True if some settings only take effect after a restart.
+	RestartRequired bool `json:"restartRequired"`
+	// Buckets whose configs were imported.
+	Buckets []string `json:"buckets,omitempty"`
+	// Buckets created by the import.
+	Created []string `json:"created,omitempty"`
+	// Configs which could not be imported, e.g. replication targets
+	// whose secret key is missing.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// passphraseHeaders - returns the headers carrying passphrase, nil if
+// it is empty.
+func passphraseHeaders(passphrase string) http.Header {
+	if passphrase == "" {
+		return nil
+	}
+	return http.Header{configPassphraseHeader: []string{passphrase}}
+}
+
+// GetConfig - returns the JSON encoded server config.
+func (c *Client) GetConfig() ([]byte, error) {
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/config",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+	return ioutil.ReadAll(resp.Body)
+}
+
+// SetConfig - validates and saves a new JSON encoded server config.
+// Credential and region take effect immediately, other settings may
+// require a restart of the server.
+func (c *Client) SetConfig(config []byte) (SetConfigResult, error) {
+	var result SetConfigResult
+	resp, err := c.executeMethod(requestData{
+		method:  "PUT",
+		relPath: "/config",
+		content: config,
+	})
+	if err != nil {
+		return result, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// ExportConfig - returns the server config and the configs of all
+// buckets as a JSON encoded bundle. Secrets are encrypted with
+// passphrase, or left out of the bundle if passphrase is empty.
+func (c *Client) ExportConfig(passphrase string) ([]byte, error) {
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/config/export",
+		headers: passphraseHeaders(passphrase),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+	return ioutil.ReadAll(resp.Body)
+}
+
+// ImportConfig - validates and saves a bundle returned by ExportConfig,
+// passphrase must be the one the bundle was exported with. Secrets left
+// out of the bundle are kept from the current config of the server.
+func (c *Client) ImportConfig(bundle []byte, passphrase string) (ImportConfigResult, error) {
+	var result ImportConfigResult
+	resp, err := c.executeMethod(requestData{
+		method:  "PUT",
+		relPath: "/config/import",
+		headers: passphraseHeaders(passphrase),
+		content: bundle,
+	})
+	if err != nil {
+		return result, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
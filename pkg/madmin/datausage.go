@@ -0,0 +1,58 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PrefixUsageInfo - object count and total size of objects.
+type PrefixUsageInfo struct {
+	ObjectsCount uint64 `json:"objectsCount"`
+	Size         uint64 `json:"size"`
+}
+
+// BucketUsageInfo - usage of a bucket and each of its top level
+// prefixes.
+type BucketUsageInfo struct {
+	PrefixUsageInfo
+	Prefixes map[string]PrefixUsageInfo `json:"prefixes,omitempty"`
+}
+
+// DataUsageInfo - usage of all buckets, LastUpdate is zero if the
+// server did not finish crawling yet.
+type DataUsageInfo struct {
+	LastUpdate time.Time `json:"lastUpdate"`
+	PrefixUsageInfo
+	Buckets map[string]BucketUsageInfo `json:"buckets"`
+}
+
+// DataUsageInfo - returns object counts and sizes per bucket and top
+// level prefix computed by the last background crawl of the server.
+func (c *Client) DataUsageInfo() (DataUsageInfo, error) {
+	var usage DataUsageInfo
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/datausage",
+	})
+	if err != nil {
+		return usage, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&usage)
+	return usage, err
+}
@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Disk states.
+const (
+	DiskStateOnline  = "online"
+	DiskStateOffline = "offline"
+)
+
+// ServerDiskInfo - usage, health and format of a disk.
+type ServerDiskInfo struct {
+	Endpoint    string `json:"endpoint"`
+	State       string `json:"state"`
+	TotalSpace  int64  `json:"totalSpace"`
+	UsedSpace   int64  `json:"usedSpace"`
+	HealStatus  string `json:"healStatus"`
+	ReadErrors  int64  `json:"readErrors"`
+	WriteErrors int64  `json:"writeErrors"`
+	FormatUUID  string `json:"formatUUID,omitempty"`
+}
+
+// NodeConnectivity - state of the connection to a node.
+type NodeConnectivity struct {
+	Online bool `json:"online"`
+	// Storage RPC protocol version spoken with the node.
+	Version   int       `json:"version,omitempty"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"lastError,omitempty"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// Time the node is dialed again after failures.
+	RetryAt time.Time `json:"retryAt,omitempty"`
+}
+
+// ServerNodeInfo - disks served by a node, Connectivity is only set for
+// nodes other than the one answering.
+type ServerNodeInfo struct {
+	Addr         string            `json:"addr"`
+	Disks        []ServerDiskInfo  `json:"disks"`
+	Connectivity *NodeConnectivity `json:"connectivity,omitempty"`
+}
+
+// ServerInfo - information about all nodes and disks of the server.
+type ServerInfo struct {
+	ServerVersion ServerVersion    `json:"serverVersion"`
+	Nodes         []ServerNodeInfo `json:"nodes"`
+}
+
+// ServerInfo - returns usage, health and format of all disks grouped
+// by the node serving them.
+func (c *Client) ServerInfo() (ServerInfo, error) {
+	var info ServerInfo
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/info",
+	})
+	if err != nil {
+		return info, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
+
+// BufferPoolStats - usage of the data path buffers of one size.
+type BufferPoolStats struct {
+	Size int `json:"size"`
+	// Buffers handed out and returned.
+	Gets int64 `json:"gets"`
+	Puts int64 `json:"puts"`
+	// Buffers allocated because the pool was empty.
+	Allocs int64 `json:"allocs"`
+}
+
+// BufferPoolStats - returns the usage of the buffer pools of the
+// server, allocations growing with gets means buffers are not reused.
+func (c *Client) BufferPoolStats() ([]BufferPoolStats, error) {
+	var stats []BufferPoolStats
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/bufferpools",
+	})
+	if err != nil {
+		return stats, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	return stats, err
+}
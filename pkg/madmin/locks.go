@@ -0,0 +1,69 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LockInfo - a namespace lock held by the server.
+type LockInfo struct {
+	Volume    string    `json:"volume"`
+	Path      string    `json:"path"`
+	WriteLock bool      `json:"writeLock"`
+	Since     time.Time `json:"since"`
+	Holders   uint      `json:"holders"`
+	Waiters   uint      `json:"waiters"`
+}
+
+// RequestInfo - a request the server is serving.
+type RequestInfo struct {
+	RequestID  string        `json:"requestID"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	RemoteAddr string        `json:"remoteAddr"`
+	AccessKey  string        `json:"accessKey,omitempty"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// TopLocksInfo - long held locks and long running requests.
+type TopLocksInfo struct {
+	Locks    []LockInfo    `json:"locks"`
+	Requests []RequestInfo `json:"requests"`
+}
+
+// TopLocks - returns the namespace locks held and the requests served
+// by the server for at least olderThan, oldest first.
+func (c *Client) TopLocks(olderThan time.Duration) (TopLocksInfo, error) {
+	var info TopLocksInfo
+	queryValues := url.Values{}
+	queryValues.Set("olderThan", strconv.Itoa(int(olderThan/time.Second)))
+	resp, err := c.executeMethod(requestData{
+		method:      "GET",
+		relPath:     "/locks",
+		queryValues: queryValues,
+	})
+	if err != nil {
+		return info, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
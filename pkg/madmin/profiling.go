@@ -0,0 +1,68 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ProfilerType - type of profiler.
+type ProfilerType string
+
+// Supported profiler types.
+const (
+	ProfilerCPU       ProfilerType = "cpu"
+	ProfilerMem       ProfilerType = "mem"
+	ProfilerBlock     ProfilerType = "block"
+	ProfilerGoroutine ProfilerType = "goroutine"
+)
+
+// StartProfiling - starts the given profilers on the server, profilers
+// which are still running are stopped and their data is discarded.
+func (c *Client) StartProfiling(profilers ...ProfilerType) error {
+	var profilerTypes []string
+	for _, profiler := range profilers {
+		profilerTypes = append(profilerTypes, string(profiler))
+	}
+	queryValues := url.Values{}
+	queryValues.Set("profilerType", strings.Join(profilerTypes, ","))
+	resp, err := c.executeMethod(requestData{
+		method:      "POST",
+		relPath:     "/profiling/start",
+		queryValues: queryValues,
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// DownloadProfilingData - stops all running profilers and returns a zip
+// archive with a 'profile-<type>.pprof' entry per profiler. The caller
+// must close the returned reader.
+func (c *Client) DownloadProfilingData() (io.ReadCloser, error) {
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/profiling/download",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,151 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Rebalance statuses.
+const (
+	RebalanceRunning  = "running"
+	RebalancePaused   = "paused"
+	RebalanceFinished = "finished"
+	RebalanceFailed   = "failed"
+)
+
+// RebalanceProgress - position and counts of a rebalance.
+type RebalanceProgress struct {
+	Set     int    `json:"set"`
+	Bucket  string `json:"bucket"`
+	Marker  string `json:"marker"`
+	Moved   int64  `json:"moved"`
+	Removed int64  `json:"removed"`
+	Failed  int64  `json:"failed"`
+	Size    int64  `json:"size"`
+}
+
+// Decommission states of an erasure set.
+const (
+	SetDecommissioning = "decommissioning"
+	SetRemoved         = "removed"
+)
+
+// SetUsage - disk usage and decommission state of an erasure set.
+type SetUsage struct {
+	Total int64  `json:"total"`
+	Free  int64  `json:"free"`
+	State string `json:"state,omitempty"`
+}
+
+// RebalanceStatus - state of the background rebalance, Status is empty
+// if no rebalance was ever started.
+type RebalanceStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Only decommissioned sets are drained.
+	Drain bool `json:"drain,omitempty"`
+	// Bytes moved per second, unlimited if 0.
+	Bandwidth int64             `json:"bandwidth"`
+	Started   time.Time         `json:"started"`
+	Updated   time.Time         `json:"updated"`
+	Progress  RebalanceProgress `json:"progress"`
+	Sets      []SetUsage        `json:"sets,omitempty"`
+}
+
+// StartRebalance - starts moving objects to the erasure sets they hash
+// to, at most bandwidth bytes per second unless bandwidth is 0.
+func (c *Client) StartRebalance(bandwidth int64) error {
+	queryValues := url.Values{}
+	if bandwidth > 0 {
+		queryValues.Set("bandwidth", strconv.FormatInt(bandwidth, 10))
+	}
+	resp, err := c.executeMethod(requestData{
+		method:      "POST",
+		relPath:     "/rebalance/start",
+		queryValues: queryValues,
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// PauseRebalance - pauses the running rebalance.
+func (c *Client) PauseRebalance() error {
+	resp, err := c.executeMethod(requestData{
+		method:  "POST",
+		relPath: "/rebalance/pause",
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// ResumeRebalance - continues a paused rebalance.
+func (c *Client) ResumeRebalance() error {
+	resp, err := c.executeMethod(requestData{
+		method:  "POST",
+		relPath: "/rebalance/resume",
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// DecommissionSet - stops placing objects on the erasure set with
+// index and drains it, at most bandwidth bytes per second unless
+// bandwidth is 0. The set is removed once it is empty.
+func (c *Client) DecommissionSet(index int, bandwidth int64) error {
+	queryValues := url.Values{}
+	queryValues.Set("set", strconv.Itoa(index))
+	if bandwidth > 0 {
+		queryValues.Set("bandwidth", strconv.FormatInt(bandwidth, 10))
+	}
+	resp, err := c.executeMethod(requestData{
+		method:      "POST",
+		relPath:     "/rebalance/decommission",
+		queryValues: queryValues,
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// RebalanceStatus - returns the state and progress of the rebalance.
+func (c *Client) RebalanceStatus() (RebalanceStatus, error) {
+	var status RebalanceStatus
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/rebalance",
+	})
+	if err != nil {
+		return status, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// ServerVersion - server version and commit it was built from.
+type ServerVersion struct {
+	Version  string `json:"version"`
+	CommitID string `json:"commitID"`
+}
+
+// ServiceStatusMetadata - status of the server.
+type ServiceStatusMetadata struct {
+	ServerVersion ServerVersion `json:"serverVersion"`
+	Uptime        time.Duration `json:"uptime"`
+}
+
+// ServiceStatus - returns version, commit and uptime of the server.
+func (c *Client) ServiceStatus() (ServiceStatusMetadata, error) {
+	var status ServiceStatusMetadata
+	resp, err := c.executeMethod(requestData{
+		method:  "GET",
+		relPath: "/service",
+	})
+	if err != nil {
+		return status, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// ServiceRestart - restarts the server, returns once the server
+// accepted the request.
+func (c *Client) ServiceRestart() error {
+	resp, err := c.executeMethod(requestData{
+		method:  "POST",
+		relPath: "/service/restart",
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// NodeUpdateResult - result of updating a node.
+type NodeUpdateResult struct {
+	Addr    string `json:"addr"`
+	Version string `json:"version,omitempty"`
+	Latest  string `json:"latest,omitempty"`
+	// True if the binary was replaced, the node restarts.
+	Updated bool   `json:"updated"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ServiceUpdate - updates all nodes to the latest official or
+// experimental release, updated nodes restart.
+func (c *Client) ServiceUpdate(experimental bool) ([]NodeUpdateResult, error) {
+	var results []NodeUpdateResult
+	queryValues := url.Values{}
+	if experimental {
+		queryValues.Set("experimental", "true")
+	}
+	resp, err := c.executeMethod(requestData{
+		method:      "POST",
+		relPath:     "/service/update",
+		queryValues: queryValues,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	return results, err
+}
+
+// ServiceStop - stops the server, returns once the server accepted
+// the request.
+func (c *Client) ServiceStop() error {
+	resp, err := c.executeMethod(requestData{
+		method:  "POST",
+		relPath: "/service/stop",
+	})
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
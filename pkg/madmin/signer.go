@@ -0,0 +1,116 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signV4Algorithm = "AWS4-HMAC-SHA256"
+	iso8601Format   = "20060102T150405Z"
+	yyyymmdd        = "20060102"
+)
+
+// sumHMAC - returns the HMAC-SHA256 of data.
+func sumHMAC(key []byte, data []byte) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+// encodePath - encodes a path as required by the canonical request,
+// unreserved characters and '/' are not encoded.
+func encodePath(path string) string {
+	var buf bytes.Buffer
+	for _, b := range []byte(path) {
+		switch {
+		case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+			buf.WriteByte(b)
+		case b == '-', b == '_', b == '.', b == '~', b == '/':
+			buf.WriteByte(b)
+		default:
+			buf.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{b})))
+		}
+	}
+	return buf.String()
+}
+
+// signV4 - signs req with AWS signature version '4', the payload hash
+// must already be set in the X-Amz-Content-Sha256 header.
+func signV4(req *http.Request, accessKeyID, secretAccessKey, region string, t time.Time) {
+	req.Header.Set("X-Amz-Date", t.Format(iso8601Format))
+
+	// Host and all x-amz-* headers are signed.
+	headers := []string{"host"}
+	for k := range req.Header {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-") {
+			headers = append(headers, strings.ToLower(k))
+		}
+	}
+	sort.Strings(headers)
+
+	var canonicalHeaders bytes.Buffer
+	for _, k := range headers {
+		canonicalHeaders.WriteString(k)
+		canonicalHeaders.WriteByte(':')
+		if k == "host" {
+			canonicalHeaders.WriteString(req.URL.Host)
+		} else {
+			canonicalHeaders.WriteString(strings.Join(req.Header[http.CanonicalHeaderKey(k)], ","))
+		}
+		canonicalHeaders.WriteByte('\n')
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(yyyymmdd), region, "s3", "aws4_request"}, "/")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		t.Format(iso8601Format),
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := sumHMAC([]byte("AWS4"+secretAccessKey), []byte(t.Format(yyyymmdd)))
+	signingKey = sumHMAC(signingKey, []byte(region))
+	signingKey = sumHMAC(signingKey, []byte("s3"))
+	signingKey = sumHMAC(signingKey, []byte("aws4_request"))
+	signature := hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", strings.Join([]string{
+		signV4Algorithm + " Credential=" + accessKeyID + "/" + scope,
+		"SignedHeaders=" + signedHeaders,
+		"Signature=" + signature,
+	}, ", "))
+}
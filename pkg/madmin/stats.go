@@ -0,0 +1,74 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// APIStats - request statistics of an S3 API or a bucket, Latency
+// holds the number of requests per latency bucket of TopStats.
+type APIStats struct {
+	Name          string        `json:"name"`
+	Requests      uint64        `json:"requests"`
+	ClientErrors  uint64        `json:"clientErrors"`
+	ServerErrors  uint64        `json:"serverErrors"`
+	TotalDuration time.Duration `json:"totalDuration"`
+	Latency       []uint64      `json:"latency"`
+}
+
+// TopStats - the busiest S3 APIs or buckets since the server started
+// collecting statistics. LatencyBounds are the upper bounds of the
+// latency buckets, the last bucket counts all slower requests.
+type TopStats struct {
+	Since         time.Time       `json:"since"`
+	LatencyBounds []time.Duration `json:"latencyBounds"`
+	Stats         []APIStats      `json:"stats"`
+}
+
+// TopAPIs - returns statistics of the count S3 APIs with the most
+// requests, all APIs if count is zero.
+func (c *Client) TopAPIs(count int) (TopStats, error) {
+	return c.topStats("/stats/apis", count)
+}
+
+// TopBuckets - returns statistics of the count buckets with the most
+// requests, all buckets if count is zero.
+func (c *Client) TopBuckets(count int) (TopStats, error) {
+	return c.topStats("/stats/buckets", count)
+}
+
+func (c *Client) topStats(relPath string, count int) (TopStats, error) {
+	var stats TopStats
+	queryValues := url.Values{}
+	if count > 0 {
+		queryValues.Set("count", strconv.Itoa(count))
+	}
+	resp, err := c.executeMethod(requestData{
+		method:      "GET",
+		relPath:     relPath,
+		queryValues: queryValues,
+	})
+	if err != nil {
+		return stats, err
+	}
+	defer closeResponse(resp)
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	return stats, err
+}
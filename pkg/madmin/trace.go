@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package madmin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TraceInfo - summary of a single API request and its response, Err
+// is set if the trace stream failed.
+type TraceInfo struct {
+	Time       time.Time     `json:"time"`
+	RequestID  string        `json:"requestID"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	RawQuery   string        `json:"rawQuery,omitempty"`
+	StatusCode int           `json:"statusCode"`
+	Duration   time.Duration `json:"duration"`
+	RemoteAddr string        `json:"remoteAddr"`
+	AccessKey  string        `json:"accessKey,omitempty"`
+	BytesSent  int64         `json:"bytesSent"`
+
+	// Only set for verbose traces.
+	ReqHeader  http.Header `json:"reqHeader,omitempty"`
+	ReqBody    []byte      `json:"reqBody,omitempty"`
+	RespHeader http.Header `json:"respHeader,omitempty"`
+	RespBody   []byte      `json:"respBody,omitempty"`
+
+	Err error `json:"-"`
+}
+
+// Trace - streams a summary of every API request served until doneCh
+// is closed. errOnly restricts traces to failed requests, verbose
+// includes request and response headers and the first bytes of their
+// bodies.
+func (c *Client) Trace(errOnly, verbose bool, doneCh <-chan struct{}) <-chan TraceInfo {
+	traceCh := make(chan TraceInfo)
+	go func() {
+		defer close(traceCh)
+
+		queryValues := url.Values{}
+		if errOnly {
+			queryValues.Set("err", "true")
+		}
+		if verbose {
+			queryValues.Set("verbose", "true")
+		}
+		req, err := c.newRequest(requestData{
+			method:      "GET",
+			relPath:     "/trace",
+			queryValues: queryValues,
+		})
+		if err != nil {
+			traceCh <- TraceInfo{Err: err}
+			return
+		}
+		// Closing doneCh cancels the request which unblocks the decoder.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-doneCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		req = req.WithContext(ctx)
+
+		// Traces are streamed until cancelled, no client timeout.
+		client := &http.Client{Transport: c.httpClient.Transport}
+		resp, err := client.Do(req)
+		if err != nil {
+			traceCh <- TraceInfo{Err: err}
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			traceCh <- TraceInfo{Err: httpRespToErrorResponse(resp)}
+			return
+		}
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var info TraceInfo
+			if err = decoder.Decode(&info); err != nil {
+				select {
+				case <-doneCh:
+				case traceCh <- TraceInfo{Err: err}:
+				}
+				return
+			}
+			select {
+			case <-doneCh:
+				return
+			case traceCh <- info:
+			}
+		}
+	}()
+	return traceCh
+}
@@ -0,0 +1,133 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	slashpath "path"
+	"sync"
+	"time"
+)
+
+// Number of directories waiting to be expired before new ones are
+// dropped.
+const emptyDirQueueSize = 10000
+
+var (
+	// Time a directory waits in the queue before it is removed. A
+	// directory is only queued when it could not be removed right
+	// away, mostly because a write to the same prefix was in flight,
+	// the delay keeps the expirer from racing with more such writes.
+	emptyDirExpiry = 5 * time.Minute
+
+	// Time between two batches of expired directories.
+	emptyDirExpireInterval = time.Minute
+)
+
+// emptyDir - directory which may be left empty by a delete.
+type emptyDir struct {
+	// Removal of parent directories stops at basePath.
+	basePath string
+	queued   time.Time
+}
+
+// emptyDirQueue - directories which may be left empty by deletes or
+// renames, removed in batches once expired together with their empty
+// parents. Directories still holding entries survive removal, which
+// fails atomically for them.
+type emptyDirQueue struct {
+	mutex *sync.Mutex
+	dirs  map[string]emptyDir
+}
+
+// globalEmptyDirs - directories of local disks waiting to be expired.
+var globalEmptyDirs = newEmptyDirQueue()
+
+func newEmptyDirQueue() *emptyDirQueue {
+	return &emptyDirQueue{
+		mutex: &sync.Mutex{},
+		dirs:  make(map[string]emptyDir),
+	}
+}
+
+// queue - queues dirPath to be removed once expired if it is empty
+// then, the volume directory basePath itself is never removed.
+func (q *emptyDirQueue) queue(basePath, dirPath string) {
+	if dirPath == basePath || !isPathPrefix(dirPath, basePath) {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if _, ok := q.dirs[dirPath]; !ok && len(q.dirs) >= emptyDirQueueSize {
+		return
+	}
+	q.dirs[dirPath] = emptyDir{basePath, time.Now().UTC()}
+}
+
+// expire - removes the directories queued before now minus the expiry
+// which are empty, together with their empty parents. Returns the
+// number of directories removed.
+func (q *emptyDirQueue) expire(now time.Time) (removed int) {
+	q.mutex.Lock()
+	expired := make(map[string]emptyDir)
+	for dirPath, dir := range q.dirs {
+		if now.Sub(dir.queued) >= emptyDirExpiry {
+			expired[dirPath] = dir
+			delete(q.dirs, dirPath)
+		}
+	}
+	q.mutex.Unlock()
+
+	for dirPath, dir := range expired {
+		removed += removeEmptyDirs(dir.basePath, dirPath)
+	}
+	return removed
+}
+
+// removeEmptyDirs - removes dirPath and its parents up to basePath as
+// long as they are empty. Returns the number of directories removed.
+func removeEmptyDirs(basePath, dirPath string) (removed int) {
+	for dirPath != basePath && isPathPrefix(dirPath, basePath) {
+		// Fails for directories which are not empty.
+		if removeFile(preparePath(dirPath)) != nil {
+			break
+		}
+		removed++
+		dirPath = slashpath.Dir(dirPath)
+	}
+	return removed
+}
+
+// isPathPrefix - returns if dirPath is below basePath.
+func isPathPrefix(dirPath, basePath string) bool {
+	return len(dirPath) > len(basePath) && dirPath[:len(basePath)] == basePath && dirPath[len(basePath)] == '/'
+}
+
+// runEmptyDirExpirer - removes expired empty directories periodically
+// until doneCh is closed.
+func runEmptyDirExpirer(doneCh <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(emptyDirExpireInterval):
+			// Directories stay queued while writes are rejected.
+			if serverModeAllowsWrites() {
+				globalEmptyDirs.expire(time.Now().UTC())
+			}
+		case <-doneCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Tests directories left empty by renames are expired in batches, and
+// deletes remove empty parents right away.
+func TestEmptyDirExpiry(t *testing.T) {
+	diskPath, err := ioutil.TempDir("", "minio-posix-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+
+	savedEmptyDirs := globalEmptyDirs
+	globalEmptyDirs = newEmptyDirQueue()
+	defer func() { globalEmptyDirs = savedEmptyDirs }()
+
+	disk, err := newPosix(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"a/b/object1/part.1", "a/c/object2/part.1"} {
+		if err = disk.AppendFile(context.Background(), "bucket", path, []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Moving object1 out leaves a/b empty.
+	if err = disk.RenameFile("bucket", "a/b/object1/", "bucket", "object1/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is removed before the expiry.
+	if removed := globalEmptyDirs.expire(time.Now().UTC()); removed != 0 {
+		t.Fatalf("Expected no directories to be removed, but instead found %d", removed)
+	}
+
+	// Only the empty directory is removed, its parent holds a/c.
+	if removed := globalEmptyDirs.expire(time.Now().UTC().Add(emptyDirExpiry)); removed != 1 {
+		t.Fatalf("Expected 1 directory to be removed, but instead found %d", removed)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "a", "b")); !os.IsNotExist(err) {
+		t.Fatalf("Expected a/b to be removed, but instead found %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "a", "c", "object2", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting the last object removes all its empty parents, but not
+	// the volume.
+	if err = disk.DeleteFile("bucket", "a/c/object2/part.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "a")); !os.IsNotExist(err) {
+		t.Fatalf("Expected a to be removed, but instead found %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "object1", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directories refilled before they expire survive.
+	if err = disk.RenameFile("bucket", "object1/", "bucket", "d/object1/"); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.RenameFile("bucket", "d/object1/", "bucket", "d/e/object1/"); err != nil {
+		t.Fatal(err)
+	}
+	if removed := globalEmptyDirs.expire(time.Now().UTC().Add(emptyDirExpiry)); removed != 0 {
+		t.Fatalf("Expected no directories to be removed, but instead found %d", removed)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "d", "e", "object1", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,7 +16,36 @@
 
 package main
 
-import "strings"
+import (
+	"bytes"
+	"strings"
+)
+
+// Appends of at least sparseBlockSize zero bytes are left as holes,
+// smaller ones are not worth the extra system calls.
+const sparseBlockSize = 64 * 1024
+
+// zeroBlock - sparseBlockSize zero bytes to compare appends against.
+var zeroBlock = make([]byte, sparseBlockSize)
+
+// isZeroBlock - returns if buf has at least sparseBlockSize bytes which
+// are all zero.
+func isZeroBlock(buf []byte) bool {
+	if len(buf) < sparseBlockSize {
+		return false
+	}
+	for len(buf) > 0 {
+		n := len(buf)
+		if n > sparseBlockSize {
+			n = sparseBlockSize
+		}
+		if !bytes.Equal(buf[:n], zeroBlock[:n]) {
+			return false
+		}
+		buf = buf[n:]
+	}
+	return true
+}
 
 // List of reserved words for files, includes old and new ones.
 var posixReservedPrefix = []string{
@@ -35,3 +64,42 @@ func hasPosixReservedPrefix(name string) (isReserved bool) {
 	}
 	return isReserved
 }
+
+// List of device names reserved on windows, with or without extension.
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// isValidWindowsPath - returns false if a component of pathName cannot
+// be created on windows, it either has reserved characters, a reserved
+// device name or ends with a dot or a space.
+func isValidWindowsPath(pathName string) bool {
+	for _, name := range strings.Split(pathName, slashSeparator) {
+		if name == "" || name == "." || name == ".." {
+			continue
+		}
+		if strings.ContainsAny(name, `\:*?"<>|`) {
+			return false
+		}
+		for _, r := range name {
+			if r < 0x20 {
+				return false
+			}
+		}
+		if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+			return false
+		}
+		base := strings.ToUpper(name)
+		if i := strings.Index(base, "."); i != -1 {
+			base = base[:i]
+		}
+		for _, reserved := range windowsReservedNames {
+			if base == reserved {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests only large blocks of zeros are detected.
+func TestIsZeroBlock(t *testing.T) {
+	block := make([]byte, 3*sparseBlockSize+1)
+	if !isZeroBlock(block) {
+		t.Error("Expected a zero block")
+	}
+	if isZeroBlock(block[:sparseBlockSize-1]) {
+		t.Error("Expected small blocks not to be zero blocks")
+	}
+	block[len(block)-1] = 1
+	if isZeroBlock(block) {
+		t.Error("Expected a block with data not to be a zero block")
+	}
+}
+
+// Tests names which cannot be created on windows.
+func TestIsValidWindowsPath(t *testing.T) {
+	testCases := []struct {
+		pathName string
+		valid    bool
+	}{
+		// Test case - 1.
+		{"object", true},
+		// Test case - 2.
+		{"dir/sub-dir/object.txt", true},
+		// Test case - 3.
+		// Multipart temporary files.
+		{"tmp/uploads/part.1", true},
+		// Test case - 4.
+		{"dir/", true},
+		// Test case - 5.
+		// Reserved characters.
+		{"a:b", false},
+		// Test case - 6.
+		{`dir\object`, false},
+		// Test case - 7.
+		{"what?", false},
+		// Test case - 8.
+		{"a\x01b", false},
+		// Test case - 9.
+		// Reserved device names, in any case and with extensions.
+		{"CON", false},
+		// Test case - 10.
+		{"dir/nul.txt", false},
+		// Test case - 11.
+		{"Lpt1/object", false},
+		// Test case - 12.
+		{"console", true},
+		// Test case - 13.
+		// Trailing dots and spaces are stripped by windows.
+		{"object.", false},
+		// Test case - 14.
+		{"dir /object", false},
+	}
+	for i, testCase := range testCases {
+		if valid := isValidWindowsPath(testCase.pathName); valid != testCase.valid {
+			t.Errorf("Test %d: Expected %t for %q, but instead found %t", i+1, testCase.valid, testCase.pathName, valid)
+		}
+	}
+}
@@ -21,6 +21,7 @@ package main
 import (
 	"os"
 	"strings"
+	"syscall"
 )
 
 // isValidVolname verifies a volname name in accordance with object
@@ -49,3 +50,36 @@ func mkdirAll(path string, perm os.FileMode) error {
 func removeAll(path string) error {
 	return os.RemoveAll(path)
 }
+
+// checkPathName - all names are valid on unix file systems.
+func checkPathName(pathName string) error {
+	return nil
+}
+
+// renameFile renames src to dst.
+func renameFile(src, dst string) error {
+	return os.Rename(src, dst)
+}
+
+// isCrossDeviceError - returns true if a rename failed because source
+// and destination are on different devices.
+func isCrossDeviceError(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	return ok && linkErr.Err == syscall.EXDEV
+}
+
+// removeFile removes the file or empty directory at path.
+func removeFile(path string) error {
+	return os.Remove(path)
+}
+
+// fsyncPath commits the contents of the file or directory at path
+// to stable storage.
+func fsyncPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -24,6 +24,14 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
+)
+
+// Number of attempts of a rename or remove of a file held open by
+// another process, the delay between attempts doubles each time.
+const (
+	sharingViolationRetries = 5
+	sharingViolationDelay   = 10 * time.Millisecond
 )
 
 // isValidVolname verifies a volname name in accordance with object
@@ -161,3 +169,85 @@ func removeAll(path string) error {
 	}
 	return err
 }
+
+// checkPathName - returns errFileNameInvalid if pathName cannot be
+// created on windows, e.g. object names with reserved characters.
+func checkPathName(pathName string) error {
+	if !isValidWindowsPath(pathName) {
+		return errFileNameInvalid
+	}
+	return nil
+}
+
+// isSharingViolation - returns true if err was caused by a file held
+// open by another process, e.g. an anti virus scanner or the search
+// indexer. Windows does not rename or remove such files.
+func isSharingViolation(err error) bool {
+	var errno error
+	switch e := err.(type) {
+	case *os.LinkError:
+		errno = e.Err
+	case *os.PathError:
+		errno = e.Err
+	default:
+		return false
+	}
+	// ERROR_SHARING_VIOLATION and ERROR_LOCK_VIOLATION.
+	return errno == syscall.Errno(32) || errno == syscall.Errno(33) || errno == syscall.ERROR_ACCESS_DENIED
+}
+
+// retrySharingViolation - calls fn until it does not fail with a
+// sharing violation or the attempts are exhausted.
+func retrySharingViolation(fn func() error) (err error) {
+	delay := sharingViolationDelay
+	for i := 0; i < sharingViolationRetries; i++ {
+		if err = fn(); err == nil || !isSharingViolation(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return err
+}
+
+// renameFile renames src to dst, retrying while either is held open.
+func renameFile(src, dst string) error {
+	return retrySharingViolation(func() error {
+		return os.Rename(src, dst)
+	})
+}
+
+// isCrossDeviceError - returns true if a rename failed because source
+// and destination are on different volumes.
+func isCrossDeviceError(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	// ERROR_NOT_SAME_DEVICE.
+	return ok && linkErr.Err == syscall.Errno(17)
+}
+
+// removeFile removes the file or empty directory at path, retrying
+// while it is held open.
+func removeFile(path string) error {
+	return retrySharingViolation(func() error {
+		return os.Remove(path)
+	})
+}
+
+// fsyncPath commits the contents of the file at path to stable
+// storage. Directories cannot be opened for flushing on windows,
+// their entries are persisted by the file system itself.
+func fsyncPath(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
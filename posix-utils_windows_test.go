@@ -20,6 +20,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -58,7 +59,7 @@ func TestUNCPaths(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		err = fs.AppendFile("voldir", test.objName, []byte("hello"))
+		err = fs.AppendFile(context.Background(), "voldir", test.objName, []byte("hello"))
 		if err != nil && test.pass {
 			t.Error(err)
 		} else if err == nil && !test.pass {
@@ -89,14 +90,14 @@ func TestUNCPathENOTDIR(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = fs.AppendFile("voldir", "/file", []byte("hello"))
+	err = fs.AppendFile(context.Background(), "voldir", "/file", []byte("hello"))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Try to create a file that includes a file in its path components.
 	// In *nix, this returns syscall.ENOTDIR while in windows we receive the following error.
-	err = fs.AppendFile("voldir", "/file/obj1", []byte("hello"))
+	err = fs.AppendFile(context.Background(), "voldir", "/file/obj1", []byte("hello"))
 	winErr := "The system cannot find the path specified."
 	if !strings.Contains(err.Error(), winErr) {
 		t.Errorf("expected to recieve %s, but received %s", winErr, err.Error())
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -38,8 +39,16 @@ const (
 
 // posix - implements StorageAPI interface.
 type posix struct {
-	ioErrCount  int32 // ref: https://golang.org/pkg/sync/atomic/#pkg-note-BUG
-	diskPath    string
+	// 64-bit counters updated atomically must come first to be 64-bit
+	// aligned on 32-bit platforms.
+	// ref: https://golang.org/pkg/sync/atomic/#pkg-note-BUG
+	readErrCount  int64
+	writeErrCount int64
+	ioErrCount    int32
+	diskPath      string
+	// Directory holding the meta volume if it is placed on a
+	// separate device, empty if it is on the disk itself.
+	metaPath    string
 	minFreeDisk int64
 }
 
@@ -82,8 +91,18 @@ func isDirEmpty(dirname string) bool {
 	return false
 }
 
-// Initialize a new storage disk.
+// Initialize a new storage disk, operations of the disk have deadlines.
 func newPosix(diskPath string) (StorageAPI, error) {
+	fs, err := openPosix(diskPath)
+	if fs == nil {
+		return nil, err
+	}
+	return &deadlineDisk{fs}, err
+}
+
+// openPosix - returns the local disk at diskPath, like newPosix the
+// disk is returned along errDiskNotFound if diskPath does not exist.
+func openPosix(diskPath string) (*posix, error) {
 	if diskPath == "" {
 		return nil, errInvalidArgument
 	}
@@ -95,6 +114,7 @@ func newPosix(diskPath string) (StorageAPI, error) {
 	}
 	fs := &posix{
 		diskPath:    diskPath,
+		metaPath:    globalMetaDisks[diskPath],
 		minFreeDisk: fsMinSpacePercent, // Minimum 5% disk should be free.
 	}
 	st, err := os.Stat(preparePath(diskPath))
@@ -107,6 +127,18 @@ func newPosix(diskPath string) (StorageAPI, error) {
 	if !st.IsDir() {
 		return fs, syscall.ENOTDIR
 	}
+	if fs.metaPath != "" {
+		st, err = os.Stat(preparePath(fs.metaPath))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs, errDiskNotFound
+			}
+			return fs, err
+		}
+		if !st.IsDir() {
+			return fs, syscall.ENOTDIR
+		}
+	}
 	return fs, nil
 }
 
@@ -134,6 +166,38 @@ func checkDiskFree(diskPath string, minFreeDisk int64) (err error) {
 	return nil
 }
 
+// isDiskIOError - returns true if err is caused by the disk rather
+// than by the request, e.g. a missing file is not a disk error.
+func isDiskIOError(err error) bool {
+	switch err {
+	case nil, io.EOF, errFileNotFound, errVolumeNotFound, errFileAccessDenied,
+		errVolumeAccessDenied, errIsNotRegular, errFileNameTooLong, errFileNameInvalid, errDiskFull:
+		return false
+	}
+	return true
+}
+
+// DiskInfo - returns disk usage and error statistics.
+func (s *posix) DiskInfo() (info DiskInfo, err error) {
+	if s.ioErrCount > maxAllowedIOError {
+		return DiskInfo{}, errFaultyDisk
+	}
+	di, err := disk.GetInfo(preparePath(s.diskPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DiskInfo{}, errDiskNotFound
+		}
+		return DiskInfo{}, err
+	}
+	return DiskInfo{
+		Total:         di.Total,
+		Free:          di.Free,
+		FSType:        di.FSType,
+		ReadErrCount:  atomic.LoadInt64(&s.readErrCount),
+		WriteErrCount: atomic.LoadInt64(&s.writeErrCount),
+	}, nil
+}
+
 // List all the volumes from diskPath.
 func listVols(dirPath string) ([]VolInfo, error) {
 	if err := checkPathLength(dirPath); err != nil {
@@ -179,6 +243,9 @@ func (s *posix) getVolDir(volume string) (string, error) {
 	if err := checkPathLength(volume); err != nil {
 		return "", err
 	}
+	if volume == minioMetaBucket && s.metaPath != "" {
+		return pathJoin(s.metaPath, volume), nil
+	}
 	volumeDir := pathJoin(s.diskPath, volume)
 	return volumeDir, nil
 }
@@ -349,94 +416,135 @@ func (s *posix) ListDir(volume, dirPath string) (entries []string, err error) {
 		}
 		return nil, err
 	}
-	return readDir(pathJoin(volumeDir, dirPath))
+	entries, err = readDir(pathJoin(volumeDir, encodePathName(dirPath)))
+	for i := range entries {
+		entries[i] = decodePathName(entries[i])
+	}
+	return entries, err
 }
 
 // ReadFile reads exactly len(buf) bytes into buf. It returns the
 // number of bytes copied. The error is EOF only if no bytes were
 // read. On return, n == len(buf) if and only if err == nil. n == 0
 // for io.EOF. Additionally ReadFile also starts reading from an
-// offset.
-func (s *posix) ReadFile(volume string, path string, offset int64, buf []byte) (n int64, err error) {
+// offset, it fails with ctx.Err() once ctx is done.
+func (s *posix) ReadFile(ctx context.Context, volume string, path string, offset int64, buf []byte) (n int64, err error) {
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+	file, err := s.OpenFile(volume, path, offset)
+	if err != nil {
+		return 0, err
+	}
+	// Close the reader.
+	defer file.Close()
+
+	defer func() {
+		if err == syscall.EIO {
+			atomic.AddInt32(&s.ioErrCount, 1)
+		}
+		if isDiskIOError(err) {
+			atomic.AddInt64(&s.readErrCount, 1)
+		}
+	}()
+
+	// Read file.
+	m, err := io.ReadFull(file, buf)
+
+	// Error unexpected is valid, set this back to nil.
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+
+	// Success.
+	return int64(m), err
+}
+
+// OpenFile - opens the regular file at path for reading from offset,
+// callers copy from the file directly to sockets without buffering.
+func (s *posix) OpenFile(volume string, path string, offset int64) (file *os.File, err error) {
 	defer func() {
 		if err == syscall.EIO {
 			atomic.AddInt32(&s.ioErrCount, 1)
 		}
+		if isDiskIOError(err) {
+			atomic.AddInt64(&s.readErrCount, 1)
+		}
 	}()
 
 	if s.ioErrCount > maxAllowedIOError {
-		return 0, errFaultyDisk
+		return nil, errFaultyDisk
 	}
 
 	// Validate if disk is free.
 	if err = checkDiskFree(s.diskPath, s.minFreeDisk); err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	volumeDir, err := s.getVolDir(volume)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	// Stat a volume entry.
 	_, err = os.Stat(preparePath(volumeDir))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, errVolumeNotFound
+			return nil, errVolumeNotFound
 		}
-		return 0, err
+		return nil, err
 	}
 
+	path = encodePathName(path)
 	filePath := pathJoin(volumeDir, path)
 	if err = checkPathLength(filePath); err != nil {
-		return 0, err
+		return nil, err
+	}
+	if err = checkPathName(path); err != nil {
+		return nil, err
 	}
-	file, err := os.Open(preparePath(filePath))
+	file, err = os.Open(preparePath(filePath))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, errFileNotFound
+			return nil, errFileNotFound
 		} else if os.IsPermission(err) {
-			return 0, errFileAccessDenied
+			return nil, errFileAccessDenied
 		} else if strings.Contains(err.Error(), "not a directory") {
-			return 0, errFileNotFound
+			return nil, errFileNotFound
 		}
-		return 0, err
+		return nil, err
 	}
 	st, err := file.Stat()
 	if err != nil {
-		return 0, err
+		file.Close()
+		return nil, err
 	}
 	// Verify if its not a regular file, since subsequent Seek is undefined.
 	if !st.Mode().IsRegular() {
-		return 0, errFileNotFound
+		file.Close()
+		return nil, errFileNotFound
 	}
 	// Seek to requested offset.
-	_, err = file.Seek(offset, os.SEEK_SET)
-	if err != nil {
-		return 0, err
-	}
-
-	// Close the reader.
-	defer file.Close()
-
-	// Read file.
-	m, err := io.ReadFull(file, buf)
-
-	// Error unexpected is valid, set this back to nil.
-	if err == io.ErrUnexpectedEOF {
-		err = nil
+	if _, err = file.Seek(offset, os.SEEK_SET); err != nil {
+		file.Close()
+		return nil, err
 	}
-
-	// Success.
-	return int64(m), err
+	return file, nil
 }
 
 // AppendFile - append a byte array at path, if file doesn't exist at
-// path this call explicitly creates it.
-func (s *posix) AppendFile(volume, path string, buf []byte) (err error) {
+// path this call explicitly creates it. It fails with ctx.Err() once
+// ctx is done.
+func (s *posix) AppendFile(ctx context.Context, volume, path string, buf []byte) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 	defer func() {
 		if err == syscall.EIO {
 			atomic.AddInt32(&s.ioErrCount, 1)
 		}
+		if isDiskIOError(err) {
+			atomic.AddInt64(&s.writeErrCount, 1)
+		}
 	}()
 
 	if s.ioErrCount > maxAllowedIOError {
@@ -460,10 +568,14 @@ func (s *posix) AppendFile(volume, path string, buf []byte) (err error) {
 		}
 		return err
 	}
+	path = encodePathName(path)
 	filePath := pathJoin(volumeDir, path)
 	if err = checkPathLength(filePath); err != nil {
 		return err
 	}
+	if err = checkPathName(path); err != nil {
+		return err
+	}
 	// Verify if the file already exists and is not of regular type.
 	var st os.FileInfo
 	if st, err = os.Stat(preparePath(filePath)); err == nil {
@@ -486,11 +598,139 @@ func (s *posix) AppendFile(volume, path string, buf []byte) (err error) {
 	// Close upon return.
 	defer w.Close()
 
+	// Zero blocks of sparse objects, like backups of virtual disks,
+	// are not written but left as holes by extending the file.
+	if isZeroBlock(buf) {
+		if st, err = w.Stat(); err != nil {
+			return err
+		}
+		return w.Truncate(st.Size() + int64(len(buf)))
+	}
+
 	// Return io.Copy
 	_, err = io.Copy(w, bytes.NewReader(buf))
 	return err
 }
 
+// PrepareFile - creates an empty file at path and preallocates size bytes
+// for the appends to follow, reducing fragmentation of large files.
+// File systems not supporting preallocation only create the file.
+func (s *posix) PrepareFile(volume, path string, size int64) (err error) {
+	defer func() {
+		if err == syscall.EIO {
+			atomic.AddInt32(&s.ioErrCount, 1)
+		}
+		if isDiskIOError(err) {
+			atomic.AddInt64(&s.writeErrCount, 1)
+		}
+	}()
+
+	if s.ioErrCount > maxAllowedIOError {
+		return errFaultyDisk
+	}
+
+	// Validate if disk is free.
+	if err = checkDiskFree(s.diskPath, s.minFreeDisk); err != nil {
+		return err
+	}
+
+	volumeDir, err := s.getVolDir(volume)
+	if err != nil {
+		return err
+	}
+	// Stat a volume entry.
+	_, err = os.Stat(preparePath(volumeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errVolumeNotFound
+		}
+		return err
+	}
+	path = encodePathName(path)
+	filePath := pathJoin(volumeDir, path)
+	if err = checkPathLength(filePath); err != nil {
+		return err
+	}
+	if err = checkPathName(path); err != nil {
+		return err
+	}
+	// Verify if the file already exists and is not of regular type.
+	var st os.FileInfo
+	if st, err = os.Stat(preparePath(filePath)); err == nil {
+		if st.IsDir() {
+			return errIsNotRegular
+		}
+	}
+	// Create top level directories if they don't exist.
+	if err = mkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+	w, err := os.OpenFile(preparePath(filePath), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		// File path cannot be verified since one of the parents is a file.
+		if strings.Contains(err.Error(), "not a directory") {
+			return errFileAccessDenied
+		}
+		return err
+	}
+	// Close upon return.
+	defer w.Close()
+
+	return fallocate(w, size)
+}
+
+// MakeDir - creates the directory at dirPath along with its parents,
+// existing directories are left as they are.
+func (s *posix) MakeDir(volume, dirPath string) (err error) {
+	defer func() {
+		if err == syscall.EIO {
+			atomic.AddInt32(&s.ioErrCount, 1)
+		}
+		if isDiskIOError(err) {
+			atomic.AddInt64(&s.writeErrCount, 1)
+		}
+	}()
+
+	if s.ioErrCount > maxAllowedIOError {
+		return errFaultyDisk
+	}
+
+	// Validate if disk is free.
+	if err = checkDiskFree(s.diskPath, s.minFreeDisk); err != nil {
+		return err
+	}
+
+	volumeDir, err := s.getVolDir(volume)
+	if err != nil {
+		return err
+	}
+	// Stat a volume entry.
+	_, err = os.Stat(preparePath(volumeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errVolumeNotFound
+		}
+		return err
+	}
+	dirPath = encodePathName(dirPath)
+	filePath := pathJoin(volumeDir, dirPath)
+	if err = checkPathLength(filePath); err != nil {
+		return err
+	}
+	if err = checkPathName(dirPath); err != nil {
+		return err
+	}
+	if err = mkdirAll(filePath, 0700); err != nil {
+		// Directory cannot be created since it or one of the parents
+		// is a file.
+		if strings.Contains(err.Error(), "not a directory") {
+			return errFileAccessDenied
+		}
+		return err
+	}
+	return nil
+}
+
 // StatFile - get file info.
 func (s *posix) StatFile(volume, path string) (file FileInfo, err error) {
 	defer func() {
@@ -521,10 +761,14 @@ func (s *posix) StatFile(volume, path string) (file FileInfo, err error) {
 		return FileInfo{}, err
 	}
 
+	path = encodePathName(path)
 	filePath := slashpath.Join(volumeDir, path)
 	if err = checkPathLength(filePath); err != nil {
 		return FileInfo{}, err
 	}
+	if err = checkPathName(path); err != nil {
+		return FileInfo{}, err
+	}
 	st, err := os.Stat(preparePath(filePath))
 	if err != nil {
 		// File is really not found.
@@ -540,8 +784,9 @@ func (s *posix) StatFile(volume, path string) (file FileInfo, err error) {
 		// Return all errors here.
 		return FileInfo{}, err
 	}
-	// If its a directory its not a regular file.
-	if st.Mode().IsDir() {
+	// If its a directory its not a regular file, unless a directory
+	// was asked for by a trailing slash.
+	if st.Mode().IsDir() && !strings.HasSuffix(path, slashSeparator) {
 		return FileInfo{}, errFileNotFound
 	}
 	return FileInfo{
@@ -553,7 +798,10 @@ func (s *posix) StatFile(volume, path string) (file FileInfo, err error) {
 	}, nil
 }
 
-// deleteFile - delete file path if its empty.
+// deleteFile - delete file path if its empty, its parent directories
+// are deleted as well while they are empty. Parents which cannot be
+// deleted, usually because a write to them is in flight, are queued
+// to be expired later.
 func deleteFile(basePath, deletePath string) error {
 	if basePath == deletePath {
 		return nil
@@ -573,12 +821,17 @@ func deleteFile(basePath, deletePath string) error {
 		return nil
 	}
 	// Attempt to remove path.
-	if err := os.Remove(preparePath(deletePath)); err != nil {
+	if err := removeFile(preparePath(deletePath)); err != nil {
 		return err
 	}
-	// Recursively go down the next path and delete again.
-	if err := deleteFile(basePath, slashpath.Dir(deletePath)); err != nil {
-		return err
+	// Go down the parent directories and delete them as well.
+	parentPath := slashpath.Dir(strings.TrimSuffix(deletePath, slashSeparator))
+	for isPathPrefix(parentPath, basePath) && isDirEmpty(parentPath) {
+		if err := removeFile(preparePath(parentPath)); err != nil {
+			globalEmptyDirs.queue(basePath, parentPath)
+			break
+		}
+		parentPath = slashpath.Dir(parentPath)
 	}
 	return nil
 }
@@ -615,15 +868,71 @@ func (s *posix) DeleteFile(volume, path string) (err error) {
 
 	// Following code is needed so that we retain "/" suffix if any in
 	// path argument.
+	path = encodePathName(path)
 	filePath := pathJoin(volumeDir, path)
 	if err = checkPathLength(filePath); err != nil {
 		return err
 	}
+	if err = checkPathName(path); err != nil {
+		return err
+	}
 
 	// Delete file and delete parent directory as well if its empty.
 	return deleteFile(volumeDir, filePath)
 }
 
+// moveAcrossDevices - moves the file or directory tree at src to dst on
+// another device, files are copied and flushed before src is removed.
+func moveAcrossDevices(src, dst string) error {
+	st, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err = copyAcrossDevices(src, dst, st); err != nil {
+		removeAll(dst)
+		return err
+	}
+	return removeAll(src)
+}
+
+// copyAcrossDevices - copies the file or directory tree at src to dst.
+func copyAcrossDevices(src, dst string, st os.FileInfo) error {
+	if st.IsDir() {
+		if err := mkdirAll(dst, 0700); err != nil {
+			return err
+		}
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		entries, err := f.Readdir(-1)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err = copyAcrossDevices(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), entry); err != nil {
+				return err
+			}
+		}
+		return fsyncPath(dst)
+	}
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if _, err = io.Copy(w, r); err != nil {
+		return err
+	}
+	return w.Sync()
+}
+
 // RenameFile - rename source path to destination path atomically.
 func (s *posix) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err error) {
 	defer func() {
@@ -670,6 +979,7 @@ func (s *posix) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err e
 	if !(srcIsDir && dstIsDir || !srcIsDir && !dstIsDir) {
 		return errFileAccessDenied
 	}
+	srcPath, dstPath = encodePathName(srcPath), encodePathName(dstPath)
 	srcFilePath := slashpath.Join(srcVolumeDir, srcPath)
 	if err = checkPathLength(srcFilePath); err != nil {
 		return err
@@ -678,6 +988,9 @@ func (s *posix) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err e
 	if err = checkPathLength(dstFilePath); err != nil {
 		return err
 	}
+	if err = checkPathName(dstPath); err != nil {
+		return err
+	}
 	if srcIsDir {
 		// If source is a directory we expect the destination to be non-existent always.
 		_, err = os.Stat(preparePath(dstFilePath))
@@ -701,12 +1014,48 @@ func (s *posix) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err e
 		}
 		return err
 	}
-	err = os.Rename(preparePath(srcFilePath), preparePath(dstFilePath))
+	// Flush the source before it becomes visible, a power loss right
+	// after the rename must never leave an empty destination behind.
+	if err = fsyncPath(preparePath(srcFilePath)); err != nil {
+		if os.IsNotExist(err) {
+			return errFileNotFound
+		}
+		return err
+	}
+	err = renameFile(preparePath(srcFilePath), preparePath(dstFilePath))
+	if err != nil && os.IsNotExist(err) {
+		// The parent of the destination may have been expired as an
+		// empty directory meanwhile, it is created once more.
+		if _, sErr := os.Stat(preparePath(srcFilePath)); sErr == nil {
+			if err = mkdirAll(preparePath(slashpath.Dir(dstFilePath)), 0755); err == nil {
+				err = renameFile(preparePath(srcFilePath), preparePath(dstFilePath))
+			}
+		}
+	}
+	if isCrossDeviceError(err) {
+		// Meta volume is on a separate device, data is copied instead.
+		err = moveAcrossDevices(preparePath(srcFilePath), preparePath(dstFilePath))
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return errFileNotFound
 		}
 		return err
 	}
+	// Flush the parent directories to persist the rename itself.
+	srcParentDir := slashpath.Dir(strings.TrimSuffix(srcFilePath, slashSeparator))
+	dstParentDir := slashpath.Dir(strings.TrimSuffix(dstFilePath, slashSeparator))
+	// Moving an object out may leave its prefix empty. Temporary and
+	// multipart directories of the meta volume are cleaned up by their
+	// owners.
+	if srcVolume != minioMetaBucket {
+		globalEmptyDirs.queue(srcVolumeDir, srcParentDir)
+	}
+	if err = fsyncPath(preparePath(dstParentDir)); err != nil {
+		return err
+	}
+	if srcParentDir != dstParentDir {
+		return fsyncPath(preparePath(srcParentDir))
+	}
 	return nil
 }
@@ -0,0 +1,199 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests prepared files are empty and appended to from the start.
+func TestPosixPrepareFile(t *testing.T) {
+	diskPath, err := ioutil.TempDir("", "minio-posix-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+
+	disk, err := newPosix(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol("volume"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = disk.PrepareFile("volume", "dir/file", 1024*1024); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := disk.StatFile("volume", "dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size != 0 {
+		t.Fatalf("Expected prepared file to be empty, but instead found %d bytes", fi.Size)
+	}
+
+	if err = disk.AppendFile(context.Background(), "volume", "dir/file", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err = disk.ReadFile(context.Background(), "volume", "dir/file", 0, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "hello" {
+		t.Fatalf("Expected hello, but instead found %s", buf)
+	}
+
+	// Preparing an existing file truncates it.
+	if err = disk.PrepareFile("volume", "dir/file", 5); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err = disk.StatFile("volume", "dir/file"); err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size != 0 {
+		t.Fatalf("Expected prepared file to be empty, but instead found %d bytes", fi.Size)
+	}
+
+	if err = disk.PrepareFile("volume", "dir", 5); err != errIsNotRegular {
+		t.Fatalf("Expected %s, but instead found %v", errIsNotRegular, err)
+	}
+	if err = disk.PrepareFile("missing", "file", 5); err != errVolumeNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errVolumeNotFound, err)
+	}
+}
+
+// Tests zero blocks are appended as holes which read as zeros.
+func TestPosixAppendSparse(t *testing.T) {
+	diskPath, err := ioutil.TempDir("", "minio-posix-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+
+	disk, err := newPosix(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol("volume"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := append([]byte("head"), make([]byte, 2*sparseBlockSize)...)
+	data = append(data, "tail"...)
+	for _, buf := range [][]byte{data[:4], data[4 : 4+2*sparseBlockSize], data[4+2*sparseBlockSize:]} {
+		if err = disk.AppendFile(context.Background(), "volume", "file", buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := make([]byte, len(data))
+	if _, err = disk.ReadFile(context.Background(), "volume", "file", 0, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, data) {
+		t.Fatal("Expected the appended data to be read back")
+	}
+}
+
+// Tests the meta volume is placed on its own device if configured.
+func TestPosixMetaPath(t *testing.T) {
+	diskPath, err := ioutil.TempDir("", "minio-posix-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+	metaPath, err := ioutil.TempDir("", "minio-posix-meta-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(metaPath)
+
+	globalMetaDisks = map[string]string{diskPath: metaPath}
+	defer func() { globalMetaDisks = map[string]string{} }()
+
+	disk, err := newPosix(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol(minioMetaBucket); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.AppendFile(context.Background(), minioMetaBucket, "tmp/uuid/part.1", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(metaPath, minioMetaBucket, "tmp", "uuid", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, minioMetaBucket)); !os.IsNotExist(err) {
+		t.Fatalf("Expected meta volume not to be on the disk, but instead found %v", err)
+	}
+
+	if err = disk.RenameFile(minioMetaBucket, "tmp/uuid/", "bucket", "object/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, "bucket", "object", "part.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Meta path has to exist.
+	globalMetaDisks[diskPath] = filepath.Join(metaPath, "missing")
+	if _, err = newPosix(diskPath); err != errDiskNotFound {
+		t.Fatalf("Expected %s, but instead found %v", errDiskNotFound, err)
+	}
+}
+
+// Tests directory trees are moved by copying them.
+func TestMoveAcrossDevices(t *testing.T) {
+	srcPath, err := ioutil.TempDir("", "minio-posix-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(srcPath)
+	dstPath, err := ioutil.TempDir("", "minio-posix-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dstPath)
+
+	if err = mkdirAll(filepath.Join(srcPath, "src", "dir"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(srcPath, "src", "dir", "file"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = moveAcrossDevices(filepath.Join(srcPath, "src"), filepath.Join(dstPath, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dstPath, "dst", "dir", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("Expected hello, but instead found %s", data)
+	}
+	if _, err = os.Stat(filepath.Join(srcPath, "src")); !os.IsNotExist(err) {
+		t.Fatalf("Expected source to be removed, but instead found %v", err)
+	}
+}
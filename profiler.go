@@ -0,0 +1,179 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+)
+
+// Profiler types supported by the admin API.
+const (
+	profilerCPU       = "cpu"
+	profilerMem       = "mem"
+	profilerBlock     = "block"
+	profilerGoroutine = "goroutine"
+)
+
+var (
+	errProfilerInvalidType = errors.New("Unsupported profiler type")
+	errProfilerNotEnabled  = errors.New("Profiling is not enabled")
+)
+
+// profiler - a running profiler, Stop returns the collected profile.
+type profiler interface {
+	Stop() ([]byte, error)
+}
+
+// cpuProfiler - samples CPU usage until stopped.
+type cpuProfiler struct {
+	buffer *bytes.Buffer
+}
+
+func startCPUProfiler() (profiler, error) {
+	p := &cpuProfiler{buffer: new(bytes.Buffer)}
+	if err := pprof.StartCPUProfile(p.buffer); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *cpuProfiler) Stop() ([]byte, error) {
+	pprof.StopCPUProfile()
+	return p.buffer.Bytes(), nil
+}
+
+// lookupProfiler - writes a snapshot of a runtime profile when
+// stopped, stop is called to undo any runtime settings.
+type lookupProfiler struct {
+	name string
+	stop func()
+}
+
+func (p *lookupProfiler) Stop() ([]byte, error) {
+	if p.stop != nil {
+		defer p.stop()
+	}
+	buffer := new(bytes.Buffer)
+	if err := pprof.Lookup(p.name).WriteTo(buffer, 0); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// startProfiler - starts a profiler of profilerType.
+func startProfiler(profilerType string) (profiler, error) {
+	switch profilerType {
+	case profilerCPU:
+		return startCPUProfiler()
+	case profilerMem:
+		return &lookupProfiler{name: "heap"}, nil
+	case profilerBlock:
+		runtime.SetBlockProfileRate(1)
+		return &lookupProfiler{name: "block", stop: func() { runtime.SetBlockProfileRate(0) }}, nil
+	case profilerGoroutine:
+		return &lookupProfiler{name: "goroutine"}, nil
+	}
+	return nil, errProfilerInvalidType
+}
+
+// profilers - profilers started through the admin API.
+type profilers struct {
+	mutex   sync.Mutex
+	running map[string]profiler
+}
+
+// Start - starts profilers of all profilerTypes, replacing any
+// running profilers.
+func (ps *profilers) Start(profilerTypes []string) error {
+	for _, profilerType := range profilerTypes {
+		switch profilerType {
+		case profilerCPU, profilerMem, profilerBlock, profilerGoroutine:
+		default:
+			return errProfilerInvalidType
+		}
+	}
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.stop()
+	ps.running = make(map[string]profiler)
+	for _, profilerType := range profilerTypes {
+		p, err := startProfiler(profilerType)
+		if err != nil {
+			ps.stop()
+			return err
+		}
+		ps.running[profilerType] = p
+	}
+	return nil
+}
+
+// stop - stops all running profilers and returns their profiles.
+func (ps *profilers) stop() (map[string][]byte, error) {
+	profiles := make(map[string][]byte)
+	var firstErr error
+	for profilerType, p := range ps.running {
+		data, err := p.Stop()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		profiles[profilerType] = data
+	}
+	ps.running = nil
+	return profiles, firstErr
+}
+
+// Download - stops all running profilers and writes their profiles
+// as a zip archive with one 'profile-<type>.pprof' entry per profiler.
+func (ps *profilers) Download(w io.Writer) error {
+	ps.mutex.Lock()
+	if len(ps.running) == 0 {
+		ps.mutex.Unlock()
+		return errProfilerNotEnabled
+	}
+	profiles, err := ps.stop()
+	ps.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var profilerTypes []string
+	for profilerType := range profiles {
+		profilerTypes = append(profilerTypes, profilerType)
+	}
+	sort.Strings(profilerTypes)
+
+	zipWriter := zip.NewWriter(w)
+	for _, profilerType := range profilerTypes {
+		fileWriter, err := zipWriter.Create("profile-" + profilerType + ".pprof")
+		if err != nil {
+			return err
+		}
+		if _, err = fileWriter.Write(profiles[profilerType]); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+// Global profilers started through the admin API.
+var globalProfilers = &profilers{}
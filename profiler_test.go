@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests profiling through the admin API.
+func TestAdminProfiling(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Data can only be downloaded while profiling.
+	if _, err = client.DownloadProfilingData(); madmin.ToErrorResponse(err).Code != "XMinioAdminProfilerNotEnabled" {
+		t.Fatalf("Expected XMinioAdminProfilerNotEnabled, but instead found %v", err)
+	}
+	if err = client.StartProfiling("unknown"); madmin.ToErrorResponse(err).Code != "XMinioAdminInvalidProfilerType" {
+		t.Fatalf("Expected XMinioAdminInvalidProfilerType, but instead found %v", err)
+	}
+
+	testCases := []struct {
+		profilers []madmin.ProfilerType
+		files     []string
+	}{
+		// Test case - 1.
+		{[]madmin.ProfilerType{madmin.ProfilerCPU}, []string{"profile-cpu.pprof"}},
+		// Test case - 2.
+		{
+			[]madmin.ProfilerType{madmin.ProfilerMem, madmin.ProfilerBlock, madmin.ProfilerGoroutine},
+			[]string{"profile-block.pprof", "profile-goroutine.pprof", "profile-mem.pprof"},
+		},
+	}
+	for i, testCase := range testCases {
+		if err = client.StartProfiling(testCase.profilers...); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		reader, err := client.DownloadProfilingData()
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if len(zipReader.File) != len(testCase.files) {
+			t.Fatalf("Test %d: Expected %d profiles, but instead found %d", i+1, len(testCase.files), len(zipReader.File))
+		}
+		for j, file := range zipReader.File {
+			if file.Name != testCase.files[j] {
+				t.Errorf("Test %d: Expected %s, but instead found %s", i+1, testCase.files[j], file.Name)
+			}
+		}
+	}
+
+	// Profilers are stopped once downloaded.
+	if _, err = client.DownloadProfilingData(); madmin.ToErrorResponse(err).Code != "XMinioAdminProfilerNotEnabled" {
+		t.Fatalf("Expected XMinioAdminProfilerNotEnabled, but instead found %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time a queued request waits for a free slot before it is rejected.
+const requestsDeadline = 10 * time.Second
+
+// apiConfig - limits of concurrent API requests, zero means unlimited.
+// Requests exceeding RequestsMax wait in a queue of QueueMax requests,
+// requests exceeding RequestsPerIP of a client are rejected right away.
+type apiConfig struct {
+	RequestsMax   int `json:"requestsMax"`
+	RequestsPerIP int `json:"requestsPerIP"`
+	QueueMax      int `json:"queueMax"`
+}
+
+// requestLimiter - tracks concurrent requests overall and per client.
+type requestLimiter struct {
+	config apiConfig
+	// Slots of concurrent requests, nil if unlimited.
+	slots chan struct{}
+
+	mutex  *sync.Mutex
+	queued int
+	perIP  map[string]int
+}
+
+// newRequestLimiter - returns a limiter for config.
+func newRequestLimiter(config apiConfig) *requestLimiter {
+	l := &requestLimiter{
+		config: config,
+		mutex:  &sync.Mutex{},
+		perIP:  make(map[string]int),
+	}
+	if config.RequestsMax > 0 {
+		l.slots = make(chan struct{}, config.RequestsMax)
+	}
+	return l
+}
+
+// acquireIP - returns false if the client has too many concurrent requests.
+func (l *requestLimiter) acquireIP(ip string) bool {
+	if l.config.RequestsPerIP <= 0 {
+		return true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.perIP[ip] >= l.config.RequestsPerIP {
+		return false
+	}
+	l.perIP[ip]++
+	return true
+}
+
+// releaseIP - releases a request acquired by acquireIP.
+func (l *requestLimiter) releaseIP(ip string) {
+	if l.config.RequestsPerIP <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// acquire - waits for a free slot, returns false if the queue is full,
+// the deadline passed or the client went away.
+func (l *requestLimiter) acquire(doneCh <-chan struct{}) bool {
+	if l.slots == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	l.mutex.Lock()
+	if l.queued >= l.config.QueueMax {
+		l.mutex.Unlock()
+		return false
+	}
+	l.queued++
+	l.mutex.Unlock()
+	defer func() {
+		l.mutex.Lock()
+		l.queued--
+		l.mutex.Unlock()
+	}()
+
+	timer := time.NewTimer(requestsDeadline)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-doneCh:
+		return false
+	}
+}
+
+// release - releases a slot acquired by acquire.
+func (l *requestLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// isRateLimitExempt - returns true for requests overloaded servers must
+// answer as well: health checks and storage RPC of other nodes, which
+// would otherwise stall requests holding slots on those nodes.
+func isRateLimitExempt(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, healthCheckPathPrefix) || r.URL.Path == storageRPCPath
+}
+
+// rateLimitHandler - limits concurrent requests.
+type rateLimitHandler struct {
+	handler http.Handler
+	limiter *requestLimiter
+}
+
+func setRateLimitHandler(h http.Handler) http.Handler {
+	return rateLimitHandler{handler: h, limiter: newRequestLimiter(serverConfig.GetAPI())}
+}
+
+func (h rateLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isRateLimitExempt(r) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !h.limiter.acquireIP(ip) {
+		writeErrorResponse(w, r, ErrSlowDown, r.URL.Path)
+		return
+	}
+	defer h.limiter.releaseIP(ip)
+	if !h.limiter.acquire(r.Context().Done()) {
+		writeErrorResponse(w, r, ErrSlowDown, r.URL.Path)
+		return
+	}
+	defer h.limiter.release()
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newBlockingHandler - returns a handler blocking until unblockCh is
+// closed, every request entering the handler is sent on startedCh.
+func newBlockingHandler(startedCh chan<- struct{}, unblockCh <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startedCh <- struct{}{}
+		<-unblockCh
+	})
+}
+
+// serveTestRequest - serves a GET request of a client at remoteAddr,
+// returns the response code.
+func serveTestRequest(handler http.Handler, remoteAddr string) int {
+	req, _ := http.NewRequest("GET", "http://localhost/bucket/object", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// Tests concurrent requests of a single client are limited.
+func TestRateLimitHandlerPerIP(t *testing.T) {
+	startedCh := make(chan struct{}, 3)
+	unblockCh := make(chan struct{})
+	handler := rateLimitHandler{
+		handler: newBlockingHandler(startedCh, unblockCh),
+		limiter: newRequestLimiter(apiConfig{RequestsPerIP: 1}),
+	}
+
+	codeCh := make(chan int)
+	go func() { codeCh <- serveTestRequest(handler, "10.0.0.1:1000") }()
+	<-startedCh
+
+	if code := serveTestRequest(handler, "10.0.0.1:1001"); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected %d, but instead found %d", http.StatusServiceUnavailable, code)
+	}
+	// Other clients are not affected.
+	go func() { codeCh <- serveTestRequest(handler, "10.0.0.2:1000") }()
+	<-startedCh
+
+	close(unblockCh)
+	for i := 0; i < 2; i++ {
+		if code := <-codeCh; code != http.StatusOK {
+			t.Fatalf("Expected %d, but instead found %d", http.StatusOK, code)
+		}
+	}
+	// Slots of finished requests are released.
+	if code := serveTestRequest(handler, "10.0.0.1:1002"); code != http.StatusOK {
+		t.Fatalf("Expected %d, but instead found %d", http.StatusOK, code)
+	}
+}
+
+// Tests requests exceeding the global limit are queued up to the
+// maximum queue depth.
+func TestRateLimitHandlerQueue(t *testing.T) {
+	startedCh := make(chan struct{}, 3)
+	unblockCh := make(chan struct{})
+	limiter := newRequestLimiter(apiConfig{RequestsMax: 1, QueueMax: 1})
+	handler := rateLimitHandler{
+		handler: newBlockingHandler(startedCh, unblockCh),
+		limiter: limiter,
+	}
+
+	codeCh := make(chan int)
+	go func() { codeCh <- serveTestRequest(handler, "10.0.0.1:1000") }()
+	<-startedCh
+	go func() { codeCh <- serveTestRequest(handler, "10.0.0.2:1000") }()
+	for i := 0; ; i++ {
+		limiter.mutex.Lock()
+		queued := limiter.queued
+		limiter.mutex.Unlock()
+		if queued == 1 {
+			break
+		}
+		if i == 500 {
+			t.Fatal("Expected request to be queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Queue is full.
+	if code := serveTestRequest(handler, "10.0.0.3:1000"); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected %d, but instead found %d", http.StatusServiceUnavailable, code)
+	}
+	// Health checks are never limited.
+	req, _ := http.NewRequest("GET", "http://localhost"+healthCheckPathPrefix+"/live", nil)
+	go handler.ServeHTTP(httptest.NewRecorder(), req)
+	<-startedCh
+	// Neither is RPC between the nodes.
+	req, _ = http.NewRequest("CONNECT", "http://localhost"+storageRPCPath, nil)
+	go handler.ServeHTTP(httptest.NewRecorder(), req)
+	<-startedCh
+
+	close(unblockCh)
+	for i := 0; i < 2; i++ {
+		if code := <-codeCh; code != http.StatusOK {
+			t.Fatalf("Expected %d, but instead found %d", http.StatusOK, code)
+		}
+	}
+}
+
+// Tests requests exempt from rate limiting.
+func TestIsRateLimitExempt(t *testing.T) {
+	testCases := []struct {
+		path   string
+		exempt bool
+	}{
+		{healthCheckPathPrefix + "/live", true},
+		{healthCheckPathPrefix + "/ready", true},
+		{storageRPCPath, true},
+		{reservedBucket + "/webrpc", false},
+		{adminAPIPathPrefix + "/info", false},
+		{"/bucket/object", false},
+		{"/bucket" + storageRPCPath, false},
+	}
+	for i, testCase := range testCases {
+		req, _ := http.NewRequest("GET", "http://localhost"+testCase.path, nil)
+		if exempt := isRateLimitExempt(req); exempt != testCase.exempt {
+			t.Errorf("Test %d: Expected %v, but instead found %v", i+1, testCase.exempt, exempt)
+		}
+	}
+}
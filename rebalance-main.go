@@ -0,0 +1,67 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/console"
+)
+
+var rebalanceCmd = cli.Command{
+	Name:   "rebalance",
+	Usage:  "Move objects to their erasure set after sets were added.",
+	Action: rebalanceMain,
+	CustomHelpTemplate: `NAME:
+  minio {{.Name}} - {{.Usage}}
+
+USAGE:
+  minio {{.Name}} PATH...
+
+  Objects are placed on an erasure set by a hash of their name, adding
+  sets changes the set of most objects. Servers find objects on their
+  previous set until they are moved by this command. Objects are read
+  and written through the object layer of the PATHs directly, run it
+  only while the server is stopped. Running servers rebalance in the
+  background through the admin API instead.
+
+EXAMPLES:
+  1. Move objects after adding a set of 16 disks to a set of 16 disks.
+      $ minio {{.Name}} /mnt/export{1..32}
+`,
+}
+
+func rebalanceMain(c *cli.Context) {
+	if len(c.Args()) == 0 {
+		cli.ShowCommandHelpAndExit(c, "rebalance", 1)
+	}
+
+	objAPI, err := newObjectLayer(c.Args())
+	fatalIf(err, "Unable to initialize object layer.")
+	sets, ok := objAPI.(*xlSets)
+	if !ok {
+		fatalIf(errInvalidArgument, "Only disks of erasure sets are rebalanced.")
+	}
+
+	start := time.Now()
+	var progress rebalanceProgress
+	err = sets.rebalance(&progress, false, func(int64) error { return nil })
+	fatalIf(err, "Unable to rebalance objects.")
+	console.Printf("Moved %d objects (%s) in %s, %d stale copies removed, %d failed.\n", progress.Moved,
+		humanize.IBytes(uint64(progress.Size)), time.Since(start).Round(time.Millisecond), progress.Removed, progress.Failed)
+}
@@ -0,0 +1,290 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Background rebalance state in minioMetaBucket.
+const rebalanceStateFile = "rebalance.json"
+
+// Rebalance statuses.
+const (
+	rebalanceRunning  = "running"
+	rebalancePaused   = "paused"
+	rebalanceFinished = "finished"
+	rebalanceFailed   = "failed"
+)
+
+// Interval the progress of a running rebalance is saved at.
+var rebalanceSaveInterval = 10 * time.Second
+
+var (
+	// errRebalanceRunning - rebalance cannot be started, one is running.
+	errRebalanceRunning = errors.New("Rebalance already running")
+
+	// errRebalanceNotRunning - rebalance cannot be paused or resumed.
+	errRebalanceNotRunning = errors.New("No rebalance running or paused")
+)
+
+// rebalanceSetUsage - disk usage and decommission state of an erasure
+// set.
+type rebalanceSetUsage struct {
+	Total int64  `json:"total"`
+	Free  int64  `json:"free"`
+	State string `json:"state,omitempty"`
+	// Small objects are placed on the set.
+	Small bool `json:"small,omitempty"`
+}
+
+// rebalanceStatus - state of the background rebalance.
+type rebalanceStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Only decommissioned sets are drained.
+	Drain bool `json:"drain,omitempty"`
+	// Bytes moved per second, unlimited if 0.
+	Bandwidth int64             `json:"bandwidth"`
+	Started   time.Time         `json:"started"`
+	Updated   time.Time         `json:"updated"`
+	Progress  rebalanceProgress `json:"progress"`
+	// Usage of every set and the size up to which objects are placed on
+	// the small object sets, only filled when reporting the status.
+	Sets            []rebalanceSetUsage `json:"sets,omitempty"`
+	SmallObjectSize int64               `json:"smallObjectSize,omitempty"`
+}
+
+// rebalancer - moves objects to the erasure sets they hash to in the
+// background, so that objects written before an expansion spread to
+// the new sets.
+type rebalancer struct {
+	mutex  sync.Mutex
+	sets   *xlSets
+	status rebalanceStatus
+	// Increased whenever a rebalance goroutine is started, older
+	// goroutines stop on their next object.
+	run   int
+	saved time.Time
+	// Serializes saves so that the last state marshaled is saved last.
+	saveMutex sync.Mutex
+}
+
+// globalRebalancer - rebalances the erasure sets, nil for other
+// object layers.
+var globalRebalancer *rebalancer
+
+// newRebalancer - loads the saved rebalance state of sets and continues
+// a rebalance which was running when the server stopped.
+func newRebalancer(sets *xlSets) (*rebalancer, error) {
+	r := &rebalancer{sets: sets}
+	data, err := loadMetaFile(sets, rebalanceStateFile)
+	if err == errFileNotFound {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &r.status); err != nil {
+		return nil, err
+	}
+	if r.status.Status == rebalanceRunning {
+		r.mutex.Lock()
+		r.startLocked()
+		r.mutex.Unlock()
+	}
+	return r, nil
+}
+
+// Start - starts a rebalance from the first set, moving at most
+// bandwidth bytes per second if bandwidth is not 0. A paused rebalance
+// is started over.
+func (r *rebalancer) Start(bandwidth int64) error {
+	r.mutex.Lock()
+	if r.status.Status == rebalanceRunning {
+		r.mutex.Unlock()
+		return errRebalanceRunning
+	}
+	now := time.Now().UTC()
+	r.status = rebalanceStatus{
+		Status:    rebalanceRunning,
+		Bandwidth: bandwidth,
+		Started:   now,
+		Updated:   now,
+	}
+	r.startLocked()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Decommission - stops placing objects on the set with index and
+// starts draining it, the set is removed from the layout once it is
+// empty.
+func (r *rebalancer) Decommission(index int, bandwidth int64) error {
+	r.mutex.Lock()
+	if r.status.Status == rebalanceRunning {
+		r.mutex.Unlock()
+		return errRebalanceRunning
+	}
+	if err := r.sets.decommission(index); err != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	now := time.Now().UTC()
+	r.status = rebalanceStatus{
+		Status:    rebalanceRunning,
+		Drain:     true,
+		Bandwidth: bandwidth,
+		Started:   now,
+		Updated:   now,
+	}
+	r.startLocked()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Pause - pauses a running rebalance, it stops after the object being
+// moved.
+func (r *rebalancer) Pause() error {
+	r.mutex.Lock()
+	if r.status.Status != rebalanceRunning {
+		r.mutex.Unlock()
+		return errRebalanceNotRunning
+	}
+	r.status.Status = rebalancePaused
+	r.status.Updated = time.Now().UTC()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Resume - continues a paused rebalance where it stopped.
+func (r *rebalancer) Resume() error {
+	r.mutex.Lock()
+	if r.status.Status != rebalancePaused {
+		r.mutex.Unlock()
+		return errRebalanceNotRunning
+	}
+	r.status.Status = rebalanceRunning
+	r.status.Error = ""
+	r.status.Updated = time.Now().UTC()
+	r.startLocked()
+	r.mutex.Unlock()
+	return r.save()
+}
+
+// Status - returns the rebalance state with the current usage and
+// placement of every set.
+func (r *rebalancer) Status() rebalanceStatus {
+	r.mutex.Lock()
+	status := r.status
+	r.mutex.Unlock()
+	for i, set := range r.sets.sets {
+		info := set.StorageInfo()
+		status.Sets = append(status.Sets, rebalanceSetUsage{
+			Total: info.Total,
+			Free:  info.Free,
+			State: r.sets.getSetState(i),
+			Small: r.sets.isSmallObjectSet(i),
+		})
+	}
+	status.SmallObjectSize = r.sets.getSmallObjectSize()
+	return status
+}
+
+// startLocked - starts a rebalance goroutine continuing at the saved
+// progress, must be called with the mutex held.
+func (r *rebalancer) startLocked() {
+	r.run++
+	go r.rebalance(r.run, r.status.Progress, r.status.Drain)
+}
+
+// rebalance - moves objects until all are on their sets or the
+// rebalance is paused or started over, then removes the drained
+// decommissioned sets.
+func (r *rebalancer) rebalance(run int, progress rebalanceProgress, drain bool) {
+	err := r.sets.rebalance(&progress, drain, func(size int64) error {
+		return r.next(run, progress, size)
+	})
+	// Removing drained sets saves the layout.
+	if err == nil && !serverModeAllowsWrites() {
+		err = errRebalanceMaintenance
+	}
+	if err == nil {
+		err = r.sets.removeDecommissioned()
+	}
+	r.mutex.Lock()
+	if run != r.run || err == errRebalanceStopped {
+		r.mutex.Unlock()
+		return
+	}
+	r.status.Progress = progress
+	r.status.Updated = time.Now().UTC()
+	switch err {
+	case nil:
+		r.status.Status = rebalanceFinished
+	case errRebalanceMaintenance:
+		r.status.Status = rebalancePaused
+		r.status.Error = err.Error()
+	default:
+		errorIf(err, "Unable to rebalance erasure sets.")
+		r.status.Status = rebalanceFailed
+		r.status.Error = err.Error()
+	}
+	r.mutex.Unlock()
+	errorIf(r.save(), "Unable to save rebalance state.")
+}
+
+// next - records progress after an object of size bytes was moved and
+// throttles to the bandwidth, returns errRebalanceStopped if the
+// rebalance of run was paused or started over.
+func (r *rebalancer) next(run int, progress rebalanceProgress, size int64) error {
+	r.mutex.Lock()
+	if run != r.run || r.status.Status != rebalanceRunning {
+		r.mutex.Unlock()
+		return errRebalanceStopped
+	}
+	r.status.Progress = progress
+	r.status.Updated = time.Now().UTC()
+	bandwidth := r.status.Bandwidth
+	save := time.Since(r.saved) >= rebalanceSaveInterval
+	r.mutex.Unlock()
+
+	if save {
+		errorIf(r.save(), "Unable to save rebalance state.")
+	}
+	if bandwidth > 0 && size > 0 {
+		time.Sleep(time.Duration(float64(size) / float64(bandwidth) * float64(time.Second)))
+	}
+	return nil
+}
+
+// save - saves the rebalance state.
+func (r *rebalancer) save() error {
+	r.saveMutex.Lock()
+	defer r.saveMutex.Unlock()
+	r.mutex.Lock()
+	data, err := json.Marshal(r.status)
+	r.saved = time.Now()
+	r.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return saveMetaFile(r.sets, rebalanceStateFile, data)
+}
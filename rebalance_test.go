@@ -0,0 +1,218 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// waitRebalance - waits until the rebalance is no longer running.
+func waitRebalance(t *testing.T, r *rebalancer) rebalanceStatus {
+	for i := 0; i < 1000; i++ {
+		if status := r.Status(); status.Status != rebalanceRunning {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Rebalance did not stop")
+	return rebalanceStatus{}
+}
+
+// Tests pausing, resuming and finishing a background rebalance after
+// the erasure sets were expanded.
+func TestRebalancer(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks[:16])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	objAPI, err = newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := objAPI.(*xlSets)
+
+	r, err := newRebalancer(sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := r.Status(); status.Status != "" || len(status.Sets) != 2 {
+		t.Fatalf("Unexpected initial status %#v", status)
+	}
+	if err = r.Pause(); err != errRebalanceNotRunning {
+		t.Fatalf("Expected %v, got %v", errRebalanceNotRunning, err)
+	}
+
+	// Throttled to 10 objects per second.
+	if err = r.Start(int64(len(data)) * 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Start(0); err != errRebalanceRunning {
+		t.Fatalf("Expected %v, got %v", errRebalanceRunning, err)
+	}
+	if err = r.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	status := waitRebalance(t, r)
+	if status.Status != rebalancePaused {
+		t.Fatalf("Expected paused rebalance, got %#v", status)
+	}
+
+	// The paused state survives a restart.
+	r, err = newRebalancer(sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status = r.Status(); status.Status != rebalancePaused {
+		t.Fatalf("Expected paused rebalance after reload, got %#v", status)
+	}
+
+	// Nothing is moved while writes are rejected.
+	defer globalServerMode.Set(serverModeOnline)
+	if err = globalServerMode.Set(serverModeMaintenance); err != nil {
+		t.Fatal(err)
+	}
+	moved := status.Progress.Moved
+	if err = r.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	status = waitRebalance(t, r)
+	if status.Status != rebalancePaused || status.Error != errRebalanceMaintenance.Error() || status.Progress.Moved != moved {
+		t.Fatalf("Expected rebalance paused for maintenance, got %#v", status)
+	}
+	if err = globalServerMode.Set(serverModeOnline); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	status = waitRebalance(t, r)
+	if status.Status != rebalanceFinished || status.Progress.Moved == 0 || status.Progress.Failed != 0 {
+		t.Fatalf("Expected finished rebalance, got %#v", status)
+	}
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		if _, err = sets.sets[sets.getHashedSet(object)].GetObjectInfo("bucket", object); err != nil {
+			t.Fatalf("Object %s not on its set after rebalance: %v", object, err)
+		}
+	}
+	if err = r.Resume(); err != errRebalanceNotRunning {
+		t.Fatalf("Expected %v, got %v", errRebalanceNotRunning, err)
+	}
+}
+
+// Tests draining an erasure set and starting without its disks.
+func TestRebalancerDecommission(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sets := objAPI.(*xlSets)
+	r, err := newRebalancer(sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Decommission(2, 0); err != errInvalidDecommission {
+		t.Fatalf("Expected %v, got %v", errInvalidDecommission, err)
+	}
+
+	// Sets are not drained while writes are rejected.
+	defer globalServerMode.Set(serverModeOnline)
+	if err = globalServerMode.Set(serverModeReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Decommission(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	status := waitRebalance(t, r)
+	if status.Status != rebalancePaused || status.Progress.Moved != 0 || status.Sets[0].State != xlSetDecommissioning {
+		t.Fatalf("Expected drain paused while read-only, got %#v", status)
+	}
+	if err = globalServerMode.Set(serverModeOnline); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	status = waitRebalance(t, r)
+	if status.Status != rebalanceFinished || !status.Drain || status.Progress.Moved == 0 {
+		t.Fatalf("Expected finished drain, got %#v", status)
+	}
+	if status.Sets[0].State != xlSetRemoved || status.Sets[1].State != "" {
+		t.Fatalf("Unexpected set states %#v", status.Sets)
+	}
+	// Objects are not placed on the last set nor is it decommissioned.
+	if sets.getHashedSet("object") != 1 {
+		t.Fatal("Expected objects to be placed on the remaining set")
+	}
+	if err = r.Decommission(1, 0); err != errInvalidDecommission {
+		t.Fatalf("Expected %v, got %v", errInvalidDecommission, err)
+	}
+
+	// Disks of the removed set are refused.
+	if _, err = newObjectLayer(disks); err == nil {
+		t.Fatal("Expected decommissioned disks to be refused")
+	}
+	objAPI, err = newObjectLayer(disks[16:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		buffer := new(bytes.Buffer)
+		if err = objAPI.GetObject(context.Background(), "bucket", object, 0, int64(len(data)), buffer); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+			t.Fatalf("Unable to read %s after decommission: %v", object, err)
+		}
+	}
+}
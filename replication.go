@@ -0,0 +1,425 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Replication configuration file saved in bucket config path.
+	bucketReplicationConfigFile = "replication.json"
+
+	// Marks requests sent by the replication queue, which are not
+	// replicated again to avoid loops between active-active targets.
+	replicationHeader = "X-Minio-Replication"
+
+	// Number of operations queued for replication before new
+	// operations are dropped, dropped objects are copied by a resync.
+	replicationQueueSize = 10000
+
+	// Number of concurrent replication workers.
+	replicationWorkers = 4
+
+	// Attempts and the interval between attempts of an operation.
+	replicationRetries       = 3
+	replicationRetryInterval = time.Second
+)
+
+// bucketReplicationConfig - replication target of a bucket, only objects
+// with one of the prefixes are replicated if any are configured.
+type bucketReplicationConfig struct {
+	Endpoint  string   `json:"endpoint"`
+	Region    string   `json:"region,omitempty"`
+	Bucket    string   `json:"bucket"`
+	AccessKey string   `json:"accessKey"`
+	SecretKey string   `json:"secretKey"`
+	Prefixes  []string `json:"prefixes,omitempty"`
+}
+
+// validateBucketReplicationConfig - validates a replication configuration
+// without connecting to the target.
+func validateBucketReplicationConfig(config bucketReplicationConfig) error {
+	if _, err := newS3Client(config.Endpoint, config.Region, nil); err != nil {
+		return err
+	}
+	if !IsValidBucketName(config.Bucket) {
+		return BucketNameInvalid{Bucket: config.Bucket}
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return errors.New("Replication target credentials are missing")
+	}
+	return nil
+}
+
+// matches - returns true if object is replicated.
+func (c bucketReplicationConfig) matches(object string) bool {
+	if len(c.Prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range c.Prefixes {
+		if strings.HasPrefix(object, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBucketReplicationConfig - read bucket replication configuration.
+func readBucketReplicationConfig(bucket string) (*bucketReplicationConfig, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, bucketReplicationConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, BucketReplicationConfigNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	config := &bucketReplicationConfig{}
+	if err = json.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeBucketReplicationConfig - save bucket replication configuration,
+// readable only by the server since it holds the target credentials.
+func writeBucketReplicationConfig(bucket string, config *bucketReplicationConfig) error {
+	// Verify if bucket path legal
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfigFile(filepath.Join(bucketConfigPath, bucketReplicationConfigFile), configBytes)
+}
+
+// removeBucketReplication - remove bucket replication configuration.
+func removeBucketReplication(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return removeBucketConfigFile(filepath.Join(bucketConfigPath, bucketReplicationConfigFile))
+}
+
+// ReplicationStats - replication metrics of a bucket.
+type ReplicationStats struct {
+	// Operations waiting in the queue.
+	Pending int64 `json:"pending"`
+	// Operations replicated successfully.
+	Replicated int64 `json:"replicated"`
+	// Operations which failed or were dropped from a full queue.
+	Failed int64 `json:"failed"`
+	// Size of all replicated objects.
+	ReplicatedBytes int64 `json:"replicatedBytes"`
+	// Time between queueing and completion of the last replicated
+	// operation.
+	LagSeconds float64 `json:"lagSeconds"`
+	// Completion time of the last replicated operation.
+	LastReplicated time.Time `json:"lastReplicated"`
+}
+
+// replicationOp - object created or deleted since it was queued.
+type replicationOp struct {
+	bucket string
+	object string
+	delete bool
+	// Objects of a resync are only copied if they differ on the target.
+	resync bool
+	queued time.Time
+}
+
+// replicationTarget - parsed replication configuration of a bucket.
+type replicationTarget struct {
+	config bucketReplicationConfig
+	client *s3Client
+}
+
+// replicationSys - asynchronously mirrors new objects and deletes to the
+// replication targets of buckets.
+type replicationSys struct {
+	objAPI ObjectLayer
+	queue  chan replicationOp
+
+	mutex *sync.Mutex
+	// Targets of buckets, nil for buckets without replication.
+	targets map[string]*replicationTarget
+	stats   map[string]*ReplicationStats
+}
+
+// globalReplication - replication queue of the server.
+var globalReplication *replicationSys
+
+// newReplicationSys - initializes a replication queue of objAPI, start
+// replicating with run.
+func newReplicationSys(objAPI ObjectLayer) *replicationSys {
+	return &replicationSys{
+		objAPI:  objAPI,
+		queue:   make(chan replicationOp, replicationQueueSize),
+		mutex:   &sync.Mutex{},
+		targets: make(map[string]*replicationTarget),
+		stats:   make(map[string]*ReplicationStats),
+	}
+}
+
+// run - starts the replication workers.
+func (sys *replicationSys) run() {
+	for i := 0; i < replicationWorkers; i++ {
+		go func() {
+			for op := range sys.queue {
+				sys.process(op)
+			}
+		}()
+	}
+}
+
+// getTarget - returns the replication target of bucket, nil if the
+// bucket is not replicated.
+func (sys *replicationSys) getTarget(bucket string) (*replicationTarget, error) {
+	sys.mutex.Lock()
+	target, ok := sys.targets[bucket]
+	sys.mutex.Unlock()
+	if ok {
+		return target, nil
+	}
+	config, err := readBucketReplicationConfig(bucket)
+	if err != nil {
+		if _, ok = err.(BucketReplicationConfigNotFound); !ok {
+			return nil, err
+		}
+	} else {
+		client, err := newS3Client(config.Endpoint, config.Region, func() credential {
+			return credential{AccessKeyID: config.AccessKey, SecretAccessKey: config.SecretKey}
+		})
+		if err != nil {
+			return nil, err
+		}
+		client.header = http.Header{replicationHeader: {"true"}}
+		target = &replicationTarget{config: *config, client: client}
+	}
+	sys.mutex.Lock()
+	sys.targets[bucket] = target
+	sys.mutex.Unlock()
+	return target, nil
+}
+
+// invalidate - drops the cached replication target of bucket, called
+// when the replication configuration changes.
+func (sys *replicationSys) invalidate(bucket string) {
+	sys.mutex.Lock()
+	delete(sys.targets, bucket)
+	sys.mutex.Unlock()
+}
+
+// updateStats - updates the replication metrics of bucket.
+func (sys *replicationSys) updateStats(bucket string, fn func(stats *ReplicationStats)) {
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+	stats, ok := sys.stats[bucket]
+	if !ok {
+		stats = &ReplicationStats{}
+		sys.stats[bucket] = stats
+	}
+	fn(stats)
+}
+
+// Stats - returns the replication metrics of all buckets.
+func (sys *replicationSys) Stats() map[string]ReplicationStats {
+	sys.mutex.Lock()
+	defer sys.mutex.Unlock()
+	stats := make(map[string]ReplicationStats, len(sys.stats))
+	for bucket, bucketStats := range sys.stats {
+		stats[bucket] = *bucketStats
+	}
+	return stats
+}
+
+// enqueue - queues op without blocking, operations are dropped if
+// the queue is full.
+func (sys *replicationSys) enqueue(op replicationOp) {
+	op.queued = time.Now().UTC()
+	sys.updateStats(op.bucket, func(stats *ReplicationStats) { stats.Pending++ })
+	select {
+	case sys.queue <- op:
+	default:
+		sys.updateStats(op.bucket, func(stats *ReplicationStats) {
+			stats.Pending--
+			stats.Failed++
+		})
+		errorIf(errors.New("Replication queue is full"), "Unable to replicate %s/%s.", op.bucket, op.object)
+	}
+}
+
+// queueReplication - queues an object created or deleted by request r
+// for replication, requests of other replication queues are ignored.
+func queueReplication(r *http.Request, bucket, object string, delete bool) {
+	if globalReplication == nil || r.Header.Get(replicationHeader) != "" {
+		return
+	}
+	target, err := globalReplication.getTarget(bucket)
+	if err != nil {
+		errorIfRequest(r, err, "Unable to read bucket replication configuration.")
+		return
+	}
+	if target == nil || !target.config.matches(object) {
+		return
+	}
+	globalReplication.enqueue(replicationOp{bucket: bucket, object: object, delete: delete})
+}
+
+// process - replicates op, retrying failed attempts.
+func (sys *replicationSys) process(op replicationOp) {
+	var size int64
+	var err error
+	for i := 0; i < replicationRetries; i++ {
+		if i > 0 {
+			time.Sleep(replicationRetryInterval)
+		}
+		if size, err = sys.replicate(op); err == nil {
+			break
+		}
+	}
+	now := time.Now().UTC()
+	sys.updateStats(op.bucket, func(stats *ReplicationStats) {
+		stats.Pending--
+		if err != nil {
+			stats.Failed++
+			return
+		}
+		stats.Replicated++
+		stats.ReplicatedBytes += size
+		stats.LagSeconds = now.Sub(op.queued).Seconds()
+		stats.LastReplicated = now
+	})
+	errorIf(err, "Unable to replicate %s/%s.", op.bucket, op.object)
+}
+
+// replicate - mirrors the current state of the object of op to the
+// replication target, returns the number of bytes copied.
+func (sys *replicationSys) replicate(op replicationOp) (int64, error) {
+	target, err := sys.getTarget(op.bucket)
+	if err != nil || target == nil {
+		// Replication was disabled since op was queued.
+		return 0, err
+	}
+	if op.delete {
+		return 0, target.client.deleteObject(target.config.Bucket, op.object)
+	}
+	objInfo, err := sys.objAPI.GetObjectInfo(op.bucket, op.object)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			// Object was deleted since, its delete is queued as well.
+			return 0, nil
+		}
+		return 0, err
+	}
+	if op.resync {
+		remoteInfo, err := target.client.statObject(target.config.Bucket, op.object)
+		if err == nil && remoteInfo.MD5Sum == objInfo.MD5Sum && remoteInfo.Size == objInfo.Size {
+			return 0, nil
+		}
+	}
+
+	// Encrypted and compressed objects are replicated with their
+	// content, staged since their size is only known once read.
+	file, err := ioutil.TempFile("", "minio-replication-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if err = getObjectContent(context.Background(), sys.objAPI, op.bucket, &objInfo, file); err != nil {
+		return 0, err
+	}
+	size, err := file.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = file.Seek(0, os.SEEK_SET); err != nil {
+		return 0, err
+	}
+	metadata := map[string]string{
+		"content-type":     objInfo.ContentType,
+		"content-encoding": objInfo.ContentEncoding,
+	}
+	if _, err = target.client.putObject(context.Background(), target.config.Bucket, op.object, size, file, metadata); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// Resync - queues all objects of bucket which are missing or differ on
+// the replication target, runs in the background.
+func (sys *replicationSys) Resync(bucket string) error {
+	target, err := sys.getTarget(bucket)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return BucketReplicationConfigNotFound{Bucket: bucket}
+	}
+	go func() {
+		marker := ""
+		for {
+			result, err := sys.objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+			if err != nil {
+				errorIf(err, "Unable to list objects for replication resync.")
+				return
+			}
+			for _, objInfo := range result.Objects {
+				if !target.config.matches(objInfo.Name) {
+					continue
+				}
+				// Resync waits for the queue instead of dropping objects.
+				sys.updateStats(bucket, func(stats *ReplicationStats) { stats.Pending++ })
+				sys.queue <- replicationOp{bucket: bucket, object: objInfo.Name, resync: true, queued: time.Now().UTC()}
+			}
+			if !result.IsTruncated {
+				return
+			}
+			marker = result.NextMarker
+		}
+	}()
+	return nil
+}
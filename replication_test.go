@@ -0,0 +1,164 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests validation of bucket replication configs.
+func TestValidateBucketReplicationConfig(t *testing.T) {
+	testCases := []struct {
+		config     bucketReplicationConfig
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{bucketReplicationConfig{Endpoint: "https://s3.amazonaws.com", Bucket: "target", AccessKey: "access", SecretKey: "secret"}, true},
+		// Test case - 2.
+		// Invalid endpoint.
+		{bucketReplicationConfig{Endpoint: "s3.amazonaws.com", Bucket: "target", AccessKey: "access", SecretKey: "secret"}, false},
+		// Test case - 3.
+		// Invalid target bucket.
+		{bucketReplicationConfig{Endpoint: "https://s3.amazonaws.com", Bucket: "t", AccessKey: "access", SecretKey: "secret"}, false},
+		// Test case - 4.
+		// Missing credentials.
+		{bucketReplicationConfig{Endpoint: "https://s3.amazonaws.com", Bucket: "target"}, false},
+	}
+	for i, testCase := range testCases {
+		err := validateBucketReplicationConfig(testCase.config)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// waitReplication - waits until all queued operations of bucket are done.
+func waitReplication(t *testing.T, sys *replicationSys, bucket string, replicated int64) {
+	for i := 0; i < 500; i++ {
+		stats := sys.Stats()[bucket]
+		if stats.Failed > 0 {
+			t.Fatalf("Replication failed: %+v", stats)
+		}
+		if stats.Pending == 0 && stats.Replicated == replicated {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for replication: %+v", sys.Stats()[bucket])
+}
+
+// Tests new objects and deletes are mirrored to the replication target.
+func TestReplication(t *testing.T) {
+	objAPI, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+	target := StartTestServer(t, "FS")
+	defer target.Stop()
+
+	cred := serverConfig.GetCredential()
+	client, err := newS3Client(target.Server.URL, "us-east-1", func() credential { return cred })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.makeBucket("target"); err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("source"); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeBucketReplicationConfig("source", &bucketReplicationConfig{
+		Endpoint:  target.Server.URL,
+		Bucket:    "target",
+		AccessKey: cred.AccessKeyID,
+		SecretKey: cred.SecretAccessKey,
+		Prefixes:  []string{"a/"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sys := newReplicationSys(objAPI)
+	sys.run()
+	prevReplication := globalReplication
+	globalReplication = sys
+	defer func() { globalReplication = prevReplication }()
+
+	req, err := http.NewRequest("PUT", "http://localhost/source/a/object", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"a/object", "b/object"} {
+		if _, err = objAPI.PutObject(context.Background(), "source", object, 5, strings.NewReader("hello"), nil); err != nil {
+			t.Fatal(err)
+		}
+		queueReplication(req, "source", object, false)
+	}
+	waitReplication(t, sys, "source", 1)
+	buffer := new(bytes.Buffer)
+	if err = client.getObject(context.Background(), "target", "a/object", 0, 5, buffer); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "hello" {
+		t.Fatalf("Expected hello, but instead found %s", buffer.String())
+	}
+	// Objects outside of the prefixes are not replicated.
+	if _, err = client.statObject("target", "b/object"); err == nil {
+		t.Fatal("Expected b/object not to be replicated")
+	}
+
+	// Requests of other replication queues are not replicated again.
+	req.Header.Set(replicationHeader, "true")
+	queueReplication(req, "source", "a/object", true)
+	if stats := sys.Stats()["source"]; stats.Pending != 0 {
+		t.Fatalf("Expected no pending operations, but instead found %d", stats.Pending)
+	}
+	req.Header.Del(replicationHeader)
+
+	if err = objAPI.DeleteObject("source", "a/object"); err != nil {
+		t.Fatal(err)
+	}
+	queueReplication(req, "source", "a/object", true)
+	waitReplication(t, sys, "source", 2)
+	if _, err = client.statObject("target", "a/object"); err == nil {
+		t.Fatal("Expected a/object to be deleted")
+	}
+
+	// Resync copies objects missing on the target.
+	if _, err = objAPI.PutObject(context.Background(), "source", "a/missing", 5, strings.NewReader("world"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.Resync("source"); err != nil {
+		t.Fatal(err)
+	}
+	waitReplication(t, sys, "source", 3)
+	if _, err = client.statObject("target", "a/missing"); err != nil {
+		t.Fatal(err)
+	}
+	// Resync of a bucket without replication fails.
+	if err = sys.Resync("other"); err == nil {
+		t.Fatal("Expected resync without replication config to fail")
+	}
+}
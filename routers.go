@@ -18,51 +18,166 @@ package main
 
 import (
 	"errors"
+	"net"
 	"net/http"
 
 	router "github.com/gorilla/mux"
 )
 
 // newObjectLayer - initialize any object layer depending on the
-// number of export paths.
+// backend format of export paths.
 func newObjectLayer(exportPaths []string) (ObjectLayer, error) {
-	if len(exportPaths) == 1 {
-		exportPath := exportPaths[0]
-		// Initialize FS object layer.
-		return newFSObjects(exportPath)
+	// Backend format is detected from format.json of the disks.
+	format, err := detectBackendFormat(exportPaths)
+	if err != nil {
+		return nil, err
 	}
-	// Initialize XL object layer.
-	objAPI, err := newXLObjects(exportPaths)
+	objAPI, err := newRegisteredObjectLayer(format, exportPaths)
 	if err == errXLWriteQuorum {
 		return objAPI, errors.New("Disks are different with last minio server run.")
 	}
 	return objAPI, err
 }
 
-// configureServer handler returns final handler for the http server.
-func configureServerHandler(srvCmdConfig serverCmdConfig) http.Handler {
-	objAPI, err := newObjectLayer(srvCmdConfig.exportPaths)
-	fatalIf(err, "Unable to intialize object layer.")
+// configureServer handler returns final handler for the http server and
+// the object layer it serves.
+func configureServerHandler(srvCmdConfig serverCmdConfig) (http.Handler, ObjectLayer) {
+	// Initialize router.
+	mux := router.NewRouter()
+
+	objAPI := srvCmdConfig.objectLayer
+	// Gateways have no local storage to serve over RPC.
+	if objAPI == nil {
+		// Nodes must be started alike and agree on the time to accept
+		// the same requests, verified before disks are formatted.
+		err := verifyBootstrap(srvCmdConfig.exportPaths)
+		fatalIf(err, "Unable to verify the parameters of the nodes.")
+		err = checkClockSkew(srvCmdConfig.exportPaths)
+		fatalIf(err, "Clocks of the nodes are out of sync.")
+
+		objAPI, err = newObjectLayer(srvCmdConfig.exportPaths)
+		fatalIf(err, "Unable to intialize object layer.")
 
-	// Initialize storage rpc server.
-	storageRPC, err := newRPCServer(srvCmdConfig.exportPaths[0]) // FIXME: should only have one path.
-	fatalIf(err, "Unable to initialize storage RPC server.")
+		// Load the config shared by all nodes from the backend, unless
+		// it is shared through etcd.
+		if globalEtcdClient == nil {
+			err = initBackendConfig(objAPI)
+			fatalIf(err, "Unable to initialize config in the backend.")
+		}
+
+		// Initialize storage rpc server.
+		storageRPC, err := newRPCServer(srvCmdConfig.exportPaths[0]) // FIXME: should only have one path.
+		fatalIf(err, "Unable to initialize storage RPC server.")
+		storageRPC.exportPaths = srvCmdConfig.exportPaths
+		registerStorageRPCRouter(mux, storageRPC)
+	} else if cache := serverConfig.GetCache(); len(cache.Drives) > 0 {
+		// Serve hot objects of remote backends from local cache drives.
+		var err error
+		objAPI, err = newCacheObjects(objAPI, cache)
+		fatalIf(err, "Unable to initialize disk cache.")
+	}
 
 	// Initialize API.
 	apiHandlers := objectAPIHandlers{
 		ObjectAPI: objAPI,
 	}
 
+	// Initialize admin API.
+	adminHandlers := adminAPIHandlers{
+		ObjectAPI: objAPI,
+	}
+
+	// Initialize health checks.
+	healthHandlers := healthCheckHandlers{
+		ObjectAPI: objAPI,
+	}
+
 	// Initialize Web.
 	webHandlers := &webAPIHandlers{
 		ObjectAPI: objAPI,
 	}
 
-	// Initialize router.
-	mux := router.NewRouter()
+	// Mirror objects of buckets with a replication target.
+	globalReplication = newReplicationSys(objAPI)
+	globalReplication.run()
+
+	// Register buckets created before federation was enabled.
+	if globalFederation != nil {
+		go registerFederatedBuckets(objAPI)
+	}
+
+	// Crawl data usage, purge expired trash, expire empty directories
+	// and save request statistics in the background, not for gateways
+	// which would list the whole remote endpoint over and over and have
+	// no trash.
+	if srvCmdConfig.objectLayer == nil {
+		errorIf(globalAPIStats.load(objAPI), "Unable to load API statistics.")
+		go runAPIStatsSaver(objAPI, nil)
+		go runDataUsageCrawler(objAPI, nil)
+		go runTrashPurger(objAPI, nil)
+		go runEmptyDirExpirer(nil)
+
+		// Heal objects written while some disks failed.
+		if globalMRF = newMRFSys(objAPI); globalMRF != nil {
+			go globalMRF.run(nil)
+		}
+	}
+
+	// Probe the nodes of network disks so that broken connections are
+	// restored in the background.
+	go runRPCEndpointProbes(nil)
+
+	// Continue a rebalance of the erasure sets interrupted by a restart.
+	if sets, ok := objAPI.(*xlSets); ok {
+		var err error
+		globalRebalancer, err = newRebalancer(sets)
+		fatalIf(err, "Unable to load rebalance state.")
+	}
+
+	// Continue a key rotation interrupted by a restart.
+	var err error
+	globalKeyRotator, err = newKeyRotator(objAPI)
+	fatalIf(err, "Unable to load key rotation state.")
+
+	// Continue a full heal interrupted by a restart.
+	globalFullHealer, err = newFullHealer(objAPI)
+	fatalIf(err, "Unable to load full heal state.")
+
+	// Serve buckets over FTP if requested.
+	if srvCmdConfig.ftpAddr != "" {
+		listener, err := net.Listen("tcp", srvCmdConfig.ftpAddr)
+		fatalIf(err, "Unable to listen for FTP clients on %s.", srvCmdConfig.ftpAddr)
+		go func() {
+			err := newFTPServer(objAPI, srvCmdConfig.ftpTLSConfig).Serve(listener)
+			fatalIf(err, "Failed to serve FTP clients.")
+		}()
+	}
+
+	// Serve buckets over SFTP if requested.
+	if srvCmdConfig.sftpAddr != "" {
+		hostKey, err := loadSFTPHostKey(getSFTPConfigFile(sftpHostKeyFile))
+		fatalIf(err, "Unable to load the SFTP host key.")
+		listener, err := net.Listen("tcp", srvCmdConfig.sftpAddr)
+		fatalIf(err, "Unable to listen for SFTP clients on %s.", srvCmdConfig.sftpAddr)
+		go func() {
+			err := newSFTPServer(objAPI, hostKey, getSFTPConfigFile(sftpAuthorizedKeysFile)).Serve(listener)
+			fatalIf(err, "Failed to serve SFTP clients.")
+		}()
+	}
+
+	// Export buckets over NFS if requested.
+	if srvCmdConfig.nfsAddr != "" {
+		listener, err := net.Listen("tcp", srvCmdConfig.nfsAddr)
+		fatalIf(err, "Unable to listen for NFS clients on %s.", srvCmdConfig.nfsAddr)
+		go func() {
+			err := newNFSServer(objAPI, srvCmdConfig.nfsExports).Serve(listener)
+			fatalIf(err, "Failed to serve NFS clients.")
+		}()
+	}
 
 	// Register all routers.
-	registerStorageRPCRouter(mux, storageRPC)
+	registerAdminRouter(mux, adminHandlers)
+	registerHealthCheckRouter(mux, healthHandlers)
 	registerWebRouter(mux, webHandlers)
 	registerAPIRouter(mux, apiHandlers)
 	// Add new routers here.
@@ -88,9 +203,27 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) http.Handler {
 		// routes them accordingly. Client receives a HTTP error for
 		// invalid/unsupported signatures.
 		setAuthHandler,
+		// Rejects writes in read-only and maintenance mode with 503.
+		setServerModeHandler,
+		// Forwards requests for buckets owned by other federated
+		// deployments, which verify the signatures themselves.
+		setBucketForwardingHandler,
+		// Rejects requests exceeding the configured concurrent
+		// request limits with 503 Slow Down.
+		setRateLimitHandler,
+		// Logs an audit entry for every S3 API call.
+		setAuditHandler,
+		// Tracks requests being served for the admin locks view.
+		setInflightHandler,
+		// Publishes a summary of every request to connected admin
+		// trace clients, must be last to trace all other handlers.
+		setTraceHandler,
+		// Assigns a request ID to every request, must be outermost
+		// for the ID to be seen by all other handlers.
+		setRequestIDHandler,
 		// Add new handlers here.
 	}
 
 	// Register rest of the handlers.
-	return registerHandlers(mux, handlerFns...)
+	return registerHandlers(mux, handlerFns...), objAPI
 }
@@ -17,8 +17,8 @@
 package main
 
 import (
+	"context"
 	"net/http"
-	"net/rpc"
 	"strings"
 	"time"
 )
@@ -27,7 +27,7 @@ type networkStorage struct {
 	netScheme  string
 	netAddr    string
 	netPath    string
-	rpcClient  *rpc.Client
+	rpcClient  *rpcEndpoint
 	httpClient *http.Client
 }
 
@@ -62,8 +62,16 @@ func toStorageErr(err error) error {
 		return errVolumeNotEmpty
 	case errFileAccessDenied.Error():
 		return errFileAccessDenied
+	case errFileNameTooLong.Error():
+		return errFileNameTooLong
+	case errFileNameInvalid.Error():
+		return errFileNameInvalid
 	case errVolumeAccessDenied.Error():
 		return errVolumeAccessDenied
+	case errDiskNotFound.Error():
+		return errDiskNotFound
+	case errFaultyDisk.Error():
+		return errFaultyDisk
 	}
 	return err
 }
@@ -78,9 +86,10 @@ func newRPCClient(networkPath string) (StorageAPI, error) {
 	// TODO validate netAddr and netPath.
 	netAddr, netPath := splitNetPath(networkPath)
 
-	// Dial minio rpc storage http path.
-	rpcClient, err := rpc.DialHTTPPath("tcp", netAddr, storageRPCPath)
-	if err != nil {
+	// Connect to minio rpc storage http path, the connection is shared
+	// with other disks of the node.
+	rpcClient := getRPCEndpoint(netAddr)
+	if _, err := rpcClient.getClient(); err != nil {
 		return nil, err
 	}
 
@@ -106,10 +115,56 @@ func newRPCClient(networkPath string) (StorageAPI, error) {
 	return ndisk, nil
 }
 
+// networkTimeout - returns the deadline of a call to a disk of another
+// node. The node enforces timeout on its disks itself, waiting twice as
+// long reports a hung disk as such instead of taking the node offline.
+func networkTimeout(timeout time.Duration) time.Duration {
+	return 2 * timeout
+}
+
+// call - calls the metadata operation method within its deadline.
+func (n networkStorage) call(method string, args interface{}, reply interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), networkTimeout(getStorageConfig().metadataTimeout()))
+	defer cancel()
+	return n.rpcClient.CallContext(ctx, method, args, reply)
+}
+
+// DiskInfo - fetch disk usage and error statistics.
+func (n networkStorage) DiskInfo() (info DiskInfo, err error) {
+	if err = n.call("Storage.DiskInfoHandler", GenericArgs{}, &info); err != nil {
+		return DiskInfo{}, toStorageErr(err)
+	}
+	return info, nil
+}
+
+// ServerTime - returns the time of the server of the disk.
+func (n networkStorage) ServerTime() (t time.Time, err error) {
+	if err = n.call("Storage.ServerTimeHandler", GenericArgs{}, &t); err != nil {
+		return time.Time{}, toStorageErr(err)
+	}
+	return t, nil
+}
+
+// BootstrapInfo - returns the parameters the server of the disk was
+// started with, its credentials are proven by a MAC over nonce.
+func (n networkStorage) BootstrapInfo(nonce []byte) (info BootstrapInfo, err error) {
+	version, err := n.rpcClient.getVersion()
+	if err != nil {
+		return BootstrapInfo{}, err
+	}
+	if version < 5 {
+		return BootstrapInfo{}, errRPCVersionUnsupported
+	}
+	if err = n.call("Storage.BootstrapInfoHandler", BootstrapArgs{Nonce: nonce}, &info); err != nil {
+		return BootstrapInfo{}, toStorageErr(err)
+	}
+	return info, nil
+}
+
 // MakeVol - make a volume.
 func (n networkStorage) MakeVol(volume string) error {
 	reply := GenericReply{}
-	if err := n.rpcClient.Call("Storage.MakeVolHandler", volume, &reply); err != nil {
+	if err := n.call("Storage.MakeVolHandler", volume, &reply); err != nil {
 		return toStorageErr(err)
 	}
 	return nil
@@ -118,7 +173,7 @@ func (n networkStorage) MakeVol(volume string) error {
 // ListVols - List all volumes.
 func (n networkStorage) ListVols() (vols []VolInfo, err error) {
 	ListVols := ListVolsReply{}
-	err = n.rpcClient.Call("Storage.ListVolsHandler", "", &ListVols)
+	err = n.call("Storage.ListVolsHandler", "", &ListVols)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +182,7 @@ func (n networkStorage) ListVols() (vols []VolInfo, err error) {
 
 // StatVol - get current Stat volume info.
 func (n networkStorage) StatVol(volume string) (volInfo VolInfo, err error) {
-	if err = n.rpcClient.Call("Storage.StatVolHandler", volume, &volInfo); err != nil {
+	if err = n.call("Storage.StatVolHandler", volume, &volInfo); err != nil {
 		return VolInfo{}, toStorageErr(err)
 	}
 	return volInfo, nil
@@ -136,7 +191,7 @@ func (n networkStorage) StatVol(volume string) (volInfo VolInfo, err error) {
 // DeleteVol - Delete a volume.
 func (n networkStorage) DeleteVol(volume string) error {
 	reply := GenericReply{}
-	if err := n.rpcClient.Call("Storage.DeleteVolHandler", volume, &reply); err != nil {
+	if err := n.call("Storage.DeleteVolHandler", volume, &reply); err != nil {
 		return toStorageErr(err)
 	}
 	return nil
@@ -145,9 +200,11 @@ func (n networkStorage) DeleteVol(volume string) error {
 // File operations.
 
 // CreateFile - create file.
-func (n networkStorage) AppendFile(volume, path string, buffer []byte) (err error) {
+func (n networkStorage) AppendFile(ctx context.Context, volume, path string, buffer []byte) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, networkTimeout(getStorageConfig().dataTimeout(len(buffer))))
+	defer cancel()
 	reply := GenericReply{}
-	if err = n.rpcClient.Call("Storage.AppendFileHandler", AppendFileArgs{
+	if err = n.rpcClient.CallContext(ctx, "Storage.AppendFileHandler", AppendFileArgs{
 		Vol:    volume,
 		Path:   path,
 		Buffer: buffer,
@@ -157,9 +214,41 @@ func (n networkStorage) AppendFile(volume, path string, buffer []byte) (err erro
 	return nil
 }
 
+// PrepareFile - preallocate space for a file at path.
+func (n networkStorage) PrepareFile(volume, path string, size int64) (err error) {
+	reply := GenericReply{}
+	if err = n.call("Storage.PrepareFileHandler", PrepareFileArgs{
+		Vol:  volume,
+		Path: path,
+		Size: size,
+	}, &reply); err != nil {
+		return toStorageErr(err)
+	}
+	return nil
+}
+
+// MakeDir - make a directory and its parents at path.
+func (n networkStorage) MakeDir(volume, path string) (err error) {
+	version, err := n.rpcClient.getVersion()
+	if err != nil {
+		return err
+	}
+	if version < 4 {
+		return errRPCVersionUnsupported
+	}
+	reply := GenericReply{}
+	if err = n.call("Storage.MakeDirHandler", MakeDirArgs{
+		Vol:  volume,
+		Path: path,
+	}, &reply); err != nil {
+		return toStorageErr(err)
+	}
+	return nil
+}
+
 // StatFile - get latest Stat information for a file at path.
 func (n networkStorage) StatFile(volume, path string) (fileInfo FileInfo, err error) {
-	if err = n.rpcClient.Call("Storage.StatFileHandler", StatFileArgs{
+	if err = n.call("Storage.StatFileHandler", StatFileArgs{
 		Vol:  volume,
 		Path: path,
 	}, &fileInfo); err != nil {
@@ -169,8 +258,10 @@ func (n networkStorage) StatFile(volume, path string) (fileInfo FileInfo, err er
 }
 
 // ReadFile - reads a file.
-func (n networkStorage) ReadFile(volume string, path string, offset int64, buffer []byte) (m int64, err error) {
-	if err = n.rpcClient.Call("Storage.ReadFileHandler", ReadFileArgs{
+func (n networkStorage) ReadFile(ctx context.Context, volume string, path string, offset int64, buffer []byte) (m int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, networkTimeout(getStorageConfig().dataTimeout(len(buffer))))
+	defer cancel()
+	if err = n.rpcClient.CallContext(ctx, "Storage.ReadFileHandler", ReadFileArgs{
 		Vol:    volume,
 		Path:   path,
 		Offset: offset,
@@ -183,7 +274,7 @@ func (n networkStorage) ReadFile(volume string, path string, offset int64, buffe
 
 // ListDir - list all entries at prefix.
 func (n networkStorage) ListDir(volume, path string) (entries []string, err error) {
-	if err = n.rpcClient.Call("Storage.ListDirHandler", ListDirArgs{
+	if err = n.call("Storage.ListDirHandler", ListDirArgs{
 		Vol:  volume,
 		Path: path,
 	}, &entries); err != nil {
@@ -196,7 +287,7 @@ func (n networkStorage) ListDir(volume, path string) (entries []string, err erro
 // DeleteFile - Delete a file at path.
 func (n networkStorage) DeleteFile(volume, path string) (err error) {
 	reply := GenericReply{}
-	if err = n.rpcClient.Call("Storage.DeleteFileHandler", DeleteFileArgs{
+	if err = n.call("Storage.DeleteFileHandler", DeleteFileArgs{
 		Vol:  volume,
 		Path: path,
 	}, &reply); err != nil {
@@ -208,7 +299,7 @@ func (n networkStorage) DeleteFile(volume, path string) (err error) {
 // RenameFile - Rename file.
 func (n networkStorage) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err error) {
 	reply := GenericReply{}
-	if err = n.rpcClient.Call("Storage.RenameFileHandler", RenameFileArgs{
+	if err = n.call("Storage.RenameFileHandler", RenameFileArgs{
 		SrcVol:  srcVolume,
 		SrcPath: srcPath,
 		DstVol:  dstVolume,
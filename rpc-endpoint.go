@@ -0,0 +1,395 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Connections to the storage RPC servers of other nodes are shared by
+// all network disks of a node and redialed when they break. Nodes which
+// cannot be dialed are skipped with exponential backoff, requests to a
+// flapping node fail fast with errDiskNotFound instead of each waiting
+// for a dial timeout.
+var (
+	// Maximum time to connect to a node.
+	rpcDialTimeout = 5 * time.Second
+
+	// Time a node is skipped after the first failed dial, doubled on
+	// every further failure up to rpcMaxRetryDelay.
+	rpcMinRetryDelay = time.Second
+	rpcMaxRetryDelay = time.Minute
+
+	// Interval nodes are probed at, connected nodes have to answer
+	// within rpcProbeTimeout.
+	rpcProbeInterval = 10 * time.Second
+	rpcProbeTimeout  = 5 * time.Second
+)
+
+// Versions of the storage RPC protocol, nodes speak the highest version
+// both support so that they can be upgraded one at a time. Version 1
+// has no handshake, version 2 adds it and the bootstrap verification,
+// version 3 added updates, which moved to the admin API since, version
+// 4 adds directory creation, version 5 proves the credentials of the
+// bootstrap verification by a MAC over a nonce.
+const (
+	storageRPCVersion    = 5
+	minStorageRPCVersion = 1
+)
+
+var (
+	// errRPCProbeTimeout - node did not answer a probe in time.
+	errRPCProbeTimeout = errors.New("Storage RPC probe timed out")
+
+	// errRPCVersionMismatch - nodes support no common protocol version.
+	errRPCVersionMismatch = errors.New("Storage RPC protocol versions are incompatible")
+
+	// errRPCVersionUnsupported - call needs a newer protocol version
+	// than the node speaks.
+	errRPCVersionUnsupported = errors.New("Storage RPC call is not supported by the node")
+)
+
+// negotiateRPCVersion - returns the highest protocol version supported
+// by both this node and a peer supporting versions min to max.
+func negotiateRPCVersion(min, max int) (int, error) {
+	if max > storageRPCVersion {
+		max = storageRPCVersion
+	}
+	if min < minStorageRPCVersion {
+		min = minStorageRPCVersion
+	}
+	if max < min {
+		return 0, errRPCVersionMismatch
+	}
+	return max, nil
+}
+
+// rpcEndpoint - connection to the storage RPC server of a node.
+type rpcEndpoint struct {
+	addr string
+
+	mutex  sync.Mutex
+	client *rpc.Client
+	// Protocol version negotiated on connect.
+	version int
+	// Consecutive failures and the time the node is dialed again.
+	failures int
+	retryAt  time.Time
+	lastErr  error
+	// Last time the node answered.
+	lastSeen time.Time
+}
+
+// rpcEndpointInfo - connectivity of a node.
+type rpcEndpointInfo struct {
+	Addr      string    `json:"addr"`
+	Online    bool      `json:"online"`
+	Version   int       `json:"version,omitempty"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"lastError,omitempty"`
+	LastSeen  time.Time `json:"lastSeen"`
+	RetryAt   time.Time `json:"retryAt,omitempty"`
+}
+
+// globalRPCEndpoints - connections by node address.
+var globalRPCEndpoints = struct {
+	mutex     sync.Mutex
+	endpoints map[string]*rpcEndpoint
+}{endpoints: make(map[string]*rpcEndpoint)}
+
+// getRPCEndpoint - returns the shared connection to the node at addr.
+func getRPCEndpoint(addr string) *rpcEndpoint {
+	globalRPCEndpoints.mutex.Lock()
+	defer globalRPCEndpoints.mutex.Unlock()
+	endpoint, ok := globalRPCEndpoints.endpoints[addr]
+	if !ok {
+		endpoint = &rpcEndpoint{addr: addr}
+		globalRPCEndpoints.endpoints[addr] = endpoint
+	}
+	return endpoint
+}
+
+// listRPCEndpoints - returns the connections to all nodes.
+func listRPCEndpoints() []*rpcEndpoint {
+	globalRPCEndpoints.mutex.Lock()
+	defer globalRPCEndpoints.mutex.Unlock()
+	var endpoints []*rpcEndpoint
+	for _, endpoint := range globalRPCEndpoints.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// getRPCEndpointsInfo - returns the connectivity of all nodes, sorted by
+// address.
+func getRPCEndpointsInfo() []rpcEndpointInfo {
+	var infos []rpcEndpointInfo
+	for _, endpoint := range listRPCEndpoints() {
+		infos = append(infos, endpoint.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Addr < infos[j].Addr
+	})
+	return infos
+}
+
+// dialRPC - connects to the storage RPC server at addr, the same as
+// rpc.DialHTTPPath with a timeout, and negotiates the protocol version.
+func dialRPC(addr string) (*rpc.Client, int, error) {
+	conn, err := net.DialTimeout("tcp", addr, rpcDialTimeout)
+	if err != nil {
+		return nil, 0, err
+	}
+	conn.SetDeadline(time.Now().Add(rpcDialTimeout))
+	io.WriteString(conn, "CONNECT "+storageRPCPath+" HTTP/1.0\n\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err == nil && resp.Status != "200 Connected to Go RPC" {
+		err = errors.New("unexpected HTTP response: " + resp.Status)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, 0, err
+	}
+	client := rpc.NewClient(conn)
+	version, err := handshakeRPC(client)
+	if err != nil {
+		client.Close()
+		return nil, 0, err
+	}
+	conn.SetDeadline(time.Time{})
+	return client, version, nil
+}
+
+// handshakeRPC - returns the protocol version negotiated with the
+// server, servers without the handshake speak version 1.
+func handshakeRPC(client *rpc.Client) (int, error) {
+	var version int
+	err := client.Call("Storage.VersionHandler", VersionArgs{
+		MinVersion: minStorageRPCVersion,
+		MaxVersion: storageRPCVersion,
+	}, &version)
+	if err == nil {
+		return version, nil
+	}
+	if err.Error() == errRPCVersionMismatch.Error() {
+		return 0, errRPCVersionMismatch
+	}
+	if isServerError(err) && strings.HasPrefix(err.Error(), "rpc: can't find method") {
+		return negotiateRPCVersion(1, 1)
+	}
+	return 0, err
+}
+
+// getClient - returns the connection to the node, dialing it if the
+// node is not skipped after failures.
+func (e *rpcEndpoint) getClient() (*rpc.Client, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.client != nil {
+		return e.client, nil
+	}
+	if time.Now().Before(e.retryAt) {
+		return nil, errDiskNotFound
+	}
+	client, version, err := dialRPC(e.addr)
+	if err != nil {
+		e.failedLocked(err)
+		return nil, err
+	}
+	e.client = client
+	e.version = version
+	e.failures = 0
+	e.lastErr = nil
+	e.lastSeen = time.Now().UTC()
+	return client, nil
+}
+
+// getVersion - returns the protocol version spoken by the node.
+func (e *rpcEndpoint) getVersion() (int, error) {
+	if _, err := e.getClient(); err != nil {
+		return 0, errDiskNotFound
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.version, nil
+}
+
+// failedLocked - records a failure and skips the node until the
+// backoff passed, must be called with the mutex held.
+func (e *rpcEndpoint) failedLocked(err error) {
+	delay := rpcMaxRetryDelay
+	if e.failures < 16 && rpcMinRetryDelay<<uint(e.failures) < rpcMaxRetryDelay {
+		delay = rpcMinRetryDelay << uint(e.failures)
+	}
+	e.failures++
+	e.retryAt = time.Now().Add(delay)
+	e.lastErr = err
+}
+
+// disconnect - closes a broken connection, the next call redials after
+// the backoff.
+func (e *rpcEndpoint) disconnect(client *rpc.Client, err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	// Another call may have replaced the connection already.
+	if e.client != client {
+		return
+	}
+	e.client.Close()
+	e.client = nil
+	e.failedLocked(err)
+}
+
+// isServerError - returns true if err was returned by the RPC handler,
+// other errors mean the connection broke.
+func isServerError(err error) bool {
+	_, ok := err.(rpc.ServerError)
+	return ok
+}
+
+// Call - calls method of the node's storage RPC server. Errors of the
+// connection are returned as errDiskNotFound.
+func (e *rpcEndpoint) Call(method string, args interface{}, reply interface{}) error {
+	return e.CallContext(context.Background(), method, args, reply)
+}
+
+// CallContext - like Call, but returns ctx.Err() as soon as ctx is
+// canceled. The request is sent before waiting, args may be reused
+// once it returns. A canceled call still completes on the server and
+// keeps the connection. Calls running past the deadline of ctx mean
+// the node hangs, it is taken offline like after a broken connection.
+func (e *rpcEndpoint) CallContext(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	client, err := e.getClient()
+	if err != nil {
+		return errDiskNotFound
+	}
+	// The reply of an abandoned call is decoded later, it is decoded
+	// into a copy which is only handed out on success.
+	replyValue := reflect.New(reflect.TypeOf(reply).Elem())
+	call := client.Go(method, args, replyValue.Interface(), make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		err = call.Error
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			return ctx.Err()
+		}
+		err = ctx.Err()
+	}
+	if err != nil {
+		if isServerError(err) {
+			return err
+		}
+		e.disconnect(client, err)
+		return errDiskNotFound
+	}
+	reflect.ValueOf(reply).Elem().Set(replyValue.Elem())
+	e.mutex.Lock()
+	e.lastSeen = time.Now().UTC()
+	e.mutex.Unlock()
+	return nil
+}
+
+// Close - closes the connection, it is redialed by the next call.
+func (e *rpcEndpoint) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.client == nil {
+		return nil
+	}
+	err := e.client.Close()
+	e.client = nil
+	return err
+}
+
+// probe - checks that the node answers, dialing it if its backoff
+// passed.
+func (e *rpcEndpoint) probe() {
+	client, err := e.getClient()
+	if err != nil {
+		return
+	}
+	var t time.Time
+	call := client.Go("Storage.ServerTimeHandler", GenericArgs{}, &t, make(chan *rpc.Call, 1))
+	timer := time.NewTimer(rpcProbeTimeout)
+	defer timer.Stop()
+	select {
+	case <-call.Done:
+		err = call.Error
+	case <-timer.C:
+		err = errRPCProbeTimeout
+	}
+	if err != nil && !isServerError(err) {
+		e.disconnect(client, err)
+		return
+	}
+	e.mutex.Lock()
+	e.lastSeen = time.Now().UTC()
+	e.mutex.Unlock()
+}
+
+// info - returns the connectivity of the node.
+func (e *rpcEndpoint) info() rpcEndpointInfo {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	info := rpcEndpointInfo{
+		Addr:     e.addr,
+		Online:   e.client != nil,
+		Version:  e.version,
+		Failures: e.failures,
+		LastSeen: e.lastSeen,
+	}
+	if e.lastErr != nil {
+		info.LastError = e.lastErr.Error()
+	}
+	if e.client == nil && e.failures > 0 {
+		info.RetryAt = e.retryAt.UTC()
+	}
+	return info
+}
+
+// runRPCEndpointProbes - probes all nodes periodically until doneCh is
+// closed, so that broken connections are noticed and restored before
+// requests need them.
+func runRPCEndpointProbes(doneCh <-chan struct{}) {
+	ticker := time.NewTicker(rpcProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, endpoint := range listRPCEndpoints() {
+				endpoint.probe()
+			}
+		case <-doneCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"strings"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests nodes are skipped after failed dials and reconnected once their
+// backoff passed.
+func TestRPCEndpointReconnect(t *testing.T) {
+	defer func(delay time.Duration) { rpcMinRetryDelay = delay }(rpcMinRetryDelay)
+	rpcMinRetryDelay = 50 * time.Millisecond
+
+	disk, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(disk)
+	storageRPC, err := newRPCServer(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := router.NewRouter()
+	registerStorageRPCRouter(mux, storageRPC)
+
+	// Reserve an address nothing listens on yet.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	if _, err = newRPCClient(addr + ":" + disk); err == nil {
+		t.Fatal("Expected unreachable node to fail")
+	}
+	endpoint := getRPCEndpoint(addr)
+	if info := endpoint.info(); info.Online || info.Failures != 1 || info.LastError == "" {
+		t.Fatalf("Unexpected connectivity %#v", info)
+	}
+	// The node is skipped without dialing until the backoff passed.
+	if err = endpoint.Call("Storage.ServerTimeHandler", GenericArgs{}, new(time.Time)); err != errDiskNotFound {
+		t.Fatalf("Expected %v, got %v", errDiskNotFound, err)
+	}
+	if info := endpoint.info(); info.Failures != 1 {
+		t.Fatalf("Expected skipped node not to be dialed, got %#v", info)
+	}
+
+	if listener, err = net.Listen("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go http.Serve(listener, mux)
+	time.Sleep(2 * rpcMinRetryDelay)
+
+	storage, err := newRPCClient(addr + ":" + disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = storage.DiskInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if info := endpoint.info(); !info.Online || info.Failures != 0 {
+		t.Fatalf("Expected node online, got %#v", info)
+	}
+
+	// Canceled calls do not count against the node.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = storage.ReadFile(ctx, "volume", "file", 0, make([]byte, 1)); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+	if info := endpoint.info(); !info.Online || info.Failures != 0 {
+		t.Fatalf("Expected node online after canceled call, got %#v", info)
+	}
+
+	// A broken connection takes the node offline until it is probed.
+	endpoint.client.Close()
+	if _, err = storage.DiskInfo(); err != errDiskNotFound {
+		t.Fatalf("Expected %v, got %v", errDiskNotFound, err)
+	}
+	if info := endpoint.info(); info.Online || info.Failures != 1 {
+		t.Fatalf("Expected node offline, got %#v", info)
+	}
+	time.Sleep(2 * rpcMinRetryDelay)
+	endpoint.probe()
+	if info := endpoint.info(); !info.Online || info.Failures != 0 {
+		t.Fatalf("Expected probed node online, got %#v", info)
+	}
+	if _, err = storage.DiskInfo(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests the highest common protocol version is picked.
+func TestNegotiateRPCVersion(t *testing.T) {
+	testCases := []struct {
+		min, max int
+		version  int
+		err      error
+	}{
+		{1, 1, 1, nil},
+		{1, storageRPCVersion, storageRPCVersion, nil},
+		{1, storageRPCVersion + 1, storageRPCVersion, nil},
+		{storageRPCVersion + 1, storageRPCVersion + 2, 0, errRPCVersionMismatch},
+		{0, minStorageRPCVersion - 1, 0, errRPCVersionMismatch},
+	}
+	for i, testCase := range testCases {
+		version, err := negotiateRPCVersion(testCase.min, testCase.max)
+		if version != testCase.version || err != testCase.err {
+			t.Errorf("Test %d: expected %d %v, got %d %v", i+1, testCase.version, testCase.err, version, err)
+		}
+	}
+}
+
+// legacyStorageServer - storage RPC server of version 1 without the
+// handshake.
+type legacyStorageServer struct{}
+
+func (s *legacyStorageServer) ServerTimeHandler(arg *GenericArgs, reply *time.Time) error {
+	*reply = time.Now().UTC()
+	return nil
+}
+
+// Tests nodes without the handshake speak version 1 and newer calls are
+// not sent to them.
+func TestRPCEndpointLegacyServer(t *testing.T) {
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterName("Storage", &legacyStorageServer{})
+	mux := router.NewRouter()
+	mux.Path(storageRPCPath).Handler(rpcServer)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	storage, err := newRPCClient(addr + ":/disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	netStorage := storage.(*networkStorage)
+	if version, err := netStorage.rpcClient.getVersion(); err != nil || version != 1 {
+		t.Fatalf("Expected version 1, got %d %v", version, err)
+	}
+	if _, err = netStorage.ServerTime(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = netStorage.BootstrapInfo(nil); err != errRPCVersionUnsupported {
+		t.Fatalf("Expected %v, got %v", errRPCVersionUnsupported, err)
+	}
+	if info := netStorage.rpcClient.info(); info.Version != 1 || !info.Online {
+		t.Fatalf("Unexpected connectivity %#v", info)
+	}
+}
+
+// hungStorageServer - storage RPC server whose disk never answers.
+type hungStorageServer struct {
+	release chan struct{}
+}
+
+func (s *hungStorageServer) DiskInfoHandler(arg *GenericArgs, reply *DiskInfo) error {
+	<-s.release
+	return nil
+}
+
+// Tests calls past their deadline take the node offline.
+func TestRPCEndpointDeadline(t *testing.T) {
+	hung := &hungStorageServer{release: make(chan struct{})}
+	defer close(hung.release)
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterName("Storage", hung)
+	mux := router.NewRouter()
+	mux.Path(storageRPCPath).Handler(rpcServer)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	endpoint := getRPCEndpoint(strings.TrimPrefix(server.URL, "http://"))
+	defer endpoint.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var info DiskInfo
+	if err := endpoint.CallContext(ctx, "Storage.DiskInfoHandler", GenericArgs{}, &info); err != errDiskNotFound {
+		t.Fatalf("Expected %v, got %v", errDiskNotFound, err)
+	}
+	if info := endpoint.info(); info.Online || info.Failures != 1 {
+		t.Fatalf("Expected hung node offline, got %#v", info)
+	}
+}
@@ -16,6 +16,10 @@
 
 package main
 
+// Types are gob encoded, nodes of different versions understand each
+// other as long as fields are only added. Changes which older nodes
+// cannot handle need a new storage RPC protocol version.
+
 // GenericReply represents any generic RPC reply.
 type GenericReply struct{}
 
@@ -55,6 +59,27 @@ type AppendFileArgs struct {
 	Buffer []byte
 }
 
+// PrepareFileArgs represents prepare file RPC arguments.
+type PrepareFileArgs struct {
+	// Name of the volume.
+	Vol string
+
+	// Name of the path.
+	Path string
+
+	// Number of bytes to be preallocated.
+	Size int64
+}
+
+// MakeDirArgs represents make directory RPC arguments.
+type MakeDirArgs struct {
+	// Name of the volume.
+	Vol string
+
+	// Name of the path.
+	Path string
+}
+
 // StatFileArgs represents stat file RPC arguments.
 type StatFileArgs struct {
 	// Name of the volume.
@@ -96,3 +121,32 @@ type RenameFileArgs struct {
 	// Destination path of renamed file.
 	DstPath string
 }
+
+// VersionArgs represents the storage RPC protocol versions supported by
+// a client.
+type VersionArgs struct {
+	MinVersion int
+	MaxVersion int
+}
+
+// BootstrapArgs represents bootstrap info RPC arguments.
+type BootstrapArgs struct {
+	// Random nonce of the caller, the credentials are proven by a
+	// MAC over it.
+	Nonce []byte
+}
+
+// BootstrapInfo represents the parameters all nodes of a deployment
+// must agree on, exchanged on start before disks are formatted.
+type BootstrapInfo struct {
+	// Version and commit of the server binary.
+	Version  string
+	CommitID string
+
+	// Disks on the command line of the node, in order.
+	Disks []string
+
+	// HMAC-SHA256 of the nonce of the caller and the access key,
+	// keyed by the secret key.
+	CredentialMAC []byte
+}
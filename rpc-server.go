@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"net/rpc"
+	"time"
 
 	router "github.com/gorilla/mux"
 )
@@ -10,6 +12,45 @@ import (
 // disk over a network.
 type storageServer struct {
 	storage StorageAPI
+	// Disks on the command line, reported to starting nodes.
+	exportPaths []string
+}
+
+/// Storage operations handlers
+
+// DiskInfoHandler - disk info handler is rpc wrapper for DiskInfo operation.
+func (s *storageServer) DiskInfoHandler(arg *GenericArgs, reply *DiskInfo) error {
+	info, err := s.storage.DiskInfo()
+	if err != nil {
+		return err
+	}
+	*reply = info
+	return nil
+}
+
+// ServerTimeHandler - server time handler returns the time of the
+// server, nodes compare their clocks with it.
+func (s *storageServer) ServerTimeHandler(arg *GenericArgs, reply *time.Time) error {
+	*reply = time.Now().UTC()
+	return nil
+}
+
+// VersionHandler - version handler returns the highest storage RPC
+// protocol version supported by both the client and the server.
+func (s *storageServer) VersionHandler(arg *VersionArgs, reply *int) error {
+	version, err := negotiateRPCVersion(arg.MinVersion, arg.MaxVersion)
+	if err != nil {
+		return err
+	}
+	*reply = version
+	return nil
+}
+
+// BootstrapInfoHandler - bootstrap info handler returns the parameters
+// of the server, starting nodes verify they were started the same.
+func (s *storageServer) BootstrapInfoHandler(arg *BootstrapArgs, reply *BootstrapInfo) error {
+	*reply = getBootstrapInfo(s.exportPaths, arg.Nonce)
+	return nil
 }
 
 /// Volume operations handlers
@@ -77,7 +118,7 @@ func (s *storageServer) ListDirHandler(arg *ListDirArgs, reply *[]string) error
 
 // ReadFileHandler - read file handler is rpc wrapper to read file.
 func (s *storageServer) ReadFileHandler(arg *ReadFileArgs, reply *int64) error {
-	n, err := s.storage.ReadFile(arg.Vol, arg.Path, arg.Offset, arg.Buffer)
+	n, err := s.storage.ReadFile(context.Background(), arg.Vol, arg.Path, arg.Offset, arg.Buffer)
 	if err != nil {
 		return err
 	}
@@ -87,7 +128,18 @@ func (s *storageServer) ReadFileHandler(arg *ReadFileArgs, reply *int64) error {
 
 // AppendFileHandler - append file handler is rpc wrapper to append file.
 func (s *storageServer) AppendFileHandler(arg *AppendFileArgs, reply *GenericReply) error {
-	return s.storage.AppendFile(arg.Vol, arg.Path, arg.Buffer)
+	return s.storage.AppendFile(context.Background(), arg.Vol, arg.Path, arg.Buffer)
+}
+
+// PrepareFileHandler - prepare file handler is rpc wrapper to prepare file.
+func (s *storageServer) PrepareFileHandler(arg *PrepareFileArgs, reply *GenericReply) error {
+	return s.storage.PrepareFile(arg.Vol, arg.Path, arg.Size)
+}
+
+// MakeDirHandler - make directory handler is rpc wrapper to make a
+// directory.
+func (s *storageServer) MakeDirHandler(arg *MakeDirArgs, reply *GenericReply) error {
+	return s.storage.MakeDir(arg.Vol, arg.Path)
 }
 
 // DeleteFileHandler - delete file handler is rpc wrapper to delete file.
@@ -0,0 +1,562 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Requests to remote endpoints are valid for 15 minutes.
+const s3ClientSignExpiry = 15 * time.Minute
+
+// s3ClientError - error response of a remote endpoint.
+type s3ClientError struct {
+	StatusCode int
+	APIErrorResponse
+}
+
+func (e s3ClientError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Message
+}
+
+// s3Client - minimal client of an S3 compatible endpoint. Requests are
+// signed with query string signature version '4' so payloads can be
+// streamed without computing their sha256 upfront.
+type s3Client struct {
+	endpoint *url.URL
+	// Returns the credentials requests are signed with.
+	getCredential func() credential
+	// Region of the endpoint, used for all requests which are not
+	// on a bucket.
+	region     string
+	httpClient *http.Client
+	// Headers sent with every request.
+	header http.Header
+
+	mutex         sync.Mutex
+	bucketRegions map[string]string
+}
+
+// newS3Client - initializes a client of endpoint, getCredential is
+// called for every request.
+func newS3Client(endpoint string, region string, getCredential func() credential) (*s3Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("Invalid endpoint %s, should be http(s)://host[:port]", endpoint)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &s3Client{
+		endpoint:      u,
+		getCredential: getCredential,
+		region:        region,
+		httpClient:    &http.Client{},
+		bucketRegions: make(map[string]string),
+	}, nil
+}
+
+// getBucketRegion - returns the region of a bucket, locations are
+// looked up once and cached.
+func (c *s3Client) getBucketRegion(bucket string) string {
+	if bucket == "" {
+		return c.region
+	}
+	c.mutex.Lock()
+	region, ok := c.bucketRegions[bucket]
+	c.mutex.Unlock()
+	if ok {
+		return region
+	}
+	query := make(url.Values)
+	query.Set("location", "")
+	req, err := c.newRequest("GET", c.region, bucket, "", query, nil, 0)
+	if err != nil {
+		return c.region
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return c.region
+	}
+	defer resp.Body.Close()
+	location := LocationResponse{}
+	if err = xml.NewDecoder(resp.Body).Decode(&location); err != nil {
+		return c.region
+	}
+	switch location.Location {
+	case "":
+		region = "us-east-1"
+	case "EU":
+		region = "eu-west-1"
+	default:
+		region = location.Location
+	}
+	c.mutex.Lock()
+	c.bucketRegions[bucket] = region
+	c.mutex.Unlock()
+	return region
+}
+
+// newRequest - returns a signed request of bucket and object.
+func (c *s3Client) newRequest(method, region, bucket, object string, query url.Values, body io.Reader, size int64) (*http.Request, error) {
+	urlPath := "/"
+	if bucket != "" {
+		urlPath += bucket
+		if object != "" {
+			urlPath += "/" + object
+		}
+	}
+	if query == nil {
+		query = make(url.Values)
+	}
+	preSignV4Query(c.getCredential(), region, method, c.endpoint.Host, urlPath, query, time.Now().UTC(), s3ClientSignExpiry)
+
+	encodedPath := strings.Replace(getURLEncodedName(urlPath), "+", "%20", -1)
+	rawQuery := strings.Replace(query.Encode(), "+", "%20", -1)
+	req, err := http.NewRequest(method, c.endpoint.Scheme+"://"+c.endpoint.Host+encodedPath+"?"+rawQuery, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	// Empty bodies are sent without chunked encoding.
+	if size > 0 {
+		req.ContentLength = size
+	} else {
+		req.Body = nil
+	}
+	return req, nil
+}
+
+// do - sends a request, error responses are returned as s3ClientError.
+func (c *s3Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Report canceled requests as such, not as network errors.
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	clientErr := s3ClientError{StatusCode: resp.StatusCode}
+	if xml.NewDecoder(resp.Body).Decode(&clientErr.APIErrorResponse) != nil || clientErr.Code == "" {
+		// Responses to HEAD requests have no body.
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			clientErr.Code = "NoSuchKey"
+		case http.StatusForbidden:
+			clientErr.Code = "AccessDenied"
+		default:
+			clientErr.Code = http.StatusText(resp.StatusCode)
+		}
+	}
+	return nil, clientErr
+}
+
+// doXML - sends a request and decodes the XML response into v.
+func (c *s3Client) doXML(req *http.Request, v interface{}) error {
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// doDiscard - sends a request and discards the response body.
+func (c *s3Client) doDiscard(req *http.Request) (http.Header, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.Header, nil
+}
+
+// setMetadataHeaders - sets the headers of object metadata.
+func setMetadataHeaders(req *http.Request, metadata map[string]string) error {
+	for key, value := range metadata {
+		switch lkey := strings.ToLower(key); {
+		case lkey == "md5sum":
+			md5Bytes, err := hex.DecodeString(value)
+			if err != nil {
+				return BadDigest{ExpectedMD5: value}
+			}
+			if len(md5Bytes) > 0 {
+				req.Header.Set("Content-Md5", base64.StdEncoding.EncodeToString(md5Bytes))
+			}
+		case lkey == "content-type", lkey == "content-encoding", strings.HasPrefix(lkey, "x-amz-meta-"):
+			if value != "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// parseAMZTime - parses time of S3 responses, zero time is returned
+// for unparseable times.
+func parseAMZTime(value string) time.Time {
+	t, err := time.Parse(timeFormatAMZ, value)
+	if err != nil {
+		t, _ = time.Parse(time.RFC3339Nano, value)
+	}
+	return t
+}
+
+// listBuckets - lists all buckets.
+func (c *s3Client) listBuckets() ([]BucketInfo, error) {
+	req, err := c.newRequest("GET", c.region, "", "", nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp := ListBucketsResponse{}
+	if err = c.doXML(req, &resp); err != nil {
+		return nil, err
+	}
+	var buckets []BucketInfo
+	for _, bucket := range resp.Buckets.Buckets {
+		buckets = append(buckets, BucketInfo{
+			Name:    bucket.Name,
+			Created: parseAMZTime(bucket.CreationDate),
+		})
+	}
+	return buckets, nil
+}
+
+// makeBucket - creates a bucket in the region of the client.
+func (c *s3Client) makeBucket(bucket string) error {
+	var body []byte
+	if c.region != "us-east-1" {
+		config := createBucketLocationConfiguration{Location: c.region}
+		var err error
+		if body, err = xml.Marshal(config); err != nil {
+			return err
+		}
+	}
+	req, err := c.newRequest("PUT", c.region, bucket, "", nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	_, err = c.doDiscard(req)
+	return err
+}
+
+// deleteBucket - deletes a bucket.
+func (c *s3Client) deleteBucket(bucket string) error {
+	req, err := c.newRequest("DELETE", c.getBucketRegion(bucket), bucket, "", nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	if _, err = c.doDiscard(req); err == nil {
+		c.mutex.Lock()
+		delete(c.bucketRegions, bucket)
+		c.mutex.Unlock()
+	}
+	return err
+}
+
+// listObjects - lists objects of a bucket.
+func (c *s3Client) listObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	query := make(url.Values)
+	query.Set("prefix", prefix)
+	query.Set("marker", marker)
+	query.Set("delimiter", delimiter)
+	query.Set("max-keys", strconv.Itoa(maxKeys))
+	req, err := c.newRequest("GET", c.getBucketRegion(bucket), bucket, "", query, nil, 0)
+	if err != nil {
+		return ListObjectsInfo{}, err
+	}
+	resp := ListObjectsResponse{}
+	if err = c.doXML(req, &resp); err != nil {
+		return ListObjectsInfo{}, err
+	}
+	result := ListObjectsInfo{
+		IsTruncated: resp.IsTruncated,
+		NextMarker:  resp.NextMarker,
+	}
+	for _, object := range resp.Contents {
+		result.Objects = append(result.Objects, ObjectInfo{
+			Bucket:  bucket,
+			Name:    object.Key,
+			ModTime: parseAMZTime(object.LastModified),
+			Size:    object.Size,
+			MD5Sum:  strings.Trim(object.ETag, "\""),
+		})
+	}
+	for _, prefix := range resp.CommonPrefixes {
+		result.Prefixes = append(result.Prefixes, prefix.Prefix)
+	}
+	// Next marker is only returned with a delimiter.
+	if result.IsTruncated && result.NextMarker == "" && len(result.Objects) > 0 {
+		result.NextMarker = result.Objects[len(result.Objects)-1].Name
+	}
+	return result, nil
+}
+
+// getObject - writes length bytes of an object from startOffset, the
+// request is canceled once ctx is done.
+func (c *s3Client) getObject(ctx context.Context, bucket, object string, startOffset, length int64, writer io.Writer) error {
+	req, err := c.newRequest("GET", c.getBucketRegion(bucket), bucket, object, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if length > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", startOffset, startOffset+length-1))
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if length <= 0 {
+		return nil
+	}
+	if _, err = copyN(writer, resp.Body, length); err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// statObject - returns the info of an object.
+func (c *s3Client) statObject(bucket, object string) (ObjectInfo, error) {
+	req, err := c.newRequest("HEAD", c.getBucketRegion(bucket), bucket, object, nil, nil, 0)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	header, err := c.doDiscard(req)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	modTime, _ := http.ParseTime(header.Get("Last-Modified"))
+	return ObjectInfo{
+		Bucket:          bucket,
+		Name:            object,
+		ModTime:         modTime,
+		Size:            size,
+		MD5Sum:          strings.Trim(header.Get("ETag"), "\""),
+		ContentType:     header.Get("Content-Type"),
+		ContentEncoding: header.Get("Content-Encoding"),
+	}, nil
+}
+
+// putObject - uploads an object of size bytes, returns its md5sum. The
+// request is canceled once ctx is done.
+func (c *s3Client) putObject(ctx context.Context, bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	req, err := c.newRequest("PUT", c.getBucketRegion(bucket), bucket, object, nil, data, size)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	if err = setMetadataHeaders(req, metadata); err != nil {
+		return "", err
+	}
+	header, err := c.doDiscard(req)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(header.Get("ETag"), "\""), nil
+}
+
+// deleteObject - deletes an object.
+func (c *s3Client) deleteObject(bucket, object string) error {
+	req, err := c.newRequest("DELETE", c.getBucketRegion(bucket), bucket, object, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	_, err = c.doDiscard(req)
+	return err
+}
+
+// listMultipartUploads - lists incomplete uploads of a bucket.
+func (c *s3Client) listMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	query := make(url.Values)
+	query.Set("uploads", "")
+	query.Set("prefix", prefix)
+	query.Set("key-marker", keyMarker)
+	query.Set("upload-id-marker", uploadIDMarker)
+	query.Set("delimiter", delimiter)
+	query.Set("max-uploads", strconv.Itoa(maxUploads))
+	req, err := c.newRequest("GET", c.getBucketRegion(bucket), bucket, "", query, nil, 0)
+	if err != nil {
+		return ListMultipartsInfo{}, err
+	}
+	resp := ListMultipartUploadsResponse{}
+	if err = c.doXML(req, &resp); err != nil {
+		return ListMultipartsInfo{}, err
+	}
+	result := ListMultipartsInfo{
+		KeyMarker:          resp.KeyMarker,
+		UploadIDMarker:     resp.UploadIDMarker,
+		NextKeyMarker:      resp.NextKeyMarker,
+		NextUploadIDMarker: resp.NextUploadIDMarker,
+		MaxUploads:         resp.MaxUploads,
+		IsTruncated:        resp.IsTruncated,
+		Prefix:             resp.Prefix,
+		Delimiter:          resp.Delimiter,
+	}
+	for _, upload := range resp.Uploads {
+		result.Uploads = append(result.Uploads, uploadMetadata{
+			Object:    upload.Key,
+			UploadID:  upload.UploadID,
+			Initiated: parseAMZTime(upload.Initiated),
+		})
+	}
+	for _, prefix := range resp.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, prefix.Prefix)
+	}
+	return result, nil
+}
+
+// newMultipartUpload - initiates a multipart upload, returns its id.
+func (c *s3Client) newMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	query := make(url.Values)
+	query.Set("uploads", "")
+	req, err := c.newRequest("POST", c.getBucketRegion(bucket), bucket, object, query, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	if err = setMetadataHeaders(req, metadata); err != nil {
+		return "", err
+	}
+	resp := InitiateMultipartUploadResponse{}
+	if err = c.doXML(req, &resp); err != nil {
+		return "", err
+	}
+	return resp.UploadID, nil
+}
+
+// putObjectPart - uploads a part of size bytes, returns its md5sum. The
+// request is canceled once ctx is done.
+func (c *s3Client) putObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+	query := make(url.Values)
+	query.Set("partNumber", strconv.Itoa(partID))
+	query.Set("uploadId", uploadID)
+	req, err := c.newRequest("PUT", c.getBucketRegion(bucket), bucket, object, query, data, size)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	if err = setMetadataHeaders(req, map[string]string{"md5Sum": md5Hex}); err != nil {
+		return "", err
+	}
+	header, err := c.doDiscard(req)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(header.Get("ETag"), "\""), nil
+}
+
+// listObjectParts - lists uploaded parts of a multipart upload.
+func (c *s3Client) listObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (ListPartsInfo, error) {
+	query := make(url.Values)
+	query.Set("uploadId", uploadID)
+	query.Set("part-number-marker", strconv.Itoa(partNumberMarker))
+	query.Set("max-parts", strconv.Itoa(maxParts))
+	req, err := c.newRequest("GET", c.getBucketRegion(bucket), bucket, object, query, nil, 0)
+	if err != nil {
+		return ListPartsInfo{}, err
+	}
+	resp := ListPartsResponse{}
+	if err = c.doXML(req, &resp); err != nil {
+		return ListPartsInfo{}, err
+	}
+	result := ListPartsInfo{
+		Bucket:               bucket,
+		Object:               object,
+		UploadID:             uploadID,
+		StorageClass:         resp.StorageClass,
+		PartNumberMarker:     resp.PartNumberMarker,
+		NextPartNumberMarker: resp.NextPartNumberMarker,
+		MaxParts:             resp.MaxParts,
+		IsTruncated:          resp.IsTruncated,
+	}
+	for _, part := range resp.Parts {
+		result.Parts = append(result.Parts, partInfo{
+			PartNumber:   part.PartNumber,
+			LastModified: parseAMZTime(part.LastModified),
+			ETag:         strings.Trim(part.ETag, "\""),
+			Size:         part.Size,
+		})
+	}
+	return result, nil
+}
+
+// abortMultipartUpload - aborts a multipart upload.
+func (c *s3Client) abortMultipartUpload(bucket, object, uploadID string) error {
+	query := make(url.Values)
+	query.Set("uploadId", uploadID)
+	req, err := c.newRequest("DELETE", c.getBucketRegion(bucket), bucket, object, query, nil, 0)
+	if err != nil {
+		return err
+	}
+	_, err = c.doDiscard(req)
+	return err
+}
+
+// completeMultipartUpload - completes a multipart upload of parts,
+// returns the md5sum of the object.
+func (c *s3Client) completeMultipartUpload(bucket, object, uploadID string, parts []completePart) (string, error) {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return "", err
+	}
+	query := make(url.Values)
+	query.Set("uploadId", uploadID)
+	req, err := c.newRequest("POST", c.getBucketRegion(bucket), bucket, object, query, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return "", err
+	}
+	resp := CompleteMultipartUploadResponse{}
+	if err = c.doXML(req, &resp); err != nil {
+		return "", err
+	}
+	return strings.Trim(resp.ETag, "\""), nil
+}
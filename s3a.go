@@ -0,0 +1,50 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// The S3A compatibility mode addresses what the Hadoop S3A file system
+// expects of a store beyond the S3 API. Retried completions of
+// multipart uploads committed by the S3A committers succeed, and
+// listings see every object written before each page was requested.
+// Directory markers are supported in every mode, see object-dir-marker.go.
+
+// isS3ACompat - returns if the S3A compatibility mode is on, it is off
+// if there is no server config.
+func isS3ACompat() bool {
+	if serverConfig == nil {
+		return false
+	}
+	return serverConfig.GetS3A()
+}
+
+// getCompletedUploadMD5 - returns the md5 of object if a retried
+// completion of a multipart upload failed with err as the first
+// completion already created object from parts.
+func getCompletedUploadMD5(objAPI ObjectLayer, bucket, object string, parts []completePart, err error) (string, bool) {
+	if _, ok := err.(InvalidUploadID); !ok || !isS3ACompat() {
+		return "", false
+	}
+	md5Sum, err := completeMultipartMD5(parts...)
+	if err != nil {
+		return "", false
+	}
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil || objInfo.MD5Sum != md5Sum {
+		return "", false
+	}
+	return md5Sum, true
+}
@@ -0,0 +1,172 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// isErrObjectNotFound - returns if err is ObjectNotFound.
+func isErrObjectNotFound(err error) bool {
+	_, ok := err.(ObjectNotFound)
+	return ok
+}
+
+// isErrObjectNameInvalid - returns if err is ObjectNameInvalid.
+func isErrObjectNameInvalid(err error) bool {
+	_, ok := err.(ObjectNameInvalid)
+	return ok
+}
+
+// Wrapper for calling testS3A for both XL and single node setup.
+func TestS3A(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	serverConfig.SetS3A(true)
+	defer serverConfig.SetS3A(false)
+
+	ExecObjectLayerTest(t, testS3A)
+}
+
+// Tests directory markers, list after write and retried completions
+// in the S3A compatibility mode.
+func testS3A(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	putObject := func(object string, data string) (string, error) {
+		return obj.PutObject(context.Background(), bucket, object, int64(len(data)), bytes.NewBufferString(data), nil)
+	}
+
+	// Empty directories are markers.
+	md5Sum, err := putObject("dir/", "")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if md5Sum != dirMarkerMD5Sum {
+		t.Errorf("%s: Expected md5 %s, got %s", instanceType, dirMarkerMD5Sum, md5Sum)
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, "dir/")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if objInfo.Size != 0 || objInfo.MD5Sum != dirMarkerMD5Sum || objInfo.ModTime.IsZero() {
+		t.Errorf("%s: Unexpected marker info %v", instanceType, objInfo)
+	}
+	var buf bytes.Buffer
+	if err = obj.GetObject(context.Background(), bucket, "dir/", 0, 0, &buf); err != nil || buf.Len() != 0 {
+		t.Errorf("%s: Expected an empty marker, got %d bytes, %v", instanceType, buf.Len(), err)
+	}
+	result, err := obj.ListObjects(bucket, "", "", slashSeparator, 10)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !reflect.DeepEqual(result.Prefixes, []string{"dir/"}) {
+		t.Errorf("%s: Expected prefix dir/, got %v", instanceType, result.Prefixes)
+	}
+
+	// Directories holding objects are no markers.
+	if _, err = putObject("dir/file", "data"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "dir/"); !isErrObjectNotFound(err) {
+		t.Errorf("%s: Expected ObjectNotFound, got %v", instanceType, err)
+	}
+	if err = obj.DeleteObject(bucket, "dir/"); !isErrObjectNotFound(err) {
+		t.Errorf("%s: Expected ObjectNotFound, got %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "dir/file"); err != nil {
+		t.Errorf("%s: Expected dir/file to be kept, got %v", instanceType, err)
+	}
+
+	// Deleted markers are gone.
+	if _, err = putObject("empty/", ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err = obj.DeleteObject(bucket, "empty/"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "empty/"); !isErrObjectNotFound(err) {
+		t.Errorf("%s: Expected ObjectNotFound, got %v", instanceType, err)
+	}
+
+	// Markers have no content and are no objects.
+	if _, err = putObject("data/", "data"); !isErrObjectNameInvalid(err) {
+		t.Errorf("%s: Expected ObjectNameInvalid, got %v", instanceType, err)
+	}
+	if _, err = putObject("object", "data"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = putObject("object/", ""); err == nil {
+		t.Errorf("%s: Expected a marker named like an object to fail", instanceType)
+	}
+
+	// Objects written between two pages are listed.
+	for _, object := range []string{"list/a", "list/b", "list/c"} {
+		if _, err = putObject(object, "data"); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	if result, err = obj.ListObjects(bucket, "list/", "", "", 1); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = putObject("list/a0", "data"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 1 || !result.IsTruncated {
+		t.Fatalf("%s: Expected a truncated page of list/a, got %v", instanceType, result.Objects)
+	}
+	if result, err = obj.ListObjects(bucket, "list/", result.Objects[0].Name, "", 1); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "list/a0" {
+		t.Errorf("%s: Expected list/a0, got %v", instanceType, result.Objects)
+	}
+
+	// Retried completions succeed.
+	uploadID, err := obj.NewMultipartUpload(bucket, "upload", nil)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	partMD5, err := obj.PutObjectPart(context.Background(), bucket, "upload", uploadID, 1, 4, bytes.NewBufferString("data"), "")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	parts := []completePart{{PartNumber: 1, ETag: partMD5}}
+	if md5Sum, err = obj.CompleteMultipartUpload(bucket, "upload", uploadID, parts); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	_, err = obj.CompleteMultipartUpload(bucket, "upload", uploadID, parts)
+	if completedMD5, ok := getCompletedUploadMD5(obj, bucket, "upload", parts, err); !ok || completedMD5 != md5Sum {
+		t.Errorf("%s: Expected the retried completion to succeed with %s, got %s", instanceType, md5Sum, completedMD5)
+	}
+	otherParts := []completePart{{PartNumber: 1, ETag: dirMarkerMD5Sum}}
+	if _, ok := getCompletedUploadMD5(obj, bucket, "upload", otherParts, err); ok {
+		t.Errorf("%s: Expected a completion of other parts to fail", instanceType)
+	}
+}
@@ -17,11 +17,14 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -32,15 +35,114 @@ import (
 	"github.com/minio/mc/pkg/console"
 )
 
+// serverFlags - flags of the server, shared by the gateways.
+var serverFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "address",
+		Value: ":9000",
+	},
+	cli.BoolFlag{
+		Name:  "worm",
+		Usage: "Enable WORM mode, existing objects cannot be overwritten or deleted.",
+	},
+	cli.StringFlag{
+		Name:  "mode",
+		Value: serverModeOnline,
+		Usage: "Start in online, read-only or maintenance mode, read-only and maintenance mode reject writes.",
+	},
+	cli.DurationFlag{
+		Name:  "shutdown-timeout",
+		Value: defaultShutdownTimeout,
+		Usage: "Time in-flight requests are given to complete when the server is stopped.",
+	},
+	cli.StringSliceFlag{
+		Name:  "meta-disk",
+		Value: &cli.StringSlice{},
+		Usage: "Place the meta volume of a disk on another device as DISK=PATH, repeat for each disk.",
+	},
+	cli.StringFlag{
+		Name:  "redirect-http",
+		Usage: "Listen for plain HTTP requests on this address and redirect them to HTTPS.",
+	},
+	cli.StringFlag{
+		Name:  "acme-domain",
+		Usage: "Obtain and renew certificates for these comma separated domains from Let's Encrypt.",
+	},
+	cli.StringFlag{
+		Name:  "acme-email",
+		Usage: "Contact email registered with Let's Encrypt for expiry notices.",
+	},
+	cli.StringFlag{
+		Name:  "acme-directory",
+		Value: acmeDirectoryURL,
+		Usage: "Directory URL of the ACME server certificates are obtained from.",
+	},
+	cli.DurationFlag{
+		Name:  "read-timeout",
+		Value: defaultReadTimeout,
+		Usage: "Maximum time to read a request including its body, 0 disables the timeout.",
+	},
+	cli.DurationFlag{
+		Name:  "write-timeout",
+		Value: defaultWriteTimeout,
+		Usage: "Maximum time to write a response including its body, 0 disables the timeout.",
+	},
+	cli.DurationFlag{
+		Name:  "idle-timeout",
+		Value: defaultIdleTimeout,
+		Usage: "Time an idle keep-alive connection is kept open, 0 uses the read timeout.",
+	},
+	cli.IntFlag{
+		Name:  "max-header-size",
+		Value: defaultMaxHeaderBytes,
+		Usage: "Maximum size in bytes of the request headers.",
+	},
+	cli.DurationFlag{
+		Name:  "keep-alive",
+		Value: defaultKeepAlivePeriod,
+		Usage: "Period of TCP keep-alive probes on client connections, negative disables them.",
+	},
+	cli.DurationFlag{
+		Name:  "max-clock-skew",
+		Value: defaultMaxClockSkew,
+		Usage: "Maximum difference between the time of signed requests and the server, nodes further apart refuse to start.",
+	},
+	cli.BoolFlag{
+		Name:  "no-http2",
+		Usage: "Disable HTTP/2, TLS clients are served over HTTP/1.1 only.",
+	},
+	cli.StringFlag{
+		Name:  "ftp",
+		Usage: "Serve buckets over FTP on this address, FTPS is offered if TLS certificates are configured.",
+	},
+	cli.StringFlag{
+		Name:  "sftp",
+		Usage: "Serve buckets over SFTP on this address, public keys in sftp/authorized_keys of the config directory log in as the access key.",
+	},
+	cli.StringFlag{
+		Name:  "nfs",
+		Usage: "Export buckets read-only over NFSv3 on this address, MOUNT is served on the same port.",
+	},
+	cli.StringSliceFlag{
+		Name:  "nfs-export",
+		Value: &cli.StringSlice{},
+		Usage: "Bucket exported over NFS, repeat for each bucket.",
+	},
+	cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print startup information as a single JSON document instead of the banner, for orchestration tools.",
+	},
+}
+
 var serverCmd = cli.Command{
 	Name:  "server",
 	Usage: "Start object storage server.",
-	Flags: []cli.Flag{
+	Flags: append(serverFlags,
 		cli.StringFlag{
-			Name:  "address",
-			Value: ":9000",
+			Name:  "backend",
+			Usage: "Serve a registered object layer other than FS and XL, which are detected from the disks.",
 		},
-	},
+	),
 	Action: serverMain,
 	CustomHelpTemplate: `NAME:
   minio {{.Name}} - {{.Usage}}
@@ -54,6 +156,9 @@ OPTIONS:
 ENVIRONMENT VARIABLES:
   MINIO_ACCESS_KEY: Access key string of 5 to 20 characters in length.
   MINIO_SECRET_KEY: Secret key string of 8 to 40 characters in length.
+  MINIO_SSE_MASTER_KEY: Master key for server side encryption as <key-id>:<hex-encoded 256 bit key>,
+     followed by comma separated retired keys still needed to unseal objects until they are rotated.
+  MINIO_ETCD_ENDPOINTS: Comma separated etcd endpoints to share the config and bucket configs through.
 
 EXAMPLES:
   1. Start minio server.
@@ -65,32 +170,116 @@ EXAMPLES:
   3. Start minio server on Windows.
       $ minio {{.Name}} C:\MyShare
 
-  4. Start minio server 12 disks to enable erasure coded layer with 6 data and 6 parity.
+  4. Start minio server in WORM mode, objects once written cannot be overwritten or deleted.
+      $ minio {{.Name}} --worm /home/shared
+
+  5. Start minio server over HTTPS and redirect plain HTTP requests on port 80.
+      $ minio {{.Name}} --address :443 --redirect-http :80 /home/shared
+
+  6. Start minio server over HTTPS with a certificate obtained from Let's Encrypt automatically.
+      $ minio {{.Name}} --address :443 --acme-domain play.example.com --acme-email admin@example.com /home/shared
+
+  7. Start minio server 12 disks to enable erasure coded layer with 6 data and 6 parity.
       $ minio {{.Name}} /mnt/export1/backend /mnt/export2/backend /mnt/export3/backend /mnt/export4/backend \
           /mnt/export5/backend /mnt/export6/backend /mnt/export7/backend /mnt/export8/backend /mnt/export9/backend \
           /mnt/export10/backend /mnt/export11/backend /mnt/export12/backend
+
+  8. Start minio server with the meta volume of a disk on a faster device.
+      $ minio {{.Name}} --meta-disk /mnt/hdd/backend=/mnt/ssd/meta /mnt/hdd/backend
+
+  9. Start minio server in read-only mode for a migration, writes are rejected until it is put online.
+      $ minio {{.Name}} --mode read-only /home/shared
+
+  10. Start minio server with an object layer registered by a compiled in backend, e.g. "tier".
+      $ minio {{.Name}} --backend tier /mnt/hot /mnt/cold
+
+  11. Start minio server allowing slow clients an hour to upload or download large objects.
+      $ minio {{.Name}} --read-timeout 1h --write-timeout 1h /home/shared
+
+  12. Start minio server letting scanners and other legacy devices upload to buckets over FTP.
+      $ minio {{.Name}} --ftp :2121 /home/shared
+
+  13. Start minio server letting clients transfer files to and from buckets over SFTP.
+      $ minio {{.Name}} --sftp :2022 /home/shared
+
+  14. Start minio server exporting bucket "photos" read-only over NFS, clients mount it with
+      "mount -o vers=3,proto=tcp,port=2049,mountport=2049,mountproto=tcp,nolock server:/photos /mnt".
+      $ minio {{.Name}} --nfs :2049 --nfs-export photos /home/shared
+
+  15. Start minio server with 32 disks in two erasure sets of 16 disks, add sets by appending their disks.
+      $ minio {{.Name}} /mnt/export{1..32}/backend
+
+  16. Start minio server printing endpoints, backend and disk states as JSON on standard output only.
+      $ minio --quiet {{.Name}} --json /home/shared
 `,
 }
 
+// Defaults of the HTTP listener flags.
+const (
+	// Timeouts of 10 minutes for unresponsive client connections.
+	defaultReadTimeout     = 10 * time.Minute
+	defaultWriteTimeout    = 10 * time.Minute
+	defaultIdleTimeout     = 90 * time.Second
+	defaultMaxHeaderBytes  = 1 << 20
+	defaultKeepAlivePeriod = 3 * time.Minute
+)
+
 type serverCmdConfig struct {
 	serverAddr  string
 	exportPaths []string
+	// Object layer served instead of the export paths, set in
+	// gateway mode.
+	objectLayer ObjectLayer
+	// Name of the object layer served, detected from the disks if
+	// empty.
+	backend string
+
+	// HTTP listener tuning.
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	idleTimeout    time.Duration
+	maxHeaderBytes int
+
+	// FTP listener, disabled if empty. FTPS is offered if a TLS
+	// config is set.
+	ftpAddr      string
+	ftpTLSConfig *tls.Config
+
+	// SFTP listener, disabled if empty.
+	sftpAddr string
+
+	// NFS listener and the buckets it exports, disabled if empty.
+	nfsAddr    string
+	nfsExports []string
 }
 
-// configureServer configure a new server instance
-func configureServer(srvCmdConfig serverCmdConfig) *http.Server {
+// configureServer configure a new server instance, returns it along
+// with the object layer it serves.
+func configureServer(srvCmdConfig serverCmdConfig) (*http.Server, ObjectLayer) {
+	handler, objAPI := configureServerHandler(srvCmdConfig)
+
 	// Minio server config
 	apiServer := &http.Server{
-		Addr: srvCmdConfig.serverAddr,
-		// Adding timeout of 10 minutes for unresponsive client connections.
-		ReadTimeout:    10 * time.Minute,
-		WriteTimeout:   10 * time.Minute,
-		Handler:        configureServerHandler(srvCmdConfig),
-		MaxHeaderBytes: 1 << 20,
+		Addr:           srvCmdConfig.serverAddr,
+		ReadTimeout:    srvCmdConfig.readTimeout,
+		WriteTimeout:   srvCmdConfig.writeTimeout,
+		IdleTimeout:    srvCmdConfig.idleTimeout,
+		Handler:        handler,
+		MaxHeaderBytes: srvCmdConfig.maxHeaderBytes,
 	}
 
 	// Returns configured HTTP server.
-	return apiServer
+	return apiServer, objAPI
+}
+
+// listenTCP - listens on addr, accepted connections send TCP
+// keep-alive probes every keepAlive period.
+func listenTCP(addr string, keepAlive time.Duration) (net.Listener, error) {
+	if addr == "" {
+		addr = ":http"
+	}
+	lc := net.ListenConfig{KeepAlive: keepAlive}
+	return lc.Listen(context.Background(), "tcp", addr)
 }
 
 // getListenIPs - gets all the ips to listen on.
@@ -116,14 +305,59 @@ func getListenIPs(httpServerConf *http.Server) (hosts []string, port string) {
 	return hosts, port
 }
 
+// getListenEndpoints - returns the endpoints of all listen ips.
+func getListenEndpoints(tls bool, hosts []string, port string) []string {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	endpoints := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		endpoints = append(endpoints, fmt.Sprintf("%s://%s:%s", scheme, host, port))
+	}
+	return endpoints
+}
+
 // Print listen ips.
 func printListenIPs(tls bool, hosts []string, port string) {
-	for _, host := range hosts {
-		if tls {
-			console.Printf("    https://%s:%s\n", host, port)
-		} else {
-			console.Printf("    http://%s:%s\n", host, port)
-		}
+	for _, endpoint := range getListenEndpoints(tls, hosts, port) {
+		console.Printf("    %s\n", endpoint)
+	}
+}
+
+// printStartupBanner - prints credentials, endpoints and how to
+// configure the Minio Client for humans.
+func printStartupBanner(tls bool, hosts []string, port string) {
+	// Credential.
+	cred := serverConfig.GetCredential()
+
+	// Region.
+	region := serverConfig.GetRegion()
+
+	// Print credentials and region.
+	console.Println("\n" + cred.String() + "  " + colorMagenta("Region: ") + colorWhite(region))
+
+	console.Println("\nMinio Object Storage:")
+	// Print api listen ips.
+	printListenIPs(tls, hosts, port)
+
+	console.Println("\nMinio Browser:")
+	// Print browser listen ips.
+	printListenIPs(tls, hosts, port)
+
+	console.Println("\nTo configure Minio Client:")
+
+	// Figure out right endpoint for 'mc'.
+	endpoint := getListenEndpoints(tls, hosts[:1], port)[0]
+
+	// Download 'mc' info.
+	if runtime.GOOS == "windows" {
+		console.Printf("    Download 'mc' from https://dl.minio.io/client/mc/release/%s-%s/mc.exe\n", runtime.GOOS, runtime.GOARCH)
+		console.Printf("    $ mc.exe config host add myminio %s %s %s\n", endpoint, cred.AccessKeyID, cred.SecretAccessKey)
+	} else {
+		console.Printf("    $ wget https://dl.minio.io/client/mc/release/%s-%s/mc\n", runtime.GOOS, runtime.GOARCH)
+		console.Printf("    $ chmod 755 mc\n")
+		console.Printf("    $ ./mc config host add myminio %s %s %s\n", endpoint, cred.AccessKeyID, cred.SecretAccessKey)
 	}
 }
 
@@ -242,6 +476,28 @@ func checkPortAvailability(port int) {
 	}
 }
 
+// parseMetaDisks - parses DISK=PATH pairs into meta volume locations
+// by absolute disk path.
+func parseMetaDisks(values []string) (map[string]string, error) {
+	metaDisks := make(map[string]string)
+	for _, value := range values {
+		i := strings.LastIndex(value, "=")
+		if i <= 0 || i == len(value)-1 {
+			return nil, fmt.Errorf("%s is not of the form DISK=PATH", value)
+		}
+		diskPath, err := filepath.Abs(value[:i])
+		if err != nil {
+			return nil, err
+		}
+		metaPath, err := filepath.Abs(value[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		metaDisks[diskPath] = metaPath
+	}
+	return metaDisks, nil
+}
+
 func serverMain(c *cli.Context) {
 	// check 'server' cli arguments.
 	checkServerSyntax(c)
@@ -249,15 +505,66 @@ func serverMain(c *cli.Context) {
 	// Initialize server config.
 	initServerConfig(c)
 
+	// Other registered object layers are served like gateways, without
+	// local disks.
+	if backend := c.String("backend"); backend != "" {
+		if backend == objectLayerFS || backend == objectLayerXL || backend == objectLayerXLSets {
+			fatalIf(errInvalidArgument, "Backend %s is detected from the disks.", backend)
+		}
+		objAPI, err := newRegisteredObjectLayer(backend, c.Args())
+		fatalIf(err, "Unable to initialize backend %s, registered backends are %s.", backend, strings.Join(registeredObjectLayers(), ", "))
+		startServer(c, serverCmdConfig{
+			objectLayer: objAPI,
+			backend:     backend,
+		})
+		return
+	}
+
+	// Save all command line args as export paths.
+	startServer(c, serverCmdConfig{
+		exportPaths: c.Args(),
+	})
+}
+
+// startServer - starts serving the object layer of srvCmdConfig, common
+// to the server and gateway commands which share the server flags.
+func startServer(c *cli.Context, srvCmdConfig serverCmdConfig) {
 	// Server address.
 	serverAddress := c.String("address")
 
+	// Enable WORM mode if requested.
+	globalWORMEnabled = c.Bool("worm")
+
+	// Signed requests are accepted within the allowed clock skew.
+	globalMaxClockSkew = c.Duration("max-clock-skew")
+
+	// Start in the requested mode, changed later through the admin API.
+	fatalIf(globalServerMode.Set(c.String("mode")), "Invalid server mode %s.", c.String("mode"))
+
+	// Meta volumes placed on other devices.
+	metaDisks, err := parseMetaDisks(c.StringSlice("meta-disk"))
+	fatalIf(err, "Invalid meta disks.")
+	globalMetaDisks = metaDisks
+
+	// Initialize key management service for server side encryption.
+	initKMS()
+
+	// Share the config through etcd if configured.
+	err = initEtcdConfig(globalServiceDoneCh)
+	fatalIf(err, "Unable to initialize config in etcd.")
+
+	// Initialize audit logging targets.
+	initAuditLogger()
+
+	// Domains to obtain certificates for from the ACME server.
+	acmeDomains := c.String("acme-domain")
+
 	host, port, _ := net.SplitHostPort(serverAddress)
 	// If port empty, default to port '80'
 	if port == "" {
 		port = "80"
 		// if SSL is enabled, choose port as "443" instead.
-		if isSSL() {
+		if isSSL() || acmeDomains != "" {
 			port = "443"
 		}
 	}
@@ -265,61 +572,125 @@ func serverMain(c *cli.Context) {
 	// Check if requested port is available.
 	checkPortAvailability(getPort(net.JoinHostPort(host, port)))
 
-	// Save all command line args as export paths.
-	exportPaths := c.Args()
+	// Initialize bucket federation.
+	initFederation(port)
 
 	// Configure server.
-	apiServer := configureServer(serverCmdConfig{
-		serverAddr:  serverAddress,
-		exportPaths: exportPaths,
-	})
-
-	// Credential.
-	cred := serverConfig.GetCredential()
-
-	// Region.
-	region := serverConfig.GetRegion()
+	srvCmdConfig.serverAddr = serverAddress
+	srvCmdConfig.readTimeout = c.Duration("read-timeout")
+	srvCmdConfig.writeTimeout = c.Duration("write-timeout")
+	srvCmdConfig.idleTimeout = c.Duration("idle-timeout")
+	srvCmdConfig.maxHeaderBytes = c.Int("max-header-size")
+
+	// Configure TLS if certs are available, certificates are reloaded
+	// on change without restarting the server.
+	var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	if isSSL() {
+		certs, err := newCertManager(mustGetCertsPath())
+		fatalIf(err, "Unable to load certificates.")
+		go certs.watch(certsReloadInterval, nil)
+		getCertificate = certs.GetCertificate
+	}
+	// HTTP/2 is negotiated over TLS, plain HTTP is served over HTTP/1.1.
+	nextProtos := []string{"h2", "http/1.1"}
+	if c.Bool("no-http2") {
+		nextProtos = []string{"http/1.1"}
+	}
 
-	// Print credentials and region.
-	console.Println("\n" + cred.String() + "  " + colorMagenta("Region: ") + colorWhite(region))
+	// Obtain certificates from the ACME server if requested, static
+	// certificates are still served for all other host names.
+	if acmeDomains != "" {
+		acmePath := filepath.Join(mustGetConfigPath(), acmeDir)
+		acme, err := newACMEManager(c.String("acme-directory"), acmePath, strings.Split(acmeDomains, ","), c.String("acme-email"))
+		fatalIf(err, "Unable to initialize ACME certificate provisioning.")
+		acme.fallback = getCertificate
+		go acme.run(acmeCheckInterval, nil)
+		getCertificate = acme.GetCertificate
+		// Domains are validated over TLS with a dedicated protocol.
+		nextProtos = append(nextProtos, acmeALPNProto)
+	}
 
-	hosts, port := getListenIPs(apiServer) // get listen ips and port.
-	tls := apiServer.TLSConfig != nil      // 'true' if TLS is enabled.
+	// Serve buckets over FTP if requested, the listener is started
+	// with the object layer.
+	if ftpAddress := c.String("ftp"); ftpAddress != "" {
+		checkPortAvailability(getPort(ftpAddress))
+		srvCmdConfig.ftpAddr = ftpAddress
+		if getCertificate != nil {
+			srvCmdConfig.ftpTLSConfig = &tls.Config{GetCertificate: getCertificate}
+		}
+	}
 
-	console.Println("\nMinio Object Storage:")
-	// Print api listen ips.
-	printListenIPs(tls, hosts, port)
+	// Serve buckets over SFTP if requested.
+	if sftpAddress := c.String("sftp"); sftpAddress != "" {
+		checkPortAvailability(getPort(sftpAddress))
+		srvCmdConfig.sftpAddr = sftpAddress
+	}
 
-	console.Println("\nMinio Browser:")
-	// Print browser listen ips.
-	printListenIPs(tls, hosts, port)
+	// Export buckets over NFS if requested.
+	if nfsAddress := c.String("nfs"); nfsAddress != "" {
+		if len(c.StringSlice("nfs-export")) == 0 {
+			fatalIf(errInvalidArgument, "NFS requires at least one bucket to be exported with --nfs-export.")
+		}
+		checkPortAvailability(getPort(nfsAddress))
+		srvCmdConfig.nfsAddr = nfsAddress
+		srvCmdConfig.nfsExports = c.StringSlice("nfs-export")
+	}
 
-	console.Println("\nTo configure Minio Client:")
+	apiServer, objAPI := configureServer(srvCmdConfig)
+	if c.Bool("no-http2") {
+		// A non-nil map keeps the server from configuring HTTP/2.
+		apiServer.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	if getCertificate != nil {
+		apiServer.TLSConfig = &tls.Config{
+			GetCertificate: getCertificate,
+			NextProtos:     nextProtos,
+		}
+	}
 
-	// Figure out right endpoint for 'mc'.
-	endpoint := fmt.Sprintf("http://%s:%s", hosts[0], port)
-	if tls {
-		endpoint = fmt.Sprintf("https://%s:%s", hosts[0], port)
+	// Redirect plain HTTP requests to HTTPS if requested.
+	if redirectAddress := c.String("redirect-http"); redirectAddress != "" {
+		if apiServer.TLSConfig == nil {
+			fatalIf(errInvalidArgument, "HTTP redirect requires TLS certificates to be configured.")
+		}
+		checkPortAvailability(getPort(redirectAddress))
+		redirectHandler := httpsRedirectHandler{port: port}
+		go func() {
+			err := http.ListenAndServe(redirectAddress, redirectHandler)
+			fatalIf(err, "Failed to start HTTP redirect server.")
+		}()
 	}
 
-	// Download 'mc' info.
-	if runtime.GOOS == "windows" {
-		console.Printf("    Download 'mc' from https://dl.minio.io/client/mc/release/%s-%s/mc.exe\n", runtime.GOOS, runtime.GOARCH)
-		console.Printf("    $ mc.exe config host add myminio %s %s %s\n", endpoint, cred.AccessKeyID, cred.SecretAccessKey)
+	hosts, port := getListenIPs(apiServer) // get listen ips and port.
+	tls := apiServer.TLSConfig != nil      // 'true' if TLS is enabled.
+
+	// Print startup information for orchestration tools or the banner.
+	if c.Bool("json") {
+		info := getStartupInfo(objAPI, srvCmdConfig, tls, hosts, port)
+		fatalIf(printStartupInfo(os.Stdout, info), "Unable to print startup information.")
 	} else {
-		console.Printf("    $ wget https://dl.minio.io/client/mc/release/%s-%s/mc\n", runtime.GOOS, runtime.GOARCH)
-		console.Printf("    $ chmod 755 mc\n")
-		console.Printf("    $ ./mc config host add myminio %s %s %s\n", endpoint, cred.AccessKeyID, cred.SecretAccessKey)
+		printStartupBanner(tls, hosts, port)
 	}
 
 	// Start server.
-	var err error
-	// Configure TLS if certs are available.
-	if isSSL() {
-		err = apiServer.ListenAndServeTLS(mustGetCertFile(), mustGetKeyFile())
-	} else {
-		// Fallback to http.
-		err = apiServer.ListenAndServe()
-	}
-	fatalIf(err, "Failed to start minio server.")
+	listener, err := listenTCP(apiServer.Addr, c.Duration("keep-alive"))
+	fatalIf(err, "Unable to listen on %s.", apiServer.Addr)
+	go func() {
+		var err error
+		if tls {
+			// Certificates are served by the TLS config.
+			err = apiServer.ServeTLS(listener, "", "")
+		} else {
+			// Fallback to http.
+			err = apiServer.Serve(listener)
+		}
+		// Server is closed on service stop and restart.
+		if err != http.ErrServerClosed {
+			fatalIf(err, "Failed to start minio server.")
+		}
+	}()
+
+	// Wait for service stop or restart requests through the admin API
+	// or termination signals.
+	handleServiceSignals(apiServer, c.Duration("shutdown-timeout"))
 }
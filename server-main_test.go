@@ -0,0 +1,43 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Tests parsing of meta disks given on the command line.
+func TestParseMetaDisks(t *testing.T) {
+	metaDisks, err := parseMetaDisks([]string{"/mnt/hdd1=/mnt/ssd/hdd1", "/mnt/hdd2=/mnt/ssd/hdd2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdd1, _ := filepath.Abs("/mnt/hdd1")
+	if metaPath, _ := filepath.Abs("/mnt/ssd/hdd1"); metaDisks[hdd1] != metaPath {
+		t.Errorf("Expected %s, but instead found %s", metaPath, metaDisks[hdd1])
+	}
+	if len(metaDisks) != 2 {
+		t.Errorf("Expected 2 meta disks, but instead found %d", len(metaDisks))
+	}
+
+	for i, value := range []string{"/mnt/hdd1", "=/mnt/ssd", "/mnt/hdd1="} {
+		if _, err = parseMetaDisks([]string{value}); err == nil {
+			t.Errorf("Test %d: Expected %s to be invalid", i+1, value)
+		}
+	}
+}
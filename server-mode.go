@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Server modes, writes are rejected in read-only and maintenance mode.
+// Maintenance mode additionally pauses background crawling and purging
+// to keep the disks idle.
+const (
+	serverModeOnline      = "online"
+	serverModeReadOnly    = "read-only"
+	serverModeMaintenance = "maintenance"
+)
+
+var errInvalidServerMode = errors.New("Invalid server mode")
+
+// serverMode - current mode of the server, set via command line and
+// changed through the admin API.
+type serverMode struct {
+	mutex *sync.RWMutex
+	mode  string
+}
+
+// globalServerMode - mode of the server, online by default.
+var globalServerMode = &serverMode{
+	mutex: &sync.RWMutex{},
+	mode:  serverModeOnline,
+}
+
+// Get - returns the current mode.
+func (m *serverMode) Get() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.mode
+}
+
+// Set - changes the mode, returns errInvalidServerMode for unknown modes.
+func (m *serverMode) Set(mode string) error {
+	switch mode {
+	case serverModeOnline, serverModeReadOnly, serverModeMaintenance:
+	default:
+		return errInvalidServerMode
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.mode = mode
+	return nil
+}
+
+// IsPaused - returns true if background operations are paused.
+func (m *serverMode) IsPaused() bool {
+	return m.Get() == serverModeMaintenance
+}
+
+// serverModeAllowsWrites - returns true if writes are allowed in the
+// current mode, background operations writing to the disks wait for
+// it instead of failing.
+func serverModeAllowsWrites() bool {
+	return globalServerMode.Get() == serverModeOnline
+}
+
+// checkWrite - returns the error writes are rejected with in the
+// current mode, ErrNone if writes are allowed.
+func (m *serverMode) checkWrite() APIErrorCode {
+	switch m.Get() {
+	case serverModeReadOnly:
+		return ErrServerReadOnly
+	case serverModeMaintenance:
+		return ErrServerMaintenance
+	}
+	return ErrNone
+}
+
+// isWriteRequest - returns true if r modifies buckets or objects. Admin,
+// RPC and browser RPC requests are checked by their handlers.
+func isWriteRequest(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, reservedBucket+"/") && !strings.HasPrefix(r.URL.Path, reservedBucket+"/upload/") {
+		return false
+	}
+	switch r.Method {
+	case "PUT", "DELETE":
+		return true
+	case "POST":
+		// Select only reads the object.
+		_, isSelect := r.URL.Query()["select"]
+		return !isSelect
+	}
+	return false
+}
+
+// serverModeHandler - rejects writes in read-only and maintenance mode.
+type serverModeHandler struct {
+	handler http.Handler
+}
+
+func setServerModeHandler(h http.Handler) http.Handler {
+	return serverModeHandler{handler: h}
+}
+
+func (h serverModeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWriteRequest(r) {
+		if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
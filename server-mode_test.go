@@ -0,0 +1,87 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests writes are rejected in read-only and maintenance mode.
+func TestServerModeHandler(t *testing.T) {
+	defer globalServerMode.Set(serverModeOnline)
+
+	if err := globalServerMode.Set("offline"); err != errInvalidServerMode {
+		t.Fatalf("Expected %s, but instead found %v", errInvalidServerMode, err)
+	}
+	handler := setServerModeHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		mode   string
+		method string
+		url    string
+		code   string
+	}{
+		// Test case - 1.
+		{serverModeOnline, "PUT", "/bucket/object", ""},
+		// Test case - 2.
+		{serverModeReadOnly, "PUT", "/bucket/object", "XMinioServerReadOnly"},
+		// Test case - 3.
+		{serverModeReadOnly, "GET", "/bucket/object", ""},
+		// Test case - 4.
+		{serverModeReadOnly, "DELETE", "/bucket", "XMinioServerReadOnly"},
+		// Test case - 5.
+		// Select only reads the object.
+		{serverModeReadOnly, "POST", "/bucket/object?select&select-type=2", ""},
+		// Test case - 6.
+		{serverModeMaintenance, "POST", "/bucket/object?uploads", "XMinioServerMaintenance"},
+		// Test case - 7.
+		// Admin requests are allowed to put the server online again.
+		{serverModeMaintenance, "PUT", "/minio/admin/v1/service/mode?mode=online", ""},
+		// Test case - 8.
+		{serverModeMaintenance, "PUT", "/minio/upload/bucket/object", "XMinioServerMaintenance"},
+	}
+	for i, testCase := range testCases {
+		if err := globalServerMode.Set(testCase.mode); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.url, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if serverModeAllowsWrites() != (testCase.mode == serverModeOnline) {
+			t.Errorf("Test %d: Background writes allowed in mode %s", i+1, testCase.mode)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if testCase.code == "" {
+			if rec.Code != http.StatusOK {
+				t.Errorf("Test %d: Expected status %d, but instead found %d", i+1, http.StatusOK, rec.Code)
+			}
+			continue
+		}
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Test %d: Expected status %d, but instead found %d", i+1, http.StatusServiceUnavailable, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "<Code>"+testCase.code+"</Code>") {
+			t.Errorf("Test %d: Expected error %s, but instead found %s", i+1, testCase.code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Disk states in the startup information.
+const (
+	startupDiskOnline  = "online"
+	startupDiskOffline = "offline"
+)
+
+// startupDisk - state of a disk at startup.
+type startupDisk struct {
+	Endpoint   string `json:"endpoint"`
+	State      string `json:"state"`
+	HealStatus string `json:"healStatus,omitempty"`
+}
+
+// startupInfo - startup information printed with --json in place of
+// the banner, for orchestration tools. Secrets are never included.
+type startupInfo struct {
+	Version    string        `json:"version"`
+	CommitID   string        `json:"commitID"`
+	Backend    string        `json:"backend"`
+	ServerMode string        `json:"serverMode"`
+	Region     string        `json:"region"`
+	AccessKey  string        `json:"accessKey"`
+	TLS        bool          `json:"tls"`
+	Endpoints  []string      `json:"endpoints"`
+	FTP        string        `json:"ftp,omitempty"`
+	SFTP       string        `json:"sftp,omitempty"`
+	NFS        string        `json:"nfs,omitempty"`
+	Disks      []startupDisk `json:"disks,omitempty"`
+}
+
+// getBackendName - returns the name of the object layer served, as
+// registered for layers detected from the disks.
+func getBackendName(objAPI ObjectLayer, backend string) string {
+	if backend != "" {
+		return backend
+	}
+	switch objAPI.(type) {
+	case fsObjects:
+		return objectLayerFS
+	case xlObjects:
+		return objectLayerXL
+	case *xlSets:
+		return objectLayerXLSets
+	}
+	return ""
+}
+
+// getStartupInfo - returns the startup information of the server.
+func getStartupInfo(objAPI ObjectLayer, srvCmdConfig serverCmdConfig, tls bool, hosts []string, port string) startupInfo {
+	info := startupInfo{
+		Version:    minioVersion,
+		CommitID:   minioCommitID,
+		Backend:    getBackendName(objAPI, srvCmdConfig.backend),
+		ServerMode: globalServerMode.Get(),
+		Region:     serverConfig.GetRegion(),
+		AccessKey:  serverConfig.GetCredential().AccessKeyID,
+		TLS:        tls,
+		Endpoints:  getListenEndpoints(tls, hosts, port),
+		FTP:        srvCmdConfig.ftpAddr,
+		SFTP:       srvCmdConfig.sftpAddr,
+		NFS:        srvCmdConfig.nfsAddr,
+	}
+	// Gateways have no local disks.
+	if srvCmdConfig.objectLayer != nil {
+		return info
+	}
+	for _, disk := range objAPI.DisksInfo() {
+		state := startupDiskOffline
+		if disk.Online {
+			state = startupDiskOnline
+		}
+		info.Disks = append(info.Disks, startupDisk{
+			Endpoint:   disk.Endpoint,
+			State:      state,
+			HealStatus: disk.HealStatus,
+		})
+	}
+	return info
+}
+
+// printStartupInfo - prints info as a single line JSON document.
+func printStartupInfo(w io.Writer, info startupInfo) error {
+	return json.NewEncoder(w).Encode(info)
+}
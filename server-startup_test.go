@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Tests startup information of an FS backend and a gateway.
+func TestStartupInfo(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	obj, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+
+	cred := serverConfig.GetCredential()
+	info := getStartupInfo(obj, serverCmdConfig{exportPaths: []string{fsDir}}, true, []string{"127.0.0.1", "10.0.0.1"}, "9000")
+	if info.Backend != objectLayerFS {
+		t.Fatalf("Expected backend %s, but instead got %s", objectLayerFS, info.Backend)
+	}
+	if info.AccessKey != cred.AccessKeyID || !info.TLS {
+		t.Fatalf("Unexpected startup information %+v", info)
+	}
+	if strings.Join(info.Endpoints, " ") != "https://127.0.0.1:9000 https://10.0.0.1:9000" {
+		t.Fatalf("Unexpected endpoints %v", info.Endpoints)
+	}
+	if len(info.Disks) != 1 || info.Disks[0].State != startupDiskOnline {
+		t.Fatalf("Expected a single online disk, but instead got %+v", info.Disks)
+	}
+
+	var buf bytes.Buffer
+	if err = printStartupInfo(&buf, info); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte(cred.SecretAccessKey)) {
+		t.Fatal("Expected secret key to be left out of the startup information")
+	}
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Fatal("Expected startup information on a single line")
+	}
+	var decoded startupInfo
+	if err = json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	// Gateways name their backend and have no local disks.
+	info = getStartupInfo(obj, serverCmdConfig{objectLayer: obj, backend: gatewayNAS}, false, []string{"127.0.0.1"}, "9000")
+	if info.Backend != gatewayNAS || len(info.Disks) != 0 {
+		t.Fatalf("Unexpected gateway startup information %+v", info)
+	}
+	if info.Endpoints[0] != "http://127.0.0.1:9000" {
+		t.Fatalf("Unexpected endpoints %v", info.Endpoints)
+	}
+}
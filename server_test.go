@@ -22,12 +22,18 @@ import (
 	"encoding/base64"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gorilla/rpc/v2/json2"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -164,6 +170,71 @@ func (s *MyAPISuite) TestDeleteBucket(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
 }
 
+func (s *MyAPISuite) TestDeleteMultipleObjects(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/deletemultipleobjects",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	for _, object := range []string{"object1", "object2"} {
+		buffer := bytes.NewReader([]byte("hello"))
+		request, err = newTestRequest("PUT", s.testServer.Server.URL+"/deletemultipleobjects/"+object,
+			int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	// Objects which do not exist are reported as deleted.
+	deleteXML := `<Delete><Object><Key>object1</Key></Object><Object><Key>object2</Key></Object><Object><Key>object3</Key></Object></Delete>`
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/deletemultipleobjects?delete",
+		int64(len(deleteXML)), bytes.NewReader([]byte(deleteXML)), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	var deleteResponse DeleteObjectsResponse
+	c.Assert(xml.NewDecoder(response.Body).Decode(&deleteResponse), IsNil)
+	c.Assert(len(deleteResponse.DeletedObjects), Equals, 3)
+	c.Assert(len(deleteResponse.Errors), Equals, 0)
+	for i, object := range []string{"object1", "object2", "object3"} {
+		c.Assert(deleteResponse.DeletedObjects[i].ObjectName, Equals, object)
+	}
+
+	request, err = newTestRequest("HEAD", s.testServer.Server.URL+"/deletemultipleobjects/object1",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	// Quiet mode only reports errors.
+	deleteXML = `<Delete><Quiet>true</Quiet><Object><Key>object1</Key></Object></Delete>`
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/deletemultipleobjects?delete",
+		int64(len(deleteXML)), bytes.NewReader([]byte(deleteXML)), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	deleteResponse = DeleteObjectsResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&deleteResponse), IsNil)
+	c.Assert(len(deleteResponse.DeletedObjects), Equals, 0)
+
+	// At most 1000 objects can be deleted at once.
+	deleteXML = "<Delete>" + strings.Repeat("<Object><Key>object</Key></Object>", maxDeleteList+1) + "</Delete>"
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/deletemultipleobjects?delete",
+		int64(len(deleteXML)), bytes.NewReader([]byte(deleteXML)), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest)
+}
+
 func (s *MyAPISuite) TestDeleteBucketNotEmpty(c *C) {
 	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/deletebucket-notempty",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
@@ -339,6 +410,230 @@ func (s *MyAPISuite) TestObjectGet(c *C) {
 
 }
 
+func (s *MyAPISuite) TestPresignedGetObject(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/presigned-get",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/presigned-get/shared object",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Presigned URL is usable without any credentials.
+	u, err := url.Parse(s.testServer.Server.URL)
+	c.Assert(err, IsNil)
+	presignedURL := preSignV4("GET", u.Host, "presigned-get", "shared object", time.Now().UTC(), time.Hour)
+	response, err = client.Get(s.testServer.Server.URL + presignedURL)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "hello world")
+
+	// Expired presigned URL.
+	presignedURL = preSignV4("GET", u.Host, "presigned-get", "shared object", time.Now().UTC().Add(-2*time.Hour), time.Hour)
+	response, err = client.Get(s.testServer.Server.URL + presignedURL)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Request has expired.", http.StatusBadRequest)
+}
+
+// webRPCCall - calls method of the browser RPC API with token.
+func webRPCCall(serverURL, token, method string, args, reply interface{}) error {
+	body, err := json2.EncodeClientRequest("Web."+method, args)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", serverURL+reservedBucket+"/webrpc", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Amz-Date", time.Now().UTC().Format(iso8601Format))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json2.DecodeClientResponse(response.Body, reply)
+}
+
+func (s *MyAPISuite) TestWebRPC(c *C) {
+	serverURL := s.testServer.Server.URL
+
+	// Login with wrong credentials fails.
+	loginReply := &LoginRep{}
+	err := webRPCCall(serverURL, "", "Login", &LoginArgs{Username: s.testServer.AccessKey, Password: "wrong"}, loginReply)
+	c.Assert(err, NotNil)
+
+	err = webRPCCall(serverURL, "", "Login", &LoginArgs{Username: s.testServer.AccessKey, Password: s.testServer.SecretKey}, loginReply)
+	c.Assert(err, IsNil)
+	token := loginReply.Token
+	c.Assert(token, Not(Equals), "")
+
+	// Calls without a valid token are rejected.
+	err = webRPCCall(serverURL, "", "ListBuckets", &WebGenericArgs{}, &ListBucketsRep{})
+	c.Assert(err, ErrorMatches, "Unauthorized request")
+	err = webRPCCall(serverURL, token+"x", "ListBuckets", &WebGenericArgs{}, &ListBucketsRep{})
+	c.Assert(err, NotNil)
+
+	err = webRPCCall(serverURL, token, "MakeBucket", &MakeBucketArgs{BucketName: "webrpc"}, &WebGenericRep{})
+	c.Assert(err, IsNil)
+
+	// Reserved bucket is not accessible.
+	err = webRPCCall(serverURL, token, "MakeBucket", &MakeBucketArgs{BucketName: "minio"}, &WebGenericRep{})
+	c.Assert(err, ErrorMatches, "All access to this bucket has been disabled.")
+
+	listBucketsReply := &ListBucketsRep{}
+	err = webRPCCall(serverURL, token, "ListBuckets", &WebGenericArgs{}, listBucketsReply)
+	c.Assert(err, IsNil)
+	var found bool
+	for _, bucket := range listBucketsReply.Buckets {
+		if bucket.Name == "webrpc" {
+			found = true
+		}
+	}
+	c.Assert(found, Equals, true)
+
+	u, err := url.Parse(serverURL)
+	c.Assert(err, IsNil)
+
+	// Upload with a presigned URL.
+	urlReply := &ObjectURLRep{}
+	err = webRPCCall(serverURL, token, "PutObjectURL", &ObjectURLArgs{TargetHost: u.Host, BucketName: "webrpc", ObjectName: "object"}, urlReply)
+	c.Assert(err, IsNil)
+	request, err := http.NewRequest("PUT", urlReply.URL, bytes.NewReader([]byte("hello world")))
+	c.Assert(err, IsNil)
+	response, err := http.DefaultClient.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	listObjectsReply := &ListObjectsRep{}
+	err = webRPCCall(serverURL, token, "ListObjects", &ListObjectsArgs{BucketName: "webrpc"}, listObjectsReply)
+	c.Assert(err, IsNil)
+	c.Assert(len(listObjectsReply.Objects), Equals, 1)
+	c.Assert(listObjectsReply.Objects[0].Key, Equals, "object")
+
+	// Download with a presigned URL.
+	err = webRPCCall(serverURL, token, "GetObjectURL", &ObjectURLArgs{TargetHost: u.Host, BucketName: "webrpc", ObjectName: "object"}, urlReply)
+	c.Assert(err, IsNil)
+	response, err = http.Get(urlReply.URL)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "hello world")
+
+	// Presigned URL of one method is not valid for another.
+	request, err = http.NewRequest("PUT", urlReply.URL, bytes.NewReader([]byte("hello world")))
+	c.Assert(err, IsNil)
+	response, err = http.DefaultClient.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// Share links with unknown expiry presets are rejected.
+	shareReply := &ShareObjectRep{}
+	err = webRPCCall(serverURL, token, "ShareObject", &ShareObjectArgs{HostName: u.Host, BucketName: "webrpc", ObjectName: "object", Expiry: "30d"}, shareReply)
+	c.Assert(err, NotNil)
+
+	// Share links allow a limited number of downloads.
+	err = webRPCCall(serverURL, token, "ShareObject", &ShareObjectArgs{HostName: u.Host, BucketName: "webrpc", ObjectName: "object", Expiry: "1h", MaxDownloads: 1}, shareReply)
+	c.Assert(err, IsNil)
+	c.Assert(shareReply.ID, Not(Equals), "")
+	// Ranged downloads are not counted.
+	request, err = http.NewRequest("GET", shareReply.URL, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("Range", "bytes=0-4")
+	response, err = http.DefaultClient.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "hello")
+	response, err = http.Get(shareReply.URL)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "hello world")
+	response, err = http.Get(shareReply.URL)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// The share id can not be removed from the signed URL.
+	shareURL, err := url.Parse(shareReply.URL)
+	c.Assert(err, IsNil)
+	query := shareURL.Query()
+	query.Del(shareQueryParam)
+	shareURL.RawQuery = query.Encode()
+	response, err = http.Get(shareURL.String())
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+}
+
+func (s *MyAPISuite) TestObjectGetResponseHeaders(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/respheaders",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/respheaders/object", int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Response headers are overridden by request parameters.
+	query := url.Values{}
+	query.Set("response-content-type", "text/plain")
+	query.Set("response-content-disposition", `attachment; filename="hello world.txt"`)
+	query.Set("response-cache-control", "no-cache")
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/respheaders/object?"+query.Encode(),
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "text/plain")
+	c.Assert(response.Header.Get("Content-Disposition"), Equals, `attachment; filename="hello world.txt"`)
+	c.Assert(response.Header.Get("Cache-Control"), Equals, "no-cache")
+
+	// Allow anonymous downloads.
+	bucketPolicyBuf := `{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::respheaders/*"]}]}`
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/respheaders?policy",
+		int64(len(bucketPolicyBuf)), bytes.NewReader([]byte(bucketPolicyBuf)), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	response, err = client.Get(s.testServer.Server.URL + "/respheaders/object")
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Anonymous requests cannot override response headers.
+	response, err = client.Get(s.testServer.Server.URL + "/respheaders/object?" + query.Encode())
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRequest", "Request specific response headers cannot be used for anonymous GET requests.", http.StatusBadRequest)
+}
+
 func (s *MyAPISuite) TestMultipleObjects(c *C) {
 	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/multipleobjects",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
@@ -491,6 +786,73 @@ func (s *MyAPISuite) TestPutBucket(c *C) {
 
 }
 
+func (s *MyAPISuite) TestPutBucketLocation(c *C) {
+	createBucketConfig := []byte("<CreateBucketConfiguration xmlns=\"http://s3.amazonaws.com/doc/2006-03-01/\"><LocationConstraint>eu-west-1</LocationConstraint></CreateBucketConfiguration>")
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/put-bucket-location",
+		int64(len(createBucketConfig)), bytes.NewReader(createBucketConfig), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Location of the bucket is returned for requests signed for any region.
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/put-bucket-location?location",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	locationConstraint := LocationResponse{}
+	err = xml.NewDecoder(response.Body).Decode(&locationConstraint)
+	c.Assert(err, IsNil)
+	c.Assert(locationConstraint.Location, Equals, "eu-west-1")
+
+	// Requests on the bucket signed for another region fail.
+	request, err = newTestRequest("HEAD", s.testServer.Server.URL+"/put-bucket-location",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/put-bucket-location",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	errorResponse := APIErrorResponse{}
+	err = xml.NewDecoder(response.Body).Decode(&errorResponse)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+	c.Assert(errorResponse.Code, Equals, "AuthorizationHeaderMalformed")
+	c.Assert(errorResponse.Region, Equals, "eu-west-1")
+
+	// Requests signed for the region of the bucket succeed.
+	request, err = newTestRequestWithRegion("HEAD", s.testServer.Server.URL+"/put-bucket-location",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey, "eu-west-1")
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Invalid location constraint.
+	createBucketConfig = []byte("<CreateBucketConfiguration xmlns=\"http://s3.amazonaws.com/doc/2006-03-01/\"><LocationConstraint>eu west</LocationConstraint></CreateBucketConfiguration>")
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/put-bucket-location-invalid",
+		int64(len(createBucketConfig)), bytes.NewReader(createBucketConfig), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidLocationConstraint", "The specified location constraint is not valid.", http.StatusBadRequest)
+}
+
 func (s *MyAPISuite) TestCopyObject(c *C) {
 	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/put-object-copy",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
@@ -822,6 +1184,279 @@ func (s *MyAPISuite) TestPartialContent(c *C) {
 	c.Assert(string(partialObject), Equals, "Wo")
 }
 
+func (s *MyAPISuite) TestSelectObjectContent(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/select-object-content",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("name,age\nalice,31\nbob,25\ncarol,42\n"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/select-object-content/people.csv",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	selectRequest := []byte(`<SelectObjectContentRequest xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+<Expression>SELECT s.name FROM S3Object s WHERE CAST(s.age AS INT) &gt; 30</Expression>
+<ExpressionType>SQL</ExpressionType>
+<InputSerialization><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>
+<OutputSerialization><CSV/></OutputSerialization>
+</SelectObjectContentRequest>`)
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/select-object-content/people.csv?select&select-type=2",
+		int64(len(selectRequest)), bytes.NewReader(selectRequest), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	messages, err := readSelectMessages(response.Body)
+	c.Assert(err, IsNil)
+	records, errCode := selectTestRecords(messages)
+	c.Assert(errCode, Equals, "")
+	c.Assert(records, Equals, "alice\ncarol\n")
+	c.Assert(messages[len(messages)-2].headers[":event-type"], Equals, "Stats")
+	c.Assert(messages[len(messages)-1].headers[":event-type"], Equals, "End")
+
+	// Invalid SQL expressions are rejected before streaming results.
+	selectRequest = bytes.Replace(selectRequest, []byte("FROM S3Object"), []byte("FROM table"), 1)
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/select-object-content/people.csv?select&select-type=2",
+		int64(len(selectRequest)), bytes.NewReader(selectRequest), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "ParseSelectFailure", "The SQL expression contains a syntax error or an unsupported operation.", http.StatusBadRequest)
+}
+
+func (s *MyAPISuite) TestRestoreObject(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/restore-object",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/restore-object/object",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Objects are not archived, there is nothing to restore.
+	restoreRequest := []byte(`<RestoreRequest><Days>2</Days></RestoreRequest>`)
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/restore-object/object?restore",
+		int64(len(restoreRequest)), bytes.NewReader(restoreRequest), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidObjectState", "The operation is not valid for the current state of the object.", http.StatusForbidden)
+
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/restore-object/missing?restore",
+		int64(len(restoreRequest)), bytes.NewReader(restoreRequest), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+
+	// Restored copies must be kept at least one day.
+	restoreRequest = []byte(`<RestoreRequest><Days>0</Days></RestoreRequest>`)
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/restore-object/object?restore",
+		int64(len(restoreRequest)), bytes.NewReader(restoreRequest), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+}
+
+func (s *MyAPISuite) TestPutObjectChecksum(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/object-checksum",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/object-checksum/object",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Crc32", "DUoRhQ==")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Checksum-Crc32"), Equals, "DUoRhQ==")
+
+	// Checksum is returned only if requested.
+	request, err = newTestRequest("HEAD", s.testServer.Server.URL+"/object-checksum/object",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Checksum-Crc32"), Equals, "")
+
+	request, err = newTestRequest("HEAD", s.testServer.Server.URL+"/object-checksum/object",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Mode", "ENABLED")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Checksum-Crc32"), Equals, "DUoRhQ==")
+
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/object-checksum/object?attributes",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Object-Attributes", "ETag,Checksum,ObjectSize")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	attributes := GetObjectAttributesResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&attributes), IsNil)
+	c.Assert(attributes.Checksum, NotNil)
+	c.Assert(attributes.Checksum.ChecksumCRC32, Equals, "DUoRhQ==")
+	c.Assert(attributes.ObjectSize, NotNil)
+	c.Assert(*attributes.ObjectSize, Equals, int64(11))
+
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/object-checksum/object?attributes",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Object-Attributes", "Owner")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Invalid attribute name specified.", http.StatusBadRequest)
+
+	// Payload does not match the checksum.
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/object-checksum/object1",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Crc32", "NhCmhg==")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "BadDigest", "The Content-Md5 you specified did not match what we received.", http.StatusBadRequest)
+
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/object-checksum/object1",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Sha256", "DUoRhQ==")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRequest", "Value for x-amz-checksum header is invalid.", http.StatusBadRequest)
+}
+
+func (s *MyAPISuite) TestPartialContentMultipleRanges(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/partial-content-ranges",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("Hello World"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/partial-content-ranges/bar",
+		int64(buffer1.Len()), buffer1, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/partial-content-ranges/bar",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Add("Range", "bytes=0-4, 6-7, -1")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	body, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(response.ContentLength, Equals, int64(len(body)))
+
+	mediaType, params, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	c.Assert(err, IsNil)
+	c.Assert(mediaType, Equals, "multipart/byteranges")
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	expectedParts := []struct {
+		contentRange string
+		data         string
+	}{
+		{"bytes 0-4/11", "Hello"},
+		{"bytes 6-7/11", "Wo"},
+		{"bytes 10-10/11", "d"},
+	}
+	for _, expected := range expectedParts {
+		part, err := reader.NextPart()
+		c.Assert(err, IsNil)
+		c.Assert(part.Header.Get("Content-Range"), Equals, expected.contentRange)
+		data, err := ioutil.ReadAll(part)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, expected.data)
+	}
+	_, err = reader.NextPart()
+	c.Assert(err, Equals, io.EOF)
+
+	// Unsatisfiable ranges are ignored.
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/partial-content-ranges/bar",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Add("Range", "bytes=0-4,7-6,20-30,-0")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	body, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	mediaType, params, err = mime.ParseMediaType(response.Header.Get("Content-Type"))
+	c.Assert(err, IsNil)
+	c.Assert(mediaType, Equals, "multipart/byteranges")
+	reader = multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	part, err := reader.NextPart()
+	c.Assert(err, IsNil)
+	c.Assert(part.Header.Get("Content-Range"), Equals, "bytes 0-4/11")
+	data, err := ioutil.ReadAll(part)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "Hello")
+	_, err = reader.NextPart()
+	c.Assert(err, Equals, io.EOF)
+
+	// The request fails only if no range is satisfiable.
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/partial-content-ranges/bar",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Add("Range", "bytes=7-6,20-30")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
+}
+
 func (s *MyAPISuite) TestListObjectsHandlerErrors(c *C) {
 	request, err := newTestRequest("GET", s.testServer.Server.URL+"/objecthandlererrors-.",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
@@ -859,6 +1494,76 @@ func (s *MyAPISuite) TestListObjectsHandlerErrors(c *C) {
 	verifyError(c, response, "InvalidArgument", "Argument maxKeys must be an integer between 0 and 2147483647.", http.StatusBadRequest)
 }
 
+func (s *MyAPISuite) TestListObjectsV2(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/listobjectsv2",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	for _, object := range []string{"object1", "object2", "object3"} {
+		buffer := bytes.NewReader([]byte("hello"))
+		request, err = newTestRequest("PUT", s.testServer.Server.URL+"/listobjectsv2/"+object,
+			int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	// First page, owner is only returned if requested.
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/listobjectsv2?list-type=2&max-keys=2",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	var listResponse ListObjectsV2Response
+	c.Assert(xml.NewDecoder(response.Body).Decode(&listResponse), IsNil)
+	c.Assert(listResponse.KeyCount, Equals, 2)
+	c.Assert(listResponse.IsTruncated, Equals, true)
+	c.Assert(listResponse.NextContinuationToken, Not(Equals), "")
+	c.Assert(listResponse.Contents[0].Owner, IsNil)
+
+	// Next page.
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/listobjectsv2?list-type=2&fetch-owner=true&continuation-token="+
+		url.QueryEscape(listResponse.NextContinuationToken), 0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	token := listResponse.NextContinuationToken
+	listResponse = ListObjectsV2Response{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&listResponse), IsNil)
+	c.Assert(listResponse.KeyCount, Equals, 1)
+	c.Assert(listResponse.IsTruncated, Equals, false)
+	c.Assert(listResponse.ContinuationToken, Equals, token)
+	c.Assert(listResponse.Contents[0].Key, Equals, "object3")
+	c.Assert(listResponse.Contents[0].Owner, NotNil)
+
+	// Listing starts after start-after.
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/listobjectsv2?list-type=2&start-after=object1",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	listResponse = ListObjectsV2Response{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&listResponse), IsNil)
+	c.Assert(listResponse.KeyCount, Equals, 2)
+	c.Assert(listResponse.StartAfter, Equals, "object1")
+
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/listobjectsv2?list-type=2&continuation-token=%25",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "The continuation token provided is incorrect.", http.StatusBadRequest)
+}
+
 func (s *MyAPISuite) TestPutBucketErrors(c *C) {
 	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/putbucket-.",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
@@ -886,9 +1591,11 @@ func (s *MyAPISuite) TestPutBucketErrors(c *C) {
 	c.Assert(err, IsNil)
 	verifyError(c, response, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.", http.StatusConflict)
 
+	// Only private and public canned ACLs are supported.
 	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/putbucket?acl",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
 	c.Assert(err, IsNil)
+	request.Header.Set("x-amz-acl", "authenticated-read")
 
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
@@ -931,9 +931,11 @@ func (s *MyAPIXLSuite) TestPutBucketErrors(c *C) {
 	c.Assert(err, IsNil)
 	verifyError(c, response, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.", http.StatusConflict)
 
+	// Only private and public canned ACLs are supported.
 	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/putbucket?acl",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
 	c.Assert(err, IsNil)
+	request.Header.Set("x-amz-acl", "authenticated-read")
 
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
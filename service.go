@@ -0,0 +1,111 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serviceSignal - service control requested through the admin API.
+type serviceSignal int
+
+const (
+	serviceRestart serviceSignal = iota // Restarts the server.
+	serviceStop                         // Stops the server.
+	// Add new service signals here.
+)
+
+// globalServiceSignalCh - receives service signals, handled by
+// handleServiceSignals.
+var globalServiceSignalCh = make(chan serviceSignal, 1)
+
+// globalServiceDoneCh - closed once the server shuts down, ends the
+// background routines watching external services.
+var globalServiceDoneCh = make(chan struct{})
+
+// sendServiceSignal - requests a service signal, ignored if another
+// signal is already pending.
+func sendServiceSignal(signal serviceSignal) {
+	select {
+	case globalServiceSignalCh <- signal:
+	default:
+	}
+}
+
+// restartProcess - starts a new server process with the same command
+// line and environment.
+func restartProcess() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	return cmd.Start()
+}
+
+// Default time in-flight requests are given to complete on shutdown.
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownServer - stops accepting new connections and waits up to
+// timeout for in-flight requests to complete before closing all
+// remaining connections. Pending audit entries are sent afterwards.
+func shutdownServer(server *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		errorIf(err, "Unable to complete in-flight requests within %s.", timeout)
+		errorIf(server.Close(), "Unable to close server.")
+	}
+	if globalAuditLogger != nil {
+		errorIf(globalAuditLogger.Flush(timeout), "Unable to send pending audit entries.")
+	}
+}
+
+// handleServiceSignals - waits for a service signal or SIGTERM/SIGINT,
+// drains the server and exits. On restart a new server process is
+// started once the server stopped listening.
+func handleServiceSignals(server *http.Server, shutdownTimeout time.Duration) {
+	osSignalCh := make(chan os.Signal, 1)
+	signal.Notify(osSignalCh, os.Interrupt, syscall.SIGTERM)
+
+	serviceSig := serviceStop
+	select {
+	case serviceSig = <-globalServiceSignalCh:
+	case <-osSignalCh:
+	}
+	// Stop listening for signals, a second one kills the process.
+	signal.Stop(osSignalCh)
+	close(globalServiceDoneCh)
+
+	shutdownServer(server, shutdownTimeout)
+	switch serviceSig {
+	case serviceRestart:
+		fatalIf(restartProcess(), "Unable to restart server.")
+	case serviceStop:
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,85 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Tests in-flight requests are drained on shutdown up to the timeout.
+func TestShutdownServer(t *testing.T) {
+	testCases := []struct {
+		requestTime time.Duration
+		timeout     time.Duration
+		shouldPass  bool
+	}{
+		// Test case - 1.
+		// Request completes within the timeout.
+		{100 * time.Millisecond, 10 * time.Second, true},
+		// Test case - 2.
+		// Request is cut off after the timeout.
+		{10 * time.Second, 100 * time.Millisecond, false},
+	}
+	for i, testCase := range testCases {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		startedCh := make(chan struct{})
+		server := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(startedCh)
+				select {
+				case <-time.After(testCase.requestTime):
+				case <-r.Context().Done():
+				}
+				w.WriteHeader(http.StatusOK)
+			}),
+		}
+		go server.Serve(listener)
+
+		errCh := make(chan error, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String())
+			if err == nil {
+				resp.Body.Close()
+			}
+			errCh <- err
+		}()
+		<-startedCh
+
+		start := time.Now()
+		shutdownServer(server, testCase.timeout)
+		if time.Since(start) > testCase.requestTime+testCase.timeout {
+			t.Errorf("Test %d: Shutdown took %s", i+1, time.Since(start))
+		}
+		err = <-errCh
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected request to complete, but failed with: <ERROR> %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected request to be cut off", i+1)
+		}
+
+		// No new connections are accepted.
+		if _, err = http.Get("http://" + listener.Addr().String()); err == nil {
+			t.Errorf("Test %d: Expected new requests to be rejected", i+1)
+		}
+	}
+}
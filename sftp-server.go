@@ -0,0 +1,874 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// Directory inside the config directory holding the host key and
+	// the authorized keys of the SFTP server.
+	sftpConfigDir          = "sftp"
+	sftpHostKeyFile        = "host_key"
+	sftpAuthorizedKeysFile = "authorized_keys"
+
+	// Time a connection may stay idle, including the login.
+	sftpIdleTimeout = 5 * time.Minute
+
+	// Version of the SFTP protocol served, the one OpenSSH speaks.
+	sftpProtocolVersion = 3
+
+	// Maximum length of a request, longer ones end the session.
+	sftpMaxPacket = 256 * 1024
+
+	// Maximum number of bytes returned by a single read.
+	sftpMaxRead = 32 * 1024
+
+	// Number of entries returned by a single READDIR.
+	sftpReadDirCount = 100
+)
+
+// SFTP packet types, see draft-ietf-secsh-filexfer-02.
+const (
+	sshFxpInit     = 1
+	sshFxpVersion  = 2
+	sshFxpOpen     = 3
+	sshFxpClose    = 4
+	sshFxpRead     = 5
+	sshFxpWrite    = 6
+	sshFxpLstat    = 7
+	sshFxpFstat    = 8
+	sshFxpSetstat  = 9
+	sshFxpFsetstat = 10
+	sshFxpOpendir  = 11
+	sshFxpReaddir  = 12
+	sshFxpRemove   = 13
+	sshFxpMkdir    = 14
+	sshFxpRmdir    = 15
+	sshFxpRealpath = 16
+	sshFxpStat     = 17
+	sshFxpStatus   = 101
+	sshFxpHandle   = 102
+	sshFxpData     = 103
+	sshFxpName     = 104
+	sshFxpAttrs    = 105
+)
+
+// SFTP status codes.
+const (
+	sshFxOK               = 0
+	sshFxEOF              = 1
+	sshFxNoSuchFile       = 2
+	sshFxPermissionDenied = 3
+	sshFxFailure          = 4
+	sshFxBadMessage       = 5
+	sshFxOpUnsupported    = 8
+)
+
+// SFTP file attribute flags and open flags.
+const (
+	sshFileXferAttrSize        = 0x1
+	sshFileXferAttrPermissions = 0x4
+	sshFileXferAttrACModTime   = 0x8
+
+	sshFxfRead   = 0x1
+	sshFxfWrite  = 0x2
+	sshFxfAppend = 0x4
+	sshFxfExcl   = 0x20
+)
+
+var errSFTPLoginIncorrect = errors.New("Login incorrect")
+
+var errSFTPBadPacket = errors.New("Malformed SFTP packet")
+
+var errSFTPSessionClosed = errors.New("SFTP session closed before the upload finished")
+
+var errSFTPFileExists = errors.New("File already exists")
+
+// sftpServer - SFTP front-end to an object layer. Users log in with the
+// access and secret key of the server, or as the access key with a
+// public key listed in the authorized keys file. Buckets are the top
+// level directories and prefixes the directories below, like the FTP
+// front-end serves them.
+type sftpServer struct {
+	objAPI ObjectLayer
+	config *ssh.ServerConfig
+	// Public keys in the format of OpenSSH, read on every login so
+	// that keys are added and revoked without a restart.
+	authorizedKeysFile string
+}
+
+// newSFTPServer - returns a new SFTP server for objAPI identified by
+// hostKey.
+func newSFTPServer(objAPI ObjectLayer, hostKey ssh.Signer, authorizedKeysFile string) *sftpServer {
+	s := &sftpServer{
+		objAPI:             objAPI,
+		authorizedKeysFile: authorizedKeysFile,
+	}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback:  s.checkPassword,
+		PublicKeyCallback: s.checkPublicKey,
+	}
+	s.config.AddHostKey(hostKey)
+	return s
+}
+
+// getSFTPConfigFile - returns the path of a file of the SFTP server in
+// the config directory.
+func getSFTPConfigFile(name string) string {
+	return filepath.Join(mustGetConfigPath(), sftpConfigDir, name)
+}
+
+// loadSFTPHostKey - loads the host key saved in filename, an Ed25519
+// key is generated and saved on the first start.
+func loadSFTPHostKey(filename string) (ssh.Signer, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		_, key, gErr := ed25519.GenerateKey(rand.Reader)
+		if gErr != nil {
+			return nil, gErr
+		}
+		block, mErr := ssh.MarshalPrivateKey(key, "")
+		if mErr != nil {
+			return nil, mErr
+		}
+		data = pem.EncodeToMemory(block)
+		if err = os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(filename, data, 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(data)
+}
+
+// checkPassword - accepts the access and secret key of the server, as
+// the FTP front-end does.
+func (s *sftpServer) checkPassword(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	if !initJWT().Authenticate(meta.User(), string(password)) {
+		return nil, errSFTPLoginIncorrect
+	}
+	return nil, nil
+}
+
+// checkPublicKey - accepts the keys of the authorized keys file for the
+// access key of the server.
+func (s *sftpServer) checkPublicKey(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	if meta.User() != initJWT().AccessKeyID {
+		return nil, errSFTPLoginIncorrect
+	}
+	data, err := ioutil.ReadFile(s.authorizedKeysFile)
+	if err != nil {
+		return nil, errSFTPLoginIncorrect
+	}
+	marshaled := key.Marshal()
+	for len(data) > 0 {
+		// Comments and malformed lines are skipped.
+		authorizedKey, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			break
+		}
+		if bytes.Equal(authorizedKey.Marshal(), marshaled) {
+			return nil, nil
+		}
+		data = rest
+	}
+	return nil, errSFTPLoginIncorrect
+}
+
+// Serve - serves SFTP clients accepted on listener until it is closed.
+func (s *sftpServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// sftpIdleConn - connection which times out if nothing is read for the
+// idle timeout.
+type sftpIdleConn struct {
+	net.Conn
+}
+
+func (c sftpIdleConn) Read(b []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(sftpIdleTimeout))
+	return c.Conn.Read(b)
+}
+
+// serveConn - serves the sessions of an SSH connection, other channels
+// such as port forwardings are rejected.
+func (s *sftpServer) serveConn(conn net.Conn) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(sftpIdleConn{conn}, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "Only sessions are supported.")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.serveSession(sshConn, channel, requests)
+	}
+}
+
+// serveSession - serves the sftp subsystem of a session, shells and
+// commands are refused.
+func (s *sftpServer) serveSession(sshConn *ssh.ServerConn, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		// The payload is the subsystem name as SSH string.
+		if req.Type != "subsystem" || len(req.Payload) < 4 || string(req.Payload[4:]) != "sftp" {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+		go ssh.DiscardRequests(requests)
+		c := &sftpConn{
+			server:     s,
+			rw:         channel,
+			user:       sshConn.User(),
+			remoteAddr: sshConn.RemoteAddr(),
+			handles:    make(map[string]interface{}),
+		}
+		c.serve()
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+		return
+	}
+}
+
+// sftpConn - state of an SFTP session.
+type sftpConn struct {
+	server     *sftpServer
+	rw         io.ReadWriter
+	user       string
+	remoteAddr net.Addr
+
+	// Open files and directories by handle.
+	handles    map[string]interface{}
+	nextHandle uint64
+}
+
+// sftpReadFile - file opened for reading.
+type sftpReadFile struct {
+	bucket, object string
+	// Info as stored, read with the plain size.
+	objInfo ObjectInfo
+	size    int64
+	modTime time.Time
+	// Content from offset on, restarted if a read is not sequential.
+	reader *io.PipeReader
+	offset int64
+}
+
+// sftpWriteFile - file opened for writing, the object is created from
+// the written data once the file is closed.
+type sftpWriteFile struct {
+	bucket, object string
+	writer         *io.PipeWriter
+	offset         int64
+	started        time.Time
+	// Result of the upload.
+	doneCh chan error
+}
+
+// sftpDir - directory opened for reading.
+type sftpDir struct {
+	entries []dirEntry
+}
+
+// sftpPacketReader - decodes the fields of an SFTP packet, the first
+// malformed field is recorded in err.
+type sftpPacketReader struct {
+	data []byte
+	err  error
+}
+
+func (r *sftpPacketReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.err = errSFTPBadPacket
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *sftpPacketReader) uint64() uint64 {
+	if len(r.data) < 8 {
+		r.err = errSFTPBadPacket
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v
+}
+
+func (r *sftpPacketReader) string() string {
+	length := r.uint32()
+	if uint32(len(r.data)) < length {
+		r.err = errSFTPBadPacket
+		return ""
+	}
+	v := string(r.data[:length])
+	r.data = r.data[length:]
+	return v
+}
+
+// Encoders of the fields of SFTP packets.
+func appendSFTPUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendSFTPUint64(b []byte, v uint64) []byte {
+	return appendSFTPUint32(appendSFTPUint32(b, uint32(v>>32)), uint32(v))
+}
+
+func appendSFTPString(b []byte, s string) []byte {
+	return append(appendSFTPUint32(b, uint32(len(s))), s...)
+}
+
+// appendSFTPAttrs - encodes the size, permissions and times of e.
+func appendSFTPAttrs(b []byte, e dirEntry) []byte {
+	mode := uint32(0100644)
+	if e.isDir {
+		mode = 040755
+	}
+	b = appendSFTPUint32(b, sshFileXferAttrSize|sshFileXferAttrPermissions|sshFileXferAttrACModTime)
+	b = appendSFTPUint64(b, uint64(e.size))
+	b = appendSFTPUint32(b, mode)
+	b = appendSFTPUint32(b, uint32(e.modTime.Unix()))
+	return appendSFTPUint32(b, uint32(e.modTime.Unix()))
+}
+
+// readPacket - reads the type and payload of the next packet.
+func (c *sftpConn) readPacket() (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length == 0 || length > sftpMaxPacket {
+		return 0, nil, errSFTPBadPacket
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, data); err != nil {
+		return 0, nil, err
+	}
+	return data[0], data[1:], nil
+}
+
+// sendPacket - writes a packet of packetType with payload.
+func (c *sftpConn) sendPacket(packetType byte, payload []byte) error {
+	data := appendSFTPUint32(make([]byte, 0, 5+len(payload)), uint32(1+len(payload)))
+	data = append(append(data, packetType), payload...)
+	_, err := c.rw.Write(data)
+	return err
+}
+
+// sendStatus - replies to request id with status code and msg.
+func (c *sftpConn) sendStatus(id, code uint32, msg string) error {
+	payload := appendSFTPUint32(nil, id)
+	payload = appendSFTPUint32(payload, code)
+	payload = appendSFTPString(payload, msg)
+	payload = appendSFTPString(payload, "")
+	return c.sendPacket(sshFxpStatus, payload)
+}
+
+// sendError - replies to request id with the status err maps to, nil
+// is a success.
+func (c *sftpConn) sendError(id uint32, err error) error {
+	if err == nil {
+		return c.sendStatus(id, sshFxOK, "OK")
+	}
+	code := uint32(sshFxFailure)
+	switch err.(type) {
+	case ObjectNotFound, BucketNotFound, ObjectNameInvalid, BucketNameInvalid:
+		code = sshFxNoSuchFile
+	case fileAPIError:
+		code = sshFxPermissionDenied
+	}
+	if err == errFilePermissionDenied {
+		code = sshFxPermissionDenied
+	}
+	return c.sendStatus(id, code, err.Error())
+}
+
+// sendName - replies to request id with the entries of a directory or
+// a single path.
+func (c *sftpConn) sendName(id uint32, entries []dirEntry) error {
+	payload := appendSFTPUint32(nil, id)
+	payload = appendSFTPUint32(payload, uint32(len(entries)))
+	for _, e := range entries {
+		payload = appendSFTPString(payload, e.name)
+		payload = appendSFTPString(payload, formatFTPEntry(e))
+		payload = appendSFTPAttrs(payload, e)
+	}
+	return c.sendPacket(sshFxpName, payload)
+}
+
+// sendAttrs - replies to request id with the attributes of e.
+func (c *sftpConn) sendAttrs(id uint32, e dirEntry) error {
+	return c.sendPacket(sshFxpAttrs, appendSFTPAttrs(appendSFTPUint32(nil, id), e))
+}
+
+// addHandle - returns a new handle of an open file or directory.
+func (c *sftpConn) addHandle(h interface{}) string {
+	c.nextHandle++
+	handle := strconv.FormatUint(c.nextHandle, 10)
+	c.handles[handle] = h
+	return handle
+}
+
+// sendHandle - replies to request id with a handle of h.
+func (c *sftpConn) sendHandle(id uint32, h interface{}) error {
+	payload := appendSFTPString(appendSFTPUint32(nil, id), c.addHandle(h))
+	return c.sendPacket(sshFxpHandle, payload)
+}
+
+// serve - serves requests until the client ends the session, requests
+// are answered in order.
+func (c *sftpConn) serve() {
+	defer c.closeHandles()
+
+	packetType, data, err := c.readPacket()
+	if err != nil || packetType != sshFxpInit {
+		return
+	}
+	// Extensions are neither offered nor accepted.
+	if err = c.sendPacket(sshFxpVersion, appendSFTPUint32(nil, sftpProtocolVersion)); err != nil {
+		return
+	}
+	for {
+		packetType, data, err = c.readPacket()
+		if err != nil {
+			return
+		}
+		r := &sftpPacketReader{data: data}
+		id := r.uint32()
+		if r.err != nil {
+			return
+		}
+		if err = c.handle(packetType, id, r); err != nil {
+			return
+		}
+	}
+}
+
+// handle - executes a single request, returns an error only if the
+// reply could not be sent.
+func (c *sftpConn) handle(packetType byte, id uint32, r *sftpPacketReader) error {
+	switch packetType {
+	case sshFxpOpen:
+		return c.handleOpen(id, r)
+	case sshFxpClose:
+		return c.handleClose(id, r)
+	case sshFxpRead:
+		return c.handleRead(id, r)
+	case sshFxpWrite:
+		return c.handleWrite(id, r)
+	case sshFxpStat, sshFxpLstat:
+		return c.handleStat(id, r)
+	case sshFxpFstat:
+		return c.handleFstat(id, r)
+	case sshFxpSetstat, sshFxpFsetstat:
+		// Times and permissions are not stored, only truncating is
+		// refused.
+		r.string()
+		if flags := r.uint32(); r.err == nil && flags&sshFileXferAttrSize != 0 {
+			return c.sendStatus(id, sshFxOpUnsupported, "Truncating files is not supported.")
+		}
+		return c.sendBadMessage(id, r, nil)
+	case sshFxpOpendir:
+		return c.handleOpendir(id, r)
+	case sshFxpReaddir:
+		return c.handleReaddir(id, r)
+	case sshFxpRemove:
+		return c.handleRemove(id, r)
+	case sshFxpMkdir:
+		return c.handleMkdir(id, r)
+	case sshFxpRmdir:
+		return c.handleRmdir(id, r)
+	case sshFxpRealpath:
+		p := r.string()
+		if r.err != nil {
+			return c.sendBadMessage(id, r, nil)
+		}
+		p = path.Join(slashSeparator, p)
+		return c.sendName(id, []dirEntry{{name: p, modTime: time.Now().UTC(), isDir: true}})
+	default:
+		// Renames, links and extensions.
+		return c.sendStatus(id, sshFxOpUnsupported, "Operation not supported.")
+	}
+}
+
+// sendBadMessage - replies with a bad message status if a field of the
+// request was malformed, with err otherwise.
+func (c *sftpConn) sendBadMessage(id uint32, r *sftpPacketReader, err error) error {
+	if r.err != nil {
+		return c.sendStatus(id, sshFxBadMessage, r.err.Error())
+	}
+	return c.sendError(id, err)
+}
+
+// logAudit - logs an audit entry for an operation started at started.
+func (c *sftpConn) logAudit(api, bucket, object string, started time.Time, err error) {
+	logFileAudit(api, bucket, object, c.user, c.remoteAddr, started, err)
+}
+
+// handleOpen - opens a file for reading, or for writing it from the
+// start. Files cannot be opened for both.
+func (c *sftpConn) handleOpen(id uint32, r *sftpPacketReader) error {
+	bucket, object := resolveFilePath(slashSeparator, r.string())
+	pflags := r.uint32()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	started := time.Now().UTC()
+	objAPI := c.server.objAPI
+	if pflags&sshFxfWrite == 0 {
+		f, err := c.openReadFile(bucket, object)
+		c.logAudit("GetObject", bucket, object, started, err)
+		if err != nil {
+			return c.sendError(id, err)
+		}
+		return c.sendHandle(id, f)
+	}
+	if pflags&(sshFxfRead|sshFxfAppend) != 0 {
+		return c.sendStatus(id, sshFxOpUnsupported, "Files are only written from the start.")
+	}
+	put, err := newFilePut(objAPI, bucket, object)
+	if err == nil && pflags&sshFxfExcl != 0 {
+		if _, sErr := objAPI.GetObjectInfo(bucket, object); sErr == nil {
+			err = errSFTPFileExists
+		}
+	}
+	if err != nil {
+		c.logAudit("PutObject", bucket, object, started, err)
+		return c.sendError(id, err)
+	}
+	reader, writer := io.Pipe()
+	f := &sftpWriteFile{
+		bucket:  bucket,
+		object:  object,
+		writer:  writer,
+		started: started,
+		doneCh:  make(chan error, 1),
+	}
+	go func() {
+		err := put.put(reader)
+		// Writes fail once the upload failed.
+		reader.CloseWithError(err)
+		f.doneCh <- err
+	}()
+	return c.sendHandle(id, f)
+}
+
+// openReadFile - returns a file of object opened for reading.
+func (c *sftpConn) openReadFile(bucket, object string) (*sftpReadFile, error) {
+	if bucket == "" || object == "" || isReservedBucket(bucket) {
+		return nil, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	objInfo, err := c.server.objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return nil, err
+	}
+	plainInfo, err := getPlainObjectInfo(bucket, objInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpReadFile{
+		bucket:  bucket,
+		object:  object,
+		objInfo: objInfo,
+		size:    plainInfo.Size,
+		modTime: plainInfo.ModTime,
+	}, nil
+}
+
+// closeHandle - closes an open file or directory, the object of a file
+// opened for writing is created unless abortErr is set.
+func (c *sftpConn) closeHandle(handle string, abortErr error) error {
+	h := c.handles[handle]
+	delete(c.handles, handle)
+	switch f := h.(type) {
+	case *sftpReadFile:
+		if f.reader != nil {
+			f.reader.Close()
+		}
+	case *sftpWriteFile:
+		f.writer.CloseWithError(abortErr)
+		err := <-f.doneCh
+		c.logAudit("PutObject", f.bucket, f.object, f.started, err)
+		return err
+	}
+	return nil
+}
+
+// closeHandles - closes all handles at the end of a session, uploads
+// which were not closed are aborted.
+func (c *sftpConn) closeHandles() {
+	for handle := range c.handles {
+		c.closeHandle(handle, errSFTPSessionClosed)
+	}
+}
+
+// handleClose - closes a handle, replies with the result of the upload
+// for files opened for writing.
+func (c *sftpConn) handleClose(id uint32, r *sftpPacketReader) error {
+	handle := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	if _, ok := c.handles[handle]; !ok {
+		return c.sendStatus(id, sshFxFailure, "Invalid handle.")
+	}
+	return c.sendError(id, c.closeHandle(handle, nil))
+}
+
+// handleRead - replies with the data at the requested offset. Reads
+// continue the download of the previous read if they are sequential.
+func (c *sftpConn) handleRead(id uint32, r *sftpPacketReader) error {
+	f, ok := c.handles[r.string()].(*sftpReadFile)
+	offset := int64(r.uint64())
+	length := r.uint32()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	if !ok {
+		return c.sendStatus(id, sshFxFailure, "Invalid handle.")
+	}
+	if offset < 0 || offset >= f.size {
+		return c.sendStatus(id, sshFxEOF, "End of file.")
+	}
+	if length > sftpMaxRead {
+		length = sftpMaxRead
+	}
+	if f.reader == nil || offset != f.offset {
+		if f.reader != nil {
+			f.reader.Close()
+		}
+		reader, writer := io.Pipe()
+		objInfo := f.objInfo
+		go func() {
+			writer.CloseWithError(getObjectContentRange(context.Background(), c.server.objAPI, f.bucket, &objInfo, offset, -1, writer))
+		}()
+		f.reader, f.offset = reader, offset
+	}
+	data := make([]byte, length)
+	n, err := io.ReadFull(f.reader, data)
+	f.offset += int64(n)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		f.reader.Close()
+		f.reader = nil
+		return c.sendError(id, err)
+	}
+	if n == 0 {
+		return c.sendStatus(id, sshFxEOF, "End of file.")
+	}
+	payload := appendSFTPString(appendSFTPUint32(nil, id), string(data[:n]))
+	return c.sendPacket(sshFxpData, payload)
+}
+
+// handleWrite - hands the data to the upload, writes have to be
+// sequential.
+func (c *sftpConn) handleWrite(id uint32, r *sftpPacketReader) error {
+	f, ok := c.handles[r.string()].(*sftpWriteFile)
+	offset := int64(r.uint64())
+	data := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	if !ok {
+		return c.sendStatus(id, sshFxFailure, "Invalid handle.")
+	}
+	if offset != f.offset {
+		return c.sendStatus(id, sshFxOpUnsupported, "Files are only written sequentially.")
+	}
+	if _, err := io.WriteString(f.writer, data); err != nil {
+		return c.sendError(id, err)
+	}
+	f.offset += int64(len(data))
+	return c.sendError(id, nil)
+}
+
+// handleStat - replies with the attributes of a file or directory.
+func (c *sftpConn) handleStat(id uint32, r *sftpPacketReader) error {
+	p := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	bucket, object := resolveFilePath(slashSeparator, p)
+	if object != "" {
+		if objInfo, err := getFileInfo(c.server.objAPI, bucket, object); err == nil {
+			return c.sendAttrs(id, dirEntry{name: path.Base(object), size: objInfo.Size, modTime: objInfo.ModTime})
+		}
+	}
+	if !isFileDir(c.server.objAPI, bucket, object) {
+		return c.sendError(id, ObjectNotFound{Bucket: bucket, Object: object})
+	}
+	modTime := time.Now().UTC()
+	if object == "" && bucket != "" {
+		if bucketInfo, err := c.server.objAPI.GetBucketInfo(bucket); err == nil {
+			modTime = bucketInfo.Created
+		}
+	}
+	return c.sendAttrs(id, dirEntry{name: path.Base(p), modTime: modTime, isDir: true})
+}
+
+// handleFstat - replies with the attributes of an open file.
+func (c *sftpConn) handleFstat(id uint32, r *sftpPacketReader) error {
+	h := c.handles[r.string()]
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	switch f := h.(type) {
+	case *sftpReadFile:
+		return c.sendAttrs(id, dirEntry{name: path.Base(f.object), size: f.size, modTime: f.modTime})
+	case *sftpWriteFile:
+		return c.sendAttrs(id, dirEntry{name: path.Base(f.object), size: f.offset, modTime: time.Now().UTC()})
+	}
+	return c.sendStatus(id, sshFxFailure, "Invalid handle.")
+}
+
+// handleOpendir - opens a directory, its entries are listed at once.
+func (c *sftpConn) handleOpendir(id uint32, r *sftpPacketReader) error {
+	p := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	started := time.Now().UTC()
+	bucket, prefix := resolveFilePath(slashSeparator, p)
+	api := "ListObjects"
+	if bucket == "" {
+		api = "ListBuckets"
+	}
+	if !isFileDir(c.server.objAPI, bucket, prefix) {
+		err := ObjectNotFound{Bucket: bucket, Object: prefix}
+		c.logAudit(api, bucket, prefix, started, err)
+		return c.sendError(id, err)
+	}
+	entries, err := listDirEntries(c.server.objAPI, bucket, prefix)
+	c.logAudit(api, bucket, prefix, started, err)
+	if err != nil {
+		return c.sendError(id, err)
+	}
+	return c.sendHandle(id, &sftpDir{entries: entries})
+}
+
+// handleReaddir - replies with the next entries of an open directory.
+func (c *sftpConn) handleReaddir(id uint32, r *sftpPacketReader) error {
+	d, ok := c.handles[r.string()].(*sftpDir)
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	if !ok {
+		return c.sendStatus(id, sshFxFailure, "Invalid handle.")
+	}
+	if len(d.entries) == 0 {
+		return c.sendStatus(id, sshFxEOF, "End of directory.")
+	}
+	entries := d.entries
+	if len(entries) > sftpReadDirCount {
+		entries = entries[:sftpReadDirCount]
+	}
+	d.entries = d.entries[len(entries):]
+	return c.sendName(id, entries)
+}
+
+// handleRemove - deletes a file, moved to the trash if configured.
+func (c *sftpConn) handleRemove(id uint32, r *sftpPacketReader) error {
+	p := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	started := time.Now().UTC()
+	bucket, object := resolveFilePath(slashSeparator, p)
+	err := checkFileWrite(c.server.objAPI, bucket, object)
+	if err == nil {
+		err = deleteObjectOrTrash(c.server.objAPI, bucket, object)
+	}
+	c.logAudit("DeleteObject", bucket, object, started, err)
+	return c.sendError(id, err)
+}
+
+// handleMkdir - creates a bucket at the top level, directories below
+// are prefixes which exist as soon as a file is stored in them.
+func (c *sftpConn) handleMkdir(id uint32, r *sftpPacketReader) error {
+	p := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	bucket, prefix := resolveFilePath(slashSeparator, p)
+	if err := checkDirWrite(bucket); err != nil || prefix != "" {
+		return c.sendError(id, err)
+	}
+	started := time.Now().UTC()
+	err := c.server.objAPI.MakeBucket(bucket)
+	c.logAudit("PutBucket", bucket, "", started, err)
+	return c.sendError(id, err)
+}
+
+// handleRmdir - removes an empty bucket, directories below vanish with
+// their last file.
+func (c *sftpConn) handleRmdir(id uint32, r *sftpPacketReader) error {
+	p := r.string()
+	if r.err != nil {
+		return c.sendBadMessage(id, r, nil)
+	}
+	bucket, prefix := resolveFilePath(slashSeparator, p)
+	if err := checkDirWrite(bucket); err != nil {
+		return c.sendError(id, err)
+	}
+	if prefix != "" {
+		if isFileDir(c.server.objAPI, bucket, prefix) {
+			return c.sendStatus(id, sshFxFailure, "Directory not empty.")
+		}
+		return c.sendError(id, nil)
+	}
+	started := time.Now().UTC()
+	err := c.server.objAPI.DeleteBucket(bucket)
+	c.logAudit("DeleteBucket", bucket, "", started, err)
+	return c.sendError(id, err)
+}
@@ -0,0 +1,418 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpTestClient - minimal SFTP client driving the server in tests.
+type sftpTestClient struct {
+	t      *testing.T
+	writer io.Writer
+	reader io.Reader
+	id     uint32
+}
+
+// request - sends a request and returns the type and fields of the
+// reply.
+func (c *sftpTestClient) request(packetType byte, payload []byte) (byte, *sftpPacketReader) {
+	c.id++
+	data := appendSFTPUint32(nil, uint32(5+len(payload)))
+	data = appendSFTPUint32(append(data, packetType), c.id)
+	if _, err := c.writer.Write(append(data, payload...)); err != nil {
+		c.t.Fatal(err)
+	}
+	var header [4]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	reply := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(c.reader, reply); err != nil {
+		c.t.Fatal(err)
+	}
+	r := &sftpPacketReader{data: reply[1:]}
+	if id := r.uint32(); id != c.id {
+		c.t.Fatalf("Expected reply to %d, got %d", c.id, id)
+	}
+	return reply[0], r
+}
+
+// status - sends a request answered by a status and checks its code.
+func (c *sftpTestClient) status(expectCode uint32, packetType byte, payload []byte) {
+	replyType, r := c.request(packetType, payload)
+	if replyType != sshFxpStatus {
+		c.t.Fatalf("Expected status, got packet type %d", replyType)
+	}
+	if code := r.uint32(); code != expectCode {
+		c.t.Fatalf("Expected status %d, got %d: %s", expectCode, code, r.string())
+	}
+}
+
+// open - opens a file or directory and returns its handle.
+func (c *sftpTestClient) open(packetType byte, payload []byte) string {
+	replyType, r := c.request(packetType, payload)
+	if replyType != sshFxpHandle {
+		c.t.Fatalf("Expected handle, got packet type %d", replyType)
+	}
+	return r.string()
+}
+
+// read - reads length bytes of handle at offset.
+func (c *sftpTestClient) read(handle string, offset uint64, length uint32) []byte {
+	payload := appendSFTPUint32(appendSFTPUint64(appendSFTPString(nil, handle), offset), length)
+	replyType, r := c.request(sshFxpRead, payload)
+	if replyType != sshFxpData {
+		c.t.Fatalf("Expected data, got packet type %d", replyType)
+	}
+	return []byte(r.string())
+}
+
+// readDir - returns the names of the entries of directory p.
+func (c *sftpTestClient) readDir(p string) []string {
+	handle := c.open(sshFxpOpendir, appendSFTPString(nil, p))
+	var names []string
+	for {
+		replyType, r := c.request(sshFxpReaddir, appendSFTPString(nil, handle))
+		if replyType == sshFxpStatus {
+			if code := r.uint32(); code != sshFxEOF {
+				c.t.Fatalf("Expected end of directory, got status %d", code)
+			}
+			break
+		}
+		for count := r.uint32(); count > 0; count-- {
+			names = append(names, r.string())
+			r.string()
+			// Size, permissions and times.
+			r.uint32()
+			r.uint64()
+			r.uint32()
+			r.uint32()
+			r.uint32()
+		}
+	}
+	c.status(sshFxOK, sshFxpClose, appendSFTPString(nil, handle))
+	return names
+}
+
+// stat - returns the size and permissions of p.
+func (c *sftpTestClient) stat(p string) (uint64, uint32) {
+	replyType, r := c.request(sshFxpStat, appendSFTPString(nil, p))
+	if replyType != sshFxpAttrs {
+		c.t.Fatalf("Expected attributes of %s, got packet type %d", p, replyType)
+	}
+	r.uint32()
+	return r.uint64(), r.uint32()
+}
+
+// sftpTestAuditTarget - collects the audit entries sent to it.
+type sftpTestAuditTarget struct {
+	mutex   sync.Mutex
+	entries []auditEntry
+}
+
+func (t *sftpTestAuditTarget) Send(entry auditEntry) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.entries = append(t.entries, entry)
+	return nil
+}
+
+// apis - returns the APIs of the collected entries.
+func (t *sftpTestAuditTarget) apis() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var apis []string
+	for _, entry := range t.entries {
+		apis = append(apis, entry.API)
+	}
+	return apis
+}
+
+// dialSFTP - logs in to the SFTP server at addr with auth and starts
+// the sftp subsystem.
+func dialSFTP(t *testing.T, addr, user string, auth ssh.AuthMethod) (*ssh.Client, *sftpTestClient, error) {
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := session.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := session.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = session.RequestSubsystem("sftp"); err != nil {
+		t.Fatal(err)
+	}
+	c := &sftpTestClient{t: t, writer: writer, reader: reader}
+	if _, err = writer.Write(appendSFTPUint32(append(appendSFTPUint32(nil, 5), sshFxpInit), sftpProtocolVersion)); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, 9)
+	if _, err = io.ReadFull(reader, reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply[4] != sshFxpVersion || binary.BigEndian.Uint32(reply[5:]) != sftpProtocolVersion {
+		t.Fatalf("Unexpected version reply %v", reply)
+	}
+	return client, c, nil
+}
+
+// Tests logging in with passwords and authorized keys.
+func TestSFTPServerLogin(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "minio-sftp-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	// The host key is generated once and loaded afterwards.
+	hostKeyFile := filepath.Join(dir, sftpConfigDir, sftpHostKeyFile)
+	hostKey, err := loadSFTPHostKey(hostKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadSFTPHostKey(hostKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(loaded.PublicKey().Marshal(), hostKey.PublicKey().Marshal()) {
+		t.Fatal("Expected the saved host key to be loaded")
+	}
+
+	newSigner := func() ssh.Signer {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := ssh.NewSignerFromKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signer
+	}
+	authorized, unknown := newSigner(), newSigner()
+	authorizedKeysFile := filepath.Join(dir, sftpConfigDir, sftpAuthorizedKeysFile)
+	authorizedKeys := append([]byte("# Backup host\n"), ssh.MarshalAuthorizedKey(authorized.PublicKey())...)
+	if err = ioutil.WriteFile(authorizedKeysFile, authorizedKeys, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go newSFTPServer(objAPI, hostKey, authorizedKeysFile).Serve(listener)
+	addr := listener.Addr().String()
+
+	testCases := []struct {
+		user    string
+		auth    ssh.AuthMethod
+		success bool
+	}{
+		{testServer.AccessKey, ssh.Password(testServer.SecretKey), true},
+		{testServer.AccessKey, ssh.Password("invalid-secret"), false},
+		{"invalid-user", ssh.Password(testServer.SecretKey), false},
+		{testServer.AccessKey, ssh.PublicKeys(authorized), true},
+		{testServer.AccessKey, ssh.PublicKeys(unknown), false},
+		{"invalid-user", ssh.PublicKeys(authorized), false},
+	}
+	for i, testCase := range testCases {
+		client, c, err := dialSFTP(t, addr, testCase.user, testCase.auth)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v, got %v", i+1, testCase.success, err)
+		}
+		if err == nil {
+			c.readDir("/")
+			client.Close()
+		}
+	}
+}
+
+// Tests a session of an SFTP client uploading, listing, downloading and
+// deleting files, operations are audit logged.
+func TestSFTPServer(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "minio-sftp-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+	hostKey, err := loadSFTPHostKey(filepath.Join(dir, sftpHostKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &sftpTestAuditTarget{}
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	globalAuditLogger = newAuditLogger([]auditTarget{target}, doneCh)
+	defer func() {
+		globalAuditLogger = nil
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go newSFTPServer(objAPI, hostKey, filepath.Join(dir, sftpAuthorizedKeysFile)).Serve(listener)
+
+	client, c, err := dialSFTP(t, listener.Addr().String(), testServer.AccessKey, ssh.Password(testServer.SecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	pathPayload := func(p string) []byte {
+		return appendSFTPString(nil, p)
+	}
+	openPayload := func(p string, pflags uint32) []byte {
+		// Attributes without any flags.
+		return appendSFTPUint32(appendSFTPUint32(pathPayload(p), pflags), 0)
+	}
+	writePayload := func(handle string, offset uint64, data string) []byte {
+		return appendSFTPString(appendSFTPUint64(pathPayload(handle), offset), data)
+	}
+
+	// Files are only written in buckets.
+	c.status(sshFxPermissionDenied, sshFxpOpen, openPayload("/top.txt", sshFxfWrite))
+	c.status(sshFxOK, sshFxpMkdir, openPayload("/bucket", 0))
+	c.status(sshFxNoSuchFile, sshFxpOpen, openPayload("/bucket/missing.txt", sshFxfRead))
+	c.status(sshFxOpUnsupported, sshFxpOpen, openPayload("/bucket/both.txt", sshFxfRead|sshFxfWrite))
+
+	data := "scanned document"
+	handle := c.open(sshFxpOpen, openPayload("bucket/dir/scan.pdf", sshFxfWrite))
+	c.status(sshFxOK, sshFxpWrite, writePayload(handle, 0, data[:8]))
+	c.status(sshFxOpUnsupported, sshFxpWrite, writePayload(handle, 0, data[:8]))
+	c.status(sshFxOK, sshFxpWrite, writePayload(handle, 8, data[8:]))
+	c.status(sshFxOK, sshFxpClose, pathPayload(handle))
+	c.status(sshFxFailure, sshFxpClose, pathPayload(handle))
+
+	if size, mode := c.stat("/bucket/dir/scan.pdf"); size != uint64(len(data)) || mode != 0100644 {
+		t.Fatalf("Unexpected size %d and mode %o", size, mode)
+	}
+	if _, mode := c.stat("/bucket/dir"); mode != 040755 {
+		t.Fatalf("Unexpected mode %o", mode)
+	}
+	c.status(sshFxNoSuchFile, sshFxpStat, pathPayload("/bucket/missing"))
+	if names := c.readDir("/bucket"); len(names) != 1 || names[0] != "dir" {
+		t.Fatalf("Unexpected names %v", names)
+	}
+	if names := c.readDir("/bucket/dir"); len(names) != 1 || names[0] != "scan.pdf" {
+		t.Fatalf("Unexpected names %v", names)
+	}
+
+	// Sequential and random reads.
+	handle = c.open(sshFxpOpen, openPayload("/bucket/dir/scan.pdf", sshFxfRead))
+	if got := string(c.read(handle, 0, 8)); got != data[:8] {
+		t.Fatalf("Expected %q, got %q", data[:8], got)
+	}
+	if got := string(c.read(handle, 8, 100)); got != data[8:] {
+		t.Fatalf("Expected %q, got %q", data[8:], got)
+	}
+	if got := string(c.read(handle, 3, 4)); got != data[3:7] {
+		t.Fatalf("Expected %q, got %q", data[3:7], got)
+	}
+	c.status(sshFxEOF, sshFxpRead, appendSFTPUint32(appendSFTPUint64(pathPayload(handle), uint64(len(data))), 10))
+	c.status(sshFxOK, sshFxpClose, pathPayload(handle))
+
+	c.status(sshFxOpUnsupported, sshFxpSetstat, appendSFTPUint64(appendSFTPUint32(pathPayload("/bucket/dir/scan.pdf"), sshFileXferAttrSize), 0))
+	c.status(sshFxFailure, sshFxpRmdir, pathPayload("/bucket/dir"))
+	c.status(sshFxOK, sshFxpRemove, pathPayload("/bucket/dir/scan.pdf"))
+	c.status(sshFxNoSuchFile, sshFxpStat, pathPayload("/bucket/dir/scan.pdf"))
+	c.status(sshFxOK, sshFxpRmdir, pathPayload("/bucket"))
+	if _, err = objAPI.GetBucketInfo("bucket"); err == nil {
+		t.Fatal("Expected bucket to be removed")
+	}
+
+	// An upload is aborted if the session ends before the file was
+	// closed.
+	c.status(sshFxOK, sshFxpMkdir, openPayload("/bucket", 0))
+	handle = c.open(sshFxpOpen, openPayload("/bucket/partial.txt", sshFxfWrite))
+	c.status(sshFxOK, sshFxpWrite, writePayload(handle, 0, data))
+	client.Close()
+
+	expected := []string{"PutObject", "PutBucket", "GetObject", "PutObject", "ListObjects", "ListObjects", "GetObject",
+		"DeleteObject", "DeleteBucket", "PutBucket", "PutObject"}
+	var apis []string
+	for i := 0; len(apis) < len(expected); i++ {
+		if i == 100 {
+			t.Fatalf("Expected audit entries of %v, got %v", expected, apis)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err = globalAuditLogger.Flush(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		apis = target.apis()
+	}
+	for i := range expected {
+		if apis[i] != expected[i] {
+			t.Fatalf("Expected audit entries of %v, got %v", expected, apis)
+		}
+	}
+	if _, err = objAPI.GetObjectInfo("bucket", "partial.txt"); err == nil {
+		t.Fatal("Expected aborted upload not to create the object")
+	}
+	entry := target.entries[3]
+	if entry.Bucket != "bucket" || entry.Object != "dir/scan.pdf" || entry.AccessKey != testServer.AccessKey ||
+		entry.RemoteIP != "127.0.0.1" || entry.StatusCode != 200 {
+		t.Fatalf("Unexpected audit entry %#v", entry)
+	}
+	if entry = target.entries[0]; entry.StatusCode != 403 {
+		t.Fatalf("Expected denied upload to be logged with 403, got %#v", entry)
+	}
+	if entry = target.entries[len(expected)-1]; entry.StatusCode == 200 {
+		t.Fatalf("Expected aborted upload to be logged as failed, got %#v", entry)
+	}
+}
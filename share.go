@@ -0,0 +1,245 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// Share links are kept in '.minio/shares/id.json' until they
+	// expire or their downloads are used up.
+	shareMetaPrefix = "shares"
+
+	// Query parameter of presigned URLs naming their share link, signed
+	// along with the URL so it can not be removed.
+	shareQueryParam = "x-minio-share"
+)
+
+// Expiries of share links selectable in the browser.
+var shareExpiryPresets = map[string]time.Duration{
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+	"7d": maxPresignedExpiry,
+}
+
+// Share links expire after 7 days unless a preset is selected.
+const defaultShareExpiry = "7d"
+
+var errShareLinkNotFound = errors.New("Share link not found")
+
+var errShareLinkExpired = errors.New("Share link expired or its downloads are used up")
+
+// shareLink - presigned download URL of an object tracked by the server.
+type shareLink struct {
+	ID        string    `json:"id"`
+	Bucket    string    `json:"bucket"`
+	Object    string    `json:"object"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Number of downloads allowed, unlimited if zero.
+	MaxDownloads int `json:"maxDownloads,omitempty"`
+	Downloads    int `json:"downloads"`
+}
+
+// getShareExpiry - returns the expiry of a preset, defaults to 7 days.
+func getShareExpiry(preset string) (time.Duration, error) {
+	if preset == "" {
+		preset = defaultShareExpiry
+	}
+	expiry, ok := shareExpiryPresets[preset]
+	if !ok {
+		return 0, fmt.Errorf("Unknown expiry ‘%s’, expected one of 1h, 1d and 7d", preset)
+	}
+	return expiry, nil
+}
+
+// getShareLinkPath - returns the path of a share link in minioMetaBucket.
+func getShareLinkPath(id string) string {
+	return path.Join(shareMetaPrefix, id+".json")
+}
+
+// newShareLink - creates a share link of an object.
+func newShareLink(objAPI ObjectLayer, bucket, object string, expiry time.Duration, maxDownloads int) (shareLink, error) {
+	if maxDownloads < 0 {
+		return shareLink{}, fmt.Errorf("Download limit must not be negative")
+	}
+	if _, err := objAPI.GetObjectInfo(bucket, object); err != nil {
+		return shareLink{}, err
+	}
+	now := time.Now().UTC()
+	link := shareLink{
+		ID:           getUUID(),
+		Bucket:       bucket,
+		Object:       object,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(expiry),
+		MaxDownloads: maxDownloads,
+	}
+	if err := saveJSONMetaFile(objAPI, getShareLinkPath(link.ID), link); err != nil {
+		return shareLink{}, err
+	}
+	return link, nil
+}
+
+// readShareLink - returns a share link.
+func readShareLink(objAPI ObjectLayer, id string) (shareLink, error) {
+	var link shareLink
+	// Ids are generated by the server, reject anything else.
+	if id == "" || id == "." || id == ".." || strings.Contains(id, slashSeparator) {
+		return link, errShareLinkNotFound
+	}
+	data, err := loadMetaFile(objAPI, getShareLinkPath(id))
+	if err != nil {
+		if err == errFileNotFound || err == errVolumeNotFound {
+			return link, errShareLinkNotFound
+		}
+		return link, err
+	}
+	err = json.Unmarshal(data, &link)
+	return link, err
+}
+
+// removeShareLink - removes a share link from all disks.
+func removeShareLink(objAPI ObjectLayer, id string) {
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		disk.DeleteFile(minioMetaBucket, getShareLinkPath(id))
+	}
+}
+
+// verifyShareLink - returns an error unless the share link can still
+// be used to download object, the download is not counted.
+func verifyShareLink(objAPI ObjectLayer, id, bucket, object string) error {
+	link, err := readShareLink(objAPI, id)
+	if err != nil {
+		return err
+	}
+	// Links are only valid for the object they were created for.
+	if link.Bucket != bucket || link.Object != object {
+		return errShareLinkNotFound
+	}
+	if time.Now().UTC().After(link.ExpiresAt) {
+		return errShareLinkExpired
+	}
+	return nil
+}
+
+// redeemShareLink - counts a download of an object through a share
+// link. Links are removed once they expired or their last download
+// was counted, later downloads are rejected with errShareLinkNotFound.
+func redeemShareLink(objAPI ObjectLayer, id, bucket, object string) (shareLink, error) {
+	nsMutex.Lock(minioMetaBucket, getShareLinkPath(id))
+	defer nsMutex.Unlock(minioMetaBucket, getShareLinkPath(id))
+
+	link, err := readShareLink(objAPI, id)
+	if err != nil {
+		return link, err
+	}
+	// Links are only valid for the object they were created for.
+	if link.Bucket != bucket || link.Object != object {
+		return link, errShareLinkNotFound
+	}
+	if time.Now().UTC().After(link.ExpiresAt) {
+		removeShareLink(objAPI, id)
+		return link, errShareLinkExpired
+	}
+	link.Downloads++
+	if link.MaxDownloads > 0 && link.Downloads >= link.MaxDownloads {
+		removeShareLink(objAPI, id)
+		return link, nil
+	}
+	return link, saveJSONMetaFile(objAPI, getShareLinkPath(id), link)
+}
+
+// presignShareLink - returns the presigned URL of a share link.
+func presignShareLink(scheme, host string, link shareLink) string {
+	query := make(url.Values)
+	query.Set(shareQueryParam, link.ID)
+	return scheme + "://" + host + preSignV4WithQuery("GET", host, link.Bucket, link.Object, query, link.CreatedAt, link.ExpiresAt.Sub(link.CreatedAt))
+}
+
+// checkShareLink - verifies the share link a presigned download names,
+// if any, can still be used. Rejected links are recorded in the audit
+// log, downloads are counted by redeemShareDownload once served.
+func checkShareLink(r *http.Request, objAPI ObjectLayer, bucket, object string) APIErrorCode {
+	id := r.URL.Query().Get(shareQueryParam)
+	if id == "" {
+		return ErrNone
+	}
+	if err := verifyShareLink(objAPI, id, bucket, object); err != nil {
+		if err != errShareLinkNotFound && err != errShareLinkExpired {
+			errorIfRequest(r, err, "Unable to read share link.")
+		}
+		auditShareLink(r, id, bucket, object, ErrAccessDenied)
+		return ErrAccessDenied
+	}
+	return ErrNone
+}
+
+// redeemShareDownload - counts a download through the share link the
+// request names, if any, after the whole object was served. Ranged
+// and conditional requests are not counted. Every redemption is
+// recorded in the audit log.
+func redeemShareDownload(r *http.Request, objAPI ObjectLayer, bucket, object string) {
+	id := r.URL.Query().Get(shareQueryParam)
+	if id == "" {
+		return
+	}
+	// Links used up or expired by concurrent downloads meanwhile need
+	// no counting.
+	if _, err := redeemShareLink(objAPI, id, bucket, object); err != nil && err != errShareLinkNotFound && err != errShareLinkExpired {
+		errorIfRequest(r, err, "Unable to redeem share link.")
+	}
+	auditShareLink(r, id, bucket, object, ErrNone)
+}
+
+// auditShareLink - records the use of share link id in the audit log.
+func auditShareLink(r *http.Request, id, bucket, object string, s3Error APIErrorCode) {
+	if globalAuditLogger == nil {
+		return
+	}
+	entry := auditEntry{
+		Version:    auditEntryVersion,
+		Time:       time.Now().UTC(),
+		RequestID:  getRequestID(r),
+		API:        "RedeemShare",
+		Bucket:     bucket,
+		Object:     object,
+		ShareID:    id,
+		AccessKey:  getRequestAccessKey(r),
+		RemoteIP:   r.RemoteAddr,
+		StatusCode: http.StatusOK,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.RemoteIP = host
+	}
+	if s3Error != ErrNone {
+		entry.StatusCode = getAPIError(s3Error).HTTPStatusCode
+	}
+	globalAuditLogger.Log(entry)
+}
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Tests expiry presets of share links.
+func TestGetShareExpiry(t *testing.T) {
+	testCases := []struct {
+		preset  string
+		expiry  time.Duration
+		success bool
+	}{
+		{"", 7 * 24 * time.Hour, true},
+		{"1h", time.Hour, true},
+		{"1d", 24 * time.Hour, true},
+		{"7d", 7 * 24 * time.Hour, true},
+		{"30d", 0, false},
+	}
+	for i, testCase := range testCases {
+		expiry, err := getShareExpiry(testCase.preset)
+		if testCase.success != (err == nil) {
+			t.Fatalf("Test %d: Expected success %v, but got %v", i+1, testCase.success, err)
+		}
+		if expiry != testCase.expiry {
+			t.Errorf("Test %d: Expected expiry %s, but got %s", i+1, testCase.expiry, expiry)
+		}
+	}
+}
+
+// Wrapper for calling testShareLink for both XL and FS.
+func TestShareLink(t *testing.T) {
+	ExecObjectLayerTest(t, testShareLink)
+}
+
+// Tests downloads through share links are limited.
+func testShareLink(obj ObjectLayer, instanceType string, t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	bucket, object := "share-bucket", "dir/object"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = newShareLink(obj, bucket, object, time.Hour, 0); err == nil {
+		t.Fatalf("%s: Expected share link of a missing object to fail", instanceType)
+	}
+	if _, err = obj.PutObject(context.Background(), bucket, object, 5, bytes.NewReader([]byte("hello")), nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	link, err := newShareLink(obj, bucket, object, time.Hour, 2)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	// The share id is signed along with the presigned URL.
+	u, err := url.Parse(presignShareLink("http", "localhost:9000", link))
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if u.Path != "/"+bucket+"/"+object || u.Query().Get(shareQueryParam) != link.ID || u.Query().Get("X-Amz-Expires") != "3600" {
+		t.Errorf("%s: Unexpected share URL %s", instanceType, u)
+	}
+
+	// Links are only valid for their object.
+	if _, err = redeemShareLink(obj, link.ID, bucket, "other"); err != errShareLinkNotFound {
+		t.Fatalf("%s: Expected %v, but got %v", instanceType, errShareLinkNotFound, err)
+	}
+	if err = verifyShareLink(obj, link.ID, bucket, "other"); err != errShareLinkNotFound {
+		t.Fatalf("%s: Expected %v, but got %v", instanceType, errShareLinkNotFound, err)
+	}
+	// Verifying a link does not count a download.
+	for i := 0; i < 3; i++ {
+		if err = verifyShareLink(obj, link.ID, bucket, object); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		redeemed, rerr := redeemShareLink(obj, link.ID, bucket, object)
+		if rerr != nil {
+			t.Fatalf("%s: Download %d: %s", instanceType, i, rerr)
+		}
+		if redeemed.Downloads != i {
+			t.Errorf("%s: Expected %d downloads, but got %d", instanceType, i, redeemed.Downloads)
+		}
+	}
+	if _, err = redeemShareLink(obj, link.ID, bucket, object); err != errShareLinkNotFound {
+		t.Fatalf("%s: Expected %v after the last download, but got %v", instanceType, errShareLinkNotFound, err)
+	}
+	if err = verifyShareLink(obj, link.ID, bucket, object); err != errShareLinkNotFound {
+		t.Fatalf("%s: Expected %v after the last download, but got %v", instanceType, errShareLinkNotFound, err)
+	}
+
+	// Expired links are rejected and removed.
+	link, err = newShareLink(obj, bucket, object, -time.Second, 0)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = verifyShareLink(obj, link.ID, bucket, object); err != errShareLinkExpired {
+		t.Fatalf("%s: Expected %v, but got %v", instanceType, errShareLinkExpired, err)
+	}
+	if _, err = redeemShareLink(obj, link.ID, bucket, object); err != errShareLinkExpired {
+		t.Fatalf("%s: Expected %v, but got %v", instanceType, errShareLinkExpired, err)
+	}
+	if _, err = readShareLink(obj, link.ID); err != errShareLinkNotFound {
+		t.Fatalf("%s: Expected expired link to be removed, but got %v", instanceType, err)
+	}
+
+	// Unlimited links are counted but never used up.
+	link, err = newShareLink(obj, bucket, object, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err = redeemShareLink(obj, link.ID, bucket, object); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -57,6 +58,25 @@ func (jwt *JWT) GenerateToken(userName string) (string, error) {
 	return token.SignedString([]byte(jwt.SecretAccessKey))
 }
 
+// keyFunc - returns the key to verify a token with, only tokens signed
+// with HMAC are accepted.
+func (jwt *JWT) keyFunc(token *jwtgo.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	return []byte(jwt.SecretAccessKey), nil
+}
+
+// isValidToken - returns if the token has not expired and was issued
+// for the current access key, sessions end when credentials change.
+func (jwt *JWT) isValidToken(token *jwtgo.Token) bool {
+	if token == nil || !token.Valid {
+		return false
+	}
+	userName, ok := token.Claims["sub"].(string)
+	return ok && userName == jwt.AccessKeyID
+}
+
 // Authenticate - authenticates incoming username and password.
 func (jwt *JWT) Authenticate(userName, password string) bool {
 	userName = strings.TrimSpace(userName)
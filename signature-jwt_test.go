@@ -0,0 +1,96 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+// Tests tokens are valid only for the current credentials.
+func TestJWTIsValidToken(t *testing.T) {
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	cred := serverConfig.GetCredential()
+	defer serverConfig.SetCredential(cred)
+
+	jwt := initJWT()
+	validToken, err := jwt.GenerateToken(cred.AccessKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherUserToken, err := jwt.GenerateToken("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := jwtgo.New(jwtgo.SigningMethodHS512)
+	expired.Claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	expired.Claims["sub"] = cred.AccessKeyID
+	expiredToken, err := expired.SignedString([]byte(cred.SecretAccessKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaSigned := jwtgo.New(jwtgo.SigningMethodES256)
+	ecdsaSigned.Claims["exp"] = time.Now().Add(time.Hour).Unix()
+	ecdsaSigned.Claims["sub"] = cred.AccessKeyID
+	ecdsaToken, err := ecdsaSigned.SignedString(ecdsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		token      string
+		credential credential
+		isValid    bool
+	}{
+		// Test case - 1.
+		{validToken, cred, true},
+		// Test case - 2.
+		// Token issued to another user.
+		{otherUserToken, cred, false},
+		// Test case - 3.
+		{expiredToken, cred, false},
+		// Test case - 4.
+		// Only HMAC signed tokens are accepted.
+		{ecdsaToken, cred, false},
+		// Test case - 5.
+		// Access key has changed since the token was issued.
+		{validToken, credential{AccessKeyID: "NEWACCESSKEY0000000X", SecretAccessKey: cred.SecretAccessKey}, false},
+		// Test case - 6.
+		// Secret key has changed since the token was issued.
+		{validToken, credential{AccessKeyID: cred.AccessKeyID, SecretAccessKey: "new-secret-key"}, false},
+	}
+	for i, testCase := range testCases {
+		serverConfig.SetCredential(testCase.credential)
+		jwt = initJWT()
+		token, err := jwtgo.Parse(testCase.token, jwt.keyFunc)
+		isValid := err == nil && jwt.isValidToken(token)
+		if isValid != testCase.isValid {
+			t.Errorf("Test %d: Expected token to be valid %t, but instead found %t", i+1, testCase.isValid, isValid)
+		}
+	}
+}
@@ -85,6 +85,22 @@ func getURLEncodedName(name string) string {
 	return encodedName
 }
 
+// getSignedPayload - returns the payload checksum signed by the client
+// in the x-amz-content-sha256 value, clients not sending one sign the
+// checksum of the received payload.
+func getSignedPayload(contentSha256, hashedPayload string) (string, APIErrorCode) {
+	if contentSha256 == "" {
+		return hashedPayload, ErrNone
+	}
+	if contentSha256 == unsignedPayload {
+		return unsignedPayload, ErrNone
+	}
+	if sum, err := hex.DecodeString(contentSha256); err != nil || len(sum) != sha256.Size {
+		return "", ErrInvalidContentSHA256
+	}
+	return contentSha256, ErrNone
+}
+
 // extractSignedHeaders extract signed headers from Authorization header
 func extractSignedHeaders(signedHeaders []string, reqHeaders http.Header) http.Header {
 	extractedSignedHeaders := make(http.Header)
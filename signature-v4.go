@@ -41,6 +41,7 @@ const (
 	signV4Algorithm = "AWS4-HMAC-SHA256"
 	iso8601Format   = "20060102T150405Z"
 	yyyymmdd        = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
 )
 
 // getCanonicalHeaders generate a list of request headers with their values
@@ -153,8 +154,8 @@ func doesPolicySignatureMatch(formValues map[string]string) APIErrorCode {
 	// Access credentials.
 	cred := serverConfig.GetCredential()
 
-	// Server region.
-	region := serverConfig.GetRegion()
+	// Region of the bucket uploaded to.
+	region := getBucketRegion(formValues["Bucket"])
 
 	// Parse credential tag.
 	credHeader, err := parseCredentialHeader("Credential=" + formValues["X-Amz-Credential"])
@@ -199,8 +200,8 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 	// Access credentials.
 	cred := serverConfig.GetCredential()
 
-	// Server region.
-	region := serverConfig.GetRegion()
+	// Region of the bucket, server region for all other requests.
+	region := getRequestRegion(r)
 
 	// Copy request
 	req := *r
@@ -233,16 +234,25 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 
 	// Construct new query.
 	query := make(url.Values)
-	if req.URL.Query().Get("X-Amz-Content-Sha256") != "" {
-		query.Set("X-Amz-Content-Sha256", hashedPayload)
-	} else {
-		hashedPayload = "UNSIGNED-PAYLOAD"
+	// Payload is unsigned, unless its checksum is part of the query.
+	signedPayload := unsignedPayload
+	if contentSha256 := req.URL.Query().Get("X-Amz-Content-Sha256"); contentSha256 != "" {
+		if signedPayload, err = getSignedPayload(contentSha256, hashedPayload); err != ErrNone {
+			return err
+		}
+		query.Set("X-Amz-Content-Sha256", signedPayload)
 	}
 	query.Set("X-Amz-Algorithm", signV4Algorithm)
 
-	if time.Now().UTC().Sub(preSignValues.Date) > time.Duration(preSignValues.Expires) {
+	now := time.Now().UTC()
+	if now.Sub(preSignValues.Date) > time.Duration(preSignValues.Expires) {
 		return ErrExpiredPresignRequest
 	}
+	// Requests signed by a client whose clock is ahead are accepted
+	// within the allowed skew.
+	if preSignValues.Date.Sub(now) > globalMaxClockSkew {
+		return ErrRequestNotReadyYet
+	}
 
 	// Save the date and expires.
 	t := preSignValues.Date
@@ -281,17 +291,11 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 	if req.URL.Query().Get("X-Amz-Credential") != query.Get("X-Amz-Credential") {
 		return ErrSignatureDoesNotMatch
 	}
-	// Verify if sha256 payload query is same.
-	if req.URL.Query().Get("X-Amz-Content-Sha256") != "" {
-		if req.URL.Query().Get("X-Amz-Content-Sha256") != query.Get("X-Amz-Content-Sha256") {
-			return ErrSignatureDoesNotMatch
-		}
-	}
 
 	/// Verify finally if signature is same.
 
 	// Get canonical request.
-	presignedCanonicalReq := getCanonicalRequest(extractedSignedHeaders, hashedPayload, encodedQuery, req.URL.Path, req.Method, req.Host)
+	presignedCanonicalReq := getCanonicalRequest(extractedSignedHeaders, signedPayload, encodedQuery, req.URL.Path, req.Method, req.Host)
 
 	// Get string to sign from canonical request.
 	presignedStringToSign := getStringToSign(presignedCanonicalReq, t, region)
@@ -306,6 +310,10 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 	if req.URL.Query().Get("X-Amz-Signature") != newSignature {
 		return ErrSignatureDoesNotMatch
 	}
+	// Verify if the signed checksum matches the payload.
+	if signedPayload != unsignedPayload && signedPayload != hashedPayload {
+		return ErrContentSHA256Mismatch
+	}
 	return ErrNone
 }
 
@@ -316,8 +324,8 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	// Access credentials.
 	cred := serverConfig.GetCredential()
 
-	// Server region.
-	region := serverConfig.GetRegion()
+	// Region of the bucket, server region for all other requests.
+	region := getRequestRegion(r)
 
 	// Copy request.
 	req := *r
@@ -334,6 +342,12 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders := extractSignedHeaders(signV4Values.SignedHeaders, req.Header)
 
+	// Extract the payload checksum signed by the client.
+	signedPayload, err := getSignedPayload(req.Header.Get("X-Amz-Content-Sha256"), hashedPayload)
+	if err != ErrNone {
+		return err
+	}
+
 	// Verify if the access key id matches.
 	if signV4Values.Credential.accessKey != cred.AccessKeyID {
 		return ErrInvalidAccessKeyID
@@ -345,7 +359,7 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	// do not need region validated for example GetBucketLocation.
 	if validateRegion {
 		if !isValidRegion(sRegion, region) {
-			return ErrInvalidRegion
+			return ErrAuthorizationHeaderMalformed
 		}
 	} else {
 		region = sRegion
@@ -368,7 +382,7 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	queryStr := req.URL.Query().Encode()
 
 	// Get canonical request.
-	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, hashedPayload, queryStr, req.URL.Path, req.Method, req.Host)
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, signedPayload, queryStr, req.URL.Path, req.Method, req.Host)
 
 	// Get string to sign from canonical request.
 	stringToSign := getStringToSign(canonicalRequest, t, region)
@@ -383,5 +397,62 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	if newSignature != signV4Values.Signature {
 		return ErrSignatureDoesNotMatch
 	}
+	// Verify if the signed checksum matches the payload.
+	if signedPayload != unsignedPayload && signedPayload != hashedPayload {
+		return ErrContentSHA256Mismatch
+	}
 	return ErrNone
 }
+
+// signV4 - signs the header of a request without payload with cred,
+// used by nodes to call the admin API of other nodes.
+func signV4(req *http.Request, cred credential, region string, t time.Time) {
+	req.Header.Set("X-Amz-Date", t.Format(iso8601Format))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	extractedSignedHeaders := make(http.Header)
+	for _, key := range []string{"X-Amz-Date", "X-Amz-Content-Sha256"} {
+		extractedSignedHeaders[key] = req.Header[key]
+	}
+
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload, req.URL.Query().Encode(), req.URL.Path, req.Method, req.URL.Host)
+	stringToSign := getStringToSign(canonicalRequest, t, region)
+	signingKey := getSigningKey(cred.SecretAccessKey, t, region)
+	req.Header.Set("Authorization", signV4Algorithm+" Credential="+cred.AccessKeyID+"/"+getScope(t, region)+
+		", SignedHeaders="+getSignedHeaders(extractedSignedHeaders)+", Signature="+getSignature(signingKey, stringToSign))
+}
+
+// preSignV4 - presigns a request of an object with the server
+// credentials, returns the path and query of the presigned URL.
+// http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func preSignV4(method, host, bucket, object string, t time.Time, expires time.Duration) string {
+	return preSignV4WithQuery(method, host, bucket, object, make(url.Values), t, expires)
+}
+
+// preSignV4WithQuery - same as preSignV4, the parameters in query are
+// signed along and can not be altered by the holder of the URL.
+func preSignV4WithQuery(method, host, bucket, object string, query url.Values, t time.Time, expires time.Duration) string {
+	urlPath := "/" + bucket + "/" + object
+	preSignV4Query(serverConfig.GetCredential(), getBucketRegion(bucket), method, host, urlPath, query, t, expires)
+
+	encodedPath := strings.Replace(getURLEncodedName(urlPath), "+", "%20", -1)
+	return encodedPath + "?" + strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+// preSignV4Query - adds the query string signature of a request to
+// query, any other query parameters already set are signed as well.
+// Only the host header is signed and the payload is left unsigned.
+func preSignV4Query(cred credential, region, method, host, urlPath string, query url.Values, t time.Time, expires time.Duration) {
+	// Only host header is signed.
+	extractedSignedHeaders := make(http.Header)
+
+	query.Set("X-Amz-Algorithm", signV4Algorithm)
+	query.Set("X-Amz-Date", t.Format(iso8601Format))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", getSignedHeaders(extractedSignedHeaders))
+	query.Set("X-Amz-Credential", cred.AccessKeyID+"/"+getScope(t, region))
+
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload, query.Encode(), urlPath, method, host)
+	stringToSign := getStringToSign(canonicalRequest, t, region)
+	signingKey := getSigningKey(cred.SecretAccessKey, t, region)
+	query.Set("X-Amz-Signature", getSignature(signingKey, stringToSign))
+}
@@ -0,0 +1,179 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signTestRequestV4 - signs the request with the server credentials
+// and signedPayload as payload checksum, contentSha256 is sent if set.
+func signTestRequestV4(req *http.Request, contentSha256, signedPayload string) {
+	t := time.Now().UTC()
+	req.Header.Set("X-Amz-Date", t.Format(iso8601Format))
+	if contentSha256 != "" {
+		req.Header.Set("X-Amz-Content-Sha256", contentSha256)
+	}
+	extractedSignedHeaders := make(http.Header)
+	for k, v := range req.Header {
+		extractedSignedHeaders[k] = v
+	}
+	cred := serverConfig.GetCredential()
+	region := serverConfig.GetRegion()
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, signedPayload, req.URL.Query().Encode(), req.URL.Path, req.Method, req.Host)
+	stringToSign := getStringToSign(canonicalRequest, t, region)
+	signature := getSignature(getSigningKey(cred.SecretAccessKey, t, region), stringToSign)
+	req.Header.Set("Authorization", signV4Algorithm+" Credential="+cred.AccessKeyID+"/"+getScope(t, region)+
+		", SignedHeaders="+getSignedHeaders(extractedSignedHeaders)+", Signature="+signature)
+}
+
+// Tests the signed payload checksum is verified against the payload.
+func TestDoesSignatureMatchPayload(t *testing.T) {
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	hashedPayload := hex.EncodeToString(sum256([]byte("hello")))
+	otherPayload := hex.EncodeToString(sum256([]byte("world")))
+
+	testCases := []struct {
+		contentSha256 string
+		signedPayload string
+		expectedErr   APIErrorCode
+	}{
+		// Test case - 1.
+		// Checksum not sent, payload checksum is signed.
+		{"", hashedPayload, ErrNone},
+		// Test case - 2.
+		{hashedPayload, hashedPayload, ErrNone},
+		// Test case - 3.
+		{unsignedPayload, unsignedPayload, ErrNone},
+		// Test case - 4.
+		// Payload does not match the signed checksum.
+		{otherPayload, otherPayload, ErrContentSHA256Mismatch},
+		// Test case - 5.
+		{"invalid", "invalid", ErrInvalidContentSHA256},
+		// Test case - 6.
+		// Signed checksum differs from the sent checksum.
+		{hashedPayload, otherPayload, ErrSignatureDoesNotMatch},
+		// Test case - 7.
+		{"", unsignedPayload, ErrSignatureDoesNotMatch},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("PUT", "http://localhost:9000/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signTestRequestV4(req, testCase.contentSha256, testCase.signedPayload)
+		if s3Error := doesSignatureMatch(hashedPayload, req, true); s3Error != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %d, but instead found %d", i+1, testCase.expectedErr, s3Error)
+		}
+	}
+}
+
+// presignTestQueryV4 - returns the query of a presigned PUT request to
+// the server root, contentSha256 is signed if set.
+func presignTestQueryV4(contentSha256 string) url.Values {
+	t := time.Now().UTC()
+	cred := serverConfig.GetCredential()
+	region := serverConfig.GetRegion()
+	query := make(url.Values)
+	query.Set("X-Amz-Algorithm", signV4Algorithm)
+	query.Set("X-Amz-Date", t.Format(iso8601Format))
+	query.Set("X-Amz-Expires", strconv.Itoa(3600))
+	query.Set("X-Amz-SignedHeaders", "host")
+	query.Set("X-Amz-Credential", cred.AccessKeyID+"/"+getScope(t, region))
+	signedPayload := unsignedPayload
+	if contentSha256 != "" {
+		query.Set("X-Amz-Content-Sha256", contentSha256)
+		signedPayload = contentSha256
+	}
+	canonicalRequest := getCanonicalRequest(make(http.Header), signedPayload, query.Encode(), "/", "PUT", "localhost:9000")
+	stringToSign := getStringToSign(canonicalRequest, t, region)
+	query.Set("X-Amz-Signature", getSignature(getSigningKey(cred.SecretAccessKey, t, region), stringToSign))
+	return query
+}
+
+// Tests the payload checksum of presigned requests is verified if set.
+func TestDoesPresignedSignatureMatchPayload(t *testing.T) {
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	hashedPayload := hex.EncodeToString(sum256([]byte("hello")))
+	otherPayload := hex.EncodeToString(sum256([]byte("world")))
+
+	testCases := []struct {
+		contentSha256 string
+		expectedErr   APIErrorCode
+	}{
+		// Test case - 1.
+		// Payload is unsigned.
+		{"", ErrNone},
+		// Test case - 2.
+		{hashedPayload, ErrNone},
+		// Test case - 3.
+		{otherPayload, ErrContentSHA256Mismatch},
+		// Test case - 4.
+		{"invalid", ErrInvalidContentSHA256},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("PUT", "http://localhost:9000/?"+presignTestQueryV4(testCase.contentSha256).Encode(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s3Error := doesPresignedSignatureMatch(hashedPayload, req, true); s3Error != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %d, but instead found %d", i+1, testCase.expectedErr, s3Error)
+		}
+	}
+}
+
+// Tests requests signed by signV4 are authenticated as admin requests.
+func TestSignV4(t *testing.T) {
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	cred := serverConfig.GetCredential()
+	otherCred := cred
+	otherCred.SecretAccessKey = "other-secret-access-key"
+
+	testCases := []struct {
+		cred        credential
+		expectedErr APIErrorCode
+	}{
+		// Test case - 1.
+		{cred, ErrNone},
+		// Test case - 2.
+		// Signed with another secret key.
+		{otherCred, ErrSignatureDoesNotMatch},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("POST", "http://localhost:9000"+adminAPIPathPrefix+"/service/update?local=true&experimental=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signV4(req, testCase.cred, serverConfig.GetRegion(), time.Now().UTC())
+		// Requests received by the server always have a body.
+		req.Body = http.NoBody
+		if s3Error := checkAdminRequestAuth(req); s3Error != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %d, but instead found %d", i+1, testCase.expectedErr, s3Error)
+		}
+	}
+}
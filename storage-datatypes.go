@@ -21,6 +21,22 @@ import (
 	"time"
 )
 
+// DiskInfo - represents disk usage and error statistics.
+type DiskInfo struct {
+	// Total disk space.
+	Total int64
+
+	// Free available disk space.
+	Free int64
+
+	// File system type.
+	FSType string
+
+	// Number of failed reads and writes since the server started.
+	ReadErrCount  int64
+	WriteErrCount int64
+}
+
 // VolInfo - represents volume stat information.
 type VolInfo struct {
 	// Name of the volume.
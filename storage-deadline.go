@@ -0,0 +1,273 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Default deadlines of disk operations.
+const (
+	storageDefaultMetadataTimeout = 30
+	storageDefaultDataTimeout     = 30
+	storageDefaultMinThroughput   = 1
+)
+
+// storageConfig - deadlines of disk operations, zero values pick the
+// defaults. Metadata operations get MetadataTimeout seconds, reads and
+// appends of data get DataTimeout seconds plus the time to transfer the
+// data at MinThroughput MiB per second. Disks not answering in time are
+// handled like failed disks by the quorum logic.
+type storageConfig struct {
+	MetadataTimeout int `json:"metadataTimeout"`
+	DataTimeout     int `json:"dataTimeout"`
+	MinThroughput   int `json:"minThroughput"`
+}
+
+// newStorageConfig - returns the default storage config.
+func newStorageConfig() storageConfig {
+	return storageConfig{
+		MetadataTimeout: storageDefaultMetadataTimeout,
+		DataTimeout:     storageDefaultDataTimeout,
+		MinThroughput:   storageDefaultMinThroughput,
+	}
+}
+
+// getStorageConfig - returns the storage config of the server, the
+// defaults if there is no server config.
+func getStorageConfig() storageConfig {
+	if serverConfig == nil {
+		return newStorageConfig()
+	}
+	return serverConfig.GetStorage()
+}
+
+// metadataTimeout - returns the deadline of metadata operations.
+func (c storageConfig) metadataTimeout() time.Duration {
+	if c.MetadataTimeout <= 0 {
+		c.MetadataTimeout = storageDefaultMetadataTimeout
+	}
+	return time.Duration(c.MetadataTimeout) * time.Second
+}
+
+// dataTimeout - returns the deadline of reading or appending size
+// bytes.
+func (c storageConfig) dataTimeout(size int) time.Duration {
+	if c.DataTimeout <= 0 {
+		c.DataTimeout = storageDefaultDataTimeout
+	}
+	if c.MinThroughput <= 0 {
+		c.MinThroughput = storageDefaultMinThroughput
+	}
+	transfer := time.Duration(size) * time.Second / time.Duration(c.MinThroughput*1024*1024)
+	return time.Duration(c.DataTimeout)*time.Second + transfer
+}
+
+// deadlineDisk - local disk failing operations which do not return
+// within their deadline with errFaultyDisk, the operations keep running
+// in the background. Like I/O errors every missed deadline counts
+// towards taking the disk offline.
+type deadlineDisk struct {
+	*posix
+}
+
+// withDeadline - runs fn in the background and waits for its result
+// until ctx is done or timeout passed, regular files have no deadlines
+// of their own. fn may use buf, a pooled buf is kept from being reused
+// until an abandoned fn returns.
+func (d *deadlineDisk) withDeadline(ctx context.Context, timeout time.Duration, buf []byte, fn func() (interface{}, error)) (interface{}, error) {
+	type result struct {
+		value interface{}
+		err   error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		resultCh <- result{value, err}
+	}()
+	abandon := func() {
+		if buf == nil {
+			return
+		}
+		release := globalBufferPools.Abandon(buf)
+		go func() {
+			<-resultCh
+			release()
+		}()
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-resultCh:
+		return res.value, res.err
+	case <-ctx.Done():
+		abandon()
+		return nil, ctx.Err()
+	case <-timer.C:
+		abandon()
+		atomic.AddInt32(&d.ioErrCount, 1)
+		errorIf(errFaultyDisk, "Disk %s did not answer within %s.", d.diskPath, timeout)
+		return nil, errFaultyDisk
+	}
+}
+
+// metadata - runs the metadata operation fn within its deadline.
+func (d *deadlineDisk) metadata(fn func() (interface{}, error)) (interface{}, error) {
+	return d.withDeadline(context.Background(), getStorageConfig().metadataTimeout(), nil, fn)
+}
+
+// DiskInfo - returns disk usage and error statistics.
+func (d *deadlineDisk) DiskInfo() (DiskInfo, error) {
+	info, err := d.metadata(func() (interface{}, error) {
+		return d.posix.DiskInfo()
+	})
+	if err != nil {
+		return DiskInfo{}, err
+	}
+	return info.(DiskInfo), nil
+}
+
+// MakeVol - creates a volume.
+func (d *deadlineDisk) MakeVol(volume string) error {
+	_, err := d.metadata(func() (interface{}, error) {
+		return nil, d.posix.MakeVol(volume)
+	})
+	return err
+}
+
+// ListVols - lists all volumes.
+func (d *deadlineDisk) ListVols() ([]VolInfo, error) {
+	vols, err := d.metadata(func() (interface{}, error) {
+		return d.posix.ListVols()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vols.([]VolInfo), nil
+}
+
+// StatVol - returns the info of a volume.
+func (d *deadlineDisk) StatVol(volume string) (VolInfo, error) {
+	vol, err := d.metadata(func() (interface{}, error) {
+		return d.posix.StatVol(volume)
+	})
+	if err != nil {
+		return VolInfo{}, err
+	}
+	return vol.(VolInfo), nil
+}
+
+// DeleteVol - deletes a volume.
+func (d *deadlineDisk) DeleteVol(volume string) error {
+	_, err := d.metadata(func() (interface{}, error) {
+		return nil, d.posix.DeleteVol(volume)
+	})
+	return err
+}
+
+// ListDir - lists the entries of a directory.
+func (d *deadlineDisk) ListDir(volume, dirPath string) ([]string, error) {
+	entries, err := d.metadata(func() (interface{}, error) {
+		return d.posix.ListDir(volume, dirPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries.([]string), nil
+}
+
+// ReadFile - reads into buf within the deadline of its size.
+func (d *deadlineDisk) ReadFile(ctx context.Context, volume string, path string, offset int64, buf []byte) (int64, error) {
+	n, err := d.withDeadline(ctx, getStorageConfig().dataTimeout(len(buf)), buf, func() (interface{}, error) {
+		return d.posix.ReadFile(ctx, volume, path, offset, buf)
+	})
+	if n == nil {
+		return 0, err
+	}
+	return n.(int64), err
+}
+
+// OpenFile - opens a file for reading at offset within the metadata
+// deadline, reads of the file have no deadline.
+func (d *deadlineDisk) OpenFile(volume string, path string, offset int64) (*os.File, error) {
+	file, err := d.metadata(func() (interface{}, error) {
+		return d.posix.OpenFile(volume, path, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return file.(*os.File), nil
+}
+
+// AppendFile - appends buf within the deadline of its size.
+func (d *deadlineDisk) AppendFile(ctx context.Context, volume, path string, buf []byte) error {
+	_, err := d.withDeadline(ctx, getStorageConfig().dataTimeout(len(buf)), buf, func() (interface{}, error) {
+		return nil, d.posix.AppendFile(ctx, volume, path, buf)
+	})
+	return err
+}
+
+// PrepareFile - preallocates space for a file.
+func (d *deadlineDisk) PrepareFile(volume, path string, size int64) error {
+	_, err := d.metadata(func() (interface{}, error) {
+		return nil, d.posix.PrepareFile(volume, path, size)
+	})
+	return err
+}
+
+// MakeDir - makes a directory and its parents.
+func (d *deadlineDisk) MakeDir(volume, dirPath string) error {
+	_, err := d.metadata(func() (interface{}, error) {
+		return nil, d.posix.MakeDir(volume, dirPath)
+	})
+	return err
+}
+
+// StatFile - returns the info of a file.
+func (d *deadlineDisk) StatFile(volume, path string) (FileInfo, error) {
+	fi, err := d.metadata(func() (interface{}, error) {
+		return d.posix.StatFile(volume, path)
+	})
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return fi.(FileInfo), nil
+}
+
+// DeleteFile - deletes a file.
+func (d *deadlineDisk) DeleteFile(volume, path string) error {
+	_, err := d.metadata(func() (interface{}, error) {
+		return nil, d.posix.DeleteFile(volume, path)
+	})
+	return err
+}
+
+// RenameFile - renames a file or directory. Disks with the meta volume
+// on a separate device copy data of unknown size when moving objects
+// into place, their renames have no deadline.
+func (d *deadlineDisk) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) error {
+	if d.metaPath != "" {
+		return d.posix.RenameFile(srcVolume, srcPath, dstVolume, dstPath)
+	}
+	_, err := d.metadata(func() (interface{}, error) {
+		return nil, d.posix.RenameFile(srcVolume, srcPath, dstVolume, dstPath)
+	})
+	return err
+}
@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Tests data deadlines grow with the size of the data.
+func TestStorageConfigTimeouts(t *testing.T) {
+	testCases := []struct {
+		config   storageConfig
+		size     int
+		metadata time.Duration
+		data     time.Duration
+	}{
+		{storageConfig{}, 0, 30 * time.Second, 30 * time.Second},
+		{newStorageConfig(), 10 * 1024 * 1024, 30 * time.Second, 40 * time.Second},
+		{storageConfig{MetadataTimeout: 5, DataTimeout: 10, MinThroughput: 4}, 2 * 1024 * 1024, 5 * time.Second, 10*time.Second + 500*time.Millisecond},
+	}
+	for i, testCase := range testCases {
+		if timeout := testCase.config.metadataTimeout(); timeout != testCase.metadata {
+			t.Errorf("Test %d: expected metadata timeout %s, got %s", i+1, testCase.metadata, timeout)
+		}
+		if timeout := testCase.config.dataTimeout(testCase.size); timeout != testCase.data {
+			t.Errorf("Test %d: expected data timeout %s, got %s", i+1, testCase.data, timeout)
+		}
+	}
+}
+
+// Tests disks not answering in time fail like faulty disks.
+func TestDeadlineDisk(t *testing.T) {
+	diskPath, err := getTestRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+	storage, err := newPosix(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := storage.(*deadlineDisk)
+
+	release := make(chan struct{})
+	defer close(release)
+	hang := func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}
+	if _, err = disk.withDeadline(context.Background(), 10*time.Millisecond, nil, hang); err != errFaultyDisk {
+		t.Fatalf("Expected %v, got %v", errFaultyDisk, err)
+	}
+	if disk.ioErrCount != 1 {
+		t.Fatalf("Expected missed deadline to count as I/O error, got %d", disk.ioErrCount)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = disk.withDeadline(ctx, time.Minute, nil, hang); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+
+	// Buffers of abandoned operations are not reused until they return.
+	done := make(chan struct{})
+	buf := globalBufferPools.Get(1024)
+	disk.withDeadline(ctx, time.Minute, buf, func() (interface{}, error) {
+		<-done
+		return nil, nil
+	})
+	globalBufferPools.mutex.RLock()
+	count := globalBufferPools.abandoned[lastByte(buf)]
+	globalBufferPools.mutex.RUnlock()
+	if count != 1 {
+		t.Fatalf("Expected abandoned buffer, got %d", count)
+	}
+	close(done)
+	for i := 0; count != 0 && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+		globalBufferPools.mutex.RLock()
+		count = globalBufferPools.abandoned[lastByte(buf)]
+		globalBufferPools.mutex.RUnlock()
+	}
+	if count != 0 {
+		t.Fatalf("Expected buffer to be released, got %d", count)
+	}
+
+	// Disks missing too many deadlines are taken offline.
+	for i := 0; i < maxAllowedIOError; i++ {
+		disk.withDeadline(context.Background(), time.Millisecond, nil, hang)
+	}
+	if err = disk.MakeVol("volume"); err != errFaultyDisk {
+		t.Fatalf("Expected %v, got %v", errFaultyDisk, err)
+	}
+}
@@ -39,6 +39,9 @@ var errFileNotFound = errors.New("file not found")
 // errFileNameTooLong - given file name is too long than supported length.
 var errFileNameTooLong = errors.New("file name too long")
 
+// errFileNameInvalid - given file name cannot be stored by the file system.
+var errFileNameInvalid = errors.New("file name invalid")
+
 // errVolumeExists - cannot create same volume again.
 var errVolumeExists = errors.New("volume already exists")
 
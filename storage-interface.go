@@ -16,18 +16,26 @@
 
 package main
 
+import "context"
+
 // StorageAPI interface.
 type StorageAPI interface {
+	// Storage operations.
+	DiskInfo() (info DiskInfo, err error)
+
 	// Volume operations.
 	MakeVol(volume string) (err error)
 	ListVols() (vols []VolInfo, err error)
 	StatVol(volume string) (vol VolInfo, err error)
 	DeleteVol(volume string) (err error)
 
-	// File operations.
+	// File operations, reads and appends fail with ctx.Err() once ctx
+	// is done.
 	ListDir(volume, dirPath string) ([]string, error)
-	ReadFile(volume string, path string, offset int64, buf []byte) (n int64, err error)
-	AppendFile(volume string, path string, buf []byte) (err error)
+	ReadFile(ctx context.Context, volume string, path string, offset int64, buf []byte) (n int64, err error)
+	AppendFile(ctx context.Context, volume string, path string, buf []byte) (err error)
+	PrepareFile(volume string, path string, size int64) (err error)
+	MakeDir(volume string, dirPath string) (err error)
 	RenameFile(srcVolume, srcPath, dstVolume, dstPath string) error
 	StatFile(volume string, path string) (file FileInfo, err error)
 	DeleteFile(volume string, path string) (err error)
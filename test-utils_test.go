@@ -96,7 +96,8 @@ func StartTestServer(t TestErrHandler, instanceType string) TestServer {
 		t.Fatalf(err.Error())
 	}
 	// Run TestServer.
-	testServer.Server = httptest.NewServer(configureServerHandler(serverCmdConfig{exportPaths: erasureDisks}))
+	handler, _ := configureServerHandler(serverCmdConfig{exportPaths: erasureDisks})
+	testServer.Server = httptest.NewServer(handler)
 
 	return testServer
 }
@@ -108,10 +109,17 @@ func (testServer TestServer) Stop() {
 		removeAll(disk)
 	}
 	testServer.Server.Close()
+	// Config is no longer saved in the removed backend.
+	globalBackendConfigLayer = nil
 }
 
 // used to formulate HTTP v4 signed HTTP request.
 func newTestRequest(method, urlStr string, contentLength int64, body io.ReadSeeker, accessKey, secretKey string) (*http.Request, error) {
+	return newTestRequestWithRegion(method, urlStr, contentLength, body, accessKey, secretKey, "us-east-1")
+}
+
+// used to formulate HTTP v4 signed HTTP request for a given region.
+func newTestRequestWithRegion(method, urlStr string, contentLength int64, body io.ReadSeeker, accessKey, secretKey, region string) (*http.Request, error) {
 	if method == "" {
 		method = "POST"
 	}
@@ -208,7 +216,7 @@ func newTestRequest(method, urlStr string, contentLength int64, body io.ReadSeek
 
 	scope := strings.Join([]string{
 		t.Format(yyyymmdd),
-		"us-east-1",
+		region,
 		"s3",
 		"aws4_request",
 	}, "/")
@@ -218,8 +226,8 @@ func newTestRequest(method, urlStr string, contentLength int64, body io.ReadSeek
 	stringToSign = stringToSign + hex.EncodeToString(sum256([]byte(canonicalRequest)))
 
 	date := sumHMAC([]byte("AWS4"+secretKey), []byte(t.Format(yyyymmdd)))
-	region := sumHMAC(date, []byte("us-east-1"))
-	service := sumHMAC(region, []byte("s3"))
+	regionHMAC := sumHMAC(date, []byte(region))
+	service := sumHMAC(regionHMAC, []byte("s3"))
 	signingKey := sumHMAC(service, []byte("aws4_request"))
 
 	signature := hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
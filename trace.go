@@ -0,0 +1,283 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum number of request and response body bytes captured by a
+// verbose trace.
+const maxTraceBodySize = 1024
+
+// Number of traces buffered per subscriber, traces are dropped for
+// subscribers which cannot keep up.
+const traceSubscriberBuffer = 1000
+
+// traceInfo - summary of a single API request and its response.
+type traceInfo struct {
+	Time       time.Time     `json:"time"`
+	RequestID  string        `json:"requestID"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	RawQuery   string        `json:"rawQuery,omitempty"`
+	StatusCode int           `json:"statusCode"`
+	Duration   time.Duration `json:"duration"`
+	RemoteAddr string        `json:"remoteAddr"`
+	AccessKey  string        `json:"accessKey,omitempty"`
+	BytesSent  int64         `json:"bytesSent"`
+
+	// Only set for verbose traces.
+	ReqHeader  http.Header `json:"reqHeader,omitempty"`
+	ReqBody    []byte      `json:"reqBody,omitempty"`
+	RespHeader http.Header `json:"respHeader,omitempty"`
+	RespBody   []byte      `json:"respBody,omitempty"`
+}
+
+// traceSubscriber - a connected trace client and its filters.
+type traceSubscriber struct {
+	traceCh chan traceInfo
+	errOnly bool
+	verbose bool
+}
+
+// tracePubSub - publishes traces to all connected trace clients.
+type tracePubSub struct {
+	mutex       sync.RWMutex
+	subscribers map[*traceSubscriber]struct{}
+}
+
+// newTracePubSub - returns a trace publisher without subscribers.
+func newTracePubSub() *tracePubSub {
+	return &tracePubSub{
+		subscribers: make(map[*traceSubscriber]struct{}),
+	}
+}
+
+// Subscribe - registers a new trace client, errOnly restricts traces
+// to failed requests, verbose includes headers and bodies.
+func (ps *tracePubSub) Subscribe(errOnly, verbose bool) *traceSubscriber {
+	sub := &traceSubscriber{
+		traceCh: make(chan traceInfo, traceSubscriberBuffer),
+		errOnly: errOnly,
+		verbose: verbose,
+	}
+	ps.mutex.Lock()
+	ps.subscribers[sub] = struct{}{}
+	ps.mutex.Unlock()
+	return sub
+}
+
+// Unsubscribe - removes a trace client.
+func (ps *tracePubSub) Unsubscribe(sub *traceSubscriber) {
+	ps.mutex.Lock()
+	delete(ps.subscribers, sub)
+	ps.mutex.Unlock()
+}
+
+// wants - returns if any trace client is connected and if any of
+// them asked for verbose traces.
+func (ps *tracePubSub) wants() (trace bool, verbose bool) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	for sub := range ps.subscribers {
+		trace = true
+		if sub.verbose {
+			verbose = true
+		}
+	}
+	return trace, verbose
+}
+
+// Publish - sends trace to all matching trace clients without blocking.
+func (ps *tracePubSub) Publish(trace traceInfo) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	for sub := range ps.subscribers {
+		if sub.errOnly && trace.StatusCode < http.StatusBadRequest {
+			continue
+		}
+		t := trace
+		if !sub.verbose {
+			t.ReqHeader, t.ReqBody, t.RespHeader, t.RespBody = nil, nil, nil, nil
+		}
+		select {
+		case sub.traceCh <- t:
+		default:
+		}
+	}
+}
+
+// Global trace publisher.
+var globalHTTPTrace = newTracePubSub()
+
+// getRequestAccessKey - returns the access key a request is signed
+// with, if any.
+func getRequestAccessKey(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSigned:
+		signV4Values, s3Error := parseSignV4(r.Header.Get("Authorization"))
+		if s3Error == ErrNone {
+			return signV4Values.Credential.accessKey
+		}
+	case authTypePresigned:
+		preSignV4Values, s3Error := parsePreSignV4(r.URL.Query())
+		if s3Error == ErrNone {
+			return preSignV4Values.Credential.accessKey
+		}
+	}
+	return ""
+}
+
+// limitedBuffer - keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.limit - b.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.Buffer.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// traceReadCloser - captures the request body as it is read.
+type traceReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// traceResponseWriter - records status, size and optionally the body
+// of a response.
+type traceResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	bytesSent   int64
+	body        *limitedBuffer
+	wroteHeader bool
+}
+
+func (w *traceResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *traceResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytesSent += int64(n)
+	if w.body != nil {
+		w.body.Write(p[:n])
+	}
+	return n, err
+}
+
+// ReadFrom - keeps the zero-copy path of the underlying response
+// writer, objects are sent with sendfile unless the body is recorded.
+func (w *traceResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	readerFrom, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok || w.body != nil {
+		// Hide ReadFrom from io.Copy to copy through Write.
+		return io.Copy(struct{ io.Writer }{w}, r)
+	}
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := readerFrom.ReadFrom(r)
+	w.bytesSent += n
+	return n, err
+}
+
+// Flush - API handlers flush responses explicitly.
+func (w *traceResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// traceHandler - publishes a trace of every request to connected
+// trace clients.
+type traceHandler struct {
+	handler http.Handler
+}
+
+func setTraceHandler(h http.Handler) http.Handler {
+	return traceHandler{h}
+}
+
+func (h traceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	trace, verbose := globalHTTPTrace.wants()
+	// Do not trace the trace requests themselves.
+	if !trace || strings.HasPrefix(r.URL.Path, adminAPIPathPrefix+"/trace") {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	t := traceInfo{
+		Time:       time.Now().UTC(),
+		RequestID:  getRequestID(r),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RawQuery:   r.URL.RawQuery,
+		RemoteAddr: r.RemoteAddr,
+		AccessKey:  getRequestAccessKey(r),
+	}
+	tw := &traceResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	var reqBody *limitedBuffer
+	if verbose {
+		t.ReqHeader = cloneHeader(r.Header)
+		reqBody = &limitedBuffer{limit: maxTraceBodySize}
+		if r.Body != nil {
+			r.Body = traceReadCloser{io.TeeReader(r.Body, reqBody), r.Body}
+		}
+		tw.body = &limitedBuffer{limit: maxTraceBodySize}
+	}
+
+	h.handler.ServeHTTP(tw, r)
+
+	t.StatusCode = tw.statusCode
+	t.Duration = time.Since(t.Time)
+	t.BytesSent = tw.bytesSent
+	if verbose {
+		t.ReqBody = reqBody.Bytes()
+		t.RespHeader = cloneHeader(w.Header())
+		t.RespBody = tw.body.Bytes()
+	}
+	globalHTTPTrace.Publish(t)
+}
+
+// cloneHeader - returns a deep copy of header.
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for k, v := range header {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
@@ -0,0 +1,185 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+// Tests traces are filtered per subscriber.
+func TestTracePubSub(t *testing.T) {
+	ps := newTracePubSub()
+	if trace, _ := ps.wants(); trace {
+		t.Fatal("Expected no traces to be wanted without subscribers")
+	}
+	allSub := ps.Subscribe(false, false)
+	errSub := ps.Subscribe(true, true)
+	if trace, verbose := ps.wants(); !trace || !verbose {
+		t.Fatal("Expected verbose traces to be wanted")
+	}
+
+	header := http.Header{"X-Test": []string{"value"}}
+	ps.Publish(traceInfo{StatusCode: http.StatusOK, ReqHeader: header})
+	ps.Publish(traceInfo{StatusCode: http.StatusNotFound, ReqHeader: header})
+
+	testCases := []struct {
+		sub         *traceSubscriber
+		statusCodes []int
+		verbose     bool
+	}{
+		// Test case - 1.
+		{allSub, []int{http.StatusOK, http.StatusNotFound}, false},
+		// Test case - 2.
+		// Only failed requests, with headers.
+		{errSub, []int{http.StatusNotFound}, true},
+	}
+	for i, testCase := range testCases {
+		if len(testCase.sub.traceCh) != len(testCase.statusCodes) {
+			t.Fatalf("Test %d: Expected %d traces, but instead found %d", i+1, len(testCase.statusCodes), len(testCase.sub.traceCh))
+		}
+		for _, statusCode := range testCase.statusCodes {
+			trace := <-testCase.sub.traceCh
+			if trace.StatusCode != statusCode {
+				t.Errorf("Test %d: Expected status %d, but instead found %d", i+1, statusCode, trace.StatusCode)
+			}
+			if (trace.ReqHeader != nil) != testCase.verbose {
+				t.Errorf("Test %d: Expected verbose %t, but instead found headers %v", i+1, testCase.verbose, trace.ReqHeader)
+			}
+		}
+	}
+
+	ps.Unsubscribe(allSub)
+	ps.Unsubscribe(errSub)
+	if trace, _ := ps.wants(); trace {
+		t.Fatal("Expected no traces to be wanted after unsubscribe")
+	}
+}
+
+// Tests requests are traced through the admin API.
+func TestAdminTrace(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	endpoint := strings.TrimPrefix(testServer.Server.URL, "http://")
+	client, err := madmin.New(endpoint, testServer.AccessKey, testServer.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	traceCh := client.Trace(true, true, doneCh)
+
+	// Keep sending anonymous requests until the trace client is connected.
+	timeout := time.After(10 * time.Second)
+	for {
+		resp, err := http.Get(testServer.Server.URL + "/bucket/object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		select {
+		case trace := <-traceCh:
+			if trace.Err != nil {
+				t.Fatal(trace.Err)
+			}
+			if trace.Method != "GET" || trace.Path != "/bucket/object" || trace.StatusCode != http.StatusForbidden {
+				t.Fatalf("Unexpected trace %#v", trace)
+			}
+			if trace.ReqHeader == nil || !strings.Contains(string(trace.RespBody), "AccessDenied") {
+				t.Fatalf("Expected verbose trace, but instead found %#v", trace)
+			}
+			return
+		case <-timeout:
+			t.Fatal("Expected request to be traced")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// readerFromRecorder - response recorder recording the readers passed
+// to ReadFrom.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readers []io.Reader
+}
+
+func (w *readerFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	w.readers = append(w.readers, r)
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+// Tests FS objects are copied from the file to traced response writers
+// through ReadFrom, so HTTP responses are sent with sendfile.
+func TestTraceResponseWriterReadFrom(t *testing.T) {
+	directory, err := ioutil.TempDir("", "minio-zero-copy-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(directory)
+
+	obj, err := newFSObjects(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = obj.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	text := "hello world"
+	if _, err = obj.PutObject(context.Background(), "bucket", "object", int64(len(text)), bytes.NewBufferString(text), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w := &traceResponseWriter{ResponseWriter: recorder, statusCode: http.StatusOK}
+	if err = obj.GetObject(context.Background(), "bucket", "object", 6, 5, w); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Body.String() != "world" {
+		t.Fatalf("Expected world, but instead found %s", recorder.Body.String())
+	}
+	if w.bytesSent != 5 {
+		t.Errorf("Expected 5 bytes sent, but instead found %d", w.bytesSent)
+	}
+	if len(recorder.readers) != 1 {
+		t.Fatalf("Expected the object to be copied with ReadFrom, but instead found %d calls", len(recorder.readers))
+	}
+	if limited, ok := recorder.readers[0].(*io.LimitedReader); !ok {
+		t.Errorf("Expected a limited reader, but instead found %T", recorder.readers[0])
+	} else if _, ok = limited.R.(*os.File); !ok {
+		t.Errorf("Expected the object file to be read, but instead found %T", limited.R)
+	}
+
+	// Recorded bodies are copied through Write.
+	recorder = &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w = &traceResponseWriter{ResponseWriter: recorder, statusCode: http.StatusOK, body: &limitedBuffer{limit: maxTraceBodySize}}
+	if err = obj.GetObject(context.Background(), "bucket", "object", 0, int64(len(text)), w); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.readers) != 0 || string(w.body.Bytes()) != text {
+		t.Errorf("Expected the body to be recorded, but instead found %q", w.body.Bytes())
+	}
+}
@@ -0,0 +1,441 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Trash configuration file saved in bucket config path.
+	bucketTrashConfigFile = "trash.json"
+
+	// Deleted objects of a bucket are moved to
+	// '.minio/trash/bucket/id/' along with an entry describing them.
+	trashMetaPrefix = "trash"
+	trashEntryFile  = "entry.json"
+	trashDataFile   = "data"
+
+	// Interval between purges of expired trash entries.
+	trashPurgeInterval = time.Hour
+)
+
+// bucketTrashConfig - deleted objects of a bucket are kept in the trash
+// for RetentionDays before they are purged.
+type bucketTrashConfig struct {
+	RetentionDays int `json:"retentionDays"`
+}
+
+// validateBucketTrashConfig - validates a trash configuration.
+func validateBucketTrashConfig(config bucketTrashConfig) error {
+	if config.RetentionDays <= 0 {
+		return errors.New("Trash retention must be at least one day")
+	}
+	return nil
+}
+
+// readBucketTrashConfig - read bucket trash configuration.
+func readBucketTrashConfig(bucket string) (*bucketTrashConfig, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, bucketTrashConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, BucketTrashConfigNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	config := &bucketTrashConfig{}
+	if err = json.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeBucketTrashConfig - save bucket trash configuration.
+func writeBucketTrashConfig(bucket string, config *bucketTrashConfig) error {
+	// Verify if bucket path legal
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfigFile(filepath.Join(bucketConfigPath, bucketTrashConfigFile), configBytes)
+}
+
+// removeBucketTrash - remove bucket trash configuration, objects
+// already in the trash are purged by the next purge.
+func removeBucketTrash(bucket string) error {
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return removeBucketConfigFile(filepath.Join(bucketConfigPath, bucketTrashConfigFile))
+}
+
+// TrashEntry - deleted object kept in the trash.
+type TrashEntry struct {
+	ID        string    `json:"id"`
+	Object    string    `json:"object"`
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// getTrashPath - returns the path of a trash entry in minioMetaBucket.
+func getTrashPath(bucket, id string) string {
+	return path.Join(trashMetaPrefix, bucket, id)
+}
+
+// deleteObjectOrTrash - moves an object to the trash if the bucket has
+// a trash configuration, deletes it otherwise.
+func deleteObjectOrTrash(objAPI ObjectLayer, bucket, object string) error {
+	// Directory markers have no content worth restoring.
+	if isDirMarker(object) {
+		return objAPI.DeleteObject(bucket, object)
+	}
+	if _, err := readBucketTrashConfig(bucket); err != nil {
+		if _, ok := err.(BucketTrashConfigNotFound); ok {
+			return objAPI.DeleteObject(bucket, object)
+		}
+		return err
+	}
+	_, err := trashObject(objAPI, bucket, object)
+	return err
+}
+
+// getLockedObjectInfo - returns the info of an object whose namespace
+// lock is held by the caller.
+func getLockedObjectInfo(objAPI ObjectLayer, bucket, object string) (ObjectInfo, error) {
+	if sets, ok := objAPI.(*xlSets); ok {
+		set, err := sets.getLockedObjectSet(bucket, object)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		objAPI = set
+	}
+	if xl, ok := objAPI.(xlObjects); ok {
+		objInfo, err := xl.getObjectInfo(bucket, object)
+		if err != nil {
+			return ObjectInfo{}, toObjectErr(err, bucket, object)
+		}
+		return objInfo, nil
+	}
+	return objAPI.GetObjectInfo(bucket, object)
+}
+
+// newTrashEntry - returns a trash entry of an object which is about to
+// be moved to the trash.
+func newTrashEntry(objAPI ObjectLayer, bucket, object string) (TrashEntry, error) {
+	objInfo, err := getLockedObjectInfo(objAPI, bucket, object)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	// Size of encrypted and compressed objects is their plain size.
+	if objInfo, err = getPlainObjectInfo(bucket, objInfo); err != nil {
+		return TrashEntry{}, err
+	}
+	return TrashEntry{
+		ID:        getUUID(),
+		Object:    object,
+		Size:      objInfo.Size,
+		DeletedAt: time.Now().UTC(),
+	}, nil
+}
+
+// trashObject - moves an object to the trash of its bucket, objects of
+// gateways are deleted since they have no trash.
+func trashObject(objAPI ObjectLayer, bucket, object string) (TrashEntry, error) {
+	if !IsValidBucketName(bucket) {
+		return TrashEntry{}, BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return TrashEntry{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	disks := getObjectLayerDisks(objAPI)
+	if disks == nil {
+		return TrashEntry{}, objAPI.DeleteObject(bucket, object)
+	}
+
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Objects of erasure sets are moved to the trash of their set.
+	if sets, ok := objAPI.(*xlSets); ok {
+		set, err := sets.getLockedObjectSet(bucket, object)
+		if err != nil {
+			return TrashEntry{}, err
+		}
+		objAPI, disks = set, set.storageDisks
+	}
+
+	entry, err := newTrashEntry(objAPI, bucket, object)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	trashPath := getTrashPath(bucket, entry.ID)
+	dataPath := path.Join(trashPath, trashDataFile)
+	switch obj := objAPI.(type) {
+	case fsObjects:
+		if err = obj.storage.RenameFile(bucket, object, minioMetaBucket, dataPath); err != nil {
+			return TrashEntry{}, toObjectErr(err, bucket, object)
+		}
+		// Objects without metadata have no fs.json.
+		metaPath := path.Join(bucketMetaPrefix, bucket, object, fsMetaJSONFile)
+		if err = obj.storage.RenameFile(minioMetaBucket, metaPath, minioMetaBucket, path.Join(trashPath, fsMetaJSONFile)); err != nil && err != errFileNotFound {
+			return TrashEntry{}, toObjectErr(err, bucket, object)
+		}
+	case xlObjects:
+		if err = obj.renameObject(bucket, object, minioMetaBucket, dataPath); err != nil {
+			return TrashEntry{}, toObjectErr(err, bucket, object)
+		}
+	}
+	// Remove parent directories left empty by the move.
+	if parent := path.Dir(object); parent != "." {
+		for _, disk := range disks {
+			if disk != nil {
+				disk.DeleteFile(bucket, parent)
+			}
+		}
+	}
+	if err = saveJSONMetaFile(objAPI, path.Join(trashPath, trashEntryFile), entry); err != nil {
+		return TrashEntry{}, err
+	}
+	return entry, nil
+}
+
+// readTrashEntry - returns a trash entry of a bucket.
+func readTrashEntry(objAPI ObjectLayer, bucket, id string) (TrashEntry, error) {
+	var entry TrashEntry
+	// Ids are generated by the server, reject anything else.
+	if id == "" || id == "." || id == ".." || strings.Contains(id, slashSeparator) {
+		return entry, TrashEntryNotFound{Bucket: bucket, Object: id}
+	}
+	data, err := loadMetaFile(objAPI, path.Join(getTrashPath(bucket, id), trashEntryFile))
+	if err != nil {
+		if err == errFileNotFound || err == errVolumeNotFound {
+			return entry, TrashEntryNotFound{Bucket: bucket, Object: id}
+		}
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+// listTrashIDs - returns the ids of all trash entries of a bucket.
+func listTrashIDs(objAPI ObjectLayer, bucket string) []string {
+	idSet := make(map[string]struct{})
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		ids, err := disk.ListDir(minioMetaBucket, retainSlash(path.Join(trashMetaPrefix, bucket)))
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			idSet[strings.TrimSuffix(id, slashSeparator)] = struct{}{}
+		}
+	}
+	var ids []string
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// listTrash - returns all objects in the trash of a bucket, the most
+// recently deleted first.
+func listTrash(objAPI ObjectLayer, bucket string) ([]TrashEntry, error) {
+	entries := []TrashEntry{}
+	for _, id := range listTrashIDs(objAPI, bucket) {
+		entry, err := readTrashEntry(objAPI, bucket, id)
+		if err != nil {
+			// Entry is being moved to or out of the trash.
+			if _, ok := err.(TrashEntryNotFound); ok {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// restoreTrash - moves a deleted object back to its bucket, fails if an
+// object with its name was created in the meantime.
+func restoreTrash(objAPI ObjectLayer, bucket, id string) (TrashEntry, error) {
+	entry, err := readTrashEntry(objAPI, bucket, id)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	object := entry.Object
+
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	if _, err = getLockedObjectInfo(objAPI, bucket, object); err == nil {
+		return TrashEntry{}, TrashObjectExists{Bucket: bucket, Object: object}
+	} else if _, ok := err.(ObjectNotFound); !ok {
+		return TrashEntry{}, err
+	}
+	// Objects are restored on the erasure set holding their trash.
+	if sets, ok := objAPI.(*xlSets); ok {
+		objAPI = nil
+		for _, set := range sets.sets {
+			if _, err = readTrashEntry(set, bucket, id); err == nil {
+				objAPI = set
+				break
+			}
+		}
+		if objAPI == nil {
+			return TrashEntry{}, TrashEntryNotFound{Bucket: bucket, Object: id}
+		}
+	}
+	trashPath := getTrashPath(bucket, id)
+	dataPath := path.Join(trashPath, trashDataFile)
+	switch obj := objAPI.(type) {
+	case fsObjects:
+		metaPath := path.Join(bucketMetaPrefix, bucket, object, fsMetaJSONFile)
+		if err = obj.storage.RenameFile(minioMetaBucket, path.Join(trashPath, fsMetaJSONFile), minioMetaBucket, metaPath); err != nil && err != errFileNotFound {
+			return TrashEntry{}, toObjectErr(err, bucket, object)
+		}
+		err = obj.storage.RenameFile(minioMetaBucket, dataPath, bucket, object)
+	case xlObjects:
+		err = obj.renameObject(minioMetaBucket, dataPath, bucket, object)
+	}
+	if err != nil {
+		if err == errFileNotFound {
+			return TrashEntry{}, TrashEntryNotFound{Bucket: bucket, Object: id}
+		}
+		return TrashEntry{}, toObjectErr(err, bucket, object)
+	}
+
+	return entry, purgeTrashEntry(objAPI, bucket, id)
+}
+
+// purgeTrashEntry - permanently deletes an object in the trash.
+func purgeTrashEntry(objAPI ObjectLayer, bucket, id string) error {
+	err := errDiskNotFound
+	purged := false
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		if err = cleanupDir(disk, minioMetaBucket, getTrashPath(bucket, id)); err != nil {
+			continue
+		}
+		purged = true
+	}
+	if purged {
+		return nil
+	}
+	return err
+}
+
+// purgeTrash - permanently deletes all objects of a bucket which were
+// deleted before olderThan.
+func purgeTrash(objAPI ObjectLayer, bucket string, olderThan time.Time) error {
+	for _, id := range listTrashIDs(objAPI, bucket) {
+		entry, err := readTrashEntry(objAPI, bucket, id)
+		if err != nil {
+			if _, ok := err.(TrashEntryNotFound); ok {
+				continue
+			}
+			return err
+		}
+		if entry.DeletedAt.After(olderThan) {
+			continue
+		}
+		if err = purgeTrashEntry(objAPI, bucket, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeExpiredTrash - purges expired trash entries of all buckets,
+// including buckets which were removed or have no trash configuration
+// anymore, whose trash is purged right away.
+func purgeExpiredTrash(objAPI ObjectLayer) {
+	buckets := make(map[string]struct{})
+	for _, disk := range getObjectLayerDisks(objAPI) {
+		if disk == nil {
+			continue
+		}
+		entries, err := disk.ListDir(minioMetaBucket, retainSlash(trashMetaPrefix))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			buckets[strings.TrimSuffix(entry, slashSeparator)] = struct{}{}
+		}
+	}
+	now := time.Now().UTC()
+	for bucket := range buckets {
+		olderThan := now
+		config, err := readBucketTrashConfig(bucket)
+		if err == nil {
+			olderThan = now.AddDate(0, 0, -config.RetentionDays)
+		} else if _, ok := err.(BucketTrashConfigNotFound); !ok {
+			errorIf(err, "Unable to read trash configuration of bucket %s.", bucket)
+			continue
+		}
+		errorIf(purgeTrash(objAPI, bucket, olderThan), "Unable to purge trash of bucket %s.", bucket)
+	}
+}
+
+// runTrashPurger - purges expired trash entries periodically until
+// doneCh is closed.
+func runTrashPurger(objAPI ObjectLayer, doneCh <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(trashPurgeInterval):
+			// Nothing is deleted while writes are rejected.
+			if serverModeAllowsWrites() {
+				purgeExpiredTrash(objAPI)
+			}
+		case <-doneCh:
+			return
+		}
+	}
+}
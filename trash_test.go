@@ -0,0 +1,132 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// Wrapper for calling testTrash for both XL and FS.
+func TestTrash(t *testing.T) {
+	ExecObjectLayerTest(t, testTrash)
+}
+
+// Tests deleted objects are moved to the trash, restored and purged.
+func testTrash(obj ObjectLayer, instanceType string, t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	bucket, object := "trash-bucket", "dir/object"
+	data := []byte("hello, trash")
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	putObject := func() {
+		if _, err = obj.PutObject(context.Background(), bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	// Objects of buckets without trash configuration are deleted.
+	putObject()
+	if err = deleteObjectOrTrash(obj, bucket, object); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	entries, err := listTrash(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("%s: Expected no trash entries, but instead found %d", instanceType, len(entries))
+	}
+
+	if err = writeBucketTrashConfig(bucket, &bucketTrashConfig{RetentionDays: 1}); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	putObject()
+	if err = deleteObjectOrTrash(obj, bucket, object); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, object); err == nil {
+		t.Fatalf("%s: Expected object to be deleted", instanceType)
+	}
+	// Parent directories left empty are removed.
+	result, err := obj.ListObjects(bucket, "", "", slashSeparator, 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Prefixes) != 0 || len(result.Objects) != 0 {
+		t.Fatalf("%s: Expected empty bucket, but instead found %v %v", instanceType, result.Prefixes, result.Objects)
+	}
+	entries, err = listTrash(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(entries) != 1 || entries[0].Object != object || entries[0].Size != int64(len(data)) {
+		t.Fatalf("%s: Unexpected trash entries %v", instanceType, entries)
+	}
+
+	if _, err = restoreTrash(obj, bucket, "../"+entries[0].ID); err == nil {
+		t.Fatalf("%s: Expected invalid id to be rejected", instanceType)
+	}
+	if _, err = restoreTrash(obj, bucket, entries[0].ID); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(context.Background(), bucket, object, 0, int64(len(data)), &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected restored data %q, but instead found %q", instanceType, data, buffer.Bytes())
+	}
+	if entries, err = listTrash(obj, bucket); err != nil || len(entries) != 0 {
+		t.Fatalf("%s: Expected empty trash, but instead found %v %v", instanceType, entries, err)
+	}
+
+	// Deleted objects are not restored over newer objects.
+	if err = deleteObjectOrTrash(obj, bucket, object); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	putObject()
+	if entries, err = listTrash(obj, bucket); err != nil || len(entries) != 1 {
+		t.Fatalf("%s: Expected a trash entry, but instead found %v %v", instanceType, entries, err)
+	}
+	_, err = restoreTrash(obj, bucket, entries[0].ID)
+	if _, ok := err.(TrashObjectExists); !ok {
+		t.Fatalf("%s: Expected %s, but instead found %v", instanceType, TrashObjectExists{Bucket: bucket, Object: object}, err)
+	}
+
+	// Entries are kept until they expire.
+	if err = purgeTrash(obj, bucket, time.Now().UTC().Add(-time.Hour)); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if entries, err = listTrash(obj, bucket); err != nil || len(entries) != 1 {
+		t.Fatalf("%s: Expected a trash entry, but instead found %v %v", instanceType, entries, err)
+	}
+	if err = purgeTrash(obj, bucket, time.Now().UTC()); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if entries, err = listTrash(obj, bucket); err != nil || len(entries) != 0 {
+		t.Fatalf("%s: Expected empty trash, but instead found %v %v", instanceType, entries, err)
+	}
+}
@@ -39,7 +39,8 @@ type treeWalkResultFS struct {
 }
 
 // treeWalk walks FS directory tree recursively pushing fileInfo into the channel as and when it encounters files.
-func (fs fsObjects) treeWalk(bucket, prefixDir, entryPrefixMatch, marker string, recursive bool, send func(treeWalkResultFS) bool, count *int, isLeaf func(string, string) bool) bool {
+// If listEmpty is set an empty prefixDir is pushed as a directory marker.
+func (fs fsObjects) treeWalk(bucket, prefixDir, entryPrefixMatch, marker string, recursive, listEmpty bool, send func(treeWalkResultFS) bool, count *int, isLeaf func(string, string) bool) bool {
 	// Example:
 	// if prefixDir="one/two/three/" and marker="four/five.txt" treeWalk is recursively
 	// called with prefixDir="one/two/three/four/" and marker="five.txt"
@@ -77,6 +78,9 @@ func (fs fsObjects) treeWalk(bucket, prefixDir, entryPrefixMatch, marker string,
 		entries = entries[1:]
 	}
 	if len(entries) == 0 {
+		if listEmpty {
+			return send(treeWalkResultFS{entry: strings.TrimSuffix(prefixDir, slashSeparator) + slashSeparator})
+		}
 		return true
 	}
 	// example:
@@ -115,7 +119,9 @@ func (fs fsObjects) treeWalk(bucket, prefixDir, entryPrefixMatch, marker string,
 			}
 			*count--
 			prefixMatch := "" // Valid only for first level treeWalk and empty for subdirectories.
-			if !fs.treeWalk(bucket, path.Join(prefixDir, entry), prefixMatch, markerArg, recursive, send, count, isLeaf) {
+			// An empty "four/" is listed unless it is the marker itself.
+			listEmpty := entry != markerDir || markerBase != ""
+			if !fs.treeWalk(bucket, path.Join(prefixDir, entry), prefixMatch, markerArg, recursive, listEmpty, send, count, isLeaf) {
 				return false
 			}
 			continue
@@ -150,6 +156,9 @@ func (fs fsObjects) startTreeWalk(bucket, prefix, marker string, recursive bool,
 		prefixDir = prefix[:lastIndex+1]
 	}
 	count := 0
+	// An empty "one/two/three/" is listed as a directory marker unless
+	// the marker is already past it.
+	listEmpty := prefixDir != "" && entryPrefixMatch == "" && marker < prefixDir
 	marker = strings.TrimPrefix(marker, prefixDir)
 	go func() {
 		defer close(ch)
@@ -166,7 +175,7 @@ func (fs fsObjects) startTreeWalk(bucket, prefix, marker string, recursive bool,
 				return false
 			}
 		}
-		fs.treeWalk(bucket, prefixDir, entryPrefixMatch, marker, recursive, send, &count, isLeaf)
+		fs.treeWalk(bucket, prefixDir, entryPrefixMatch, marker, recursive, listEmpty, send, &count, isLeaf)
 	}()
 	return &walkNotify
 }
@@ -204,3 +213,30 @@ func (fs fsObjects) lookupTreeWalk(params listParams) *treeWalkerFS {
 	}
 	return nil
 }
+
+// Lookup the goroutine reference from map, falls back to the walker of
+// the same listing saved at the closest marker before params.marker.
+// Returns the marker up to which entries have to be skipped.
+func (fs fsObjects) lookupNearestTreeWalk(params listParams) (*treeWalkerFS, string) {
+	if walker := fs.lookupTreeWalk(params); walker != nil {
+		return walker, ""
+	}
+	fs.listObjectMapMutex.Lock()
+	nearest, found := listParams{}, false
+	for p := range fs.listObjectMap {
+		if p.bucket != params.bucket || p.prefix != params.prefix || p.recursive != params.recursive {
+			continue
+		}
+		if p.marker < params.marker && (!found || p.marker > nearest.marker) {
+			nearest, found = p, true
+		}
+	}
+	fs.listObjectMapMutex.Unlock()
+	if !found {
+		return nil, ""
+	}
+	if walker := fs.lookupTreeWalk(nearest); walker != nil {
+		return walker, params.marker
+	}
+	return nil, ""
+}
@@ -27,6 +27,10 @@ const (
 	globalLookupTimeout = time.Minute * 30 // 30minutes.
 )
 
+// maxWalkSkip - maximum number of entries skipped to resume a walk
+// parked at an earlier marker, starting a new walk is cheaper beyond.
+const maxWalkSkip = 10000
+
 // listParams - list object params used for list object map
 type listParams struct {
 	bucket    string
@@ -95,6 +99,36 @@ func (t treeWalkPool) Release(params listParams) (resultCh chan treeWalkResult,
 	return nil, nil
 }
 
+// ReleaseNearest - like Release, but falls back to the walk of the same
+// listing parked at the closest marker before params.marker, so that
+// listings resuming at a marker other than the one returned by the
+// previous listing continue its walk as well. Returns the marker up to
+// which entries have to be skipped, empty if the walk was parked at
+// params.marker.
+func (t treeWalkPool) ReleaseNearest(params listParams) (resultCh chan treeWalkResult, endWalkCh chan struct{}, skipMarker string) {
+	if resultCh, endWalkCh = t.Release(params); resultCh != nil {
+		return resultCh, endWalkCh, ""
+	}
+	t.lock.Lock()
+	nearest, found := listParams{}, false
+	for p := range t.pool {
+		if p.bucket != params.bucket || p.prefix != params.prefix || p.recursive != params.recursive {
+			continue
+		}
+		if p.marker < params.marker && (!found || p.marker > nearest.marker) {
+			nearest, found = p, true
+		}
+	}
+	t.lock.Unlock()
+	if !found {
+		return nil, nil, ""
+	}
+	if resultCh, endWalkCh = t.Release(nearest); resultCh == nil {
+		return nil, nil, ""
+	}
+	return resultCh, endWalkCh, params.marker
+}
+
 // Set - adds a treeWalk to the treeWalkPool.
 // Also starts a timer go-routine that ends when:
 // 1) time.After() expires after t.timeOut seconds.
@@ -0,0 +1,131 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test if ReleaseNearest picks the walk parked at the closest earlier marker.
+func TestTreeWalkPoolReleaseNearest(t *testing.T) {
+	tw := newTreeWalkPool(time.Minute)
+	params := []listParams{
+		{bucket: "bucket", recursive: true, marker: "b", prefix: ""},
+		{bucket: "bucket", recursive: true, marker: "d", prefix: ""},
+		{bucket: "bucket", recursive: false, marker: "e", prefix: ""},
+		{bucket: "bucket", recursive: true, marker: "e", prefix: "e"},
+		{bucket: "other", recursive: true, marker: "e", prefix: ""},
+	}
+	resultChs := make(map[listParams]chan treeWalkResult)
+	for _, p := range params {
+		resultCh := make(chan treeWalkResult)
+		resultChs[p] = resultCh
+		tw.Set(p, resultCh, make(chan struct{}))
+	}
+
+	testCases := []struct {
+		params     listParams
+		released   listParams
+		skipMarker string
+		found      bool
+	}{
+		// Exact match, nothing to skip.
+		{listParams{"bucket", true, "d", ""}, params[1], "", true},
+		// The walk at "d" is gone, "b" is the closest one.
+		{listParams{"bucket", true, "f", ""}, params[0], "f", true},
+		// No walk before "a".
+		{listParams{"bucket", true, "a", ""}, listParams{}, "", false},
+		// Different prefix, recursive flag or bucket never match.
+		{listParams{"bucket", true, "z", "z"}, listParams{}, "", false},
+		{listParams{"bucket", false, "f", ""}, params[2], "f", true},
+		{listParams{"other", false, "f", ""}, listParams{}, "", false},
+	}
+	for i, testCase := range testCases {
+		resultCh, endWalkCh, skipMarker := tw.ReleaseNearest(testCase.params)
+		if !testCase.found {
+			if resultCh != nil || endWalkCh != nil {
+				t.Errorf("Test %d: expected no walk, got one", i+1)
+			}
+			continue
+		}
+		if resultCh == nil || resultCh != resultChs[testCase.released] {
+			t.Errorf("Test %d: expected the walk parked at %#v", i+1, testCase.released)
+		}
+		if skipMarker != testCase.skipMarker {
+			t.Errorf("Test %d: expected skip marker %q, got %q", i+1, testCase.skipMarker, skipMarker)
+		}
+	}
+}
+
+// Wrapper for calling testListObjectsArbitraryMarkers tests for both XL and FS.
+func TestListObjectsArbitraryMarkers(t *testing.T) {
+	ExecObjectLayerTest(t, testListObjectsArbitraryMarkers)
+}
+
+// Tests listings resuming at markers other than the returned NextMarker.
+func testListObjectsArbitraryMarkers(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var objects []string
+	for _, dir := range []string{"a/", "b/", ""} {
+		for i := 0; i < 5; i++ {
+			object := fmt.Sprintf("%sobj%d", dir, i)
+			if _, err := obj.PutObject(context.Background(), bucket, object, 1, bytes.NewReader([]byte("a")), nil); err != nil {
+				t.Fatalf("%s: %s", instanceType, err)
+			}
+			objects = append(objects, object)
+		}
+	}
+	// Park a walk at the start of the listing.
+	result, err := obj.ListObjects(bucket, "", "", "", 2)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if result.Objects[1].Name != objects[1] {
+		t.Fatalf("%s: expected %s, got %s", instanceType, objects[1], result.Objects[1].Name)
+	}
+
+	// Resume at markers ahead of the parked walk.
+	for _, marker := range []int{3, 6, 11} {
+		result, err = obj.ListObjects(bucket, "", objects[marker], "", 2)
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		if len(result.Objects) != 2 || !result.IsTruncated {
+			t.Fatalf("%s: expected 2 truncated results after %s, got %#v", instanceType, objects[marker], result)
+		}
+		for i, objInfo := range result.Objects {
+			if objInfo.Name != objects[marker+1+i] {
+				t.Errorf("%s: expected %s, got %s", instanceType, objects[marker+1+i], objInfo.Name)
+			}
+		}
+	}
+
+	// Marker past the parked walk's remaining entries ends the listing.
+	result, err = obj.ListObjects(bucket, "", objects[len(objects)-1], "", 2)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 0 || result.IsTruncated {
+		t.Errorf("%s: expected an empty final listing, got %#v", instanceType, result)
+	}
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"container/heap"
 	"sort"
 	"strings"
 )
@@ -28,30 +29,33 @@ type treeWalkResult struct {
 	end   bool
 }
 
-// listDir - lists all the entries at a given prefix, takes additional params as filter and leaf detection.
-// filter is required to filter out the listed entries usually this function is supposed to return
-// true or false.
-// isLeaf is required to differentiate between directories and objects, this is a special requirement for XL
-// backend since objects are kept as directories, the only way to know if a directory is truly an object
-// we validate if 'xl.json' exists at the leaf. isLeaf replies true/false based on the outcome of a Stat
-// operation.
-func (xl xlObjects) listDir(bucket, prefixDir string, filter func(entry string) bool, isLeaf func(string, string) bool) (entries []string, err error) {
-	for _, disk := range xl.getLoadBalancedQuorumDisks() {
-		if disk == nil {
-			continue
-		}
+// Number of entries buffered per disk during a merged tree walk, bounds
+// the memory used by a walk independent of the size of the namespace.
+const treeWalkDiskBuffer = 100
+
+// listDirFunc - lists the entries of prefixDir matching entryPrefixMatch
+// in sorted order, leaf directories are listed without trailing slash.
+type listDirFunc func(bucket, prefixDir, entryPrefixMatch string) (entries []string, err error)
+
+// listDirFactory - returns a listDirFunc listing disk. isLeaf is required
+// to differentiate between directories and objects, this is a special
+// requirement for XL backend since objects are kept as directories, the
+// only way to know if a directory is truly an object we validate if
+// 'xl.json' exists at the leaf. isLeaf replies true/false based on the
+// outcome of a Stat operation.
+func (xl xlObjects) listDirFactory(disk StorageAPI, isLeaf func(string, string) bool) listDirFunc {
+	return func(bucket, prefixDir, entryPrefixMatch string) (entries []string, err error) {
 		entries, err = disk.ListDir(bucket, prefixDir)
+		if err == errFileNotFound {
+			// Prefix might exist only on some of the disks.
+			return nil, nil
+		}
 		if err != nil {
-			// For any reason disk was deleted or goes offline, continue
-			// and list form other disks if possible.
-			if err == errDiskNotFound || err == errFaultyDisk {
-				continue
-			}
-			break
+			return nil, err
 		}
-		// Skip the entries which do not match the filter.
+		// Skip the entries which do not match the prefix.
 		for i, entry := range entries {
-			if !filter(entry) {
+			if !strings.HasPrefix(entry, entryPrefixMatch) {
 				entries[i] = ""
 				continue
 			}
@@ -66,13 +70,11 @@ func (xl xlObjects) listDir(bucket, prefixDir string, filter func(entry string)
 		}
 		return entries, nil
 	}
-
-	// Return error at the end.
-	return nil, err
 }
 
 // treeWalk walks directory tree recursively pushing fileInfo into the channel as and when it encounters files.
-func (xl xlObjects) doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker string, recursive bool, isLeaf func(string, string) bool, resultCh chan treeWalkResult, endWalkCh chan struct{}, isEnd bool) error {
+// If listEmpty is set an empty prefixDir is pushed as a directory marker.
+func (xl xlObjects) doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker string, recursive, listEmpty bool, listDir listDirFunc, resultCh chan treeWalkResult, endWalkCh chan struct{}, isEnd bool) error {
 	// Example:
 	// if prefixDir="one/two/three/" and marker="four/five.txt" treeWalk is recursively
 	// called with prefixDir="one/two/three/four/" and marker="five.txt"
@@ -87,9 +89,7 @@ func (xl xlObjects) doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker strin
 			markerBase = markerSplit[1]
 		}
 	}
-	entries, err := xl.listDir(bucket, prefixDir, func(entry string) bool {
-		return strings.HasPrefix(entry, entryPrefixMatch)
-	}, isLeaf)
+	entries, err := listDir(bucket, prefixDir, entryPrefixMatch)
 	if err != nil {
 		select {
 		case <-endWalkCh:
@@ -100,6 +100,13 @@ func (xl xlObjects) doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker strin
 	}
 	// For an empty list return right here.
 	if len(entries) == 0 {
+		if listEmpty {
+			select {
+			case <-endWalkCh:
+				return errWalkAbort
+			case resultCh <- treeWalkResult{entry: strings.TrimSuffix(prefixDir, slashSeparator) + slashSeparator, end: isEnd}:
+			}
+		}
 		return nil
 	}
 
@@ -141,7 +148,9 @@ func (xl xlObjects) doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker strin
 			// markIsEnd is passed to this entry's treeWalk() so that treeWalker.end can be marked
 			// true at the end of the treeWalk stream.
 			markIsEnd := i == len(entries)-1 && isEnd
-			if tErr := xl.doTreeWalk(bucket, pathJoin(prefixDir, entry), prefixMatch, markerArg, recursive, isLeaf, resultCh, endWalkCh, markIsEnd); tErr != nil {
+			// An empty "four/" is listed unless it is the marker itself.
+			listEmpty := entry != markerDir || markerBase != ""
+			if tErr := xl.doTreeWalk(bucket, pathJoin(prefixDir, entry), prefixMatch, markerArg, recursive, listEmpty, listDir, resultCh, endWalkCh, markIsEnd); tErr != nil {
 				return tErr
 			}
 			continue
@@ -159,7 +168,9 @@ func (xl xlObjects) doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker strin
 	return nil
 }
 
-// Initiate a new treeWalk in a goroutine.
+// Initiate a new treeWalk in a goroutine. Every disk is walked on its
+// own and the sorted per disk walks are merged, so entries missing on
+// some of the disks are still listed.
 func (xl xlObjects) startTreeWalk(bucket, prefix, marker string, recursive bool, isLeaf func(string, string) bool, endWalkCh chan struct{}) chan treeWalkResult {
 	// Example 1
 	// If prefix is "one/two/three/" and marker is "one/two/three/four/five.txt"
@@ -179,11 +190,112 @@ func (xl xlObjects) startTreeWalk(bucket, prefix, marker string, recursive bool,
 		entryPrefixMatch = prefix[lastIndex+1:]
 		prefixDir = prefix[:lastIndex+1]
 	}
+	// An empty "one/two/three/" is listed as a directory marker unless
+	// the marker is already past it.
+	listEmpty := prefixDir != "" && entryPrefixMatch == "" && marker < prefixDir
 	marker = strings.TrimPrefix(marker, prefixDir)
-	go func() {
-		isEnd := true // Indication to start walking the tree with end as true.
-		xl.doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker, recursive, isLeaf, resultCh, endWalkCh, isEnd)
-		close(resultCh)
-	}()
+
+	var diskResultChs []chan treeWalkResult
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		diskResultCh := make(chan treeWalkResult, treeWalkDiskBuffer)
+		diskResultChs = append(diskResultChs, diskResultCh)
+		go func(listDir listDirFunc) {
+			isEnd := true // Indication to start walking the tree with end as true.
+			xl.doTreeWalk(bucket, prefixDir, entryPrefixMatch, marker, recursive, listEmpty, listDir, diskResultCh, endWalkCh, isEnd)
+			close(diskResultCh)
+		}(xl.listDirFactory(disk, isLeaf))
+	}
+	go mergeTreeWalks(diskResultChs, resultCh, endWalkCh)
 	return resultCh
 }
+
+// treeWalkHead - next entry of a per disk tree walk.
+type treeWalkHead struct {
+	entry string
+	index int // Index of the per disk tree walk.
+}
+
+// treeWalkHeap - min heap of the next entries of all per disk tree walks.
+type treeWalkHeap []treeWalkHead
+
+func (h treeWalkHeap) Len() int            { return len(h) }
+func (h treeWalkHeap) Less(i, j int) bool  { return h[i].entry < h[j].entry }
+func (h treeWalkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *treeWalkHeap) Push(x interface{}) { *h = append(*h, x.(treeWalkHead)) }
+func (h *treeWalkHeap) Pop() interface{} {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
+
+// mergeTreeWalks - merges sorted per disk tree walks into resultCh with
+// a k-way merge, every entry is sent once. Walks which fail, e.g. as
+// their disk went offline, are left out. An error is only sent if all
+// walks failed. The last entry is marked as end.
+func mergeTreeWalks(walkChs []chan treeWalkResult, resultCh chan treeWalkResult, endWalkCh chan struct{}) {
+	defer close(resultCh)
+
+	h := &treeWalkHeap{}
+	var firstErr error
+	succeeded := false
+	// next - pushes the next entry of walk index, records how it ended.
+	next := func(index int) {
+		result, ok := <-walkChs[index]
+		if !ok {
+			succeeded = true
+			return
+		}
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			// Drain the failed walk, it ends after the error.
+			for range walkChs[index] {
+			}
+			return
+		}
+		heap.Push(h, treeWalkHead{result.entry, index})
+	}
+	for index := range walkChs {
+		next(index)
+	}
+
+	// Entries are sent once the following entry is known, to mark the
+	// last one as end.
+	var pending string
+	for h.Len() > 0 {
+		head := heap.Pop(h).(treeWalkHead)
+		next(head.index)
+		if head.entry == pending {
+			continue
+		}
+		if pending != "" {
+			select {
+			case <-endWalkCh:
+				return
+			case resultCh <- treeWalkResult{entry: pending}:
+			}
+		}
+		pending = head.entry
+	}
+	if pending != "" {
+		select {
+		case <-endWalkCh:
+		case resultCh <- treeWalkResult{entry: pending, end: true}:
+		}
+		return
+	}
+	if !succeeded {
+		if firstErr == nil {
+			firstErr = errDiskNotFound
+		}
+		select {
+		case <-endWalkCh:
+		case resultCh <- treeWalkResult{err: firstErr}:
+		}
+	}
+}
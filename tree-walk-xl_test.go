@@ -0,0 +1,152 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tests sorted per disk walks are merged into a single sorted walk.
+func TestMergeTreeWalks(t *testing.T) {
+	testCases := []struct {
+		walks   [][]treeWalkResult
+		entries []string
+		err     error
+	}{
+		// Test case - 1.
+		// Entries common to all walks are sent once.
+		{
+			walks: [][]treeWalkResult{
+				{{entry: "a"}, {entry: "c"}, {entry: "d/"}},
+				{{entry: "a"}, {entry: "b"}, {entry: "d/"}},
+				{{entry: "c"}, {entry: "e"}},
+			},
+			entries: []string{"a", "b", "c", "d/", "e"},
+		},
+		// Test case - 2.
+		// Failed walks are left out.
+		{
+			walks: [][]treeWalkResult{
+				{{entry: "a"}, {err: errFaultyDisk}},
+				{{entry: "b"}},
+			},
+			entries: []string{"a", "b"},
+		},
+		// Test case - 3.
+		// Error is sent only if all walks failed.
+		{
+			walks: [][]treeWalkResult{
+				{{err: errVolumeNotFound}},
+				{{err: errFaultyDisk}},
+			},
+			err: errVolumeNotFound,
+		},
+		// Test case - 4.
+		// Empty walks.
+		{
+			walks: [][]treeWalkResult{{}, {}},
+		},
+	}
+	for i, testCase := range testCases {
+		var walkChs []chan treeWalkResult
+		for _, walk := range testCase.walks {
+			walkCh := make(chan treeWalkResult, len(walk))
+			for _, result := range walk {
+				walkCh <- result
+			}
+			close(walkCh)
+			walkChs = append(walkChs, walkCh)
+		}
+		resultCh := make(chan treeWalkResult)
+		go mergeTreeWalks(walkChs, resultCh, make(chan struct{}))
+
+		var entries []string
+		var err error
+		var end bool
+		for result := range resultCh {
+			if result.err != nil {
+				err = result.err
+				continue
+			}
+			entries = append(entries, result.entry)
+			end = result.end
+		}
+		if err != testCase.err {
+			t.Errorf("Test %d: Expected error %v, but instead found %v", i+1, testCase.err, err)
+		}
+		if !reflect.DeepEqual(entries, testCase.entries) {
+			t.Errorf("Test %d: Expected entries %v, but instead found %v", i+1, testCase.entries, entries)
+		}
+		if len(entries) > 0 && !end {
+			t.Errorf("Test %d: Expected last entry to be marked as end", i+1)
+		}
+	}
+}
+
+// Tests objects missing on some of the disks are still walked.
+func TestTreeWalkMissingOnDisks(t *testing.T) {
+	objLayer, disks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	bucket := "bucket"
+	if err = objLayer.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{"a", "b", "c/d", "e"}
+	for _, object := range objects {
+		if _, err = objLayer.PutObject(context.Background(), bucket, object, 5, bytes.NewReader([]byte("hello")), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Remove objects from a few of the disks each.
+	for i, disk := range disks[:6] {
+		object := "a"
+		if i >= 3 {
+			object = "e"
+		}
+		if err = os.RemoveAll(filepath.Join(disk, bucket, object)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	xl := objLayer.(xlObjects)
+	// Offline disks are left out of the walk.
+	xl.storageDisks[len(xl.storageDisks)-1] = nil
+
+	var entries []string
+	var end bool
+	for result := range xl.startTreeWalk(bucket, "", "", true, xl.isObject, make(chan struct{})) {
+		if result.err != nil {
+			t.Fatal(result.err)
+		}
+		entries = append(entries, result.entry)
+		end = result.end
+	}
+	if !reflect.DeepEqual(entries, objects) {
+		t.Fatalf("Expected entries %v, but instead found %v", objects, entries)
+	}
+	if !end {
+		t.Fatal("Expected last entry to be marked as end")
+	}
+}
@@ -27,5 +27,8 @@ var errInvalidArgument = errors.New("Invalid arguments specified")
 // errSignatureMismatch means signature did not match.
 var errSignatureMismatch = errors.New("Signature does not match")
 
+// errContentSHA256Mismatch means payload did not match its signed checksum.
+var errContentSHA256Mismatch = errors.New("Payload checksum does not match")
+
 // used when token used for authentication by the MinioBrowser has expired
 var errInvalidToken = errors.New("Invalid token")
@@ -0,0 +1,124 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Delay before an updated server restarts, so that the reply to the
+// update request reaches the caller first.
+var updateRestartDelay = time.Second
+
+// restartAfterUpdate - restarts the server into the updated binary.
+func restartAfterUpdate() {
+	time.AfterFunc(updateRestartDelay, func() {
+		sendServiceSignal(serviceRestart)
+	})
+}
+
+// nodeUpdateResult - result of updating a node.
+type nodeUpdateResult struct {
+	Addr    string `json:"addr"`
+	Version string `json:"version,omitempty"`
+	Latest  string `json:"latest,omitempty"`
+	// True if the binary was replaced, the node restarts.
+	Updated bool   `json:"updated"`
+	Error   string `json:"error,omitempty"`
+}
+
+// updateLocalNode - updates this node, reported as addr, to the latest
+// release. The node restarts once the binary was replaced.
+func updateLocalNode(experimental bool, addr string) nodeUpdateResult {
+	result := nodeUpdateResult{Addr: addr}
+	updateMsg, err := updateBinary(getUpdateURL(experimental))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Version, result.Latest, result.Updated = updateMsg.Version, updateMsg.Latest, updateMsg.Applied
+	return result
+}
+
+// updateNode - updates the node at addr to the latest release through
+// its admin API, the request is signed with the server credentials.
+func updateNode(addr string, experimental bool) (nodeUpdateResult, error) {
+	scheme := "http"
+	if isSSL() {
+		scheme = "https"
+	}
+	query := make(url.Values)
+	query.Set("local", "true")
+	if experimental {
+		query.Set("experimental", "true")
+	}
+	req, err := http.NewRequest("POST", scheme+"://"+addr+adminAPIPathPrefix+"/service/update?"+query.Encode(), nil)
+	if err != nil {
+		return nodeUpdateResult{}, err
+	}
+	signV4(req, serverConfig.GetCredential(), serverConfig.GetRegion(), time.Now().UTC())
+	client := &http.Client{Timeout: updateTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nodeUpdateResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nodeUpdateResult{}, errors.New("unexpected response: " + resp.Status)
+	}
+	var result nodeUpdateResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// updateNodes - updates this node, reported as localAddr, and the nodes
+// of all network disks to the latest release. Updated nodes restart,
+// nodes already running the latest release are left alone.
+func updateNodes(experimental bool, localAddr string) []nodeUpdateResult {
+	endpoints := listRPCEndpoints()
+	results := make([]nodeUpdateResult, len(endpoints)+1)
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint *rpcEndpoint) {
+			defer wg.Done()
+			result, err := updateNode(endpoint.addr, experimental)
+			if err != nil {
+				result.Error = err.Error()
+			}
+			// Nodes report the address they were reached at.
+			result.Addr = endpoint.addr
+			results[i] = result
+		}(i, endpoint)
+	}
+
+	results[len(endpoints)] = updateLocalNode(experimental, localAddr)
+	wg.Wait()
+	if results[len(endpoints)].Updated {
+		restartAfterUpdate()
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Addr < results[j].Addr
+	})
+	return results
+}
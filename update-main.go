@@ -17,10 +17,15 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -39,7 +44,15 @@ var (
 		},
 		cli.BoolFlag{
 			Name:  "experimental, E",
-			Usage: "Check experimental update.",
+			Usage: "Update to the experimental release.",
+		},
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "Only check for an update, do not install it.",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the result in JSON format.",
 		},
 	}
 )
@@ -47,7 +60,7 @@ var (
 // Check for new software updates.
 var updateCmd = cli.Command{
 	Name:   "update",
-	Usage:  "Check for a new software update.",
+	Usage:  "Update to the latest release.",
 	Action: mainUpdate,
 	Flags:  updateFlags,
 	CustomHelpTemplate: `Name:
@@ -56,15 +69,23 @@ var updateCmd = cli.Command{
 USAGE:
    minio {{.Name}} [FLAGS]
 
+DESCRIPTION:
+  Downloads the latest release, verifies its SHA-256 checksum and
+  replaces the running binary. Restart the server to run it, all nodes
+  of a deployment are updated and restarted by the admin API.
+
 FLAGS:
   {{range .Flags}}{{.}}
   {{end}}
 EXAMPLES:
-   1. Check for any new official release.
+   1. Update to the latest official release.
       $ minio {{.Name}}
 
-   2. Check for any new experimental release.
-      $ minio {{.Name}} --experimental
+   2. Check for any new official release.
+      $ minio {{.Name}} --check
+
+   3. Update to the latest experimental release, print the result in JSON.
+      $ minio {{.Name}} --experimental --json
 `,
 }
 
@@ -74,16 +95,41 @@ const (
 	minioUpdateExperimentalURL = "https://dl.minio.io/server/minio/experimental/"
 )
 
+const (
+	// Update notices on start must not delay it.
+	updateNoticeTimeout = 1 * time.Millisecond
+
+	// Maximum time to check for and download an update.
+	updateTimeout = 10 * time.Minute
+)
+
+var (
+	// errUpdateNotSupported - binary was not built as a release.
+	errUpdateNotSupported = errors.New("Updates are not supported for custom builds, download official releases from https://minio.io/#minio")
+
+	// errUpdateChecksumMismatch - downloaded binary is corrupted.
+	errUpdateChecksumMismatch = errors.New("Checksum of the downloaded release does not match")
+)
+
 // updateMessage container to hold update messages.
 type updateMessage struct {
 	Status   string `json:"status"`
 	Update   bool   `json:"update"`
 	Download string `json:"downloadURL"`
 	Version  string `json:"version"`
+	// Latest release and the SHA-256 checksum of its binary.
+	Latest   string `json:"latest,omitempty"`
+	Checksum string `json:"sha256,omitempty"`
+	// True if the running binary was replaced by the latest release.
+	Applied bool `json:"applied"`
 }
 
 // String colorized update message.
 func (u updateMessage) String() string {
+	if u.Applied {
+		updateMessage := color.New(color.FgGreen, color.Bold).SprintfFunc()
+		return updateMessage("Updated ‘minio’ to %s, restart the server to run it.", u.Latest)
+	}
 	if !u.Update {
 		updateMessage := color.New(color.FgGreen, color.Bold).SprintfFunc()
 		return updateMessage("You are already running the most recent version of ‘minio’.")
@@ -132,10 +178,20 @@ func parseReleaseData(data string) (time.Time, error) {
 	return parsedDate, nil
 }
 
-// verify updates for releases.
-func getReleaseUpdate(updateURL string, noError bool) updateMessage {
+// getUpdateURL - returns the URL of the official or experimental
+// releases.
+func getUpdateURL(experimental bool) string {
+	if experimental {
+		return minioUpdateExperimentalURL
+	}
+	return minioUpdateStableURL
+}
+
+// checkReleaseUpdate - returns the latest release at updateURL and
+// whether it is newer than the running binary.
+func checkReleaseUpdate(updateURL string, timeout time.Duration) (updateMessage, error) {
 	// Construct a new update url.
-	newUpdateURLPrefix := updateURL + "/" + runtime.GOOS + "-" + runtime.GOARCH
+	newUpdateURLPrefix := strings.TrimSuffix(updateURL, "/") + "/" + runtime.GOOS + "-" + runtime.GOARCH
 	newUpdateURL := newUpdateURLPrefix + "/minio.shasum"
 
 	// Get the downloadURL.
@@ -155,86 +211,151 @@ func getReleaseUpdate(updateURL string, noError bool) updateMessage {
 		Version:  minioVersion,
 	}
 
-	// Instantiate a new client with 1 sec timeout.
-	client := &http.Client{
-		Timeout: 1 * time.Millisecond,
+	// Releases are named by their time, custom builds cannot be
+	// compared with them.
+	current, err := time.Parse(time.RFC3339, minioVersion)
+	if err != nil || current.IsZero() {
+		return updateMsg, errUpdateNotSupported
 	}
 
 	// Fetch new update.
+	client := &http.Client{
+		Timeout: timeout,
+	}
 	data, err := client.Get(newUpdateURL)
-	if err != nil && noError {
-		return updateMsg
+	if err != nil {
+		return updateMsg, err
+	}
+	defer data.Body.Close()
+	if data.StatusCode != http.StatusOK {
+		return updateMsg, errors.New("Failed to retrieve update notice, " + data.Status)
+	}
+	updateBody, err := ioutil.ReadAll(io.LimitReader(data.Body, 4096))
+	if err != nil {
+		return updateMsg, err
 	}
-	fatalIf((err), "Unable to read from update URL ‘"+newUpdateURL+"’.")
 
-	// Error out if 'update' command is issued for development based builds.
-	if minioVersion == "DEVELOPMENT.GOGET" && !noError {
-		fatalIf((errors.New("")),
-			"Update mechanism is not supported for ‘go get’ based binary builds. Please download official releases from https://minio.io/#minio")
+	// The notice holds the checksum and the name of the release.
+	latest, err := parseReleaseData(string(updateBody))
+	if err != nil {
+		return updateMsg, err
 	}
+	fields := strings.Fields(string(updateBody))
+	if checksum, err := hex.DecodeString(fields[0]); err != nil || len(checksum) != sha256.Size {
+		return updateMsg, errors.New("Update data malformed, invalid SHA-256 checksum")
+	}
+	updateMsg.Checksum = fields[0]
+	updateMsg.Latest = fields[1]
 
-	// Parse current minio version into RFC3339.
-	current, err := time.Parse(time.RFC3339, minioVersion)
-	if err != nil && noError {
-		return updateMsg
+	// Is the update latest?.
+	updateMsg.Update = latest.After(current)
+	return updateMsg, nil
+}
+
+// verify updates for releases.
+func getReleaseUpdate(updateURL string, noError bool) updateMessage {
+	updateMsg, err := checkReleaseUpdate(updateURL, updateNoticeTimeout)
+	if err != nil && !noError {
+		fatalIf(err, "Unable to check for updates.")
 	}
-	fatalIf((err), "Unable to parse version string as time.")
+	return updateMsg
+}
 
-	// Verify if current minio version is zero.
-	if current.IsZero() && !noError {
-		fatalIf((errors.New("")),
-			"Updates mechanism is not supported for custom builds. Please download official releases from https://minio.io/#minio")
+// downloadUpdate - downloads the release of updateMsg and verifies its
+// checksum.
+func downloadUpdate(updateMsg updateMessage, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{
+		Timeout: timeout,
 	}
+	resp, err := client.Get(updateMsg.Download)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Failed to download update, " + resp.Status)
+	}
+	binary, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(binary)
+	if hex.EncodeToString(sum[:]) != strings.ToLower(updateMsg.Checksum) {
+		return nil, errUpdateChecksumMismatch
+	}
+	return binary, nil
+}
 
-	// Verify if we have a valid http response i.e http.StatusOK.
-	if data != nil {
-		if data.StatusCode != http.StatusOK {
-			// Return quickly if noError is set.
-			if noError {
-				return updateMsg
-			}
-			fatalIf((errors.New("")), "Failed to retrieve update notice. "+data.Status)
+// applyUpdate - replaces the binary at path, the new binary is written
+// next to it first so that the binary is never left half written.
+func applyUpdate(path string, binary []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), ".minio-update-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err = tmpFile.Write(binary); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpFile.Name(), 0755); err != nil {
+		return err
+	}
+	// Running binaries cannot be replaced on windows, only renamed.
+	if runtime.GOOS == "windows" {
+		oldPath := path + ".old"
+		os.Remove(oldPath)
+		if err = os.Rename(path, oldPath); err != nil {
+			return err
 		}
 	}
+	return os.Rename(tmpFile.Name(), path)
+}
 
-	// Read the response body.
-	updateBody, err := ioutil.ReadAll(data.Body)
-	if err != nil && noError {
-		return updateMsg
+// updateBinary - replaces the running binary by the latest release at
+// updateURL if it is newer.
+func updateBinary(updateURL string) (updateMessage, error) {
+	updateMsg, err := checkReleaseUpdate(updateURL, updateTimeout)
+	if err != nil || !updateMsg.Update {
+		return updateMsg, err
 	}
-	fatalIf((err), "Failed to retrieve update notice. Please try again later.")
-
-	// Parse the date if its valid.
-	latest, err := parseReleaseData(string(updateBody))
-	if err != nil && noError {
-		return updateMsg
+	binary, err := downloadUpdate(updateMsg, updateTimeout)
+	if err != nil {
+		return updateMsg, err
 	}
-	errMsg := "Failed to retrieve update notice. Please try again later. Please report this issue at https://github.com/minio/minio/issues"
-	fatalIf(err, errMsg)
-
-	// Verify if the date is not zero.
-	if latest.IsZero() && !noError {
-		fatalIf((errors.New("")), errMsg)
+	path, err := os.Executable()
+	if err != nil {
+		return updateMsg, err
 	}
-
-	// Is the update latest?.
-	if latest.After(current) {
-		updateMsg.Update = true
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return updateMsg, err
 	}
-
-	// Return update message.
-	return updateMsg
+	if err = applyUpdate(path, binary); err != nil {
+		return updateMsg, err
+	}
+	updateMsg.Applied = true
+	return updateMsg, nil
 }
 
 // main entry point for update command.
 func mainUpdate(ctx *cli.Context) {
-	// Print all errors as they occur.
-	noError := false
+	updateURL := getUpdateURL(ctx.Bool("experimental"))
 
-	// Check for update.
-	if ctx.Bool("experimental") {
-		console.Println(getReleaseUpdate(minioUpdateExperimentalURL, noError))
+	var updateMsg updateMessage
+	var err error
+	if ctx.Bool("check") {
+		updateMsg, err = checkReleaseUpdate(updateURL, updateTimeout)
 	} else {
-		console.Println(getReleaseUpdate(minioUpdateStableURL, noError))
+		updateMsg, err = updateBinary(updateURL)
+	}
+	fatalIf(err, "Unable to update ‘minio’.")
+
+	if ctx.Bool("json") {
+		console.Println(updateMsg.JSON())
+	} else if !ctx.Bool("quiet") && !ctx.GlobalBool("quiet") {
+		console.Println(updateMsg)
 	}
 }
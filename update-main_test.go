@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Tests a newer release is found, verified and replaces a binary.
+func TestUpdateBinary(t *testing.T) {
+	defer func(version string) { minioVersion = version }(minioVersion)
+
+	release := []byte("new minio binary")
+	sum := sha256.Sum256(release)
+	checksum := hex.EncodeToString(sum[:])
+	prefix := "/" + runtime.GOOS + "-" + runtime.GOARCH
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/minio.shasum", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(checksum + " minio.RELEASE.2017-01-01T00-00-00Z\n"))
+	})
+	mux.HandleFunc(prefix+"/minio", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(release)
+	})
+	mux.HandleFunc(prefix+"/minio.exe", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(release)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Custom builds cannot be updated.
+	minioVersion = "DEVELOPMENT.GOGET"
+	if _, err := checkReleaseUpdate(server.URL, updateTimeout); err != errUpdateNotSupported {
+		t.Fatalf("Expected %v, got %v", errUpdateNotSupported, err)
+	}
+
+	minioVersion = "2017-01-01T00:00:00Z"
+	updateMsg, err := checkReleaseUpdate(server.URL, updateTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updateMsg.Update {
+		t.Fatal("Expected the running release to be the latest")
+	}
+
+	minioVersion = "2016-01-01T00:00:00Z"
+	if updateMsg, err = checkReleaseUpdate(server.URL, updateTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if !updateMsg.Update || updateMsg.Checksum != checksum || updateMsg.Latest != "minio.RELEASE.2017-01-01T00-00-00Z" {
+		t.Fatalf("Unexpected update %#v", updateMsg)
+	}
+	binary, err := downloadUpdate(updateMsg, updateTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := updateMsg
+	corrupted.Checksum = hex.EncodeToString(make([]byte, sha256.Size))
+	if _, err = downloadUpdate(corrupted, updateTimeout); err != errUpdateChecksumMismatch {
+		t.Fatalf("Expected %v, got %v", errUpdateChecksumMismatch, err)
+	}
+
+	dir, err := ioutil.TempDir("", "minio-update-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+	path := filepath.Join(dir, "minio")
+	if err = ioutil.WriteFile(path, []byte("old minio binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = applyUpdate(path, binary); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || !bytes.Equal(data, release) {
+		t.Fatalf("Expected the binary to be replaced, got %q %v", data, err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0755 {
+		t.Fatalf("Expected an executable binary, got %v", err)
+	}
+	// Only the binary is left.
+	if entries, err := ioutil.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Fatalf("Expected temporary files to be removed, got %d entries %v", len(entries), err)
+	}
+}
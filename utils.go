@@ -17,8 +17,10 @@
 package main
 
 import (
+	"crypto/md5"
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"io"
 	"strings"
 )
@@ -29,36 +31,33 @@ func xmlDecoder(body io.Reader, v interface{}) error {
 	return d.Decode(v)
 }
 
+// errInvalidMD5Size - md5 is not 128 bits long.
+var errInvalidMD5Size = errors.New("Invalid md5 size")
+
 // checkValidMD5 - verify if valid md5, returns md5 in bytes.
-func checkValidMD5(md5 string) ([]byte, error) {
-	return base64.StdEncoding.DecodeString(strings.TrimSpace(md5))
+func checkValidMD5(md5Base64 string) ([]byte, error) {
+	md5Bytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(md5Base64))
+	if err != nil {
+		return nil, err
+	}
+	// Empty md5 is not verified.
+	if len(md5Bytes) != 0 && len(md5Bytes) != md5.Size {
+		return nil, errInvalidMD5Size
+	}
+	return md5Bytes, nil
 }
 
 /// http://docs.aws.amazon.com/AmazonS3/latest/dev/UploadingObjects.html
 const (
-	// maximum object size per PUT request is 5GiB
-	maxObjectSize = 1024 * 1024 * 1024 * 5
 	// minimum Part size for multipart upload is 5MB
 	minPartSize = 1024 * 1024 * 5
-	// maximum Part ID for multipart upload is 10000 (Acceptable values range from 1 to 10000 inclusive)
-	maxPartID = 10000
 )
 
-// isMaxObjectSize - verify if max object size
-func isMaxObjectSize(size int64) bool {
-	return size > maxObjectSize
-}
-
 // Check if part size is more than or equal to minimum allowed size.
 func isMinAllowedPartSize(size int64) bool {
 	return size >= minPartSize
 }
 
-// isMaxPartNumber - Check if part ID is greater than the maximum allowed ID.
-func isMaxPartID(partID int) bool {
-	return partID > maxPartID
-}
-
 func contains(stringList []string, element string) bool {
 	for _, e := range stringList {
 		if e == element {
@@ -0,0 +1,55 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests validate parsing of Content-Md5 values.
+func TestCheckValidMD5(t *testing.T) {
+	testCases := []struct {
+		md5        string
+		md5Size    int
+		shouldPass bool
+	}{
+		// Test case - 1.
+		// Missing md5 is not verified.
+		{"", 0, true},
+		// Test case - 2.
+		{"1B2M2Y8AsgTpgAmY7PhCfg==", 16, true},
+		// Test case - 3.
+		// Surrounding spaces are ignored.
+		{" 1B2M2Y8AsgTpgAmY7PhCfg== ", 16, true},
+		// Test case - 4.
+		// Not base64 encoded.
+		{"d41d8cd98f00b204e9800998ecf8427e", 0, false},
+		// Test case - 5.
+		// Not 128 bits long.
+		{"YWJj", 0, false},
+	}
+	for i, testCase := range testCases {
+		md5Bytes, err := checkValidMD5(testCase.md5)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+		if len(md5Bytes) != testCase.md5Size {
+			t.Errorf("Test %d: Expected md5 of %d bytes, but instead found %d", i+1, testCase.md5Size, len(md5Bytes))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+)
+
+// Backup products like Veeam probe the capabilities and the capacity of
+// a bucket by reading the objects of the Smart Object Storage API
+// (SOSAPI), which the server answers for every bucket.
+const (
+	veeamSOSAPIFolder   = ".system-d26a9498-cb7c-4a87-a44a-8ae204f5ba6c/"
+	veeamSystemObject   = veeamSOSAPIFolder + "system.xml"
+	veeamCapacityObject = veeamSOSAPIFolder + "capacity.xml"
+
+	// Block size recommended to backup products, larger blocks mean
+	// fewer objects and less metadata per backed up byte.
+	veeamKbBlockSize = 4096
+)
+
+// veeamProtocolCapabilities - SOSAPI features supported.
+type veeamProtocolCapabilities struct {
+	CapacityInfo   bool `xml:"CapacityInfo"`
+	UploadSessions bool `xml:"UploadSessions"`
+	IAMSTS         bool `xml:"IAMSTS"`
+}
+
+// veeamSystemRecommendations - SOSAPI recommendations to clients.
+type veeamSystemRecommendations struct {
+	KbBlockSize int `xml:"KbBlockSize"`
+}
+
+// veeamSystemInfo - content of the system.xml SOSAPI object.
+type veeamSystemInfo struct {
+	XMLName               xml.Name                   `xml:"SystemInfo"`
+	ProtocolVersion       string                     `xml:"ProtocolVersion"`
+	ModelName             string                     `xml:"ModelName"`
+	ProtocolCapabilities  veeamProtocolCapabilities  `xml:"ProtocolCapabilities"`
+	SystemRecommendations veeamSystemRecommendations `xml:"SystemRecommendations"`
+}
+
+// veeamCapacityInfo - content of the capacity.xml SOSAPI object, in
+// bytes.
+type veeamCapacityInfo struct {
+	XMLName   xml.Name `xml:"CapacityInfo"`
+	Capacity  int64    `xml:"Capacity"`
+	Available int64    `xml:"Available"`
+	Used      int64    `xml:"Used"`
+}
+
+// getUsableStorageInfo - returns the storage of objAPI available to
+// object data, erasure coded backends keep half of their raw capacity
+// for parity.
+func getUsableStorageInfo(objAPI ObjectLayer) StorageInfo {
+	switch obj := objAPI.(type) {
+	case *xlSets:
+		var info StorageInfo
+		for _, set := range obj.sets {
+			setInfo := getUsableStorageInfo(set)
+			info.Total += setInfo.Total
+			info.Free += setInfo.Free
+		}
+		return info
+	case xlObjects:
+		info := obj.StorageInfo()
+		disks := int64(len(obj.storageDisks))
+		return StorageInfo{
+			Total: info.Total / disks * int64(obj.dataBlocks),
+			Free:  info.Free / disks * int64(obj.dataBlocks),
+		}
+	}
+	return objAPI.StorageInfo()
+}
+
+// getVeeamSOSAPIObject - returns the content of the SOSAPI object, false
+// if object is no SOSAPI object.
+func getVeeamSOSAPIObject(objAPI ObjectLayer, object string) ([]byte, bool) {
+	switch object {
+	case veeamSystemObject:
+		return encodeResponse(veeamSystemInfo{
+			ProtocolVersion: `"1.0"`,
+			ModelName:       `"Minio ` + minioReleaseTag + `"`,
+			ProtocolCapabilities: veeamProtocolCapabilities{
+				CapacityInfo: true,
+			},
+			SystemRecommendations: veeamSystemRecommendations{
+				KbBlockSize: veeamKbBlockSize,
+			},
+		}), true
+	case veeamCapacityObject:
+		info := getUsableStorageInfo(objAPI)
+		return encodeResponse(veeamCapacityInfo{
+			Capacity:  info.Total,
+			Available: info.Free,
+			Used:      info.Total - info.Free,
+		}), true
+	}
+	return nil, false
+}
+
+// serveVeeamSOSAPIObject - answers GET and HEAD requests of the SOSAPI
+// objects of bucket, returns false if object is no SOSAPI object.
+func serveVeeamSOSAPIObject(w http.ResponseWriter, r *http.Request, objAPI ObjectLayer, bucket, object string) bool {
+	data, ok := getVeeamSOSAPIObject(objAPI, object)
+	if !ok {
+		return false
+	}
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIfRequest(r, err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return true
+	}
+	md5Sum := md5.Sum(data)
+	setCommonHeaders(w)
+	w.Header().Set("ETag", "\""+hex.EncodeToString(md5Sum[:])+"\"")
+	w.Header().Set("Content-Type", "application/xml")
+	http.ServeContent(w, r, object, globalBootTime, bytes.NewReader(data))
+	return true
+}
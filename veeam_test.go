@@ -0,0 +1,84 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the SOSAPI objects report the usable capacity.
+func TestVeeamSOSAPIObjects(t *testing.T) {
+	objAPI, disks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	if _, ok := getVeeamSOSAPIObject(objAPI, "object"); ok {
+		t.Fatal("Expected object not to be a SOSAPI object")
+	}
+	data, ok := getVeeamSOSAPIObject(objAPI, veeamSystemObject)
+	if !ok {
+		t.Fatal("Expected system.xml to be a SOSAPI object")
+	}
+	var systemInfo veeamSystemInfo
+	if err = xml.Unmarshal(data, &systemInfo); err != nil {
+		t.Fatal(err)
+	}
+	if !systemInfo.ProtocolCapabilities.CapacityInfo || systemInfo.SystemRecommendations.KbBlockSize != veeamKbBlockSize {
+		t.Errorf("Unexpected system info %+v", systemInfo)
+	}
+
+	if data, ok = getVeeamSOSAPIObject(objAPI, veeamCapacityObject); !ok {
+		t.Fatal("Expected capacity.xml to be a SOSAPI object")
+	}
+	var capacityInfo veeamCapacityInfo
+	if err = xml.Unmarshal(data, &capacityInfo); err != nil {
+		t.Fatal(err)
+	}
+	// Half of the disks of the XL setup keep parity.
+	rawInfo := objAPI.StorageInfo()
+	if capacityInfo.Capacity <= 0 || capacityInfo.Capacity > rawInfo.Total/2 {
+		t.Errorf("Expected a capacity of at most %d, got %d", rawInfo.Total/2, capacityInfo.Capacity)
+	}
+	if capacityInfo.Used != capacityInfo.Capacity-capacityInfo.Available {
+		t.Errorf("Unexpected capacity info %+v", capacityInfo)
+	}
+
+	// SOSAPI objects are only answered for existing buckets.
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/bucket/"+veeamSystemObject, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !serveVeeamSOSAPIObject(w, r, objAPI, "bucket", veeamSystemObject) || w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	if !serveVeeamSOSAPIObject(w, r, objAPI, "bucket", veeamSystemObject) || w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/xml" {
+		t.Errorf("Expected an XML object, got %s", w.Header().Get("Content-Type"))
+	}
+}
@@ -37,18 +37,22 @@ import (
 // valid JWT authenticated request.
 func isJWTReqAuthenticated(req *http.Request) bool {
 	jwt := initJWT()
-	token, e := jwtgo.ParseFromRequest(req, func(token *jwtgo.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwt.SecretAccessKey), nil
-	})
+	token, e := jwtgo.ParseFromRequest(req, jwt.keyFunc)
 	if e != nil {
 		return false
 	}
-	return token.Valid
+	return jwt.isValidToken(token)
+}
+
+// isReservedBucket - the bucket the browser is served from is not
+// accessible like any other bucket, same as for S3 requests.
+func isReservedBucket(bucket string) bool {
+	return bucket == path.Base(reservedBucket)
 }
 
+// errReservedBucket - error for requests on the reserved bucket.
+var errReservedBucket = &json2.Error{Message: getAPIError(ErrAllAccessDisabled).Description}
+
 // WebGenericArgs - empty struct for calls that don't accept arguments
 // for ex. ServerInfo, GenerateAuth
 type WebGenericArgs struct{}
@@ -123,8 +127,18 @@ func (web *webAPIHandlers) MakeBucket(r *http.Request, args *MakeBucketArgs, rep
 	if !isJWTReqAuthenticated(r) {
 		return &json2.Error{Message: "Unauthorized request"}
 	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
 	reply.UIVersion = miniobrowser.UIVersion
-	if err := web.ObjectAPI.MakeBucket(args.BucketName); err != nil {
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		return &json2.Error{Message: getAPIError(s3Error).Description}
+	}
+	if err := makeFederatedBucket(web.ObjectAPI, args.BucketName); err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	// Bucket is created in the server region.
+	if err := writeBucketLocation(args.BucketName, serverConfig.GetRegion()); err != nil {
 		return &json2.Error{Message: err.Error()}
 	}
 	return nil
@@ -196,12 +210,17 @@ func (web *webAPIHandlers) ListObjects(r *http.Request, args *ListObjectsArgs, r
 	if !isJWTReqAuthenticated(r) {
 		return &json2.Error{Message: "Unauthorized request"}
 	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
 	for {
 		lo, err := web.ObjectAPI.ListObjects(args.BucketName, args.Prefix, marker, "/", 1000)
 		if err != nil {
 			return &json2.Error{Message: err.Error()}
 		}
 		marker = lo.NextMarker
+		// Report plain sizes of encrypted objects.
+		setListedObjectSizes(args.BucketName, lo.Objects)
 		for _, obj := range lo.Objects {
 			reply.Objects = append(reply.Objects, WebObjectInfo{
 				Key:          obj.Name,
@@ -234,10 +253,289 @@ func (web *webAPIHandlers) RemoveObject(r *http.Request, args *RemoveObjectArgs,
 	if !isJWTReqAuthenticated(r) {
 		return &json2.Error{Message: "Unauthorized request"}
 	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
 	reply.UIVersion = miniobrowser.UIVersion
-	if err := web.ObjectAPI.DeleteObject(args.BucketName, args.ObjectName); err != nil {
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		return &json2.Error{Message: getAPIError(s3Error).Description}
+	}
+	if s3Error := enforceObjectLock(web.ObjectAPI, args.BucketName, args.ObjectName, r); s3Error != ErrNone {
+		return &json2.Error{Message: getAPIError(s3Error).Description}
+	}
+	if err := deleteObjectOrTrash(web.ObjectAPI, args.BucketName, args.ObjectName); err != nil {
 		return &json2.Error{Message: err.Error()}
 	}
+	// Object is gone, remove any expired retention left behind.
+	removeObjectLockInfo(web.ObjectAPI, args.BucketName, args.ObjectName)
+	// Mirror the delete to the replication target.
+	queueReplication(r, args.BucketName, args.ObjectName, true)
+	return nil
+}
+
+// Upload and download URLs are valid for an hour.
+const objectURLExpiry = time.Hour
+
+// ObjectURLArgs - get/put object url args.
+type ObjectURLArgs struct {
+	TargetHost string `json:"targetHost"`
+	BucketName string `json:"bucketName"`
+	ObjectName string `json:"objectName"`
+}
+
+// ObjectURLRep - get/put object url reply.
+type ObjectURLRep struct {
+	URL       string `json:"url"`
+	UIVersion string `json:"uiVersion"`
+}
+
+// presignObjectURL - presigns method on the object for the browser.
+func presignObjectURL(r *http.Request, method string, args *ObjectURLArgs, reply *ObjectURLRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
+	if args.BucketName == "" || args.ObjectName == "" {
+		return &json2.Error{Message: "Bucket and Object are mandatory arguments."}
+	}
+	host := args.TargetHost
+	if host == "" {
+		host = r.Host
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	reply.UIVersion = miniobrowser.UIVersion
+	reply.URL = scheme + "://" + host + preSignV4(method, host, args.BucketName, args.ObjectName, time.Now().UTC(), objectURLExpiry)
+	return nil
+}
+
+// GetObjectURL - returns presigned url to download an object.
+func (web *webAPIHandlers) GetObjectURL(r *http.Request, args *ObjectURLArgs, reply *ObjectURLRep) error {
+	return presignObjectURL(r, "GET", args, reply)
+}
+
+// PutObjectURL - returns presigned url to upload an object.
+func (web *webAPIHandlers) PutObjectURL(r *http.Request, args *ObjectURLArgs, reply *ObjectURLRep) error {
+	return presignObjectURL(r, "PUT", args, reply)
+}
+
+// GetBucketPolicyArgs - get bucket policy args.
+type GetBucketPolicyArgs struct {
+	BucketName string `json:"bucketName"`
+	Prefix     string `json:"prefix"`
+}
+
+// GetBucketPolicyRep - get bucket policy reply.
+type GetBucketPolicyRep struct {
+	UIVersion string `json:"uiVersion"`
+	Policy    string `json:"policy"`
+}
+
+// GetBucketPolicy - get canned policy of a bucket prefix.
+func (web *webAPIHandlers) GetBucketPolicy(r *http.Request, args *GetBucketPolicyArgs, reply *GetBucketPolicyRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
+	statements, err := readBucketPolicyStatements(args.BucketName)
+	if err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	reply.UIVersion = miniobrowser.UIVersion
+	reply.Policy = getCannedPolicy(statements, args.BucketName, args.Prefix)
+	return nil
+}
+
+// ListAllBucketPoliciesArgs - list all bucket policies args.
+type ListAllBucketPoliciesArgs struct {
+	BucketName string `json:"bucketName"`
+}
+
+// BucketAccessPolicy - canned policy of a bucket prefix.
+type BucketAccessPolicy struct {
+	Prefix string `json:"prefix"`
+	Policy string `json:"policy"`
+}
+
+// ListAllBucketPoliciesRep - list all bucket policies reply.
+type ListAllBucketPoliciesRep struct {
+	UIVersion string               `json:"uiVersion"`
+	Policies  []BucketAccessPolicy `json:"policies"`
+}
+
+// ListAllBucketPolicies - list canned policies of all prefixes of a bucket.
+func (web *webAPIHandlers) ListAllBucketPolicies(r *http.Request, args *ListAllBucketPoliciesArgs, reply *ListAllBucketPoliciesRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
+	statements, err := readBucketPolicyStatements(args.BucketName)
+	if err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	reply.UIVersion = miniobrowser.UIVersion
+	for _, prefix := range cannedPolicyPrefixes(statements, args.BucketName) {
+		if policy := getCannedPolicy(statements, args.BucketName, prefix); policy != bucketPolicyNone {
+			reply.Policies = append(reply.Policies, BucketAccessPolicy{
+				Prefix: prefix,
+				Policy: policy,
+			})
+		}
+	}
+	return nil
+}
+
+// SetBucketPolicyArgs - set bucket policy args.
+type SetBucketPolicyArgs struct {
+	BucketName string `json:"bucketName"`
+	Prefix     string `json:"prefix"`
+	Policy     string `json:"policy"`
+}
+
+// SetBucketPolicy - set canned policy of a bucket prefix, other
+// statements of the bucket policy are kept.
+func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolicyArgs, reply *WebGenericRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
+	if !isValidCannedPolicy(args.Policy) {
+		return &json2.Error{Message: "Invalid policy type " + args.Policy}
+	}
+	if s3Error := globalServerMode.checkWrite(); s3Error != ErrNone {
+		return &json2.Error{Message: getAPIError(s3Error).Description}
+	}
+	if _, err := web.ObjectAPI.GetBucketInfo(args.BucketName); err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	statements, err := readBucketPolicyStatements(args.BucketName)
+	if err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	statements = setCannedPolicy(statements, args.Policy, args.BucketName, args.Prefix)
+	if err = writeBucketPolicyStatements(args.BucketName, statements); err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	reply.UIVersion = miniobrowser.UIVersion
+	return nil
+}
+
+// Presigned URLs are valid for at most 7 days.
+const maxPresignedExpiry = 7 * 24 * time.Hour
+
+// PresignedGetArgs - presigned-get API args.
+type PresignedGetArgs struct {
+	// Host header required for signed headers.
+	HostName string `json:"host"`
+	// Bucket name of the object to be presigned.
+	BucketName string `json:"bucket"`
+	// Object name to be presigned.
+	ObjectName string `json:"object"`
+	// Expiry in seconds, defaults to 7 days.
+	Expiry int64 `json:"expiry"`
+}
+
+// PresignedGetRep - presigned-get URL reply.
+type PresignedGetRep struct {
+	UIVersion string `json:"uiVersion"`
+	// Presigned URL of the object.
+	URL string `json:"url"`
+}
+
+// PresignedGet - returns presigned-Get url for a shareable link.
+func (web *webAPIHandlers) PresignedGet(r *http.Request, args *PresignedGetArgs, reply *PresignedGetRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
+	if args.BucketName == "" || args.ObjectName == "" {
+		return &json2.Error{Message: "Bucket and Object are mandatory arguments."}
+	}
+	expiry := time.Duration(args.Expiry) * time.Second
+	if expiry <= 0 || expiry > maxPresignedExpiry {
+		expiry = maxPresignedExpiry
+	}
+	host := args.HostName
+	if host == "" {
+		host = r.Host
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	reply.UIVersion = miniobrowser.UIVersion
+	reply.URL = scheme + "://" + host + preSignV4("GET", host, args.BucketName, args.ObjectName, time.Now().UTC(), expiry)
+	return nil
+}
+
+// ShareObjectArgs - share-object API args.
+type ShareObjectArgs struct {
+	// Host header required for signed headers.
+	HostName string `json:"host"`
+	// Bucket name of the object to be shared.
+	BucketName string `json:"bucket"`
+	// Object name to be shared.
+	ObjectName string `json:"object"`
+	// Expiry preset, one of 1h, 1d and 7d, defaults to 7d.
+	Expiry string `json:"expiry"`
+	// Number of downloads allowed, unlimited if zero.
+	MaxDownloads int `json:"maxDownloads"`
+}
+
+// ShareObjectRep - share-object reply.
+type ShareObjectRep struct {
+	UIVersion string `json:"uiVersion"`
+	// Presigned URL of the object.
+	URL string `json:"url"`
+	// Id of the share link, logged in audit entries of its downloads.
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ShareObject - returns a presigned share link of an object whose
+// downloads are counted and audited by the server.
+func (web *webAPIHandlers) ShareObject(r *http.Request, args *ShareObjectArgs, reply *ShareObjectRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if isReservedBucket(args.BucketName) {
+		return errReservedBucket
+	}
+	if args.BucketName == "" || args.ObjectName == "" {
+		return &json2.Error{Message: "Bucket and Object are mandatory arguments."}
+	}
+	expiry, err := getShareExpiry(args.Expiry)
+	if err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	link, err := newShareLink(web.ObjectAPI, args.BucketName, args.ObjectName, expiry, args.MaxDownloads)
+	if err != nil {
+		return &json2.Error{Message: err.Error()}
+	}
+	host := args.HostName
+	if host == "" {
+		host = r.Host
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	reply.UIVersion = miniobrowser.UIVersion
+	reply.URL = presignShareLink(scheme, host, link)
+	reply.ID = link.ID
+	reply.ExpiresAt = link.ExpiresAt
 	return nil
 }
 
@@ -356,9 +654,49 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
 	object := vars["object"]
-	if _, err := web.ObjectAPI.PutObject(bucket, object, -1, r.Body, nil); err != nil {
+	if isReservedBucket(bucket) {
+		writeWebErrorCode(w, ErrAllAccessDisabled)
+		return
+	}
+	// Same size limit as for PUT object.
+	if isMaxObjectSize(r.ContentLength) {
+		writeWebErrorCode(w, ErrObjectTooLarge)
+		return
+	}
+	if s3Error := enforceObjectLock(web.ObjectAPI, bucket, object, r); s3Error != ErrNone {
+		writeWebErrorCode(w, s3Error)
+		return
+	}
+	lockInfo, s3Error := getObjectLockRequestInfo(bucket, r)
+	if s3Error != ErrNone {
+		writeWebErrorCode(w, s3Error)
+		return
+	}
+	sse, encrypt, s3Error := getObjectEncryptionRequest(bucket, r)
+	if s3Error != ErrNone {
+		writeWebErrorCode(w, s3Error)
+		return
+	}
+	metadata := map[string]string{"content-type": r.Header.Get("Content-Type")}
+	var err error
+	if encrypt {
+		_, err = putEncryptedObject(r.Context(), web.ObjectAPI, bucket, object, sse, -1, r.Body, make(map[string]string))
+	} else if getObjectCompressionRequest(web.ObjectAPI, object, metadata, encrypt) {
+		_, err = putCompressedObject(r.Context(), web.ObjectAPI, bucket, object, r.Body, metadata)
+	} else {
+		_, err = web.ObjectAPI.PutObject(r.Context(), bucket, object, -1, r.Body, nil)
+	}
+	if err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+	// Save retention and legal hold for the new object.
+	if err = writeObjectLockInfo(web.ObjectAPI, bucket, object, lockInfo); err != nil {
 		writeWebErrorResponse(w, err)
+		return
 	}
+	// Mirror the new object to the replication target.
+	queueReplication(r, bucket, object, false)
 }
 
 // Download - file download handler.
@@ -369,16 +707,15 @@ func (web *webAPIHandlers) Download(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
 
 	jwt := initJWT()
-	jwttoken, e := jwtgo.Parse(token, func(token *jwtgo.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwt.SecretAccessKey), nil
-	})
-	if e != nil || !jwttoken.Valid {
+	jwttoken, e := jwtgo.Parse(token, jwt.keyFunc)
+	if e != nil || !jwt.isValidToken(jwttoken) {
 		writeWebErrorResponse(w, errInvalidToken)
 		return
 	}
+	if isReservedBucket(bucket) {
+		writeWebErrorCode(w, ErrAllAccessDisabled)
+		return
+	}
 	// Add content disposition.
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(object)))
 
@@ -387,14 +724,69 @@ func (web *webAPIHandlers) Download(w http.ResponseWriter, r *http.Request) {
 		writeWebErrorResponse(w, err)
 		return
 	}
+	sseInfo, encrypted, err := getObjectEncryption(bucket, &objInfo)
+	if err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+	var compInfo objectCompressionInfo
+	var compressed bool
+	if !encrypted {
+		if compInfo, compressed, err = getObjectCompression(bucket, &objInfo); err != nil {
+			writeWebErrorResponse(w, err)
+			return
+		}
+	}
 	offset := int64(0)
-	err = web.ObjectAPI.GetObject(bucket, object, offset, objInfo.Size, w)
+	if encrypted {
+		var objectKey [32]byte
+		if objectKey, err = sseInfo.unsealKey(bucket); err != nil {
+			writeWebErrorResponse(w, err)
+			return
+		}
+		err = getDecryptedObject(r.Context(), web.ObjectAPI, bucket, object, sseInfo, objectKey, offset, objInfo.Size, w)
+	} else if compressed {
+		err = getCompressedObject(r.Context(), web.ObjectAPI, bucket, object, compInfo, offset, objInfo.Size, w)
+	} else {
+		err = web.ObjectAPI.GetObject(r.Context(), bucket, object, offset, objInfo.Size, w)
+	}
 	if err != nil {
 		/// No need to print error, response writer already written to.
 		return
 	}
 }
 
+// DownloadZip - downloads all objects under a prefix as a zip archive.
+func (web *webAPIHandlers) DownloadZip(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	prefix := r.URL.Query().Get("prefix")
+	token := r.URL.Query().Get("token")
+
+	jwt := initJWT()
+	jwttoken, e := jwtgo.Parse(token, jwt.keyFunc)
+	if e != nil || !jwt.isValidToken(jwttoken) {
+		writeWebErrorResponse(w, errInvalidToken)
+		return
+	}
+	if isReservedBucket(bucket) {
+		writeWebErrorCode(w, ErrAllAccessDisabled)
+		return
+	}
+	if _, err := web.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+	// Add content disposition.
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", zipArchiveName(bucket, prefix)))
+
+	if err := writeZipArchive(r.Context(), web.ObjectAPI, bucket, prefix, w); err != nil {
+		/// No need to print error, response writer already written to.
+		return
+	}
+}
+
 // writeWebErrorResponse - set HTTP status code and write error description to the body.
 func writeWebErrorResponse(w http.ResponseWriter, err error) {
 	// Handle invalid token as a special case.
@@ -403,32 +795,11 @@ func writeWebErrorResponse(w http.ResponseWriter, err error) {
 		w.Write([]byte(err.Error()))
 		return
 	}
-	// Convert error type to api error code.
-	var apiErrCode APIErrorCode
-	switch err.(type) {
-	case StorageFull:
-		apiErrCode = ErrStorageFull
-	case BucketNotFound:
-		apiErrCode = ErrNoSuchBucket
-	case BucketNameInvalid:
-		apiErrCode = ErrInvalidBucketName
-	case BadDigest:
-		apiErrCode = ErrBadDigest
-	case IncompleteBody:
-		apiErrCode = ErrIncompleteBody
-	case ObjectExistsAsDirectory:
-		apiErrCode = ErrObjectExistsAsDirectory
-	case ObjectNotFound:
-		apiErrCode = ErrNoSuchKey
-	case ObjectNameInvalid:
-		apiErrCode = ErrNoSuchKey
-	case InsufficientWriteQuorum:
-		apiErrCode = ErrWriteQuorum
-	case InsufficientReadQuorum:
-		apiErrCode = ErrReadQuorum
-	default:
-		apiErrCode = ErrInternalError
-	}
+	writeWebErrorCode(w, toAPIErrorCode(err))
+}
+
+// writeWebErrorCode - set HTTP status code and write api error description to the body.
+func writeWebErrorCode(w http.ResponseWriter, apiErrCode APIErrorCode) {
 	apiErr := getAPIError(apiErrCode)
 	w.WriteHeader(apiErr.HTTPStatusCode)
 	w.Write([]byte(apiErr.Description))
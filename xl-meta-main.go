@@ -0,0 +1,77 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/console"
+)
+
+var xlMetaCmd = cli.Command{
+	Name:   "xl-meta",
+	Usage:  "Dump xl.json metadata of objects as JSON.",
+	Action: xlMetaMain,
+	CustomHelpTemplate: `NAME:
+  minio {{.Name}} - {{.Usage}}
+
+USAGE:
+  minio {{.Name}} PATH...
+
+  PATH is a xl.json file or an object directory on a disk, metadata
+  in binary and in JSON format are both understood.
+
+EXAMPLES:
+  1. Dump the metadata of an object on a disk.
+      $ minio {{.Name}} /mnt/export1/photos/2016/august/photo.jpg
+
+  2. Dump a xl.json file.
+      $ minio {{.Name}} /mnt/export1/photos/2016/august/photo.jpg/xl.json
+`,
+}
+
+// dumpXLMeta - returns the metadata of a `xl.json` file, or of the
+// object directory holding it, as indented JSON.
+func dumpXLMeta(filePath string) ([]byte, error) {
+	if fi, err := os.Stat(filePath); err == nil && fi.IsDir() {
+		filePath = filepath.Join(filePath, xlMetaJSONFile)
+	}
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var xlMeta xlMetaV1
+	if err = unmarshalXLMeta(buf, &xlMeta); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(xlMeta, "", "  ")
+}
+
+func xlMetaMain(c *cli.Context) {
+	if len(c.Args()) < 1 {
+		cli.ShowCommandHelpAndExit(c, "xl-meta", 1)
+	}
+	for _, filePath := range c.Args() {
+		buf, err := dumpXLMeta(filePath)
+		fatalIf(err, "Unable to read metadata of %s.", filePath)
+		console.Println(string(buf))
+	}
+}
@@ -0,0 +1,1046 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dchest/blake2b"
+)
+
+// Deployments with more disks than one XL erasure set supports are
+// split into erasure sets of equal size, consecutive disks on the
+// command line form a set. Objects are placed on a set by a hash of
+// their name keyed with the deployment ID, any node can locate the set
+// of an object without a central index. The layout is saved in
+// format-sets.json on all disks, sets are added by appending their
+// disks to the command line and retired by decommissioning them. Sets
+// on fast disks can be reserved for small objects, objects are placed
+// on them by the size declared on upload.
+const (
+	// Layout of the erasure sets in minioMetaBucket.
+	xlSetsFormatFile = "format-sets.json"
+
+	// Version of the erasure sets layout.
+	xlSetsFormatVersion = "1"
+)
+
+// errXLSetsDiskCount - disks cannot be split into sets of equal size.
+var errXLSetsDiskCount = errors.New("Number of disks should be a multiple of the disks per erasure set")
+
+// errInvalidDecommission - set does not exist, was removed or is the
+// last set objects can be placed on.
+var errInvalidDecommission = errors.New("Erasure set cannot be decommissioned")
+
+// errInvalidSmallObjectSets - small object sets do not exist, were
+// removed or leave no set for large objects, or the size is not
+// positive.
+var errInvalidSmallObjectSets = errors.New("Invalid small object sets")
+
+// errRebalanceStopped - rebalance was stopped before all objects were
+// moved.
+var errRebalanceStopped = errors.New("Rebalance stopped")
+
+// errRebalanceMaintenance - rebalance was paused as moving objects
+// writes, which are rejected in read-only and maintenance mode.
+var errRebalanceMaintenance = errors.New("Rebalance paused while writes are rejected")
+
+// xlSetsFormat - layout of the erasure sets.
+type xlSetsFormat struct {
+	Version string `json:"version"`
+	// Deployment ID, keys the hash placing objects on sets.
+	ID string `json:"id"`
+	// Number of disks of every set.
+	DriveCount int `json:"driveCount"`
+	// Disk uuids of every set as saved in their format.json.
+	Sets [][]string `json:"sets"`
+	// Indices of the sets being drained.
+	Decommissioning []int `json:"decommissioning,omitempty"`
+	// Disk uuids of removed sets, their disks must not be used again.
+	Removed [][]string `json:"removed,omitempty"`
+	// Indices of the sets objects of at most SmallObjectSize bytes are
+	// placed on, larger objects are placed on the other sets.
+	SmallObjectSets []int `json:"smallObjectSets,omitempty"`
+	SmallObjectSize int64 `json:"smallObjectSize,omitempty"`
+}
+
+// States of a set which is decommissioned, objects are not placed on
+// it anymore. A removed set is empty and left out of the saved layout,
+// its disks can be detached once servers are restarted without them.
+const (
+	xlSetDecommissioning = "decommissioning"
+	xlSetRemoved         = "removed"
+)
+
+// xlSets - implements the object layer over multiple XL erasure sets.
+type xlSets struct {
+	format xlSetsFormat
+	sets   []xlObjects
+	// Disk uuids of every set.
+	jbods [][]string
+
+	// Guards states, small and the active sets.
+	mutex sync.RWMutex
+	// Decommission state of every set, empty if objects are placed on
+	// the set.
+	states []string
+	// True for the sets small objects are placed on.
+	small []bool
+	// Indices of the sets objects are placed on, of those small and
+	// large objects are placed on.
+	active      []int
+	activeSmall []int
+	activeLarge []int
+}
+
+func init() {
+	registerObjectLayer(objectLayerXLSets, newXLSets)
+}
+
+// loadXLSetsFormat - loads the layout of the erasure sets from the
+// first disk which has it, returns errFileNotFound if no disk has it.
+func loadXLSetsFormat(disks []string) (xlSetsFormat, error) {
+	format := xlSetsFormat{}
+	for _, disk := range disks {
+		storage, err := newStorageAPI(disk)
+		if err != nil {
+			continue
+		}
+		data, err := readAll(storage, minioMetaBucket, xlSetsFormatFile)
+		if err != nil {
+			continue
+		}
+		if err = json.Unmarshal(data, &format); err != nil {
+			return format, err
+		}
+		return format, nil
+	}
+	return format, errFileNotFound
+}
+
+// getXLSetsDriveCount - returns the number of disks per set of a new
+// layout. Disks of an XL deployment which is expanded keep forming one
+// set, otherwise the largest set size dividing the disks is chosen.
+func getXLSetsDriveCount(disks []string) (int, error) {
+	for _, disk := range disks {
+		if format, _ := loadDiskFormat(disk); format != nil && format.Format == "xl" {
+			return len(format.XL.JBOD), nil
+		}
+	}
+	for count := maxErasureBlocks; count >= minErasureBlocks; count -= 2 {
+		if len(disks)%count == 0 {
+			return count, nil
+		}
+	}
+	return 0, errXLSetsDiskCount
+}
+
+// getXLFormatJBOD - returns the disk uuids of an XL erasure set.
+func getXLFormatJBOD(xl xlObjects) ([]string, error) {
+	err := errUnformattedDisk
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		var format *formatConfigV1
+		if format, err = loadFormat(disk); err == nil {
+			return format.XL.JBOD, nil
+		}
+	}
+	return nil, err
+}
+
+// isEqualJBOD - returns true if both sets of disk uuids are equal.
+func isEqualJBOD(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newXLSets - initializes the erasure sets of disks, sets whose disks
+// were appended are formatted and added to the saved layout.
+func newXLSets(disks []string) (ObjectLayer, error) {
+	format, err := loadXLSetsFormat(disks)
+	if err != nil && err != errFileNotFound {
+		return nil, fmt.Errorf("Unable to load %s, %s", xlSetsFormatFile, err)
+	}
+	if err == errFileNotFound {
+		format.Version = xlSetsFormatVersion
+		if format.DriveCount, err = getXLSetsDriveCount(disks); err != nil {
+			return nil, err
+		}
+	}
+	if format.DriveCount <= 0 || len(disks)%format.DriveCount != 0 {
+		return nil, errXLSetsDiskCount
+	}
+	if len(disks)/format.DriveCount < len(format.Sets) {
+		return nil, fmt.Errorf("Disks of %d erasure sets are saved in %s, only %d sets supplied",
+			len(format.Sets), xlSetsFormatFile, len(disks)/format.DriveCount)
+	}
+
+	s := &xlSets{}
+	changed := false
+	for i := 0; i < len(disks); i += format.DriveCount {
+		index := i / format.DriveCount
+		setDisks := disks[i : i+format.DriveCount]
+		if _, err = detectDisksFormat(setDisks); err != nil {
+			return nil, fmt.Errorf("Erasure set %d: %s", index+1, err)
+		}
+		objAPI, err := newXLObjects(setDisks)
+		if err != nil {
+			return nil, fmt.Errorf("Erasure set %d: %s", index+1, err)
+		}
+		xl := objAPI.(xlObjects)
+		jbod, err := getXLFormatJBOD(xl)
+		if err != nil {
+			return nil, fmt.Errorf("Erasure set %d: %s", index+1, err)
+		}
+		if findJBOD(format.Removed, jbod) >= 0 {
+			return nil, fmt.Errorf("Erasure set %d was decommissioned, remove its disks", index+1)
+		}
+		if index < len(format.Sets) {
+			if !isEqualJBOD(jbod, format.Sets[index]) {
+				return nil, fmt.Errorf("Disks of erasure set %d do not match the set saved in %s", index+1, xlSetsFormatFile)
+			}
+		} else {
+			format.Sets = append(format.Sets, jbod)
+			changed = true
+		}
+		s.sets = append(s.sets, xl)
+		s.jbods = append(s.jbods, jbod)
+	}
+	// Nodes agree on the disks of the first set, the deployment ID is
+	// derived from them instead of being generated by every node.
+	if format.ID == "" {
+		sum := sha256.Sum256([]byte(fmt.Sprint(format.Sets[0])))
+		format.ID = hex.EncodeToString(sum[:16])
+	}
+	s.format = format
+	s.states = make([]string, len(s.sets))
+	for _, index := range format.Decommissioning {
+		if index >= 0 && index < len(s.states) {
+			s.states[index] = xlSetDecommissioning
+		}
+	}
+	s.small = make([]bool, len(s.sets))
+	for _, index := range format.SmallObjectSets {
+		if index >= 0 && index < len(s.small) {
+			s.small[index] = true
+		}
+	}
+	s.updateActive()
+	if changed {
+		if err = s.saveFormat(); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.syncBuckets(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// findJBOD - returns the index of the set with the disk uuids jbod, -1
+// if there is none.
+func findJBOD(sets [][]string, jbod []string) int {
+	for i := range sets {
+		if isEqualJBOD(sets[i], jbod) {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateActive - updates the sets objects are placed on, must be called
+// with the mutex held.
+func (s *xlSets) updateActive() {
+	s.active, s.activeSmall, s.activeLarge = nil, nil, nil
+	for i, state := range s.states {
+		if state != "" {
+			continue
+		}
+		s.active = append(s.active, i)
+		if s.small[i] {
+			s.activeSmall = append(s.activeSmall, i)
+		} else {
+			s.activeLarge = append(s.activeLarge, i)
+		}
+	}
+}
+
+// saveFormat - saves the layout with the current set states, must be
+// called with the mutex held. Removed sets are saved on their disks as
+// well, so that they are refused if they are used again.
+func (s *xlSets) saveFormat() error {
+	format := s.format
+	format.Sets, format.Decommissioning, format.SmallObjectSets = nil, nil, nil
+	format.Removed = append([][]string(nil), s.format.Removed...)
+	for i, jbod := range s.jbods {
+		switch s.states[i] {
+		case xlSetRemoved:
+			format.Removed = append(format.Removed, jbod)
+			continue
+		case xlSetDecommissioning:
+			format.Decommissioning = append(format.Decommissioning, len(format.Sets))
+		}
+		if s.small[i] {
+			format.SmallObjectSets = append(format.SmallObjectSets, len(format.Sets))
+		}
+		format.Sets = append(format.Sets, jbod)
+	}
+	if len(format.SmallObjectSets) == 0 {
+		format.SmallObjectSize = 0
+	}
+	return saveJSONMetaFile(s, xlSetsFormatFile, format)
+}
+
+// getSetState - returns the decommission state of a set.
+func (s *xlSets) getSetState(index int) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.states[index]
+}
+
+// decommission - stops placing objects on a set, its objects are moved
+// to the remaining sets by the next rebalance.
+func (s *xlSets) decommission(index int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if index < 0 || index >= len(s.sets) || s.states[index] == xlSetRemoved {
+		return errInvalidDecommission
+	}
+	if s.states[index] == xlSetDecommissioning {
+		return nil
+	}
+	if len(s.active) == 1 {
+		return errInvalidDecommission
+	}
+	s.states[index] = xlSetDecommissioning
+	s.updateActive()
+	if err := s.saveFormat(); err != nil {
+		s.states[index] = ""
+		s.updateActive()
+		return err
+	}
+	return nil
+}
+
+// isSmallObjectSet - returns true if small objects are placed on a set.
+func (s *xlSets) isSmallObjectSet(index int) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.small[index]
+}
+
+// getSmallObjectSize - returns the size up to which objects are placed
+// on the small object sets, 0 if there are none.
+func (s *xlSets) getSmallObjectSize() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.format.SmallObjectSize
+}
+
+// setSmallObjectSets - places objects of at most size bytes on the sets
+// with indices, larger objects on the other sets. Objects are placed by
+// size only if both tiers have sets which are not decommissioned, no
+// indices place all objects on all sets again. Objects written before
+// are moved to their tier by the next rebalance.
+func (s *xlSets) setSmallObjectSets(indices []int, size int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	small := make([]bool, len(s.sets))
+	for _, index := range indices {
+		if index < 0 || index >= len(s.sets) || s.states[index] == xlSetRemoved {
+			return errInvalidSmallObjectSets
+		}
+		small[index] = true
+	}
+	if len(indices) > 0 {
+		large := false
+		for i := range s.sets {
+			large = large || (!small[i] && s.states[i] != xlSetRemoved)
+		}
+		if !large || size <= 0 {
+			return errInvalidSmallObjectSets
+		}
+	} else {
+		size = 0
+	}
+	oldSmall, oldSize := s.small, s.format.SmallObjectSize
+	s.small, s.format.SmallObjectSize = small, size
+	s.updateActive()
+	if err := s.saveFormat(); err != nil {
+		s.small, s.format.SmallObjectSize = oldSmall, oldSize
+		s.updateActive()
+		return err
+	}
+	return nil
+}
+
+// removeDecommissioned - removes the decommissioned sets from the
+// layout once they hold no objects and uploads. Deleted objects in the
+// trash of a removed set are dropped with it.
+func (s *xlSets) removeDecommissioned() error {
+	buckets, err := s.ListBuckets()
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	removed := false
+	for i, set := range s.sets {
+		if s.states[i] != xlSetDecommissioning {
+			continue
+		}
+		for _, bucket := range buckets {
+			result, err := set.ListObjects(bucket.Name, "", "", "", 1)
+			if err != nil {
+				return err
+			}
+			uploads, err := set.ListMultipartUploads(bucket.Name, "", "", "", "", 1)
+			if err != nil {
+				return err
+			}
+			if len(result.Objects) > 0 || len(uploads.Uploads) > 0 {
+				return fmt.Errorf("Erasure set %d still holds objects of bucket %s", i+1, bucket.Name)
+			}
+		}
+		s.states[i] = xlSetRemoved
+		removed = true
+	}
+	if !removed {
+		return nil
+	}
+	return s.saveFormat()
+}
+
+// syncBuckets - creates the buckets of the first set on added sets.
+func (s *xlSets) syncBuckets() error {
+	buckets, err := s.sets[0].ListBuckets()
+	if err != nil {
+		return err
+	}
+	for _, set := range s.sets[1:] {
+		for _, bucket := range buckets {
+			if err = set.MakeBucket(bucket.Name); err != nil {
+				if _, ok := err.(BucketExists); !ok {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hashObjectSet - returns the set out of count sets an object is placed
+// on, hashed with the deployment ID as key.
+func hashObjectSet(id, object string, count int) int {
+	h := blake2b.NewMAC(8, []byte(id))
+	h.Write([]byte(object))
+	return int(binary.LittleEndian.Uint64(h.Sum(nil)) % uint64(count))
+}
+
+// getPlacementSet - returns the index of the set an object of size
+// bytes is placed on, decommissioned sets are skipped. Objects of
+// unknown size, a negative size, are placed like large objects.
+func (s *xlSets) getPlacementSet(object string, size int64) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	active := s.active
+	if len(s.activeSmall) > 0 && len(s.activeLarge) > 0 {
+		if size >= 0 && size <= s.format.SmallObjectSize {
+			active = s.activeSmall
+		} else {
+			active = s.activeLarge
+		}
+	}
+	return active[hashObjectSet(s.format.ID, object, len(active))]
+}
+
+// getHashedSet - returns the index of the set an object of unknown size
+// is placed on.
+func (s *xlSets) getHashedSet(object string) int {
+	return s.getPlacementSet(object, -1)
+}
+
+// getTierSets - returns the indices of the sets an object is placed on
+// if it is small and if it is large, both are the same unless small
+// object sets are configured.
+func (s *xlSets) getTierSets(object string) (int, int) {
+	return s.getPlacementSet(object, 0), s.getHashedSet(object)
+}
+
+// getLookupOrder - returns the indices of all sets, the sets an object
+// is placed on by size first. Objects written before sets were added
+// stay on their old set until they are rebalanced.
+func (s *xlSets) getLookupOrder(object string) []int {
+	small, large := s.getTierSets(object)
+	order := []int{small}
+	if large != small {
+		order = append(order, large)
+	}
+	for i := range s.sets {
+		if i != small && i != large {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// removeTierCopy - removes the copy of an object placed on set index
+// from the set of the other tier, it is left there when an object is
+// overwritten with a size of the other tier and would be found first.
+// Callers hold the write lock of the object.
+func (s *xlSets) removeTierCopy(bucket, object string, index int) {
+	small, large := s.getTierSets(object)
+	for _, i := range []int{small, large} {
+		if i == index || !s.sets[i].isObject(bucket, object) {
+			continue
+		}
+		if err := s.sets[i].deleteObject(bucket, object); err != nil {
+			errorIf(err, "Unable to remove %s/%s from erasure set %d.", bucket, object, i+1)
+		}
+	}
+}
+
+// getObjectSet - returns the set holding an object.
+func (s *xlSets) getObjectSet(bucket, object string) (xlObjects, ObjectInfo, error) {
+	var firstErr error
+	for _, i := range s.getLookupOrder(object) {
+		objInfo, err := s.sets[i].GetObjectInfo(bucket, object)
+		if err == nil {
+			return s.sets[i], objInfo, nil
+		}
+		if _, ok := err.(ObjectNotFound); !ok {
+			return xlObjects{}, ObjectInfo{}, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return xlObjects{}, ObjectInfo{}, firstErr
+}
+
+// getLockedObjectSet - returns the set holding an object whose
+// namespace lock is held by the caller.
+func (s *xlSets) getLockedObjectSet(bucket, object string) (xlObjects, error) {
+	for _, i := range s.getLookupOrder(object) {
+		_, err := s.sets[i].getObjectInfo(bucket, object)
+		if err == nil {
+			return s.sets[i], nil
+		}
+		if err != errFileNotFound {
+			return xlObjects{}, toObjectErr(err, bucket, object)
+		}
+	}
+	return xlObjects{}, ObjectNotFound{Bucket: bucket, Object: object}
+}
+
+// getUploadSetIndex - returns the index of the set holding a multipart
+// upload.
+func (s *xlSets) getUploadSetIndex(bucket, object, uploadID string) int {
+	for _, i := range s.getLookupOrder(object) {
+		if s.sets[i].isUploadIDExists(bucket, object, uploadID) {
+			return i
+		}
+	}
+	// Sets report the missing upload themselves.
+	return s.getHashedSet(object)
+}
+
+// getUploadSet - returns the set holding a multipart upload.
+func (s *xlSets) getUploadSet(bucket, object, uploadID string) xlObjects {
+	return s.sets[s.getUploadSetIndex(bucket, object, uploadID)]
+}
+
+// StorageInfo - returns the storage of all sets.
+func (s *xlSets) StorageInfo() StorageInfo {
+	var info StorageInfo
+	for _, set := range s.sets {
+		setInfo := set.StorageInfo()
+		info.Total += setInfo.Total
+		info.Free += setInfo.Free
+	}
+	return info
+}
+
+// DisksInfo - returns status of the disks of all sets.
+func (s *xlSets) DisksInfo() []DiskStatus {
+	var disksStatus []DiskStatus
+	for _, set := range s.sets {
+		disksStatus = append(disksStatus, set.DisksInfo()...)
+	}
+	return disksStatus
+}
+
+// MakeBucket - creates a bucket on all sets.
+func (s *xlSets) MakeBucket(bucket string) error {
+	exists := 0
+	for i, set := range s.sets {
+		err := set.MakeBucket(bucket)
+		if _, ok := err.(BucketExists); ok {
+			exists++
+			continue
+		}
+		if err != nil {
+			// Undo the bucket on the sets it was created on.
+			for _, created := range s.sets[:i] {
+				created.DeleteBucket(bucket)
+			}
+			return err
+		}
+	}
+	if exists == len(s.sets) {
+		return BucketExists{Bucket: bucket}
+	}
+	return nil
+}
+
+// GetBucketInfo - returns bucket info from the first set.
+func (s *xlSets) GetBucketInfo(bucket string) (BucketInfo, error) {
+	return s.sets[0].GetBucketInfo(bucket)
+}
+
+// ListBuckets - lists buckets of the first set, all sets have the same
+// buckets.
+func (s *xlSets) ListBuckets() ([]BucketInfo, error) {
+	return s.sets[0].ListBuckets()
+}
+
+// DeleteBucket - deletes a bucket on all sets, if it is empty on all
+// sets.
+func (s *xlSets) DeleteBucket(bucket string) error {
+	return s.deleteBucket(bucket, false)
+}
+
+// ListObjects - merges the objects and prefixes listed on all sets.
+func (s *xlSets) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	var result ListObjectsInfo
+	objects := make(map[string]ObjectInfo)
+	prefixes := make(map[string]struct{})
+	var names []string
+	for i, set := range s.sets {
+		setResult, err := set.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		result.IsTruncated = result.IsTruncated || setResult.IsTruncated
+		for _, objInfo := range setResult.Objects {
+			// Objects not rebalanced yet are on their old set as
+			// well, the set they hash to has the current copy.
+			if _, ok := objects[objInfo.Name]; ok {
+				if s.getPlacementSet(objInfo.Name, objInfo.Size) == i {
+					objects[objInfo.Name] = objInfo
+				}
+				continue
+			}
+			objects[objInfo.Name] = objInfo
+			names = append(names, objInfo.Name)
+		}
+		for _, p := range setResult.Prefixes {
+			if _, ok := prefixes[p]; ok {
+				continue
+			}
+			prefixes[p] = struct{}{}
+			names = append(names, p)
+		}
+	}
+	// Every truncated set listed maxKeys entries, the first maxKeys
+	// merged entries are complete.
+	sort.Strings(names)
+	if len(names) > maxKeys {
+		names = names[:maxKeys]
+		result.IsTruncated = true
+	}
+	for _, name := range names {
+		if objInfo, ok := objects[name]; ok {
+			result.Objects = append(result.Objects, objInfo)
+		} else {
+			result.Prefixes = append(result.Prefixes, name)
+		}
+	}
+	if result.IsTruncated && len(names) > 0 {
+		result.NextMarker = names[len(names)-1]
+	}
+	return result, nil
+}
+
+// GetObject - reads an object from the set holding it.
+func (s *xlSets) GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	set, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return set.GetObject(ctx, bucket, object, startOffset, length, writer)
+}
+
+// HealObject - heals an object on the set holding it.
+func (s *xlSets) HealObject(bucket, object string) error {
+	set, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return set.HealObject(bucket, object)
+}
+
+// HealFormat - heals `format.json` of every set, the layout is saved
+// again so that replaced disks have it and hold the new disk uuids.
+func (s *xlSets) HealFormat() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, set := range s.sets {
+		if s.states[i] == xlSetRemoved {
+			continue
+		}
+		if err := set.HealFormat(); err != nil {
+			return err
+		}
+		jbod, err := getXLFormatJBOD(set)
+		if err != nil {
+			return err
+		}
+		s.jbods[i] = jbod
+	}
+	return s.saveFormat()
+}
+
+// ListBucketsHeal - lists the buckets found on any disk of any set,
+// sorted by name.
+func (s *xlSets) ListBucketsHeal() ([]BucketInfo, error) {
+	buckets := make(map[string]BucketInfo)
+	for i, set := range s.sets {
+		if s.getSetState(i) == xlSetRemoved {
+			continue
+		}
+		setBuckets, err := set.ListBucketsHeal()
+		if err != nil {
+			return nil, err
+		}
+		for _, bucketInfo := range setBuckets {
+			if _, ok := buckets[bucketInfo.Name]; !ok {
+				buckets[bucketInfo.Name] = bucketInfo
+			}
+		}
+	}
+	bucketsInfo := make([]BucketInfo, 0, len(buckets))
+	for _, bucketInfo := range buckets {
+		bucketsInfo = append(bucketsInfo, bucketInfo)
+	}
+	sort.Sort(byBucketName(bucketsInfo))
+	return bucketsInfo, nil
+}
+
+// HealBucket - heals a bucket on every set.
+func (s *xlSets) HealBucket(bucket string) error {
+	for i, set := range s.sets {
+		if s.getSetState(i) == xlSetRemoved {
+			continue
+		}
+		if err := set.HealBucket(bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetObjectInfo - returns object info from the set holding it.
+func (s *xlSets) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	_, objInfo, err := s.getObjectSet(bucket, object)
+	return objInfo, err
+}
+
+// PutObject - creates an object on the set it hashes to among the sets
+// of its size. The object stays locked until the copy on the other
+// tier is removed, a concurrent write could be removed otherwise.
+func (s *xlSets) PutObject(ctx context.Context, bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	index := s.getPlacementSet(object, size)
+	// Directory markers hold their own lock and have no tier copies.
+	if isDirMarker(object) {
+		return s.sets[index].PutObject(ctx, bucket, object, size, data, metadata)
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	md5Sum, err := s.sets[index].putObject(ctx, bucket, object, size, data, metadata)
+	if err != nil {
+		return "", err
+	}
+	s.removeTierCopy(bucket, object, index)
+	return md5Sum, nil
+}
+
+// DeleteObject - deletes an object from all sets holding a copy.
+func (s *xlSets) DeleteObject(bucket, object string) error {
+	var firstErr error
+	deleted := false
+	for _, i := range s.getLookupOrder(object) {
+		err := s.sets[i].DeleteObject(bucket, object)
+		if err == nil {
+			deleted = true
+			continue
+		}
+		if _, ok := err.(ObjectNotFound); ok {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		return err
+	}
+	if deleted {
+		return nil
+	}
+	return firstErr
+}
+
+// UpdateObjectMetadata - saves the metadata of an object on the set
+// holding it.
+func (s *xlSets) UpdateObjectMetadata(bucket, object string, update func(metadata map[string]string) error) error {
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	set, err := s.getLockedObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return set.updateObjectMetadata(bucket, object, update)
+}
+
+// UpdateUploadMetadata - saves the metadata of an upload on the set
+// holding it.
+func (s *xlSets) UpdateUploadMetadata(bucket, object, uploadID string, update func(metadata map[string]string) error) error {
+	return s.getUploadSet(bucket, object, uploadID).UpdateUploadMetadata(bucket, object, uploadID, update)
+}
+
+// ListMultipartUploads - merges the uploads listed on all sets.
+func (s *xlSets) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	if maxUploads < 0 || maxUploads > maxUploadsList {
+		maxUploads = maxUploadsList
+	}
+	var result ListMultipartsInfo
+	var uploads []uploadMetadata
+	prefixes := make(map[string]struct{})
+	truncated := false
+	for i, set := range s.sets {
+		setResult, err := set.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+		if err != nil {
+			return ListMultipartsInfo{}, err
+		}
+		if i == 0 {
+			result = setResult
+			result.Uploads, result.CommonPrefixes = nil, nil
+			result.NextKeyMarker, result.NextUploadIDMarker = "", ""
+		}
+		truncated = truncated || setResult.IsTruncated
+		uploads = append(uploads, setResult.Uploads...)
+		for _, p := range setResult.CommonPrefixes {
+			if _, ok := prefixes[p]; !ok {
+				prefixes[p] = struct{}{}
+				uploads = append(uploads, uploadMetadata{Object: p})
+			}
+		}
+	}
+	sort.SliceStable(uploads, func(i, j int) bool {
+		return uploads[i].Object < uploads[j].Object
+	})
+	if len(uploads) > maxUploads {
+		uploads = uploads[:maxUploads]
+		truncated = true
+	}
+	fillMultipartsInfo(&result, uploads, truncated)
+	return result, nil
+}
+
+// NewMultipartUpload - starts an upload on the set the object hashes
+// to, the size of uploads is not known so they are placed like large
+// objects.
+func (s *xlSets) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	return s.sets[s.getHashedSet(object)].NewMultipartUpload(bucket, object, metadata)
+}
+
+// PutObjectPart - uploads a part to the set holding the upload.
+func (s *xlSets) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+	return s.getUploadSet(bucket, object, uploadID).PutObjectPart(ctx, bucket, object, uploadID, partID, size, data, md5Hex)
+}
+
+// ListObjectParts - lists parts of an upload on the set holding it.
+func (s *xlSets) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (ListPartsInfo, error) {
+	return s.getUploadSet(bucket, object, uploadID).ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+}
+
+// AbortMultipartUpload - aborts an upload on the set holding it.
+func (s *xlSets) AbortMultipartUpload(bucket, object, uploadID string) error {
+	return s.getUploadSet(bucket, object, uploadID).AbortMultipartUpload(bucket, object, uploadID)
+}
+
+// CompleteMultipartUpload - completes an upload on the set holding it,
+// an object of an upload started before sets were added is rebalanced
+// later. Like PutObject the object stays locked until the copy on the
+// other tier is removed.
+func (s *xlSets) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (string, error) {
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	index := s.getUploadSetIndex(bucket, object, uploadID)
+	md5Sum, err := s.sets[index].completeMultipartUpload(bucket, object, uploadID, uploadedParts, true)
+	if err != nil {
+		return "", err
+	}
+	s.removeTierCopy(bucket, object, index)
+	return md5Sum, nil
+}
+
+// rebalanceProgress - position and counts of a rebalance, objects of
+// sets and buckets before the position were rebalanced.
+type rebalanceProgress struct {
+	Set     int    `json:"set"`
+	Bucket  string `json:"bucket"`
+	Marker  string `json:"marker"`
+	Moved   int64  `json:"moved"`
+	Removed int64  `json:"removed"`
+	Failed  int64  `json:"failed"`
+	Size    int64  `json:"size"`
+}
+
+// rebalanceObject - moves an object from set from to the set it hashes
+// to among the sets of its size, keeping its modification time. If the
+// target set has a newer copy the stale copy is removed instead.
+// Returns true if the object was moved.
+func (s *xlSets) rebalanceObject(bucket, object string, from int) (bool, error) {
+	src := s.sets[from]
+	objInfo, err := src.GetObjectInfo(bucket, object)
+	if err != nil {
+		return false, err
+	}
+	dst := s.sets[s.getPlacementSet(object, objInfo.Size)]
+	dstInfo, err := dst.GetObjectInfo(bucket, object)
+	if err == nil && !dstInfo.ModTime.Before(objInfo.ModTime) {
+		return false, src.DeleteObject(bucket, object)
+	}
+	if _, ok := err.(ObjectNotFound); err != nil && !ok {
+		return false, err
+	}
+
+	// Metadata is kept, including the internal keys needed to read
+	// the object like its encryption info.
+	metadata := make(map[string]string, len(objInfo.Metadata)+1)
+	for key, value := range objInfo.Metadata {
+		metadata[key] = value
+	}
+	metadata[objectModTimeKey] = objInfo.ModTime.Format(time.RFC3339Nano)
+	// Only md5sums of objects which were not uploaded in parts can be
+	// verified and kept.
+	if len(objInfo.MD5Sum) != hex.EncodedLen(md5.Size) {
+		delete(metadata, "md5Sum")
+	}
+	// Namespace locks are shared by all sets, the object is read under
+	// the write lock held by PutObject of the target set.
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(src.getObject(context.Background(), bucket, object, 0, objInfo.Size, pipeWriter))
+	}()
+	_, err = dst.PutObject(context.Background(), bucket, object, objInfo.Size, pipeReader, metadata)
+	pipeReader.Close()
+	if err != nil {
+		return false, err
+	}
+	return true, src.DeleteObject(bucket, object)
+}
+
+// rebalance - moves all objects which are not on the set they hash to,
+// only those of decommissioned sets if drain is set, continuing at the
+// position of progress. After every object next is called with the
+// size moved, rebalance stops with the error returned by next.
+func (s *xlSets) rebalance(progress *rebalanceProgress, drain bool, next func(size int64) error) error {
+	buckets, err := s.ListBuckets()
+	if err != nil {
+		return err
+	}
+	for ; progress.Set < len(s.sets); progress.Set, progress.Bucket, progress.Marker = progress.Set+1, "", "" {
+		state := s.getSetState(progress.Set)
+		if state == xlSetRemoved || (drain && state != xlSetDecommissioning) {
+			continue
+		}
+		set := s.sets[progress.Set]
+		for _, bucket := range buckets {
+			// Buckets are listed in order, skip the ones done.
+			if bucket.Name < progress.Bucket {
+				continue
+			}
+			if bucket.Name != progress.Bucket {
+				progress.Bucket, progress.Marker = bucket.Name, ""
+			}
+			for {
+				result, err := set.ListObjects(bucket.Name, "", progress.Marker, "", maxObjectList)
+				if err != nil {
+					// Bucket may have been deleted meanwhile.
+					if _, ok := err.(BucketNotFound); ok {
+						break
+					}
+					return err
+				}
+				for _, objInfo := range result.Objects {
+					if !serverModeAllowsWrites() {
+						return errRebalanceMaintenance
+					}
+					progress.Marker = objInfo.Name
+					if s.getPlacementSet(objInfo.Name, objInfo.Size) == progress.Set {
+						continue
+					}
+					var size int64
+					moved, err := s.rebalanceObject(bucket.Name, objInfo.Name, progress.Set)
+					if err != nil {
+						// Objects deleted meanwhile need no move.
+						if _, ok := err.(ObjectNotFound); !ok {
+							errorIf(err, "Unable to rebalance %s/%s.", bucket.Name, objInfo.Name)
+							progress.Failed++
+						}
+					} else if moved {
+						progress.Moved++
+						progress.Size += objInfo.Size
+						size = objInfo.Size
+					} else {
+						progress.Removed++
+					}
+					if err = next(size); err != nil {
+						return err
+					}
+				}
+				if !result.IsTruncated {
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
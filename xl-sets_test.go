@@ -0,0 +1,429 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// getTestDisks - creates count temporary disks.
+func getTestDisks(count int) ([]string, error) {
+	var disks []string
+	for i := 0; i < count; i++ {
+		disk, err := ioutil.TempDir(os.TempDir(), "minio-")
+		if err != nil {
+			removeRoots(disks)
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
+
+// Tests objects are placed on sets deterministically and spread over
+// all sets.
+func TestHashObjectSet(t *testing.T) {
+	counts := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		object := fmt.Sprintf("object-%d", i)
+		index := hashObjectSet("id", object, len(counts))
+		if index != hashObjectSet("id", object, len(counts)) {
+			t.Fatalf("Object %s placed on different sets", object)
+		}
+		counts[index]++
+	}
+	for i, count := range counts {
+		if count < 150 {
+			t.Fatalf("Only %d of 1000 objects placed on set %d", count, i)
+		}
+	}
+	// The deployment ID changes the placement.
+	moved := 0
+	for i := 0; i < 100; i++ {
+		object := fmt.Sprintf("object-%d", i)
+		if hashObjectSet("id", object, 4) != hashObjectSet("other", object, 4) {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Fatal("Expected placement to depend on the deployment ID")
+	}
+}
+
+// Tests objects are stored on and listed from multiple erasure sets.
+func TestXLSets(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets, ok := objAPI.(*xlSets)
+	if !ok {
+		t.Fatalf("Expected erasure sets, got %T", objAPI)
+	}
+	if len(sets.sets) != 2 || sets.format.DriveCount != 16 || sets.format.ID == "" {
+		t.Fatalf("Unexpected sets format %#v", sets.format)
+	}
+
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("bucket"); err == nil {
+		t.Fatal("Expected BucketExists error")
+	}
+	data := []byte("hello")
+	var names []string
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("dir%d/object-%02d", i%2, i)
+		names = append(names, object)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+		// Objects are stored only on the set they hash to.
+		for index, set := range sets.sets {
+			_, err = set.GetObjectInfo("bucket", object)
+			if (err == nil) != (index == sets.getHashedSet(object)) {
+				t.Fatalf("Object %s found on set %d: %v", object, index, err)
+			}
+		}
+	}
+	buffer := new(bytes.Buffer)
+	if err = objAPI.GetObject(context.Background(), "bucket", names[3], 0, int64(len(data)), buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Expected %q, got %q", data, buffer.Bytes())
+	}
+
+	// Pages merged from both sets list every object once, in order.
+	var listed []string
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects("bucket", "", marker, "", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, objInfo := range result.Objects {
+			listed = append(listed, objInfo.Name)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	sort.Strings(names)
+	if fmt.Sprint(listed) != fmt.Sprint(names) {
+		t.Fatalf("Expected %v, got %v", names, listed)
+	}
+	result, err := objAPI.ListObjects("bucket", "", "", slashSeparator, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(result.Prefixes) != "[dir0/ dir1/]" || len(result.Objects) != 0 {
+		t.Fatalf("Unexpected delimited listing %#v", result)
+	}
+
+	// Uploads are completed on the set they were started on.
+	uploadID, err := objAPI.NewMultipartUpload("bucket", "multipart", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md5Sum, err := objAPI.PutObjectPart(context.Background(), "bucket", "multipart", uploadID, 1, int64(len(data)), bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads, err := objAPI.ListMultipartUploads("bucket", "", "", "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads.Uploads) != 1 || uploads.Uploads[0].UploadID != uploadID {
+		t.Fatalf("Unexpected uploads %#v", uploads.Uploads)
+	}
+	if _, err = objAPI.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: md5Sum}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = objAPI.DeleteBucket("bucket"); err == nil {
+		t.Fatal("Expected BucketNotEmpty error")
+	}
+	for _, object := range append(names, "multipart") {
+		if err = objAPI.DeleteObject("bucket", object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = objAPI.DeleteBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sets are recognized on restart, swapped sets are refused.
+	if objAPI, err = newObjectLayer(disks); err != nil {
+		t.Fatal(err)
+	}
+	if objAPI.(*xlSets).format.ID != sets.format.ID {
+		t.Fatal("Deployment ID changed on restart")
+	}
+	swapped := append(append([]string{}, disks[16:]...), disks[:16]...)
+	if _, err = newObjectLayer(swapped); err == nil {
+		t.Fatal("Expected swapped sets to be refused")
+	}
+}
+
+// Tests an XL deployment expanded by a set finds all objects and moves
+// them to their set on rebalance.
+func TestXLSetsRebalance(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks[:16])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	var names []string
+	for i := 0; i < 20; i++ {
+		object := fmt.Sprintf("object-%02d", i)
+		names = append(names, object)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objAPI, err = newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := objAPI.(*xlSets)
+	if len(sets.sets) != 2 {
+		t.Fatalf("Expected 2 sets, got %d", len(sets.sets))
+	}
+	// The bucket was created on the added set.
+	if _, err = sets.sets[1].GetBucketInfo("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	misplaced := 0
+	for _, object := range names {
+		if sets.getHashedSet(object) != 0 {
+			misplaced++
+		}
+		if _, err = objAPI.GetObjectInfo("bucket", object); err != nil {
+			t.Fatalf("Object %s not found before rebalance: %v", object, err)
+		}
+	}
+	if misplaced == 0 {
+		t.Fatal("Expected objects to hash to the added set")
+	}
+
+	var progress rebalanceProgress
+	if err = sets.rebalance(&progress, false, func(int64) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if progress.Moved != int64(misplaced) || progress.Failed != 0 {
+		t.Fatalf("Expected %d objects moved, got %#v", misplaced, progress)
+	}
+	for _, object := range names {
+		for index, set := range sets.sets {
+			_, err = set.GetObjectInfo("bucket", object)
+			if (err == nil) != (index == sets.getHashedSet(object)) {
+				t.Fatalf("Object %s found on set %d after rebalance: %v", object, index, err)
+			}
+		}
+	}
+	buffer := new(bytes.Buffer)
+	if err = objAPI.GetObject(context.Background(), "bucket", names[0], 0, int64(len(data)), buffer); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("Unable to read rebalanced object: %v", err)
+	}
+}
+
+// Tests small objects are placed on the small object sets, large
+// objects on the other sets and both are found on read.
+func TestXLSetsSmallObjectSets(t *testing.T) {
+	initNSLock()
+	disks, err := getTestDisks(48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	objAPI, err := newObjectLayer(disks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := objAPI.(*xlSets)
+	if len(sets.sets) != 3 {
+		t.Fatalf("Expected 3 sets, got %d", len(sets.sets))
+	}
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	small, large := []byte("hello"), bytes.Repeat([]byte("a"), 100)
+	// Objects written before are moved to their tier on rebalance.
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("before-%d", i)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(large)), bytes.NewReader(large), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invalid := []struct {
+		indices []int
+		size    int64
+	}{
+		{[]int{3}, 10},
+		{[]int{-1}, 10},
+		{[]int{0, 1, 2}, 10},
+		{[]int{0}, 0},
+	}
+	for i, testCase := range invalid {
+		if err = sets.setSmallObjectSets(testCase.indices, testCase.size); err != errInvalidSmallObjectSets {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, errInvalidSmallObjectSets, err)
+		}
+	}
+	if err = sets.setSmallObjectSets([]int{0}, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// onlyOn - fails unless object is found on set index only.
+	onlyOn := func(object string, index int) {
+		for i, set := range sets.sets {
+			if _, err := set.GetObjectInfo("bucket", object); (err == nil) != (i == index) {
+				t.Fatalf("Object %s found on set %d: %v", object, i, err)
+			}
+		}
+	}
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("small-%d", i)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(small)), bytes.NewReader(small), nil); err != nil {
+			t.Fatal(err)
+		}
+		onlyOn(object, 0)
+		object = fmt.Sprintf("large-%d", i)
+		if _, err = objAPI.PutObject(context.Background(), "bucket", object, int64(len(large)), bytes.NewReader(large), nil); err != nil {
+			t.Fatal(err)
+		}
+		if index := sets.getHashedSet(object); index == 0 {
+			t.Fatalf("Large object %s placed on the small object set", object)
+		} else {
+			onlyOn(object, index)
+		}
+	}
+
+	// Objects overwritten with a size of the other tier move to it.
+	if _, err = objAPI.PutObject(context.Background(), "bucket", "small-0", int64(len(large)), bytes.NewReader(large), nil); err != nil {
+		t.Fatal(err)
+	}
+	onlyOn("small-0", sets.getHashedSet("small-0"))
+	buffer := new(bytes.Buffer)
+	if err = objAPI.GetObject(context.Background(), "bucket", "small-0", 0, int64(len(large)), buffer); err != nil || !bytes.Equal(buffer.Bytes(), large) {
+		t.Fatalf("Unable to read overwritten object: %v", err)
+	}
+	if _, err = objAPI.PutObject(context.Background(), "bucket", "large-0", int64(len(small)), bytes.NewReader(small), nil); err != nil {
+		t.Fatal(err)
+	}
+	onlyOn("large-0", 0)
+
+	// Concurrent overwrites of both tiers leave a single copy.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		data := small
+		if i%2 == 0 {
+			data = large
+		}
+		wg.Add(1)
+		go func(data []byte) {
+			defer wg.Done()
+			if _, err := objAPI.PutObject(context.Background(), "bucket", "large-1", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+				t.Error(err)
+			}
+		}(data)
+	}
+	wg.Wait()
+	objInfo, err := objAPI.GetObjectInfo("bucket", "large-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	onlyOn("large-1", sets.getPlacementSet("large-1", objInfo.Size))
+
+	// Uploads are placed like large objects.
+	uploadID, err := objAPI.NewMultipartUpload("bucket", "small-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md5Sum, err := objAPI.PutObjectPart(context.Background(), "bucket", "small-1", uploadID, 1, int64(len(small)), bytes.NewReader(small), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = objAPI.CompleteMultipartUpload("bucket", "small-1", uploadID, []completePart{{PartNumber: 1, ETag: md5Sum}}); err != nil {
+		t.Fatal(err)
+	}
+	onlyOn("small-1", sets.getHashedSet("small-1"))
+
+	var progress rebalanceProgress
+	if err = sets.rebalance(&progress, false, func(int64) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if progress.Failed != 0 {
+		t.Fatalf("Unexpected rebalance progress %#v", progress)
+	}
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("before-%d", i)
+		onlyOn(object, sets.getHashedSet(object))
+	}
+	result, err := objAPI.ListObjects("bucket", "", "", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 30 {
+		t.Fatalf("Expected 30 objects, got %d", len(result.Objects))
+	}
+
+	// Small object sets are kept on restart and can be reset.
+	if objAPI, err = newObjectLayer(disks); err != nil {
+		t.Fatal(err)
+	}
+	sets = objAPI.(*xlSets)
+	if !sets.isSmallObjectSet(0) || sets.isSmallObjectSet(1) || sets.getSmallObjectSize() != 10 {
+		t.Fatalf("Small object sets not loaded %#v", sets.format)
+	}
+	if _, err = objAPI.GetObjectInfo("bucket", "small-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sets.setSmallObjectSets(nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if sets.isSmallObjectSet(0) || sets.getSmallObjectSize() != 0 {
+		t.Fatalf("Small object sets not reset %#v", sets.format)
+	}
+}
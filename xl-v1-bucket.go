@@ -205,59 +205,8 @@ func (xl xlObjects) ListBuckets() ([]BucketInfo, error) {
 	return bucketInfos, nil
 }
 
-// DeleteBucket - deletes a bucket.
+// DeleteBucket - deletes a bucket with its uploads on all disks, if it
+// has no objects on any disk.
 func (xl xlObjects) DeleteBucket(bucket string) error {
-	// Verify if bucket is valid.
-	if !IsValidBucketName(bucket) {
-		return BucketNameInvalid{Bucket: bucket}
-	}
-
-	nsMutex.Lock(bucket, "")
-	defer nsMutex.Unlock(bucket, "")
-
-	// Collect if all disks report volume not found.
-	var volumeNotFoundErrCnt int
-
-	var wg = &sync.WaitGroup{}
-	var dErrs = make([]error, len(xl.storageDisks))
-
-	// Remove a volume entry on all underlying storage disks.
-	for index, disk := range xl.storageDisks {
-		if disk == nil {
-			dErrs[index] = errDiskNotFound
-			continue
-		}
-		wg.Add(1)
-		// Delete volume inside a go-routine.
-		go func(index int, disk StorageAPI) {
-			defer wg.Done()
-			err := disk.DeleteVol(bucket)
-			if err != nil {
-				dErrs[index] = err
-			}
-		}(index, disk)
-	}
-
-	// Wait for all the delete vols to finish.
-	wg.Wait()
-
-	// Count the errors for known errors, return quickly if we found
-	// an unknown error.
-	for _, err := range dErrs {
-		if err != nil {
-			// We ignore error if errVolumeNotFound, errDiskNotFound or errFaultyDisk
-			if err == errVolumeNotFound || err == errDiskNotFound || err == errFaultyDisk {
-				volumeNotFoundErrCnt++
-				continue
-			}
-			return toObjectErr(err, bucket)
-		}
-	}
-
-	// Return errVolumeNotFound if all disks report volume not found.
-	if volumeNotFoundErrCnt == len(xl.storageDisks) {
-		return toObjectErr(errVolumeNotFound, bucket)
-	}
-
-	return nil
+	return xl.deleteBucket(bucket, false)
 }
@@ -17,8 +17,11 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"io"
 	"path"
+	"sort"
 	"sync"
 )
 
@@ -69,7 +72,7 @@ func (xl xlObjects) readAllXLMetadata(bucket, object string) ([]xlMetaV1, []erro
 				errs[index] = err
 				return
 			}
-			err = json.Unmarshal(buffer, &metadataArray[index])
+			err = unmarshalXLMeta(buffer, &metadataArray[index])
 			if err != nil {
 				// Unable to parse xl.json, set error.
 				errs[index] = err
@@ -133,3 +136,216 @@ func (xl xlObjects) listOnlineDisks(partsMetadata []xlMetaV1, errs []error) (onl
 	}
 	return onlineDisks, highestVersion, nil
 }
+
+// HealObject - rewrites the object on all disks from the disks holding
+// its latest version, disks which missed writes or lost data hold the
+// whole object again afterwards. Fails with errSomeDiskOffline if not
+// all disks are online.
+func (xl xlObjects) HealObject(bucket, object string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	if diskCount(xl.storageDisks) < len(xl.storageDisks) {
+		return errSomeDiskOffline
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	// `xl.json` is replaced by a rename, not seen by the cache.
+	defer xl.metaCache.Invalidate(bucket, object)
+
+	if !xl.isObject(bucket, object) {
+		return ObjectNotFound{Bucket: bucket, Object: object}
+	}
+
+	// Read metadata associated with the object from all disks.
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
+	for _, err := range errs {
+		if err == errDiskNotFound || err == errFaultyDisk {
+			return errSomeDiskOffline
+		}
+	}
+
+	// List all online disks.
+	onlineDisks, highestVersion, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+
+	// Pick latest valid metadata.
+	var xlMeta xlMetaV1
+	for _, meta := range partsMetadata {
+		if meta.IsValid() && meta.Stat.Version == highestVersion {
+			xlMeta = meta
+			break
+		}
+	}
+
+	// Collect the erasure infos to read with and fresh ones to write
+	// with, the checksums are recomputed.
+	readEInfos := make([]erasureInfo, len(onlineDisks))
+	writeEInfos := make([]erasureInfo, len(onlineDisks))
+	for index := range onlineDisks {
+		readEInfos[index] = partsMetadata[index].Erasure
+		writeEInfos[index] = xlMeta.Erasure
+		writeEInfos[index].Checksum = nil
+	}
+
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	readDisks := onlineDisks
+	// Disks are set to nil by failed writes, xl.storageDisks must not
+	// be modified.
+	writeDisks := append([]StorageAPI(nil), xl.storageDisks...)
+	inline := xlMeta.Data != nil
+	if inline {
+		inlineData := make([][]byte, len(onlineDisks))
+		for index := range onlineDisks {
+			inlineData[index] = partsMetadata[index].Data
+		}
+		partName := xlMeta.Parts[0].Name
+		readDisks = newInlineDisks(onlineDisks, bucket, pathJoin(object, partName), inlineData)
+		writeDisks = newInlineDisks(writeDisks, minioMetaBucket, pathJoin(tempObj, partName), make([][]byte, len(writeDisks)))
+	}
+
+	// Rewrite all parts to all disks.
+	for _, part := range xlMeta.Parts {
+		var reader io.Reader = bytes.NewReader(nil)
+		if part.Size > 0 {
+			pipeReader, pipeWriter := io.Pipe()
+			go func(part objectPartInfo) {
+				_, rErr := erasureReadFile(context.Background(), pipeWriter, readDisks, bucket, pathJoin(object, part.Name), part.Name, readEInfos, 0, part.Size, part.Size)
+				pipeWriter.CloseWithError(rErr)
+			}(part)
+			defer pipeReader.Close()
+			reader = pipeReader
+		}
+		var n int64
+		writeEInfos, n, err = erasureCreateFile(context.Background(), writeDisks, minioMetaBucket, pathJoin(tempObj, part.Name), part.Name, reader, writeEInfos, len(xl.storageDisks))
+		if err == nil && n != part.Size {
+			err = errUnexpected
+		}
+		if err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return toObjectErr(err, bucket, object)
+		}
+		if diskCount(writeDisks) < len(xl.storageDisks) {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return errSomeDiskOffline
+		}
+	}
+
+	// Update `xl.json` content on each disks.
+	var inlineData [][]byte
+	if inline {
+		inlineData = getInlineData(writeDisks)
+	}
+	for index := range partsMetadata {
+		partsMetadata[index] = xlMeta
+		partsMetadata[index].Erasure = writeEInfos[index]
+		if inline {
+			partsMetadata[index].Data = inlineData[index]
+		}
+	}
+	if err = xl.writeUniqueXLMetadata(minioMetaBucket, tempObj, partsMetadata); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(err, bucket, object)
+	}
+
+	// Replace the object by the healed one.
+	oldObj := path.Join(tmpMetaPrefix, getUUID())
+	if err = xl.renameObject(bucket, object, minioMetaBucket, oldObj); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(err, bucket, object)
+	}
+	if err = xl.renameObject(minioMetaBucket, tempObj, bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	xl.deleteObject(minioMetaBucket, oldObj)
+	return nil
+}
+
+// HealFormat - writes `format.json` to disks which were replaced or
+// wiped. Fails with errSomeDiskOffline if not all disks are online.
+func (xl xlObjects) HealFormat() error {
+	if diskCount(xl.storageDisks) < len(xl.storageDisks) {
+		return errSomeDiskOffline
+	}
+	// Wiped disks lack minioMetaBucket as well.
+	for _, disk := range xl.storageDisks {
+		if err := disk.MakeVol(minioMetaBucket); err != nil && err != errVolumeExists {
+			return err
+		}
+	}
+	return healFormatXL(xl.storageDisks)
+}
+
+// ListBucketsHeal - lists the buckets found on any disk, sorted by
+// name. Buckets missing on a read quorum are listed too, HealBucket
+// reports them as not found.
+func (xl xlObjects) ListBucketsHeal() ([]BucketInfo, error) {
+	buckets := make(map[string]BucketInfo)
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		volsInfo, err := disk.ListVols()
+		if err != nil {
+			return nil, toObjectErr(err)
+		}
+		for _, volInfo := range volsInfo {
+			if !IsValidBucketName(volInfo.Name) {
+				continue
+			}
+			if _, ok := buckets[volInfo.Name]; !ok {
+				buckets[volInfo.Name] = BucketInfo{Name: volInfo.Name, Created: volInfo.Created}
+			}
+		}
+	}
+	bucketsInfo := make([]BucketInfo, 0, len(buckets))
+	for _, bucketInfo := range buckets {
+		bucketsInfo = append(bucketsInfo, bucketInfo)
+	}
+	sort.Sort(byBucketName(bucketsInfo))
+	return bucketsInfo, nil
+}
+
+// HealBucket - creates the bucket on disks which miss it. Fails with
+// errSomeDiskOffline if not all disks are online.
+func (xl xlObjects) HealBucket(bucket string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if diskCount(xl.storageDisks) < len(xl.storageDisks) {
+		return errSomeDiskOffline
+	}
+	nsMutex.Lock(bucket, "")
+	defer nsMutex.Unlock(bucket, "")
+
+	// Only buckets on a read quorum of disks are healed, others are
+	// leftovers of failed creations or deletions.
+	var missing []StorageAPI
+	for _, disk := range xl.storageDisks {
+		_, err := disk.StatVol(bucket)
+		if err == errVolumeNotFound {
+			missing = append(missing, disk)
+			continue
+		}
+		if err != nil {
+			return toObjectErr(err, bucket)
+		}
+	}
+	if len(xl.storageDisks)-len(missing) < xl.readQuorum {
+		return BucketNotFound{Bucket: bucket}
+	}
+	for _, disk := range missing {
+		if err := disk.MakeVol(bucket); err != nil && err != errVolumeExists {
+			return toObjectErr(err, bucket)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"io"
+)
+
+// Objects up to this size are stored inline, the erasure coded data
+// of each disk is kept in its `xl.json` instead of a separate part
+// file, so that an object is a single file on every disk. Objects
+// written by previous releases keep their part files until they are
+// overwritten.
+const xlInlineDataThreshold = 128 * 1024
+
+// inlineDisk - serves a single part file from memory, all other
+// calls are passed to the underlying disk.
+type inlineDisk struct {
+	StorageAPI
+	volume string
+	path   string
+	data   []byte
+}
+
+// newInlineDisks - wraps disks to keep the part at volume/path in
+// memory, data is the inline data of each disk, nil while writing.
+func newInlineDisks(disks []StorageAPI, volume, path string, data [][]byte) []StorageAPI {
+	inlineDisks := make([]StorageAPI, len(disks))
+	for index, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		inlineDisks[index] = &inlineDisk{
+			StorageAPI: disk,
+			volume:     volume,
+			path:       path,
+			data:       data[index],
+		}
+	}
+	return inlineDisks
+}
+
+// getInlineData - returns the inline data of each disk, nil for
+// offline disks.
+func getInlineData(disks []StorageAPI) [][]byte {
+	data := make([][]byte, len(disks))
+	for index, disk := range disks {
+		if d, ok := disk.(*inlineDisk); ok {
+			data[index] = d.data
+			// Empty inline data is still distinguished from no data.
+			if data[index] == nil {
+				data[index] = []byte{}
+			}
+		}
+	}
+	return data
+}
+
+// AppendFile - appends to the inline data of the part.
+func (d *inlineDisk) AppendFile(ctx context.Context, volume, path string, buf []byte) error {
+	if volume != d.volume || path != d.path {
+		return d.StorageAPI.AppendFile(ctx, volume, path, buf)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d.data = append(d.data, buf...)
+	return nil
+}
+
+// ReadFile - reads from the inline data of the part.
+func (d *inlineDisk) ReadFile(ctx context.Context, volume, path string, offset int64, buf []byte) (int64, error) {
+	if volume != d.volume || path != d.path {
+		return d.StorageAPI.ReadFile(ctx, volume, path, offset, buf)
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, errInvalidArgument
+	}
+	if offset >= int64(len(d.data)) {
+		if len(buf) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	return int64(copy(buf, d.data[offset:])), nil
+}
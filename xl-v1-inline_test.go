@@ -0,0 +1,95 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests small objects are stored inline in `xl.json` and larger
+// objects in part files.
+func TestXLInlineObjects(t *testing.T) {
+	objLayer, dirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(dirs)
+
+	if err = objLayer.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		object string
+		size   int
+		inline bool
+	}{
+		{"empty", 0, true},
+		{"small", 1000, true},
+		{"threshold", xlInlineDataThreshold, true},
+		{"large", xlInlineDataThreshold + 1, false},
+	}
+	for i, testCase := range testCases {
+		data := bytes.Repeat([]byte{byte('a' + i)}, testCase.size)
+		if _, err = objLayer.PutObject(context.Background(), "bucket", testCase.object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		_, err = os.Stat(filepath.Join(dirs[0], "bucket", testCase.object, "object1"))
+		if testCase.inline && !os.IsNotExist(err) {
+			t.Errorf("Test %d: Expected no part file for inline object, got %v", i+1, err)
+		}
+		if !testCase.inline && err != nil {
+			t.Errorf("Test %d: Expected part file, got %v", i+1, err)
+		}
+		if testCase.size == 0 {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if err = objLayer.GetObject(context.Background(), "bucket", testCase.object, 0, int64(len(data)), buf); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("Test %d: Object data mismatch", i+1)
+		}
+		// Ranges are served from inline data as well.
+		buf.Reset()
+		if err = objLayer.GetObject(context.Background(), "bucket", testCase.object, 10, 100, buf); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[10:110]) {
+			t.Errorf("Test %d: Object range mismatch", i+1)
+		}
+	}
+
+	// Objects stay readable with disks missing.
+	for _, dir := range dirs[:4] {
+		if err = os.RemoveAll(filepath.Join(dir, "bucket", "small")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err = objLayer.GetObject(context.Background(), "bucket", "small", 0, 1000, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), bytes.Repeat([]byte{'b'}, 1000)) {
+		t.Error("Object data mismatch with disks missing")
+	}
+}
@@ -26,7 +26,8 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 		recursive = false
 	}
 
-	walkResultCh, endWalkCh := xl.listPool.Release(listParams{bucket, recursive, marker, prefix})
+	// Continue the walk of the previous listing if there is one.
+	walkResultCh, endWalkCh, skipMarker := xl.listPool.ReleaseNearest(listParams{bucket, recursive, marker, prefix})
 	if walkResultCh == nil {
 		endWalkCh = make(chan struct{})
 		walkResultCh = xl.startTreeWalk(bucket, prefix, marker, recursive, xl.isObject, endWalkCh)
@@ -35,6 +36,7 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 	var objInfos []ObjectInfo
 	var eof bool
 	var nextMarker string
+	var skipped int
 	for i := 0; i < maxKeys; {
 		walkResult, ok := <-walkResultCh
 		if !ok {
@@ -50,9 +52,39 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 			}
 			return ListObjectsInfo{}, toObjectErr(walkResult.err, bucket, prefix)
 		}
+		// A walk parked at an earlier marker skips up to the marker.
+		if skipMarker != "" && walkResult.entry <= skipMarker {
+			if walkResult.end {
+				eof = true
+				break
+			}
+			if skipped++; skipped > maxWalkSkip {
+				close(endWalkCh)
+				endWalkCh = make(chan struct{})
+				walkResultCh = xl.startTreeWalk(bucket, prefix, marker, recursive, xl.isObject, endWalkCh)
+				skipMarker = ""
+			}
+			continue
+		}
+		skipMarker = ""
 		entry := walkResult.entry
 		var objInfo ObjectInfo
-		if strings.HasSuffix(entry, slashSeparator) {
+		if strings.HasSuffix(entry, slashSeparator) && (recursive || entry == prefix) {
+			// Directories listed here are directory markers.
+			var err error
+			objInfo, err = xl.getDirMarkerInfo(bucket, entry)
+			if _, ok := err.(ObjectNotFound); ok {
+				// Skip directories which are no longer empty.
+				if walkResult.end {
+					eof = true
+					break
+				}
+				continue
+			}
+			if err != nil {
+				return ListObjectsInfo{}, err
+			}
+		} else if strings.HasSuffix(entry, slashSeparator) {
 			// Object name needs to be full path.
 			objInfo.Bucket = bucket
 			objInfo.Name = entry
@@ -61,6 +93,15 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 			// Set the Mode to a "regular" file.
 			var err error
 			objInfo, err = xl.getObjectInfo(bucket, entry)
+			if err == errFileNotFound {
+				// Entry was only found on a minority of the
+				// disks, skip it.
+				if walkResult.end {
+					eof = true
+					break
+				}
+				continue
+			}
 			if err != nil {
 				return ListObjectsInfo{}, toObjectErr(err, bucket, prefix)
 			}
@@ -76,7 +117,13 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 
 	params := listParams{bucket, recursive, nextMarker, prefix}
 	if !eof {
-		xl.listPool.Set(params, walkResultCh, endWalkCh)
+		// A saved walk misses objects written to directories it
+		// already listed, the S3A mode walks afresh for every page.
+		if isS3ACompat() {
+			close(endWalkCh)
+		} else {
+			xl.listPool.Set(params, walkResultCh, endWalkCh)
+		}
 	}
 
 	result := ListObjectsInfo{IsTruncated: !eof}
@@ -87,10 +134,12 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 			continue
 		}
 		result.Objects = append(result.Objects, ObjectInfo{
-			Name:    objInfo.Name,
-			ModTime: objInfo.ModTime,
-			Size:    objInfo.Size,
-			IsDir:   false,
+			Name:     objInfo.Name,
+			ModTime:  objInfo.ModTime,
+			Size:     objInfo.Size,
+			IsDir:    false,
+			MD5Sum:   objInfo.MD5Sum,
+			Metadata: objInfo.Metadata,
 		})
 	}
 	return result, nil
@@ -109,12 +158,6 @@ func (xl xlObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKey
 	if !IsValidObjectPrefix(prefix) {
 		return ListObjectsInfo{}, ObjectNameInvalid{Bucket: bucket, Object: prefix}
 	}
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != slashSeparator {
-		return ListObjectsInfo{}, UnsupportedDelimiter{
-			Delimiter: delimiter,
-		}
-	}
 	// Verify if marker has prefix.
 	if marker != "" {
 		if !strings.HasPrefix(marker, prefix) {
@@ -143,6 +186,11 @@ func (xl xlObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKey
 		maxKeys = maxObjectList
 	}
 
+	// Other delimiters than '/' are rolled up from recursive listings.
+	if !isWalkDelimiter(delimiter) {
+		return listObjectsDelimited(xl.ListObjects, bucket, prefix, marker, delimiter, maxKeys)
+	}
+
 	// Initiate a list operation, if successful filter and return quickly.
 	listObjInfo, err := xl.listObjects(bucket, prefix, marker, delimiter, maxKeys)
 	if err == nil {
@@ -0,0 +1,272 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// `xl.json` written in binary format starts with xlMetaBinaryMagic
+// followed by the version of the encoding, files without the magic are
+// parsed as JSON as written by previous releases. Version 2 adds inline
+// object data.
+const (
+	xlMetaBinaryMagic   = "XLMB"
+	xlMetaBinaryVersion = 2
+)
+
+// errXLMetaCorrupted - returned for truncated or malformed binary `xl.json`.
+var errXLMetaCorrupted = errors.New("Corrupted binary xl.json metadata")
+
+// errXLMetaVersion - returned for binary `xl.json` of a newer encoding.
+var errXLMetaVersion = errors.New("Unsupported binary xl.json metadata version")
+
+// xlMetaEncoder - appends values of `xl.json` in binary format.
+type xlMetaEncoder struct {
+	buf []byte
+	tmp [binary.MaxVarintLen64]byte
+}
+
+func (e *xlMetaEncoder) putUvarint(v uint64) {
+	n := binary.PutUvarint(e.tmp[:], v)
+	e.buf = append(e.buf, e.tmp[:n]...)
+}
+
+func (e *xlMetaEncoder) putVarint(v int64) {
+	n := binary.PutVarint(e.tmp[:], v)
+	e.buf = append(e.buf, e.tmp[:n]...)
+}
+
+func (e *xlMetaEncoder) putString(s string) {
+	e.putUvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *xlMetaEncoder) putTime(t time.Time) {
+	e.putVarint(t.Unix())
+	e.putVarint(int64(t.Nanosecond()))
+}
+
+// xlMetaDecoder - reads values of `xl.json` in binary format, the
+// first error is kept and returned by all following reads.
+type xlMetaDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *xlMetaDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errXLMetaCorrupted
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *xlMetaDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errXLMetaCorrupted
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// length - reads the length of a string or a list, which cannot be
+// longer than the remaining input.
+func (d *xlMetaDecoder) length() int {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.err = errXLMetaCorrupted
+		return 0
+	}
+	return int(n)
+}
+
+func (d *xlMetaDecoder) string() string {
+	n := d.length()
+	if d.err != nil {
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+func (d *xlMetaDecoder) time() time.Time {
+	sec := d.varint()
+	nsec := d.varint()
+	return time.Unix(sec, nsec).UTC()
+}
+
+// MarshalBinary - encodes `xl.json` in binary format.
+func (m xlMetaV1) MarshalBinary() ([]byte, error) {
+	e := &xlMetaEncoder{buf: make([]byte, 0, 512)}
+	e.buf = append(e.buf, xlMetaBinaryMagic...)
+	e.putUvarint(xlMetaBinaryVersion)
+
+	e.putString(m.Version)
+	e.putString(m.Format)
+	e.putVarint(m.Stat.Size)
+	e.putTime(m.Stat.ModTime)
+	e.putVarint(m.Stat.Version)
+
+	e.putString(m.Erasure.Algorithm)
+	e.putVarint(int64(m.Erasure.DataBlocks))
+	e.putVarint(int64(m.Erasure.ParityBlocks))
+	e.putVarint(m.Erasure.BlockSize)
+	e.putVarint(int64(m.Erasure.Index))
+	e.putUvarint(uint64(len(m.Erasure.Distribution)))
+	for _, index := range m.Erasure.Distribution {
+		e.putVarint(int64(index))
+	}
+	e.putUvarint(uint64(len(m.Erasure.Checksum)))
+	for _, checkSum := range m.Erasure.Checksum {
+		e.putString(checkSum.Name)
+		e.putString(checkSum.Algorithm)
+		e.putString(checkSum.Hash)
+	}
+
+	e.putString(m.Minio.Release)
+
+	// Keys are sorted so that equal metadata is encoded the same.
+	keys := make([]string, 0, len(m.Meta))
+	for key := range m.Meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e.putUvarint(uint64(len(keys)))
+	for _, key := range keys {
+		e.putString(key)
+		e.putString(m.Meta[key])
+	}
+
+	e.putUvarint(uint64(len(m.Parts)))
+	for _, part := range m.Parts {
+		e.putVarint(int64(part.Number))
+		e.putString(part.Name)
+		e.putString(part.ETag)
+		e.putVarint(part.Size)
+	}
+
+	// Inline data is flagged, so that empty data is kept apart from none.
+	if m.Data == nil {
+		e.putUvarint(0)
+	} else {
+		e.putUvarint(1)
+		e.putUvarint(uint64(len(m.Data)))
+		e.buf = append(e.buf, m.Data...)
+	}
+	return e.buf, nil
+}
+
+// UnmarshalBinary - decodes `xl.json` in binary format.
+func (m *xlMetaV1) UnmarshalBinary(buf []byte) error {
+	if !bytes.HasPrefix(buf, []byte(xlMetaBinaryMagic)) {
+		return errXLMetaCorrupted
+	}
+	d := &xlMetaDecoder{buf: buf[len(xlMetaBinaryMagic):]}
+	version := d.uvarint()
+	if d.err == nil && (version < 1 || version > xlMetaBinaryVersion) {
+		return errXLMetaVersion
+	}
+
+	var xlMeta xlMetaV1
+	xlMeta.Version = d.string()
+	xlMeta.Format = d.string()
+	xlMeta.Stat.Size = d.varint()
+	xlMeta.Stat.ModTime = d.time()
+	xlMeta.Stat.Version = d.varint()
+
+	xlMeta.Erasure.Algorithm = d.string()
+	xlMeta.Erasure.DataBlocks = int(d.varint())
+	xlMeta.Erasure.ParityBlocks = int(d.varint())
+	xlMeta.Erasure.BlockSize = d.varint()
+	xlMeta.Erasure.Index = int(d.varint())
+	if n := d.length(); n > 0 {
+		xlMeta.Erasure.Distribution = make([]int, n)
+		for i := range xlMeta.Erasure.Distribution {
+			xlMeta.Erasure.Distribution[i] = int(d.varint())
+		}
+	}
+	if n := d.length(); n > 0 {
+		xlMeta.Erasure.Checksum = make([]checkSumInfo, n)
+		for i := range xlMeta.Erasure.Checksum {
+			xlMeta.Erasure.Checksum[i] = checkSumInfo{
+				Name:      d.string(),
+				Algorithm: d.string(),
+				Hash:      d.string(),
+			}
+		}
+	}
+
+	xlMeta.Minio.Release = d.string()
+
+	n := d.length()
+	xlMeta.Meta = make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		key := d.string()
+		xlMeta.Meta[key] = d.string()
+	}
+
+	if n := d.length(); n > 0 {
+		xlMeta.Parts = make([]objectPartInfo, n)
+		for i := range xlMeta.Parts {
+			xlMeta.Parts[i] = objectPartInfo{
+				Number: int(d.varint()),
+				Name:   d.string(),
+				ETag:   d.string(),
+				Size:   d.varint(),
+			}
+		}
+	}
+
+	if version >= 2 && d.uvarint() == 1 {
+		n := d.length()
+		if d.err == nil {
+			xlMeta.Data = append([]byte{}, d.buf[:n]...)
+			d.buf = d.buf[n:]
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	*m = xlMeta
+	return nil
+}
+
+// unmarshalXLMeta - decodes `xl.json` in binary format, or in JSON
+// format as written by previous releases.
+func unmarshalXLMeta(buf []byte, xlMeta *xlMetaV1) error {
+	if bytes.HasPrefix(buf, []byte(xlMetaBinaryMagic)) {
+		return xlMeta.UnmarshalBinary(buf)
+	}
+	return json.Unmarshal(buf, xlMeta)
+}
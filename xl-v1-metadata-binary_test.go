@@ -0,0 +1,144 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Returns xl metadata of a multipart object for tests.
+func newTestXLMeta() xlMetaV1 {
+	xlMeta := newXLMetaV1(4, 4)
+	xlMeta.Stat.Size = 20 * 1024 * 1024
+	xlMeta.Stat.ModTime = time.Date(2016, 8, 1, 10, 20, 30, 123456789, time.UTC)
+	xlMeta.Stat.Version = 3
+	xlMeta.Erasure.Index = 2
+	xlMeta.Erasure.Checksum = []checkSumInfo{
+		{Name: "part.1", Algorithm: "blake2b", Hash: "0123456789abcdef"},
+		{Name: "part.2", Algorithm: "blake2b", Hash: "fedcba9876543210"},
+	}
+	xlMeta.Meta = map[string]string{
+		"md5Sum":       "d41d8cd98f00b204e9800998ecf8427e-2",
+		"content-type": "application/octet-stream",
+	}
+	xlMeta.AddObjectPart(1, "part.1", "etag1", 10*1024*1024)
+	xlMeta.AddObjectPart(2, "part.2", "etag2", 10*1024*1024)
+	return xlMeta
+}
+
+// Tests encoding and decoding xl metadata in binary format.
+func TestXLMetaBinary(t *testing.T) {
+	xlMeta := newTestXLMeta()
+	buf, err := xlMeta.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonBuf, err := json.Marshal(xlMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) >= len(jsonBuf) {
+		t.Errorf("Expected binary metadata (%d bytes) to be smaller than JSON (%d bytes)", len(buf), len(jsonBuf))
+	}
+
+	var decoded xlMetaV1
+	if err = unmarshalXLMeta(buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(xlMeta, decoded) {
+		t.Fatalf("Expected %#v, got %#v", xlMeta, decoded)
+	}
+
+	// Inline data, empty or not, is kept apart from no data.
+	for _, data := range [][]byte{{}, []byte("inline data")} {
+		inlineMeta := newTestXLMeta()
+		inlineMeta.Data = data
+		inlineBuf, err := inlineMeta.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var inlineDecoded xlMetaV1
+		if err = unmarshalXLMeta(inlineBuf, &inlineDecoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(inlineMeta, inlineDecoded) {
+			t.Fatalf("Expected %#v, got %#v", inlineMeta, inlineDecoded)
+		}
+	}
+
+	// Metadata written by previous releases is parsed as JSON.
+	var fromJSON xlMetaV1
+	if err = unmarshalXLMeta(jsonBuf, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(xlMeta, fromJSON) {
+		t.Fatalf("Expected %#v, got %#v", xlMeta, fromJSON)
+	}
+
+	// Truncated metadata is rejected.
+	for i := len(xlMetaBinaryMagic); i < len(buf); i++ {
+		if err = unmarshalXLMeta(buf[:i], &decoded); err != errXLMetaCorrupted {
+			t.Fatalf("Expected %v for %d bytes, got %v", errXLMetaCorrupted, i, err)
+		}
+	}
+
+	// Metadata of newer encodings is rejected.
+	newer := append([]byte(xlMetaBinaryMagic), byte(xlMetaBinaryVersion+1))
+	if err = unmarshalXLMeta(newer, &decoded); err != errXLMetaVersion {
+		t.Fatalf("Expected %v, got %v", errXLMetaVersion, err)
+	}
+}
+
+// Tests dumping xl metadata as JSON.
+func TestDumpXLMeta(t *testing.T) {
+	dir, err := ioutil.TempDir("", "minio-xl-meta-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(dir)
+
+	xlMeta := newTestXLMeta()
+	buf, err := xlMeta.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, xlMetaJSONFile), buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, filePath := range []string{dir, filepath.Join(dir, xlMetaJSONFile)} {
+		dump, err := dumpXLMeta(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded xlMetaV1
+		if err = json.Unmarshal(dump, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(xlMeta, decoded) {
+			t.Fatalf("Expected %#v, got %#v", xlMeta, decoded)
+		}
+	}
+	if _, err = dumpXLMeta(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("Expected not exist error, got %v", err)
+	}
+}
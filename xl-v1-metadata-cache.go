@@ -0,0 +1,148 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"container/list"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Maximum number of `xl.json` entries kept in memory.
+const xlMetaCacheSize = 10000
+
+// xlMetaCacheEntry - cached `xl.json` content of an object.
+type xlMetaCacheEntry struct {
+	key    string
+	xlMeta xlMetaV1
+}
+
+// xlMetaCache - LRU of recently read `xl.json` content of objects,
+// entries are invalidated on every write to an object. A nil cache
+// caches nothing.
+type xlMetaCache struct {
+	mutex   *sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int
+	// Incremented on every invalidation, entries read from disk are only
+	// added if no invalidation happened while they were being read.
+	version uint64
+}
+
+// newXLMetaCache - returns an empty cache of size entries.
+func newXLMetaCache(size int) *xlMetaCache {
+	return &xlMetaCache{
+		mutex:   &sync.Mutex{},
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		size:    size,
+	}
+}
+
+// Get - returns the cached `xl.json` content of an object and the
+// current version of the cache.
+func (c *xlMetaCache) Get(bucket, object string) (xlMetaV1, uint64, bool) {
+	if c == nil {
+		return xlMetaV1{}, 0, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[path.Join(bucket, object)]
+	if !ok {
+		return xlMetaV1{}, c.version, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*xlMetaCacheEntry).xlMeta, c.version, true
+}
+
+// Add - caches `xl.json` content of an object read at version,
+// evicting the least recently used entry if the cache is full.
+func (c *xlMetaCache) Add(bucket, object string, xlMeta xlMetaV1, version uint64) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if version != c.version {
+		return
+	}
+	key := path.Join(bucket, object)
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*xlMetaCacheEntry).xlMeta = xlMeta
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&xlMetaCacheEntry{key: key, xlMeta: xlMeta})
+	for c.lru.Len() > c.size {
+		elem := c.lru.Back()
+		c.lru.Remove(elem)
+		delete(c.entries, elem.Value.(*xlMetaCacheEntry).key)
+	}
+}
+
+// Invalidate - removes an object from the cache.
+func (c *xlMetaCache) Invalidate(bucket, object string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version++
+	key := path.Join(bucket, object)
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// InvalidateBucket - removes all objects of a bucket from the cache.
+func (c *xlMetaCache) InvalidateBucket(bucket string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version++
+	prefix := bucket + slashSeparator
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Len - returns the number of cached entries.
+func (c *xlMetaCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+// isRemoteDisks - returns true if any of the disks is served by
+// another server.
+func isRemoteDisks(disks []string) bool {
+	for _, disk := range disks {
+		if strings.ContainsRune(disk, ':') && filepath.VolumeName(disk) == "" {
+			return true
+		}
+	}
+	return false
+}
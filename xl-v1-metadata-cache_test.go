@@ -0,0 +1,121 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// Tests LRU eviction and invalidation of the metadata cache.
+func TestXLMetaCache(t *testing.T) {
+	cache := newXLMetaCache(2)
+	_, version, ok := cache.Get("bucket", "a")
+	if ok {
+		t.Fatal("Expected empty cache")
+	}
+	cache.Add("bucket", "a", xlMetaV1{Version: "a"}, version)
+	cache.Add("bucket", "b", xlMetaV1{Version: "b"}, version)
+	// Touch "a" so that "b" is evicted.
+	if xlMeta, _, ok := cache.Get("bucket", "a"); !ok || xlMeta.Version != "a" {
+		t.Fatal("Expected cached entry a")
+	}
+	cache.Add("bucket", "c", xlMetaV1{Version: "c"}, version)
+	if _, _, ok = cache.Get("bucket", "b"); ok {
+		t.Fatal("Expected b to be evicted")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", cache.Len())
+	}
+
+	// Invalidated entries are removed, entries read before the
+	// invalidation are not added.
+	cache.Invalidate("bucket", "a")
+	if _, _, ok = cache.Get("bucket", "a"); ok {
+		t.Fatal("Expected a to be invalidated")
+	}
+	cache.Add("bucket", "a", xlMetaV1{Version: "a"}, version)
+	if _, _, ok = cache.Get("bucket", "a"); ok {
+		t.Fatal("Expected stale entry not to be added")
+	}
+
+	// A nil cache caches nothing.
+	var nilCache *xlMetaCache
+	nilCache.Add("bucket", "a", xlMetaV1{}, 0)
+	nilCache.Invalidate("bucket", "a")
+	if _, _, ok = nilCache.Get("bucket", "a"); ok {
+		t.Fatal("Expected nil cache to be empty")
+	}
+}
+
+// Tests object info is served from the metadata cache and stays
+// consistent with writes.
+func TestXLMetaCacheObjects(t *testing.T) {
+	objLayer, dirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(dirs)
+	xl := objLayer.(xlObjects)
+	if xl.metaCache == nil {
+		t.Fatal("Expected metadata cache for local disks")
+	}
+
+	if err = objLayer.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = objLayer.PutObject(context.Background(), "bucket", "object", 5, strings.NewReader("hello"), nil); err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := objLayer.GetObjectInfo("bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != 5 {
+		t.Fatalf("Expected size 5, got %d", objInfo.Size)
+	}
+	if _, _, ok := xl.metaCache.Get("bucket", "object"); !ok {
+		t.Fatal("Expected object metadata to be cached")
+	}
+
+	// Overwriting the object invalidates the cached metadata.
+	if _, err = objLayer.PutObject(context.Background(), "bucket", "object", 11, strings.NewReader("hello world"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if objInfo, err = objLayer.GetObjectInfo("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != 11 {
+		t.Fatalf("Expected size 11, got %d", objInfo.Size)
+	}
+
+	// Deleted objects are not served from the cache.
+	if err = objLayer.DeleteObject("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = objLayer.GetObjectInfo("bucket", "object"); err == nil {
+		t.Fatal("Expected deleted object to be not found")
+	}
+
+	if isRemoteDisks([]string{"/mnt/disk1", "/mnt/disk2"}) {
+		t.Fatal("Expected local disks")
+	}
+	if !isRemoteDisks([]string{"/mnt/disk1", "server:/mnt/disk2"}) {
+		t.Fatal("Expected remote disks")
+	}
+}
@@ -17,7 +17,7 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"path"
 	"sort"
 	"sync"
@@ -105,6 +105,9 @@ type xlMetaV1 struct {
 	Meta map[string]string `json:"meta"`
 	// Captures all the individual object `xl.json`.
 	Parts []objectPartInfo `json:"parts,omitempty"`
+	// Erasure coded data of this disk for objects stored inline, nil
+	// if the data is kept in part files.
+	Data []byte `json:"data,omitempty"`
 }
 
 // newXLMetaV1 - initializes new xlMetaV1, adds version, allocates a
@@ -195,8 +198,30 @@ func pickValidXLMeta(xlMetas []xlMetaV1) xlMetaV1 {
 }
 
 // readXLMetadata - returns the object metadata `xl.json` content from
-// one of the disks picked at random.
+// the metadata cache or one of the disks picked at random.
 func (xl xlObjects) readXLMetadata(bucket, object string) (xlMeta xlMetaV1, err error) {
+	// Metadata of multipart uploads and temporary objects is not cached.
+	if bucket == minioMetaBucket {
+		return xl.readXLMetadataFromDisks(bucket, object)
+	}
+	xlMeta, version, ok := xl.metaCache.Get(bucket, object)
+	if ok {
+		return xlMeta, nil
+	}
+	xlMeta, err = xl.readXLMetadataFromDisks(bucket, object)
+	if err != nil {
+		return xlMetaV1{}, err
+	}
+	// Inline data is read along with the object, it is not cached.
+	cached := xlMeta
+	cached.Data = nil
+	xl.metaCache.Add(bucket, object, cached, version)
+	return cached, nil
+}
+
+// readXLMetadataFromDisks - returns the object metadata `xl.json`
+// content from one of the disks picked at random.
+func (xl xlObjects) readXLMetadataFromDisks(bucket, object string) (xlMeta xlMetaV1, err error) {
 	for _, disk := range xl.getLoadBalancedQuorumDisks() {
 		if disk == nil {
 			continue
@@ -205,18 +230,19 @@ func (xl xlObjects) readXLMetadata(bucket, object string) (xlMeta xlMetaV1, err
 		buf, err = readAll(disk, bucket, path.Join(object, xlMetaJSONFile))
 		if err != nil {
 			// For any reason disk is not available continue and read from other disks.
-			if err == errDiskNotFound || err == errFaultyDisk {
+			// A replaced disk misses the object until it is healed.
+			if err == errDiskNotFound || err == errFaultyDisk || err == errFileNotFound {
 				continue
 			}
 			return xlMetaV1{}, err
 		}
-		err = json.Unmarshal(buf, &xlMeta)
+		err = unmarshalXLMeta(buf, &xlMeta)
 		if err != nil {
 			return xlMetaV1{}, err
 		}
 		break
 	}
-	return xlMeta, nil
+	return xlMeta, err
 }
 
 // Undo rename xl metadata, renames successfully renamed `xl.json` back to source location.
@@ -253,13 +279,13 @@ func deleteXLMetdata(disk StorageAPI, bucket, prefix string) error {
 func writeXLMetadata(disk StorageAPI, bucket, prefix string, xlMeta xlMetaV1) error {
 	jsonFile := path.Join(prefix, xlMetaJSONFile)
 
-	// Marshal json.
-	metadataBytes, err := json.Marshal(&xlMeta)
+	// Marshal binary metadata.
+	metadataBytes, err := xlMeta.MarshalBinary()
 	if err != nil {
 		return err
 	}
 	// Persist marshalled data.
-	return disk.AppendFile(bucket, jsonFile, metadataBytes)
+	return disk.AppendFile(context.Background(), bucket, jsonFile, metadataBytes)
 }
 
 // deleteAllXLMetadata - deletes all partially written `xl.json` depending on errs.
@@ -285,6 +311,8 @@ func (xl xlObjects) deleteAllXLMetadata(bucket, prefix string, errs []error) {
 
 // writeUniqueXLMetadata - writes unique `xl.json` content for each disk in order.
 func (xl xlObjects) writeUniqueXLMetadata(bucket, prefix string, xlMetas []xlMetaV1) error {
+	defer xl.metaCache.Invalidate(bucket, prefix)
+
 	var wg = &sync.WaitGroup{}
 	var mErrs = make([]error, len(xl.storageDisks))
 
@@ -338,6 +366,8 @@ func (xl xlObjects) writeUniqueXLMetadata(bucket, prefix string, xlMetas []xlMet
 
 // writeSameXLMetadata - write `xl.json` on all disks in order.
 func (xl xlObjects) writeSameXLMetadata(bucket, prefix string, xlMeta xlMetaV1) error {
+	defer xl.metaCache.Invalidate(bucket, prefix)
+
 	var wg = &sync.WaitGroup{}
 	var mErrs = make([]error, len(xl.storageDisks))
 
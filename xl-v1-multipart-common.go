@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"path"
 	"sort"
@@ -105,7 +106,7 @@ func updateUploadsJSON(bucket, object string, uploadsJSON uploadsV1, storageDisk
 				errs[index] = wErr
 				return
 			}
-			if wErr = disk.AppendFile(minioMetaBucket, tmpUploadsPath, uploadsBytes); wErr != nil {
+			if wErr = disk.AppendFile(context.Background(), minioMetaBucket, tmpUploadsPath, uploadsBytes); wErr != nil {
 				errs[index] = wErr
 				return
 			}
@@ -214,7 +215,7 @@ func writeUploadJSON(bucket, object, uploadID string, initiated time.Time, stora
 				return
 			}
 			// Write `uploads.json` to disk.
-			if wErr = disk.AppendFile(minioMetaBucket, tmpUploadsPath, uploadsJSONBytes); wErr != nil {
+			if wErr = disk.AppendFile(context.Background(), minioMetaBucket, tmpUploadsPath, uploadsJSONBytes); wErr != nil {
 				errs[index] = wErr
 				return
 			}
@@ -415,6 +416,23 @@ func (xl xlObjects) removeObjectPart(bucket, object, uploadID, partName string)
 	wg.Wait()
 }
 
+// Removes a temporary part file from minioMetaBucket, left by a failed part upload.
+func (xl xlObjects) removeTmpPart(tmpPartPath string) {
+	wg := sync.WaitGroup{}
+	for i, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			// Ignoring failure, temporary files are not part of any object.
+			_ = disk.DeleteFile(minioMetaBucket, tmpPartPath)
+		}(i, disk)
+	}
+	wg.Wait()
+}
+
 // statPart - returns fileInfo structure for a successful stat on part file.
 func (xl xlObjects) statPart(bucket, object, uploadID, partName string) (fileInfo FileInfo, err error) {
 	partNamePath := path.Join(mpartMetaPrefix, bucket, object, uploadID, partName)
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
@@ -33,11 +34,19 @@ import (
 // listMultipartUploads - lists all multipart uploads.
 func (xl xlObjects) listMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
 	result := ListMultipartsInfo{
-		IsTruncated: true,
-		MaxUploads:  maxUploads,
-		KeyMarker:   keyMarker,
-		Prefix:      prefix,
-		Delimiter:   delimiter,
+		MaxUploads:     maxUploads,
+		KeyMarker:      keyMarker,
+		UploadIDMarker: uploadIDMarker,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+	}
+	if maxUploads > maxUploadsList {
+		maxUploads = maxUploadsList
+	}
+
+	keyMarker, uploadIDMarker, done := getMultipartMarkers(prefix, keyMarker, uploadIDMarker)
+	if done {
+		return result, nil
 	}
 
 	recursive := true
@@ -56,120 +65,60 @@ func (xl xlObjects) listMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 	if keyMarker != "" {
 		multipartMarkerPath = pathJoin(mpartMetaPrefix, bucket, keyMarker)
 	}
-	var uploads []uploadMetadata
-	var err error
-	var eof bool
-	// List all upload ids for the keyMarker starting from
-	// uploadIDMarker first.
-	if uploadIDMarker != "" {
-		nsMutex.RLock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, keyMarker))
-		for _, disk := range xl.getLoadBalancedQuorumDisks() {
-			if disk == nil {
-				continue
-			}
-			uploads, _, err = listMultipartUploadIDs(bucket, keyMarker, uploadIDMarker, maxUploads, disk)
-			if err == errDiskNotFound || err == errFaultyDisk {
-				continue
-			}
-			break
-		}
-		nsMutex.RUnlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, keyMarker))
-		if err != nil {
-			return ListMultipartsInfo{}, err
+
+	// The tree walk is started lazily, listing the uploads of the key
+	// marker alone might fill the page.
+	var walkerCh chan treeWalkResult
+	var walkerDoneCh chan struct{}
+	defer func() {
+		if walkerDoneCh != nil {
+			close(walkerDoneCh)
 		}
-		maxUploads = maxUploads - len(uploads)
-	}
-	// Validate if we need to list further depending on maxUploads.
-	if maxUploads > 0 {
-		walkerCh, walkerDoneCh := xl.listPool.Release(listParams{minioMetaBucket, recursive, multipartMarkerPath, multipartPrefixPath})
+	}()
+	nextEntry := func() (string, bool, error) {
 		if walkerCh == nil {
-			walkerDoneCh = make(chan struct{})
-			walkerCh = xl.startTreeWalk(minioMetaBucket, multipartPrefixPath, multipartMarkerPath, recursive, xl.isMultipartUpload, walkerDoneCh)
+			walkerCh, walkerDoneCh = xl.listPool.Release(listParams{minioMetaBucket, recursive, multipartMarkerPath, multipartPrefixPath})
+			if walkerCh == nil {
+				walkerDoneCh = make(chan struct{})
+				walkerCh = xl.startTreeWalk(minioMetaBucket, multipartPrefixPath, multipartMarkerPath, recursive, xl.isMultipartUpload, walkerDoneCh)
+			}
 		}
-		// Collect uploads until we have reached maxUploads count to 0.
-		for maxUploads > 0 {
+		for {
 			walkResult, ok := <-walkerCh
 			if !ok {
-				// Closed channel.
-				eof = true
-				break
+				return "", false, nil
 			}
-			// For any walk error return right away.
 			if walkResult.err != nil {
 				// File not found or Disk not found is a valid case.
 				if walkResult.err == errFileNotFound || walkResult.err == errDiskNotFound || walkResult.err == errFaultyDisk {
 					continue
 				}
-				return ListMultipartsInfo{}, err
+				return "", false, walkResult.err
 			}
-			entry := strings.TrimPrefix(walkResult.entry, retainSlash(pathJoin(mpartMetaPrefix, bucket)))
-			// For an entry looking like a directory, store and
-			// continue the loop not need to fetch uploads.
-			if strings.HasSuffix(walkResult.entry, slashSeparator) {
-				uploads = append(uploads, uploadMetadata{
-					Object: entry,
-				})
-				maxUploads--
-				if maxUploads == 0 {
-					eof = true
-					break
-				}
+			return strings.TrimPrefix(walkResult.entry, retainSlash(pathJoin(mpartMetaPrefix, bucket))), true, nil
+		}
+	}
+	listUploadIDs := func(object, uploadIDMarker string, count int) (uploads []uploadMetadata, err error) {
+		nsMutex.RLock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
+		defer nsMutex.RUnlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
+		for _, disk := range xl.getLoadBalancedQuorumDisks() {
+			if disk == nil {
 				continue
 			}
-			var newUploads []uploadMetadata
-			var end bool
-			uploadIDMarker = ""
-			// For the new object entry we get all its pending uploadIDs.
-			nsMutex.RLock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, entry))
-			var disk StorageAPI
-			for _, disk = range xl.getLoadBalancedQuorumDisks() {
-				if disk == nil {
-					continue
-				}
-				newUploads, end, err = listMultipartUploadIDs(bucket, entry, uploadIDMarker, maxUploads, disk)
-				if err == errDiskNotFound || err == errFaultyDisk {
-					continue
-				}
-				break
-			}
-			nsMutex.RUnlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, entry))
-			if err != nil {
-				if err == errFileNotFound || walkResult.err == errDiskNotFound || walkResult.err == errFaultyDisk {
-					continue
-				}
-				return ListMultipartsInfo{}, err
-			}
-			uploads = append(uploads, newUploads...)
-			maxUploads -= len(newUploads)
-			if end && walkResult.end {
-				eof = true
-				break
+			uploads, _, err = listMultipartUploadIDs(bucket, object, uploadIDMarker, count, disk)
+			if err == errDiskNotFound || err == errFaultyDisk {
+				continue
 			}
+			break
 		}
+		return uploads, err
 	}
-	// For all received uploads fill in the multiparts result.
-	for _, upload := range uploads {
-		var objectName string
-		var uploadID string
-		if strings.HasSuffix(upload.Object, slashSeparator) {
-			// All directory entries are common prefixes.
-			uploadID = "" // For common prefixes, upload ids are empty.
-			objectName = upload.Object
-			result.CommonPrefixes = append(result.CommonPrefixes, objectName)
-		} else {
-			uploadID = upload.UploadID
-			objectName = upload.Object
-			result.Uploads = append(result.Uploads, upload)
-		}
-		result.NextKeyMarker = objectName
-		result.NextUploadIDMarker = uploadID
-	}
-	result.IsTruncated = !eof
-	// Result is not truncated, reset the markers.
-	if !result.IsTruncated {
-		result.NextKeyMarker = ""
-		result.NextUploadIDMarker = ""
+
+	uploads, truncated, err := listMultipartsPage(keyMarker, uploadIDMarker, maxUploads, nextEntry, listUploadIDs)
+	if err != nil {
+		return ListMultipartsInfo{}, err
 	}
+	fillMultipartsInfo(&result, uploads, truncated)
 	return result, nil
 }
 
@@ -195,20 +144,11 @@ func (xl xlObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 	if !IsValidObjectPrefix(prefix) {
 		return ListMultipartsInfo{}, ObjectNameInvalid{Bucket: bucket, Object: prefix}
 	}
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != slashSeparator {
-		return ListMultipartsInfo{}, UnsupportedDelimiter{
-			Delimiter: delimiter,
-		}
-	}
-	// Verify if marker has prefix.
-	if keyMarker != "" && !strings.HasPrefix(keyMarker, prefix) {
-		return ListMultipartsInfo{}, InvalidMarkerPrefixCombination{
-			Marker: keyMarker,
-			Prefix: prefix,
-		}
+	// Other delimiters than '/' are rolled up from recursive listings.
+	if !isWalkDelimiter(delimiter) {
+		return listMultipartUploadsDelimited(xl.ListMultipartUploads, bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
 	}
-	if uploadIDMarker != "" {
+	if keyMarker != "" && uploadIDMarker != "" {
 		if strings.HasSuffix(keyMarker, slashSeparator) {
 			return result, InvalidUploadIDKeyCombination{
 				UploadIDMarker: uploadIDMarker,
@@ -304,7 +244,7 @@ func (xl xlObjects) NewMultipartUpload(bucket, object string, meta map[string]st
 // putObjectPart - reads incoming data until EOF for the part file on
 // an ongoing multipart transaction. Internally incoming data is
 // erasure coded and written across all disks.
-func (xl xlObjects) putObjectPart(bucket string, object string, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+func (xl xlObjects) putObjectPart(ctx context.Context, bucket string, object string, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
 	// Hold the lock and start the operation.
 	uploadIDPath := pathJoin(mpartMetaPrefix, bucket, object, uploadID)
 	nsMutex.Lock(minioMetaBucket, uploadIDPath)
@@ -347,8 +287,10 @@ func (xl xlObjects) putObjectPart(bucket string, object string, uploadID string,
 	}
 
 	// Erasure code data and write across all disks.
-	newEInfos, n, err := erasureCreateFile(onlineDisks, minioMetaBucket, tmpPartPath, partSuffix, teeReader, eInfos, xl.writeQuorum)
+	newEInfos, n, err := erasureCreateFile(ctx, onlineDisks, minioMetaBucket, tmpPartPath, partSuffix, teeReader, eInfos, xl.writeQuorum)
 	if err != nil {
+		// Data could not be read or written, delete the temporary part.
+		xl.removeTmpPart(tmpPartPath)
 		return "", toObjectErr(err, minioMetaBucket, tmpPartPath)
 	}
 	if size == -1 {
@@ -358,8 +300,8 @@ func (xl xlObjects) putObjectPart(bucket string, object string, uploadID string,
 	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
 	if md5Hex != "" {
 		if newMD5Hex != md5Hex {
-			// MD5 mismatch, delete the temporary object.
-			xl.deleteObject(minioMetaBucket, tmpPartPath)
+			// MD5 mismatch, delete the temporary part.
+			xl.removeTmpPart(tmpPartPath)
 			// Returns md5 mismatch.
 			return "", BadDigest{md5Hex, newMD5Hex}
 		}
@@ -411,7 +353,7 @@ func (xl xlObjects) putObjectPart(bucket string, object string, uploadID string,
 // of the multipart transcation.
 //
 // Implements S3 compatible Upload Part API.
-func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+func (xl xlObjects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
@@ -423,7 +365,7 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	if !IsValidObjectName(object) {
 		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
 	}
-	return xl.putObjectPart(bucket, object, uploadID, partID, size, data, md5Hex)
+	return xl.putObjectPart(ctx, bucket, object, uploadID, partID, size, data, md5Hex)
 }
 
 // listObjectParts - wrapper reading `xl.json` for a given object and
@@ -443,24 +385,11 @@ func (xl xlObjects) listObjectParts(bucket, object, uploadID string, partNumberM
 	result.Object = object
 	result.UploadID = uploadID
 	result.MaxParts = maxParts
-
-	// For empty number of parts or maxParts as zero, return right here.
-	if len(xlMeta.Parts) == 0 || maxParts == 0 {
-		return result, nil
-	}
-
-	// Limit output to maxPartsList.
-	if maxParts > maxPartsList {
-		maxParts = maxPartsList
-	}
+	result.PartNumberMarker = partNumberMarker
+	result.Metadata = xlMeta.Meta
 
 	// Only parts with higher part numbers will be listed.
-	partIdx := xlMeta.ObjectPartIndex(partNumberMarker)
-	parts := xlMeta.Parts
-	if partIdx != -1 {
-		parts = xlMeta.Parts[partIdx+1:]
-	}
-	count := maxParts
+	parts, truncated := listPartsPage(xlMeta.Parts, partNumberMarker, maxParts)
 	for _, part := range parts {
 		var fi FileInfo
 		fi, err = xl.statPart(bucket, object, uploadID, part.Name)
@@ -473,18 +402,15 @@ func (xl xlObjects) listObjectParts(bucket, object, uploadID string, partNumberM
 			LastModified: fi.ModTime,
 			Size:         part.Size,
 		})
-		count--
-		if count == 0 {
-			break
-		}
 	}
-	// If listed entries are more than maxParts, we set IsTruncated as true.
-	if len(parts) > len(result.Parts) {
+	// Make sure to fill next part number marker if IsTruncated is
+	// true for subsequent listing.
+	if truncated {
 		result.IsTruncated = true
-		// Make sure to fill next part number marker if IsTruncated is
-		// true for subsequent listing.
-		nextPartNumberMarker := result.Parts[len(result.Parts)-1].PartNumber
-		result.NextPartNumberMarker = nextPartNumberMarker
+		result.NextPartNumberMarker = partNumberMarker
+		if len(parts) > 0 {
+			result.NextPartNumberMarker = parts[len(parts)-1].Number
+		}
 	}
 	return result, nil
 }
@@ -526,6 +452,12 @@ func (xl xlObjects) ListObjectParts(bucket, object, uploadID string, partNumberM
 //
 // Implements S3 compatible Complete multipart API.
 func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, uploadID string, parts []completePart) (string, error) {
+	return xl.completeMultipartUpload(bucket, object, uploadID, parts, false)
+}
+
+// completeMultipartUpload - completes an upload, objectLocked is set by
+// callers already holding the write lock of the object.
+func (xl xlObjects) completeMultipartUpload(bucket string, object string, uploadID string, parts []completePart, objectLocked bool) (string, error) {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
@@ -612,6 +544,9 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 			Name:   fmt.Sprintf("object%d", part.PartNumber),
 		}
 	}
+	if isMaxObjectSize(objectSize) {
+		return "", ObjectTooLarge{Bucket: bucket, Object: object}
+	}
 
 	// Check if an object is present as one of the parent dir.
 	if xl.parentDirIsObject(bucket, path.Dir(object)) {
@@ -644,8 +579,10 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 		return "", toObjectErr(rErr, minioMetaBucket, uploadIDPath)
 	}
 	// Hold write lock on the destination before rename.
-	nsMutex.Lock(bucket, object)
-	defer nsMutex.Unlock(bucket, object)
+	if !objectLocked {
+		nsMutex.Lock(bucket, object)
+		defer nsMutex.Unlock(bucket, object)
+	}
 
 	// Rename if an object already exists to temporary location.
 	uniqueID := getUUID()
@@ -677,6 +614,14 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 	// Delete the previously successfully renamed object.
 	xl.deleteObject(minioMetaBucket, path.Join(tmpMetaPrefix, uniqueID))
 
+	// Disks missing the upload miss the object as well.
+	for _, mErr := range errs {
+		if mErr != nil {
+			queueHeal(bucket, object)
+			break
+		}
+	}
+
 	// Hold the lock so that two parallel complete-multipart-uploads do not
 	// leave a stale uploads.json behind.
 	nsMutex.Lock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
@@ -17,8 +17,10 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"path"
 	"path/filepath"
@@ -38,11 +40,16 @@ import (
 // startOffset indicates the location at which the client requested
 // object to be read at. length indicates the total length of the
 // object requested by client.
-func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+func (xl xlObjects) GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) error {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return BucketNameInvalid{Bucket: bucket}
 	}
+	// Directory markers have no content.
+	if isDirMarker(object) {
+		_, err := xl.getDirMarkerInfo(bucket, object)
+		return err
+	}
 	// Verify if object is valid.
 	if !IsValidObjectName(object) {
 		return ObjectNameInvalid{Bucket: bucket, Object: object}
@@ -51,7 +58,12 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 	// Lock the object before reading.
 	nsMutex.RLock(bucket, object)
 	defer nsMutex.RUnlock(bucket, object)
+	return xl.getObject(ctx, bucket, object, startOffset, length, writer)
+}
 
+// getObject - reads an object whose namespace lock is held by the
+// caller.
+func (xl xlObjects) getObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer) error {
 	// Read metadata associated with the object from all disks.
 	metaArr, errs := xl.readAllXLMetadata(bucket, object)
 
@@ -88,8 +100,18 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 		eInfos = append(eInfos, metaArr[index].Erasure)
 	}
 
+	// Serve the part of objects stored inline from their `xl.json`.
+	if xlMeta.Data != nil {
+		inlineData := make([][]byte, len(onlineDisks))
+		for index := range onlineDisks {
+			inlineData[index] = metaArr[index].Data
+		}
+		onlineDisks = newInlineDisks(onlineDisks, bucket, pathJoin(object, xlMeta.Parts[0].Name), inlineData)
+	}
+
 	totalBytesRead := int64(0)
-	// Read from all parts.
+	// Collect readers of all the parts to be read.
+	var readFns []func(io.Writer) (int64, error)
 	for ; partIndex <= lastPartIndex; partIndex++ {
 		if length == totalBytesRead {
 			break
@@ -104,19 +126,29 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 			readSize = length - totalBytesRead
 		}
 
-		// Start reading the part name.
-		n, err := erasureReadFile(writer, onlineDisks, bucket, pathJoin(object, partName), partName, eInfos, partOffset, readSize, partSize)
-		if err != nil {
-			return err
-		}
+		offset := partOffset
+		readFns = append(readFns, func(w io.Writer) (int64, error) {
+			return erasureReadFile(ctx, w, onlineDisks, bucket, pathJoin(object, partName), partName, eInfos, offset, readSize, partSize)
+		})
 
-		totalBytesRead += n
+		totalBytesRead += readSize
 
 		// partOffset will be valid only for the first part, hence reset it to 0 for
 		// the remaining parts.
 		partOffset = 0
 	} // End of read all parts loop.
 
+	// A single part is read directly, otherwise the following parts
+	// are read ahead while the current one is sent.
+	if len(readFns) == 1 {
+		_, err = readFns[0](writer)
+	} else {
+		_, err = readPartsAhead(writer, readFns)
+	}
+	if err != nil {
+		return err
+	}
+
 	// Return success.
 	return nil
 }
@@ -127,6 +159,9 @@ func (xl xlObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
 	if !IsValidBucketName(bucket) {
 		return ObjectInfo{}, BucketNameInvalid{Bucket: bucket}
 	}
+	if isDirMarker(object) {
+		return xl.getDirMarkerInfo(bucket, object)
+	}
 	// Verify if object is valid.
 	if !IsValidObjectName(object) {
 		return ObjectInfo{}, ObjectNameInvalid{Bucket: bucket, Object: object}
@@ -157,6 +192,7 @@ func (xl xlObjects) getObjectInfo(bucket, object string) (objInfo ObjectInfo, er
 		MD5Sum:          xlMeta.Meta["md5Sum"],
 		ContentType:     xlMeta.Meta["content-type"],
 		ContentEncoding: xlMeta.Meta["content-encoding"],
+		Metadata:        xlMeta.Meta,
 	}
 	return objInfo, nil
 }
@@ -203,6 +239,9 @@ func (xl xlObjects) undoRenamePart(srcBucket, srcPart, dstBucket, dstPart string
 // rename - common function that renamePart and renameObject use to rename
 // the respective underlying storage layer representations.
 func (xl xlObjects) rename(srcBucket, srcEntry, dstBucket, dstEntry string, isPart bool) error {
+	defer xl.metaCache.Invalidate(srcBucket, srcEntry)
+	defer xl.metaCache.Invalidate(dstBucket, dstEntry)
+
 	// Initialize sync waitgroup.
 	var wg = &sync.WaitGroup{}
 
@@ -238,6 +277,7 @@ func (xl xlObjects) rename(srcBucket, srcEntry, dstBucket, dstEntry string, isPa
 	if !isQuorum(errs, xl.writeQuorum) {
 		// Check we have successful read quorum.
 		if isQuorum(errs, xl.readQuorum) {
+			xl.queueHealRenamed(dstBucket, dstEntry, isPart)
 			return nil // Return success.
 		} // else - failed to acquire read quorum.
 		// Undo all the partial rename operations.
@@ -252,9 +292,27 @@ func (xl xlObjects) rename(srcBucket, srcEntry, dstBucket, dstEntry string, isPa
 			return err
 		}
 	}
+	// Disks which were offline miss the object.
+	if !isQuorum(errs, len(xl.storageDisks)) {
+		xl.queueHealRenamed(dstBucket, dstEntry, isPart)
+	}
 	return nil
 }
 
+// queueHealRenamed - queues the object renamed to dstEntry for healing,
+// renames into minioMetaBucket do not commit objects.
+func (xl xlObjects) queueHealRenamed(dstBucket, dstEntry string, isPart bool) {
+	if dstBucket == minioMetaBucket {
+		return
+	}
+	object := strings.TrimSuffix(dstEntry, slashSeparator)
+	// Parts and `xl.json` are renamed into the object directory.
+	if isPart {
+		object = path.Dir(object)
+	}
+	queueHeal(dstBucket, object)
+}
+
 // renamePart - renames a part of the source object to the destination
 // across all disks in parallel. Additionally if we have errors and do
 // not have a readQuorum partially renamed files are renamed back to
@@ -277,7 +335,19 @@ func (xl xlObjects) renameObject(srcBucket, srcObject, dstBucket, dstObject stri
 // until EOF, erasure codes the data across all disk and additionally
 // writes `xl.json` which carries the necessary metadata for future
 // object operations.
-func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+func (xl xlObjects) PutObject(ctx context.Context, bucket string, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	// Directory markers hold their own lock.
+	if isDirMarker(object) {
+		return xl.putObject(ctx, bucket, object, size, data, metadata)
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	return xl.putObject(ctx, bucket, object, size, data, metadata)
+}
+
+// putObject - PutObject of callers holding the write lock of the
+// object.
+func (xl xlObjects) putObject(ctx context.Context, bucket string, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
@@ -286,6 +356,9 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	if !xl.isBucketExist(bucket) {
 		return "", BucketNotFound{Bucket: bucket}
 	}
+	if isDirMarker(object) {
+		return xl.putDirMarker(bucket, object, size)
+	}
 	if !IsValidObjectName(object) {
 		return "", ObjectNameInvalid{
 			Bucket: bucket,
@@ -296,8 +369,6 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	if metadata == nil {
 		metadata = make(map[string]string)
 	}
-	nsMutex.Lock(bucket, object)
-	defer nsMutex.Unlock(bucket, object)
 
 	uniqueID := getUUID()
 	tempErasureObj := path.Join(tmpMetaPrefix, uniqueID, "object1")
@@ -333,16 +404,28 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		eInfos = append(eInfos, xlMeta.Erasure)
 	}
 
+	// Small objects are erasure coded in memory and kept inline in `xl.json`.
+	writeDisks := onlineDisks
+	inline := size >= 0 && size <= xlInlineDataThreshold
+	if inline {
+		writeDisks = newInlineDisks(onlineDisks, minioMetaBucket, tempErasureObj, make([][]byte, len(onlineDisks)))
+	}
+
 	// Erasure code and write across all disks.
-	newEInfos, n, err := erasureCreateFile(onlineDisks, minioMetaBucket, tempErasureObj, "object1", teeReader, eInfos, xl.writeQuorum)
+	newEInfos, n, err := erasureCreateFile(ctx, writeDisks, minioMetaBucket, tempErasureObj, "object1", teeReader, eInfos, xl.writeQuorum)
 	if err != nil {
+		// Data could not be read or written, delete the temporary object.
+		xl.deleteObject(minioMetaBucket, tempObj)
 		return "", toObjectErr(err, minioMetaBucket, tempErasureObj)
 	}
 	if size == -1 {
 		size = n
 	}
 	// Save additional erasureMetadata.
-	modTime := time.Now().UTC()
+	modTime, ok := getObjectModTime(metadata)
+	if !ok {
+		modTime = time.Now().UTC()
+	}
 
 	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
 	// Update the md5sum if not set with the newly calculated one.
@@ -394,9 +477,16 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	xlMeta.AddObjectPart(1, "object1", newMD5Hex, xlMeta.Stat.Size)
 
 	// Update `xl.json` content on each disks.
+	var inlineData [][]byte
+	if inline {
+		inlineData = getInlineData(writeDisks)
+	}
 	for index := range partsMetadata {
 		partsMetadata[index] = xlMeta
 		partsMetadata[index].Erasure = newEInfos[index]
+		if inline {
+			partsMetadata[index].Data = inlineData[index]
+		}
 	}
 
 	// Write unique `xl.json` for each disk.
@@ -413,14 +503,265 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	// Delete the temporary object.
 	xl.deleteObject(minioMetaBucket, path.Join(tmpMetaPrefix, newUniqueID))
 
+	// Disks which were offline, outdated or failed have to be healed.
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		queueHeal(bucket, object)
+	}
+
 	// Return md5sum, successfully wrote object.
 	return newMD5Hex, nil
 }
 
+// AppendObject - appends data to an existing object by erasure coding
+// it into a new part of the object. Objects stored inline in `xl.json`
+// are moved to their part file first.
+func (xl xlObjects) AppendObject(ctx context.Context, bucket, object string, size int64, data io.Reader, md5Hex string) (string, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify bucket exists.
+	if !xl.isBucketExist(bucket) {
+		return "", BucketNotFound{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	// `xl.json` is replaced by a rename, not seen by the cache.
+	defer xl.metaCache.Invalidate(bucket, object)
+
+	if !xl.isObject(bucket, object) {
+		return "", ObjectNotFound{Bucket: bucket, Object: object}
+	}
+
+	// Read metadata associated with the object from all disks.
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
+
+	// List all online disks.
+	onlineDisks, higherVersion, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Increment version only if we have online disks less than configured storage disks.
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		higherVersion++
+	}
+
+	// Pick one from the first valid metadata.
+	xlMeta := pickValidXLMeta(partsMetadata)
+
+	partID := xlMeta.Parts[len(xlMeta.Parts)-1].Number + 1
+	if isMaxPartID(partID) {
+		return "", ObjectTooManyAppends{Bucket: bucket, Object: object}
+	}
+	partSuffix := fmt.Sprintf("object%d", partID)
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	tmpPartPath := path.Join(tempObj, partSuffix)
+
+	// Initialize md5 writer.
+	md5Writer := md5.New()
+
+	// Construct a tee reader for md5sum.
+	teeReader := io.TeeReader(data, md5Writer)
+
+	// Collect all the previous erasure infos across the disk.
+	var eInfos []erasureInfo
+	for index := range onlineDisks {
+		eInfos = append(eInfos, partsMetadata[index].Erasure)
+	}
+
+	// Erasure code data and write across all disks.
+	newEInfos, n, err := erasureCreateFile(ctx, onlineDisks, minioMetaBucket, tmpPartPath, partSuffix, teeReader, eInfos, xl.writeQuorum)
+	if err != nil {
+		// Data could not be read or written, delete the temporary part.
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, minioMetaBucket, tmpPartPath)
+	}
+	if size == -1 {
+		size = n
+	}
+	// Calculate new md5sum.
+	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
+	if md5Hex != "" {
+		if newMD5Hex != md5Hex {
+			// MD5 mismatch, delete the temporary part.
+			xl.deleteObject(minioMetaBucket, tempObj)
+			// Returns md5 mismatch.
+			return "", BadDigest{md5Hex, newMD5Hex}
+		}
+	}
+	objectMD5Hex, err := getAppendedMD5(xlMeta.Meta["md5Sum"], newMD5Hex)
+	if err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	partNames := []string{partSuffix}
+	if xlMeta.Data != nil {
+		// Inline data of each disk is exactly the content of its part file.
+		inlinePart := xlMeta.Parts[0].Name
+		wErrs := make([]error, len(onlineDisks))
+		for index, disk := range onlineDisks {
+			if disk == nil {
+				wErrs[index] = errDiskNotFound
+				continue
+			}
+			wErrs[index] = disk.AppendFile(context.Background(), minioMetaBucket, path.Join(tempObj, inlinePart), partsMetadata[index].Data)
+		}
+		if !isQuorum(wErrs, xl.writeQuorum) {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return "", toObjectErr(errXLWriteQuorum, bucket, object)
+		}
+		partNames = append([]string{inlinePart}, partNames...)
+	}
+
+	// Rename temporary part files to their final location.
+	for _, partName := range partNames {
+		err = xl.renamePart(minioMetaBucket, path.Join(tempObj, partName), bucket, path.Join(object, partName))
+		if err != nil {
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+
+	// Once parts are successfully committed, proceed with updating XL metadata.
+	xlMeta.Stat.Size += size
+	xlMeta.Stat.ModTime = time.Now().UTC()
+	xlMeta.Stat.Version = higherVersion
+	xlMeta.Meta["md5Sum"] = objectMD5Hex
+	xlMeta.Data = nil
+
+	// Add the current part.
+	xlMeta.AddObjectPart(partID, partSuffix, newMD5Hex, size)
+
+	// Update `xl.json` content for each disks.
+	for index := range partsMetadata {
+		partsMetadata[index] = xlMeta
+		partsMetadata[index].Erasure = newEInfos[index]
+	}
+
+	// Writes a unique `xl.json` each disk carrying new checksum
+	// related information.
+	if err = xl.writeUniqueXLMetadata(minioMetaBucket, tempObj, partsMetadata); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+	err = xl.renamePart(minioMetaBucket, path.Join(tempObj, xlMetaJSONFile), bucket, path.Join(object, xlMetaJSONFile))
+	if err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Delete the temporary object.
+	xl.deleteObject(minioMetaBucket, tempObj)
+
+	// Disks which were offline, outdated or failed have to be healed.
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		queueHeal(bucket, object)
+	}
+
+	// Return md5sum of the whole object.
+	return objectMD5Hex, nil
+}
+
+// UpdateObjectMetadata - saves the metadata of an object changed by
+// update in `xl.json` on all disks.
+func (xl xlObjects) UpdateObjectMetadata(bucket, object string, update func(metadata map[string]string) error) error {
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+	return xl.updateObjectMetadata(bucket, object, update)
+}
+
+// updateObjectMetadata - saves the metadata of an object whose
+// namespace lock is held by the caller.
+func (xl xlObjects) updateObjectMetadata(bucket, object string, update func(metadata map[string]string) error) error {
+	if !xl.isObject(bucket, object) {
+		return ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	if err := xl.updateXLMetadata(bucket, object, update); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// UpdateUploadMetadata - saves the metadata of a multipart upload
+// changed by update in its `xl.json` on all disks.
+func (xl xlObjects) UpdateUploadMetadata(bucket, object, uploadID string, update func(metadata map[string]string) error) error {
+	uploadIDPath := pathJoin(mpartMetaPrefix, bucket, object, uploadID)
+	nsMutex.Lock(minioMetaBucket, uploadIDPath)
+	defer nsMutex.Unlock(minioMetaBucket, uploadIDPath)
+
+	if !xl.isUploadIDExists(bucket, object, uploadID) {
+		return InvalidUploadID{UploadID: uploadID}
+	}
+	if err := xl.updateXLMetadata(minioMetaBucket, uploadIDPath, update); err != nil {
+		return toObjectErr(err, minioMetaBucket, uploadIDPath)
+	}
+	return nil
+}
+
+// updateXLMetadata - replaces `xl.json` of prefix on the disks holding
+// its latest version with a copy holding the metadata changed by
+// update. The version is increased if other disks are left out, they
+// are healed later.
+func (xl xlObjects) updateXLMetadata(bucket, prefix string, update func(metadata map[string]string) error) error {
+	// `xl.json` is replaced by a rename, not seen by the cache.
+	defer xl.metaCache.Invalidate(bucket, prefix)
+
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, prefix)
+	onlineDisks, higherVersion, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		return err
+	}
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		higherVersion++
+	}
+	// Metadata of a disk left out may be stale, start from an online one.
+	var latest []xlMetaV1
+	for index, disk := range onlineDisks {
+		if disk != nil {
+			latest = append(latest, partsMetadata[index])
+		}
+	}
+	xlMeta := pickValidXLMeta(latest)
+	meta := make(map[string]string, len(xlMeta.Meta))
+	for key, value := range xlMeta.Meta {
+		meta[key] = value
+	}
+	if err = update(meta); err != nil {
+		return err
+	}
+	// Erasure info and inline data differ per disk, only the metadata
+	// and the version are replaced.
+	for index := range partsMetadata {
+		partsMetadata[index].Meta = meta
+		partsMetadata[index].Stat.Version = higherVersion
+	}
+
+	online := xl
+	online.storageDisks = onlineDisks
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	if err = online.writeUniqueXLMetadata(minioMetaBucket, tempObj, partsMetadata); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return err
+	}
+	err = online.renamePart(minioMetaBucket, path.Join(tempObj, xlMetaJSONFile), bucket, path.Join(prefix, xlMetaJSONFile))
+	xl.deleteObject(minioMetaBucket, tempObj)
+	return err
+}
+
 // deleteObject - wrapper for delete object, deletes an object from
 // all the disks in parallel, including `xl.json` associated with the
 // object.
 func (xl xlObjects) deleteObject(bucket, object string) error {
+	defer xl.metaCache.Invalidate(bucket, object)
+
 	// Initialize sync waitgroup.
 	var wg = &sync.WaitGroup{}
 
@@ -462,6 +803,9 @@ func (xl xlObjects) DeleteObject(bucket, object string) (err error) {
 	if !IsValidBucketName(bucket) {
 		return BucketNameInvalid{Bucket: bucket}
 	}
+	if isDirMarker(object) {
+		return xl.deleteDirMarker(bucket, object)
+	}
 	if !IsValidObjectName(object) {
 		return ObjectNameInvalid{Bucket: bucket, Object: object}
 	}
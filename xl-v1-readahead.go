@@ -0,0 +1,119 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// Number of parts read ahead of the part being sent to the client.
+const readAheadParts = 1
+
+// Number of decoded data blocks buffered per part read ahead.
+const readAheadBlocks = 16
+
+// errReadAheadAborted - the part is no longer wanted by the reader.
+var errReadAheadAborted = errors.New("Read ahead aborted")
+
+// partReadAhead - decodes a part in the background into a bounded
+// queue of blocks, which are later written out in order.
+type partReadAhead struct {
+	blocks  chan []byte
+	abortCh chan struct{}
+	doneCh  chan struct{}
+	n       int64
+	err     error
+}
+
+// Write - queues a copy of p, blocks while the queue is full.
+func (p *partReadAhead) Write(b []byte) (int, error) {
+	buf := globalBufferPools.Get(len(b))
+	copy(buf, b)
+	select {
+	case p.blocks <- buf:
+		return len(b), nil
+	case <-p.abortCh:
+		globalBufferPools.Put(buf)
+		return 0, errReadAheadAborted
+	}
+}
+
+// newPartReadAhead - starts reading a part with readFn in the background.
+func newPartReadAhead(readFn func(io.Writer) (int64, error)) *partReadAhead {
+	p := &partReadAhead{
+		blocks:  make(chan []byte, readAheadBlocks),
+		abortCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	go func() {
+		defer close(p.doneCh)
+		defer close(p.blocks)
+		p.n, p.err = readFn(p)
+	}()
+	return p
+}
+
+// WriteTo - writes all the blocks of the part to w in order.
+func (p *partReadAhead) WriteTo(w io.Writer) (int64, error) {
+	for buf := range p.blocks {
+		_, err := w.Write(buf)
+		globalBufferPools.Put(buf)
+		if err != nil {
+			p.Abort()
+			return 0, err
+		}
+	}
+	<-p.doneCh
+	return p.n, p.err
+}
+
+// Abort - stops reading the part and waits for the reader to exit.
+func (p *partReadAhead) Abort() {
+	close(p.abortCh)
+	for buf := range p.blocks {
+		globalBufferPools.Put(buf)
+	}
+	<-p.doneCh
+}
+
+// readPartsAhead - writes parts to w in order while reading up to
+// readAheadParts following parts in the background, so that disk reads
+// overlap with sending data to the client.
+func readPartsAhead(w io.Writer, readFns []func(io.Writer) (int64, error)) (n int64, err error) {
+	var pending []*partReadAhead
+	// Abort whatever is still being read ahead on return.
+	defer func() {
+		for _, p := range pending {
+			p.Abort()
+		}
+	}()
+	for len(readFns) > 0 || len(pending) > 0 {
+		for len(readFns) > 0 && len(pending) <= readAheadParts {
+			pending = append(pending, newPartReadAhead(readFns[0]))
+			readFns = readFns[1:]
+		}
+		var p *partReadAhead
+		p, pending = pending[0], pending[1:]
+		m, err := p.WriteTo(w)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
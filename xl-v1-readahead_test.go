@@ -0,0 +1,97 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Returns a part reader writing size bytes of b in blocks of blockSize.
+func testPartReadFn(b byte, size, blockSize int, err error) func(io.Writer) (int64, error) {
+	return func(w io.Writer) (int64, error) {
+		var n int64
+		for n < int64(size) {
+			m, werr := w.Write(bytes.Repeat([]byte{b}, blockSize))
+			n += int64(m)
+			if werr != nil {
+				return n, werr
+			}
+		}
+		return n, err
+	}
+}
+
+// failingWriter - fails all writes after limit bytes.
+type failingWriter struct {
+	limit int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		return 0, errors.New("write failed")
+	}
+	f.limit -= len(p)
+	return len(p), nil
+}
+
+// Tests reading parts ahead.
+func TestReadPartsAhead(t *testing.T) {
+	var readFns []func(io.Writer) (int64, error)
+	var expected []byte
+	for i := 0; i < 5; i++ {
+		readFns = append(readFns, testPartReadFn(byte('a'+i), 40*(i+1), 4, nil))
+		expected = append(expected, bytes.Repeat([]byte{byte('a' + i)}, 40*(i+1))...)
+	}
+	buf := &bytes.Buffer{}
+	n, err := readPartsAhead(buf, readFns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(expected)) {
+		t.Fatalf("Expected %d bytes, got %d", len(expected), n)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatal("Parts written out of order")
+	}
+
+	// Errors reading a part are returned after the previous parts are written.
+	errPart := errors.New("part read failed")
+	readFns = []func(io.Writer) (int64, error){
+		testPartReadFn('a', 1024, 4, nil),
+		testPartReadFn('b', 4, 4, errPart),
+		testPartReadFn('c', 1024, 4, nil),
+	}
+	buf.Reset()
+	if _, err = readPartsAhead(buf, readFns); err != errPart {
+		t.Fatalf("Expected %v, got %v", errPart, err)
+	}
+	if buf.Len() != 1028 {
+		t.Fatalf("Expected 1028 bytes written, got %d", buf.Len())
+	}
+
+	// Failing to write aborts the parts read ahead.
+	readFns = []func(io.Writer) (int64, error){
+		testPartReadFn('a', 1024*1024, 4, nil),
+		testPartReadFn('b', 1024*1024, 4, nil),
+	}
+	if _, err = readPartsAhead(&failingWriter{limit: 100}, readFns); err == nil {
+		t.Fatal("Expected write to fail")
+	}
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"math/rand"
 	"path"
@@ -70,11 +71,12 @@ func readAll(disk StorageAPI, volume string, path string) ([]byte, error) {
 	startOffset := int64(0)
 
 	// Allocate 10MiB buffer.
-	buf := make([]byte, blockSizeV1)
+	buf := globalBufferPools.Get(blockSizeV1)
+	defer globalBufferPools.Put(buf)
 
 	// Read until io.EOF.
 	for {
-		n, err := disk.ReadFile(volume, path, startOffset, buf)
+		n, err := disk.ReadFile(context.Background(), volume, path, startOffset, buf)
 		if err == io.EOF {
 			break
 		}
@@ -97,7 +99,7 @@ func readXLMeta(disk StorageAPI, bucket string, object string) ([]byte, error) {
 
 	// Read until io.EOF.
 	for {
-		n, err := disk.ReadFile(bucket, path.Join(object, xlMetaJSONFile), startOffset, buf)
+		n, err := disk.ReadFile(context.Background(), bucket, path.Join(object, xlMetaJSONFile), startOffset, buf)
 		if err == io.EOF {
 			break
 		}
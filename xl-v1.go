@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/minio/minio/pkg/disk"
 )
@@ -41,15 +42,19 @@ const (
 
 // xlObjects - Implements XL object layer.
 type xlObjects struct {
-	physicalDisks []string     // Collection of regular disks.
-	storageDisks  []StorageAPI // Collection of initialized backend disks.
-	dataBlocks    int          // dataBlocks count caculated for erasure.
-	parityBlocks  int          // parityBlocks count calculated for erasure.
-	readQuorum    int          // readQuorum minimum required disks to read data.
-	writeQuorum   int          // writeQuorum minimum required disks to write data.
+	physicalDisks  []string     // Collection of regular disks.
+	bootstrapDisks []StorageAPI // Collection of all disks in physicalDisks order.
+	storageDisks   []StorageAPI // Collection of initialized backend disks.
+	dataBlocks     int          // dataBlocks count caculated for erasure.
+	parityBlocks   int          // parityBlocks count calculated for erasure.
+	readQuorum     int          // readQuorum minimum required disks to read data.
+	writeQuorum    int          // writeQuorum minimum required disks to write data.
 
 	// List pool management.
 	listPool *treeWalkPool
+
+	// Cache of recently read `xl.json`, nil if disabled.
+	metaCache *xlMetaCache
 }
 
 // errXLMaxDisks - returned for reached maximum of disks.
@@ -102,6 +107,10 @@ func checkSufficientDisks(disks []string) error {
 	return nil
 }
 
+func init() {
+	registerObjectLayer(objectLayerXL, newXLObjects)
+}
+
 // newXLObjects - initialize new xl object layer.
 func newXLObjects(disks []string) (ObjectLayer, error) {
 	// Validate if input disks are sufficient.
@@ -162,11 +171,12 @@ func newXLObjects(disks []string) (ObjectLayer, error) {
 
 	// Initialize xl objects.
 	xl := xlObjects{
-		physicalDisks: disks,
-		storageDisks:  newPosixDisks,
-		dataBlocks:    dataBlocks,
-		parityBlocks:  parityBlocks,
-		listPool:      newTreeWalkPool(globalLookupTimeout),
+		physicalDisks:  disks,
+		bootstrapDisks: storageDisks,
+		storageDisks:   newPosixDisks,
+		dataBlocks:     dataBlocks,
+		parityBlocks:   parityBlocks,
+		listPool:       newTreeWalkPool(globalLookupTimeout),
 	}
 
 	// Figure out read and write quorum based on number of storage disks.
@@ -181,6 +191,11 @@ func newXLObjects(disks []string) (ObjectLayer, error) {
 		xl.writeQuorum = len(xl.storageDisks)
 	}
 
+	// Metadata is cached only if no other server writes to the disks.
+	if !isRemoteDisks(disks) {
+		xl.metaCache = newXLMetaCache(xlMetaCacheSize)
+	}
+
 	// Return successfully initialized object layer.
 	return xl, nil
 }
@@ -217,3 +232,19 @@ func (xl xlObjects) StorageInfo() StorageInfo {
 		Free:  disksInfo[0].Free * int64(len(xl.storageDisks)),
 	}
 }
+
+// DisksInfo - returns status of all disks, including disks which are
+// offline or not part of the format yet.
+func (xl xlObjects) DisksInfo() []DiskStatus {
+	disksStatus := make([]DiskStatus, len(xl.physicalDisks))
+	var wg = &sync.WaitGroup{}
+	for index, diskPath := range xl.physicalDisks {
+		wg.Add(1)
+		go func(index int, diskPath string, disk StorageAPI) {
+			defer wg.Done()
+			disksStatus[index] = getDiskStatus(diskPath, disk)
+		}(index, diskPath, xl.bootstrapDisks[index])
+	}
+	wg.Wait()
+	return disksStatus
+}